@@ -4,7 +4,14 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/cobra"
@@ -74,13 +81,14 @@ func newAuthCmd() *cobra.Command {
 		newAuthAddCmd(),
 		newAuthListCmd(),
 		newAuthRemoveCmd(),
+		newAuthSetDefaultCmd(),
 	)
 
 	return cmd
 }
 
 func newAuthAddCmd() *cobra.Command {
-	var scopes []string
+	var scopes, presets []string
 
 	cmd := &cobra.Command{
 		Use:   "add <account-name>",
@@ -91,7 +99,18 @@ The name is your own label (e.g. "personal", "work").
 Requires credentials.json from Google Cloud Console at the default
 path (~/.config/google-mcp/credentials.json) or via --credentials.
 
-By default, all scopes (Gmail, Drive, Calendar) are requested. Use --scopes to limit.`,
+By default, all scopes (Gmail, Drive, Calendar) are requested. Use --preset
+to request a curated scope set instead (comma-separated to combine):
+  full               all Gmail, Drive and Calendar scopes (the default)
+  readonly           read-only scopes for all three services
+  gmail, drive, calendar
+                     the scopes of one service
+  gmail-readonly, drive-readonly, calendar-readonly
+                     the read-only scopes of one service
+Use --scopes to request specific scopes, alone or on top of presets.
+
+Accounts authorized with read-only presets can be served with
+--read-only --readonly-scopes.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			mgr, err := newManager()
@@ -102,9 +121,9 @@ By default, all scopes (Gmail, Drive, Calendar) are requested. Use --scopes to l
 			name := args[0]
 
 			// Build scope list.
-			allScopes := mergeScopes(gmail.AccountScopes(), drive.AccountScopes(), calendar.AccountScopes())
-			if len(scopes) > 0 {
-				allScopes = scopes
+			allScopes, err := expandScopes(presets, scopes)
+			if err != nil {
+				return err
 			}
 
 			return mgr.Authenticate(cmd.Context(), name, allScopes)
@@ -112,37 +131,42 @@ By default, all scopes (Gmail, Drive, Calendar) are requested. Use --scopes to l
 	}
 
 	cmd.Flags().StringSliceVar(&scopes, "scopes", nil, "specific OAuth scopes to request (default: all Gmail+Drive+Calendar scopes)")
+	cmd.Flags().StringSliceVar(&presets, "preset", nil, "curated scope sets to request: full, readonly, gmail, gmail-readonly, drive, drive-readonly, calendar, calendar-readonly (comma-separated)")
 
 	return cmd
 }
 
 func newAuthListCmd() *cobra.Command {
-	return &cobra.Command{
+	var check bool
+
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List configured accounts",
+		Long:  "List configured accounts with granted scopes, token expiry, and last refresh time.\nUse --check to validate each token with Google.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			mgr, err := newManager()
 			if err != nil {
 				return err
 			}
 
-			accounts := mgr.ListAccounts()
-			if len(accounts) == 0 {
+			var statuses []auth.AccountStatus
+			if check {
+				statuses = mgr.CheckAccountStatuses(cmd.Context())
+			} else {
+				statuses = mgr.AccountStatuses()
+			}
+			if len(statuses) == 0 {
 				fmt.Println("No accounts configured.")
 				return nil
 			}
 
-			fmt.Println("Configured accounts:")
-			for name, email := range accounts {
-				if email != "" {
-					fmt.Printf("  - %s (%s)\n", name, email)
-				} else {
-					fmt.Printf("  - %s\n", name)
-				}
-			}
+			auth.WriteAccountStatus(os.Stdout, statuses, time.Now())
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Validate each account's token with Google")
+	return cmd
 }
 
 func newAuthRemoveCmd() *cobra.Command {
@@ -164,23 +188,77 @@ func newAuthRemoveCmd() *cobra.Command {
 	}
 }
 
+func newAuthSetDefaultCmd() *cobra.Command {
+	var clearDefault bool
+
+	cmd := &cobra.Command{
+		Use:   "set-default [account-name]",
+		Short: "Set the account used when a tool call omits the account",
+		Long: `Set the default account. Tools use it when the account argument is omitted.
+With a single configured account, that account is always the default.
+Use --clear to remove the default.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if clearDefault {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr, err := newManager()
+			if err != nil {
+				return err
+			}
+			if clearDefault {
+				if err := mgr.SetDefault(""); err != nil {
+					return err
+				}
+				fmt.Println("Default account cleared.")
+				return nil
+			}
+			if err := mgr.SetDefault(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Default account set to %q.\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&clearDefault, "clear", false, "Clear the default account")
+	return cmd
+}
+
 // --- MCP server commands ---
 
 // toolFilterFlags holds the CLI flags for tool filtering.
 type toolFilterFlags struct {
-	readOnly bool
-	enable   []string
-	disable  []string
+	readOnly       bool
+	readonlyScopes bool
+	enable         []string
+	disable        []string
 }
 
-// addToolFilterFlags adds --read-only, --enable, and --disable flags to a command.
+// addToolFilterFlags adds --read-only, --readonly-scopes, --enable, and
+// --disable flags to a command.
 func addToolFilterFlags(cmd *cobra.Command, f *toolFilterFlags) {
 	cmd.Flags().BoolVar(&f.readOnly, "read-only", false, "only expose read-only tools (no mutations)")
+	cmd.Flags().BoolVar(&f.readonlyScopes, "readonly-scopes", false, "with --read-only, use read-only OAuth scopes and refuse accounts granted broader ones")
 	cmd.Flags().StringSliceVar(&f.enable, "enable", nil, "whitelist of tool names to expose (comma-separated)")
 	cmd.Flags().StringSliceVar(&f.disable, "disable", nil, "blacklist of tool names to hide (comma-separated)")
 	cmd.MarkFlagsMutuallyExclusive("enable", "disable")
 }
 
+// applyScopes restricts mgr to read-only scopes when --readonly-scopes is set.
+func (f *toolFilterFlags) applyScopes(mgr *auth.Manager) error {
+	if !f.readonlyScopes {
+		return nil
+	}
+	if !f.readOnly {
+		return fmt.Errorf("--readonly-scopes requires --read-only")
+	}
+	mgr.RequireReadOnlyScopes()
+	return nil
+}
+
 // toToolFilter converts the CLI flags to an server.ToolFilter.
 func (f *toolFilterFlags) toToolFilter() server.ToolFilter {
 	return server.ToolFilter{
@@ -190,37 +268,210 @@ func (f *toolFilterFlags) toToolFilter() server.ToolFilter {
 	}
 }
 
+// outputFlags holds the CLI flags that bound tool calls and shape their
+// results.
+type outputFlags struct {
+	maxBlockSize   int
+	maxOutputBytes int
+	toolTimeout    time.Duration
+}
+
+// addOutputFlags adds --max-block-size, --max-output-bytes and
+// --tool-timeout to a command.
+func addOutputFlags(cmd *cobra.Command, f *outputFlags) {
+	cmd.Flags().IntVar(&f.maxBlockSize, "max-block-size", 0, "split text results larger than this many bytes into multiple content blocks (0 disables)")
+	cmd.Flags().IntVar(&f.maxOutputBytes, "max-output-bytes", server.DefaultMaxOutputBytes, "truncate list results (messages, threads, events, files) after this many bytes, at an item boundary (0 disables)")
+	cmd.Flags().DurationVar(&f.toolTimeout, "tool-timeout", server.DefaultToolTimeout, "fail a tool call that runs longer than this, e.g. on a hung API request (0 disables)")
+}
+
+// apply configures the server with the output flags.
+func (f *outputFlags) apply(srv *server.Server) {
+	srv.SetMaxBlockSize(f.maxBlockSize)
+	srv.SetMaxOutputBytes(f.maxOutputBytes)
+	srv.SetToolTimeout(f.toolTimeout)
+}
+
+// auditFlags holds the CLI flags for the mutation audit log.
+type auditFlags struct {
+	auditLog bool
+}
+
+// addAuditFlags adds --audit-log to a command.
+func addAuditFlags(cmd *cobra.Command, f *auditFlags) {
+	cmd.Flags().BoolVar(&f.auditLog, "audit-log", false, "append every change made through the tools to "+server.AuditLogFile+" in the config directory, and list earlier sessions' changes in list_recent_mutations")
+}
+
+// apply enables the audit log on the server when --audit-log is set.
+func (f *auditFlags) apply(srv *server.Server, mgr *auth.Manager) error {
+	if !f.auditLog {
+		return nil
+	}
+	if err := os.MkdirAll(mgr.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	return srv.SetAuditLog(filepath.Join(mgr.ConfigDir(), server.AuditLogFile))
+}
+
+// defaultAccountEnv is the environment variable --default-account
+// defaults to.
+const defaultAccountEnv = "GOOGLE_MCP_DEFAULT_ACCOUNT"
+
+// accountFlags holds the CLI flags that choose the account of tool calls.
+type accountFlags struct {
+	defaultAccount string
+}
+
+// addAccountFlags adds --default-account to a command.
+func addAccountFlags(cmd *cobra.Command, f *accountFlags) {
+	cmd.Flags().StringVar(&f.defaultAccount, "default-account", os.Getenv(defaultAccountEnv), "account used by tool calls that omit account, unless the client names one at connect time (default $"+defaultAccountEnv+")")
+}
+
+// apply pins the server's sessions to --default-account, checking that
+// the account exists.
+func (f *accountFlags) apply(srv *server.Server, mgr *auth.Manager) error {
+	if f.defaultAccount == "" {
+		return nil
+	}
+	accounts, err := mgr.ResolveAccounts(f.defaultAccount)
+	if err != nil {
+		return fmt.Errorf("--default-account: %w", err)
+	}
+	if len(accounts) != 1 {
+		return fmt.Errorf("--default-account must name a single account")
+	}
+	srv.SetDefaultAccount(accounts[0])
+	return nil
+}
+
 // localFSFlags holds the CLI flags for local filesystem access.
 type localFSFlags struct {
 	readDirs  []string
 	writeDirs []string
+	dirsFile  string
 }
 
-// addLocalFSFlags adds --allow-read-dir and --allow-write-dir flags to a command.
+// addLocalFSFlags adds --allow-read-dir, --allow-write-dir and
+// --local-dirs-file flags to a command.
 func addLocalFSFlags(cmd *cobra.Command, f *localFSFlags) {
-	cmd.Flags().StringSliceVar(&f.readDirs, "allow-read-dir", nil, "local directories to allow reading from (repeatable, comma-separated)")
-	cmd.Flags().StringSliceVar(&f.writeDirs, "allow-write-dir", nil, "local directories to allow reading and writing (repeatable, comma-separated)")
+	cmd.Flags().StringSliceVar(&f.readDirs, "allow-read-dir", nil, "local directories to allow reading from, as path or label=path (repeatable, comma-separated)")
+	cmd.Flags().StringSliceVar(&f.writeDirs, "allow-write-dir", nil, "local directories to allow reading and writing, as path or label=path (repeatable, comma-separated)")
+	cmd.Flags().StringVar(&f.dirsFile, "local-dirs-file", "", "file listing more allowed directories, one 'read [label=]path' or 'write [label=]path' per line; re-read on SIGHUP")
 }
 
-// toLocalFS creates a localfs.FS from the CLI flags.
-// Returns nil if no directories are configured (local file access disabled).
-func (f *localFSFlags) toLocalFS() (*localfs.FS, error) {
-	if len(f.readDirs) == 0 && len(f.writeDirs) == 0 {
-		return nil, nil
+// parseDirFlag parses an allowed directory given as path or label=path. A
+// value is only split when the part before '=' has no path separator, so
+// paths containing '=' can still be given with a directory prefix (./a=b).
+func parseDirFlag(value string, mode localfs.Mode) localfs.Dir {
+	if label, path, ok := strings.Cut(value, "="); ok && label != "" && !strings.ContainsAny(label, `/\`) {
+		return localfs.Dir{Path: path, Mode: mode, Label: label}
 	}
+	return localfs.Dir{Path: value, Mode: mode}
+}
+
+// parseDirsFile parses a --local-dirs-file: one directory per line, as
+// "read [label=]path" or "write [label=]path". Blank lines and lines
+// starting with '#' are ignored.
+func parseDirsFile(data string) ([]localfs.Dir, error) {
+	var dirs []localfs.Dir
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kind, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("line %d: missing directory", n+1)
+		}
+		switch kind {
+		case "read":
+			dirs = append(dirs, parseDirFlag(value, localfs.ModeRead))
+		case "write":
+			dirs = append(dirs, parseDirFlag(value, localfs.ModeReadWrite))
+		default:
+			return nil, fmt.Errorf("line %d: unknown mode %q (use read or write)", n+1, kind)
+		}
+	}
+	return dirs, nil
+}
+
+// dirs returns the allowed directories of the flags, followed by those of
+// the --local-dirs-file, if set.
+func (f *localFSFlags) dirs() ([]localfs.Dir, error) {
 	var dirs []localfs.Dir
 	for _, d := range f.readDirs {
-		dirs = append(dirs, localfs.Dir{Path: d, Mode: localfs.ModeRead})
+		dirs = append(dirs, parseDirFlag(d, localfs.ModeRead))
 	}
 	for _, d := range f.writeDirs {
-		dirs = append(dirs, localfs.Dir{Path: d, Mode: localfs.ModeReadWrite})
+		dirs = append(dirs, parseDirFlag(d, localfs.ModeReadWrite))
+	}
+	if f.dirsFile != "" {
+		data, err := os.ReadFile(f.dirsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading local dirs file: %w", err)
+		}
+		more, err := parseDirsFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.dirsFile, err)
+		}
+		dirs = append(dirs, more...)
+	}
+	return dirs, nil
+}
+
+// toLocalFS creates a localfs.FS from the CLI flags.
+// Returns nil if no directories are configured (local file access disabled).
+// With --local-dirs-file the FS is created even if the file lists no
+// directories yet, so that they can be added by a reload.
+func (f *localFSFlags) toLocalFS() (*localfs.FS, error) {
+	dirs, err := f.dirs()
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 && f.dirsFile == "" {
+		return nil, nil
 	}
 	return localfs.New(dirs)
 }
 
+// reloadOnSignal reloads the allowed directories of srv from the flags and
+// the --local-dirs-file each time the process receives SIGHUP, until ctx is
+// done. It does nothing without --local-dirs-file, so that SIGHUP keeps its
+// default behavior otherwise. Reload errors are reported on stderr and
+// leave the previous directories in place.
+func (f *localFSFlags) reloadOnSignal(ctx context.Context, srv *server.Server) {
+	if f.dirsFile == "" || srv.LocalFS() == nil {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+		}
+		dirs, err := f.dirs()
+		if err == nil {
+			err = srv.ReloadLocalFS(dirs)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "google-mcp: reloading local directories: %v\n", err)
+		}
+	}
+}
+
 func newGmailCmd() *cobra.Command {
 	var flags toolFilterFlags
 	var fsFlags localFSFlags
+	var outFlags outputFlags
+	var audit auditFlags
+	var acctFlags accountFlags
+	var alwaysBcc, extraHeaders, urlPrefixes []string
+	var maxSendsPerHour int
+	var duplicateSendWindow time.Duration
+	var crossAccountHints bool
 	cmd := &cobra.Command{
 		Use:   "gmail",
 		Short: "Start the Gmail MCP server (stdio)",
@@ -231,17 +482,46 @@ func newGmailCmd() *cobra.Command {
 
 Use --read-only to expose only read-only tools.
 Use --enable or --disable for granular tool control.
-Use --allow-read-dir to enable local file attachments (opt-in, secure).`,
+Use --allow-read-dir to enable local file attachments (opt-in, secure).
+Use --allow-attachment-url-prefix to allow attaching files downloaded from
+URLs under the given prefixes.
+Use --always-bcc and --extra-header to add a Bcc or headers to every
+message and draft the tools compose.
+Use --max-sends-per-hour and --duplicate-send-window to stop runaway sends.
+Use --cross-account-hints to be told which account has a message ID that
+isn't found in the one asked.
+Use --audit-log to keep a log of the changes made through the tools.
+Use --default-account to pin calls that omit account to one account.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := gmail.NewComposePolicy(alwaysBcc, extraHeaders)
+			if err != nil {
+				return err
+			}
+			urlPolicy, err := gmail.NewURLAttachmentPolicy(urlPrefixes)
+			if err != nil {
+				return err
+			}
+
 			mgr, err := newManager()
 			if err != nil {
 				return err
 			}
+			if err := flags.applyScopes(mgr); err != nil {
+				return err
+			}
 
 			srv := server.NewServer(&mcp.Implementation{
 				Name:    "google-mcp-gmail",
 				Version: version,
 			}, nil)
+			outFlags.apply(srv)
+			srv.SetCrossAccountHints(crossAccountHints)
+			if err := audit.apply(srv, mgr); err != nil {
+				return err
+			}
+			if err := acctFlags.apply(srv, mgr); err != nil {
+				return err
+			}
 
 			lfs, err := fsFlags.toLocalFS()
 			if err != nil {
@@ -252,23 +532,49 @@ Use --allow-read-dir to enable local file attachments (opt-in, secure).`,
 				srv.SetLocalFS(lfs)
 			}
 
-			gmail.RegisterTools(srv, mgr)
+			gmail.RegisterTools(srv, mgr,
+				gmail.WithComposePolicy(policy),
+				gmail.WithSendGuard(maxSendsPerHour, duplicateSendWindow),
+				gmail.WithURLAttachmentPolicy(urlPolicy))
 
 			if err := srv.ApplyFilter(flags.toToolFilter()); err != nil {
 				return err
 			}
 
-			return srv.Run(context.Background(), &mcp.StdioTransport{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go fsFlags.reloadOnSignal(ctx, srv)
+			// Waking snoozed messages and renewing watches change the
+			// account, which a read-only server must not do.
+			if !flags.readOnly {
+				go gmail.RunSnoozeScheduler(ctx, mgr)
+				go gmail.RunWatchRenewer(ctx, mgr)
+			}
+
+			return srv.Run(ctx, &mcp.StdioTransport{})
 		},
 	}
 	addToolFilterFlags(cmd, &flags)
 	addLocalFSFlags(cmd, &fsFlags)
+	addOutputFlags(cmd, &outFlags)
+	addAuditFlags(cmd, &audit)
+	addAccountFlags(cmd, &acctFlags)
+	cmd.Flags().StringSliceVar(&alwaysBcc, "always-bcc", nil, "address to Bcc on every composed message and draft (repeatable, comma-separated)")
+	cmd.Flags().StringArrayVar(&extraHeaders, "extra-header", nil, "header to set on every composed message and draft, as name=value (repeatable)")
+	cmd.Flags().StringSliceVar(&urlPrefixes, "allow-attachment-url-prefix", nil, "URL prefix (e.g. https://ci.example.com/artifacts/) that url_attachments may be downloaded from (repeatable, comma-separated)")
+	cmd.Flags().IntVar(&maxSendsPerHour, "max-sends-per-hour", 0, "refuse sends beyond this many per hour, across all accounts (0 disables)")
+	cmd.Flags().DurationVar(&duplicateSendWindow, "duplicate-send-window", gmail.DefaultDuplicateSendWindow, "refuse a send identical to one made within this long, unless the call sets force (0 disables)")
+	cmd.Flags().BoolVar(&crossAccountHints, "cross-account-hints", false, "when a message ID isn't found, check the other accounts for it and name the one that has it (extra API calls per miss)")
 	return cmd
 }
 
 func newDriveCmd() *cobra.Command {
 	var flags toolFilterFlags
 	var fsFlags localFSFlags
+	var outFlags outputFlags
+	var audit auditFlags
+	var acctFlags accountFlags
+	var crossAccountHints bool
 	cmd := &cobra.Command{
 		Use:   "drive",
 		Short: "Start the Google Drive MCP server (stdio)",
@@ -278,17 +584,32 @@ func newDriveCmd() *cobra.Command {
 
 Use --read-only to expose only read-only tools.
 Use --enable or --disable for granular tool control.
-Use --allow-read-dir to enable uploading local files (opt-in, secure).`,
+Use --allow-read-dir to enable uploading local files (opt-in, secure).
+Use --cross-account-hints to be told which account has a file ID that
+isn't found in the one asked.
+Use --audit-log to keep a log of the changes made through the tools.
+Use --default-account to pin calls that omit account to one account.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			mgr, err := newManager()
 			if err != nil {
 				return err
 			}
+			if err := flags.applyScopes(mgr); err != nil {
+				return err
+			}
 
 			srv := server.NewServer(&mcp.Implementation{
 				Name:    "google-mcp-drive",
 				Version: version,
 			}, nil)
+			outFlags.apply(srv)
+			srv.SetCrossAccountHints(crossAccountHints)
+			if err := audit.apply(srv, mgr); err != nil {
+				return err
+			}
+			if err := acctFlags.apply(srv, mgr); err != nil {
+				return err
+			}
 
 			lfs, err := fsFlags.toLocalFS()
 			if err != nil {
@@ -305,17 +626,28 @@ Use --allow-read-dir to enable uploading local files (opt-in, secure).`,
 				return err
 			}
 
-			return srv.Run(context.Background(), &mcp.StdioTransport{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go fsFlags.reloadOnSignal(ctx, srv)
+
+			return srv.Run(ctx, &mcp.StdioTransport{})
 		},
 	}
 	addToolFilterFlags(cmd, &flags)
 	addLocalFSFlags(cmd, &fsFlags)
+	addOutputFlags(cmd, &outFlags)
+	addAuditFlags(cmd, &audit)
+	addAccountFlags(cmd, &acctFlags)
+	cmd.Flags().BoolVar(&crossAccountHints, "cross-account-hints", false, "when a file ID isn't found, check the other accounts for it and name the one that has it (extra API calls per miss)")
 	return cmd
 }
 
 func newCalendarCmd() *cobra.Command {
 	var flags toolFilterFlags
 	var fsFlags localFSFlags
+	var outFlags outputFlags
+	var audit auditFlags
+	var acctFlags accountFlags
 	cmd := &cobra.Command{
 		Use:   "calendar",
 		Short: "Start the Google Calendar MCP server (stdio)",
@@ -325,17 +657,29 @@ func newCalendarCmd() *cobra.Command {
 
 Use --read-only to expose only read-only tools.
 Use --enable or --disable for granular tool control.
-Use --allow-read-dir to enable local file access (opt-in, secure).`,
+Use --allow-read-dir to enable local file access (opt-in, secure).
+Use --audit-log to keep a log of the changes made through the tools.
+Use --default-account to pin calls that omit account to one account.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			mgr, err := newManager()
 			if err != nil {
 				return err
 			}
+			if err := flags.applyScopes(mgr); err != nil {
+				return err
+			}
 
 			srv := server.NewServer(&mcp.Implementation{
 				Name:    "google-mcp-calendar",
 				Version: version,
 			}, nil)
+			outFlags.apply(srv)
+			if err := audit.apply(srv, mgr); err != nil {
+				return err
+			}
+			if err := acctFlags.apply(srv, mgr); err != nil {
+				return err
+			}
 
 			lfs, err := fsFlags.toLocalFS()
 			if err != nil {
@@ -352,16 +696,56 @@ Use --allow-read-dir to enable local file access (opt-in, secure).`,
 				return err
 			}
 
-			return srv.Run(context.Background(), &mcp.StdioTransport{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go fsFlags.reloadOnSignal(ctx, srv)
+
+			return srv.Run(ctx, &mcp.StdioTransport{})
 		},
 	}
 	addToolFilterFlags(cmd, &flags)
 	addLocalFSFlags(cmd, &fsFlags)
+	addOutputFlags(cmd, &outFlags)
+	addAuditFlags(cmd, &audit)
+	addAccountFlags(cmd, &acctFlags)
 	return cmd
 }
 
 // --- helpers ---
 
+// scopePresets returns the scope sets selectable with auth add --preset.
+func scopePresets() map[string][]string {
+	return map[string][]string{
+		"full":              mergeScopes(gmail.AccountScopes(), drive.AccountScopes(), calendar.AccountScopes()),
+		"readonly":          mergeScopes(gmail.AccountReadonlyScopes(), drive.AccountReadonlyScopes(), calendar.AccountReadonlyScopes()),
+		"gmail":             gmail.AccountScopes(),
+		"gmail-readonly":    gmail.AccountReadonlyScopes(),
+		"drive":             drive.AccountScopes(),
+		"drive-readonly":    drive.AccountReadonlyScopes(),
+		"calendar":          calendar.AccountScopes(),
+		"calendar-readonly": calendar.AccountReadonlyScopes(),
+	}
+}
+
+// expandScopes returns the scopes to request for auth add: the union of the
+// given presets and explicit scopes, or the full preset when neither is set.
+func expandScopes(presetNames, scopes []string) ([]string, error) {
+	presets := scopePresets()
+	if len(presetNames) == 0 && len(scopes) == 0 {
+		return presets["full"], nil
+	}
+	var sets [][]string
+	for _, name := range presetNames {
+		set, ok := presets[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			names := slices.Sorted(maps.Keys(presets))
+			return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		sets = append(sets, set)
+	}
+	return mergeScopes(append(sets, scopes)...), nil
+}
+
 func mergeScopes(scopeSets ...[]string) []string {
 	seen := make(map[string]bool)
 	var result []string
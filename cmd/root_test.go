@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/calendar"
+	"github.com/thegrumpylion/google-mcp/internal/drive"
+	"github.com/thegrumpylion/google-mcp/internal/gmail"
+	"github.com/thegrumpylion/google-mcp/internal/localfs"
+)
+
+func TestExpandScopes(t *testing.T) {
+	full := mergeScopes(gmail.AccountScopes(), drive.AccountScopes(), calendar.AccountScopes())
+
+	got, err := expandScopes(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, full) {
+		t.Errorf("default = %v, want all scopes %v", got, full)
+	}
+
+	got, err = expandScopes([]string{"gmail-readonly", "Calendar-Readonly"}, []string{"openid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mergeScopes(gmail.ReadonlyScopes, calendar.ReadonlyScopes, []string{"openid"})
+	if !slices.Equal(got, want) {
+		t.Errorf("expandScopes() = %v, want %v", got, want)
+	}
+
+	got, err = expandScopes(nil, []string{"https://www.googleapis.com/auth/drive.file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("explicit scopes alone = %v, want only them", got)
+	}
+
+	if _, err := expandScopes([]string{"gmail-ro"}, nil); err == nil || !strings.Contains(err.Error(), "available: calendar, calendar-readonly") {
+		t.Errorf("err = %v, want unknown preset listing the presets", err)
+	}
+}
+
+func TestScopePresets_ReadOnly(t *testing.T) {
+	for name, scopes := range scopePresets() {
+		if len(scopes) == 0 {
+			t.Errorf("preset %s is empty", name)
+		}
+		if !strings.HasSuffix(name, "readonly") {
+			continue
+		}
+		for _, scope := range scopes {
+			if !auth.IsReadOnlyScope(scope) {
+				t.Errorf("preset %s includes %s, which allows changes", name, scope)
+			}
+		}
+	}
+}
+
+func TestParseDirFlag(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  localfs.Dir
+	}{
+		{"/home/me/docs", localfs.Dir{Path: "/home/me/docs"}},
+		{"invoices=/home/me/invoices", localfs.Dir{Path: "/home/me/invoices", Label: "invoices"}},
+		{"./a=b", localfs.Dir{Path: "./a=b"}},
+		{"=/x", localfs.Dir{Path: "=/x"}},
+	} {
+		if got := parseDirFlag(tt.value, localfs.ModeRead); got != tt.want {
+			t.Errorf("parseDirFlag(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDirsFile(t *testing.T) {
+	dirs, err := parseDirsFile("# allowed directories\nread docs=/home/me/docs\n\n  write /tmp/out  \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []localfs.Dir{
+		{Path: "/home/me/docs", Mode: localfs.ModeRead, Label: "docs"},
+		{Path: "/tmp/out", Mode: localfs.ModeReadWrite},
+	}
+	if !slices.Equal(dirs, want) {
+		t.Errorf("parseDirsFile() = %+v, want %+v", dirs, want)
+	}
+
+	for _, data := range []string{"read\n", "append /tmp\n"} {
+		if _, err := parseDirsFile(data); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("parseDirsFile(%q) error = %v", data, err)
+		}
+	}
+}
+
+func TestLocalFSFlags_DirsFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "dirs")
+	f := localFSFlags{readDirs: []string{"docs=" + dir}, dirsFile: file}
+	if _, err := f.toLocalFS(); err == nil {
+		t.Error("toLocalFS() with a missing dirs file succeeded")
+	}
+
+	// An empty dirs file still enables local file access, so directories
+	// can be added by a reload.
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lfs, err := (&localFSFlags{dirsFile: file}).toLocalFS()
+	if err != nil || lfs == nil {
+		t.Fatalf("toLocalFS() = %v, %v", lfs, err)
+	}
+	lfs.Close()
+
+	if err := os.WriteFile(file, []byte("write out="+dir+"/sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dirs, err := f.dirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 2 || dirs[0].Label != "docs" || dirs[1].Label != "out" || dirs[1].Mode != localfs.ModeReadWrite {
+		t.Errorf("dirs() = %+v", dirs)
+	}
+}
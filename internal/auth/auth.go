@@ -12,7 +12,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -23,12 +26,18 @@ import (
 // directly from the Google credentials.json file, not stored here.
 type Config struct {
 	Accounts map[string]*Account `json:"accounts"`
+	// Default is the account used when a tool call omits the account.
+	Default string `json:"default,omitempty"`
 }
 
-// Account holds the OAuth2 token for a single Google account.
+// Account holds the OAuth2 token for a single Google account, along with
+// metadata recorded at authentication and refresh time so that status
+// reporting doesn't need a network round trip.
 type Account struct {
-	Email string        `json:"email,omitempty"`
-	Token *oauth2.Token `json:"token"`
+	Email       string        `json:"email,omitempty"`
+	Token       *oauth2.Token `json:"token"`
+	Scopes      []string      `json:"scopes,omitempty"`
+	LastRefresh time.Time     `json:"last_refresh,omitzero"`
 }
 
 // Manager handles loading/saving tokens and reading credentials from the
@@ -38,6 +47,15 @@ type Manager struct {
 	configDir       string
 	credentialsFile string
 	config          *Config
+
+	// refreshMu guards refreshLocks, which serialize token refreshes per
+	// account across all token sources handed out for it.
+	refreshMu    sync.Mutex
+	refreshLocks map[string]*sync.Mutex
+
+	// readOnlyScopes makes ClientOption use read-only scope sets and refuse
+	// accounts granted anything broader. See RequireReadOnlyScopes.
+	readOnlyScopes bool
 }
 
 // NewManager creates a new auth manager.
@@ -120,12 +138,59 @@ func (m *Manager) save() error {
 	if err != nil {
 		return fmt.Errorf("marshaling tokens: %w", err)
 	}
-	if err := os.WriteFile(m.tokensPath(), data, 0o600); err != nil {
+
+	// Write to a temp file and rename it into place, so a crash or another
+	// server process (each subcommand runs separately and shares the file)
+	// never sees a partially written tokens.json.
+	tmp, err := os.CreateTemp(m.configDir, "tokens-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("writing tokens: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing tokens: %w", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing tokens: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing tokens: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.tokensPath()); err != nil {
 		return fmt.Errorf("writing tokens: %w", err)
 	}
 	return nil
 }
 
+// refreshLock returns the mutex that serializes token refreshes for the
+// named account.
+func (m *Manager) refreshLock(name string) *sync.Mutex {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	if m.refreshLocks == nil {
+		m.refreshLocks = make(map[string]*sync.Mutex)
+	}
+	l, ok := m.refreshLocks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		m.refreshLocks[name] = l
+	}
+	return l
+}
+
+// storedToken returns the account's current token, or nil if the account
+// no longer exists.
+func (m *Manager) storedToken(name string) *oauth2.Token {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if acct, ok := m.config.Accounts[name]; ok {
+		return acct.Token
+	}
+	return nil
+}
+
 // oauthConfig reads the credentials.json file and builds an oauth2.Config
 // with the given scopes.
 func (m *Manager) oauthConfig(scopes []string) (*oauth2.Config, error) {
@@ -146,7 +211,9 @@ func (m *Manager) oauthConfig(scopes []string) (*oauth2.Config, error) {
 
 // ResolveAccounts resolves an account parameter to a list of account names.
 // If account is "all", it returns all configured account names.
-// Otherwise it validates the account exists and returns it as a single-element slice.
+// If account is empty, it returns the default account (see DefaultAccount).
+// Otherwise it resolves the account by name or email address, ignoring
+// case, and returns it as a single-element slice.
 func (m *Manager) ResolveAccounts(account string) ([]string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -162,10 +229,79 @@ func (m *Manager) ResolveAccounts(account string) ([]string, error) {
 		return names, nil
 	}
 
-	if _, ok := m.config.Accounts[account]; !ok {
-		return nil, fmt.Errorf("account %q not found; run 'google-mcp auth add %s' first", account, account)
+	if account == "" {
+		name, err := m.defaultAccountLocked()
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+
+	name, err := m.resolveNameLocked(account)
+	if err != nil {
+		return nil, err
+	}
+	return []string{name}, nil
+}
+
+// DefaultAccount returns the account used when a tool call omits the
+// account: the account set with SetDefault, or the only configured account.
+// It returns an error listing the configured accounts when neither applies.
+func (m *Manager) DefaultAccount() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.defaultAccountLocked()
+}
+
+func (m *Manager) defaultAccountLocked() (string, error) {
+	if m.config.Default != "" {
+		if _, ok := m.config.Accounts[m.config.Default]; ok {
+			return m.config.Default, nil
+		}
+	}
+	switch len(m.config.Accounts) {
+	case 0:
+		return "", fmt.Errorf("no accounts configured; run 'google-mcp auth add <name>' first")
+	case 1:
+		for name := range m.config.Accounts {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("account is required when multiple accounts are configured (available: %s); pass one of them or run 'google-mcp auth set-default <name>'",
+		strings.Join(m.sortedNamesLocked(), ", "))
+}
+
+// sortedNamesLocked returns the configured account names in sorted order.
+// The caller must hold m.mu.
+func (m *Manager) sortedNamesLocked() []string {
+	names := make([]string, 0, len(m.config.Accounts))
+	for name := range m.config.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDefault sets the account used when a tool call omits the account.
+// An empty name clears the default.
+func (m *Manager) SetDefault(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name != "" {
+		if _, ok := m.config.Accounts[name]; !ok {
+			return fmt.Errorf("account %q not found", name)
+		}
 	}
-	return []string{account}, nil
+	m.config.Default = name
+	return m.save()
+}
+
+// AccountNames returns the configured account names in sorted order.
+func (m *Manager) AccountNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedNamesLocked()
 }
 
 // ListAccounts returns all configured account names and their email addresses.
@@ -189,6 +325,9 @@ func (m *Manager) RemoveAccount(name string) error {
 		return fmt.Errorf("account %q not found", name)
 	}
 	delete(m.config.Accounts, name)
+	if m.config.Default == name {
+		m.config.Default = ""
+	}
 	return m.save()
 }
 
@@ -257,7 +396,11 @@ func (m *Manager) Authenticate(ctx context.Context, name string, scopes []string
 			return fmt.Errorf("exchanging auth code for token: %w", err)
 		}
 		m.mu.Lock()
-		m.config.Accounts[name] = &Account{Token: token}
+		m.config.Accounts[name] = &Account{
+			Token:       token,
+			Scopes:      grantedScopes(token, scopes),
+			LastRefresh: time.Now(),
+		}
 		err = m.save()
 		m.mu.Unlock()
 		if err != nil {
@@ -270,17 +413,26 @@ func (m *Manager) Authenticate(ctx context.Context, name string, scopes []string
 	}
 }
 
-// TokenSource returns an oauth2.TokenSource for the named account.
+// TokenSource returns an oauth2.TokenSource for the named account, or for
+// the default account if name is empty. The name is resolved the same way
+// as in ResolveAccounts.
 // The token source automatically refreshes expired tokens and persists
 // the updated token back to the tokens file.
 func (m *Manager) TokenSource(ctx context.Context, name string, scopes []string) (oauth2.TokenSource, error) {
+	if name == "" {
+		var err error
+		if name, err = m.DefaultAccount(); err != nil {
+			return nil, err
+		}
+	}
+
 	m.mu.RLock()
-	acct, ok := m.config.Accounts[name]
-	if !ok {
+	name, err := m.resolveNameLocked(name)
+	if err != nil {
 		m.mu.RUnlock()
-		return nil, fmt.Errorf("account %q not found; run 'google-mcp auth add %s' first", name, name)
+		return nil, err
 	}
-	token := acct.Token
+	token := m.config.Accounts[name].Token
 	m.mu.RUnlock()
 
 	cfg, err := m.oauthConfig(scopes)
@@ -288,18 +440,57 @@ func (m *Manager) TokenSource(ctx context.Context, name string, scopes []string)
 		return nil, err
 	}
 
-	ts := cfg.TokenSource(ctx, token)
+	newBase := func(t *oauth2.Token) oauth2.TokenSource { return cfg.TokenSource(ctx, t) }
 	return &persistingTokenSource{
-		base:    ts,
+		base:    newBase(token),
+		newBase: newBase,
 		manager: m,
 		name:    name,
 		orig:    token,
 	}, nil
 }
 
+// RequireReadOnlyScopes makes ClientOption use only read-only scope sets
+// and fail for accounts granted scopes that allow changes. Servers started
+// with --read-only --readonly-scopes call it before serving, so the tokens
+// in use can't modify anything even if a mutating call got through.
+func (m *Manager) RequireReadOnlyScopes() {
+	m.mu.Lock()
+	m.readOnlyScopes = true
+	m.mu.Unlock()
+}
+
 // ClientOption returns a google API option.ClientOption for the named account.
-func (m *Manager) ClientOption(ctx context.Context, name string, scopes []string) (option.ClientOption, error) {
-	ts, err := m.TokenSource(ctx, name, scopes)
+//
+// scopeSets are the scope sets the caller can work with, ordered from widest
+// to narrowest (typically a service's Scopes and ReadonlyScopes). The narrowest
+// set that the account's granted scopes satisfy for the request is used; see
+// selectScopes.
+func (m *Manager) ClientOption(ctx context.Context, name string, scopeSets ...[]string) (option.ClientOption, error) {
+	if name == "" {
+		var err error
+		if name, err = m.DefaultAccount(); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.RLock()
+	resolved, err := m.resolveNameLocked(name)
+	var granted []string
+	if err == nil {
+		granted = m.config.Accounts[resolved].Scopes
+	}
+	readOnly := m.readOnlyScopes
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, err := selectScopes(resolved, granted, scopeSets, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := m.TokenSource(ctx, resolved, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -307,17 +498,30 @@ func (m *Manager) ClientOption(ctx context.Context, name string, scopes []string
 }
 
 // persistingTokenSource wraps a token source and saves refreshed tokens.
+//
+// Every tool call gets its own token source, so concurrent calls for an
+// account with an expired token would each refresh it. Token therefore
+// holds the account's refresh lock and first checks whether another source
+// already stored a newer valid token, in which case that one is reused and
+// base is rebuilt from it so later calls don't refresh the stale token.
 type persistingTokenSource struct {
-	mu      sync.Mutex
 	base    oauth2.TokenSource
+	newBase func(*oauth2.Token) oauth2.TokenSource
 	manager *Manager
 	name    string
 	orig    *oauth2.Token
 }
 
 func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	lock := s.manager.refreshLock(s.name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if current := s.manager.storedToken(s.name); current != nil && current.AccessToken != s.orig.AccessToken && current.Valid() {
+		s.orig = current
+		s.base = s.newBase(current)
+		return current, nil
+	}
 
 	token, err := s.base.Token()
 	if err != nil {
@@ -330,9 +534,22 @@ func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
 		s.manager.mu.Lock()
 		if acct, ok := s.manager.config.Accounts[s.name]; ok {
 			acct.Token = token
+			acct.LastRefresh = time.Now()
+			if granted := grantedScopes(token, nil); len(granted) > 0 {
+				acct.Scopes = granted
+			}
 			_ = s.manager.save() // best-effort persist
 		}
 		s.manager.mu.Unlock()
 	}
 	return token, nil
 }
+
+// grantedScopes returns the scopes reported in the token response's "scope"
+// field, falling back to the requested scopes when the field is absent.
+func grantedScopes(token *oauth2.Token, requested []string) []string {
+	if raw, ok := token.Extra("scope").(string); ok && raw != "" {
+		return strings.Fields(raw)
+	}
+	return requested
+}
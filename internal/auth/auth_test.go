@@ -1,9 +1,18 @@
 package auth
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"golang.org/x/oauth2"
 )
@@ -151,6 +160,20 @@ func TestListAccounts(t *testing.T) {
 	}
 }
 
+func TestAccountNames(t *testing.T) {
+	mgr := newTestManager(t)
+	if names := mgr.AccountNames(); len(names) != 0 {
+		t.Errorf("AccountNames() = %v, want none", names)
+	}
+
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{}}
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{}}
+
+	if got := strings.Join(mgr.AccountNames(), ","); got != "personal,work" {
+		t.Errorf("AccountNames() = %s, want personal,work", got)
+	}
+}
+
 func TestResolveAccounts_Single(t *testing.T) {
 	mgr := newTestManager(t)
 	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{}}
@@ -198,6 +221,109 @@ func TestResolveAccounts_NotFound(t *testing.T) {
 	}
 }
 
+func TestResolveAccounts_Fuzzy(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["personal"] = &Account{Email: "alice@gmail.com", Token: &oauth2.Token{}}
+	mgr.config.Accounts["work"] = &Account{Email: "alice@corp.example", Token: &oauth2.Token{}}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"personal", "personal"},
+		{"Personal", "personal"},
+		{"WORK", "work"},
+		{"alice@gmail.com", "personal"},
+		{"Alice@Corp.Example", "work"},
+	}
+	for _, tt := range tests {
+		names, err := mgr.ResolveAccounts(tt.input)
+		if err != nil {
+			t.Errorf("ResolveAccounts(%q) error: %v", tt.input, err)
+			continue
+		}
+		if len(names) != 1 || names[0] != tt.want {
+			t.Errorf("ResolveAccounts(%q) = %v, want [%s]", tt.input, names, tt.want)
+		}
+	}
+}
+
+func TestResolveAccounts_NearMiss(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{}}
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{}}
+
+	tests := []struct {
+		input   string
+		suggest string
+	}{
+		{"personal-gmail", "personal"},
+		{"persnal", "personal"},
+		{"wrk", "work"},
+		{"xyzzy", ""},
+	}
+	for _, tt := range tests {
+		_, err := mgr.ResolveAccounts(tt.input)
+		if err == nil {
+			t.Errorf("ResolveAccounts(%q) returned nil error, want error", tt.input)
+			continue
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, "available: personal, work") {
+			t.Errorf("ResolveAccounts(%q) error %q should list configured accounts", tt.input, msg)
+		}
+		if tt.suggest == "" {
+			if strings.Contains(msg, "did you mean") {
+				t.Errorf("ResolveAccounts(%q) error %q should not suggest a match", tt.input, msg)
+			}
+		} else if !strings.Contains(msg, fmt.Sprintf("did you mean %q", tt.suggest)) {
+			t.Errorf("ResolveAccounts(%q) error %q should suggest %q", tt.input, msg, tt.suggest)
+		}
+	}
+}
+
+func TestResolveAccounts_AmbiguousEmail(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["gmail"] = &Account{Email: "alice@gmail.com", Token: &oauth2.Token{}}
+	mgr.config.Accounts["calendar"] = &Account{Email: "alice@gmail.com", Token: &oauth2.Token{}}
+
+	_, err := mgr.ResolveAccounts("alice@gmail.com")
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("ResolveAccounts with shared email: err = %v, want ambiguous error", err)
+	}
+}
+
+func TestTokenSource_CaseInsensitive(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}}
+
+	ts, err := mgr.TokenSource(context.Background(), "Personal", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pts := ts.(*persistingTokenSource); pts.name != "personal" {
+		t.Errorf("token source name = %q, want personal", pts.name)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"work", "work", 0},
+		{"work", "wrk", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func TestResolveAccounts_AllEmpty(t *testing.T) {
 	mgr := newTestManager(t)
 
@@ -296,3 +422,541 @@ func TestTokensFilePermissions(t *testing.T) {
 		t.Errorf("tokens.json permissions = %o, want 600", perm)
 	}
 }
+
+func TestAccountMetadataRoundTrip(t *testing.T) {
+	mgr := newTestManager(t)
+	refreshed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mgr.config.Accounts["work"] = &Account{
+		Email:       "me@work.com",
+		Token:       &oauth2.Token{AccessToken: "x"},
+		Scopes:      []string{"https://mail.google.com/"},
+		LastRefresh: refreshed,
+	}
+	if err := mgr.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr2, err := NewManager(mgr.configDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct := mgr2.config.Accounts["work"]
+	if len(acct.Scopes) != 1 || acct.Scopes[0] != "https://mail.google.com/" {
+		t.Errorf("Scopes = %v, want [https://mail.google.com/]", acct.Scopes)
+	}
+	if !acct.LastRefresh.Equal(refreshed) {
+		t.Errorf("LastRefresh = %v, want %v", acct.LastRefresh, refreshed)
+	}
+}
+
+func TestAccountStatuses_Sorted(t *testing.T) {
+	mgr := newTestManager(t)
+	expiry := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{Expiry: expiry}}
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{}}
+	mgr.config.Accounts["alt"] = &Account{}
+
+	statuses := mgr.AccountStatuses()
+	if len(statuses) != 3 {
+		t.Fatalf("got %d statuses, want 3", len(statuses))
+	}
+	for i, want := range []string{"alt", "personal", "work"} {
+		if statuses[i].Name != want {
+			t.Errorf("statuses[%d].Name = %q, want %q", i, statuses[i].Name, want)
+		}
+	}
+	if !statuses[2].Expiry.Equal(expiry) {
+		t.Errorf("work Expiry = %v, want %v", statuses[2].Expiry, expiry)
+	}
+}
+
+func TestScopeGroup(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{"https://mail.google.com/", "Gmail"},
+		{"https://www.googleapis.com/auth/gmail.settings.basic", "Gmail"},
+		{"https://www.googleapis.com/auth/drive", "Drive"},
+		{"https://www.googleapis.com/auth/drive.readonly", "Drive"},
+		{"https://www.googleapis.com/auth/calendar", "Calendar"},
+		{"openid", "Other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			if got := ScopeGroup(tt.scope); got != tt.want {
+				t.Errorf("ScopeGroup(%q) = %q, want %q", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAccountStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	statuses := []AccountStatus{
+		{
+			Name:  "work",
+			Email: "me@work.com",
+			Scopes: []string{
+				"https://www.googleapis.com/auth/drive",
+				"https://mail.google.com/",
+				"https://www.googleapis.com/auth/calendar",
+			},
+			Expiry:      now.Add(-time.Minute),
+			LastRefresh: now.Add(-time.Hour),
+			Checked:     true,
+			Healthy:     false,
+			CheckError:  "token rejected: invalid_token",
+		},
+		{Name: "personal"},
+	}
+
+	var sb strings.Builder
+	WriteAccountStatus(&sb, statuses, now)
+	got := sb.String()
+
+	for _, want := range []string{
+		"  - work (me@work.com)",
+		"      Gmail: https://mail.google.com/",
+		"      Drive: https://www.googleapis.com/auth/drive",
+		"      Calendar: https://www.googleapis.com/auth/calendar",
+		"(expired, will refresh on next use)",
+		"Last Refresh: 2024-03-01T11:00:00Z",
+		"Health: error (token rejected: invalid_token)",
+		"  - personal\n",
+		"Scopes: unknown",
+		"Token Expiry: unknown",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "Gmail:") > strings.Index(got, "Drive:") {
+		t.Error("Gmail scopes should be listed before Drive scopes")
+	}
+}
+
+func TestGrantedScopes(t *testing.T) {
+	requested := []string{"a", "b"}
+
+	tok := &oauth2.Token{AccessToken: "x"}
+	if got := grantedScopes(tok, requested); len(got) != 2 {
+		t.Errorf("grantedScopes without scope field = %v, want requested", got)
+	}
+
+	tok = tok.WithExtra(map[string]any{"scope": "c d e"})
+	got := grantedScopes(tok, requested)
+	if len(got) != 3 || got[0] != "c" || got[2] != "e" {
+		t.Errorf("grantedScopes with scope field = %v, want [c d e]", got)
+	}
+}
+
+func TestCheckAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"healthy", http.StatusOK, `{"scope":"https://mail.google.com/ https://www.googleapis.com/auth/drive","email":"me@work.com"}`, false},
+		{"revoked", http.StatusBadRequest, `{"error_description":"Invalid Value"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("access_token") != "access-123" {
+					t.Errorf("access_token = %q, want access-123", r.URL.Query().Get("access_token"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+			orig := tokenInfoURL
+			tokenInfoURL = ts.URL
+			defer func() { tokenInfoURL = orig }()
+
+			mgr := newTestManager(t)
+			mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{
+				AccessToken: "access-123",
+				Expiry:      time.Now().Add(time.Hour),
+			}}
+
+			statuses := mgr.CheckAccountStatuses(context.Background())
+			if len(statuses) != 1 || !statuses[0].Checked {
+				t.Fatalf("statuses = %+v, want one checked status", statuses)
+			}
+			if statuses[0].Healthy == tt.wantErr {
+				t.Errorf("Healthy = %v, CheckError = %q", statuses[0].Healthy, statuses[0].CheckError)
+			}
+			if !tt.wantErr {
+				if len(statuses[0].Scopes) != 2 {
+					t.Errorf("Scopes = %v, want 2 scopes recorded from tokeninfo", statuses[0].Scopes)
+				}
+				if statuses[0].Email != "me@work.com" {
+					t.Errorf("Email = %q, want me@work.com", statuses[0].Email)
+				}
+			}
+		})
+	}
+}
+
+// staticTokenSource returns a fixed token.
+type staticTokenSource struct{ token *oauth2.Token }
+
+func (s staticTokenSource) Token() (*oauth2.Token, error) { return s.token, nil }
+
+func TestPersistingTokenSource_RecordsRefresh(t *testing.T) {
+	mgr := newTestManager(t)
+	orig := &oauth2.Token{AccessToken: "old"}
+	mgr.config.Accounts["work"] = &Account{Token: orig, Scopes: []string{"keep"}}
+
+	fresh := (&oauth2.Token{AccessToken: "new"}).WithExtra(map[string]any{"scope": "https://mail.google.com/"})
+	pts := &persistingTokenSource{
+		base:    staticTokenSource{token: fresh},
+		manager: mgr,
+		name:    "work",
+		orig:    orig,
+	}
+	before := time.Now()
+	if _, err := pts.Token(); err != nil {
+		t.Fatal(err)
+	}
+
+	acct := mgr.config.Accounts["work"]
+	if acct.Token.AccessToken != "new" {
+		t.Errorf("AccessToken = %q, want new", acct.Token.AccessToken)
+	}
+	if acct.LastRefresh.Before(before) {
+		t.Errorf("LastRefresh = %v, want >= %v", acct.LastRefresh, before)
+	}
+	if len(acct.Scopes) != 1 || acct.Scopes[0] != "https://mail.google.com/" {
+		t.Errorf("Scopes = %v, want scopes from refreshed token", acct.Scopes)
+	}
+}
+
+func TestPersistingTokenSource_ReusesStoredToken(t *testing.T) {
+	mgr := newTestManager(t)
+	orig := &oauth2.Token{AccessToken: "old"}
+	stored := &oauth2.Token{AccessToken: "stored", Expiry: time.Now().Add(time.Hour)}
+	mgr.config.Accounts["work"] = &Account{Token: stored}
+
+	var rebuilt *oauth2.Token
+	pts := &persistingTokenSource{
+		base: staticTokenSource{token: &oauth2.Token{AccessToken: "refreshed"}},
+		newBase: func(t *oauth2.Token) oauth2.TokenSource {
+			rebuilt = t
+			return staticTokenSource{token: t}
+		},
+		manager: mgr,
+		name:    "work",
+		orig:    orig,
+	}
+	for i := 0; i < 2; i++ {
+		got, err := pts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.AccessToken != "stored" {
+			t.Fatalf("call %d: AccessToken = %q, want stored", i, got.AccessToken)
+		}
+	}
+	if rebuilt != stored {
+		t.Errorf("base rebuilt from %v, want the stored token", rebuilt)
+	}
+}
+
+func TestResolveAccounts_Default(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		def      string
+		want     string
+		wantErr  bool
+	}{
+		{"zero accounts", nil, "", "", true},
+		{"one account", []string{"personal"}, "", "personal", false},
+		{"one account with default", []string{"personal"}, "personal", "personal", false},
+		{"multiple without default", []string{"personal", "work"}, "", "", true},
+		{"multiple with default", []string{"personal", "work"}, "work", "work", false},
+		{"stale default", []string{"personal", "work"}, "gone", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := newTestManager(t)
+			for _, name := range tt.accounts {
+				mgr.config.Accounts[name] = &Account{Token: &oauth2.Token{}}
+			}
+			mgr.config.Default = tt.def
+
+			names, err := mgr.ResolveAccounts("")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ResolveAccounts(\"\") = %v, want error", names)
+				}
+				if len(tt.accounts) > 1 && !strings.Contains(err.Error(), "personal, work") {
+					t.Errorf("error %q should list available accounts", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(names) != 1 || names[0] != tt.want {
+				t.Errorf("ResolveAccounts(\"\") = %v, want [%s]", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAccount(t *testing.T) {
+	ctx := context.Background()
+	session := WithSessionAccount(ctx, "work")
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		account string
+		want    string
+	}{
+		{"explicit over session", session, "home", "home"},
+		{"all over session", session, "all", "all"},
+		{"session default", session, "", "work"},
+		{"no session default", ctx, "", ""},
+		{"empty session default", WithSessionAccount(ctx, ""), "", ""},
+	}
+	for _, tt := range tests {
+		if got := ResolveAccount(tt.ctx, tt.account); got != tt.want {
+			t.Errorf("%s: ResolveAccount(%q) = %q, want %q", tt.name, tt.account, got, tt.want)
+		}
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{}}
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{}}
+
+	if err := mgr.SetDefault("missing"); err == nil {
+		t.Error("SetDefault(\"missing\") returned nil error, want error")
+	}
+	if err := mgr.SetDefault("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify persistence.
+	mgr2, err := NewManager(mgr.configDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := mgr2.DefaultAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "work" {
+		t.Errorf("DefaultAccount() = %q, want work", name)
+	}
+
+	// Removing the default account clears it.
+	if err := mgr2.RemoveAccount("work"); err != nil {
+		t.Fatal(err)
+	}
+	if mgr2.config.Default != "" {
+		t.Errorf("Default = %q after removal, want empty", mgr2.config.Default)
+	}
+	name, err = mgr2.DefaultAccount()
+	if err != nil || name != "personal" {
+		t.Errorf("DefaultAccount() = %q, %v; want sole account personal", name, err)
+	}
+}
+
+func TestTokenSource_EmptyNameUsesDefault(t *testing.T) {
+	mgr := newTestManager(t)
+	mgr.config.Accounts["personal"] = &Account{Token: &oauth2.Token{AccessToken: "x"}}
+
+	if _, err := mgr.TokenSource(context.Background(), "", nil); err != nil {
+		t.Errorf("TokenSource with empty name: %v", err)
+	}
+
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{AccessToken: "y"}}
+	if _, err := mgr.TokenSource(context.Background(), "", nil); err == nil {
+		t.Error("TokenSource with empty name and two accounts should fail")
+	}
+}
+
+func TestTokenSource_ConcurrentRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		// Widen the window in which other goroutines could start a
+		// refresh of their own.
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+
+	dir := t.TempDir()
+	creds := fmt.Sprintf(`{"installed":{"client_id":"id","client_secret":"secret","auth_uri":"https://a","token_uri":%q,"redirect_uris":["http://localhost"]}}`, tokenServer.URL)
+	if err := os.WriteFile(filepath.Join(dir, "credentials.json"), []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := NewManager(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr.config.Accounts["work"] = &Account{Token: &oauth2.Token{
+		AccessToken:  "stale",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(-time.Hour),
+	}}
+	if err := mgr.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each tool call builds its own client option (and with it its own
+	// token source), as ClientOption does.
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts, err := mgr.TokenSource(context.Background(), "work", nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			token, err := ts.Token()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if token.AccessToken != "fresh" {
+				errs <- fmt.Errorf("AccessToken = %q, want fresh", token.AccessToken)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("token endpoint hit %d times, want 1", n)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("tokens.json is not valid JSON: %v\n%s", err, data)
+	}
+	if got := cfg.Accounts["work"].Token.AccessToken; got != "fresh" {
+		t.Errorf("persisted AccessToken = %q, want fresh", got)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temp file %s left behind", e.Name())
+		}
+	}
+}
+
+const (
+	testMailScope     = "https://mail.google.com/"
+	testGmailRead     = "https://www.googleapis.com/auth/gmail.readonly"
+	testDriveScope    = "https://www.googleapis.com/auth/drive"
+	testDriveRead     = "https://www.googleapis.com/auth/drive.readonly"
+	testCalendarRead  = "https://www.googleapis.com/auth/calendar.readonly"
+	testUserinfoEmail = "https://www.googleapis.com/auth/userinfo.email"
+)
+
+func TestScopesCovered(t *testing.T) {
+	tests := []struct {
+		granted, want []string
+		ok            bool
+	}{
+		{[]string{testMailScope}, []string{testMailScope}, true},
+		{[]string{testMailScope}, []string{testGmailRead}, true},
+		{[]string{testDriveScope}, []string{testDriveRead}, true},
+		{[]string{testGmailRead}, []string{testMailScope}, false},
+		{[]string{testGmailRead}, []string{testGmailRead, testDriveRead}, false},
+		{nil, nil, true},
+	}
+	for _, tt := range tests {
+		if got := scopesCovered(tt.granted, tt.want); got != tt.ok {
+			t.Errorf("scopesCovered(%v, %v) = %v, want %v", tt.granted, tt.want, got, tt.ok)
+		}
+	}
+}
+
+func TestSelectScopes(t *testing.T) {
+	full := []string{testMailScope, testDriveScope}
+	readonly := []string{testGmailRead}
+	sets := [][]string{full, readonly}
+
+	tests := []struct {
+		name     string
+		granted  []string
+		readOnly bool
+		want     []string
+		err      string
+	}{
+		{name: "full grant", granted: full, want: full},
+		{name: "unknown grant", want: full},
+		{name: "readonly grant falls back to narrowest covered set", granted: []string{testGmailRead, testUserinfoEmail}, want: readonly},
+		{name: "grant covers neither", granted: []string{testCalendarRead}, want: full},
+		{name: "read-only mode", granted: []string{testGmailRead, testDriveRead, testUserinfoEmail}, readOnly: true, want: readonly},
+		{name: "read-only mode refuses broad grants", granted: []string{testGmailRead, testDriveScope}, readOnly: true,
+			err: `account "work" was granted scopes that allow changes (https://www.googleapis.com/auth/drive)`},
+		{name: "read-only mode needs a recorded grant", readOnly: true, err: "auth list --check"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectScopes("work", tt.granted, sets, tt.readOnly)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("selectScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := selectScopes("work", readonly, [][]string{full}, true); err == nil || !strings.Contains(err.Error(), "no read-only scope set") {
+		t.Errorf("err = %v, want missing read-only set", err)
+	}
+}
+
+func TestClientOption_ReadOnlyScopes(t *testing.T) {
+	mgr := newTestManager(t)
+	token := &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}
+	mgr.config.Accounts["analytics"] = &Account{Token: token, Scopes: []string{testGmailRead}}
+	mgr.config.Accounts["work"] = &Account{Token: token, Scopes: []string{testMailScope}}
+	sets := [][]string{{testMailScope}, {testGmailRead}}
+
+	for _, name := range []string{"analytics", "work"} {
+		if _, err := mgr.ClientOption(context.Background(), name, sets...); err != nil {
+			t.Errorf("ClientOption(%s): %v", name, err)
+		}
+	}
+
+	mgr.RequireReadOnlyScopes()
+	if _, err := mgr.ClientOption(context.Background(), "Analytics", sets...); err != nil {
+		t.Errorf("ClientOption(analytics) in read-only mode: %v", err)
+	}
+	if _, err := mgr.ClientOption(context.Background(), "work", sets...); err == nil || !strings.Contains(err.Error(), "allow changes") {
+		t.Errorf("ClientOption(work) in read-only mode: err = %v, want refusal", err)
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveNameLocked maps a user-supplied account reference to a configured
+// account name. It accepts, in order of preference, the exact name, the
+// name ignoring case, and the account's email address ignoring case. When
+// nothing matches, the error lists the configured accounts and suggests
+// the closest name. The caller must hold m.mu.
+func (m *Manager) resolveNameLocked(account string) (string, error) {
+	if _, ok := m.config.Accounts[account]; ok {
+		return account, nil
+	}
+
+	names := m.sortedNamesLocked()
+	if name, err := uniqueMatch(account, names, func(name string) string { return name }); name != "" || err != nil {
+		return name, err
+	}
+	if name, err := uniqueMatch(account, names, func(name string) string { return m.config.Accounts[name].Email }); name != "" || err != nil {
+		return name, err
+	}
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("account %q not found: no accounts configured; run 'google-mcp auth add %s' first", account, account)
+	}
+	hint := ""
+	if s := closestName(account, names); s != "" {
+		hint = fmt.Sprintf("; did you mean %q?", s)
+	}
+	return "", fmt.Errorf("account %q not found (available: %s)%s", account, strings.Join(names, ", "), hint)
+}
+
+// uniqueMatch returns the single name whose key(name) equals account
+// ignoring case. It returns "" if there is no match and an error if the
+// match is ambiguous.
+func uniqueMatch(account string, names []string, key func(string) string) (string, error) {
+	var matches []string
+	for _, name := range names {
+		if k := key(name); k != "" && strings.EqualFold(k, account) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("account %q is ambiguous: matches %s", account, strings.Join(matches, ", "))
+	}
+}
+
+// closestName returns the name with the smallest edit distance to account,
+// or "" if none is close enough to be a plausible typo. A name that
+// contains the input, or vice versa ("personal-gmail" for "personal"),
+// always qualifies.
+func closestName(account string, names []string) string {
+	account = strings.ToLower(account)
+	best, bestDist := "", -1
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		d := editDistance(account, lower)
+		limit := max(len(account), len(lower)) / 2
+		if d > limit && !strings.Contains(account, lower) && !strings.Contains(lower, account) {
+			continue
+		}
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
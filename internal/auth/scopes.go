@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// impliedBy lists, for scopes that have broader variants, the scopes that
+// include them, so an account granted the broader scope satisfies a request
+// for the narrower one.
+var impliedBy = map[string][]string{
+	"https://www.googleapis.com/auth/gmail.readonly":          {"https://mail.google.com/", "https://www.googleapis.com/auth/gmail.modify"},
+	"https://www.googleapis.com/auth/gmail.modify":            {"https://mail.google.com/"},
+	"https://www.googleapis.com/auth/drive.readonly":          {"https://www.googleapis.com/auth/drive"},
+	"https://www.googleapis.com/auth/drive.activity.readonly": {"https://www.googleapis.com/auth/drive.activity"},
+	"https://www.googleapis.com/auth/calendar.readonly":       {"https://www.googleapis.com/auth/calendar"},
+	"https://www.googleapis.com/auth/contacts.readonly":       {"https://www.googleapis.com/auth/contacts"},
+}
+
+// identityScopes are scopes that only reveal who the user is. Google may add
+// them to a token, and they don't allow changing any data.
+var identityScopes = []string{
+	"openid",
+	"email",
+	"profile",
+	"https://www.googleapis.com/auth/userinfo.email",
+	"https://www.googleapis.com/auth/userinfo.profile",
+}
+
+// IsReadOnlyScope reports whether scope only allows reading data.
+func IsReadOnlyScope(scope string) bool {
+	return strings.HasSuffix(scope, ".readonly") ||
+		scope == "https://www.googleapis.com/auth/gmail.metadata" ||
+		slices.Contains(identityScopes, scope)
+}
+
+// scopesCovered reports whether granted includes every scope in want,
+// directly or through a broader scope.
+func scopesCovered(granted, want []string) bool {
+	for _, scope := range want {
+		if slices.Contains(granted, scope) {
+			continue
+		}
+		if !slices.ContainsFunc(impliedBy[scope], func(broader string) bool { return slices.Contains(granted, broader) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectScopes picks the scope set to request for an account from sets,
+// which callers order from widest (everything the service can do) to
+// narrowest. granted is the account's recorded scopes, if known.
+//
+// Normally the widest set is used when the account was granted it (or its
+// grant is unknown); otherwise the narrowest set the grant covers is used, so
+// an account authorized with a read-only preset keeps working for reads.
+//
+// With readOnly set, the narrowest set made only of read-only scopes is
+// used, and the account must have been granted read-only scopes only. An
+// access token carries every scope of its grant, whatever a client
+// requests, so refusing broader grants is what guarantees the token can't
+// change anything.
+func selectScopes(account string, granted []string, sets [][]string, readOnly bool) ([]string, error) {
+	if len(sets) == 0 {
+		return nil, nil
+	}
+	if !readOnly {
+		if len(granted) == 0 || scopesCovered(granted, sets[0]) {
+			return sets[0], nil
+		}
+		for _, set := range slices.Backward(sets) {
+			if scopesCovered(granted, set) {
+				return set, nil
+			}
+		}
+		return sets[0], nil
+	}
+
+	var want []string
+	for _, set := range slices.Backward(sets) {
+		if len(set) > 0 && !slices.ContainsFunc(set, isWriteScope) {
+			want = set
+			break
+		}
+	}
+	if want == nil {
+		return nil, fmt.Errorf("this service has no read-only scope set")
+	}
+	if len(granted) == 0 {
+		return nil, fmt.Errorf("the scopes granted to account %q are unknown; run 'google-mcp auth list --check' to record them", account)
+	}
+	var broad []string
+	for _, scope := range granted {
+		if isWriteScope(scope) {
+			broad = append(broad, scope)
+		}
+	}
+	if len(broad) > 0 {
+		return nil, fmt.Errorf("account %q was granted scopes that allow changes (%s), but read-only scopes are required; re-authorize it with 'google-mcp auth add %s --preset readonly'", account, strings.Join(broad, ", "), account)
+	}
+	return want, nil
+}
+
+// isWriteScope reports whether scope allows changing data.
+func isWriteScope(scope string) bool {
+	return !IsReadOnlyScope(scope)
+}
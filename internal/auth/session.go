@@ -0,0 +1,37 @@
+package auth
+
+import "context"
+
+// sessionAccountKey is the context key of the session default account.
+type sessionAccountKey struct{}
+
+// WithSessionAccount returns a copy of ctx carrying name as the default
+// account of the MCP session the call belongs to. An empty name leaves ctx
+// unchanged.
+func WithSessionAccount(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionAccountKey{}, name)
+}
+
+// SessionAccount returns the session default account carried by ctx, or ""
+// if there is none.
+func SessionAccount(ctx context.Context) string {
+	name, _ := ctx.Value(sessionAccountKey{}).(string)
+	return name
+}
+
+// ResolveAccount returns the account a tool call should use given its
+// account argument. Precedence: the explicit argument, then the session
+// default carried by ctx (see WithSessionAccount). It returns "" when
+// neither is set, which the Manager resolves to the configured default
+// account or the only account (see DefaultAccount). Tools pass their
+// account argument through it before calling ResolveAccounts or
+// ClientOption.
+func ResolveAccount(ctx context.Context, account string) string {
+	if account != "" {
+		return account
+	}
+	return SessionAccount(ctx)
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tokenInfoURL is the Google endpoint used to validate access tokens.
+// It is a variable so tests can point it at a local server.
+var tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// AccountStatus describes the stored state of a configured account.
+type AccountStatus struct {
+	Name        string
+	Email       string
+	Scopes      []string
+	Expiry      time.Time
+	LastRefresh time.Time
+	// Default is true for the account used when a tool call omits the account.
+	Default bool
+
+	// Checked is true when a live token check was performed; Healthy and
+	// CheckError hold its outcome.
+	Checked    bool
+	Healthy    bool
+	CheckError string
+}
+
+// AccountStatuses returns the stored status of every configured account,
+// sorted by account name. It reads only tokens.json and makes no network calls.
+func (m *Manager) AccountStatuses() []AccountStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	defaultName, _ := m.defaultAccountLocked()
+	statuses := make([]AccountStatus, 0, len(m.config.Accounts))
+	for name, acct := range m.config.Accounts {
+		st := AccountStatus{
+			Name:        name,
+			Email:       acct.Email,
+			Scopes:      append([]string(nil), acct.Scopes...),
+			LastRefresh: acct.LastRefresh,
+			Default:     name == defaultName,
+		}
+		if acct.Token != nil {
+			st.Expiry = acct.Token.Expiry
+		}
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// tokenInfo is the subset of the tokeninfo response we care about.
+type tokenInfo struct {
+	Scope string `json:"scope"`
+	Email string `json:"email"`
+	Error string `json:"error_description"`
+}
+
+// CheckAccount validates the named account's token against Google's
+// tokeninfo endpoint, refreshing it first if it has expired. On success the
+// granted scopes (and email, if reported) are recorded in tokens.json.
+func (m *Manager) CheckAccount(ctx context.Context, name string) error {
+	m.mu.RLock()
+	acct, ok := m.config.Accounts[name]
+	var scopes []string
+	if ok {
+		scopes = acct.Scopes
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("account %q not found", name)
+	}
+
+	ts, err := m.TokenSource(ctx, name, scopes)
+	if err != nil {
+		return err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return fmt.Errorf("refreshing token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+"?access_token="+url.QueryEscape(token.AccessToken), nil)
+	if err != nil {
+		return fmt.Errorf("building tokeninfo request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling tokeninfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info tokenInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return fmt.Errorf("parsing tokeninfo response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if info.Error != "" {
+			return fmt.Errorf("token rejected: %s", info.Error)
+		}
+		return fmt.Errorf("token rejected: %s", resp.Status)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if acct, ok := m.config.Accounts[name]; ok {
+		if info.Scope != "" {
+			acct.Scopes = strings.Fields(info.Scope)
+		}
+		if acct.Email == "" && info.Email != "" {
+			acct.Email = info.Email
+		}
+		_ = m.save() // best-effort persist
+	}
+	return nil
+}
+
+// CheckAccountStatuses runs CheckAccount for every configured account and
+// returns the resulting statuses with the health fields populated.
+func (m *Manager) CheckAccountStatuses(ctx context.Context) []AccountStatus {
+	results := make(map[string]error)
+	for _, st := range m.AccountStatuses() {
+		results[st.Name] = m.CheckAccount(ctx, st.Name)
+	}
+
+	statuses := m.AccountStatuses()
+	for i := range statuses {
+		err, ok := results[statuses[i].Name]
+		if !ok {
+			continue
+		}
+		statuses[i].Checked = true
+		statuses[i].Healthy = err == nil
+		if err != nil {
+			statuses[i].CheckError = err.Error()
+		}
+	}
+	return statuses
+}
+
+// ScopeGroup maps an OAuth scope URL to the service it belongs to:
+// "Gmail", "Drive", "Calendar", or "Other".
+func ScopeGroup(scope string) string {
+	switch {
+	case scope == "https://mail.google.com/" || strings.Contains(scope, "/auth/gmail"):
+		return "Gmail"
+	case strings.Contains(scope, "/auth/drive"):
+		return "Drive"
+	case strings.Contains(scope, "/auth/calendar"):
+		return "Calendar"
+	default:
+		return "Other"
+	}
+}
+
+// scopeGroupOrder is the display order for grouped scopes.
+var scopeGroupOrder = []string{"Gmail", "Drive", "Calendar", "Other"}
+
+// WriteAccountStatus writes a human-readable report of the given account
+// statuses. It is shared by the list_accounts tool and 'auth list'.
+func WriteAccountStatus(w io.Writer, statuses []AccountStatus, now time.Time) {
+	fmt.Fprintln(w, "Configured accounts:")
+	for _, st := range statuses {
+		marker := ""
+		if st.Default {
+			marker = " [default]"
+		}
+		if st.Email != "" {
+			fmt.Fprintf(w, "  - %s (%s)%s\n", st.Name, st.Email, marker)
+		} else {
+			fmt.Fprintf(w, "  - %s%s\n", st.Name, marker)
+		}
+
+		if len(st.Scopes) == 0 {
+			fmt.Fprintln(w, "    Scopes: unknown (re-run 'google-mcp auth add' to record them)")
+		} else {
+			groups := make(map[string][]string)
+			for _, scope := range st.Scopes {
+				g := ScopeGroup(scope)
+				groups[g] = append(groups[g], scope)
+			}
+			fmt.Fprintln(w, "    Scopes:")
+			for _, g := range scopeGroupOrder {
+				if len(groups[g]) == 0 {
+					continue
+				}
+				fmt.Fprintf(w, "      %s: %s\n", g, strings.Join(groups[g], ", "))
+			}
+		}
+
+		switch {
+		case st.Expiry.IsZero():
+			fmt.Fprintln(w, "    Token Expiry: unknown")
+		case st.Expiry.Before(now):
+			fmt.Fprintf(w, "    Token Expiry: %s (expired, will refresh on next use)\n", st.Expiry.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(w, "    Token Expiry: %s\n", st.Expiry.Format(time.RFC3339))
+		}
+
+		if st.LastRefresh.IsZero() {
+			fmt.Fprintln(w, "    Last Refresh: unknown")
+		} else {
+			fmt.Fprintf(w, "    Last Refresh: %s\n", st.LastRefresh.Format(time.RFC3339))
+		}
+
+		if st.Checked {
+			if st.Healthy {
+				fmt.Fprintln(w, "    Health: ok")
+			} else {
+				fmt.Fprintf(w, "    Health: error (%s)\n", st.CheckError)
+			}
+		}
+	}
+}
@@ -1,6 +1,9 @@
 // Package bridge provides cross-service functions that transfer data between
 // Google APIs server-side, avoiding the need to round-trip file content through
 // the LLM's context window.
+//
+// Empty account names in the parameter structs resolve to the auth manager's
+// default account.
 package bridge
 
 import (
@@ -11,8 +14,10 @@ import (
 	"io"
 
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	driveapi "google.golang.org/api/drive/v3"
 	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 )
 
 // Gmail scopes needed by bridge functions.
@@ -21,8 +26,15 @@ var GmailScopes = []string{gmailapi.MailGoogleComScope}
 // Drive scopes needed by bridge functions.
 var DriveScopes = []string{driveapi.DriveScope}
 
+// Read-only variants of GmailScopes and DriveScopes, used with
+// --readonly-scopes by the bridge functions that only read.
+var (
+	GmailReadonlyScopes = []string{gmailapi.GmailReadonlyScope}
+	DriveReadonlyScopes = []string{driveapi.DriveReadonlyScope}
+)
+
 func newGmailService(ctx context.Context, mgr *auth.Manager, account string) (*gmailapi.Service, error) {
-	opt, err := mgr.ClientOption(ctx, account, GmailScopes)
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), GmailScopes, GmailReadonlyScopes)
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +42,7 @@ func newGmailService(ctx context.Context, mgr *auth.Manager, account string) (*g
 }
 
 func newDriveService(ctx context.Context, mgr *auth.Manager, account string) (*driveapi.Service, error) {
-	opt, err := mgr.ClientOption(ctx, account, DriveScopes)
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), DriveScopes, DriveReadonlyScopes)
 	if err != nil {
 		return nil, err
 	}
@@ -59,18 +71,12 @@ type SaveAttachmentToDriveResult struct {
 // SaveAttachmentToDrive downloads a Gmail attachment and uploads it directly
 // to Google Drive without the data ever entering the LLM context window.
 func SaveAttachmentToDrive(ctx context.Context, mgr *auth.Manager, params SaveAttachmentToDriveParams) (*SaveAttachmentToDriveResult, error) {
-	if params.GmailAccount == "" {
-		return nil, fmt.Errorf("gmail account is required")
-	}
 	if params.MessageID == "" {
 		return nil, fmt.Errorf("message_id is required")
 	}
 	if params.AttachmentID == "" {
 		return nil, fmt.Errorf("attachment_id is required")
 	}
-	if params.DriveAccount == "" {
-		return nil, fmt.Errorf("drive account is required")
-	}
 	if params.FileName == "" {
 		return nil, fmt.Errorf("file_name is required")
 	}
@@ -81,9 +87,9 @@ func SaveAttachmentToDrive(ctx context.Context, mgr *auth.Manager, params SaveAt
 		return nil, fmt.Errorf("creating Gmail service: %w", err)
 	}
 
-	att, err := gmailSvc.Users.Messages.Attachments.Get("me", params.MessageID, params.AttachmentID).Do()
+	att, err := gmailSvc.Users.Messages.Attachments.Get("me", params.MessageID, params.AttachmentID).Context(ctx).Do()
 	if err != nil {
-		return nil, fmt.Errorf("getting attachment: %w", err)
+		return nil, gerrors.Wrap(err, "getting attachment")
 	}
 
 	data, err := base64.URLEncoding.DecodeString(att.Data)
@@ -104,10 +110,10 @@ func SaveAttachmentToDrive(ctx context.Context, mgr *auth.Manager, params SaveAt
 
 	created, err := driveSvc.Files.Create(file).
 		Media(bytes.NewReader(data)).
-		Fields("id,name,mimeType,size,webViewLink").
+		Fields("id,name,mimeType,size,webViewLink").Context(ctx).
 		Do()
 	if err != nil {
-		return nil, fmt.Errorf("uploading to Drive: %w", err)
+		return nil, gerrors.Wrap(err, "uploading to Drive")
 	}
 
 	return &SaveAttachmentToDriveResult{
@@ -136,9 +142,51 @@ type ReadDriveFileResult struct {
 // This is used by the attach_drive_file tool to attach Drive files to emails
 // without the data transiting through the LLM context window.
 func ReadDriveFile(ctx context.Context, mgr *auth.Manager, params ReadDriveFileParams) (*ReadDriveFileResult, error) {
-	if params.DriveAccount == "" {
-		return nil, fmt.Errorf("drive account is required")
+	file, err := OpenDriveFile(ctx, mgr, OpenDriveFileParams{DriveAccount: params.DriveAccount, FileID: params.FileID})
+	if err != nil {
+		return nil, err
 	}
+	defer file.Body.Close()
+
+	// Limit to 25MB (Gmail's practical attachment limit).
+	const maxSize = 25 * 1024 * 1024
+	data, err := io.ReadAll(io.LimitReader(file.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading file content: %w", err)
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("file exceeds 25 MB attachment limit (%s)", file.FileName)
+	}
+
+	return &ReadDriveFileResult{
+		Data:     data,
+		FileName: file.FileName,
+		MIMEType: file.MIMEType,
+	}, nil
+}
+
+// OpenDriveFileParams holds the parameters for OpenDriveFile.
+type OpenDriveFileParams struct {
+	DriveAccount string
+	FileID       string
+	// ExportMIMEType is the format Google Workspace files are exported to
+	// (default: plain text, CSV for Sheets, PNG for Drawings).
+	ExportMIMEType string
+}
+
+// OpenDriveFileResult holds the result of OpenDriveFile. The caller must
+// close Body.
+type OpenDriveFileResult struct {
+	Body     io.ReadCloser
+	FileName string
+	// MIMEType is the type of Body: the export type for Workspace files.
+	MIMEType string
+}
+
+// OpenDriveFile starts downloading a file from Google Drive, exporting
+// Google Workspace files. The content is streamed so large files can be
+// written to disk without being held in memory.
+func OpenDriveFile(ctx context.Context, mgr *auth.Manager, params OpenDriveFileParams) (*OpenDriveFileResult, error) {
 	if params.FileID == "" {
 		return nil, fmt.Errorf("file_id is required")
 	}
@@ -149,45 +197,28 @@ func ReadDriveFile(ctx context.Context, mgr *auth.Manager, params ReadDriveFileP
 	}
 
 	// Get metadata first.
-	file, err := driveSvc.Files.Get(params.FileID).Fields("id,name,mimeType,size").Do()
+	file, err := driveSvc.Files.Get(params.FileID).Fields("id,name,mimeType,size").Context(ctx).Do()
 	if err != nil {
-		return nil, fmt.Errorf("getting file metadata: %w", err)
+		return nil, gerrors.Wrap(err, "getting file metadata")
 	}
 
 	// Google Workspace files need export; regular files use download.
-	var body io.ReadCloser
 	if isGoogleWorkspaceFile(file.MimeType) {
-		exportMIME := defaultExportMIME(file.MimeType)
-		resp, err := driveSvc.Files.Export(params.FileID, exportMIME).Download()
-		if err != nil {
-			return nil, fmt.Errorf("exporting file: %w", err)
+		exportMIME := params.ExportMIMEType
+		if exportMIME == "" {
+			exportMIME = defaultExportMIME(file.MimeType)
 		}
-		body = resp.Body
-		file.MimeType = exportMIME
-	} else {
-		resp, err := driveSvc.Files.Get(params.FileID).Download()
+		resp, err := driveSvc.Files.Export(params.FileID, exportMIME).Context(ctx).Download()
 		if err != nil {
-			return nil, fmt.Errorf("downloading file: %w", err)
+			return nil, gerrors.Wrap(err, "exporting file")
 		}
-		body = resp.Body
+		return &OpenDriveFileResult{Body: resp.Body, FileName: file.Name, MIMEType: exportMIME}, nil
 	}
-	defer body.Close()
-
-	// Limit to 25MB (Gmail's practical attachment limit).
-	const maxSize = 25 * 1024 * 1024
-	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	resp, err := driveSvc.Files.Get(params.FileID).Context(ctx).Download()
 	if err != nil {
-		return nil, fmt.Errorf("reading file content: %w", err)
+		return nil, gerrors.Wrap(err, "downloading file")
 	}
-	if len(data) > maxSize {
-		return nil, fmt.Errorf("file exceeds 25 MB attachment limit (%s)", file.Name)
-	}
-
-	return &ReadDriveFileResult{
-		Data:     data,
-		FileName: file.Name,
-		MIMEType: file.MimeType,
-	}, nil
+	return &OpenDriveFileResult{Body: resp.Body, FileName: file.Name, MIMEType: file.MimeType}, nil
 }
 
 // GetDriveFileMetadataParams holds the parameters for GetDriveFileMetadata.
@@ -202,15 +233,15 @@ type GetDriveFileMetadataResult struct {
 	FileName    string
 	MIMEType    string
 	WebViewLink string
+	// Size is the file size in bytes, or 0 for Google Workspace files,
+	// which have no stored content.
+	Size int64
 }
 
 // GetDriveFileMetadata returns metadata for a Drive file without downloading
 // its content. This is used for Calendar event attachments where only a
 // reference (fileUrl, title, mimeType) is needed, not the actual file bytes.
 func GetDriveFileMetadata(ctx context.Context, mgr *auth.Manager, params GetDriveFileMetadataParams) (*GetDriveFileMetadataResult, error) {
-	if params.DriveAccount == "" {
-		return nil, fmt.Errorf("drive account is required")
-	}
 	if params.FileID == "" {
 		return nil, fmt.Errorf("file_id is required")
 	}
@@ -220,9 +251,9 @@ func GetDriveFileMetadata(ctx context.Context, mgr *auth.Manager, params GetDriv
 		return nil, fmt.Errorf("creating Drive service: %w", err)
 	}
 
-	file, err := driveSvc.Files.Get(params.FileID).Fields("id,name,mimeType,webViewLink").Do()
+	file, err := driveSvc.Files.Get(params.FileID).Fields("id,name,mimeType,size,webViewLink").Context(ctx).Do()
 	if err != nil {
-		return nil, fmt.Errorf("getting file metadata: %w", err)
+		return nil, gerrors.Wrap(err, "getting file metadata")
 	}
 
 	return &GetDriveFileMetadataResult{
@@ -230,9 +261,156 @@ func GetDriveFileMetadata(ctx context.Context, mgr *auth.Manager, params GetDriv
 		FileName:    file.Name,
 		MIMEType:    file.MimeType,
 		WebViewLink: file.WebViewLink,
+		Size:        file.Size,
+	}, nil
+}
+
+// CreateMeetingNotesDocParams holds the parameters for CreateMeetingNotesDoc.
+type CreateMeetingNotesDocParams struct {
+	DriveAccount string
+	Title        string
+	FolderID     string // Optional destination folder.
+}
+
+// CreateMeetingNotesDoc creates an empty Google Doc to hold meeting notes
+// and returns its metadata. Calendar uses this to fill the {{notes_link}}
+// description variable and attach the doc to the event.
+func CreateMeetingNotesDoc(ctx context.Context, mgr *auth.Manager, params CreateMeetingNotesDocParams) (*GetDriveFileMetadataResult, error) {
+	if params.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	driveSvc, err := newDriveService(ctx, mgr, params.DriveAccount)
+	if err != nil {
+		return nil, fmt.Errorf("creating Drive service: %w", err)
+	}
+
+	file := &driveapi.File{
+		Name:     params.Title,
+		MimeType: "application/vnd.google-apps.document",
+	}
+	if params.FolderID != "" {
+		file.Parents = []string{params.FolderID}
+	}
+
+	created, err := driveSvc.Files.Create(file).Fields("id,name,mimeType,webViewLink").Context(ctx).Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "creating notes document")
+	}
+
+	return &GetDriveFileMetadataResult{
+		FileID:      created.Id,
+		FileName:    created.Name,
+		MIMEType:    created.MimeType,
+		WebViewLink: created.WebViewLink,
 	}, nil
 }
 
+// CreateDriveFileParams holds the parameters for CreateDriveFile.
+type CreateDriveFileParams struct {
+	DriveAccount string
+	Name         string
+	MIMEType     string
+	FolderID     string // Optional destination folder.
+	Content      []byte
+}
+
+// CreateDriveFile uploads content generated server-side as a new Drive file
+// and returns its metadata.
+func CreateDriveFile(ctx context.Context, mgr *auth.Manager, params CreateDriveFileParams) (*GetDriveFileMetadataResult, error) {
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	driveSvc, err := newDriveService(ctx, mgr, params.DriveAccount)
+	if err != nil {
+		return nil, fmt.Errorf("creating Drive service: %w", err)
+	}
+
+	file := &driveapi.File{Name: params.Name, MimeType: params.MIMEType}
+	if params.FolderID != "" {
+		file.Parents = []string{params.FolderID}
+	}
+
+	created, err := driveSvc.Files.Create(file).
+		Media(bytes.NewReader(params.Content), googleapi.ContentType(params.MIMEType)).
+		Fields("id,name,mimeType,size,webViewLink").Context(ctx).
+		Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "uploading to Drive")
+	}
+
+	return &GetDriveFileMetadataResult{
+		FileID:      created.Id,
+		FileName:    created.Name,
+		MIMEType:    created.MimeType,
+		WebViewLink: created.WebViewLink,
+		Size:        created.Size,
+	}, nil
+}
+
+// CreateDriveFolderParams holds the parameters for CreateDriveFolder.
+type CreateDriveFolderParams struct {
+	DriveAccount string
+	Name         string
+	FolderID     string // Optional parent folder.
+}
+
+// CreateDriveFolder creates a Drive folder and returns its metadata.
+func CreateDriveFolder(ctx context.Context, mgr *auth.Manager, params CreateDriveFolderParams) (*GetDriveFileMetadataResult, error) {
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	driveSvc, err := newDriveService(ctx, mgr, params.DriveAccount)
+	if err != nil {
+		return nil, fmt.Errorf("creating Drive service: %w", err)
+	}
+
+	folder := &driveapi.File{
+		Name:     params.Name,
+		MimeType: "application/vnd.google-apps.folder",
+	}
+	if params.FolderID != "" {
+		folder.Parents = []string{params.FolderID}
+	}
+
+	created, err := driveSvc.Files.Create(folder).Fields("id,name,mimeType,webViewLink").Context(ctx).Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "creating folder")
+	}
+
+	return &GetDriveFileMetadataResult{
+		FileID:      created.Id,
+		FileName:    created.Name,
+		MIMEType:    created.MimeType,
+		WebViewLink: created.WebViewLink,
+	}, nil
+}
+
+// DeleteDriveFileParams holds the parameters for DeleteDriveFile.
+type DeleteDriveFileParams struct {
+	DriveAccount string
+	FileID       string
+}
+
+// DeleteDriveFile permanently deletes a Drive file. Calendar uses this to
+// remove a notes document again when the event it was made for could not
+// be saved.
+func DeleteDriveFile(ctx context.Context, mgr *auth.Manager, params DeleteDriveFileParams) error {
+	if params.FileID == "" {
+		return fmt.Errorf("file ID is required")
+	}
+
+	driveSvc, err := newDriveService(ctx, mgr, params.DriveAccount)
+	if err != nil {
+		return fmt.Errorf("creating Drive service: %w", err)
+	}
+
+	err = driveSvc.Files.Delete(params.FileID).SupportsAllDrives(true).Context(ctx).Do()
+	return gerrors.Wrap(err, "deleting file")
+}
+
 // isGoogleWorkspaceFile returns true if the MIME type is a Google Workspace type.
 func isGoogleWorkspaceFile(mimeType string) bool {
 	switch mimeType {
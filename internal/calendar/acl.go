@@ -7,6 +7,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/calendar/v3"
 )
@@ -14,7 +15,7 @@ import (
 // --- share_calendar ---
 
 type shareCalendarInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID to share (default: 'primary')"`
 	Type       string `json:"type" jsonschema:"Scope type: 'user', 'group', 'domain', or 'default' (public)"`
 	Value      string `json:"value,omitempty" jsonschema:"Email address (for user/group) or domain name (for domain). Omit for 'default' (public)."`
@@ -38,7 +39,10 @@ Roles:
   - "writer" — Edit events
   - "owner" — Full management access`,
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input shareCalendarInput) (*mcp.CallToolResult, any, error) {
 		// Validate type.
@@ -78,9 +82,9 @@ Roles:
 			},
 		}
 
-		created, err := svc.Acl.Insert(calendarID, rule).Do()
+		created, err := svc.Acl.Insert(calendarID, rule).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("sharing calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "sharing calendar")
 		}
 
 		return &mcp.CallToolResult{
@@ -95,7 +99,7 @@ Roles:
 // --- list_calendar_sharing ---
 
 type listCalendarSharingInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 }
 
@@ -104,7 +108,10 @@ func registerListCalendarSharing(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_calendar_sharing",
 		Description: "List all sharing rules (ACL) for a calendar. Shows who has access and their role.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listCalendarSharingInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -117,9 +124,9 @@ func registerListCalendarSharing(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		resp, err := svc.Acl.List(calendarID).Do()
+		resp, err := svc.Acl.List(calendarID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing calendar sharing: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing calendar sharing")
 		}
 
 		var sb strings.Builder
@@ -148,7 +155,7 @@ func registerListCalendarSharing(srv *server.Server, mgr *auth.Manager) {
 // --- get_acl_rule ---
 
 type getACLRuleInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	RuleID     string `json:"rule_id" jsonschema:"ACL rule ID (from list_calendar_sharing)"`
 }
@@ -158,7 +165,10 @@ func registerGetACLRule(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_acl_rule",
 		Description: "Get details of a specific calendar sharing rule (ACL entry) by ID.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getACLRuleInput) (*mcp.CallToolResult, any, error) {
 		if input.RuleID == "" {
@@ -175,9 +185,9 @@ func registerGetACLRule(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		rule, err := svc.Acl.Get(calendarID, input.RuleID).Do()
+		rule, err := svc.Acl.Get(calendarID, input.RuleID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting ACL rule: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting ACL rule")
 		}
 
 		scope := rule.Scope.Value
@@ -205,7 +215,7 @@ func registerGetACLRule(srv *server.Server, mgr *auth.Manager) {
 // --- update_acl_rule ---
 
 type updateACLRuleInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	RuleID     string `json:"rule_id" jsonschema:"ACL rule ID to update (from list_calendar_sharing)"`
 	Role       string `json:"role" jsonschema:"New access role: 'freeBusyReader', 'reader', 'writer', or 'owner'"`
@@ -216,7 +226,10 @@ func registerUpdateACLRule(srv *server.Server, mgr *auth.Manager) {
 		Name:        "update_acl_rule",
 		Description: "Update the role of an existing calendar sharing rule (ACL entry). Changes the access level for the user, group, or domain.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateACLRuleInput) (*mcp.CallToolResult, any, error) {
 		if input.RuleID == "" {
@@ -240,16 +253,16 @@ func registerUpdateACLRule(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// Fetch current rule to preserve scope.
-		current, err := svc.Acl.Get(calendarID, input.RuleID).Do()
+		current, err := svc.Acl.Get(calendarID, input.RuleID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting ACL rule: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting ACL rule")
 		}
 
 		current.Role = input.Role
 
-		updated, err := svc.Acl.Update(calendarID, input.RuleID, current).Do()
+		updated, err := svc.Acl.Update(calendarID, input.RuleID, current).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating ACL rule: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating ACL rule")
 		}
 
 		scope := updated.Scope.Value
@@ -269,7 +282,7 @@ func registerUpdateACLRule(srv *server.Server, mgr *auth.Manager) {
 // --- delete_acl_rule ---
 
 type deleteACLRuleInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	RuleID     string `json:"rule_id" jsonschema:"ACL rule ID to delete (from list_calendar_sharing)"`
 }
@@ -278,7 +291,12 @@ func registerDeleteACLRule(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_acl_rule",
 		Description: "Delete a calendar sharing rule (ACL entry), revoking access for the specified user, group, or domain.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteACLRuleInput) (*mcp.CallToolResult, any, error) {
 		if input.RuleID == "" {
 			return nil, nil, fmt.Errorf("rule_id is required")
@@ -294,8 +312,8 @@ func registerDeleteACLRule(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		if err := svc.Acl.Delete(calendarID, input.RuleID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting ACL rule: %w", err)
+		if err := svc.Acl.Delete(calendarID, input.RuleID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting ACL rule")
 		}
 
 		return &mcp.CallToolResult{
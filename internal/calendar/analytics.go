@@ -0,0 +1,309 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+)
+
+// oneOffSeriesKey groups non-recurring events in the series breakdown.
+const oneOffSeriesKey = ""
+
+// loadGroup aggregates meeting time for one series or organizer.
+type loadGroup struct {
+	Key   string
+	Label string
+	// Meetings is the number of event occurrences counted.
+	Meetings int
+	// Duration is the calendar time spent in the meetings.
+	Duration time.Duration
+	// PersonTime is Duration weighted by the number of attendees.
+	PersonTime time.Duration
+}
+
+// loadReport is the result of aggregating meeting load over a set of events.
+type loadReport struct {
+	Series     []loadGroup
+	Organizers []loadGroup
+	Total      loadGroup
+
+	SkippedAllDay   int
+	SkippedDeclined int
+}
+
+// meetingLoad aggregates timed, non-declined events by recurring series and
+// by organizer. Each group's person-time is its duration multiplied by the
+// number of attendees who haven't declined (at least 1). Groups are sorted
+// by person-time, largest first. Events with the same iCalUID and start
+// (the same meeting seen from several accounts) are counted once.
+func meetingLoad(events []*calendar.Event) loadReport {
+	var r loadReport
+	series := make(map[string]*loadGroup)
+	organizers := make(map[string]*loadGroup)
+	seen := make(map[string]bool)
+
+	for _, e := range events {
+		if e.Status == "cancelled" || e.Start == nil || e.End == nil {
+			continue
+		}
+		if e.Start.Date != "" {
+			r.SkippedAllDay++
+			continue
+		}
+		if selfDeclined(e) {
+			r.SkippedDeclined++
+			continue
+		}
+		start, err1 := time.Parse(time.RFC3339, e.Start.DateTime)
+		end, err2 := time.Parse(time.RFC3339, e.End.DateTime)
+		if err1 != nil || err2 != nil || !end.After(start) {
+			continue
+		}
+		if e.ICalUID != "" {
+			key := e.ICalUID + "@" + start.UTC().Format(time.RFC3339)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		d := end.Sub(start)
+		person := d * time.Duration(attendeeWeight(e))
+
+		seriesKey, seriesLabel := oneOffSeriesKey, "(one-off meetings)"
+		if e.RecurringEventId != "" {
+			seriesKey, seriesLabel = e.RecurringEventId, e.Summary
+			if seriesLabel == "" {
+				seriesLabel = "(no title)"
+			}
+		}
+		addLoad(series, seriesKey, seriesLabel, d, person)
+
+		organizer := "(unknown organizer)"
+		if e.Organizer != nil {
+			switch {
+			case e.Organizer.Email != "":
+				organizer = e.Organizer.Email
+			case e.Organizer.DisplayName != "":
+				organizer = e.Organizer.DisplayName
+			}
+		}
+		addLoad(organizers, strings.ToLower(organizer), organizer, d, person)
+
+		r.Total.Meetings++
+		r.Total.Duration += d
+		r.Total.PersonTime += person
+	}
+
+	r.Series = sortedLoadGroups(series)
+	r.Organizers = sortedLoadGroups(organizers)
+	return r
+}
+
+// selfDeclined reports whether the calendar owner declined the event.
+func selfDeclined(e *calendar.Event) bool {
+	for _, a := range e.Attendees {
+		if a.Self && a.ResponseStatus == "declined" {
+			return true
+		}
+	}
+	return false
+}
+
+// attendeeWeight counts the attendees who haven't declined. Events without
+// an attendee list count as 1 (just the calendar owner). Resources such as
+// meeting rooms are not people and aren't counted.
+func attendeeWeight(e *calendar.Event) int {
+	n := 0
+	for _, a := range e.Attendees {
+		if a.Resource || a.ResponseStatus == "declined" {
+			continue
+		}
+		n++
+	}
+	return max(n, 1)
+}
+
+func addLoad(groups map[string]*loadGroup, key, label string, d, person time.Duration) {
+	g, ok := groups[key]
+	if !ok {
+		g = &loadGroup{Key: key, Label: label}
+		groups[key] = g
+	}
+	g.Meetings++
+	g.Duration += d
+	g.PersonTime += person
+}
+
+func sortedLoadGroups(groups map[string]*loadGroup) []loadGroup {
+	out := make([]loadGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PersonTime != out[j].PersonTime {
+			return out[i].PersonTime > out[j].PersonTime
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// formatLoadReport renders the report with at most top rows per table.
+func formatLoadReport(r loadReport, top int) string {
+	var sb strings.Builder
+	if r.Total.Meetings == 0 {
+		sb.WriteString("No timed meetings found in the specified time range.\n")
+	} else {
+		fmt.Fprintf(&sb, "Totals: %d meetings, %s in meetings, %s person-time\n",
+			r.Total.Meetings, formatDuration(r.Total.Duration), formatDuration(r.Total.PersonTime))
+		writeLoadTable(&sb, "By recurring series", r.Series, top)
+		writeLoadTable(&sb, "By organizer", r.Organizers, top)
+	}
+	if r.SkippedAllDay > 0 || r.SkippedDeclined > 0 {
+		fmt.Fprintf(&sb, "\nExcluded: %d all-day, %d declined\n", r.SkippedAllDay, r.SkippedDeclined)
+	}
+	return sb.String()
+}
+
+func writeLoadTable(sb *strings.Builder, title string, groups []loadGroup, top int) {
+	fmt.Fprintf(sb, "\n%s (ranked by person-time):\n", title)
+	for i, g := range groups {
+		if i == top {
+			fmt.Fprintf(sb, "  ... and %d more\n", len(groups)-top)
+			break
+		}
+		fmt.Fprintf(sb, "  %d. %s — %d meetings, %s, %s person-time\n",
+			i+1, g.Label, g.Meetings, formatDuration(g.Duration), formatDuration(g.PersonTime))
+	}
+}
+
+// --- meeting_load_report ---
+
+type meetingLoadReportInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	TimeMin    string `json:"time_min,omitempty" jsonschema:"Start of the window in RFC3339 format (default: 30 days ago)"`
+	TimeMax    string `json:"time_max,omitempty" jsonschema:"End of the window in RFC3339 format (default: now)"`
+	Top        int    `json:"top,omitempty" jsonschema:"Rows per ranking table (default 10)"`
+}
+
+func registerMeetingLoadReport(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "meeting_load_report",
+		Description: `Report how much time meetings take in a time window, ranked by recurring series and by organizer.
+
+Person-time is meeting duration multiplied by the number of attendees who haven't declined, so large recurring meetings rank highest. All-day events and meetings you declined are excluded. Set account to 'all' for a report per account plus a combined view (meetings shared between accounts are counted once).`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input meetingLoadReportInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Strings(accounts)
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+		now := time.Now()
+		timeMin := input.TimeMin
+		if timeMin == "" {
+			timeMin = now.Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+		timeMax := input.TimeMax
+		if timeMax == "" {
+			timeMax = now.Format(time.RFC3339)
+		}
+		top := input.Top
+		if top <= 0 {
+			top = 10
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Meeting load from %s to %s\n\n", timeMin, timeMax)
+		multiAccount := len(accounts) > 1
+		var all []*calendar.Event
+
+		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
+			svc, err := newService(ctx, mgr, account)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+			}
+
+			events, err := listAllEvents(ctx, svc, calendarID, timeMin, timeMax)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing events: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, gerrors.Wrap(err, "listing events")
+			}
+			all = append(all, events...)
+
+			if multiAccount {
+				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+			}
+			sb.WriteString(formatLoadReport(meetingLoad(events), top))
+			sb.WriteString("\n")
+		}
+
+		if multiAccount {
+			sb.WriteString("=== Combined ===\n")
+			sb.WriteString(formatLoadReport(meetingLoad(all), top))
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// listAllEvents lists every event occurrence in a time window, following
+// pages.
+func listAllEvents(ctx context.Context, svc *calendar.Service, calendarID, timeMin, timeMax string) ([]*calendar.Event, error) {
+	var events []*calendar.Event
+	pageToken := ""
+	for {
+		call := svc.Events.List(calendarID).
+			TimeMin(timeMin).
+			TimeMax(timeMax).
+			SingleEvents(true).
+			MaxResults(2500).
+			Fields("nextPageToken,items(id,iCalUID,status,summary,recurringEventId,start,end,organizer,attendees(email,self,resource,responseStatus))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, resp.Items...)
+		if resp.NextPageToken == "" {
+			return events, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
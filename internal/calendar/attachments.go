@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/bridge"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+)
+
+// --- get_event_attachment ---
+
+type getEventAttachmentInput struct {
+	Account        string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID     string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	EventID        string `json:"event_id" jsonschema:"Event ID whose attachment to fetch"`
+	FileID         string `json:"file_id,omitempty" jsonschema:"Drive file ID of the attachment (from get_event). Optional when the event has exactly one attachment."`
+	DriveAccount   string `json:"drive_account,omitempty" jsonschema:"Drive account to download the file with (default: same as account)"`
+	ExportMIMEType string `json:"export_mime_type,omitempty" jsonschema:"Export format for Google Docs/Sheets/Slides attachments (default: plain text, CSV for Sheets)"`
+	SaveTo         string `json:"save_to,omitempty" jsonschema:"Save the file to this local path (relative to an allowed directory) instead of returning its content. Requires --allow-write-dir."`
+}
+
+// maxAttachmentContent is how much of an attachment get_event_attachment
+// returns in the conversation.
+const maxAttachmentContent = 512 * 1024
+
+func registerGetEventAttachment(srv *server.Server, mgr *auth.Manager) {
+	desc := `Fetch the content of a file attached to a calendar event, such as a meeting agenda doc.
+
+The event's attachment is downloaded from Google Drive with drive_account. By default the content is returned in the conversation (text directly, base64 for binary, truncated at 512 KB); set save_to to write it to a local directory instead. Attachments that are not Drive files are returned as their URL.` + srv.WriteDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "get_event_attachment",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input getEventAttachmentInput) (*mcp.CallToolResult, any, error) {
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+
+		event, err := svc.Events.Get(calendarID, input.EventID).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "getting event")
+		}
+		att, err := findEventAttachment(event, input.FileID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if att.FileId == "" {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Attachment %s is not a Google Drive file and cannot be downloaded here.\n\nURL: %s", attachmentTitle(att), att.FileUrl)},
+				},
+			}, nil, nil
+		}
+
+		driveAccount := input.DriveAccount
+		if driveAccount == "" {
+			driveAccount = input.Account
+		}
+		file, err := bridge.OpenDriveFile(ctx, mgr, bridge.OpenDriveFileParams{
+			DriveAccount:   driveAccount,
+			FileID:         att.FileId,
+			ExportMIMEType: input.ExportMIMEType,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Body.Close()
+
+		if input.SaveTo != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
+			}
+
+			// Stream to disk so large files aren't held in memory.
+			f, dir, err := lfs.CreateFile(input.SaveTo)
+			if err != nil {
+				return nil, nil, fmt.Errorf("saving file: %w", err)
+			}
+			defer f.Close()
+			n, err := io.Copy(f, file.Body)
+			if err == nil {
+				err = f.Commit()
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("saving file to %s/%s (stopped after %d bytes; nothing was saved): %w", dir, input.SaveTo, n, err)
+			}
+
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Attachment saved to local disk.\n\nName: %s\nMIME Type: %s\nSize: %d bytes\nSaved to: %s/%s",
+						file.FileName, file.MIMEType, n, dir, input.SaveTo)},
+				},
+			}, nil, nil
+		}
+
+		data, err := io.ReadAll(io.LimitReader(file.Body, maxAttachmentContent+1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading file content: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatAttachmentContent(file.FileName, file.MIMEType, data)},
+			},
+		}, nil, nil
+	}, server.LocalWriteParams("save_to"))
+}
+
+// findEventAttachment returns the attachment of event with the given Drive
+// file ID, or its only attachment when fileID is empty.
+func findEventAttachment(event *calendar.Event, fileID string) (*calendar.EventAttachment, error) {
+	if len(event.Attachments) == 0 {
+		return nil, fmt.Errorf("event %s has no attachments", event.Id)
+	}
+	if fileID == "" {
+		if len(event.Attachments) == 1 {
+			return event.Attachments[0], nil
+		}
+		return nil, fmt.Errorf("event %s has %d attachments; set file_id to one of: %s", event.Id, len(event.Attachments), describeEventAttachments(event.Attachments))
+	}
+	for _, att := range event.Attachments {
+		if att.FileId == fileID {
+			return att, nil
+		}
+	}
+	return nil, fmt.Errorf("event %s has no attachment with file ID %s (attachments: %s)", event.Id, fileID, describeEventAttachments(event.Attachments))
+}
+
+// describeEventAttachments lists attachments as "title (file ID)" for
+// error messages.
+func describeEventAttachments(atts []*calendar.EventAttachment) string {
+	parts := make([]string, len(atts))
+	for i, att := range atts {
+		id := att.FileId
+		if id == "" {
+			id = "not a Drive file"
+		}
+		parts[i] = fmt.Sprintf("%s (%s)", attachmentTitle(att), id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// attachmentTitle returns an attachment's title, or its URL if untitled.
+func attachmentTitle(att *calendar.EventAttachment) string {
+	if att.Title != "" {
+		return att.Title
+	}
+	return att.FileUrl
+}
+
+// formatAttachmentContent formats downloaded attachment content, which may
+// hold one byte more than maxAttachmentContent to signal truncation. Text
+// is returned as is and anything else as base64.
+func formatAttachmentContent(name, mimeType string, data []byte) string {
+	truncated := len(data) > maxAttachmentContent
+	if truncated {
+		data = data[:maxAttachmentContent]
+	}
+	suffix := ""
+	if truncated {
+		suffix = "\n\n[Content truncated at 512 KB]"
+	}
+	// Truncation can split a multi-byte character; ignore a partial one at
+	// the end when deciding whether this is text.
+	text := data
+	if truncated {
+		for i := 0; i < utf8.UTFMax && len(text) > 0 && !utf8.Valid(text); i++ {
+			text = text[:len(text)-1]
+		}
+	}
+	if utf8.Valid(text) {
+		return fmt.Sprintf("File: %s (%s)\n\n%s%s", name, mimeType, text, suffix)
+	}
+	return fmt.Sprintf("File: %s (%s, base64)\n\n%s%s", name, mimeType, base64.StdEncoding.EncodeToString(data), suffix)
+}
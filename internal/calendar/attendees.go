@@ -0,0 +1,85 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"google.golang.org/api/calendar/v3"
+)
+
+// attendee is an attendee to add to an event. In tool input it is either a
+// plain email address, as create_event has always accepted, or an object
+// that can also mark the attendee optional or a resource such as a room.
+type attendee struct {
+	Email    string `json:"email"`
+	Optional bool   `json:"optional,omitempty"`
+	Resource bool   `json:"resource,omitempty"`
+}
+
+// UnmarshalJSON accepts an email address string or an attendee object.
+func (a *attendee) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '"' {
+		*a = attendee{}
+		return json.Unmarshal(data, &a.Email)
+	}
+	type plain attendee // without the UnmarshalJSON method
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("attendee must be an email address or an object with email, optional and resource: %w", err)
+	}
+	*a = attendee(p)
+	return nil
+}
+
+// attendeeSchema describes both attendee shapes. jsonschema.For would only
+// describe the object, rejecting the plain email addresses existing callers
+// send.
+var attendeeSchema = &jsonschema.Schema{
+	AnyOf: []*jsonschema.Schema{
+		{Type: "string", Description: "Attendee email address"},
+		{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"email":    {Type: "string", Description: "Attendee email address, or a room or other resource calendar's email (e.g. 'c_1234@resource.calendar.google.com')"},
+				"optional": {Type: "boolean", Description: "Attendance is optional (default: false)"},
+				"resource": {Type: "boolean", Description: "The attendee is a resource such as a conference room; check its availability with find_available_room (default: false)"},
+			},
+			Required:             []string{"email"},
+			AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+		},
+	},
+}
+
+// inputSchema returns the input schema of a tool taking In, with attendee
+// fields described by attendeeSchema. It panics if the schema can't be
+// inferred, as mcp.AddTool does.
+func inputSchema[In any]() *jsonschema.Schema {
+	schema, err := jsonschema.For[In](&jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[attendee](): attendeeSchema,
+		},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("inferring input schema: %v", err))
+	}
+	return schema
+}
+
+// eventAttendees converts attendee input to API attendees.
+func eventAttendees(in []attendee) ([]*calendar.EventAttendee, error) {
+	var out []*calendar.EventAttendee
+	for i, a := range in {
+		if a.Email == "" {
+			return nil, fmt.Errorf("attendees[%d]: email is required", i)
+		}
+		out = append(out, &calendar.EventAttendee{
+			Email:    a.Email,
+			Optional: a.Optional,
+			Resource: a.Resource,
+		})
+	}
+	return out, nil
+}
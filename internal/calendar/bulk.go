@@ -0,0 +1,251 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+)
+
+// maxBulkEvents is the maximum number of events create_events_bulk creates
+// in one call.
+const maxBulkEvents = 50
+
+// --- create_events_bulk ---
+
+type createEventsBulkInput struct {
+	Account         string             `json:"account,omitempty" jsonschema:"Account name for events that don't set their own (optional when only one account is configured or a default is set)"`
+	CalendarID      string             `json:"calendar_id,omitempty" jsonschema:"Calendar ID for events that don't set their own (default: 'primary')"`
+	Events          []createEventInput `json:"events" jsonschema:"Events to create (at most 50), each with the fields create_event accepts"`
+	ContinueOnError bool               `json:"continue_on_error,omitempty" jsonschema:"Create the valid events even if some are invalid, and keep going after a failed insert (default: false — nothing is created if any event is invalid, and the call stops at the first failed insert)"`
+}
+
+// bulkEventResult is the outcome for one entry of create_events_bulk.
+type bulkEventResult struct {
+	Event     *calendar.Event // created or duplicate event; nil on failure
+	Duplicate bool
+	Conflicts []string // overlapping events, with check_conflicts
+	Note      string   // description truncation, if any
+	Err       error
+	Skipped   bool // not attempted after an earlier failure
+}
+
+func registerCreateEventsBulk(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "create_events_bulk",
+		InputSchema: inputSchema[createEventsBulkInput](),
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: `Create up to 50 calendar events in one call, e.g. to import a schedule or plan a week. Each entry takes the same fields as create_event; account and calendar_id default to the top-level values.
+
+All entries are validated before anything is created, including that their accounts are available and their color_id exists: if any is invalid, the call fails listing every problem and nothing is created. Events are then inserted one at a time, in order; the call stops at the first failed insert. Set continue_on_error to create the valid entries anyway and keep going after failures.
+
+The result lists every entry with its Event ID or error. Set idempotency_key on entries to make a retry of the whole call skip the events already created.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input createEventsBulkInput) (*mcp.CallToolResult, any, error) {
+		if len(input.Events) == 0 {
+			return nil, nil, fmt.Errorf("events is required")
+		}
+		if len(input.Events) > maxBulkEvents {
+			return nil, nil, fmt.Errorf("too many events: %d (max %d per call)", len(input.Events), maxBulkEvents)
+		}
+
+		entries := bulkEntries(input)
+		events, errs := buildBulkEvents(entries)
+		services, errs := checkBulkAccounts(ctx, mgr, entries, events, errs)
+		if !input.ContinueOnError && len(errs) > 0 {
+			return nil, nil, fmt.Errorf("%s\n\nNothing was created. Fix these entries, or set continue_on_error to create the valid ones", errors.Join(errs...))
+		}
+
+		results := make([]bulkEventResult, len(entries))
+		stopped := false
+		for i, entry := range entries {
+			if events[i] == nil {
+				results[i].Err = fmt.Errorf("invalid: %w", bulkEntryError(errs, i))
+				continue
+			}
+			if stopped || ctx.Err() != nil {
+				results[i].Skipped = true
+				continue
+			}
+			created, duplicate, conflicts, note, err := insertEvent(ctx, mgr, services[entry.Account], entry.CalendarID, entry, events[i])
+			results[i] = bulkEventResult{Event: created, Duplicate: duplicate, Conflicts: conflicts, Note: note, Err: err}
+			if err != nil && !input.ContinueOnError {
+				stopped = true
+			}
+			server.Progress(ctx, req, i+1, len(entries), fmt.Sprintf("Processed %d of %d events", i+1, len(entries)))
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatBulkResults(entries, results)},
+			},
+		}, nil, nil
+	}, server.Timeout(server.BulkToolTimeout))
+}
+
+// bulkEntries returns the events of input with the top-level account and
+// calendar ID filled in.
+func bulkEntries(input createEventsBulkInput) []createEventInput {
+	entries := make([]createEventInput, len(input.Events))
+	for i, e := range input.Events {
+		if e.Account == "" {
+			e.Account = input.Account
+		}
+		if e.CalendarID == "" {
+			e.CalendarID = input.CalendarID
+		}
+		if e.CalendarID == "" {
+			e.CalendarID = "primary"
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+// bulkEntryErr is a validation error of one create_events_bulk entry.
+type bulkEntryErr struct {
+	index int
+	err   error
+}
+
+func (e *bulkEntryErr) Error() string { return fmt.Sprintf("events[%d]: %v", e.index, e.err) }
+func (e *bulkEntryErr) Unwrap() error { return e.err }
+
+// buildBulkEvents builds every entry with buildEvent. The events of invalid
+// entries are nil, and errs has one *bulkEntryErr for each of them.
+func buildBulkEvents(entries []createEventInput) (events []*calendar.Event, errs []error) {
+	events = make([]*calendar.Event, len(entries))
+	for i, entry := range entries {
+		event, err := buildEvent(entry)
+		if err != nil {
+			errs = append(errs, &bulkEntryErr{index: i, err: err})
+			continue
+		}
+		events[i] = event
+	}
+	return events, errs
+}
+
+// checkBulkAccounts creates the Calendar service of every account the valid
+// entries use and checks their color_id against that account's palette, so
+// that these problems are found before the first insert. Entries that fail
+// get a *bulkEntryErr in the returned errs, sorted by entry, and their
+// event is set to nil.
+func checkBulkAccounts(ctx context.Context, mgr *auth.Manager, entries []createEventInput, events []*calendar.Event, errs []error) (map[string]*calendar.Service, []error) {
+	services := make(map[string]*calendar.Service)
+	serviceErrs := make(map[string]error)
+	colorErrs := make(map[[2]string]error)
+	for i, entry := range entries {
+		if events[i] == nil {
+			continue
+		}
+		svc, ok := services[entry.Account]
+		err, failed := serviceErrs[entry.Account]
+		if !ok && !failed {
+			svc, err = newService(ctx, mgr, entry.Account)
+			if err != nil {
+				err = fmt.Errorf("creating Calendar service: %w", err)
+				serviceErrs[entry.Account] = err
+			} else {
+				services[entry.Account] = svc
+			}
+		}
+		if err == nil && entry.ColorID != "" {
+			key := [2]string{entry.Account, entry.ColorID}
+			var checked bool
+			if err, checked = colorErrs[key]; !checked {
+				err = validateEventColor(ctx, svc, entry.ColorID)
+				colorErrs[key] = err
+			}
+		}
+		if err != nil {
+			errs = append(errs, &bulkEntryErr{index: i, err: err})
+			events[i] = nil
+		}
+	}
+	slices.SortStableFunc(errs, func(a, b error) int {
+		return a.(*bulkEntryErr).index - b.(*bulkEntryErr).index
+	})
+	return services, errs
+}
+
+// bulkEntryError returns the validation error of entry i.
+func bulkEntryError(errs []error, i int) error {
+	for _, err := range errs {
+		if e, ok := err.(*bulkEntryErr); ok && e.index == i {
+			return e.err
+		}
+	}
+	return nil
+}
+
+// formatBulkResults formats the result of create_events_bulk: a summary
+// line, then one entry per event in input order.
+func formatBulkResults(entries []createEventInput, results []bulkEventResult) string {
+	var created, duplicates, failed, skipped int
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Err != nil:
+			failed++
+		case r.Duplicate:
+			duplicates++
+		default:
+			created++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Created %d of %d events", created, len(entries))
+	var notes []string
+	if duplicates > 0 {
+		notes = append(notes, fmt.Sprintf("%d already existed", duplicates))
+	}
+	if failed > 0 {
+		notes = append(notes, fmt.Sprintf("%d failed", failed))
+	}
+	if skipped > 0 {
+		notes = append(notes, fmt.Sprintf("%d not attempted", skipped))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(&sb, " (%s)", strings.Join(notes, ", "))
+	}
+	sb.WriteString(".\n")
+
+	for i, entry := range entries {
+		r := results[i]
+		fmt.Fprintf(&sb, "\n%d. %s (%s)\n", i+1, entry.Summary, entry.StartTime)
+		switch {
+		case r.Skipped:
+			sb.WriteString("   Not attempted\n")
+		case r.Err != nil:
+			fmt.Fprintf(&sb, "   Error: %v\n", r.Err)
+		case r.Duplicate:
+			sb.WriteString("   Duplicate detected, not created\n")
+			fmt.Fprintf(&sb, "   Event ID: %s\n", r.Event.Id)
+		default:
+			fmt.Fprintf(&sb, "   Event ID: %s\n", r.Event.Id)
+			if len(r.Conflicts) > 0 {
+				fmt.Fprintf(&sb, "   Warning: conflicts with %s\n", strings.Join(r.Conflicts, "; "))
+			}
+			if r.Note != "" {
+				fmt.Fprintf(&sb, "   %s\n", strings.TrimSpace(r.Note))
+			}
+		}
+	}
+	if skipped > 0 {
+		sb.WriteString("\nThe call stopped at the first failed insert. Fix that event and call again with it and the entries not attempted, or set continue_on_error.\n")
+	}
+	return sb.String()
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/calendar/v3"
 )
@@ -14,7 +15,7 @@ import (
 // --- list_calendars ---
 
 type listCalendarsInput struct {
-	Account string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
 }
 
 func registerListCalendars(srv *server.Server, mgr *auth.Manager) {
@@ -22,10 +23,13 @@ func registerListCalendars(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_calendars",
 		Description: "List all calendars accessible by the account. Set account to 'all' to list calendars from all accounts. Returns calendar IDs and names.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listCalendarsInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -34,6 +38,9 @@ func registerListCalendars(srv *server.Server, mgr *auth.Manager) {
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
 				if multiAccount {
@@ -43,13 +50,13 @@ func registerListCalendars(srv *server.Server, mgr *auth.Manager) {
 				return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
 			}
 
-			resp, err := svc.CalendarList.List().Do()
+			resp, err := svc.CalendarList.List().Context(ctx).Do()
 			if err != nil {
 				if multiAccount {
 					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing calendars: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing calendars: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing calendars")
 			}
 
 			if multiAccount {
@@ -80,7 +87,7 @@ func registerListCalendars(srv *server.Server, mgr *auth.Manager) {
 // --- create_calendar ---
 
 type createCalendarInput struct {
-	Account     string `json:"account" jsonschema:"Account name"`
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Summary     string `json:"summary" jsonschema:"Calendar name/title"`
 	Description string `json:"description,omitempty" jsonschema:"Calendar description"`
 	TimeZone    string `json:"time_zone,omitempty" jsonschema:"IANA timezone (e.g. 'America/New_York'). Defaults to account timezone."`
@@ -92,7 +99,10 @@ func registerCreateCalendar(srv *server.Server, mgr *auth.Manager) {
 		Name:        "create_calendar",
 		Description: "Create a new Google Calendar with a given name, description, and timezone.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createCalendarInput) (*mcp.CallToolResult, any, error) {
 		if input.Summary == "" {
@@ -111,9 +121,9 @@ func registerCreateCalendar(srv *server.Server, mgr *auth.Manager) {
 			Location:    input.Location,
 		}
 
-		created, err := svc.Calendars.Insert(cal).Do()
+		created, err := svc.Calendars.Insert(cal).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "creating calendar")
 		}
 
 		var sb strings.Builder
@@ -141,7 +151,7 @@ func registerCreateCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- delete_calendar ---
 
 type deleteCalendarInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id" jsonschema:"Calendar ID to delete"`
 }
 
@@ -149,7 +159,12 @@ func registerDeleteCalendar(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_calendar",
 		Description: "Delete a secondary calendar. The primary calendar cannot be deleted. This action is permanent.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteCalendarInput) (*mcp.CallToolResult, any, error) {
 		if input.CalendarID == "" {
 			return nil, nil, fmt.Errorf("calendar_id is required")
@@ -160,8 +175,8 @@ func registerDeleteCalendar(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
 		}
 
-		if err := svc.Calendars.Delete(input.CalendarID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting calendar: %w", err)
+		if err := svc.Calendars.Delete(input.CalendarID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting calendar")
 		}
 
 		return &mcp.CallToolResult{
@@ -175,7 +190,7 @@ func registerDeleteCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- get_calendar ---
 
 type getCalendarInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 }
 
@@ -184,7 +199,10 @@ func registerGetCalendar(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_calendar",
 		Description: "Get details of a specific calendar including name, description, timezone, and location.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getCalendarInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -197,9 +215,9 @@ func registerGetCalendar(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		cal, err := svc.Calendars.Get(calendarID).Do()
+		cal, err := svc.Calendars.Get(calendarID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting calendar")
 		}
 
 		var sb strings.Builder
@@ -229,7 +247,7 @@ func registerGetCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- update_calendar ---
 
 type updateCalendarInput struct {
-	Account     string `json:"account" jsonschema:"Account name"`
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID  string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	Summary     string `json:"summary,omitempty" jsonschema:"New calendar name (leave empty to keep current)"`
 	Description string `json:"description,omitempty" jsonschema:"New calendar description (leave empty to keep current)"`
@@ -242,7 +260,10 @@ func registerUpdateCalendar(srv *server.Server, mgr *auth.Manager) {
 		Name:        "update_calendar",
 		Description: "Update a calendar's name, description, timezone, or location. Only specified fields are changed.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateCalendarInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -256,9 +277,9 @@ func registerUpdateCalendar(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// Fetch current calendar to merge updates.
-		cal, err := svc.Calendars.Get(calendarID).Do()
+		cal, err := svc.Calendars.Get(calendarID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting calendar")
 		}
 
 		if input.Summary != "" {
@@ -274,9 +295,9 @@ func registerUpdateCalendar(srv *server.Server, mgr *auth.Manager) {
 			cal.Location = input.Location
 		}
 
-		updated, err := svc.Calendars.Update(calendarID, cal).Do()
+		updated, err := svc.Calendars.Update(calendarID, cal).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating calendar")
 		}
 
 		var sb strings.Builder
@@ -304,7 +325,7 @@ func registerUpdateCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- get_calendar_list_entry ---
 
 type getCalendarListEntryInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id" jsonschema:"Calendar ID to get details for"`
 }
 
@@ -313,7 +334,10 @@ func registerGetCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_calendar_list_entry",
 		Description: "Get detailed info about a specific calendar in the user's calendar list, including color, notifications, access role, and visibility settings.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getCalendarListEntryInput) (*mcp.CallToolResult, any, error) {
 		if input.CalendarID == "" {
@@ -325,9 +349,9 @@ func registerGetCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
 		}
 
-		entry, err := svc.CalendarList.Get(input.CalendarID).Do()
+		entry, err := svc.CalendarList.Get(input.CalendarID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting calendar list entry: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting calendar list entry")
 		}
 
 		var sb strings.Builder
@@ -391,7 +415,7 @@ func registerGetCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 // --- subscribe_calendar ---
 
 type subscribeCalendarInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id" jsonschema:"Calendar ID to subscribe to (e.g. a public calendar or one shared with you)"`
 }
 
@@ -400,7 +424,10 @@ func registerSubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 		Name:        "subscribe_calendar",
 		Description: "Subscribe to an existing calendar by adding it to the user's calendar list. Use this for public calendars or calendars that have been shared with you.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input subscribeCalendarInput) (*mcp.CallToolResult, any, error) {
 		if input.CalendarID == "" {
@@ -414,9 +441,9 @@ func registerSubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 
 		entry, err := svc.CalendarList.Insert(&calendar.CalendarListEntry{
 			Id: input.CalendarID,
-		}).Do()
+		}).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("subscribing to calendar: %w", err)
+			return nil, nil, gerrors.Wrap(err, "subscribing to calendar")
 		}
 
 		var sb strings.Builder
@@ -436,7 +463,7 @@ func registerSubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- unsubscribe_calendar ---
 
 type unsubscribeCalendarInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id" jsonschema:"Calendar ID to unsubscribe from"`
 }
 
@@ -444,7 +471,12 @@ func registerUnsubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "unsubscribe_calendar",
 		Description: "Unsubscribe from a calendar by removing it from the user's calendar list. The calendar itself is not deleted — only removed from your list.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input unsubscribeCalendarInput) (*mcp.CallToolResult, any, error) {
 		if input.CalendarID == "" {
 			return nil, nil, fmt.Errorf("calendar_id is required")
@@ -455,8 +487,8 @@ func registerUnsubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
 		}
 
-		if err := svc.CalendarList.Delete(input.CalendarID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("unsubscribing from calendar: %w", err)
+		if err := svc.CalendarList.Delete(input.CalendarID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "unsubscribing from calendar")
 		}
 
 		return &mcp.CallToolResult{
@@ -470,7 +502,7 @@ func registerUnsubscribeCalendar(srv *server.Server, mgr *auth.Manager) {
 // --- update_calendar_list_entry ---
 
 type updateCalendarListEntryInput struct {
-	Account         string `json:"account" jsonschema:"Account name"`
+	Account         string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID      string `json:"calendar_id" jsonschema:"Calendar ID to update"`
 	SummaryOverride string `json:"summary_override,omitempty" jsonschema:"Custom display name for the calendar (leave empty to keep current)"`
 	ColorID         string `json:"color_id,omitempty" jsonschema:"Color ID from get_colors (leave empty to keep current)"`
@@ -483,7 +515,10 @@ func registerUpdateCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 		Name:        "update_calendar_list_entry",
 		Description: "Update a calendar's display settings in the user's calendar list: custom name, color, visibility, and selection state. Only specified fields are changed.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateCalendarListEntryInput) (*mcp.CallToolResult, any, error) {
 		if input.CalendarID == "" {
@@ -496,9 +531,9 @@ func registerUpdateCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// Fetch current entry to merge updates.
-		entry, err := svc.CalendarList.Get(input.CalendarID).Do()
+		entry, err := svc.CalendarList.Get(input.CalendarID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting calendar list entry: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting calendar list entry")
 		}
 
 		if input.SummaryOverride != "" {
@@ -514,9 +549,9 @@ func registerUpdateCalendarListEntry(srv *server.Server, mgr *auth.Manager) {
 			entry.Selected = *input.Selected
 		}
 
-		updated, err := svc.CalendarList.Update(input.CalendarID, entry).Do()
+		updated, err := svc.CalendarList.Update(input.CalendarID, entry).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating calendar list entry: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating calendar list entry")
 		}
 
 		var sb strings.Builder
@@ -8,13 +8,14 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 )
 
 // --- get_colors ---
 
 type getColorsInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerGetColors(srv *server.Server, mgr *auth.Manager) {
@@ -22,7 +23,10 @@ func registerGetColors(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_colors",
 		Description: "Get the available color palette for calendars and events. Returns color IDs with their background and foreground hex values. Use these IDs when setting colors on calendars or events.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getColorsInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -30,9 +34,9 @@ func registerGetColors(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
 		}
 
-		colors, err := svc.Colors.Get().Do()
+		colors, err := svc.Colors.Get().Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting colors: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting colors")
 		}
 
 		var sb strings.Builder
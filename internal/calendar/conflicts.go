@@ -0,0 +1,225 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"google.golang.org/api/calendar/v3"
+)
+
+// conflictCheckInput holds the conflict check options of create_event and
+// update_event.
+type conflictCheckInput struct {
+	CheckConflicts bool `json:"check_conflicts,omitempty" jsonschema:"Before saving, look for busy events on the calendar that overlap the event and warn about them in the result (default: false)"`
+	FailOnConflict bool `json:"fail_on_conflict,omitempty" jsonschema:"Check for conflicts as check_conflicts does, but don't save the event if there are any and list them instead (default: false)"`
+}
+
+// enabled reports whether the input asks for a conflict check.
+func (in conflictCheckInput) enabled() bool {
+	return in.CheckConflicts || in.FailOnConflict
+}
+
+// conflictFields is the Fields mask for the events scanned by the conflict
+// check.
+const conflictFields = "nextPageToken,timeZone,items(id,recurringEventId,status,summary,start,end,transparency,eventType,attendees(self,responseStatus))"
+
+// maxConflictScan is the number of events the conflict check scans before
+// it stops.
+const maxConflictScan = 2500
+
+// conflictError is returned by create_event and update_event with
+// fail_on_conflict when the event overlaps others, which it lists as
+// described by describeConflict.
+type conflictError struct {
+	conflicts []string
+}
+
+func (e *conflictError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "the event conflicts with %d other events, so it was not saved:\n", len(e.conflicts))
+	for _, c := range e.conflicts {
+		fmt.Fprintf(&sb, "  - %s\n", c)
+	}
+	sb.WriteString("\nPick another time, or leave out fail_on_conflict to save it anyway")
+	return sb.String()
+}
+
+// checkConflicts runs the conflict check requested by in for event, to be
+// saved on calendarID, and returns the conflicts described by
+// describeConflict. With fail_on_conflict, conflicts are returned as a
+// *conflictError instead. excludeID is the event being updated, if any.
+func checkConflicts(ctx context.Context, svc *calendar.Service, calendarID string, event *calendar.Event, excludeID string, in conflictCheckInput) ([]string, error) {
+	if !in.enabled() {
+		return nil, nil
+	}
+	conflicts, loc, err := eventConflicts(ctx, svc, calendarID, event, excludeID)
+	if err != nil {
+		return nil, err
+	}
+	var descs []string
+	for _, c := range conflicts {
+		descs = append(descs, describeConflict(c, loc))
+	}
+	if in.FailOnConflict && len(descs) > 0 {
+		return nil, &conflictError{conflicts: descs}
+	}
+	return descs, nil
+}
+
+// eventConflicts returns the busy events on calendarID that overlap event,
+// ignoring the event excludeID (the one being updated) and its instances,
+// with the calendar's timezone for formatting them. The calendar is listed
+// with a day of margin on both sides, since all-day events only have an
+// interval in the calendar's timezone, which the listing reports.
+func eventConflicts(ctx context.Context, svc *calendar.Service, calendarID string, event *calendar.Event, excludeID string) ([]*calendar.Event, *time.Location, error) {
+	start, end, ok := eventSpan(event, time.UTC)
+	if !ok {
+		return nil, nil, fmt.Errorf("checking conflicts: can't read the event's start and end times")
+	}
+
+	var events []*calendar.Event
+	loc := time.UTC
+	pageToken := ""
+	for len(events) < maxConflictScan {
+		call := svc.Events.List(calendarID).
+			TimeMin(start.Add(-24 * time.Hour).Format(time.RFC3339)).
+			TimeMax(end.Add(24 * time.Hour).Format(time.RFC3339)).
+			SingleEvents(true).
+			MaxResults(250).
+			Fields(conflictFields).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "checking conflicts")
+		}
+		if l, err := time.LoadLocation(resp.TimeZone); err == nil && resp.TimeZone != "" {
+			loc = l
+		}
+		events = append(events, resp.Items...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	start, end, _ = eventSpan(event, loc)
+	return findConflicts(events, start, end, excludeID, loc), loc, nil
+}
+
+// findConflicts returns the events that overlap [start, end) and keep the
+// time busy. Cancelled events, transparent (free) events, working-location
+// events, events the calendar owner declined, and the event excludeID and
+// its instances are skipped. All-day events span their dates in loc.
+// Events that merely touch the interval don't overlap it.
+func findConflicts(events []*calendar.Event, start, end time.Time, excludeID string, loc *time.Location) []*calendar.Event {
+	var out []*calendar.Event
+	for _, e := range events {
+		if excludeID != "" && (e.Id == excludeID || e.RecurringEventId == excludeID) {
+			continue
+		}
+		if e.Status == "cancelled" || e.Transparency == "transparent" || e.EventType == "workingLocation" || declinedBySelf(e) {
+			continue
+		}
+		s, en, ok := eventSpan(e, loc)
+		if !ok {
+			continue
+		}
+		if s.Before(end) && start.Before(en) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// declinedBySelf reports whether the calendar owner declined event.
+func declinedBySelf(event *calendar.Event) bool {
+	for _, a := range event.Attendees {
+		if a.Self {
+			return a.ResponseStatus == "declined"
+		}
+	}
+	return false
+}
+
+// eventSpan returns the instants event starts and ends. Dates of all-day
+// events and times without a UTC offset are read in the event time's
+// timezone if it has one, else in loc.
+func eventSpan(event *calendar.Event, loc *time.Location) (start, end time.Time, ok bool) {
+	start, ok1 := eventInstant(event.Start, loc)
+	end, ok2 := eventInstant(event.End, loc)
+	return start, end, ok1 && ok2
+}
+
+// eventInstant returns the instant of an event time; see eventSpan.
+func eventInstant(dt *calendar.EventDateTime, loc *time.Location) (time.Time, bool) {
+	if dt == nil {
+		return time.Time{}, false
+	}
+	if dt.TimeZone != "" {
+		if l, err := time.LoadLocation(dt.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	if dt.DateTime != "" {
+		if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+			return t, true
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04:05", dt.DateTime, loc)
+		return t, err == nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", dt.Date, loc)
+	return t, err == nil
+}
+
+// describeConflict describes a conflicting event for warnings, e.g.
+// "Standup (2024-01-15 10:00–10:30)", with times in loc.
+func describeConflict(event *calendar.Event, loc *time.Location) string {
+	summary := event.Summary
+	if summary == "" {
+		summary = "(no title)"
+	}
+	if event.Start != nil && event.Start.Date != "" {
+		last := event.Start.Date
+		if event.End != nil {
+			if t, err := time.Parse("2006-01-02", event.End.Date); err == nil {
+				last = t.AddDate(0, 0, -1).Format("2006-01-02")
+			}
+		}
+		if last == event.Start.Date {
+			return fmt.Sprintf("%s (all day %s)", summary, event.Start.Date)
+		}
+		return fmt.Sprintf("%s (all day %s – %s)", summary, event.Start.Date, last)
+	}
+	start, end, ok := eventSpan(event, loc)
+	if !ok {
+		return summary
+	}
+	start, end = start.In(loc), end.In(loc)
+	if start.Format("2006-01-02") == end.Format("2006-01-02") {
+		return fmt.Sprintf("%s (%s–%s)", summary, start.Format("2006-01-02 15:04"), end.Format("15:04"))
+	}
+	return fmt.Sprintf("%s (%s – %s)", summary, start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+}
+
+// conflictWarning formats the warning section listing conflicts, or ""
+// if there are none.
+func conflictWarning(conflicts []string) string {
+	switch len(conflicts) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("\n\nWarning: conflicts with %s\n", conflicts[0])
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nWarning: conflicts with:\n")
+	for _, c := range conflicts {
+		fmt.Fprintf(&sb, "  - %s\n", c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,333 @@
+package calendar
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// maxDescriptionLength is the number of characters of an event description
+// the tools send. Calendar rejects descriptions much longer than this.
+const maxDescriptionLength = 8000
+
+// descriptionTruncatedMarker ends a description cut to
+// maxDescriptionLength.
+const descriptionTruncatedMarker = "… [truncated]"
+
+// validateDescriptionFormat checks the description_format input.
+func validateDescriptionFormat(format string) error {
+	switch format {
+	case "", "text", "markdown", "html":
+		return nil
+	}
+	return fmt.Errorf("invalid description_format %q: use text, markdown or html", format)
+}
+
+// formatDescription converts desc, written in format, to the HTML Calendar
+// shows: text is escaped, markdown is converted by markdownToHTML and html
+// is passed through sanitizeHTML. An empty format returns desc as given.
+// The result is cut to maxDescriptionLength; note then explains the cut,
+// for the tool result.
+func formatDescription(desc, format string) (out, note string) {
+	switch format {
+	case "text":
+		out = html.EscapeString(desc)
+	case "markdown":
+		out = markdownToHTML(desc)
+	case "html":
+		out = sanitizeHTML(desc)
+	default:
+		out = desc
+	}
+	n := utf8.RuneCountInString(out)
+	if n <= maxDescriptionLength {
+		return out, ""
+	}
+	out = truncateDescription(out, maxDescriptionLength-utf8.RuneCountInString(descriptionTruncatedMarker), format != "")
+	note = fmt.Sprintf("\nNote: the description was %d characters, more than the limit of %d, and was truncated to fit.", n, maxDescriptionLength)
+	return out + descriptionTruncatedMarker, note
+}
+
+// truncateDescription cuts s to at most limit characters. For HTML, the cut
+// is moved back so that it doesn't split a tag or an entity; tags left
+// open are harmless, since Calendar closes them.
+func truncateDescription(s string, limit int, isHTML bool) string {
+	i := 0
+	for n := 0; n < limit && i < len(s); n++ {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	s = s[:i]
+	if !isHTML {
+		return s
+	}
+	if lt := strings.LastIndexByte(s, '<'); lt > strings.LastIndexByte(s, '>') {
+		s = s[:lt]
+	}
+	if amp := strings.LastIndexByte(s, '&'); amp >= 0 && !strings.Contains(s[amp:], ";") {
+		s = s[:amp]
+	}
+	return s
+}
+
+// unsafeElements are removed with their content by sanitizeHTML.
+var unsafeElements = map[string]bool{"script": true, "style": true, "iframe": true}
+
+// sanitizeHTML removes script, style and iframe elements with their
+// content from s, along with event handler attributes (onclick, ...) and
+// javascript: links. Everything else is kept; Calendar drops the markup it
+// doesn't render itself.
+func sanitizeHTML(s string) string {
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	var sb strings.Builder
+	skip := "" // unsafe element whose content is being skipped
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			return sb.String()
+		}
+		tok := z.Token()
+		if skip != "" {
+			if tt == xhtml.EndTagToken && tok.Data == skip {
+				skip = ""
+			}
+			continue
+		}
+		switch tt {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if unsafeElements[tok.Data] {
+				if tt == xhtml.StartTagToken {
+					skip = tok.Data
+				}
+				continue
+			}
+			tok.Attr = safeAttributes(tok.Attr)
+		case xhtml.EndTagToken:
+			if unsafeElements[tok.Data] {
+				continue
+			}
+		case xhtml.CommentToken, xhtml.DoctypeToken:
+			continue
+		}
+		sb.WriteString(tok.String())
+	}
+}
+
+// safeAttributes drops event handlers and script URLs from attrs.
+func safeAttributes(attrs []xhtml.Attribute) []xhtml.Attribute {
+	var out []xhtml.Attribute
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		if strings.HasPrefix(key, "on") {
+			continue
+		}
+		if (key == "href" || key == "src") && !safeURL(a.Val) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// safeURL reports whether u is a link Calendar may show: anything but a
+// javascript:, vbscript: or data: URL.
+func safeURL(u string) bool {
+	u = strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, u))
+	for _, scheme := range []string{"javascript:", "vbscript:", "data:"} {
+		if strings.HasPrefix(u, scheme) {
+			return false
+		}
+	}
+	return true
+}
+
+// Markdown block syntax recognized by markdownToHTML.
+var (
+	mdBullet   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdNumbered = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdHeading  = regexp.MustCompile(`^\s*#{1,6}\s+(.*?)\s*#*\s*$`)
+)
+
+// markdownToHTML converts the Markdown most often written in event
+// descriptions to the HTML subset Calendar renders: **bold**, *italics*
+// (or _italics_), [links](https://...), bullet and numbered lists, and line
+// breaks. Headings become bold lines. Other text, including any HTML in
+// it, is escaped.
+func markdownToHTML(md string) string {
+	var sb strings.Builder
+	list := ""     // "ul" or "ol" while in a list
+	last := ""     // "text" or "list": what was written last
+	blank := false // a blank line followed the last text line
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		tag, item := "", ""
+		if m := mdBullet.FindStringSubmatch(line); m != nil && !isThematicBreak(line) {
+			tag, item = "ul", m[1]
+		} else if m := mdNumbered.FindStringSubmatch(line); m != nil {
+			tag, item = "ol", m[1]
+		}
+		if list != "" && tag != list {
+			fmt.Fprintf(&sb, "</%s>", list)
+			list = ""
+		}
+		switch {
+		case tag != "":
+			if list == "" {
+				fmt.Fprintf(&sb, "<%s>", tag)
+				list = tag
+			}
+			fmt.Fprintf(&sb, "<li>%s</li>", markdownInline(item))
+			last = "list"
+		case strings.TrimSpace(line) == "":
+			blank = last == "text"
+		default:
+			// Lists are blocks of their own; text lines are separated by a
+			// line break, and paragraphs by two.
+			if last == "text" {
+				sb.WriteString("<br>")
+				if blank {
+					sb.WriteString("<br>")
+				}
+			}
+			if m := mdHeading.FindStringSubmatch(line); m != nil {
+				fmt.Fprintf(&sb, "<b>%s</b>", markdownInline(m[1]))
+			} else {
+				sb.WriteString(markdownInline(strings.TrimRight(line, " \t")))
+			}
+			last, blank = "text", false
+		}
+	}
+	if list != "" {
+		fmt.Fprintf(&sb, "</%s>", list)
+	}
+	return sb.String()
+}
+
+// isThematicBreak reports whether line is a "---" or "* * *" rule, which
+// mdBullet would otherwise take for a list item.
+func isThematicBreak(line string) bool {
+	s := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	return len(s) >= 3 && strings.Trim(s, string(s[0])) == ""
+}
+
+// mdEscapable are the characters a backslash makes literal in Markdown.
+const mdEscapable = "\\`*_[]()#+-.!<>"
+
+// markdownInline converts the inline Markdown of one line: emphasis,
+// links and code spans, whose content is kept literally. Bare URLs are
+// copied as they are, so that underscores and asterisks in them aren't
+// taken for emphasis.
+func markdownInline(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(mdEscapable, s[i+1]) >= 0:
+			sb.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				sb.WriteString(html.EscapeString(s[i+1 : i+1+end]))
+				i += end + 2
+				continue
+			}
+		case c == '[':
+			if text, href, n, ok := markdownLink(s[i:]); ok {
+				fmt.Fprintf(&sb, `<a href="%s">%s</a>`, html.EscapeString(href), markdownInline(text))
+				i += n
+				continue
+			}
+		case c == 'h' && (i == 0 || !isWordByte(s[i-1])) && (strings.HasPrefix(s[i:], "http://") || strings.HasPrefix(s[i:], "https://")):
+			end := strings.IndexFunc(s[i:], unicode.IsSpace)
+			if end < 0 {
+				end = len(s) - i
+			}
+			sb.WriteString(html.EscapeString(s[i : i+end]))
+			i += end
+			continue
+		case c == '*' || c == '_':
+			if inner, n, tag, ok := markdownEmphasis(s, i); ok {
+				fmt.Fprintf(&sb, "<%s>%s</%s>", tag, markdownInline(inner), tag)
+				i += n
+				continue
+			}
+		}
+		sb.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return sb.String()
+}
+
+// markdownLink parses a [text](url) link at the start of s, returning its
+// parts and length. Only http, https and mailto links are converted.
+func markdownLink(s string) (text, href string, n int, ok bool) {
+	mid := strings.Index(s, "](")
+	if mid < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[mid+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	text, href = s[1:mid], strings.TrimSpace(s[mid+2:mid+2+end])
+	if text == "" || strings.ContainsAny(href, " \t") {
+		return "", "", 0, false
+	}
+	lower := strings.ToLower(href)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "mailto:") {
+		return "", "", 0, false
+	}
+	return text, href, mid + 2 + end + 1, true
+}
+
+// markdownEmphasis parses emphasis opened by the delimiter at s[i]: ** or
+// __ for bold, * or _ for italics. The content may not start or end with a
+// space, and _ only counts at word boundaries, so snake_case names are
+// left alone. It returns the content, the length of the whole span and
+// the HTML tag.
+func markdownEmphasis(s string, i int) (inner string, n int, tag string, ok bool) {
+	c := s[i]
+	delim, tag := s[i:i+1], "i"
+	if i+1 < len(s) && s[i+1] == c {
+		delim, tag = s[i:i+2], "b"
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, "", false
+	}
+	start := i + len(delim)
+	if start >= len(s) || s[start] == ' ' {
+		return "", 0, "", false
+	}
+	for j := start + 1; j+len(delim) <= len(s); j++ {
+		if s[j:j+len(delim)] != delim || s[j-1] == ' ' {
+			continue
+		}
+		after := j + len(delim)
+		if tag == "i" && (s[j-1] == c || after < len(s) && s[after] == c) {
+			// Part of a ** or __ inside the italics.
+			j++
+			continue
+		}
+		if c == '_' && after < len(s) && isWordByte(s[after]) {
+			continue
+		}
+		return s[start:j], after - i, tag, true
+	}
+	return "", 0, "", false
+}
+
+// isWordByte reports whether b is an ASCII letter or digit, or part of a
+// multi-byte character.
+func isWordByte(b byte) bool {
+	return b >= utf8.RuneSelf || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
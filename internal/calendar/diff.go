@@ -0,0 +1,153 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// diffEvents summarizes how after differs from before for update_event, as
+// in "Changed: Start 09:00→10:00; Attendees +bob@example.com; Location
+// unchanged". Summary, start, end, location, attendees and description are
+// compared; the unchanged ones are listed last so the result also shows
+// what was left alone.
+func diffEvents(before, after *calendar.Event) string {
+	var changed, unchanged []string
+	field := func(name string, diff string) {
+		if diff == "" {
+			unchanged = append(unchanged, name)
+		} else {
+			changed = append(changed, diff)
+		}
+	}
+
+	field("Summary", diffText("Summary", before.Summary, after.Summary))
+	field("Start", diffEventTime("Start", before.Start, after.Start))
+	field("End", diffEventTime("End", before.End, after.End))
+	field("Location", diffText("Location", before.Location, after.Location))
+	field("Attendees", diffAttendees(before.Attendees, after.Attendees))
+	if before.Description != after.Description {
+		field("Description", "Description changed")
+	} else {
+		field("Description", "")
+	}
+
+	parts := changed
+	if len(changed) == 0 {
+		parts = []string{"nothing"}
+	}
+	if len(unchanged) > 0 {
+		parts = append(parts, strings.Join(unchanged, ", ")+" unchanged")
+	}
+	return "Changed: " + strings.Join(parts, "; ")
+}
+
+// diffText describes a change of a text field, or returns "" if unchanged.
+func diffText(name, from, to string) string {
+	if from == to {
+		return ""
+	}
+	return name + " " + quoteOrNone(from) + "→" + quoteOrNone(to)
+}
+
+func quoteOrNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return `"` + s + `"`
+}
+
+// diffEventTime describes a change of an event start or end, or returns ""
+// if it is the same instant (or date, for all-day events). Only the time is
+// shown when the date stays the same, and UTC offsets only when two times
+// differ in them.
+func diffEventTime(name string, from, to *calendar.EventDateTime) string {
+	if sameEventTime(from, to) {
+		return ""
+	}
+	fromDate, fromClock, fromZone := eventTimeParts(from)
+	toDate, toClock, toZone := eventTimeParts(to)
+	if fromZone != "" && toZone != "" && fromZone != toZone {
+		fromClock += fromZone
+		toClock += toZone
+	}
+	if fromDate == toDate && fromClock != "" && toClock != "" {
+		return name + " " + fromClock + "→" + toClock
+	}
+	return name + " " + joinTimeParts(fromDate, fromClock) + "→" + joinTimeParts(toDate, toClock)
+}
+
+// sameEventTime reports whether a and b are the same instant, or the same
+// date for all-day events. The API may echo a time back in another offset
+// than it was sent in, so times are compared as instants.
+func sameEventTime(a, b *calendar.EventDateTime) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.DateTime == "" || b.DateTime == "" {
+		return a.DateTime == b.DateTime && a.Date == b.Date
+	}
+	ta, err1 := time.Parse(time.RFC3339, a.DateTime)
+	tb, err2 := time.Parse(time.RFC3339, b.DateTime)
+	if err1 != nil || err2 != nil {
+		return a.DateTime == b.DateTime
+	}
+	return ta.Equal(tb)
+}
+
+// eventTimeParts returns the date, the clock time and the UTC offset of dt,
+// in the offset it was given in. Clock and offset are "" for all-day
+// events. Times that don't parse are returned whole as the date.
+func eventTimeParts(dt *calendar.EventDateTime) (date, clock, zone string) {
+	switch {
+	case dt == nil:
+		return "", "", ""
+	case dt.DateTime != "":
+		t, err := time.Parse(time.RFC3339, dt.DateTime)
+		if err != nil {
+			return dt.DateTime, "", ""
+		}
+		return t.Format("2006-01-02"), t.Format("15:04"), t.Format("Z07:00")
+	default:
+		return dt.Date, "", ""
+	}
+}
+
+func joinTimeParts(date, clock string) string {
+	switch {
+	case date == "":
+		return "(none)"
+	case clock == "":
+		return date + " (all day)"
+	default:
+		return date + " " + clock
+	}
+}
+
+// diffAttendees lists the attendees added (+) and removed (-), compared by
+// email address, or returns "" if they are the same.
+func diffAttendees(from, to []*calendar.EventAttendee) string {
+	before := make(map[string]bool, len(from))
+	for _, a := range from {
+		before[strings.ToLower(a.Email)] = true
+	}
+	after := make(map[string]bool, len(to))
+	var parts []string
+	for _, a := range to {
+		key := strings.ToLower(a.Email)
+		after[key] = true
+		if !before[key] {
+			parts = append(parts, "+"+a.Email)
+		}
+	}
+	for _, a := range from {
+		if !after[strings.ToLower(a.Email)] {
+			parts = append(parts, "-"+a.Email)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Attendees " + strings.Join(parts, " ")
+}
@@ -2,15 +2,21 @@ package calendar
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/bridge"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 )
 
 // calendarDriveAttachment references a Google Drive file to attach to an event.
@@ -54,23 +60,41 @@ func resolveDriveAttachmentsForEvent(ctx context.Context, mgr *auth.Manager, att
 // --- list_events ---
 
 type listEventsInput struct {
-	Account    string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	TimeMin    string `json:"time_min,omitempty" jsonschema:"Start of time range in RFC3339 format (e.g. '2024-01-15T00:00:00Z'). Default: now"`
 	TimeMax    string `json:"time_max,omitempty" jsonschema:"End of time range in RFC3339 format. Default: 7 days from now"`
 	Query      string `json:"query,omitempty" jsonschema:"Free text search query"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of events per account (default 20, max 100)"`
+	Format     string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per event)"`
+	DisplayTZ  string `json:"display_timezone,omitempty" jsonschema:"IANA timezone (e.g. 'Europe/Berlin') to show start and end times in, with the zone abbreviation and the original UTC offset when it differs. All-day dates are never converted. Default: times as the calendar returns them"`
 }
 
+// listEventsFields is the Fields mask for event lists shown with
+// formatEvent, which skips attendees, descriptions and the like.
+const listEventsFields = "items(id,summary,start,end,location,status,htmlLink)"
+
 func registerListEvents(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "list_events",
-		Description: "List events from a Google Calendar within a time range. Set account to 'all' to list events from all accounts. Defaults to upcoming events in the next 7 days.",
+		Description: "List events from a Google Calendar within a time range. Set account to 'all' to list events from all accounts. Defaults to upcoming events in the next 7 days. Set format to json or csv for machine-readable output, and display_timezone to show every start and end time in one timezone.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listEventsInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table, err := server.NewTable(input.Format, eventColumns)
+		if err != nil {
+			return nil, nil, err
+		}
+		loc, err := displayLocation(input.DisplayTZ)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -98,14 +122,21 @@ func registerListEvents(srv *server.Server, mgr *auth.Manager) {
 			maxResults = 100
 		}
 
-		var sb strings.Builder
+		out := srv.NewOutputBuilder()
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
 					continue
 				}
 				return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
@@ -116,38 +147,55 @@ func registerListEvents(srv *server.Server, mgr *auth.Manager) {
 				TimeMax(timeMax).
 				MaxResults(maxResults).
 				SingleEvents(true).
-				OrderBy("startTime")
+				OrderBy("startTime").
+				Fields(listEventsFields)
 
 			if input.Query != "" {
 				call = call.Q(input.Query)
 			}
 
-			resp, err := call.Do()
+			resp, err := call.Context(ctx).Do()
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: listing events: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing events: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError listing events: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing events: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing events")
+			}
+
+			if table != nil {
+				for _, event := range resp.Items {
+					table.Add(eventRow{Account: account, Event: eventInZone(event, loc, false)})
+				}
+				continue
 			}
 
 			if multiAccount {
-				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+				fmt.Fprintf(out, "=== Account: %s ===\n", account)
 			}
 
 			if len(resp.Items) == 0 {
-				sb.WriteString("No events found in the specified time range.\n\n")
+				out.WriteString("No events found in the specified time range.\n\n")
 				continue
 			}
 
-			fmt.Fprintf(&sb, "Found %d events:\n\n", len(resp.Items))
+			fmt.Fprintf(out, "Found %d events:\n\n", len(resp.Items))
 			for _, event := range resp.Items {
-				sb.WriteString(formatEvent(event, account))
-				sb.WriteString("\n")
+				if !out.AddItem(formatEvent(eventInZone(event, loc, true), account) + "\n") {
+					break
+				}
 			}
 		}
 
-		text := sb.String()
+		if table != nil {
+			return table.Result(), nil, nil
+		}
+
+		text := out.String()
 		if text == "" {
 			text = "No events found in the specified time range."
 		}
@@ -163,9 +211,10 @@ func registerListEvents(srv *server.Server, mgr *auth.Manager) {
 // --- get_event ---
 
 type getEventInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	EventID    string `json:"event_id" jsonschema:"Event ID to retrieve"`
+	DisplayTZ  string `json:"display_timezone,omitempty" jsonschema:"IANA timezone (e.g. 'Europe/Berlin') to show start and end times in, with the zone abbreviation and the original UTC offset when it differs. All-day dates are never converted. Default: times as the calendar returns them"`
 }
 
 func registerGetEvent(srv *server.Server, mgr *auth.Manager) {
@@ -173,9 +222,16 @@ func registerGetEvent(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_event",
 		Description: "Get full details of a specific calendar event by ID.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getEventInput) (*mcp.CallToolResult, any, error) {
+		loc, err := displayLocation(input.DisplayTZ)
+		if err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
@@ -186,14 +242,14 @@ func registerGetEvent(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		event, err := svc.Events.Get(calendarID, input.EventID).Do()
+		event, err := svc.Events.Get(calendarID, input.EventID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting event: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting event")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: formatEventDetailed(event)},
+				&mcp.TextContent{Text: formatEventDetailed(eventInZone(event, loc, true))},
 			},
 		}, nil, nil
 	})
@@ -202,26 +258,146 @@ func registerGetEvent(srv *server.Server, mgr *auth.Manager) {
 // --- create_event ---
 
 type createEventInput struct {
-	Account          string                    `json:"account" jsonschema:"Account name"`
-	CalendarID       string                    `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
-	Summary          string                    `json:"summary" jsonschema:"Event title"`
-	Description      string                    `json:"description,omitempty" jsonschema:"Event description"`
-	Location         string                    `json:"location,omitempty" jsonschema:"Event location"`
-	StartTime        string                    `json:"start_time" jsonschema:"Event start time in RFC3339 format (e.g. '2024-01-15T09:00:00-05:00') or date for all-day events (e.g. '2024-01-15')"`
-	EndTime          string                    `json:"end_time" jsonschema:"Event end time in RFC3339 format or date for all-day events"`
-	TimeZone         string                    `json:"time_zone,omitempty" jsonschema:"IANA timezone (e.g. 'America/New_York'). Defaults to account calendar timezone."`
-	Attendees        []string                  `json:"attendees,omitempty" jsonschema:"Email addresses of attendees"`
-	DriveAttachments []calendarDriveAttachment `json:"drive_attachments,omitempty" jsonschema:"Google Drive files to attach to the event (metadata only, no file download)"`
+	Account           string                    `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID        string                    `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	Summary           string                    `json:"summary" jsonschema:"Event title"`
+	Description       string                    `json:"description,omitempty" jsonschema:"Event description"`
+	DescriptionFormat string                    `json:"description_format,omitempty" jsonschema:"How description is written: text (shown as written, HTML escaped), markdown (bold, italics, links and lists converted to the HTML Calendar shows), or html (script, style and iframe removed) (default: sent as given)"`
+	Location          string                    `json:"location,omitempty" jsonschema:"Event location"`
+	StartTime         string                    `json:"start_time" jsonschema:"Event start time in RFC3339 format (e.g. '2024-01-15T09:00:00-05:00') or date for all-day events (e.g. '2024-01-15')"`
+	EndTime           string                    `json:"end_time" jsonschema:"Event end time in RFC3339 format or date for all-day events"`
+	TimeZone          string                    `json:"time_zone,omitempty" jsonschema:"IANA timezone (e.g. 'America/New_York'). Defaults to account calendar timezone."`
+	Attendees         []attendee                `json:"attendees,omitempty" jsonschema:"Attendees: email addresses, or objects {email, optional, resource} to mark an attendee optional or book a room or other resource"`
+	DriveAttachments  []calendarDriveAttachment `json:"drive_attachments,omitempty" jsonschema:"Google Drive files to attach to the event (metadata only, no file download)"`
+	NotesAccount      string                    `json:"notes_account,omitempty" jsonschema:"Drive account for the {{notes_link}} document (default: same as account)"`
+	ColorID           string                    `json:"color_id,omitempty" jsonschema:"Event color ID from get_colors (default: the calendar's color)"`
+	Visibility        string                    `json:"visibility,omitempty" jsonschema:"Event visibility: default, public, or private (default: default)"`
+	Transparency      string                    `json:"transparency,omitempty" jsonschema:"opaque (shows as busy) or transparent (shows as free) (default: opaque)"`
+	Dedupe            bool                      `json:"dedupe,omitempty" jsonschema:"Before creating, look for an event with the same title starting within a minute of start_time and return it instead of creating a duplicate (default: false)"`
+	IdempotencyKey    string                    `json:"idempotency_key,omitempty" jsonschema:"Caller-chosen unique key stored on the event. If an event with this key already exists on the calendar, it is returned instead of creating another."`
+	PrivateProperties map[string]string         `json:"private_properties,omitempty" jsonschema:"Key/value pairs stored on the event, visible only to this account (e.g. {\"ticket\": \"OPS-123\"}). Find events by them with find_events_by_private_property. Keys up to 44 characters, values up to 1024."`
+	eventTypeInput
+	conflictCheckInput
+}
+
+// eventTypeInput selects a special event type for create_event.
+type eventTypeInput struct {
+	EventType      string `json:"event_type,omitempty" jsonschema:"default (a regular event), outOfOffice, or focusTime. Out-of-office and focus-time events must be timed, go on the primary calendar, and cannot be changed to another type later (default: default)"`
+	AutoDecline    string `json:"auto_decline,omitempty" jsonschema:"outOfOffice and focusTime only: which overlapping invitations to decline: all (existing and new), new (only new ones), or none (default: all for outOfOffice, none for focusTime)"`
+	DeclineMessage string `json:"decline_message,omitempty" jsonschema:"outOfOffice and focusTime only: message sent with automatic declines"`
+	ChatStatus     string `json:"chat_status,omitempty" jsonschema:"focusTime only: Chat status during the event: available or doNotDisturb"`
+}
+
+// autoDeclineModes maps auto_decline values to the API's autoDeclineMode.
+var autoDeclineModes = map[string]string{
+	"all":  "declineAllConflictingInvitations",
+	"new":  "declineOnlyNewConflictingInvitations",
+	"none": "declineNone",
+}
+
+// applyEventType sets the event type and its properties on event. It must
+// run after event.Start is set, since the special types must be timed.
+func applyEventType(event *calendar.Event, in eventTypeInput) error {
+	eventType := in.EventType
+	if eventType == "" {
+		eventType = "default"
+	}
+	switch eventType {
+	case "default":
+		if in.AutoDecline != "" || in.DeclineMessage != "" || in.ChatStatus != "" {
+			return fmt.Errorf("auto_decline, decline_message, and chat_status require event_type outOfOffice or focusTime")
+		}
+		return nil
+	case "outOfOffice", "focusTime":
+	case "workingLocation", "birthday", "fromGmail":
+		return fmt.Errorf("event_type %q is not supported by create_event: use default, outOfOffice, or focusTime", eventType)
+	default:
+		return fmt.Errorf("invalid event_type %q: must be default, outOfOffice, or focusTime", eventType)
+	}
+
+	if event.Start == nil || event.Start.DateTime == "" {
+		return fmt.Errorf("%s events cannot be all-day: give start_time and end_time as RFC3339 timestamps", eventType)
+	}
+
+	autoDecline := in.AutoDecline
+	if autoDecline == "" {
+		autoDecline = "none"
+		if eventType == "outOfOffice" {
+			autoDecline = "all"
+		}
+	}
+	mode, ok := autoDeclineModes[autoDecline]
+	if !ok {
+		return fmt.Errorf("invalid auto_decline %q: must be all, new, or none", in.AutoDecline)
+	}
+	if in.DeclineMessage != "" && autoDecline == "none" {
+		return fmt.Errorf("decline_message needs auto_decline all or new")
+	}
+
+	event.EventType = eventType
+	if eventType == "outOfOffice" {
+		if in.ChatStatus != "" {
+			return fmt.Errorf("chat_status is only supported for focusTime events")
+		}
+		event.OutOfOfficeProperties = &calendar.EventOutOfOfficeProperties{
+			AutoDeclineMode: mode,
+			DeclineMessage:  in.DeclineMessage,
+		}
+		return nil
+	}
+	switch in.ChatStatus {
+	case "", "available", "doNotDisturb":
+	default:
+		return fmt.Errorf("invalid chat_status %q: must be available or doNotDisturb", in.ChatStatus)
+	}
+	event.FocusTimeProperties = &calendar.EventFocusTimeProperties{
+		AutoDeclineMode: mode,
+		DeclineMessage:  in.DeclineMessage,
+		ChatStatus:      in.ChatStatus,
+	}
+	return nil
+}
+
+// eventTypeError explains API rejections of special event types, which
+// otherwise come back as a bare "Bad Request".
+func eventTypeError(eventType string, err error) error {
+	if eventType == "" || eventType == "default" {
+		return gerrors.Wrap(err, "creating event")
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Code == http.StatusBadRequest {
+		return fmt.Errorf("creating %s event: %w\n\n%s events must be timed and on the account's primary calendar, and some account types do not support them", eventType, err, eventType)
+	}
+	return gerrors.Wrapf(err, "creating %s event", eventType)
 }
 
 func registerCreateEvent(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name: "create_event",
+		Name:        "create_event",
+		InputSchema: inputSchema[createEventInput](),
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
-		Description: "Create a new event on a Google Calendar. Supports timed and all-day events, with optional attendees, location, and Google Drive file attachments.",
+		Description: `Create a new event on a Google Calendar. Supports timed and all-day events, out-of-office and focus-time blocks (event_type) that can decline conflicting invitations, with optional attendees, location, Google Drive file attachments, color, visibility, and free/busy transparency.
+
+To make retries safe, set idempotency_key (exact: the key is stored on the event) or dedupe (heuristic: same title starting within a minute). When a match is found, the existing event is returned and nothing is created.
+
+To correlate events with your own records, store keys such as ticket IDs in private_properties and look the events up later with find_events_by_private_property.
+
+To book a conference room, add its resource calendar email as an attendee object with resource: true; find_available_room checks which rooms are free first.
+
+Set check_conflicts to be warned when the event overlaps busy events on the calendar (declined and free events don't count), or fail_on_conflict to not create it then.
+
+Calendar shows descriptions as HTML. Set description_format to markdown to write **bold**, *italics*, [links](https://...) and lists in Markdown, to html to send HTML (script, style and iframe elements are removed), or to text to show the description exactly as written. Descriptions longer than 8000 characters are truncated, with a note in the result.` + templateHelp(),
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createEventInput) (*mcp.CallToolResult, any, error) {
+		event, err := buildEvent(input)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
@@ -232,89 +408,348 @@ func registerCreateEvent(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		event := &calendar.Event{
-			Summary:     input.Summary,
-			Description: input.Description,
-			Location:    input.Location,
+		created, duplicate, conflicts, note, err := insertEvent(ctx, mgr, svc, calendarID, input, event)
+		if err != nil {
+			return nil, nil, err
+		}
+		status := "Event created."
+		if duplicate {
+			status = "Duplicate detected, not created."
 		}
 
-		// Determine if this is an all-day event (date only) or timed event.
-		if isDateOnly(input.StartTime) {
-			event.Start = &calendar.EventDateTime{Date: input.StartTime}
-			event.End = &calendar.EventDateTime{Date: input.EndTime}
-		} else {
-			event.Start = &calendar.EventDateTime{
-				DateTime: input.StartTime,
-				TimeZone: input.TimeZone,
-			}
-			event.End = &calendar.EventDateTime{
-				DateTime: input.EndTime,
-				TimeZone: input.TimeZone,
-			}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("%s\n\nEvent ID: %s\nLink: %s\n\n%s%s%s",
+					status, created.Id, created.HtmlLink, formatEvent(created, input.Account), conflictWarning(conflicts), note)},
+			},
+		}, nil, nil
+	})
+}
+
+// buildEvent validates the input of create_event and builds the event to
+// insert. It makes no API calls: the color check, Drive attachments and
+// description templates are left to insertEvent.
+func buildEvent(input createEventInput) (*calendar.Event, error) {
+	if err := validateTemplate(input.Description); err != nil {
+		return nil, err
+	}
+	if err := validateDescriptionFormat(input.DescriptionFormat); err != nil {
+		return nil, err
+	}
+	if err := validatePrivateProperties(input.PrivateProperties, false); err != nil {
+		return nil, err
+	}
+	if err := validateEventDisplay(input.Visibility, input.Transparency); err != nil {
+		return nil, err
+	}
+	if err := validateEventTimes(input.StartTime, input.EndTime); err != nil {
+		return nil, err
+	}
+
+	event := &calendar.Event{
+		Summary:      input.Summary,
+		Description:  input.Description,
+		Location:     input.Location,
+		ColorId:      input.ColorID,
+		Visibility:   input.Visibility,
+		Transparency: input.Transparency,
+	}
+
+	// Determine if this is an all-day event (date only) or timed event.
+	if isDateOnly(input.StartTime) {
+		event.Start = &calendar.EventDateTime{Date: input.StartTime}
+		event.End = &calendar.EventDateTime{Date: input.EndTime}
+	} else {
+		event.Start = &calendar.EventDateTime{
+			DateTime: input.StartTime,
+			TimeZone: input.TimeZone,
+		}
+		event.End = &calendar.EventDateTime{
+			DateTime: input.EndTime,
+			TimeZone: input.TimeZone,
 		}
+	}
 
-		// Add attendees.
-		for _, email := range input.Attendees {
-			event.Attendees = append(event.Attendees, &calendar.EventAttendee{
-				Email: email,
-			})
+	if err := applyEventType(event, input.eventTypeInput); err != nil {
+		return nil, err
+	}
+	if input.IdempotencyKey != "" {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{
+			Private: map[string]string{idempotencyKeyProperty: input.IdempotencyKey},
 		}
+	}
+	applyPrivateProperties(event, input.PrivateProperties)
 
-		// Resolve Drive attachments.
-		if len(input.DriveAttachments) > 0 {
-			attachments, err := resolveDriveAttachmentsForEvent(ctx, mgr, input.DriveAttachments)
-			if err != nil {
-				return nil, nil, err
-			}
-			event.Attachments = attachments
+	attendees, err := eventAttendees(input.Attendees)
+	if err != nil {
+		return nil, err
+	}
+	event.Attendees = attendees
+	return event, nil
+}
+
+// validateEventTimes checks that start and end are given, are both dates or
+// both times, and that end is after start. Times without a UTC offset, which
+// the API accepts when time_zone is set, are not compared.
+func validateEventTimes(start, end string) error {
+	if start == "" || end == "" {
+		return fmt.Errorf("start_time and end_time are required")
+	}
+	if isDateOnly(start) != isDateOnly(end) {
+		return fmt.Errorf("start_time %q and end_time %q must both be dates (all-day event) or both be times", start, end)
+	}
+	layout := time.RFC3339
+	if isDateOnly(start) {
+		layout = "2006-01-02"
+	}
+	ts, err1 := time.Parse(layout, start)
+	te, err2 := time.Parse(layout, end)
+	if err1 == nil && err2 == nil && !te.After(ts) {
+		return fmt.Errorf("end_time %q must be after start_time %q", end, start)
+	}
+	return nil
+}
+
+// insertEvent inserts an event built by buildEvent from input, unless
+// idempotency_key or dedupe find an existing copy, which is returned with
+// duplicate set instead. With check_conflicts, conflicts describes the
+// events the new one overlaps; with fail_on_conflict, overlapping events
+// fail the insert with a *conflictError. note explains a description cut
+// to the length limit.
+func insertEvent(ctx context.Context, mgr *auth.Manager, svc *calendar.Service, calendarID string, input createEventInput, event *calendar.Event) (created *calendar.Event, duplicate bool, conflicts []string, note string, err error) {
+	if err := validateEventColor(ctx, svc, input.ColorID); err != nil {
+		return nil, false, nil, "", err
+	}
+
+	// Look for an existing copy before anything with side effects (notes
+	// docs, attachments) happens. An existing copy would also conflict
+	// with the event, so conflicts are only checked after.
+	existing, err := findExistingEvent(ctx, svc, calendarID, input.IdempotencyKey, input.Dedupe, input.Summary, event.Start)
+	if err != nil {
+		return nil, false, nil, "", err
+	}
+	if existing != nil {
+		return existing, true, nil, "", nil
+	}
+	conflicts, err = checkConflicts(ctx, svc, calendarID, event, "", input.conflictCheckInput)
+	if err != nil {
+		return nil, false, nil, "", err
+	}
+
+	// Resolve Drive attachments.
+	if len(input.DriveAttachments) > 0 {
+		attachments, err := resolveDriveAttachmentsForEvent(ctx, mgr, input.DriveAttachments)
+		if err != nil {
+			return nil, false, nil, "", err
 		}
+		event.Attachments = attachments
+	}
 
-		call := svc.Events.Insert(calendarID, event)
-		if len(event.Attachments) > 0 {
-			call = call.SupportsAttachments(true)
+	// Expand description templates last so that variables see the final
+	// event; this may create the notes doc and attach it.
+	notesAccount := input.NotesAccount
+	if notesAccount == "" {
+		notesAccount = input.Account
+	}
+	notes := newMeetingNotes(ctx, mgr, notesAccount, event)
+	event.Description, err = renderDescription(input.Description, event, notes.create)
+	if err != nil {
+		return nil, false, nil, "", err
+	}
+	event.Description, note = formatDescription(event.Description, input.DescriptionFormat)
+
+	call := svc.Events.Insert(calendarID, event)
+	if len(event.Attachments) > 0 {
+		call = call.SupportsAttachments(true)
+	}
+	created, err = call.Context(ctx).Do()
+	if err != nil {
+		return nil, false, nil, "", notes.discard(eventTypeError(event.EventType, err))
+	}
+	return created, false, conflicts, note, nil
+}
+
+// idempotencyKeyProperty is the private extended property create_event
+// stores idempotency keys in.
+const idempotencyKeyProperty = "googleMcpIdempotencyKey"
+
+// dedupeWindow is how far apart two start times can be for create_event's
+// dedupe to treat events with the same title as duplicates.
+const dedupeWindow = time.Minute
+
+// findExistingEvent returns an event that create_event should return instead
+// of creating a new one, or nil. An idempotency key is checked first; dedupe
+// then looks for an event with the same summary and start.
+func findExistingEvent(ctx context.Context, svc *calendar.Service, calendarID, idempotencyKey string, dedupe bool, summary string, start *calendar.EventDateTime) (*calendar.Event, error) {
+	if idempotencyKey != "" {
+		resp, err := svc.Events.List(calendarID).
+			PrivateExtendedProperty(idempotencyKeyProperty + "=" + idempotencyKey).
+			MaxResults(1).Context(ctx).
+			Do()
+		if err != nil {
+			return nil, gerrors.Wrap(err, "checking idempotency key")
+		}
+		if len(resp.Items) > 0 {
+			return resp.Items[0], nil
 		}
-		created, err := call.Do()
+	}
+	if !dedupe {
+		return nil, nil
+	}
+
+	timeMin, timeMax, ok := dedupeRange(start)
+	if !ok {
+		// A time without an offset or a known time zone is in the
+		// calendar's timezone, which isn't known here.
+		return nil, nil
+	}
+	resp, err := svc.Events.List(calendarID).
+		TimeMin(timeMin.Format(time.RFC3339)).
+		TimeMax(timeMax.Format(time.RFC3339)).
+		SingleEvents(true).
+		Q(summary).
+		MaxResults(250).Context(ctx).
+		Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "checking for duplicate events")
+	}
+	return matchDuplicate(resp.Items, summary, start), nil
+}
+
+// dedupeRange returns the Events.List window that contains every possible
+// duplicate of an event starting at start. All-day events get a day of
+// slack on each side since their dates are in the calendar's timezone.
+// Times without a UTC offset are read in start's time zone; ok is false
+// when start has neither or can't be parsed.
+func dedupeRange(start *calendar.EventDateTime) (timeMin, timeMax time.Time, ok bool) {
+	if start.Date != "" {
+		day, err := time.Parse(time.DateOnly, start.Date)
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating event: %w", err)
+			return time.Time{}, time.Time{}, false
 		}
+		return day.AddDate(0, 0, -1), day.AddDate(0, 0, 2), true
+	}
+	t, ok := dedupeStart(start)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return t.Add(-dedupeWindow), t.Add(dedupeWindow), true
+}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Event created.\n\nEvent ID: %s\nLink: %s\n\n%s",
-					created.Id, created.HtmlLink, formatEvent(created, input.Account))},
-			},
-		}, nil, nil
-	})
+// dedupeStart returns the instant of a timed start. A dateTime without an
+// offset is read in start.TimeZone, which must then be a known zone.
+func dedupeStart(start *calendar.EventDateTime) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, start.DateTime); err == nil {
+		return t, true
+	}
+	loc, err := time.LoadLocation(start.TimeZone)
+	if start.TimeZone == "" || err != nil {
+		return time.Time{}, false
+	}
+	t, ok := eventInstant(start, loc)
+	return t.UTC(), ok
+}
+
+// matchDuplicate returns the first event with the same summary (ignoring
+// case and surrounding space) whose start is the same date, for all-day
+// events, or within dedupeWindow, for timed events.
+func matchDuplicate(events []*calendar.Event, summary string, start *calendar.EventDateTime) *calendar.Event {
+	want, _ := dedupeStart(start)
+	for _, e := range events {
+		if e.Status == "cancelled" || e.Start == nil {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(e.Summary), strings.TrimSpace(summary)) {
+			continue
+		}
+		if start.Date != "" {
+			if e.Start.Date == start.Date {
+				return e
+			}
+			continue
+		}
+		got, err := time.Parse(time.RFC3339, e.Start.DateTime)
+		if err != nil {
+			continue
+		}
+		if d := got.Sub(want); d >= -dedupeWindow && d <= dedupeWindow {
+			return e
+		}
+	}
+	return nil
 }
 
 // --- update_event ---
 
 type updateEventInput struct {
-	Account          string                    `json:"account" jsonschema:"Account name"`
-	CalendarID       string                    `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
-	EventID          string                    `json:"event_id" jsonschema:"Event ID to update"`
-	Summary          string                    `json:"summary,omitempty" jsonschema:"New event title (leave empty to keep current)"`
-	Description      string                    `json:"description,omitempty" jsonschema:"New event description (leave empty to keep current)"`
-	Location         string                    `json:"location,omitempty" jsonschema:"New event location (leave empty to keep current)"`
-	StartTime        string                    `json:"start_time,omitempty" jsonschema:"New start time in RFC3339 format or date for all-day events (leave empty to keep current)"`
-	EndTime          string                    `json:"end_time,omitempty" jsonschema:"New end time in RFC3339 format or date for all-day events (leave empty to keep current)"`
-	TimeZone         string                    `json:"time_zone,omitempty" jsonschema:"IANA timezone (e.g. 'America/New_York')"`
-	Attendees        []string                  `json:"attendees,omitempty" jsonschema:"Replace attendee list with these email addresses. Omit to keep current attendees."`
-	DriveAttachments []calendarDriveAttachment `json:"drive_attachments,omitempty" jsonschema:"Google Drive files to attach to the event (adds to existing attachments). Metadata only, no file download."`
+	Account           string                    `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID        string                    `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	EventID           string                    `json:"event_id" jsonschema:"Event ID to update"`
+	Summary           string                    `json:"summary,omitempty" jsonschema:"New event title (leave empty to keep current)"`
+	Description       string                    `json:"description,omitempty" jsonschema:"New event description (leave empty to keep current)"`
+	DescriptionFormat string                    `json:"description_format,omitempty" jsonschema:"How description is written: text (shown as written, HTML escaped), markdown (bold, italics, links and lists converted to the HTML Calendar shows), or html (script, style and iframe removed) (default: sent as given)"`
+	Location          string                    `json:"location,omitempty" jsonschema:"New event location (leave empty to keep current)"`
+	StartTime         string                    `json:"start_time,omitempty" jsonschema:"New start time in RFC3339 format or date for all-day events (leave empty to keep current)"`
+	EndTime           string                    `json:"end_time,omitempty" jsonschema:"New end time in RFC3339 format or date for all-day events (leave empty to keep current)"`
+	TimeZone          string                    `json:"time_zone,omitempty" jsonschema:"IANA timezone (e.g. 'America/New_York')"`
+	Attendees         []string                  `json:"attendees,omitempty" jsonschema:"Replace attendee list with these email addresses. Omit to keep current attendees."`
+	AddAttendees      []string                  `json:"add_attendees,omitempty" jsonschema:"Email addresses to add to the existing attendees (cannot be combined with attendees)"`
+	RemoveAttendees   []string                  `json:"remove_attendees,omitempty" jsonschema:"Email addresses to remove from the existing attendees (cannot be combined with attendees)"`
+	SendUpdates       string                    `json:"send_updates,omitempty" jsonschema:"Who to email about the change: all, externalOnly, or none (default: the calendar's default behavior)"`
+	DriveAttachments  []calendarDriveAttachment `json:"drive_attachments,omitempty" jsonschema:"Google Drive files to attach to the event (adds to existing attachments). Metadata only, no file download."`
+	RemoveAttachments []string                  `json:"remove_attachments,omitempty" jsonschema:"Attachments to remove, by Drive file ID or title (see get_event)"`
+	NotesAccount      string                    `json:"notes_account,omitempty" jsonschema:"Drive account for the {{notes_link}} document (default: same as account)"`
+	ColorID           string                    `json:"color_id,omitempty" jsonschema:"New event color ID from get_colors (leave empty to keep current)"`
+	Visibility        string                    `json:"visibility,omitempty" jsonschema:"New visibility: default, public, or private (leave empty to keep current)"`
+	Transparency      string                    `json:"transparency,omitempty" jsonschema:"opaque (shows as busy) or transparent (shows as free) (leave empty to keep current)"`
+	PrivateProperties map[string]string         `json:"private_properties,omitempty" jsonschema:"Private key/value pairs to set on the event; other properties are kept. An empty value removes that key."`
+	conflictCheckInput
 }
 
 func registerUpdateEvent(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "update_event",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: `Update an existing calendar event. Only specified fields are changed; omitted fields keep their current values.
 
-To update attendees, provide the full list — it replaces the existing attendees.
+To add or remove individual attendees, use add_attendees and remove_attendees — everyone else keeps their response status. The attendees field replaces the entire list and cannot be combined with them.
+Set send_updates to control whether attendees are emailed about the change.
+Set color_id (see get_colors), visibility (default/public/private), or transparency (opaque = busy, transparent = free) to change how the event is shown.
 To change times, provide both start_time and end_time.
-To add Drive file attachments, provide drive_attachments — they are appended to any existing attachments.`,
+To add Drive file attachments, provide drive_attachments — they are appended to any existing attachments.
+To remove attachments, list their file IDs or titles in remove_attachments.
+Set description_format (text, markdown or html) to convert a new description as create_event does; descriptions longer than 8000 characters are truncated, with a note in the result.
+To set or remove private properties, pass them in private_properties (an empty value removes a key).
+Set check_conflicts to be warned when the updated event overlaps other busy events on the calendar, or fail_on_conflict to leave the event unchanged then.
+The result starts with what changed, e.g. "Changed: Start 09:00→10:00; Attendees +bob@example.com; Summary, End, Location, Description unchanged", followed by the updated event.` + templateHelp(),
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateEventInput) (*mcp.CallToolResult, any, error) {
+		if err := validateTemplate(input.Description); err != nil {
+			return nil, nil, err
+		}
+		if err := validateDescriptionFormat(input.DescriptionFormat); err != nil {
+			return nil, nil, err
+		}
+		if input.DescriptionFormat != "" && input.Description == "" {
+			return nil, nil, fmt.Errorf("description_format needs a description to convert")
+		}
+		if input.Attendees != nil && (len(input.AddAttendees) > 0 || len(input.RemoveAttendees) > 0) {
+			return nil, nil, fmt.Errorf("attendees replaces the whole list and cannot be combined with add_attendees or remove_attendees")
+		}
+		if err := validateSendUpdates(input.SendUpdates); err != nil {
+			return nil, nil, err
+		}
+		if err := validateEventDisplay(input.Visibility, input.Transparency); err != nil {
+			return nil, nil, err
+		}
+		if err := validatePrivateProperties(input.PrivateProperties, true); err != nil {
+			return nil, nil, err
+		}
+
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
@@ -326,20 +761,34 @@ To add Drive file attachments, provide drive_attachments — they are appended t
 		}
 
 		// Fetch the existing event so we can apply partial updates.
-		existing, err := svc.Events.Get(calendarID, input.EventID).Do()
+		existing, err := svc.Events.Get(calendarID, input.EventID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting event: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting event")
 		}
+		// Keep the fields diffEvents compares as they were. The updates
+		// below replace them rather than modifying them in place.
+		before := *existing
+		before.Attendees = slices.Clone(existing.Attendees)
 
 		if input.Summary != "" {
 			existing.Summary = input.Summary
 		}
-		if input.Description != "" {
-			existing.Description = input.Description
-		}
 		if input.Location != "" {
 			existing.Location = input.Location
 		}
+		if input.ColorID != "" {
+			if err := validateEventColor(ctx, svc, input.ColorID); err != nil {
+				return nil, nil, err
+			}
+			existing.ColorId = input.ColorID
+		}
+		if input.Visibility != "" {
+			existing.Visibility = input.Visibility
+		}
+		if input.Transparency != "" {
+			existing.Transparency = input.Transparency
+		}
+		applyPrivateProperties(existing, input.PrivateProperties)
 
 		// Update times if provided.
 		if input.StartTime != "" {
@@ -363,7 +812,16 @@ To add Drive file attachments, provide drive_attachments — they are appended t
 			}
 		}
 
-		// Replace attendees if provided.
+		// Check conflicts once the times are final, before Drive
+		// attachments and notes documents have side effects.
+		conflicts, err := checkConflicts(ctx, svc, calendarID, existing, input.EventID, input.conflictCheckInput)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Replace or merge attendees if provided.
+		hadAttendees := len(existing.Attendees) > 0
+		var attendeesNotFound []string
 		if input.Attendees != nil {
 			existing.Attendees = nil
 			for _, email := range input.Attendees {
@@ -371,6 +829,19 @@ To add Drive file attachments, provide drive_attachments — they are appended t
 					Email: email,
 				})
 			}
+		} else if len(input.AddAttendees) > 0 || len(input.RemoveAttendees) > 0 {
+			existing.Attendees, attendeesNotFound, err = mergeAttendees(existing.Attendees, input.AddAttendees, input.RemoveAttendees)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Remove attachments before adding, so a file can be replaced in
+		// one call.
+		hadAttachments := len(existing.Attachments) > 0
+		var notFound []string
+		if len(input.RemoveAttachments) > 0 {
+			existing.Attachments, notFound = removeEventAttachments(existing.Attachments, input.RemoveAttachments)
 		}
 
 		// Add Drive attachments (appended to any existing attachments).
@@ -382,36 +853,211 @@ To add Drive file attachments, provide drive_attachments — they are appended t
 			existing.Attachments = append(existing.Attachments, attachments...)
 		}
 
+		// Expand the new description after all other fields are merged so
+		// that template variables reflect the updated event.
+		var descriptionNote string
+		notesAccount := input.NotesAccount
+		if notesAccount == "" {
+			notesAccount = input.Account
+		}
+		notes := newMeetingNotes(ctx, mgr, notesAccount, existing)
+		if input.Description != "" {
+			existing.Description, err = renderDescription(input.Description, existing, notes.create)
+			if err != nil {
+				return nil, nil, err
+			}
+			existing.Description, descriptionNote = formatDescription(existing.Description, input.DescriptionFormat)
+		}
+
+		// Send an explicit empty list when the last attachment was removed;
+		// an omitted field would leave the attachments untouched.
+		if hadAttachments && len(existing.Attachments) == 0 {
+			existing.ForceSendFields = append(existing.ForceSendFields, "Attachments")
+		}
+		if hadAttendees && len(existing.Attendees) == 0 {
+			existing.ForceSendFields = append(existing.ForceSendFields, "Attendees")
+		}
+
 		call := svc.Events.Update(calendarID, input.EventID, existing)
-		if len(existing.Attachments) > 0 {
+		if input.SendUpdates != "" {
+			call = call.SendUpdates(input.SendUpdates)
+		}
+		// Attachments are only written when SupportsAttachments is set, so it
+		// is also needed to clear the last one.
+		if hadAttachments || len(existing.Attachments) > 0 {
 			call = call.SupportsAttachments(true)
 		}
-		updated, err := call.Do()
+		updated, err := call.Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating event: %w", err)
+			return nil, nil, notes.discard(gerrors.Wrap(err, "updating event"))
 		}
 
+		text := fmt.Sprintf("Event updated.\n%s\n\nEvent ID: %s\nLink: %s\n\n%s%s",
+			diffEvents(&before, updated), updated.Id, updated.HtmlLink, formatEvent(updated, input.Account), conflictWarning(conflicts))
+		if len(notFound) > 0 {
+			text += fmt.Sprintf("\nNote: no attachment matched %s; nothing removed for those.", strings.Join(notFound, ", "))
+		}
+		if len(attendeesNotFound) > 0 {
+			text += fmt.Sprintf("\nNote: not attendees, nothing removed: %s.", strings.Join(attendeesNotFound, ", "))
+		}
+		text += descriptionNote
+
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Event updated.\n\nEvent ID: %s\nLink: %s\n\n%s",
-					updated.Id, updated.HtmlLink, formatEvent(updated, input.Account))},
+				&mcp.TextContent{Text: text},
 			},
 		}, nil, nil
 	})
 }
 
+// validateEventDisplay checks the visibility and transparency values. Empty
+// values are allowed and leave the API default (or current value).
+func validateEventDisplay(visibility, transparency string) error {
+	switch visibility {
+	case "", "default", "public", "private":
+	default:
+		return fmt.Errorf("invalid visibility %q: must be default, public, or private", visibility)
+	}
+	switch transparency {
+	case "", "opaque", "transparent":
+	default:
+		return fmt.Errorf("invalid transparency %q: must be opaque (busy) or transparent (free)", transparency)
+	}
+	return nil
+}
+
+// validateEventColor checks colorID against the account's event color
+// palette. An empty ID is always valid.
+func validateEventColor(ctx context.Context, svc *calendar.Service, colorID string) error {
+	if colorID == "" {
+		return nil
+	}
+	colors, err := svc.Colors.Get().Context(ctx).Do()
+	if err != nil {
+		return gerrors.Wrap(err, "getting colors")
+	}
+	if _, ok := colors.Event[colorID]; ok {
+		return nil
+	}
+	ids := make([]string, 0, len(colors.Event))
+	for id := range colors.Event {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+	return fmt.Errorf("invalid color_id %q: must be one of %s (see get_colors)", colorID, strings.Join(ids, ", "))
+}
+
+// validateSendUpdates checks the send_updates value accepted by the Events
+// API. An empty value leaves the choice to the API.
+func validateSendUpdates(v string) error {
+	switch v {
+	case "", "all", "externalOnly", "none":
+		return nil
+	}
+	return fmt.Errorf("invalid send_updates %q: must be all, externalOnly, or none", v)
+}
+
+// mergeAttendees removes and adds attendees by email (ignoring case) without
+// touching anyone else, so existing attendees keep their response status and
+// optional flag. Emails to add that are already attendees are left as they
+// are. It returns the new list and the emails to remove that weren't
+// attendees.
+func mergeAttendees(existing []*calendar.EventAttendee, add, remove []string) ([]*calendar.EventAttendee, []string, error) {
+	removeSet := make(map[string]bool, len(remove))
+	for _, email := range remove {
+		removeSet[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+	for _, email := range add {
+		if removeSet[strings.ToLower(strings.TrimSpace(email))] {
+			return nil, nil, fmt.Errorf("%s is in both add_attendees and remove_attendees", email)
+		}
+	}
+
+	var merged []*calendar.EventAttendee
+	present := make(map[string]bool, len(existing))
+	removed := make(map[string]bool, len(remove))
+	for _, a := range existing {
+		key := strings.ToLower(a.Email)
+		if removeSet[key] {
+			removed[key] = true
+			continue
+		}
+		present[key] = true
+		merged = append(merged, a)
+	}
+
+	for _, email := range add {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		key := strings.ToLower(email)
+		if present[key] {
+			continue
+		}
+		present[key] = true
+		merged = append(merged, &calendar.EventAttendee{Email: email})
+	}
+
+	var notFound []string
+	for _, email := range remove {
+		if !removed[strings.ToLower(strings.TrimSpace(email))] {
+			notFound = append(notFound, email)
+		}
+	}
+	return merged, notFound, nil
+}
+
+// removeEventAttachments drops the attachments whose file ID or title
+// (ignoring case) matches one of refs. It returns the remaining attachments
+// and the refs that matched nothing.
+func removeEventAttachments(attachments []*calendar.EventAttachment, refs []string) ([]*calendar.EventAttachment, []string) {
+	matched := make([]bool, len(refs))
+	var kept []*calendar.EventAttachment
+	for _, att := range attachments {
+		remove := false
+		for i, ref := range refs {
+			if (att.FileId != "" && att.FileId == ref) || (att.Title != "" && strings.EqualFold(att.Title, ref)) {
+				matched[i] = true
+				remove = true
+			}
+		}
+		if !remove {
+			kept = append(kept, att)
+		}
+	}
+
+	var notFound []string
+	for i, ref := range refs {
+		if !matched[i] {
+			notFound = append(notFound, fmt.Sprintf("%q", ref))
+		}
+	}
+	return kept, notFound
+}
+
 // --- delete_event ---
 
 type deleteEventInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	EventID    string `json:"event_id" jsonschema:"Event ID to delete"`
 }
 
 func registerDeleteEvent(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "delete_event",
-		Annotations: &mcp.ToolAnnotations{},
+		Name: "delete_event",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 		Description: "Delete a calendar event by ID. The event is kept in trash for 30 days before permanent removal.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteEventInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -424,8 +1070,8 @@ func registerDeleteEvent(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		if err := svc.Events.Delete(calendarID, input.EventID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting event: %w", err)
+		if err := svc.Events.Delete(calendarID, input.EventID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting event")
 		}
 
 		return &mcp.CallToolResult{
@@ -439,31 +1085,58 @@ func registerDeleteEvent(srv *server.Server, mgr *auth.Manager) {
 // --- respond_event ---
 
 type respondEventInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
-	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
-	EventID    string `json:"event_id" jsonschema:"Event ID to respond to"`
-	Response   string `json:"response" jsonschema:"Response status: 'accepted', 'declined', or 'tentative'"`
+	Account        string        `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID     string        `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	EventID        string        `json:"event_id" jsonschema:"Event ID to respond to"`
+	Response       string        `json:"response,omitempty" jsonschema:"Response status: 'accepted', 'declined', or 'tentative' (optional with propose_new_time, which always declines)"`
+	Comment        string        `json:"comment,omitempty" jsonschema:"Comment to the organizer, shown with your response"`
+	ProposeNewTime *proposedTime `json:"propose_new_time,omitempty" jsonschema:"Propose a different time: declines the event with a comment stating the proposed slot"`
+	CreateHold     bool          `json:"create_hold,omitempty" jsonschema:"With propose_new_time: also block the proposed slot with a tentative hold on your primary calendar (default: false)"`
 }
 
 func registerRespondEvent(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "respond_event",
-		Description: `Respond to a calendar event invitation. Sets your attendance status.
+		Description: `Respond to a calendar event invitation. Sets your attendance status, optionally with a comment to the organizer.
 
 Valid responses:
   - "accepted" — Accept the invitation
   - "declined" — Decline the invitation
-  - "tentative" — Tentatively accept the invitation`,
+  - "tentative" — Tentatively accept the invitation
+
+To propose a new time, set propose_new_time. The Calendar API has no native proposals, so the event is declined with a comment starting "Proposed new time: <start> to <end>", followed by your comment. Set create_hold to also block the proposed slot with a tentative hold on your primary calendar; proposing again for the same event moves the existing hold.`,
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
 			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input respondEventInput) (*mcp.CallToolResult, any, error) {
-		// Validate response value.
-		switch input.Response {
-		case "accepted", "declined", "tentative":
-		default:
-			return nil, nil, fmt.Errorf("invalid response %q: must be 'accepted', 'declined', or 'tentative'", input.Response)
+		response := input.Response
+		if input.ProposeNewTime != nil {
+			if response != "" && response != "declined" {
+				return nil, nil, fmt.Errorf("propose_new_time declines the event; response must be empty or 'declined', not %q", response)
+			}
+			response = "declined"
+		} else if input.CreateHold {
+			return nil, nil, fmt.Errorf("create_hold requires propose_new_time")
+		}
+
+		if err := validateResponse(response); err != nil {
+			return nil, nil, err
+		}
+
+		comment := input.Comment
+		var proposedStart, proposedEnd time.Time
+		if input.ProposeNewTime != nil {
+			var err error
+			proposedStart, proposedEnd, err = parseProposal(input.ProposeNewTime, func() (*time.Location, error) {
+				return accountTimeZone(ctx, mgr, input.Account)
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			comment = proposalComment(proposedStart, proposedEnd, input.Comment)
 		}
 
 		svc, err := newService(ctx, mgr, input.Account)
@@ -477,56 +1150,159 @@ Valid responses:
 		}
 
 		// Fetch the event to find our attendee entry.
-		event, err := svc.Events.Get(calendarID, input.EventID).Do()
+		event, err := svc.Events.Get(calendarID, input.EventID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting event: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting event")
+		}
+		patch, err := responsePatch(event, response, comment)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		// Find the attendee entry for the authenticated user (Self: true).
-		found := false
-		for _, a := range event.Attendees {
-			if a.Self {
-				a.ResponseStatus = input.Response
-				found = true
-				break
+		// Create or move the hold first, so the declined event can link to it.
+		var hold *calendar.Event
+		var holdMoved bool
+		if input.CreateHold {
+			hold, holdMoved, err = upsertProposalHold(ctx, svc, event, calendarID, proposedStart, proposedEnd)
+			if err != nil {
+				return nil, nil, err
+			}
+			patch.ExtendedProperties = &calendar.EventExtendedProperties{
+				Private: map[string]string{proposalHoldProperty: hold.Id},
 			}
 		}
 
-		if !found {
-			return nil, nil, fmt.Errorf("you are not listed as an attendee of this event")
+		updated, err := svc.Events.Patch(calendarID, input.EventID, patch).Context(ctx).Do()
+		if err != nil {
+			if hold != nil {
+				return nil, nil, gerrors.Wrapf(err, "updating response (the hold %s was already saved on your primary calendar)", hold.Id)
+			}
+			return nil, nil, gerrors.Wrap(err, "updating response")
 		}
 
-		updated, err := svc.Events.Patch(calendarID, input.EventID, &calendar.Event{
-			Attendees: event.Attendees,
-		}).Do()
-		if err != nil {
-			return nil, nil, fmt.Errorf("updating response: %w", err)
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Response updated to %q for event %q.\n", response, updated.Summary)
+		if input.ProposeNewTime != nil {
+			fmt.Fprintf(&sb, "Proposed new time: %s to %s (sent as your decline comment; the organizer has to reschedule the event).\n",
+				proposedStart.Format(time.RFC3339), proposedEnd.Format(time.RFC3339))
+		}
+		if comment != "" {
+			fmt.Fprintf(&sb, "Comment: %s\n", comment)
+		}
+		if hold != nil {
+			verb := "created"
+			if holdMoved {
+				verb = "moved to the proposed time"
+			}
+			fmt.Fprintf(&sb, "Tentative hold %s on your primary calendar: Event ID %s\n", verb, hold.Id)
+			if hold.HtmlLink != "" {
+				fmt.Fprintf(&sb, "Hold link: %s\n", hold.HtmlLink)
+			}
 		}
+		sb.WriteString("\n")
+		sb.WriteString(formatEvent(updated, input.Account))
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Response updated to %q for event %q.\n\n%s",
-					input.Response, updated.Summary, formatEvent(updated, input.Account))},
+				&mcp.TextContent{Text: sb.String()},
 			},
 		}, nil, nil
 	})
 }
 
+// validateResponse checks the response input of respond_event and
+// respond_events_bulk.
+func validateResponse(response string) error {
+	switch response {
+	case "accepted", "declined", "tentative":
+		return nil
+	}
+	return fmt.Errorf("invalid response %q: must be 'accepted', 'declined', or 'tentative'", response)
+}
+
+// responsePatch sets the response status of the calendar owner's attendee
+// entry (Self: true) in event, with comment unless it is empty, and returns
+// the patch that saves it. The patch carries every attendee, since Patch
+// replaces the whole list.
+func responsePatch(event *calendar.Event, response, comment string) (*calendar.Event, error) {
+	switch event.EventType {
+	case "workingLocation", "outOfOffice", "focusTime":
+		return nil, fmt.Errorf("event %s is a %s event, which has no invitation to respond to", event.Id, event.EventType)
+	}
+	a := selfAttendee(event)
+	if a == nil {
+		return nil, fmt.Errorf("you are not listed as an attendee of this event")
+	}
+	a.ResponseStatus = response
+	if comment != "" {
+		a.Comment = comment
+	}
+	return &calendar.Event{Attendees: event.Attendees}, nil
+}
+
+// selfAttendee returns the calendar owner's attendee entry of event, or nil.
+func selfAttendee(event *calendar.Event) *calendar.EventAttendee {
+	for _, a := range event.Attendees {
+		if a.Self {
+			return a
+		}
+	}
+	return nil
+}
+
+// upsertProposalHold creates the hold for a proposed new time for event on
+// the primary calendar, or moves the hold created by an earlier proposal.
+// moved reports whether an existing hold was moved.
+func upsertProposalHold(ctx context.Context, svc *calendar.Service, event *calendar.Event, calendarID string, start, end time.Time) (hold *calendar.Event, moved bool, err error) {
+	want := proposalHold(event, calendarID, start, end)
+	existing, err := findProposalHold(ctx, svc, "primary", event.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		hold, err = svc.Events.Patch("primary", existing.Id, &calendar.Event{
+			Summary:     want.Summary,
+			Description: want.Description,
+			Start:       want.Start,
+			End:         want.End,
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, false, gerrors.Wrapf(err, "moving hold %s", existing.Id)
+		}
+		return hold, true, nil
+	}
+	hold, err = svc.Events.Insert("primary", want).Context(ctx).Do()
+	if err != nil {
+		return nil, false, gerrors.Wrap(err, "creating hold")
+	}
+	return hold, false, nil
+}
+
 // --- quick_add_event ---
 
 type quickAddEventInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	Text       string `json:"text" jsonschema:"Natural language event description (e.g. 'Lunch with Bob tomorrow at noon')"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"Only show how Google parses the text, without keeping the event (default: false)"`
 }
 
+// quickAddHorizon is how far ahead a parsed start may be before
+// quick_add_event warns about it.
+const quickAddHorizon = 365 * 24 * time.Hour
+
 func registerQuickAddEvent(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "quick_add_event",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
-		Description: "Create a calendar event from a natural language description (e.g. \"Lunch with Bob tomorrow at noon\"). Google parses the text to extract event details.",
+		Description: `Create a calendar event from a natural language description (e.g. "Lunch with Bob tomorrow at noon"). Google parses the text to extract event details, and sometimes gets the day or time wrong; the result warns when the parsed start is in the past or more than a year away.
+
+Set dry_run to see what Google parses before creating anything. The API has no preview, so a dry run creates the event on the calendar without notifying anyone and deletes it right away; it may briefly show up in calendar clients and stays in the calendar's deleted events.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input quickAddEventInput) (*mcp.CallToolResult, any, error) {
 		if input.Text == "" {
 			return nil, nil, fmt.Errorf("text is required")
@@ -542,29 +1318,107 @@ func registerQuickAddEvent(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		created, err := svc.Events.QuickAdd(calendarID, input.Text).Do()
+		call := svc.Events.QuickAdd(calendarID, input.Text)
+		if input.DryRun {
+			call = call.SendUpdates("none")
+		}
+		created, err := call.Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("quick-adding event: %w", err)
+			return nil, nil, gerrors.Wrap(err, "quick-adding event")
+		}
+		warnings := quickAddWarnings(created, time.Now())
+
+		if input.DryRun {
+			if err := svc.Events.Delete(calendarID, created.Id).SendUpdates("none").Context(ctx).Do(); err != nil {
+				return nil, nil, gerrors.Wrapf(err, "deleting preview event %s (delete it with delete_event)", created.Id)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: formatQuickAddPreview(created, calendarID, warnings)},
+				},
+			}, nil, nil
 		}
 
+		text := fmt.Sprintf("Event created.\n\nEvent ID: %s\nLink: %s\n\n%s",
+			created.Id, created.HtmlLink, formatEvent(created, input.Account))
+		for _, w := range warnings {
+			text += "\nWarning: " + w
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Event created.\n\nEvent ID: %s\nLink: %s\n\n%s",
-					created.Id, created.HtmlLink, formatEvent(created, input.Account))},
+				&mcp.TextContent{Text: text},
 			},
 		}, nil, nil
 	})
 }
 
+// quickAddWarnings flags parsed starts that are likely misparses: in the
+// past, or more than a year after now. All-day events count from the start
+// of their day in UTC.
+func quickAddWarnings(event *calendar.Event, now time.Time) []string {
+	if event.Start == nil {
+		return nil
+	}
+	start, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	allDay := false
+	if event.Start.DateTime == "" {
+		start, err = time.Parse(time.DateOnly, event.Start.Date)
+		allDay = true
+	}
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	switch {
+	case allDay && start.Before(now.UTC().Truncate(24*time.Hour)),
+		!allDay && start.Before(now):
+		warnings = append(warnings, fmt.Sprintf("the parsed start %s is in the past; check the date in the text", eventDateTime(event.Start)))
+	case start.After(now.Add(quickAddHorizon)):
+		warnings = append(warnings, fmt.Sprintf("the parsed start %s is more than a year away; check the date in the text", eventDateTime(event.Start)))
+	}
+	return warnings
+}
+
+// formatQuickAddPreview formats the result of a quick_add_event dry run:
+// what Google parsed from the text, and how the preview was made.
+func formatQuickAddPreview(event *calendar.Event, calendarID string, warnings []string) string {
+	var sb strings.Builder
+	sb.WriteString("Dry run: nothing was created.\n\nGoogle parsed the text as:\n")
+	fmt.Fprintf(&sb, "Summary: %s\n", event.Summary)
+	if start := eventDateTime(event.Start); start != "" {
+		fmt.Fprintf(&sb, "Start: %s\n", start)
+	}
+	if end := eventDateTime(event.End); end != "" {
+		fmt.Fprintf(&sb, "End: %s\n", end)
+	}
+	if event.Location != "" {
+		fmt.Fprintf(&sb, "Location: %s\n", event.Location)
+	}
+	if len(event.Attendees) > 0 {
+		emails := make([]string, len(event.Attendees))
+		for i, a := range event.Attendees {
+			emails[i] = a.Email
+		}
+		fmt.Fprintf(&sb, "Attendees: %s\n", strings.Join(emails, ", "))
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(&sb, "\nWarning: %s\n", w)
+	}
+	fmt.Fprintf(&sb, "\nTo preview it, the event was created on %s without notifications and deleted right away. Call again without dry_run to create it, or use create_event to set the times exactly.\n", calendarID)
+	return sb.String()
+}
+
 // --- list_event_instances ---
 
 type listEventInstancesInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
 	EventID    string `json:"event_id" jsonschema:"Recurring event ID to list instances of"`
 	TimeMin    string `json:"time_min,omitempty" jsonschema:"Start of time range in RFC3339 format. Default: now"`
 	TimeMax    string `json:"time_max,omitempty" jsonschema:"End of time range in RFC3339 format. Default: 30 days from now"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of instances to return (default 25, max 100)"`
+	DisplayTZ  string `json:"display_timezone,omitempty" jsonschema:"IANA timezone (e.g. 'Europe/Berlin') to show start and end times in, with the zone abbreviation and the original UTC offset when it differs. All-day dates are never converted. Default: times as the calendar returns them"`
 }
 
 func registerListEventInstances(srv *server.Server, mgr *auth.Manager) {
@@ -572,9 +1426,16 @@ func registerListEventInstances(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_event_instances",
 		Description: "List individual occurrences of a recurring calendar event. Use this to see when a repeating event occurs within a time range.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listEventInstancesInput) (*mcp.CallToolResult, any, error) {
+		loc, err := displayLocation(input.DisplayTZ)
+		if err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
@@ -607,9 +1468,10 @@ func registerListEventInstances(srv *server.Server, mgr *auth.Manager) {
 			TimeMin(timeMin).
 			TimeMax(timeMax).
 			MaxResults(maxResults).
+			Fields(listEventsFields).Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing event instances: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing event instances")
 		}
 
 		var sb strings.Builder
@@ -618,7 +1480,7 @@ func registerListEventInstances(srv *server.Server, mgr *auth.Manager) {
 		} else {
 			fmt.Fprintf(&sb, "Found %d instances:\n\n", len(resp.Items))
 			for _, event := range resp.Items {
-				sb.WriteString(formatEvent(event, input.Account))
+				sb.WriteString(formatEvent(eventInZone(event, loc, true), input.Account))
 				sb.WriteString("\n")
 			}
 		}
@@ -634,7 +1496,7 @@ func registerListEventInstances(srv *server.Server, mgr *auth.Manager) {
 // --- move_event ---
 
 type moveEventInput struct {
-	Account       string `json:"account" jsonschema:"Account name"`
+	Account       string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	CalendarID    string `json:"calendar_id,omitempty" jsonschema:"Source calendar ID (default: 'primary')"`
 	EventID       string `json:"event_id" jsonschema:"Event ID to move"`
 	DestinationID string `json:"destination_id" jsonschema:"Destination calendar ID"`
@@ -645,7 +1507,10 @@ func registerMoveEvent(srv *server.Server, mgr *auth.Manager) {
 		Name:        "move_event",
 		Description: "Move a calendar event to a different calendar. The event is removed from the source calendar and added to the destination.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input moveEventInput) (*mcp.CallToolResult, any, error) {
 		if input.DestinationID == "" {
@@ -662,9 +1527,9 @@ func registerMoveEvent(srv *server.Server, mgr *auth.Manager) {
 			calendarID = "primary"
 		}
 
-		moved, err := svc.Events.Move(calendarID, input.EventID, input.DestinationID).Do()
+		moved, err := svc.Events.Move(calendarID, input.EventID, input.DestinationID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("moving event: %w", err)
+			return nil, nil, gerrors.Wrap(err, "moving event")
 		}
 
 		return &mcp.CallToolResult{
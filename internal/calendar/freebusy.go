@@ -9,6 +9,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/calendar/v3"
 )
@@ -16,7 +17,7 @@ import (
 // --- query_free_busy ---
 
 type queryFreeBusyInput struct {
-	Account   string   `json:"account" jsonschema:"Account name"`
+	Account   string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Calendars []string `json:"calendars" jsonschema:"Calendar IDs or email addresses to check availability for"`
 	TimeMin   string   `json:"time_min" jsonschema:"Start of time range in RFC3339 format (e.g. '2024-01-15T00:00:00Z')"`
 	TimeMax   string   `json:"time_max" jsonschema:"End of time range in RFC3339 format"`
@@ -28,7 +29,10 @@ func registerQueryFreeBusy(srv *server.Server, mgr *auth.Manager) {
 		Name:        "query_free_busy",
 		Description: "Check availability (free/busy) for one or more users or calendars within a time range. Useful for finding open slots before scheduling meetings.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input queryFreeBusyInput) (*mcp.CallToolResult, any, error) {
 		if len(input.Calendars) == 0 {
@@ -58,9 +62,9 @@ func registerQueryFreeBusy(srv *server.Server, mgr *auth.Manager) {
 			Items:    items,
 		}
 
-		resp, err := svc.Freebusy.Query(fbReq).Do()
+		resp, err := svc.Freebusy.Query(fbReq).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("querying free/busy: %w", err)
+			return nil, nil, gerrors.Wrap(err, "querying free/busy")
 		}
 
 		var sb strings.Builder
@@ -115,6 +119,123 @@ func registerQueryFreeBusy(srv *server.Server, mgr *auth.Manager) {
 	})
 }
 
+// --- find_available_room ---
+
+// maxFreeBusyItems is the number of calendars a Freebusy.Query call
+// accepts.
+const maxFreeBusyItems = 50
+
+type findRoomInput struct {
+	Account  string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Rooms    []string `json:"rooms" jsonschema:"Email addresses of the room (resource) calendars to check, e.g. 'c_1234@resource.calendar.google.com' (at most 50)"`
+	TimeMin  string   `json:"time_min" jsonschema:"Start of the meeting in RFC3339 format (e.g. '2024-01-15T09:00:00-05:00')"`
+	TimeMax  string   `json:"time_max" jsonschema:"End of the meeting in RFC3339 format"`
+	TimeZone string   `json:"time_zone,omitempty" jsonschema:"IANA timezone for the busy periods in the response (default: UTC)"`
+}
+
+func registerFindAvailableRoom(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "find_available_room",
+		Description: "Find which of a list of conference rooms (or other resource calendars) are free for a whole time window. Returns the free rooms first, then the busy ones with their busy periods, and rooms whose availability can't be read (e.g. no access). Book a free room by adding it to create_event's attendees as {\"email\": ..., \"resource\": true}.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input findRoomInput) (*mcp.CallToolResult, any, error) {
+		if len(input.Rooms) == 0 {
+			return nil, nil, fmt.Errorf("rooms is required: provide at least one room calendar email")
+		}
+		if len(input.Rooms) > maxFreeBusyItems {
+			return nil, nil, fmt.Errorf("too many rooms: %d (max %d)", len(input.Rooms), maxFreeBusyItems)
+		}
+		start, err := time.Parse(time.RFC3339, input.TimeMin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time_min %q: use RFC3339, e.g. 2024-01-15T09:00:00-05:00", input.TimeMin)
+		}
+		end, err := time.Parse(time.RFC3339, input.TimeMax)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time_max %q: use RFC3339, e.g. 2024-01-15T10:00:00-05:00", input.TimeMax)
+		}
+		if !end.After(start) {
+			return nil, nil, fmt.Errorf("time_max must be after time_min")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		items := make([]*calendar.FreeBusyRequestItem, len(input.Rooms))
+		for i, room := range input.Rooms {
+			items[i] = &calendar.FreeBusyRequestItem{Id: room}
+		}
+		resp, err := svc.Freebusy.Query(&calendar.FreeBusyRequest{
+			TimeMin:  input.TimeMin,
+			TimeMax:  input.TimeMax,
+			TimeZone: input.TimeZone,
+			Items:    items,
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "querying free/busy")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatRoomAvailability(input.Rooms, resp)},
+			},
+		}, nil, nil
+	})
+}
+
+// formatRoomAvailability formats a free/busy response for
+// find_available_room, keeping the order the rooms were given in.
+func formatRoomAvailability(rooms []string, resp *calendar.FreeBusyResponse) string {
+	var free, busy, failed []string
+	busyPeriods := make(map[string][]*calendar.TimePeriod)
+	for _, room := range rooms {
+		fbCal, ok := resp.Calendars[room]
+		switch {
+		case !ok:
+			failed = append(failed, room+": not in the response")
+		case len(fbCal.Errors) > 0:
+			var reasons []string
+			for _, e := range fbCal.Errors {
+				reasons = append(reasons, e.Reason)
+			}
+			failed = append(failed, room+": "+strings.Join(reasons, ", "))
+		case len(fbCal.Busy) == 0:
+			free = append(free, room)
+		default:
+			busy = append(busy, room)
+			busyPeriods[room] = fbCal.Busy
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Rooms free from %s to %s: %d of %d\n", resp.TimeMin, resp.TimeMax, len(free), len(rooms))
+	for _, room := range free {
+		fmt.Fprintf(&sb, "  - %s\n", room)
+	}
+	if len(busy) > 0 {
+		sb.WriteString("\nBusy:\n")
+		for _, room := range busy {
+			fmt.Fprintf(&sb, "  - %s\n", room)
+			for _, period := range busyPeriods[room] {
+				fmt.Fprintf(&sb, "      %s to %s\n", period.Start, period.End)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		sb.WriteString("\nUnavailable to check:\n")
+		for _, f := range failed {
+			fmt.Fprintf(&sb, "  - %s\n", f)
+		}
+	}
+	return sb.String()
+}
+
 // formatDuration formats a duration in a human-readable way.
 func formatDuration(d time.Duration) string {
 	hours := int(d.Hours())
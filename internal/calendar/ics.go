@@ -0,0 +1,1111 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+)
+
+// icsProdID identifies this server as the producer of exported documents.
+const icsProdID = "-//google-mcp//Calendar Export//EN"
+
+// icsMaxLineOctets is the RFC 5545 line length limit, excluding CRLF.
+const icsMaxLineOctets = 75
+
+// formatICS renders events as an iCalendar (RFC 5545) document. stamp is
+// used for every DTSTAMP. Events that can't be represented (no start or
+// end) are left out and described in skipped.
+//
+// Timed events are written in UTC, except recurring masters with a time
+// zone, which keep their TZID so that the RRULE expands in local time
+// across DST changes. Recurrence lines (RRULE, EXDATE, RDATE) are passed
+// through as Google returns them. Every TZID used gets a VTIMEZONE
+// component, as RFC 5545 requires.
+func formatICS(events []*calendar.Event, stamp time.Time) (doc string, skipped []string) {
+	var vevents []string
+	zones := make(map[string]time.Time) // TZID -> earliest event using it
+	var zoneOrder []string
+	for _, ev := range events {
+		lines, err := icsEventLines(ev, stamp)
+		if err != nil {
+			name := ev.Summary
+			if name == "" {
+				name = "(no title)"
+			}
+			skipped = append(skipped, fmt.Sprintf("%s (%s): %v", name, ev.Id, err))
+			continue
+		}
+		start := icsEventStart(ev, stamp)
+		for _, l := range lines {
+			for _, m := range icsTZIDPattern.FindAllStringSubmatch(l, -1) {
+				first, ok := zones[m[1]]
+				if !ok {
+					zoneOrder = append(zoneOrder, m[1])
+				}
+				if !ok || start.Before(first) {
+					zones[m[1]] = start
+				}
+			}
+		}
+		vevents = append(vevents, lines...)
+	}
+
+	var sb strings.Builder
+	writeICSLine(&sb, "BEGIN:VCALENDAR")
+	writeICSLine(&sb, "VERSION:2.0")
+	writeICSLine(&sb, "PRODID:"+icsProdID)
+	writeICSLine(&sb, "CALSCALE:GREGORIAN")
+	for _, tzid := range zoneOrder {
+		for _, l := range icsTimezoneLines(tzid, zones[tzid]) {
+			writeICSLine(&sb, l)
+		}
+	}
+	for _, l := range vevents {
+		writeICSLine(&sb, l)
+	}
+	writeICSLine(&sb, "END:VCALENDAR")
+	return sb.String(), skipped
+}
+
+// icsTZIDPattern matches the TZID parameter of a property line, such as
+// the one of "EXDATE;TZID=Europe/Berlin:20240605T093000".
+var icsTZIDPattern = regexp.MustCompile(`;TZID=([^;:]+)`)
+
+// icsEventStart returns when ev starts, or stamp if that can't be told.
+func icsEventStart(ev *calendar.Event, stamp time.Time) time.Time {
+	for _, t := range []*calendar.EventDateTime{ev.Start, ev.OriginalStartTime} {
+		if t == nil {
+			continue
+		}
+		if ts, err := time.Parse(time.RFC3339, t.DateTime); err == nil {
+			return ts
+		}
+		if ts, err := time.Parse(time.DateOnly, t.Date); err == nil {
+			return ts
+		}
+	}
+	return stamp
+}
+
+// icsEventLines returns the unfolded lines of a VEVENT block for ev.
+func icsEventLines(ev *calendar.Event, stamp time.Time) ([]string, error) {
+	tzid := ""
+	if len(ev.Recurrence) > 0 && ev.Start != nil {
+		tzid = ev.Start.TimeZone
+	}
+	start, err := icsDateTime("DTSTART", ev.Start, tzid)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	end, err := icsDateTime("DTEND", ev.End, tzid)
+	if err != nil {
+		return nil, fmt.Errorf("end: %w", err)
+	}
+
+	uid := ev.ICalUID
+	if uid == "" {
+		uid = ev.Id + "@google.com"
+	}
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"),
+		start,
+		end,
+	}
+	if ev.OriginalStartTime != nil {
+		// A modified or cancelled instance of a recurring event. Its ID
+		// uses the master's time zone so that it matches the master's
+		// DTSTART.
+		rid, err := icsDateTime("RECURRENCE-ID", ev.OriginalStartTime, ev.OriginalStartTime.TimeZone)
+		if err == nil {
+			lines = append(lines, rid)
+		}
+	}
+	lines = append(lines, ev.Recurrence...)
+	if ev.Summary != "" {
+		lines = append(lines, "SUMMARY:"+icsEscape(ev.Summary))
+	}
+	if ev.Location != "" {
+		lines = append(lines, "LOCATION:"+icsEscape(ev.Location))
+	}
+	if ev.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icsEscape(ev.Description))
+	}
+	switch ev.Status {
+	case "confirmed", "tentative", "cancelled":
+		lines = append(lines, "STATUS:"+strings.ToUpper(ev.Status))
+	}
+	if ev.Sequence > 0 {
+		lines = append(lines, fmt.Sprintf("SEQUENCE:%d", ev.Sequence))
+	}
+	return append(lines, "END:VEVENT"), nil
+}
+
+// icsDateTime formats an event time as a property line. All-day times use
+// VALUE=DATE. Timed values are converted to UTC unless tzid names a
+// loadable time zone, in which case they are written as local time with a
+// TZID parameter.
+func icsDateTime(prop string, t *calendar.EventDateTime, tzid string) (string, error) {
+	if t == nil || (t.Date == "" && t.DateTime == "") {
+		return "", fmt.Errorf("missing")
+	}
+	if t.DateTime == "" {
+		d, err := time.Parse(time.DateOnly, t.Date)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q", t.Date)
+		}
+		return prop + ";VALUE=DATE:" + d.Format("20060102"), nil
+	}
+	ts, err := time.Parse(time.RFC3339, t.DateTime)
+	if err != nil {
+		return "", fmt.Errorf("invalid dateTime %q", t.DateTime)
+	}
+	if tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return prop + ";TZID=" + tzid + ":" + ts.In(loc).Format("20060102T150405"), nil
+		}
+	}
+	return prop + ":" + ts.UTC().Format("20060102T150405Z"), nil
+}
+
+// icsTimezoneLines returns the VTIMEZONE component for tzid, for events
+// from the given time on. Each DST change is written as a yearly
+// STANDARD or DAYLIGHT observance, derived from the zone's transitions in
+// the year before from and checked against the following years; a zone
+// without DST gets a single STANDARD observance. It returns nil for a
+// zone that can't be loaded.
+func icsTimezoneLines(tzid string, from time.Time) []string {
+	loc, err := time.LoadLocation(tzid)
+	if err != nil {
+		return nil
+	}
+	year := from.In(loc).Year() - 1
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + tzid}
+	transitions := zoneTransitions(loc, year)
+	if len(transitions) == 0 {
+		t := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		name, offset := t.Zone()
+		lines = append(lines,
+			"BEGIN:STANDARD",
+			"DTSTART:"+t.Format("20060102T150405"),
+			"TZOFFSETFROM:"+icsOffset(offset),
+			"TZOFFSETTO:"+icsOffset(offset),
+			"TZNAME:"+name,
+			"END:STANDARD")
+	}
+	next := zoneTransitions(loc, year+1)
+	for _, t := range transitions {
+		kind := "STANDARD"
+		if t.In(loc).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		_, before := t.Add(-time.Second).In(loc).Zone()
+		name, after := t.In(loc).Zone()
+		// DTSTART is the local time of the change in the offset before it.
+		local := t.In(time.FixedZone("", before))
+		lines = append(lines, "BEGIN:"+kind, "DTSTART:"+local.Format("20060102T150405"))
+		if rule := yearlyRule(local, next, before); rule != "" {
+			lines = append(lines, rule)
+		}
+		lines = append(lines,
+			"TZOFFSETFROM:"+icsOffset(before),
+			"TZOFFSETTO:"+icsOffset(after),
+			"TZNAME:"+name,
+			"END:"+kind)
+	}
+	return append(lines, "END:VTIMEZONE")
+}
+
+// zoneTransitions returns the instants in year at which loc changes its
+// UTC offset or zone name.
+func zoneTransitions(loc *time.Location, year int) []time.Time {
+	var out []time.Time
+	t := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+	end := time.Date(year+1, 1, 1, 0, 0, 0, 0, loc)
+	for {
+		_, next := t.ZoneBounds()
+		if next.IsZero() || !next.Before(end) {
+			return out
+		}
+		out = append(out, next)
+		t = next
+	}
+}
+
+// yearlyRule returns the RRULE of a zone transition at local time, given
+// the transitions of the next year, such as
+// "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU" for the last Sunday of March.
+// It returns "" when no transition of the next year matches, so the
+// change is written as happening once.
+func yearlyRule(local time.Time, next []time.Time, offset int) string {
+	weekday := strings.ToUpper(local.Weekday().String()[:2])
+	nth := (local.Day()-1)/7 + 1
+	last := local.Day()+7 > daysIn(local.Month(), local.Year())
+	for _, t := range next {
+		n := t.In(time.FixedZone("", offset))
+		if n.Month() != local.Month() || n.Hour() != local.Hour() || n.Minute() != local.Minute() {
+			continue
+		}
+		rule := fmt.Sprintf("RRULE:FREQ=YEARLY;BYMONTH=%d;", local.Month())
+		switch {
+		case n.Weekday() != local.Weekday():
+			if n.Day() == local.Day() {
+				return rule + fmt.Sprintf("BYMONTHDAY=%d", local.Day())
+			}
+		case (n.Day()-1)/7+1 == nth && !(last && nth > 4):
+			return rule + fmt.Sprintf("BYDAY=%d%s", nth, weekday)
+		case last && n.Day()+7 > daysIn(n.Month(), n.Year()):
+			return rule + "BYDAY=-1" + weekday
+		}
+	}
+	return ""
+}
+
+// daysIn returns the number of days in month of year.
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// icsOffset formats a UTC offset in seconds as a UTC-OFFSET value, such
+// as +0200 or -0330.
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	s := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+// icsEscape escapes a TEXT property value.
+func icsEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// writeICSLine writes line folded at icsMaxLineOctets, with CRLF line
+// endings. Continuation lines start with a space, which counts toward
+// their length. Lines are never split inside a UTF-8 sequence.
+func writeICSLine(sb *strings.Builder, line string) {
+	limit := icsMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsMaxLineOctets - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+}
+
+// --- export_events_ics ---
+
+type exportEventsICSInput struct {
+	Account         string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID      string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	TimeMin         string `json:"time_min,omitempty" jsonschema:"Start of time range in RFC3339 format (e.g. '2024-01-15T00:00:00Z'). Default: now"`
+	TimeMax         string `json:"time_max,omitempty" jsonschema:"End of time range in RFC3339 format. Default: 30 days from now"`
+	Query           string `json:"query,omitempty" jsonschema:"Free text search query"`
+	MaxResults      int64  `json:"max_results,omitempty" jsonschema:"Maximum number of events (default 250, max 2500)"`
+	ExpandRecurring bool   `json:"expand_recurring,omitempty" jsonschema:"Export each occurrence of recurring events as its own event instead of one event with an RRULE"`
+	SaveTo          string `json:"save_to,omitempty" jsonschema:"Save the .ics document to a local file instead of returning it (path relative to an allowed directory). Requires --allow-write-dir."`
+}
+
+func registerExportEventsICS(srv *server.Server, mgr *auth.Manager) {
+	desc := `Export calendar events as an iCalendar (.ics) document that other calendar apps can import.
+
+Takes the same filters as list_events. Recurring events are exported once with their RRULE, in their own time zone with a VTIMEZONE definition, unless expand_recurring is set; other times are written in UTC. Events without a start or end are skipped and listed in a note.` + srv.WriteDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "export_events_ics",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input exportEventsICSInput) (*mcp.CallToolResult, any, error) {
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+
+		now := time.Now()
+		timeMin := input.TimeMin
+		if timeMin == "" {
+			timeMin = now.Format(time.RFC3339)
+		}
+		timeMax := input.TimeMax
+		if timeMax == "" {
+			timeMax = now.Add(30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+
+		maxResults := input.MaxResults
+		if maxResults <= 0 {
+			maxResults = 250
+		}
+		if maxResults > 2500 {
+			maxResults = 2500
+		}
+
+		call := svc.Events.List(calendarID).
+			TimeMin(timeMin).
+			TimeMax(timeMax).
+			MaxResults(maxResults).
+			SingleEvents(input.ExpandRecurring)
+		if input.ExpandRecurring {
+			call = call.OrderBy("startTime")
+		}
+		if input.Query != "" {
+			call = call.Q(input.Query)
+		}
+
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing events")
+		}
+
+		doc, skipped := formatICS(resp.Items, now)
+
+		var note strings.Builder
+		fmt.Fprintf(&note, "Exported %d events.\n", len(resp.Items)-len(skipped))
+		if len(skipped) > 0 {
+			fmt.Fprintf(&note, "Skipped %d events:\n", len(skipped))
+			for _, s := range skipped {
+				fmt.Fprintf(&note, "  - %s\n", s)
+			}
+		}
+		if resp.NextPageToken != "" {
+			fmt.Fprintf(&note, "More events match; narrow the time range or raise max_results.\n")
+		}
+
+		if input.SaveTo != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
+			}
+			dir, err := lfs.WriteFile(input.SaveTo, []byte(doc))
+			if err != nil {
+				return nil, nil, fmt.Errorf("saving calendar: %w", err)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("%s\nSaved to: %s/%s", note.String(), dir, input.SaveTo)},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: note.String() + "\n" + doc},
+			},
+		}, nil, nil
+	}, server.LocalWriteParams("save_to"))
+}
+
+// icsParam is a property parameter, such as TZID=Europe/Berlin.
+type icsParam struct {
+	Name  string
+	Value string
+}
+
+// icsProperty is an unfolded iCalendar content line.
+type icsProperty struct {
+	Name   string
+	Params []icsParam
+	Value  string
+}
+
+// param returns the value of the named parameter, or "".
+func (p icsProperty) param(name string) string {
+	for _, prm := range p.Params {
+		if prm.Name == name {
+			return prm.Value
+		}
+	}
+	return ""
+}
+
+// icsEvent is a VEVENT parsed from an iCalendar document, converted to the
+// Calendar API's representation.
+type icsEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+	Start       *calendar.EventDateTime
+	End         *calendar.EventDateTime
+	Recurrence  []string
+	// Err is set when the VEVENT can't be imported. The other fields are
+	// filled as far as parsing got, for reporting.
+	Err error
+}
+
+// calendarEvent returns the event to insert or import.
+func (e *icsEvent) calendarEvent() *calendar.Event {
+	ev := &calendar.Event{
+		ICalUID:     e.UID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Start:       e.Start,
+		End:         e.End,
+		Recurrence:  e.Recurrence,
+	}
+	if e.Status == "tentative" {
+		ev.Status = e.Status
+	}
+	return ev
+}
+
+// windowsTimeZones maps the Windows time zone names Outlook and Exchange
+// write as TZID to IANA names. Only common zones are listed; events in
+// other Windows zones fail to import with an unknown time zone error.
+var windowsTimeZones = map[string]string{
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"FLE Standard Time":              "Europe/Kiev",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Atlantic Standard Time":         "America/Halifax",
+	"SA Pacific Standard Time":       "America/Bogota",
+	"E. South America Standard Time": "America/Sao_Paulo",
+	"India Standard Time":            "Asia/Kolkata",
+	"China Standard Time":            "Asia/Shanghai",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+}
+
+// icsLocation resolves a TZID parameter to a location, accepting IANA and
+// common Windows names. It returns the IANA name used for the Calendar API.
+func icsLocation(tzid string) (*time.Location, string, error) {
+	name := tzid
+	if iana, ok := windowsTimeZones[tzid]; ok {
+		name = iana
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown time zone %q", tzid)
+	}
+	return loc, name, nil
+}
+
+// unfoldICS splits an iCalendar document into unfolded content lines.
+// Lines may end in CRLF or LF; a line starting with a space or tab
+// continues the previous one.
+func unfoldICS(data string) []string {
+	var lines []string
+	for _, l := range strings.Split(data, "\n") {
+		l = strings.TrimSuffix(l, "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// parseICSLine parses a content line: NAME *(";" PARAM "=" VALUE) ":" VALUE.
+// Parameter values may be quoted, in which case they can contain ':', ';'
+// and ','.
+func parseICSLine(line string) (icsProperty, error) {
+	var p icsProperty
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("malformed line %q", line)
+	}
+	p.Name = strings.ToUpper(line[:i])
+	rest := line[i:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("malformed parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return p, fmt.Errorf("unterminated quoted parameter in %q", line)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return p, fmt.Errorf("malformed line %q", line)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		p.Params = append(p.Params, icsParam{Name: name, Value: value})
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return p, fmt.Errorf("malformed line %q", line)
+	}
+	p.Value = rest[1:]
+	return p, nil
+}
+
+// icsUnescape reverses TEXT value escaping.
+func icsUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// parseICS parses the VEVENT blocks of an iCalendar document. Floating
+// times (no TZID and no Z suffix) are interpreted in defaultZone. Events
+// that can't be converted are returned with Err set; the returned error is
+// only set when data isn't an iCalendar document.
+func parseICS(data string, defaultZone string) ([]icsEvent, error) {
+	lines := unfoldICS(data)
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar document (expected BEGIN:VCALENDAR)")
+	}
+
+	var events []icsEvent
+	var props []icsProperty
+	var stack []string
+	for _, line := range lines {
+		p, err := parseICSLine(line)
+		if err != nil {
+			if len(stack) > 0 && stack[len(stack)-1] == "VEVENT" {
+				props = append(props, icsProperty{Name: "X-PARSE-ERROR", Value: err.Error()})
+			}
+			continue
+		}
+		switch p.Name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(p.Value))
+			if strings.EqualFold(p.Value, "VEVENT") {
+				props = nil
+			}
+			continue
+		case "END":
+			if len(stack) > 0 {
+				if stack[len(stack)-1] == "VEVENT" {
+					events = append(events, newICSEvent(props, defaultZone))
+				}
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		// Properties of nested components (VALARM) are ignored.
+		if len(stack) > 0 && stack[len(stack)-1] == "VEVENT" {
+			props = append(props, p)
+		}
+	}
+	return events, nil
+}
+
+// newICSEvent converts the properties of one VEVENT.
+func newICSEvent(props []icsProperty, defaultZone string) icsEvent {
+	var e icsEvent
+	var dtstart, dtend, duration *icsProperty
+	for i, p := range props {
+		switch p.Name {
+		case "UID":
+			e.UID = p.Value
+		case "SUMMARY":
+			e.Summary = icsUnescape(p.Value)
+		case "DESCRIPTION":
+			e.Description = icsUnescape(p.Value)
+		case "LOCATION":
+			e.Location = icsUnescape(p.Value)
+		case "STATUS":
+			e.Status = strings.ToLower(p.Value)
+		case "DTSTART":
+			dtstart = &props[i]
+		case "DTEND":
+			dtend = &props[i]
+		case "DURATION":
+			duration = &props[i]
+		case "RRULE", "EXDATE", "RDATE":
+			line, err := recurrenceLine(p)
+			if err != nil {
+				e.Err = err
+				return e
+			}
+			e.Recurrence = append(e.Recurrence, line)
+		case "RECURRENCE-ID":
+			e.Err = fmt.Errorf("modified instances of recurring events (RECURRENCE-ID) are not imported")
+		case "X-PARSE-ERROR":
+			e.Err = fmt.Errorf("%s", p.Value)
+		}
+	}
+	if e.Err != nil {
+		return e
+	}
+	if dtstart == nil {
+		e.Err = fmt.Errorf("missing DTSTART")
+		return e
+	}
+
+	start, startTime, allDay, err := icsTime(*dtstart, defaultZone)
+	if err != nil {
+		e.Err = fmt.Errorf("DTSTART: %w", err)
+		return e
+	}
+	e.Start = start
+
+	switch {
+	case dtend != nil:
+		e.End, _, _, err = icsTime(*dtend, defaultZone)
+		if err != nil {
+			e.Err = fmt.Errorf("DTEND: %w", err)
+			return e
+		}
+	case duration != nil:
+		d, err := parseICSDuration(duration.Value)
+		if err != nil {
+			e.Err = fmt.Errorf("DURATION: %w", err)
+			return e
+		}
+		e.End = shiftEventTime(start, startTime, allDay, d)
+	case allDay:
+		// RFC 5545: a DATE start without an end lasts one day.
+		e.End = shiftEventTime(start, startTime, true, 24*time.Hour)
+	default:
+		e.End = shiftEventTime(start, startTime, false, 0)
+	}
+
+	// The Calendar API requires a time zone on recurring timed events.
+	if len(e.Recurrence) > 0 && e.Start.DateTime != "" && e.Start.TimeZone == "" {
+		e.Start.TimeZone, e.End.TimeZone = "UTC", "UTC"
+	}
+	return e
+}
+
+// icsTime converts a DTSTART or DTEND property. It also returns the time
+// as a time.Time and whether it is an all-day date.
+func icsTime(p icsProperty, defaultZone string) (*calendar.EventDateTime, time.Time, bool, error) {
+	v := p.Value
+	if strings.EqualFold(p.param("VALUE"), "DATE") || len(v) == 8 {
+		d, err := time.Parse("20060102", v)
+		if err != nil {
+			return nil, time.Time{}, false, fmt.Errorf("invalid date %q", v)
+		}
+		return &calendar.EventDateTime{Date: d.Format(time.DateOnly)}, d, true, nil
+	}
+
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse("20060102T150405Z", v)
+		if err != nil {
+			return nil, time.Time{}, false, fmt.Errorf("invalid date-time %q", v)
+		}
+		return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339)}, t, false, nil
+	}
+
+	zone := p.param("TZID")
+	if zone == "" {
+		zone = defaultZone
+	}
+	loc, name, err := icsLocation(zone)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("invalid date-time %q", v)
+	}
+	return &calendar.EventDateTime{DateTime: t.Format(time.RFC3339), TimeZone: name}, t, false, nil
+}
+
+// shiftEventTime returns start moved by d, in the same form as start.
+func shiftEventTime(start *calendar.EventDateTime, t time.Time, allDay bool, d time.Duration) *calendar.EventDateTime {
+	if allDay {
+		days := max(int(d/(24*time.Hour)), 1)
+		return &calendar.EventDateTime{Date: t.AddDate(0, 0, days).Format(time.DateOnly)}
+	}
+	return &calendar.EventDateTime{DateTime: t.Add(d).Format(time.RFC3339), TimeZone: start.TimeZone}
+}
+
+// parseICSDuration parses a non-negative RFC 5545 duration such as P1D,
+// PT1H30M or P2W.
+func parseICSDuration(s string) (time.Duration, error) {
+	v, ok := strings.CutPrefix(strings.TrimPrefix(s, "+"), "P")
+	if !ok || v == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	inTime := false
+	parts := 0
+	for v != "" {
+		if v[0] == 'T' {
+			inTime = true
+			v = v[1:]
+			continue
+		}
+		i := 0
+		for i < len(v) && v[i] >= '0' && v[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(v) {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n := time.Duration(0)
+		for _, c := range v[:i] {
+			n = n*10 + time.Duration(c-'0')
+		}
+		var unit time.Duration
+		switch {
+		case !inTime && v[i] == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && v[i] == 'D':
+			unit = 24 * time.Hour
+		case inTime && v[i] == 'H':
+			unit = time.Hour
+		case inTime && v[i] == 'M':
+			unit = time.Minute
+		case inTime && v[i] == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += n * unit
+		v = v[i+1:]
+		parts++
+	}
+	if parts == 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// recurrenceLine rebuilds an RRULE, EXDATE or RDATE line for the Calendar
+// API, mapping Windows TZIDs to IANA names.
+func recurrenceLine(p icsProperty) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(p.Name)
+	for _, prm := range p.Params {
+		value := prm.Value
+		if prm.Name == "TZID" {
+			_, name, err := icsLocation(value)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", p.Name, err)
+			}
+			value = name
+		}
+		fmt.Fprintf(&sb, ";%s=%s", prm.Name, value)
+	}
+	sb.WriteString(":")
+	sb.WriteString(p.Value)
+	return sb.String(), nil
+}
+
+// describeICSEvent summarizes a parsed event for import reports.
+func describeICSEvent(e icsEvent) string {
+	name := e.Summary
+	if name == "" {
+		name = "(no title)"
+	}
+	if e.Start != nil {
+		when := e.Start.DateTime
+		if when == "" {
+			when = e.Start.Date + " (all day)"
+		}
+		name += " — " + when
+	}
+	if len(e.Recurrence) > 0 {
+		name += " (recurring)"
+	}
+	return name
+}
+
+// --- import_ics ---
+
+type importICSInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	Content    string `json:"content,omitempty" jsonschema:"iCalendar (.ics) document text. Set this or local_path."`
+	LocalPath  string `json:"local_path,omitempty" jsonschema:"Read the .ics document from a local file (path relative to an allowed directory). Requires --allow-read-dir."`
+	TimeZone   string `json:"time_zone,omitempty" jsonschema:"IANA time zone for times without a TZID or UTC marker (default: UTC)"`
+	DryRun     bool   `json:"dry_run,omitempty" jsonschema:"List the events that would be created or overwritten without changing anything"`
+}
+
+func registerImportICS(srv *server.Server, mgr *auth.Manager) {
+	desc := `Create calendar events from an iCalendar (.ics) document, such as one exported from Outlook, Apple Calendar, or export_events_ics.
+
+Each VEVENT becomes one event; recurring events keep their RRULE. Events with a UID are imported with Events.Import so the UID is preserved: an event already on the calendar with the same UID is overwritten with the document's version, so re-importing the same document updates those events instead of duplicating them. Events marked STATUS:CANCELLED are skipped. Set dry_run to preview, including which existing events would be overwritten. The result lists which events were created, which were overwritten, which were skipped and why others failed.` + srv.ReadDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "import_ics",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input importICSInput) (*mcp.CallToolResult, any, error) {
+		if (input.Content == "") == (input.LocalPath == "") {
+			return nil, nil, fmt.Errorf("exactly one of content or local_path is required")
+		}
+
+		data := []byte(input.Content)
+		if input.LocalPath != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
+			}
+			var err error
+			if data, _, err = lfs.ReadFile(input.LocalPath); err != nil {
+				return nil, nil, fmt.Errorf("reading calendar file: %w", err)
+			}
+		}
+
+		zone := input.TimeZone
+		if zone == "" {
+			zone = "UTC"
+		}
+		if _, _, err := icsLocation(zone); err != nil {
+			return nil, nil, fmt.Errorf("time_zone: %w", err)
+		}
+
+		events, err := parseICS(string(data), zone)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(events) == 0 {
+			return nil, nil, fmt.Errorf("the document contains no events")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+
+		existing, err := existingICSEvents(ctx, svc, calendarID, events)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if input.DryRun {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: formatICSDryRun(events, existing)},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatICSImport(importICSEvents(ctx, svc, calendarID, events, existing))},
+			},
+		}, nil, nil
+	})
+}
+
+// icsImportResult is the outcome of importing one parsed event.
+type icsImportResult struct {
+	Event   icsEvent
+	EventID string
+	// Existing is the event with the same UID that the import overwrote.
+	Existing *calendar.Event
+	Skipped  bool // cancelled in the document
+	Err      error
+}
+
+// skipped reports whether e is left out of the import: a cancelled event
+// that isn't an override of a recurring one (those fail parsing) would
+// only create or overwrite an event that is already gone for the sender.
+func (e *icsEvent) skipped() bool {
+	return e.Err == nil && e.Status == "cancelled"
+}
+
+// existingICSEvents returns the events in calendarID that share a UID with
+// one of events, keyed by UID. Events.Import overwrites those.
+func existingICSEvents(ctx context.Context, svc *calendar.Service, calendarID string, events []icsEvent) (map[string]*calendar.Event, error) {
+	existing := make(map[string]*calendar.Event)
+	for _, e := range events {
+		if e.UID == "" || e.Err != nil || e.skipped() {
+			continue
+		}
+		if _, ok := existing[e.UID]; ok {
+			continue
+		}
+		resp, err := svc.Events.List(calendarID).ICalUID(e.UID).MaxResults(1).Fields("items(id,summary,start)").Context(ctx).Do()
+		if err != nil {
+			return nil, gerrors.Wrapf(err, "looking up existing events with UID %q", e.UID)
+		}
+		if len(resp.Items) > 0 {
+			existing[e.UID] = resp.Items[0]
+		}
+	}
+	return existing, nil
+}
+
+// importICSEvents creates each event that parsed successfully, except
+// cancelled ones. Events with a UID go through Events.Import, which keeps
+// the UID and overwrites the event in existing with that UID; others are
+// inserted.
+func importICSEvents(ctx context.Context, svc *calendar.Service, calendarID string, events []icsEvent, existing map[string]*calendar.Event) []icsImportResult {
+	results := make([]icsImportResult, 0, len(events))
+	for _, e := range events {
+		res := icsImportResult{Event: e, Err: e.Err, Skipped: e.skipped()}
+		if e.UID != "" {
+			res.Existing = existing[e.UID]
+		}
+		if res.Err == nil && !res.Skipped {
+			var created *calendar.Event
+			var err error
+			if e.UID != "" {
+				created, err = svc.Events.Import(calendarID, e.calendarEvent()).Context(ctx).Do()
+			} else {
+				created, err = svc.Events.Insert(calendarID, e.calendarEvent()).Context(ctx).Do()
+			}
+			if err != nil {
+				res.Err = err
+			} else {
+				res.EventID = created.Id
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func formatICSImport(results []icsImportResult) string {
+	var sb strings.Builder
+	imported, overwritten, skipped := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			skipped++
+		case r.Err == nil:
+			imported++
+			if r.Existing != nil {
+				overwritten++
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "Imported %d of %d events", imported, len(results))
+	if overwritten > 0 {
+		fmt.Fprintf(&sb, ", %d of them overwriting existing events", overwritten)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "; %d cancelled events skipped", skipped)
+	}
+	sb.WriteString(".\n\n")
+	for i, r := range results {
+		switch {
+		case r.Skipped:
+			fmt.Fprintf(&sb, "%d. %s — skipped: cancelled\n", i+1, describeICSEvent(r.Event))
+		case r.Err != nil:
+			fmt.Fprintf(&sb, "%d. %s — failed: %v\n", i+1, describeICSEvent(r.Event), r.Err)
+		case r.Existing != nil:
+			fmt.Fprintf(&sb, "%d. %s — overwrote existing event %q (Event ID: %s)\n", i+1, describeICSEvent(r.Event), r.Existing.Summary, r.EventID)
+		default:
+			fmt.Fprintf(&sb, "%d. %s — created (Event ID: %s)\n", i+1, describeICSEvent(r.Event), r.EventID)
+		}
+	}
+	return sb.String()
+}
+
+// formatICSDryRun lists what import_ics would do with events, given the
+// existing events with their UIDs.
+func formatICSDryRun(events []icsEvent, existing map[string]*calendar.Event) string {
+	var sb strings.Builder
+	ok, overwrite, skipped := 0, 0, 0
+	for _, e := range events {
+		switch {
+		case e.skipped():
+			skipped++
+		case e.Err == nil:
+			ok++
+			if existing[e.UID] != nil {
+				overwrite++
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "Dry run: %d of %d events would be imported", ok, len(events))
+	if overwrite > 0 {
+		fmt.Fprintf(&sb, ", %d of them overwriting existing events", overwrite)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "; %d cancelled events would be skipped", skipped)
+	}
+	sb.WriteString(".\n\n")
+	for i, e := range events {
+		switch {
+		case e.skipped():
+			fmt.Fprintf(&sb, "%d. %s — would be skipped: cancelled\n", i+1, describeICSEvent(e))
+		case e.Err != nil:
+			fmt.Fprintf(&sb, "%d. %s — would fail: %v\n", i+1, describeICSEvent(e), e.Err)
+		case existing[e.UID] != nil:
+			fmt.Fprintf(&sb, "%d. %s — would overwrite existing event %q (Event ID: %s)\n", i+1, describeICSEvent(e), existing[e.UID].Summary, existing[e.UID].Id)
+		default:
+			fmt.Fprintf(&sb, "%d. %s\n", i+1, describeICSEvent(e))
+		}
+	}
+	return sb.String()
+}
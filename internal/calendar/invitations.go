@@ -0,0 +1,492 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// maxInvitationScan is the number of events list_pending_invitations and
+// respond_events_bulk scan per calendar before they stop.
+const maxInvitationScan = 5000
+
+// invitationFields is the Fields mask for the events scanned by
+// list_pending_invitations.
+const invitationFields = "nextPageToken,items(id,status,summary,start,end,location,htmlLink,organizer(email,displayName,self),attendees(self,responseStatus))"
+
+// respondBulkFields is the Fields mask for the events scanned by
+// respond_events_bulk. The attendees are fetched in full, since the patch
+// that sets the response replaces the list.
+const respondBulkFields = "nextPageToken,items(id,status,eventType,summary,start,end,organizer(email,displayName,self),attendees)"
+
+// maxBulkResponses is the maximum number of events respond_events_bulk
+// changes in one call.
+const maxBulkResponses = 50
+
+// --- list_pending_invitations ---
+
+type listPendingInvitationsInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID, or 'all' for every calendar you own (default: 'primary')"`
+	TimeMin    string `json:"time_min,omitempty" jsonschema:"Start of the window in RFC3339 format (default: now)"`
+	TimeMax    string `json:"time_max,omitempty" jsonschema:"End of the window in RFC3339 format (default: 30 days from now)"`
+	MaxResults int    `json:"max_results,omitempty" jsonschema:"Maximum number of invitations to show per account (default 25, max 100)"`
+}
+
+// pendingInvitation is an event awaiting the user's response.
+type pendingInvitation struct {
+	CalendarID string
+	Event      *calendar.Event
+}
+
+func registerListPendingInvitations(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "list_pending_invitations",
+		Description: `List the invitations you haven't responded to: events in a time window where your attendee status is still "needs action". Cancelled events are skipped.
+
+The Calendar API can't filter by response, so every event in the window is scanned; the result shows how many were scanned and matched. Set calendar_id to 'all' to check every calendar you own; calendars others share with you, even with edit access, hold their invitations, not yours. Set account to 'all' to check all accounts. Answer with respond_event.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listPendingInvitationsInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+		now := time.Now()
+		timeMin := input.TimeMin
+		if timeMin == "" {
+			timeMin = now.Format(time.RFC3339)
+		}
+		timeMax := input.TimeMax
+		if timeMax == "" {
+			timeMax = now.Add(30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+		maxResults := input.MaxResults
+		if maxResults <= 0 {
+			maxResults = 25
+		}
+		if maxResults > 100 {
+			maxResults = 100
+		}
+
+		out := srv.NewOutputBuilder()
+		fmt.Fprintf(out, "Pending invitations from %s to %s\n\n", timeMin, timeMax)
+		multiAccount := len(accounts) > 1
+		listed := false
+
+		for _, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
+			svc, err := newService(ctx, mgr, account)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+			}
+
+			calendarIDs := []string{calendarID}
+			if calendarID == "all" {
+				calendarIDs, err = ownedCalendarIDs(ctx, svc)
+				if err != nil {
+					if multiAccount {
+						fmt.Fprintf(out, "=== Account: %s ===\nError listing calendars: %v\n\n", account, err)
+						continue
+					}
+					return nil, nil, gerrors.Wrap(err, "listing calendars")
+				}
+			}
+
+			if multiAccount {
+				fmt.Fprintf(out, "=== Account: %s ===\n", account)
+			}
+			var pending []pendingInvitation
+			scanned := 0
+			var capped []string
+			for _, id := range calendarIDs {
+				if ctx.Err() != nil {
+					break
+				}
+				events, more, err := scanInvitationEvents(ctx, svc, id, timeMin, timeMax, invitationFields)
+				if err != nil {
+					if len(calendarIDs) > 1 || multiAccount {
+						fmt.Fprintf(out, "Error listing events of %s: %v\n", id, err)
+						continue
+					}
+					return nil, nil, gerrors.Wrap(err, "listing events")
+				}
+				scanned += len(events)
+				if more {
+					capped = append(capped, id)
+				}
+				for _, e := range pendingInvitations(events) {
+					pending = append(pending, pendingInvitation{CalendarID: id, Event: e})
+				}
+			}
+			sortInvitations(pending)
+
+			fmt.Fprintf(out, "Scanned %d events, %d awaiting your response.\n", scanned, len(pending))
+			if len(capped) > 0 {
+				fmt.Fprintf(out, "Stopped after %d events in %s; narrow the time window to scan the rest.\n", maxInvitationScan, strings.Join(capped, ", "))
+			}
+			out.WriteString("\n")
+			for i, p := range pending {
+				if i == maxResults {
+					fmt.Fprintf(out, "Showing the first %d; narrow the time window or raise max_results to see the rest.\n\n", maxResults)
+					break
+				}
+				if !out.AddItem(formatInvitation(p, account) + "\n") {
+					break
+				}
+				listed = true
+			}
+		}
+
+		text := out.String()
+		if listed {
+			text += "Answer with respond_event, passing the account, calendar_id and event_id shown.\n"
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// --- respond_events_bulk ---
+
+type respondEventsBulkInput struct {
+	Account         string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID      string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	TimeMin         string `json:"time_min" jsonschema:"Start of the window in RFC3339 format"`
+	TimeMax         string `json:"time_max" jsonschema:"End of the window in RFC3339 format"`
+	Organizer       string `json:"organizer,omitempty" jsonschema:"Only events organized by this email address (case-insensitive)"`
+	SummaryContains string `json:"summary_contains,omitempty" jsonschema:"Only events whose title contains this text (case-insensitive)"`
+	Response        string `json:"response" jsonschema:"Response status: 'accepted', 'declined', or 'tentative'"`
+	Comment         string `json:"comment,omitempty" jsonschema:"Comment to the organizers, shown with your response"`
+	DryRun          bool   `json:"dry_run,omitempty" jsonschema:"Only list the matching events and the change to each, without responding (default: false)"`
+}
+
+// responseFilter selects the events respond_events_bulk responds to.
+type responseFilter struct {
+	Organizer       string
+	SummaryContains string
+}
+
+// responseChange is a matching event of respond_events_bulk and its
+// outcome.
+type responseChange struct {
+	Event    *calendar.Event
+	Previous string // the response before the change
+	Err      error
+	Skipped  bool // not attempted because ctx was done
+}
+
+func registerRespondEventsBulk(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "respond_events_bulk",
+		Description: `Respond to every invitation in a time window that matches the filters, e.g. to decline all meetings from one organizer next week. Sets your attendance status on each, optionally with a comment.
+
+Only events that list you as an attendee are considered; cancelled events, events you organize and working location, out-of-office and focus time events are skipped. organizer and summary_contains narrow the match. Recurring events are answered one occurrence at a time, for the occurrences in the window.
+
+Set dry_run to list the matching events and the change to each first. At most 50 events are changed per call; events that already have the response are left as they are. The result reports every event with its outcome.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input respondEventsBulkInput) (*mcp.CallToolResult, any, error) {
+		if input.TimeMin == "" || input.TimeMax == "" {
+			return nil, nil, fmt.Errorf("time_min and time_max are required")
+		}
+		if err := validateResponse(input.Response); err != nil {
+			return nil, nil, err
+		}
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+
+		events, more, err := scanInvitationEvents(ctx, svc, calendarID, input.TimeMin, input.TimeMax, respondBulkFields)
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing events")
+		}
+		filter := responseFilter{Organizer: input.Organizer, SummaryContains: input.SummaryContains}
+		matched := matchResponseEvents(events, filter)
+		var changes []responseChange
+		unchanged := 0
+		for _, e := range matched {
+			previous := selfAttendee(e).ResponseStatus
+			if previous == input.Response {
+				unchanged++
+				continue
+			}
+			changes = append(changes, responseChange{Event: e, Previous: previous})
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Scanned %d events from %s to %s: %d match, %d to change to %q", len(events), input.TimeMin, input.TimeMax, len(matched), len(changes), input.Response)
+		if unchanged > 0 {
+			fmt.Fprintf(&sb, " (%d already %s)", unchanged, input.Response)
+		}
+		sb.WriteString(".\n")
+		if more {
+			fmt.Fprintf(&sb, "Stopped after %d events; narrow the time window to scan the rest.\n", maxInvitationScan)
+		}
+
+		if input.DryRun {
+			sb.WriteString("\nDry run: nothing was changed.\n")
+			for _, c := range changes {
+				sb.WriteString(formatResponseChange(c, input.Response))
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: sb.String()},
+				},
+			}, nil, nil
+		}
+		if len(changes) > maxBulkResponses {
+			return nil, nil, fmt.Errorf("%d events would change, more than %d per call; narrow the time window or the filters (dry_run lists them)", len(changes), maxBulkResponses)
+		}
+
+		done := 0
+		for i := range changes {
+			c := &changes[i]
+			if ctx.Err() != nil {
+				c.Skipped = true
+				continue
+			}
+			patch, err := responsePatch(c.Event, input.Response, input.Comment)
+			if err == nil {
+				_, err = svc.Events.Patch(calendarID, c.Event.Id, patch).Context(ctx).Do()
+				err = gerrors.Wrap(err, "updating response")
+			}
+			c.Err = err
+			if err == nil {
+				done++
+				server.Progress(ctx, req, done, len(changes), fmt.Sprintf("Responded to %d of %d events", done, len(changes)))
+			}
+		}
+
+		fmt.Fprintf(&sb, "\nResponse set to %q on %d of %d events.\n", input.Response, done, len(changes))
+		for _, c := range changes {
+			sb.WriteString(formatResponseChange(c, input.Response))
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	}, server.Timeout(server.BulkToolTimeout))
+}
+
+// matchResponseEvents returns the events matching filter that
+// respond_events_bulk may respond to: invitations from someone else that
+// list the calendar owner as an attendee. Cancelled events and working
+// location, out-of-office and focus time events are skipped.
+func matchResponseEvents(events []*calendar.Event, filter responseFilter) []*calendar.Event {
+	var out []*calendar.Event
+	for _, e := range events {
+		if e.Status == "cancelled" || (e.Organizer != nil && e.Organizer.Self) || selfAttendee(e) == nil {
+			continue
+		}
+		switch e.EventType {
+		case "workingLocation", "outOfOffice", "focusTime":
+			continue
+		}
+		if filter.Organizer != "" && (e.Organizer == nil || !strings.EqualFold(e.Organizer.Email, strings.TrimSpace(filter.Organizer))) {
+			continue
+		}
+		if filter.SummaryContains != "" && !strings.Contains(strings.ToLower(e.Summary), strings.ToLower(filter.SummaryContains)) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// formatResponseChange formats a responseChange as a list entry.
+func formatResponseChange(c responseChange, response string) string {
+	e := c.Event
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- %s\n  Event ID: %s\n", e.Summary, e.Id)
+	if e.Start != nil {
+		if e.Start.DateTime != "" {
+			fmt.Fprintf(&sb, "  Start: %s\n", e.Start.DateTime)
+		} else if e.Start.Date != "" {
+			fmt.Fprintf(&sb, "  Start: %s (all day)\n", e.Start.Date)
+		}
+	}
+	if e.Organizer != nil && e.Organizer.Email != "" {
+		fmt.Fprintf(&sb, "  Organizer: %s\n", e.Organizer.Email)
+	}
+	switch {
+	case c.Err != nil:
+		fmt.Fprintf(&sb, "  Failed: %v\n", c.Err)
+	case c.Skipped:
+		sb.WriteString("  Skipped: the call was cancelled or timed out\n")
+	default:
+		fmt.Fprintf(&sb, "  Response: %s -> %s\n", c.Previous, response)
+	}
+	return sb.String()
+}
+
+// pendingInvitations returns the events in which the calendar owner is an
+// attendee who hasn't responded yet. Events without attendees, cancelled
+// events and events the owner organizes are skipped.
+func pendingInvitations(events []*calendar.Event) []*calendar.Event {
+	var out []*calendar.Event
+	for _, e := range events {
+		if e.Status == "cancelled" || (e.Organizer != nil && e.Organizer.Self) {
+			continue
+		}
+		for _, a := range e.Attendees {
+			if a.Self && a.ResponseStatus == "needsAction" {
+				out = append(out, e)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// sortInvitations orders invitations by start time. Invitations from several
+// calendars may give times in different offsets, so they are compared as
+// instants; all-day events sort as of midnight UTC.
+func sortInvitations(pending []pendingInvitation) {
+	start := func(e *calendar.Event) time.Time {
+		if e.Start == nil {
+			return time.Time{}
+		}
+		if t, err := time.Parse(time.RFC3339, e.Start.DateTime); err == nil {
+			return t
+		}
+		t, _ := time.Parse("2006-01-02", e.Start.Date)
+		return t
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return start(pending[i].Event).Before(start(pending[j].Event))
+	})
+}
+
+// formatInvitation formats a pending invitation as a list entry.
+func formatInvitation(p pendingInvitation, account string) string {
+	e := p.Event
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- %s\n  Event ID: %s\n  Calendar ID: %s\n  Account: %s\n", e.Summary, e.Id, p.CalendarID, account)
+	if e.Start != nil {
+		if e.Start.DateTime != "" {
+			fmt.Fprintf(&sb, "  Start: %s\n", e.Start.DateTime)
+		} else if e.Start.Date != "" {
+			fmt.Fprintf(&sb, "  Start: %s (all day)\n", e.Start.Date)
+		}
+	}
+	if e.End != nil {
+		if e.End.DateTime != "" {
+			fmt.Fprintf(&sb, "  End: %s\n", e.End.DateTime)
+		} else if e.End.Date != "" {
+			fmt.Fprintf(&sb, "  End: %s\n", e.End.Date)
+		}
+	}
+	if o := e.Organizer; o != nil {
+		switch {
+		case o.DisplayName != "" && o.Email != "":
+			fmt.Fprintf(&sb, "  Organizer: %s <%s>\n", o.DisplayName, o.Email)
+		case o.Email != "":
+			fmt.Fprintf(&sb, "  Organizer: %s\n", o.Email)
+		}
+	}
+	if e.Location != "" {
+		fmt.Fprintf(&sb, "  Location: %s\n", e.Location)
+	}
+	if e.HtmlLink != "" {
+		fmt.Fprintf(&sb, "  Link: %s\n", e.HtmlLink)
+	}
+	return sb.String()
+}
+
+// scanInvitationEvents lists the event occurrences of a calendar in a time
+// window with the fields mask, following pages up to maxInvitationScan
+// events. more reports whether it stopped before the end.
+func scanInvitationEvents(ctx context.Context, svc *calendar.Service, calendarID, timeMin, timeMax, fields string) (events []*calendar.Event, more bool, err error) {
+	pageToken := ""
+	for {
+		call := svc.Events.List(calendarID).
+			TimeMin(timeMin).
+			TimeMax(timeMax).
+			SingleEvents(true).
+			MaxResults(2500).
+			Fields(googleapi.Field(fields)).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, false, err
+		}
+		events = append(events, resp.Items...)
+		if resp.NextPageToken == "" {
+			return events, false, nil
+		}
+		if len(events) >= maxInvitationScan {
+			return events, true, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// ownedCalendarIDs returns the IDs of the calendars in the user's calendar
+// list that they own, starting with the primary one. Invitations on
+// calendars shared with the user are addressed to the calendar's owner,
+// so calendars the user can only edit are left out.
+func ownedCalendarIDs(ctx context.Context, svc *calendar.Service) ([]string, error) {
+	ids := []string{"primary"}
+	pageToken := ""
+	for {
+		call := svc.CalendarList.List().MinAccessRole("owner").Fields("nextPageToken,items(id,primary)")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range resp.Items {
+			if !c.Primary {
+				ids = append(ids, c.Id)
+			}
+		}
+		if resp.NextPageToken == "" {
+			return ids, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
@@ -0,0 +1,33 @@
+package calendar
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// registerPrompts registers the Calendar prompt templates.
+func registerPrompts(srv *server.Server) {
+	server.AddPrompt(srv, server.PromptTemplate{
+		Name:        "schedule_meeting",
+		Title:       "Schedule a meeting",
+		Description: "Find a time when all attendees are free within a window and create the event after confirmation.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "attendees", Description: "Attendee email addresses, comma-separated", Required: true},
+			{Name: "duration", Description: "Meeting length in minutes (default 30)"},
+			{Name: "window", Description: "When the meeting should happen, e.g. 'next Tuesday to Thursday, 9:00-17:00'", Required: true},
+			{Name: "account", Description: "Account name (default: the default account)"},
+		},
+		Defaults: map[string]string{"duration": "30"},
+		Tools:    []string{"query_free_busy", "create_event"},
+		Template: `Schedule a {{.duration}}-minute meeting with {{.attendees}} {{.window}}.
+
+1. Work out the start and end of the window as RFC3339 times in my time zone.
+2. Call query_free_busy{{if .account}} with account "{{.account}}"{{end}} for "primary" and each attendee ({{.attendees}}) over the window. Attendees whose calendars can't be checked are reported as errors; tell me who they are.
+3. Find the earliest slots of {{.duration}} minutes in which everyone is free, within working hours unless the window says otherwise, and propose up to three.
+{{- if tool "find_available_room"}}
+4. If I ask for a room, call find_available_room for the chosen slot and add the room as a resource attendee.
+{{- end}}
+5. Once I pick a slot, call create_event{{if .account}} with account "{{.account}}"{{end}}, the attendees, the chosen start and end, and a short summary. Don't create anything before I confirm.
+`,
+	})
+}
@@ -0,0 +1,205 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Limits the Calendar API enforces on extended properties.
+const (
+	maxPropertyKeyLen   = 44
+	maxPropertyValueLen = 1024
+	maxEventProperties  = 300
+)
+
+// validatePrivateProperties checks caller-supplied private extended
+// properties against the API limits. Empty values are only allowed when
+// allowEmpty is set (update_event uses them to remove a property). Keys
+// can't contain '=', which separates key and value in Events.List filters.
+func validatePrivateProperties(props map[string]string, allowEmpty bool) error {
+	if len(props) > maxEventProperties {
+		return fmt.Errorf("private_properties: %d properties given, at most %d per event", len(props), maxEventProperties)
+	}
+	for _, key := range slices.Sorted(maps.Keys(props)) {
+		value := props[key]
+		switch {
+		case strings.TrimSpace(key) == "":
+			return fmt.Errorf("private_properties: keys must not be empty")
+		case strings.Contains(key, "="):
+			return fmt.Errorf("private_properties: key %q must not contain '='", key)
+		case utf8.RuneCountInString(key) > maxPropertyKeyLen:
+			return fmt.Errorf("private_properties: key %q is longer than %d characters", key, maxPropertyKeyLen)
+		case key == idempotencyKeyProperty:
+			return fmt.Errorf("private_properties: key %q is reserved; use idempotency_key instead", key)
+		case value == "" && !allowEmpty:
+			return fmt.Errorf("private_properties: value of %q must not be empty", key)
+		case utf8.RuneCountInString(value) > maxPropertyValueLen:
+			return fmt.Errorf("private_properties: value of %q is longer than %d characters", key, maxPropertyValueLen)
+		}
+	}
+	return nil
+}
+
+// applyPrivateProperties merges props into the event's private extended
+// properties. An empty value removes the property.
+func applyPrivateProperties(event *calendar.Event, props map[string]string) {
+	if len(props) == 0 {
+		return
+	}
+	if event.ExtendedProperties == nil {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{}
+	}
+	if event.ExtendedProperties.Private == nil {
+		event.ExtendedProperties.Private = make(map[string]string, len(props))
+	}
+	for key, value := range props {
+		if value == "" {
+			delete(event.ExtendedProperties.Private, key)
+		} else {
+			event.ExtendedProperties.Private[key] = value
+		}
+	}
+}
+
+// privatePropertyFilters returns the privateExtendedProperty parameters of
+// an Events.List call matching events that have all of props, sorted so
+// requests are deterministic.
+func privatePropertyFilters(props map[string]string) []string {
+	filters := make([]string, 0, len(props))
+	for _, key := range slices.Sorted(maps.Keys(props)) {
+		filters = append(filters, key+"="+props[key])
+	}
+	return filters
+}
+
+// formatPrivateProperties lists an event's private extended properties,
+// without the one create_event uses for idempotency keys. Each line starts
+// with indent.
+func formatPrivateProperties(event *calendar.Event, indent string) string {
+	if event.ExtendedProperties == nil || len(event.ExtendedProperties.Private) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(event.ExtendedProperties.Private)) {
+		if key == idempotencyKeyProperty {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s  %s: %s\n", indent, key, event.ExtendedProperties.Private[key])
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return indent + "Private properties:\n" + sb.String()
+}
+
+// --- find_events_by_private_property ---
+
+type findByPropertyInput struct {
+	Account    string            `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID string            `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	Properties map[string]string `json:"properties" jsonschema:"Private properties to match, as key/value pairs (e.g. {\"ticket\": \"OPS-123\"}). Events must have all of them."`
+	TimeMin    string            `json:"time_min,omitempty" jsonschema:"Only events ending after this RFC3339 time (default: no limit)"`
+	TimeMax    string            `json:"time_max,omitempty" jsonschema:"Only events starting before this RFC3339 time (default: no limit)"`
+	MaxResults int64             `json:"max_results,omitempty" jsonschema:"Maximum number of events (default 20, max 250)"`
+}
+
+// findByPropertyFields is the Fields mask for find_events_by_private_property.
+const findByPropertyFields = "items(id,summary,start,end,location,status,htmlLink,extendedProperties(private))"
+
+func registerFindEventsByPrivateProperty(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "find_events_by_private_property",
+		Description: "Find events by the private properties stored on them with create_event or update_event (private_properties), such as a ticket ID an agent stamped on the events it manages. Events must match every given key/value pair exactly. Recurring events are expanded into instances.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input findByPropertyInput) (*mcp.CallToolResult, any, error) {
+		if len(input.Properties) == 0 {
+			return nil, nil, fmt.Errorf("properties must contain at least one key/value pair")
+		}
+		if err := validatePrivateProperties(input.Properties, false); err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+		maxResults := input.MaxResults
+		if maxResults <= 0 {
+			maxResults = 20
+		}
+		if maxResults > 250 {
+			maxResults = 250
+		}
+
+		events, err := findEventsByProperties(ctx, svc, calendarID, input.Properties, input.TimeMin, input.TimeMax, maxResults)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		filters := strings.Join(privatePropertyFilters(input.Properties), ", ")
+		if len(events) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No events found with %s.", filters)},
+				},
+			}, nil, nil
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Found %d events with %s:\n\n", len(events), filters)
+		for _, event := range events {
+			sb.WriteString(formatEvent(event, input.Account))
+			sb.WriteString(formatPrivateProperties(event, "  "))
+			sb.WriteString("\n")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// findEventsByProperties lists the events on calendarID that have all of
+// the private properties in props.
+func findEventsByProperties(ctx context.Context, svc *calendar.Service, calendarID string, props map[string]string, timeMin, timeMax string, maxResults int64) ([]*calendar.Event, error) {
+	call := svc.Events.List(calendarID).
+		PrivateExtendedProperty(privatePropertyFilters(props)...).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(maxResults).
+		Fields(googleapi.Field(findByPropertyFields))
+	if timeMin != "" {
+		call = call.TimeMin(timeMin)
+	}
+	if timeMax != "" {
+		call = call.TimeMax(timeMax)
+	}
+	resp, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "listing events")
+	}
+	return resp.Items, nil
+}
@@ -0,0 +1,123 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"google.golang.org/api/calendar/v3"
+)
+
+// The Calendar API has no way to propose a new time for an event, so
+// respond_event declines with a comment stating the proposed slot and can
+// block the slot with a tentative hold on the responder's primary calendar.
+// The hold and the declined event point at each other through private
+// extended properties.
+const (
+	// proposalForProperty is set on a hold to the ID of the event it
+	// proposes a new time for.
+	proposalForProperty = "googleMcpProposalFor"
+	// proposalHoldProperty is set on the declined event to the ID of the
+	// hold created for it.
+	proposalHoldProperty = "googleMcpProposalHold"
+)
+
+// proposalPrefix starts the first line of a proposal comment, so the
+// organizer (or a tool reading attendee comments) can recognize it.
+const proposalPrefix = "Proposed new time: "
+
+// proposedTime is the propose_new_time input of respond_event.
+type proposedTime struct {
+	Start string `json:"start" jsonschema:"Proposed start, RFC3339 (e.g. 2026-03-02T10:00:00+01:00). Without an offset the account's calendar timezone is used."`
+	End   string `json:"end" jsonschema:"Proposed end, in the same format as start"`
+}
+
+// proposalLayouts are the date-time layouts accepted without an offset.
+var proposalLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// parseProposalTime parses a proposed start or end. Times without an offset
+// are interpreted in the location returned by zone, which is only called
+// when needed.
+func parseProposalTime(name, value string, zone func() (*time.Location, error)) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range proposalLayouts {
+		if _, err := time.Parse(layout, value); err != nil {
+			continue
+		}
+		loc, err := zone()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("propose_new_time.%s has no offset and the calendar timezone is unknown: %w", name, err)
+		}
+		return time.ParseInLocation(layout, value, loc)
+	}
+	return time.Time{}, fmt.Errorf("invalid propose_new_time.%s %q: use RFC3339, e.g. 2026-03-02T10:00:00+01:00", name, value)
+}
+
+// parseProposal parses and checks a proposed slot.
+func parseProposal(p *proposedTime, zone func() (*time.Location, error)) (start, end time.Time, err error) {
+	if p.Start == "" || p.End == "" {
+		return start, end, fmt.Errorf("propose_new_time requires start and end")
+	}
+	if start, err = parseProposalTime("start", p.Start, zone); err != nil {
+		return start, end, err
+	}
+	if end, err = parseProposalTime("end", p.End, zone); err != nil {
+		return start, end, err
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("propose_new_time.start (%s) must be earlier than end (%s)", p.Start, p.End)
+	}
+	return start, end, nil
+}
+
+// proposalComment returns the attendee comment for a proposal: a line with
+// the proposed slot, followed by the responder's own comment, if any.
+func proposalComment(start, end time.Time, comment string) string {
+	line := proposalPrefix + start.Format(time.RFC3339) + " to " + end.Format(time.RFC3339)
+	if comment = strings.TrimSpace(comment); comment != "" {
+		return line + "\n" + comment
+	}
+	return line
+}
+
+// proposalHold returns the tentative hold for a proposed new time for
+// event, which lives on calendarID of the same account.
+func proposalHold(event *calendar.Event, calendarID string, start, end time.Time) *calendar.Event {
+	summary := event.Summary
+	if summary == "" {
+		summary = "(no title)"
+	}
+	description := fmt.Sprintf("Proposed new time for %q (event %s on calendar %s).", summary, event.Id, calendarID)
+	if event.HtmlLink != "" {
+		description += "\n" + event.HtmlLink
+	}
+	return &calendar.Event{
+		Summary:      "Hold: " + summary,
+		Description:  description,
+		Start:        &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:          &calendar.EventDateTime{DateTime: end.Format(time.RFC3339)},
+		Status:       "tentative",
+		Transparency: "opaque",
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{proposalForProperty: event.Id},
+		},
+	}
+}
+
+// findProposalHold returns the hold previously created for eventID on
+// calendarID, or nil, so proposing again moves the hold instead of adding
+// another one.
+func findProposalHold(ctx context.Context, svc *calendar.Service, calendarID, eventID string) (*calendar.Event, error) {
+	holds, err := findEventsByProperties(ctx, svc, calendarID, map[string]string{proposalForProperty: eventID}, "", "", 1)
+	if err != nil {
+		return nil, gerrors.Wrap(err, "looking for an existing hold")
+	}
+	if len(holds) == 0 {
+		return nil, nil
+	}
+	return holds[0], nil
+}
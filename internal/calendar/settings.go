@@ -0,0 +1,210 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/calendar/v3"
+)
+
+// --- get_calendar_settings ---
+
+type getSettingsInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+}
+
+func registerGetCalendarSettings(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "get_calendar_settings",
+		Description: "Get the user's Google Calendar settings, such as timezone, week start, 24-hour time and default event length, along with the current local time in the calendar timezone. Set account to 'all' to get settings for all accounts. Use this before proposing meeting times.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input getSettingsInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var sb strings.Builder
+		multiAccount := len(accounts) > 1
+		now := time.Now()
+
+		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
+			svc, err := newService(ctx, mgr, account)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+			}
+
+			settings, err := listSettings(ctx, svc)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing settings: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, gerrors.Wrap(err, "listing settings")
+			}
+
+			if multiAccount {
+				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+			}
+			for _, s := range settings {
+				if s.Id == "timezone" {
+					accountTimeZones.set(account, s.Value)
+				}
+			}
+			sb.WriteString(formatSettings(settings, now))
+			sb.WriteString("\n")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// listSettings returns all of the user's calendar settings, sorted by ID.
+func listSettings(ctx context.Context, svc *calendar.Service) ([]*calendar.Setting, error) {
+	var settings []*calendar.Setting
+	pageToken := ""
+	for {
+		call := svc.Settings.List().Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, resp.Items...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Id < settings[j].Id })
+	return settings, nil
+}
+
+// weekdayNames maps the weekStart setting to a day name.
+var weekdayNames = map[string]string{"0": "Sunday", "1": "Monday", "6": "Saturday"}
+
+// formatSettings formats settings as key/value lines, followed by the
+// current time in the calendar timezone when the timezone is known.
+func formatSettings(settings []*calendar.Setting, now time.Time) string {
+	var sb strings.Builder
+	var zone string
+	for _, s := range settings {
+		value := s.Value
+		switch s.Id {
+		case "timezone":
+			zone = s.Value
+		case "weekStart":
+			if day, ok := weekdayNames[s.Value]; ok {
+				value = fmt.Sprintf("%s (%s)", s.Value, day)
+			}
+		case "defaultEventLength":
+			value += " minutes"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", s.Id, value)
+	}
+	if zone != "" {
+		if loc, err := time.LoadLocation(zone); err == nil {
+			local := now.In(loc)
+			fmt.Fprintf(&sb, "Local now: %s (%s, %s)\n", local.Format(time.RFC3339), local.Format("Monday"), zone)
+		} else {
+			fmt.Fprintf(&sb, "Local now: unknown (timezone %q is not recognized)\n", zone)
+		}
+	}
+	return sb.String()
+}
+
+// timeZoneCache caches each account's calendar timezone for the life of the
+// process, so tools can interpret times without a settings call each time.
+type timeZoneCache struct {
+	mu    sync.Mutex
+	zones map[string]*time.Location
+}
+
+var accountTimeZones = &timeZoneCache{zones: make(map[string]*time.Location)}
+
+// get returns the cached timezone of account, calling fetch to get the
+// IANA name on a miss. Failed fetches are not cached.
+func (c *timeZoneCache) get(account string, fetch func() (string, error)) (*time.Location, error) {
+	c.mu.Lock()
+	loc, ok := c.zones[account]
+	c.mu.Unlock()
+	if ok {
+		return loc, nil
+	}
+
+	name, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	loc, err = time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("calendar timezone %q: %w", name, err)
+	}
+	c.mu.Lock()
+	c.zones[account] = loc
+	c.mu.Unlock()
+	return loc, nil
+}
+
+// set records the timezone of account from a settings listing. Unknown
+// timezone names are ignored.
+func (c *timeZoneCache) set(account, name string) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.zones[account] = loc
+	c.mu.Unlock()
+}
+
+// accountTimeZone returns the calendar timezone setting of account (a
+// single account, resolved like any account input), for interpreting
+// datetimes that carry no offset.
+func accountTimeZone(ctx context.Context, mgr *auth.Manager, account string) (*time.Location, error) {
+	accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, account))
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) != 1 {
+		return nil, fmt.Errorf("a single account is required to look up the calendar timezone")
+	}
+	name := accounts[0]
+	return accountTimeZones.get(name, func() (string, error) {
+		svc, err := newService(ctx, mgr, name)
+		if err != nil {
+			return "", fmt.Errorf("creating Calendar service: %w", err)
+		}
+		setting, err := svc.Settings.Get("timezone").Context(ctx).Do()
+		if err != nil {
+			return "", gerrors.Wrap(err, "getting timezone setting")
+		}
+		return setting.Value, nil
+	})
+}
@@ -0,0 +1,208 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/bridge"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"google.golang.org/api/calendar/v3"
+)
+
+// templateVarPattern matches {{name}} placeholders in event descriptions.
+// Whitespace inside the braces is allowed: {{ notes_link }}.
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// notesLinkVar is the template variable that triggers creation of a
+// meeting notes document.
+const notesLinkVar = "notes_link"
+
+// templateVarDocs describes the variables available in event description
+// templates. It is used both for validation and for tool descriptions.
+var templateVarDocs = map[string]string{
+	"summary":    "event title",
+	"date":       "start date (YYYY-MM-DD)",
+	"start":      "start time as given (RFC3339 or date)",
+	"end":        "end time as given (RFC3339 or date)",
+	"location":   "event location",
+	"attendees":  "comma-separated attendee emails",
+	notesLinkVar: "link to a new Google Doc for meeting notes (created and attached on demand)",
+}
+
+// templateHelp returns a description snippet listing the template variables.
+func templateHelp() string {
+	names := make([]string, 0, len(templateVarDocs))
+	for name := range templateVarDocs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("\n\nThe description may contain {{variable}} placeholders, expanded server-side:\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  - {{%s}} — %s\n", name, templateVarDocs[name])
+	}
+	sb.WriteString("Unknown variables are rejected.")
+	return sb.String()
+}
+
+// templateVariables returns the distinct variable names referenced in tmpl,
+// in order of first appearance.
+func templateVariables(tmpl string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range templateVarPattern.FindAllStringSubmatch(tmpl, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// validateTemplate returns an error naming any unknown variables in tmpl.
+func validateTemplate(tmpl string) error {
+	var unknown []string
+	for _, name := range templateVariables(tmpl) {
+		if _, ok := templateVarDocs[name]; !ok {
+			unknown = append(unknown, "{{"+name+"}}")
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	known := make([]string, 0, len(templateVarDocs))
+	for name := range templateVarDocs {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("unknown template variable(s) %s in description; available: %s",
+		strings.Join(unknown, ", "), strings.Join(known, ", "))
+}
+
+// eventTemplateVars computes the template variables derived from an event.
+// notes_link is not included; it is supplied separately once the notes
+// document has been created.
+func eventTemplateVars(event *calendar.Event) map[string]string {
+	vars := map[string]string{
+		"summary":  event.Summary,
+		"location": event.Location,
+	}
+	if event.Start != nil {
+		if event.Start.Date != "" {
+			vars["date"] = event.Start.Date
+			vars["start"] = event.Start.Date
+		} else {
+			vars["start"] = event.Start.DateTime
+			if len(event.Start.DateTime) >= 10 {
+				vars["date"] = event.Start.DateTime[:10]
+			}
+		}
+	}
+	if event.End != nil {
+		if event.End.Date != "" {
+			vars["end"] = event.End.Date
+		} else {
+			vars["end"] = event.End.DateTime
+		}
+	}
+	emails := make([]string, 0, len(event.Attendees))
+	for _, a := range event.Attendees {
+		if a.Email != "" {
+			emails = append(emails, a.Email)
+		}
+	}
+	vars["attendees"] = strings.Join(emails, ", ")
+	return vars
+}
+
+// renderDescription expands the template variables in tmpl using values
+// from event. If {{notes_link}} is referenced, createNotes is called to
+// create the notes document — only after the template has been validated,
+// so a typo never leaves an orphaned document behind. Templates without
+// placeholders are returned unchanged.
+func renderDescription(tmpl string, event *calendar.Event, createNotes func() (string, error)) (string, error) {
+	names := templateVariables(tmpl)
+	if len(names) == 0 {
+		return tmpl, nil
+	}
+	if err := validateTemplate(tmpl); err != nil {
+		return "", err
+	}
+
+	vars := eventTemplateVars(event)
+	for _, name := range names {
+		if name == notesLinkVar {
+			link, err := createNotes()
+			if err != nil {
+				return "", gerrors.Wrap(err, "creating notes document for {{notes_link}}")
+			}
+			vars[notesLinkVar] = link
+			break
+		}
+	}
+
+	return templateVarPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		name := templateVarPattern.FindStringSubmatch(m)[1]
+		return vars[name]
+	}), nil
+}
+
+// meetingNotes creates the {{notes_link}} document of an event in
+// account's Drive and attaches it to the event.
+type meetingNotes struct {
+	ctx     context.Context
+	mgr     *auth.Manager
+	account string
+	event   *calendar.Event
+	doc     *bridge.GetDriveFileMetadataResult // set once created
+}
+
+func newMeetingNotes(ctx context.Context, mgr *auth.Manager, driveAccount string, event *calendar.Event) *meetingNotes {
+	return &meetingNotes{ctx: ctx, mgr: mgr, account: driveAccount, event: event}
+}
+
+// create is the createNotes callback for renderDescription.
+func (n *meetingNotes) create() (string, error) {
+	title := "Notes: " + n.event.Summary
+	if date := eventTemplateVars(n.event)["date"]; date != "" {
+		title += " (" + date + ")"
+	}
+	doc, err := bridge.CreateMeetingNotesDoc(n.ctx, n.mgr, bridge.CreateMeetingNotesDocParams{
+		DriveAccount: n.account,
+		Title:        title,
+	})
+	if err != nil {
+		return "", err
+	}
+	n.doc = doc
+	n.event.Attachments = append(n.event.Attachments, &calendar.EventAttachment{
+		FileId:   doc.FileID,
+		FileUrl:  doc.WebViewLink,
+		MimeType: doc.MIMEType,
+		Title:    doc.FileName,
+	})
+	return doc.WebViewLink, nil
+}
+
+// discard deletes the notes document, if one was created, after saving
+// the event failed, so no orphaned document is left behind. It returns
+// saveErr, noting the document's link if it couldn't be deleted.
+func (n *meetingNotes) discard(saveErr error) error {
+	if n.doc == nil {
+		return saveErr
+	}
+	// Clean up even when the call was cancelled.
+	err := bridge.DeleteDriveFile(context.WithoutCancel(n.ctx), n.mgr, bridge.DeleteDriveFileParams{
+		DriveAccount: n.account,
+		FileID:       n.doc.FileID,
+	})
+	if err != nil {
+		return fmt.Errorf("%w (the notes document created for it could not be deleted: %s: %v)", saveErr, n.doc.WebViewLink, err)
+	}
+	return saveErr
+}
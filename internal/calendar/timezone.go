@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// displayLocation loads the display_timezone input. An empty name returns
+// nil, which leaves times as the API returns them.
+func displayLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display_timezone %q: use an IANA name such as 'America/New_York'", name)
+	}
+	return loc, nil
+}
+
+// inZone returns the RFC3339 date-time dt re-rendered in loc, and the
+// offset dt was written with if it differs from the one in loc, else "".
+// A dt that doesn't parse is returned unchanged.
+func inZone(dt string, loc *time.Location) (converted, original string) {
+	t, err := time.Parse(time.RFC3339, dt)
+	if err != nil {
+		return dt, ""
+	}
+	local := t.In(loc)
+	_, from := t.Zone()
+	if _, to := local.Zone(); from != to {
+		original = t.Format("-07:00")
+	}
+	return local.Format(time.RFC3339), original
+}
+
+// displayDateTime formats the RFC3339 date-time dt for display in loc, e.g.
+// "2024-03-10T10:00:00+01:00 CET (originally -08:00)". A nil loc returns dt
+// unchanged.
+func displayDateTime(dt string, loc *time.Location) string {
+	if loc == nil {
+		return dt
+	}
+	t, err := time.Parse(time.RFC3339, dt)
+	if err != nil {
+		return dt
+	}
+	converted, original := inZone(dt, loc)
+	s := converted + " " + t.In(loc).Format("MST")
+	if original != "" {
+		s += " (originally " + original + ")"
+	}
+	return s
+}
+
+// eventInZone returns a copy of event whose timed start and end are shown
+// in loc: as displayDateTime formats them if annotate is set, or as plain
+// RFC3339 date-times for machine-readable output. Dates of all-day events
+// are left alone, since they name days rather than instants. A nil loc
+// returns event itself.
+func eventInZone(event *calendar.Event, loc *time.Location, annotate bool) *calendar.Event {
+	if loc == nil {
+		return event
+	}
+	convert := func(dt *calendar.EventDateTime) *calendar.EventDateTime {
+		if dt == nil || dt.DateTime == "" {
+			return dt
+		}
+		c := *dt
+		if annotate {
+			c.DateTime = displayDateTime(dt.DateTime, loc)
+		} else {
+			c.DateTime, _ = inZone(dt.DateTime, loc)
+		}
+		return &c
+	}
+	e := *event
+	e.Start = convert(event.Start)
+	e.End = convert(event.End)
+	return &e
+}
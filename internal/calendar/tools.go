@@ -21,9 +21,21 @@ var Scopes = []string{
 	driveapi.DriveScope,
 }
 
+// ReadonlyScopes is the scope set used with --readonly-scopes.
+// DriveReadonlyScope lets get_event_attachment download attached files.
+var ReadonlyScopes = []string{
+	calendar.CalendarReadonlyScope,
+	driveapi.DriveReadonlyScope,
+}
+
 // RegisterTools registers all Calendar MCP tools on the given server.
 func RegisterTools(srv *server.Server, mgr *auth.Manager) {
+	srv.SetAccountNames(mgr.AccountNames())
 	server.RegisterAccountsListTool(srv, mgr)
+	server.RegisterAccountsResource(srv, mgr)
+	// prompts.go
+	registerPrompts(srv)
+	server.RegisterMutationsTool(srv)
 	server.RegisterLocalFSTools(srv)
 	// calendars.go
 	registerListCalendars(srv, mgr)
@@ -45,8 +57,19 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerQuickAddEvent(srv, mgr)
 	registerListEventInstances(srv, mgr)
 	registerMoveEvent(srv, mgr)
+	registerFindEventsByPrivateProperty(srv, mgr)
+	// bulk.go
+	registerCreateEventsBulk(srv, mgr)
+	// attachments.go
+	registerGetEventAttachment(srv, mgr)
 	// freebusy.go
 	registerQueryFreeBusy(srv, mgr)
+	registerFindAvailableRoom(srv, mgr)
+	// analytics.go
+	registerMeetingLoadReport(srv, mgr)
+	// invitations.go
+	registerListPendingInvitations(srv, mgr)
+	registerRespondEventsBulk(srv, mgr)
 	// acl.go
 	registerShareCalendar(srv, mgr)
 	registerListCalendarSharing(srv, mgr)
@@ -55,10 +78,18 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerDeleteACLRule(srv, mgr)
 	// colors.go
 	registerGetColors(srv, mgr)
+	registerGetCalendarSettings(srv, mgr)
+	// ics.go
+	registerExportEventsICS(srv, mgr)
+	registerImportICS(srv, mgr)
+	// watch.go
+	registerWatchEvents(srv, mgr)
+	registerListWatchChannels(srv, mgr)
+	registerStopChannel(srv, mgr)
 }
 
 func newService(ctx context.Context, mgr *auth.Manager, account string) (*calendar.Service, error) {
-	opt, err := mgr.ClientOption(ctx, account, Scopes)
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), Scopes, ReadonlyScopes)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +102,39 @@ func isDateOnly(s string) bool {
 	return err == nil
 }
 
+// eventRow is a list_events result in json or csv format.
+type eventRow struct {
+	Account string
+	Event   *calendar.Event
+}
+
+// eventColumns are the json and csv columns of list_events. All-day events
+// have dates instead of date-times in start and end.
+var eventColumns = []server.Column[eventRow]{
+	{Name: "account", Value: func(r eventRow) any { return r.Account }},
+	{Name: "event_id", Value: func(r eventRow) any { return r.Event.Id }},
+	{Name: "summary", Value: func(r eventRow) any { return r.Event.Summary }},
+	{Name: "start", Value: func(r eventRow) any { return eventDateTime(r.Event.Start) }},
+	{Name: "end", Value: func(r eventRow) any { return eventDateTime(r.Event.End) }},
+	{Name: "all_day", Value: func(r eventRow) any {
+		return r.Event.Start != nil && r.Event.Start.DateTime == "" && r.Event.Start.Date != ""
+	}},
+	{Name: "location", Value: func(r eventRow) any { return r.Event.Location }},
+	{Name: "status", Value: func(r eventRow) any { return r.Event.Status }},
+	{Name: "link", Value: func(r eventRow) any { return r.Event.HtmlLink }},
+}
+
+// eventDateTime returns the date-time of t, or its date for all-day events.
+func eventDateTime(t *calendar.EventDateTime) string {
+	if t == nil {
+		return ""
+	}
+	if t.DateTime != "" {
+		return t.DateTime
+	}
+	return t.Date
+}
+
 // formatEvent formats an event for brief display.
 func formatEvent(event *calendar.Event, account string) string {
 	var sb strings.Builder
@@ -133,6 +197,19 @@ func formatEventDetailed(event *calendar.Event) string {
 	if event.Status != "" {
 		fmt.Fprintf(&sb, "Status: %s\n", event.Status)
 	}
+	sb.WriteString(formatEventType(event))
+	if event.ColorId != "" {
+		fmt.Fprintf(&sb, "Color ID: %s\n", event.ColorId)
+	}
+	if event.Visibility != "" && event.Visibility != "default" {
+		fmt.Fprintf(&sb, "Visibility: %s\n", event.Visibility)
+	}
+	switch event.Transparency {
+	case "transparent":
+		sb.WriteString("Show as: free (transparent)\n")
+	case "opaque":
+		sb.WriteString("Show as: busy (opaque)\n")
+	}
 	if event.HtmlLink != "" {
 		fmt.Fprintf(&sb, "Link: %s\n", event.HtmlLink)
 	}
@@ -150,8 +227,12 @@ func formatEventDetailed(event *calendar.Event) string {
 				name = a.Email
 			}
 			fmt.Fprintf(&sb, "  - %s (%s)\n", name, a.ResponseStatus)
+			if a.Comment != "" {
+				fmt.Fprintf(&sb, "    Comment: %s\n", strings.ReplaceAll(a.Comment, "\n", "\n    "))
+			}
 		}
 	}
+	sb.WriteString(formatPrivateProperties(event, ""))
 	if len(event.Recurrence) > 0 {
 		fmt.Fprintf(&sb, "Recurrence: %s\n", strings.Join(event.Recurrence, "; "))
 	}
@@ -166,6 +247,9 @@ func formatEventDetailed(event *calendar.Event) string {
 			if att.MimeType != "" {
 				fmt.Fprintf(&sb, " (%s)", att.MimeType)
 			}
+			if att.FileId != "" {
+				fmt.Fprintf(&sb, "\n    File ID: %s", att.FileId)
+			}
 			if att.FileUrl != "" {
 				fmt.Fprintf(&sb, "\n    URL: %s", att.FileUrl)
 			}
@@ -182,7 +266,65 @@ func formatEventDetailed(event *calendar.Event) string {
 	return sb.String()
 }
 
+// autoDeclineDescriptions describes the API's autoDeclineMode values.
+var autoDeclineDescriptions = map[string]string{
+	"declineAllConflictingInvitations":     "all conflicting invitations",
+	"declineOnlyNewConflictingInvitations": "new conflicting invitations",
+	"declineNone":                          "none",
+}
+
+// formatEventType formats the type of a special event and its properties,
+// or returns "" for regular events.
+func formatEventType(event *calendar.Event) string {
+	if event.EventType == "" || event.EventType == "default" {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Type: %s\n", event.EventType)
+	autoDecline := func(mode, message string) {
+		if mode != "" {
+			desc := autoDeclineDescriptions[mode]
+			if desc == "" {
+				desc = mode
+			}
+			fmt.Fprintf(&sb, "Auto-decline: %s\n", desc)
+		}
+		if message != "" {
+			fmt.Fprintf(&sb, "Decline message: %s\n", message)
+		}
+	}
+	switch {
+	case event.OutOfOfficeProperties != nil:
+		autoDecline(event.OutOfOfficeProperties.AutoDeclineMode, event.OutOfOfficeProperties.DeclineMessage)
+	case event.FocusTimeProperties != nil:
+		autoDecline(event.FocusTimeProperties.AutoDeclineMode, event.FocusTimeProperties.DeclineMessage)
+		if status := event.FocusTimeProperties.ChatStatus; status != "" {
+			fmt.Fprintf(&sb, "Chat status: %s\n", status)
+		}
+	case event.WorkingLocationProperties != nil:
+		wl := event.WorkingLocationProperties
+		switch {
+		case wl.HomeOffice != nil:
+			sb.WriteString("Working location: home\n")
+		case wl.OfficeLocation != nil:
+			label := wl.OfficeLocation.Label
+			if label == "" {
+				label = wl.OfficeLocation.BuildingId
+			}
+			fmt.Fprintf(&sb, "Working location: office %s\n", label)
+		case wl.CustomLocation != nil:
+			fmt.Fprintf(&sb, "Working location: %s\n", wl.CustomLocation.Label)
+		}
+	}
+	return sb.String()
+}
+
 // AccountScopes returns the scopes used by Calendar tools.
 func AccountScopes() []string {
 	return Scopes
 }
+
+// AccountReadonlyScopes returns the scopes used by the read-only Calendar tools.
+func AccountReadonlyScopes() []string {
+	return ReadonlyScopes
+}
@@ -2,18 +2,28 @@ package calendar
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	calendarapi "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 func newTestManager(t *testing.T) *auth.Manager {
@@ -180,6 +190,193 @@ func TestFormatEventDetailed_Minimal(t *testing.T) {
 	}
 }
 
+func TestFormatEventDetailed_Attachments(t *testing.T) {
+	event := &calendarapi.Event{
+		Summary: "Review",
+		Id:      "event-att",
+		Attachments: []*calendarapi.EventAttachment{
+			{FileId: "file-1", Title: "Spec", FileUrl: "https://drive.google.com/file/d/file-1", MimeType: "application/pdf"},
+		},
+	}
+
+	result := formatEventDetailed(event)
+
+	for _, want := range []string{"Attachments:", "Spec", "File ID: file-1", "https://drive.google.com/file/d/file-1"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatEventDetailed_Display(t *testing.T) {
+	event := &calendarapi.Event{
+		Summary:      "Focus",
+		Id:           "event-focus",
+		ColorId:      "11",
+		Visibility:   "private",
+		Transparency: "transparent",
+	}
+
+	result := formatEventDetailed(event)
+
+	for _, want := range []string{"Color ID: 11", "Visibility: private", "Show as: free (transparent)"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q:\n%s", want, result)
+		}
+	}
+
+	result = formatEventDetailed(&calendarapi.Event{Summary: "Plain", Visibility: "default"})
+	for _, unwanted := range []string{"Color ID:", "Visibility:", "Show as:"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("result should not contain %q:\n%s", unwanted, result)
+		}
+	}
+}
+
+func TestValidateEventDisplay(t *testing.T) {
+	valid := [][2]string{{"", ""}, {"default", "opaque"}, {"public", "transparent"}, {"private", ""}}
+	for _, v := range valid {
+		if err := validateEventDisplay(v[0], v[1]); err != nil {
+			t.Errorf("validateEventDisplay(%q, %q) = %v", v[0], v[1], err)
+		}
+	}
+	if err := validateEventDisplay("secret", ""); err == nil || !strings.Contains(err.Error(), "visibility") {
+		t.Errorf("invalid visibility: err = %v", err)
+	}
+	if err := validateEventDisplay("", "free"); err == nil || !strings.Contains(err.Error(), "transparency") {
+		t.Errorf("invalid transparency: err = %v", err)
+	}
+}
+
+func TestValidateEventColor(t *testing.T) {
+	requests := 0
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasSuffix(r.URL.Path, "/colors") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&calendarapi.Colors{Event: map[string]calendarapi.ColorDefinition{
+			"1":  {Background: "#a4bdfc"},
+			"2":  {Background: "#7ae7bf"},
+			"10": {Background: "#51b749"},
+		}})
+	})
+
+	if err := validateEventColor(context.Background(), svc, ""); err != nil || requests != 0 {
+		t.Errorf("empty color: err = %v, requests = %d", err, requests)
+	}
+	if err := validateEventColor(context.Background(), svc, "10"); err != nil {
+		t.Errorf("valid color: %v", err)
+	}
+	err := validateEventColor(context.Background(), svc, "12")
+	if err == nil || !strings.Contains(err.Error(), "must be one of 1, 2, 10") {
+		t.Errorf("invalid color: err = %v", err)
+	}
+}
+
+func TestRemoveEventAttachments(t *testing.T) {
+	attachments := []*calendarapi.EventAttachment{
+		{FileId: "file-1", Title: "Spec"},
+		{FileId: "file-2", Title: "Budget"},
+		{FileId: "file-3", Title: "Notes: Review"},
+	}
+
+	kept, notFound := removeEventAttachments(attachments, []string{"file-1", "budget", "missing"})
+	if len(kept) != 1 || kept[0].FileId != "file-3" {
+		t.Errorf("kept = %v, want only file-3", kept)
+	}
+	if len(notFound) != 1 || notFound[0] != `"missing"` {
+		t.Errorf("notFound = %v, want [\"missing\"]", notFound)
+	}
+	if len(attachments) != 3 {
+		t.Errorf("input slice was modified: %d attachments", len(attachments))
+	}
+}
+
+func TestRemoveEventAttachments_NoMatch(t *testing.T) {
+	attachments := []*calendarapi.EventAttachment{{FileId: "file-1", Title: "Spec"}}
+
+	kept, notFound := removeEventAttachments(attachments, []string{"file-9"})
+	if len(kept) != 1 {
+		t.Errorf("kept %d attachments, want 1 (no-op)", len(kept))
+	}
+	if len(notFound) != 1 {
+		t.Errorf("notFound = %v, want one entry", notFound)
+	}
+
+	kept, notFound = removeEventAttachments(nil, []string{"file-1"})
+	if len(kept) != 0 || len(notFound) != 1 {
+		t.Errorf("removing from no attachments: kept=%v notFound=%v", kept, notFound)
+	}
+}
+
+func TestMergeAttendees(t *testing.T) {
+	existing := []*calendarapi.EventAttendee{
+		{Email: "alice@example.com", ResponseStatus: "accepted"},
+		{Email: "Bob@Example.com", ResponseStatus: "tentative", Optional: true},
+		{Email: "carol@example.com", ResponseStatus: "declined"},
+	}
+
+	merged, notFound, err := mergeAttendees(existing,
+		[]string{"dave@example.com", "bob@example.com", "DAVE@example.com", " erin@example.com "},
+		[]string{"CAROL@example.com", "zed@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, a := range merged {
+		got = append(got, fmt.Sprintf("%s/%s/%t", a.Email, a.ResponseStatus, a.Optional))
+	}
+	want := []string{
+		"alice@example.com/accepted/false",
+		"Bob@Example.com/tentative/true",
+		"dave@example.com//false",
+		"erin@example.com//false",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("merged = %v, want %v", got, want)
+	}
+	if len(notFound) != 1 || notFound[0] != "zed@example.com" {
+		t.Errorf("notFound = %v, want [zed@example.com]", notFound)
+	}
+	// Existing attendees are reused, not copied, so no fields are lost.
+	if merged[1] != existing[1] {
+		t.Error("existing attendee was replaced")
+	}
+}
+
+func TestMergeAttendees_RemoveAll(t *testing.T) {
+	merged, notFound, err := mergeAttendees(
+		[]*calendarapi.EventAttendee{{Email: "alice@example.com"}},
+		nil, []string{"alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 0 || len(notFound) != 0 {
+		t.Errorf("merged=%v notFound=%v, want both empty", merged, notFound)
+	}
+}
+
+func TestMergeAttendees_Conflict(t *testing.T) {
+	_, _, err := mergeAttendees(nil, []string{"alice@example.com"}, []string{"Alice@example.com"})
+	if err == nil || !strings.Contains(err.Error(), "both add_attendees and remove_attendees") {
+		t.Errorf("err = %v, want add/remove conflict", err)
+	}
+}
+
+func TestValidateSendUpdates(t *testing.T) {
+	for _, v := range []string{"", "all", "externalOnly", "none"} {
+		if err := validateSendUpdates(v); err != nil {
+			t.Errorf("validateSendUpdates(%q) = %v", v, err)
+		}
+	}
+	if err := validateSendUpdates("external"); err == nil {
+		t.Error("validateSendUpdates(\"external\") should fail")
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		input time.Duration
@@ -259,30 +456,44 @@ func TestToolNames(t *testing.T) {
 	want := []string{
 		"create_calendar",
 		"create_event",
+		"create_events_bulk",
 		"delete_acl_rule",
 		"delete_calendar",
 		"delete_event",
+		"export_events_ics",
+		"find_available_room",
+		"find_events_by_private_property",
 		"get_acl_rule",
 		"get_calendar",
 		"get_calendar_list_entry",
+		"get_calendar_settings",
 		"get_colors",
 		"get_event",
+		"get_event_attachment",
+		"import_ics",
 		"list_accounts",
 		"list_calendar_sharing",
 		"list_calendars",
 		"list_event_instances",
 		"list_events",
+		"list_pending_invitations",
+		"list_recent_mutations",
+		"list_watch_channels",
+		"meeting_load_report",
 		"move_event",
 		"query_free_busy",
 		"quick_add_event",
 		"respond_event",
+		"respond_events_bulk",
 		"share_calendar",
+		"stop_channel",
 		"subscribe_calendar",
 		"unsubscribe_calendar",
 		"update_acl_rule",
 		"update_calendar",
 		"update_calendar_list_entry",
 		"update_event",
+		"watch_events",
 	}
 
 	if len(got) != len(want) {
@@ -309,6 +520,9 @@ func TestToolAnnotations(t *testing.T) {
 		"list_accounts", "list_calendars", "list_events", "get_event",
 		"list_event_instances", "query_free_busy", "list_calendar_sharing",
 		"get_calendar", "get_calendar_list_entry", "get_acl_rule", "get_colors",
+		"meeting_load_report", "list_watch_channels", "export_events_ics", "get_event_attachment",
+		"get_calendar_settings", "find_events_by_private_property", "list_recent_mutations",
+		"find_available_room", "list_pending_invitations",
 	}
 	for _, name := range readOnly {
 		tool := toolMap[name]
@@ -326,7 +540,8 @@ func TestToolAnnotations(t *testing.T) {
 		"quick_add_event", "move_event",
 		"share_calendar", "create_calendar", "update_calendar", "delete_calendar",
 		"subscribe_calendar", "unsubscribe_calendar", "update_calendar_list_entry",
-		"update_acl_rule", "delete_acl_rule",
+		"update_acl_rule", "delete_acl_rule", "watch_events", "stop_channel", "import_ics",
+		"create_events_bulk", "respond_events_bulk",
 	}
 	for _, name := range mutations {
 		tool := toolMap[name]
@@ -364,9 +579,9 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 	}
 	sort.Strings(got)
 
-	// Should include all 26 base tools + 2 localfs tools = 28.
-	if len(got) != 28 {
-		t.Fatalf("got %d tools, want 28\ngot: %v", len(got), got)
+	// Should include all 40 base tools + 3 localfs tools = 43.
+	if len(got) != 43 {
+		t.Fatalf("got %d tools, want 43\ngot: %v", len(got), got)
 	}
 
 	names := make(map[string]bool)
@@ -379,4 +594,2348 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 	if !names["read_local_file"] {
 		t.Error("expected read_local_file tool")
 	}
+	if !names["stat_local_file"] {
+		t.Error("expected stat_local_file tool")
+	}
+}
+
+func TestRenderDescription_Variables(t *testing.T) {
+	event := &calendarapi.Event{
+		Summary:  "Planning",
+		Location: "Room 4",
+		Start:    &calendarapi.EventDateTime{DateTime: "2024-01-15T09:00:00-05:00"},
+		End:      &calendarapi.EventDateTime{DateTime: "2024-01-15T10:00:00-05:00"},
+		Attendees: []*calendarapi.EventAttendee{
+			{Email: "alice@example.com"},
+			{Email: "bob@example.com"},
+		},
+	}
+	noNotes := func() (string, error) {
+		t.Fatal("createNotes should not be called")
+		return "", nil
+	}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{{summary}}", "Planning"},
+		{"{{date}}", "2024-01-15"},
+		{"{{start}}", "2024-01-15T09:00:00-05:00"},
+		{"{{end}}", "2024-01-15T10:00:00-05:00"},
+		{"{{location}}", "Room 4"},
+		{"{{attendees}}", "alice@example.com, bob@example.com"},
+		{"Agenda for {{ summary }} on {{date}}", "Agenda for Planning on 2024-01-15"},
+		{"no placeholders", "no placeholders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := renderDescription(tt.tmpl, event, noNotes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderDescription(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDescription_AllDayDate(t *testing.T) {
+	event := &calendarapi.Event{
+		Start: &calendarapi.EventDateTime{Date: "2024-12-25"},
+		End:   &calendarapi.EventDateTime{Date: "2024-12-26"},
+	}
+	got, err := renderDescription("{{date}}/{{end}}", event, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2024-12-25/2024-12-26" {
+		t.Errorf("got %q, want 2024-12-25/2024-12-26", got)
+	}
+}
+
+func TestRenderDescription_NotesLink(t *testing.T) {
+	event := &calendarapi.Event{Summary: "Sync"}
+	calls := 0
+	createNotes := func() (string, error) {
+		calls++
+		return "https://docs.google.com/document/d/abc", nil
+	}
+
+	got, err := renderDescription("Notes doc: {{notes_link}}\nAgain: {{notes_link}}", event, createNotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("createNotes called %d times, want 1", calls)
+	}
+	want := "Notes doc: https://docs.google.com/document/d/abc\nAgain: https://docs.google.com/document/d/abc"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderDescription_UnknownVariable(t *testing.T) {
+	called := false
+	createNotes := func() (string, error) {
+		called = true
+		return "link", nil
+	}
+
+	_, err := renderDescription("{{notes_link}} {{agnda}}", &calendarapi.Event{}, createNotes)
+	if err == nil {
+		t.Fatal("expected error for unknown variable")
+	}
+	if !strings.Contains(err.Error(), "{{agnda}}") {
+		t.Errorf("error %q should name the unknown variable", err)
+	}
+	if want := "available: attendees, date, end, location, notes_link, start, summary"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q should list the known variables (%s)", err, want)
+	}
+	if called {
+		t.Error("notes doc must not be created when the template is invalid")
+	}
+}
+
+func TestTemplateHelp(t *testing.T) {
+	help := templateHelp()
+	for name := range templateVarDocs {
+		if !strings.Contains(help, "{{"+name+"}}") {
+			t.Errorf("templateHelp() missing %q", name)
+		}
+	}
+}
+
+// timedEvent builds a synthetic timed event for meeting load tests.
+func timedEvent(id, series, organizer, start, end string, attendees ...*calendarapi.EventAttendee) *calendarapi.Event {
+	return &calendarapi.Event{
+		Id:               id,
+		ICalUID:          id + "@google.com",
+		Summary:          "Meeting " + series,
+		RecurringEventId: series,
+		Organizer:        &calendarapi.EventOrganizer{Email: organizer},
+		Start:            &calendarapi.EventDateTime{DateTime: start},
+		End:              &calendarapi.EventDateTime{DateTime: end},
+		Attendees:        attendees,
+	}
+}
+
+func attendees(n int) []*calendarapi.EventAttendee {
+	out := make([]*calendarapi.EventAttendee, n)
+	for i := range out {
+		out[i] = &calendarapi.EventAttendee{Email: fmt.Sprintf("a%d@example.com", i), ResponseStatus: "accepted"}
+	}
+	return out
+}
+
+func TestQuickAddWarnings(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name  string
+		start *calendarapi.EventDateTime
+		want  string
+	}{
+		{"upcoming", &calendarapi.EventDateTime{DateTime: "2026-03-11T12:00:00+01:00"}, ""},
+		{"past", &calendarapi.EventDateTime{DateTime: "2026-03-10T11:00:00Z"}, "2026-03-10T11:00:00Z is in the past"},
+		{"far future", &calendarapi.EventDateTime{DateTime: "2027-03-11T12:00:00Z"}, "more than a year away"},
+		{"today all day", &calendarapi.EventDateTime{Date: "2026-03-10"}, ""},
+		{"yesterday all day", &calendarapi.EventDateTime{Date: "2026-03-09"}, "2026-03-09 is in the past"},
+		{"no start", nil, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := strings.Join(quickAddWarnings(&calendarapi.Event{Start: tc.start}, now), "; ")
+			if tc.want == "" && got != "" || !strings.Contains(got, tc.want) {
+				t.Errorf("warnings = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuickAddPreview(t *testing.T) {
+	got := formatQuickAddPreview(&calendarapi.Event{
+		Id:        "e1",
+		Summary:   "Lunch with Bob",
+		Start:     &calendarapi.EventDateTime{DateTime: "2026-03-11T12:00:00+01:00"},
+		End:       &calendarapi.EventDateTime{DateTime: "2026-03-11T13:00:00+01:00"},
+		Attendees: []*calendarapi.EventAttendee{{Email: "bob@example.com"}},
+	}, "primary", []string{"the parsed start is in the past"})
+	for _, want := range []string{
+		"Dry run: nothing was created.",
+		"Summary: Lunch with Bob\nStart: 2026-03-11T12:00:00+01:00\nEnd: 2026-03-11T13:00:00+01:00\nAttendees: bob@example.com\n",
+		"Warning: the parsed start is in the past",
+		"created on primary without notifications and deleted right away",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("preview missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Event ID") {
+		t.Errorf("preview shows the deleted event's ID:\n%s", got)
+	}
+}
+
+func TestPendingInvitations(t *testing.T) {
+	self := func(status string) []*calendarapi.EventAttendee {
+		return []*calendarapi.EventAttendee{
+			{Email: "alice@example.com", ResponseStatus: "accepted"},
+			{Email: "me@example.com", Self: true, ResponseStatus: status},
+		}
+	}
+	events := []*calendarapi.Event{
+		{Id: "pending", Status: "confirmed", Attendees: self("needsAction")},
+		{Id: "accepted", Status: "confirmed", Attendees: self("accepted")},
+		{Id: "tentative", Status: "confirmed", Attendees: self("tentative")},
+		{Id: "no-attendees", Status: "confirmed"},
+		{Id: "others-pending", Status: "confirmed", Attendees: []*calendarapi.EventAttendee{{Email: "bob@example.com", ResponseStatus: "needsAction"}}},
+		{Id: "cancelled", Status: "cancelled", Attendees: self("needsAction")},
+		{Id: "own", Status: "confirmed", Organizer: &calendarapi.EventOrganizer{Self: true}, Attendees: self("needsAction")},
+		{Id: "pending-2", Attendees: self("needsAction")},
+	}
+	var got []string
+	for _, e := range pendingInvitations(events) {
+		got = append(got, e.Id)
+	}
+	if strings.Join(got, ",") != "pending,pending-2" {
+		t.Errorf("pendingInvitations = %v, want [pending pending-2]", got)
+	}
+}
+
+func TestSortInvitations(t *testing.T) {
+	at := func(id, dateTime, date string) pendingInvitation {
+		return pendingInvitation{Event: &calendarapi.Event{Id: id, Start: &calendarapi.EventDateTime{DateTime: dateTime, Date: date}}}
+	}
+	pending := []pendingInvitation{
+		at("late", "2026-03-02T09:00:00Z", ""),
+		at("offset", "2026-03-01T11:00:00+02:00", ""), // 09:00 UTC
+		at("all-day", "", "2026-03-01"),
+		at("utc", "2026-03-01T10:00:00Z", ""),
+	}
+	sortInvitations(pending)
+	var got []string
+	for _, p := range pending {
+		got = append(got, p.Event.Id)
+	}
+	if strings.Join(got, ",") != "all-day,offset,utc,late" {
+		t.Errorf("order = %v, want [all-day offset utc late]", got)
+	}
+}
+
+func TestFormatInvitation(t *testing.T) {
+	got := formatInvitation(pendingInvitation{
+		CalendarID: "team@example.com",
+		Event: &calendarapi.Event{
+			Id:        "e1",
+			Summary:   "Planning",
+			Start:     &calendarapi.EventDateTime{DateTime: "2026-03-01T10:00:00Z"},
+			End:       &calendarapi.EventDateTime{DateTime: "2026-03-01T11:00:00Z"},
+			Organizer: &calendarapi.EventOrganizer{DisplayName: "Alice", Email: "alice@example.com"},
+			HtmlLink:  "https://calendarapi.google.com/event?eid=e1",
+		},
+	}, "work")
+	want := "- Planning\n  Event ID: e1\n  Calendar ID: team@example.com\n  Account: work\n  Start: 2026-03-01T10:00:00Z\n  End: 2026-03-01T11:00:00Z\n  Organizer: Alice <alice@example.com>\n  Link: https://calendarapi.google.com/event?eid=e1\n"
+	if got != want {
+		t.Errorf("formatInvitation =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestOwnedCalendarIDs(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("minAccessRole"); got != "owner" {
+			t.Errorf("minAccessRole = %q, want owner", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"items":[{"id":"me@example.com","primary":true},{"id":"family@group.calendar.google.com"}]}`)
+	})
+	ids, err := ownedCalendarIDs(context.Background(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ids, ","); got != "primary,family@group.calendar.google.com" {
+		t.Errorf("ownedCalendarIDs = %s, want primary,family@group.calendar.google.com", got)
+	}
+}
+
+func TestMeetingLoad_GroupsAndRanks(t *testing.T) {
+	events := []*calendarapi.Event{
+		// Weekly standup: 2 occurrences × 30m × 8 people = 8h person-time.
+		timedEvent("s1", "standup", "lead@example.com", "2024-03-04T09:00:00Z", "2024-03-04T09:30:00Z", attendees(8)...),
+		timedEvent("s2", "standup", "lead@example.com", "2024-03-11T09:00:00Z", "2024-03-11T09:30:00Z", attendees(8)...),
+		// Monthly review: 1 × 2h × 3 people = 6h person-time.
+		timedEvent("r1", "review", "boss@example.com", "2024-03-05T14:00:00Z", "2024-03-05T16:00:00Z", attendees(3)...),
+		// One-off 1:1 with no attendee list: 1h × 1.
+		timedEvent("o1", "", "boss@example.com", "2024-03-06T10:00:00Z", "2024-03-06T11:00:00Z"),
+	}
+
+	r := meetingLoad(events)
+
+	if r.Total.Meetings != 4 {
+		t.Errorf("Total.Meetings = %d, want 4", r.Total.Meetings)
+	}
+	if r.Total.Duration != 4*time.Hour {
+		t.Errorf("Total.Duration = %v, want 4h", r.Total.Duration)
+	}
+	if r.Total.PersonTime != 15*time.Hour {
+		t.Errorf("Total.PersonTime = %v, want 15h", r.Total.PersonTime)
+	}
+
+	if len(r.Series) != 3 {
+		t.Fatalf("got %d series, want 3: %+v", len(r.Series), r.Series)
+	}
+	if r.Series[0].Key != "standup" || r.Series[0].Meetings != 2 || r.Series[0].PersonTime != 8*time.Hour {
+		t.Errorf("top series = %+v, want standup with 2 meetings, 8h", r.Series[0])
+	}
+	if r.Series[1].Key != "review" || r.Series[2].Key != oneOffSeriesKey {
+		t.Errorf("series order = %s, %s; want review, one-off", r.Series[1].Key, r.Series[2].Key)
+	}
+
+	if len(r.Organizers) != 2 {
+		t.Fatalf("got %d organizers, want 2", len(r.Organizers))
+	}
+	if r.Organizers[0].Label != "lead@example.com" || r.Organizers[1].PersonTime != 7*time.Hour {
+		t.Errorf("organizers = %+v", r.Organizers)
+	}
+}
+
+func TestMeetingLoad_Exclusions(t *testing.T) {
+	declinedByMe := timedEvent("d1", "", "x@example.com", "2024-03-04T09:00:00Z", "2024-03-04T10:00:00Z",
+		&calendarapi.EventAttendee{Email: "me@example.com", Self: true, ResponseStatus: "declined"})
+	allDay := &calendarapi.Event{
+		Id:    "a1",
+		Start: &calendarapi.EventDateTime{Date: "2024-03-05"},
+		End:   &calendarapi.EventDateTime{Date: "2024-03-06"},
+	}
+	cancelled := timedEvent("c1", "", "x@example.com", "2024-03-04T09:00:00Z", "2024-03-04T10:00:00Z")
+	cancelled.Status = "cancelled"
+	// Declined attendees and rooms don't add person-time.
+	partial := timedEvent("p1", "", "x@example.com", "2024-03-07T09:00:00Z", "2024-03-07T10:00:00Z",
+		&calendarapi.EventAttendee{Email: "me@example.com", Self: true, ResponseStatus: "accepted"},
+		&calendarapi.EventAttendee{Email: "no@example.com", ResponseStatus: "declined"},
+		&calendarapi.EventAttendee{Email: "room@resource.calendar.google.com", Resource: true, ResponseStatus: "accepted"},
+		&calendarapi.EventAttendee{Email: "maybe@example.com", ResponseStatus: "needsAction"},
+	)
+
+	r := meetingLoad([]*calendarapi.Event{declinedByMe, allDay, cancelled, partial})
+
+	if r.SkippedDeclined != 1 || r.SkippedAllDay != 1 {
+		t.Errorf("skipped declined=%d allDay=%d, want 1 and 1", r.SkippedDeclined, r.SkippedAllDay)
+	}
+	if r.Total.Meetings != 1 || r.Total.PersonTime != 2*time.Hour {
+		t.Errorf("total = %+v, want 1 meeting with 2h person-time", r.Total)
+	}
+}
+
+func TestMeetingLoad_DST(t *testing.T) {
+	events := []*calendarapi.Event{
+		// US fall back: 01:30 EDT to 01:30 EST is one hour of wall time
+		// that reads as zero on the clock.
+		timedEvent("f1", "", "x@example.com", "2024-11-03T01:30:00-04:00", "2024-11-03T01:30:00-05:00"),
+		// US spring forward: 01:30 EST to 03:30 EDT is one hour, not two.
+		timedEvent("s1", "", "x@example.com", "2024-03-10T01:30:00-05:00", "2024-03-10T03:30:00-04:00"),
+		// A week-long span across the change is 7×24h plus the extra hour.
+		timedEvent("w1", "", "x@example.com", "2024-10-31T12:00:00-04:00", "2024-11-07T12:00:00-05:00"),
+	}
+
+	r := meetingLoad(events)
+
+	want := time.Hour + time.Hour + 7*24*time.Hour + time.Hour
+	if r.Total.Duration != want {
+		t.Errorf("Total.Duration = %v, want %v", r.Total.Duration, want)
+	}
+}
+
+func TestMeetingLoad_CombinedDedupesSharedMeetings(t *testing.T) {
+	// The same meeting on two accounts' calendars has the same iCalUID and
+	// start but different event IDs.
+	work := timedEvent("w1", "", "x@example.com", "2024-03-04T09:00:00Z", "2024-03-04T10:00:00Z", attendees(2)...)
+	personal := timedEvent("p1", "", "x@example.com", "2024-03-04T10:00:00+01:00", "2024-03-04T11:00:00+01:00", attendees(2)...)
+	personal.ICalUID = work.ICalUID
+	other := timedEvent("w2", "", "x@example.com", "2024-03-05T09:00:00Z", "2024-03-05T10:00:00Z")
+
+	r := meetingLoad([]*calendarapi.Event{work, personal, other})
+	if r.Total.Meetings != 2 {
+		t.Errorf("Total.Meetings = %d, want 2 (shared meeting counted once)", r.Total.Meetings)
+	}
+}
+
+func TestFormatLoadReport(t *testing.T) {
+	var events []*calendarapi.Event
+	for i := range 3 {
+		day := fmt.Sprintf("2024-03-0%d", i+1)
+		events = append(events, timedEvent(fmt.Sprintf("e%d", i), fmt.Sprintf("series-%d", i), "x@example.com",
+			day+"T09:00:00Z", day+"T10:00:00Z", attendees(i+1)...))
+	}
+	events = append(events, &calendarapi.Event{
+		Start: &calendarapi.EventDateTime{Date: "2024-03-09"},
+		End:   &calendarapi.EventDateTime{Date: "2024-03-10"},
+	})
+
+	got := formatLoadReport(meetingLoad(events), 2)
+	for _, want := range []string{
+		"Totals: 3 meetings, 3h in meetings, 6h person-time",
+		"By recurring series (ranked by person-time):\n  1. Meeting series-2 — 1 meetings, 1h, 3h person-time",
+		"... and 1 more",
+		"By organizer",
+		"Excluded: 1 all-day, 0 declined",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := formatLoadReport(meetingLoad(nil), 10); !strings.Contains(got, "No timed meetings") {
+		t.Errorf("empty report = %q", got)
+	}
+}
+
+// newFakeService returns a Calendar service that sends all requests to handler.
+func newFakeService(t *testing.T, handler http.HandlerFunc) *calendarapi.Service {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	svc, err := calendarapi.NewService(context.Background(),
+		option.WithHTTPClient(ts.Client()),
+		option.WithEndpoint(ts.URL+"/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+// toolHints is the expected annotation of a tool. Every hint must be set
+// explicitly; clients disagree on the defaults of unset hints.
+type toolHints struct {
+	readOnly, destructive, idempotent, openWorld bool
+}
+
+var (
+	// Reads Google data.
+	readHints = toolHints{readOnly: true, idempotent: true, openWorld: true}
+	// Reads local configuration only.
+	localReadHints = toolHints{readOnly: true, idempotent: true}
+	// Creates or sends something new on each call.
+	createHints = toolHints{openWorld: true}
+	// Adds or restores state; repeating the call changes nothing further.
+	additiveHints = toolHints{idempotent: true, openWorld: true}
+	// Overwrites or removes existing state.
+	destructiveHints = toolHints{destructive: true, idempotent: true, openWorld: true}
+)
+
+func TestToolAnnotationMatrix(t *testing.T) {
+	want := map[string]toolHints{
+		"create_calendar":                 createHints,
+		"create_event":                    createHints,
+		"create_events_bulk":              createHints,
+		"delete_acl_rule":                 destructiveHints,
+		"delete_calendar":                 destructiveHints,
+		"delete_event":                    destructiveHints,
+		"export_events_ics":               readHints,
+		"find_available_room":             readHints,
+		"find_events_by_private_property": readHints,
+		"get_acl_rule":                    readHints,
+		"get_calendar":                    readHints,
+		"get_calendar_list_entry":         readHints,
+		"get_calendar_settings":           readHints,
+		"get_colors":                      readHints,
+		"get_event":                       readHints,
+		"get_event_attachment":            readHints,
+		"import_ics":                      createHints,
+		"list_accounts":                   localReadHints,
+		"list_calendar_sharing":           readHints,
+		"list_calendars":                  readHints,
+		"list_event_instances":            readHints,
+		"list_events":                     readHints,
+		"list_pending_invitations":        readHints,
+		"list_recent_mutations":           localReadHints,
+		"list_watch_channels":             localReadHints,
+		"meeting_load_report":             readHints,
+		"move_event":                      destructiveHints,
+		"query_free_busy":                 readHints,
+		"quick_add_event":                 createHints,
+		"respond_event":                   additiveHints,
+		"respond_events_bulk":             additiveHints,
+		"share_calendar":                  createHints,
+		"stop_channel":                    destructiveHints,
+		"subscribe_calendar":              createHints,
+		"unsubscribe_calendar":            destructiveHints,
+		"update_acl_rule":                 destructiveHints,
+		"update_calendar":                 destructiveHints,
+		"update_calendar_list_entry":      destructiveHints,
+		"update_event":                    destructiveHints,
+		"watch_events":                    createHints,
+	}
+
+	tools := listTools(t, newTestServer(t))
+	if len(tools) != len(want) {
+		t.Errorf("got %d tools, matrix has %d; add new tools to the matrix", len(tools), len(want))
+	}
+	for _, tool := range tools {
+		w, ok := want[tool.Name]
+		if !ok {
+			t.Errorf("tool %q missing from the annotation matrix", tool.Name)
+			continue
+		}
+		a := tool.Annotations
+		if a == nil || a.DestructiveHint == nil || a.OpenWorldHint == nil {
+			t.Errorf("tool %q must set DestructiveHint and OpenWorldHint explicitly", tool.Name)
+			continue
+		}
+		got := toolHints{a.ReadOnlyHint, *a.DestructiveHint, a.IdempotentHint, *a.OpenWorldHint}
+		if got != w {
+			t.Errorf("tool %q annotations = %+v, want %+v", tool.Name, got, w)
+		}
+	}
+}
+
+func TestMatchDuplicate(t *testing.T) {
+	events := []*calendarapi.Event{
+		{Id: "other", Summary: "Standup", Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T09:00:30Z"}},
+		{Id: "far", Summary: "Design review", Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T09:05:00Z"}},
+		{Id: "gone", Summary: "Design review", Status: "cancelled", Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T09:00:00Z"}},
+		{Id: "near", Summary: " design REVIEW ", Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T11:00:45+02:00"}},
+		{Id: "allday", Summary: "Offsite", Start: &calendarapi.EventDateTime{Date: "2024-06-04"}},
+	}
+
+	// Same instant in another offset, 45s off, title differs only in case
+	// and spacing.
+	if got := matchDuplicate(events, "Design review", &calendarapi.EventDateTime{DateTime: "2024-06-03T09:00:00Z"}); got == nil || got.Id != "near" {
+		t.Errorf("timed match = %v, want near", got)
+	}
+	if got := matchDuplicate(events, "Design review", &calendarapi.EventDateTime{DateTime: "2024-06-03T08:58:00Z"}); got != nil {
+		t.Errorf("expected no match outside the window, got %s", got.Id)
+	}
+	if got := matchDuplicate(events, "Offsite", &calendarapi.EventDateTime{Date: "2024-06-04"}); got == nil || got.Id != "allday" {
+		t.Errorf("all-day match = %v, want allday", got)
+	}
+	if got := matchDuplicate(events, "Offsite", &calendarapi.EventDateTime{Date: "2024-06-05"}); got != nil {
+		t.Errorf("expected no all-day match on another date, got %s", got.Id)
+	}
+}
+
+func TestFindExistingEvent_Dedupe(t *testing.T) {
+	var query url.Values
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&calendarapi.Events{Items: []*calendarapi.Event{
+			{Id: "dup", Summary: "Lunch", Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T12:00:00Z"}},
+		}})
+	})
+
+	start := &calendarapi.EventDateTime{DateTime: "2024-06-03T12:00:00Z"}
+	got, err := findExistingEvent(context.Background(), svc, "primary", "", true, "Lunch", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Id != "dup" {
+		t.Fatalf("got %v, want dup", got)
+	}
+	if query.Get("timeMin") != "2024-06-03T11:59:00Z" || query.Get("timeMax") != "2024-06-03T12:01:00Z" || query.Get("q") != "Lunch" {
+		t.Errorf("unexpected list query: %v", query)
+	}
+
+	// A time without an offset is read in its time zone: 14:00 in Berlin
+	// is 12:00 UTC in June.
+	floating := &calendarapi.EventDateTime{DateTime: "2024-06-03T14:00:00", TimeZone: "Europe/Berlin"}
+	got, err = findExistingEvent(context.Background(), svc, "primary", "", true, "Lunch", floating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Id != "dup" {
+		t.Fatalf("floating time: got %v, want dup", got)
+	}
+	if query.Get("timeMin") != "2024-06-03T11:59:00Z" {
+		t.Errorf("floating time: timeMin = %q, want 2024-06-03T11:59:00Z", query.Get("timeMin"))
+	}
+
+	// Without a time zone the instant isn't known, so dedupe is skipped.
+	query = nil
+	got, err = findExistingEvent(context.Background(), svc, "primary", "", true, "Lunch", &calendarapi.EventDateTime{DateTime: "2024-06-03T14:00:00"})
+	if err != nil || got != nil || query != nil {
+		t.Errorf("floating time without zone: got %v, %v, query %v; want no lookup", got, err, query)
+	}
+
+	// Without dedupe or a key nothing is looked up.
+	query = nil
+	if got, err := findExistingEvent(context.Background(), svc, "primary", "", false, "Lunch", start); err != nil || got != nil {
+		t.Errorf("got %v, %v; want nil, nil", got, err)
+	}
+	if query != nil {
+		t.Error("expected no API call without dedupe or idempotency_key")
+	}
+}
+
+func TestFindExistingEvent_IdempotencyKey(t *testing.T) {
+	stored := map[string]*calendarapi.Event{
+		"retry-123": {Id: "first", Summary: "Anything"},
+	}
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		prop := r.URL.Query().Get("privateExtendedProperty")
+		key, ok := strings.CutPrefix(prop, idempotencyKeyProperty+"=")
+		if !ok {
+			t.Errorf("unexpected privateExtendedProperty %q", prop)
+		}
+		var items []*calendarapi.Event
+		if e, ok := stored[key]; ok {
+			items = append(items, e)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&calendarapi.Events{Items: items})
+	})
+
+	start := &calendarapi.EventDateTime{DateTime: "2024-06-03T12:00:00Z"}
+	got, err := findExistingEvent(context.Background(), svc, "primary", "retry-123", false, "Different title", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Id != "first" {
+		t.Fatalf("got %v, want first", got)
+	}
+
+	got, err = findExistingEvent(context.Background(), svc, "primary", "retry-456", false, "Anything", start)
+	if err != nil || got != nil {
+		t.Errorf("unknown key: got %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestValidateWebhookAddress(t *testing.T) {
+	for _, addr := range []string{"https://example.com/hook", "https://example.com:8443/hook?x=1"} {
+		if err := validateWebhookAddress(addr); err != nil {
+			t.Errorf("validateWebhookAddress(%q) = %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"", "http://example.com/hook", "example.com/hook", "https:///hook", "ftp://example.com"} {
+		if err := validateWebhookAddress(addr); err == nil {
+			t.Errorf("validateWebhookAddress(%q) = nil, want error", addr)
+		}
+	}
+}
+
+func TestWatchEvents_RejectsAllAccounts(t *testing.T) {
+	mgr := newTestManager(t)
+	tokens := `{"accounts":{"work":{"email":"me@work.example","token":{"refresh_token":"r"}},"home":{"email":"me@home.example","token":{"refresh_token":"r"}}}}`
+	if err := os.WriteFile(filepath.Join(mgr.ConfigDir(), "tokens.json"), []byte(tokens), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := auth.NewManager(mgr.ConfigDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(&mcp.Implementation{Name: "test-calendar", Version: "test"}, nil)
+	RegisterTools(srv, mgr)
+
+	res, err := connect(t, srv).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "watch_events",
+		Arguments: map[string]any{"account": "all", "address": "https://example.com/hook"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("watch_events accepted account 'all'")
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "a single account is required") {
+		t.Errorf("error = %q, want a single account required", text)
+	}
+}
+
+func TestChannelStore_Persistence(t *testing.T) {
+	mgr := newTestManager(t)
+	created := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	exp := created.Add(7 * 24 * time.Hour)
+
+	store := newChannelStore(mgr)
+	if err := store.add(watchChannel{Account: "work", CalendarID: "primary", ChannelID: "ch-1", ResourceID: "res-1", Expiration: exp, CreatedAt: created.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.add(watchChannel{Account: "home", CalendarID: "primary", ChannelID: "ch-2", ResourceID: "res-2", CreatedAt: created}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh store on the same config dir sees the same channels, oldest first.
+	reopened := newChannelStore(mgr)
+	channels, err := reopened.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 2 || channels[0].ChannelID != "ch-2" || channels[1].ChannelID != "ch-1" {
+		t.Fatalf("channels = %+v", channels)
+	}
+	if !channels[1].Expiration.Equal(exp) {
+		t.Errorf("expiration = %v, want %v", channels[1].Expiration, exp)
+	}
+
+	c, err := reopened.get("ch-1")
+	if err != nil || c == nil || c.ResourceID != "res-1" {
+		t.Fatalf("get(ch-1) = %+v, %v", c, err)
+	}
+	if c, err := reopened.get("missing"); c != nil || err != nil {
+		t.Errorf("get(missing) = %+v, %v; want nil, nil", c, err)
+	}
+
+	if found, err := reopened.remove("ch-1"); !found || err != nil {
+		t.Errorf("remove = %v, %v; want true, nil", found, err)
+	}
+	if found, err := reopened.remove("ch-1"); found || err != nil {
+		t.Errorf("remove of absent channel = %v, %v; want false, nil", found, err)
+	}
+	channels, _ = store.list()
+	if len(channels) != 1 || channels[0].ChannelID != "ch-2" {
+		t.Errorf("channels after remove = %+v", channels)
+	}
+}
+
+func TestFormatChannels(t *testing.T) {
+	now := time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+	channels := []watchChannel{
+		{Account: "work", CalendarID: "primary", ChannelID: "ch-1", ResourceID: "res-1", Address: "https://example.com/a", Expiration: now.Add(time.Hour)},
+		{Account: "work", CalendarID: "team@example.com", ChannelID: "ch-2", ResourceID: "res-2", Address: "https://example.com/b", Expiration: now.Add(-time.Hour)},
+		{Account: "home", CalendarID: "primary", ChannelID: "ch-3", ResourceID: "res-3", Address: "https://example.com/c"},
+	}
+
+	got := formatChannels(channels, []string{"work"}, now)
+	for _, want := range []string{"Watch channels (2)", "ch-1", "Expires: 2024-06-05T01:00:00Z", "Expired: 2024-06-04T23:00:00Z"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ch-3") || strings.Contains(got, "Account:") {
+		t.Errorf("single-account output should not include other accounts:\n%s", got)
+	}
+
+	if got := formatChannels(channels, []string{"home", "work"}, now); !strings.Contains(got, "Account: home") {
+		t.Errorf("multi-account output missing account:\n%s", got)
+	}
+	if got := formatChannels(channels, []string{"other"}, now); got != "No watch channels." {
+		t.Errorf("empty output = %q", got)
+	}
+}
+
+func TestListEventsFields(t *testing.T) {
+	// A recorded Events.List item with the attendee list, description and
+	// conference data list_events never prints.
+	var attendees []map[string]any
+	for i := range 40 {
+		attendees = append(attendees, map[string]any{"email": fmt.Sprintf("user%d@example.com", i), "responseStatus": "needsAction"})
+	}
+	item := map[string]any{
+		"kind": "calendar#event", "etag": `"3391"`, "id": "ev1", "status": "confirmed",
+		"htmlLink": "https://www.google.com/calendar/event?eid=ZXYx", "created": "2024-05-01T10:00:00.000Z",
+		"updated": "2024-05-02T10:00:00.000Z", "summary": "All hands", "location": "Main hall",
+		"description": strings.Repeat("Agenda item. ", 200),
+		"creator":     map[string]any{"email": "alice@example.com"}, "organizer": map[string]any{"email": "alice@example.com"},
+		"start":     map[string]any{"dateTime": "2024-06-03T09:00:00Z", "timeZone": "UTC"},
+		"end":       map[string]any{"dateTime": "2024-06-03T10:00:00Z", "timeZone": "UTC"},
+		"attendees": attendees, "iCalUID": "ev1@google.com", "sequence": 2,
+		"conferenceData": map[string]any{"entryPoints": []any{map[string]any{"entryPointType": "video", "uri": "https://meet.google.com/abc-defg-hij"}}},
+		"reminders":      map[string]any{"useDefault": true},
+	}
+
+	if !strings.HasPrefix(listEventsFields, "items(") || !strings.HasSuffix(listEventsFields, ")") {
+		t.Fatalf("listEventsFields = %q, want items(...)", listEventsFields)
+	}
+	masked := map[string]any{}
+	for _, f := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(listEventsFields, "items("), ")"), ",") {
+		if v, ok := item[f]; ok {
+			masked[f] = v
+		}
+	}
+
+	decode := func(v map[string]any) (*calendarapi.Event, int) {
+		data, err := json.Marshal(map[string]any{"items": []any{v}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp calendarapi.Events
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Items[0], len(data)
+	}
+	fullEvent, fullSize := decode(item)
+	maskedEvent, maskedSize := decode(masked)
+	t.Logf("list_events payload per event: full %d bytes, masked %d bytes", fullSize, maskedSize)
+
+	if maskedSize*5 > fullSize {
+		t.Errorf("masked payload %d bytes, want under 20%% of full (%d bytes)", maskedSize, fullSize)
+	}
+	if got, want := formatEvent(maskedEvent, "work"), formatEvent(fullEvent, "work"); got != want {
+		t.Errorf("masked event formats differently:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// icsTestEvents covers timed, all-day, recurring (with a modified
+// instance), escaping, folding, and an event with no end.
+var icsTestEvents = []*calendarapi.Event{
+	{
+		Id: "ev1", ICalUID: "ev1@google.com", Status: "confirmed", Summary: "Planning; Q3, budget",
+		Location:    `Room 4\B`,
+		Description: "Agenda:\n1. Review\n2. Plan",
+		Start:       &calendarapi.EventDateTime{DateTime: "2024-06-03T09:00:00+02:00", TimeZone: "Europe/Berlin"},
+		End:         &calendarapi.EventDateTime{DateTime: "2024-06-03T10:30:00+02:00", TimeZone: "Europe/Berlin"},
+	},
+	{
+		Id: "ev2", Status: "confirmed", Summary: "Company offsite",
+		Start: &calendarapi.EventDateTime{Date: "2024-06-10"},
+		End:   &calendarapi.EventDateTime{Date: "2024-06-12"},
+	},
+	{
+		Id: "ev3", ICalUID: "standup@google.com", Status: "confirmed", Summary: "Standup", Sequence: 2,
+		Start:      &calendarapi.EventDateTime{DateTime: "2024-06-03T09:30:00+02:00", TimeZone: "Europe/Berlin"},
+		End:        &calendarapi.EventDateTime{DateTime: "2024-06-03T09:45:00+02:00", TimeZone: "Europe/Berlin"},
+		Recurrence: []string{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR", "EXDATE;TZID=Europe/Berlin:20240605T093000"},
+	},
+	{
+		Id: "ev3_20240607T073000Z", ICalUID: "standup@google.com", Status: "confirmed", Summary: "Standup (moved)",
+		RecurringEventId:  "ev3",
+		OriginalStartTime: &calendarapi.EventDateTime{DateTime: "2024-06-07T09:30:00+02:00", TimeZone: "Europe/Berlin"},
+		Start:             &calendarapi.EventDateTime{DateTime: "2024-06-07T11:00:00+02:00"},
+		End:               &calendarapi.EventDateTime{DateTime: "2024-06-07T11:15:00+02:00"},
+	},
+	{
+		Id: "ev4", Status: "tentative", Summary: "Long description",
+		Description: strings.Repeat("Ünïcödé text that goes on and on. ", 4),
+		Start:       &calendarapi.EventDateTime{DateTime: "2024-06-04T15:00:00Z"},
+		End:         &calendarapi.EventDateTime{DateTime: "2024-06-04T16:00:00Z"},
+	},
+	{
+		Id: "ev5", Summary: "Broken", Start: &calendarapi.EventDateTime{DateTime: "2024-06-05T15:00:00Z"},
+	},
+}
+
+func TestFormatICS_Golden(t *testing.T) {
+	stamp := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	doc, skipped := formatICS(icsTestEvents, stamp)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "export.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc != string(want) {
+		t.Errorf("formatICS output differs from testdata/export.ics:\n%s", doc)
+	}
+
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "Broken (ev5): end: missing") {
+		t.Errorf("skipped = %v, want the event without an end", skipped)
+	}
+}
+
+func TestFormatICS_LineLengthAndEndings(t *testing.T) {
+	doc, _ := formatICS(icsTestEvents, time.Now())
+	if !strings.HasSuffix(doc, "END:VCALENDAR\r\n") {
+		t.Error("document does not end with END:VCALENDAR CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(doc, "\r\n"), "\r\n")
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Errorf("line %d is %d octets: %q", i, len(l), l)
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("line %d splits a UTF-8 sequence: %q", i, l)
+		}
+		if strings.ContainsAny(l, "\r\n") {
+			t.Errorf("line %d contains a bare line break: %q", i, l)
+		}
+	}
+}
+
+func TestICSTimezoneLines(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := strings.Join(icsTimezoneLines("America/New_York", from), "\n")
+	for _, want := range []string{
+		"TZID:America/New_York",
+		"BEGIN:DAYLIGHT\nDTSTART:20230312T020000\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\nTZOFFSETFROM:-0500\nTZOFFSETTO:-0400\nTZNAME:EDT\nEND:DAYLIGHT",
+		"BEGIN:STANDARD\nDTSTART:20231105T020000\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\nTZOFFSETFROM:-0400\nTZOFFSETTO:-0500\nTZNAME:EST\nEND:STANDARD",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("VTIMEZONE missing %q:\n%s", want, got)
+		}
+	}
+
+	// A zone without DST has a single observance.
+	got = strings.Join(icsTimezoneLines("Asia/Kolkata", from), "\n")
+	if want := "BEGIN:STANDARD\nDTSTART:20230101T000000\nTZOFFSETFROM:+0530\nTZOFFSETTO:+0530\nTZNAME:IST\nEND:STANDARD"; !strings.Contains(got, want) {
+		t.Errorf("VTIMEZONE = \n%s\nwant %q", got, want)
+	}
+	if lines := icsTimezoneLines("Mars/Olympus", from); lines != nil {
+		t.Errorf("unknown zone = %q, want nil", lines)
+	}
+}
+
+func TestICSEscape(t *testing.T) {
+	if got, want := icsEscape("a\\b;c,d\r\ne\nf"), `a\\b\;c\,d\ne\nf`; got != want {
+		t.Errorf("icsEscape = %q, want %q", got, want)
+	}
+}
+
+func TestParseICS_Fixtures(t *testing.T) {
+	type want struct {
+		summary, start, end, tz, err string
+		recurrence                   []string
+	}
+	tests := []struct {
+		file   string
+		events []want
+	}{
+		{"outlook.ics", []want{
+			{summary: "Quarterly planning", start: "2024-06-03T09:30:00+02:00", end: "2024-06-03T10:30:00+02:00", tz: "Europe/Berlin"},
+			{summary: "Team standup", start: "2024-06-05T08:30:00-07:00", end: "2024-06-05T09:00:00-07:00", tz: "America/Los_Angeles",
+				recurrence: []string{"EXDATE;TZID=America/Los_Angeles:20240612T083000", "RRULE:FREQ=WEEKLY;COUNT=10;BYDAY=WE"}},
+			{summary: "Team standup (moved)", err: "RECURRENCE-ID"},
+			{summary: "Company holiday", start: "2024-07-01", end: "2024-07-02"},
+			{summary: "Lunch on Mars", err: `unknown time zone "Mars Standard Time"`},
+		}},
+		{"apple.ics", []want{
+			{summary: "Dinner with Maria", start: "2024-06-04T18:30:00+03:00", end: "2024-06-04T20:00:00+03:00", tz: "Europe/Athens"},
+			{summary: "Vacation", start: "2024-08-12", end: "2024-08-19"},
+			{summary: "Nikos's birthday", start: "2024-03-15", end: "2024-03-16", recurrence: []string{"RRULE:FREQ=YEARLY"}},
+			{summary: "Run", start: "2024-06-07T07:00:00Z", end: "2024-06-07T08:30:00Z"},
+			{summary: "Floating coffee", start: "2024-06-08T10:00:00-04:00", end: "2024-06-08T11:00:00-04:00", tz: "America/New_York"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			events, err := parseICS(string(data), "America/New_York")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != len(tt.events) {
+				t.Fatalf("got %d events, want %d", len(events), len(tt.events))
+			}
+			for i, w := range tt.events {
+				e := events[i]
+				if e.Summary != w.summary {
+					t.Errorf("event %d: summary = %q, want %q", i, e.Summary, w.summary)
+				}
+				if w.err != "" {
+					if e.Err == nil || !strings.Contains(e.Err.Error(), w.err) {
+						t.Errorf("%s: err = %v, want containing %q", w.summary, e.Err, w.err)
+					}
+					continue
+				}
+				if e.Err != nil {
+					t.Errorf("%s: unexpected error: %v", w.summary, e.Err)
+					continue
+				}
+				start, end := e.Start.DateTime+e.Start.Date, e.End.DateTime+e.End.Date
+				if start != w.start || end != w.end || e.Start.TimeZone != w.tz {
+					t.Errorf("%s: start/end/tz = %s/%s/%q, want %s/%s/%q", w.summary, start, end, e.Start.TimeZone, w.start, w.end, w.tz)
+				}
+				if strings.Join(e.Recurrence, "\n") != strings.Join(w.recurrence, "\n") {
+					t.Errorf("%s: recurrence = %q, want %q", w.summary, e.Recurrence, w.recurrence)
+				}
+			}
+		})
+	}
+}
+
+func TestParseICS_UnfoldAndUnescape(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "outlook.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := parseICS(string(data), "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := events[0]
+	if want := "Agenda: budget, hiring; roadmap.\nDial-in details in the invitation.\n"; e.Description != want {
+		t.Errorf("description = %q, want %q", e.Description, want)
+	}
+	if want := "Room 4.12, Building B"; e.Location != want {
+		t.Errorf("location = %q, want %q", e.Location, want)
+	}
+	if want := "040000008200E00074C5B7101A82E00800000000D0A1C2B3C4D5DA01000000000000000010000000A1B2C3D4E5F60718293A4B5C6D7E8F90"; e.UID != want {
+		t.Errorf("UID = %q, want %q", e.UID, want)
+	}
+
+	// The exporter's own output parses back to the same times.
+	doc, _ := formatICS([]*calendarapi.Event{{
+		Id: "e1", ICalUID: "e1@google.com", Summary: "Round, trip; test",
+		Start: &calendarapi.EventDateTime{DateTime: "2024-06-03T09:30:00+02:00"},
+		End:   &calendarapi.EventDateTime{DateTime: "2024-06-03T10:30:00+02:00"},
+	}}, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	events, err = parseICS(doc, "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Summary != "Round, trip; test" || events[0].Start.DateTime != "2024-06-03T07:30:00Z" {
+		t.Errorf("round trip = %+v", events)
+	}
+}
+
+func TestParseICS_NotCalendar(t *testing.T) {
+	if _, err := parseICS("hello", "UTC"); err == nil {
+		t.Error("expected error for non-iCalendar input")
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M":  90 * time.Minute,
+		"P1D":      24 * time.Hour,
+		"P1W":      7 * 24 * time.Hour,
+		"P1DT2H":   26 * time.Hour,
+		"+PT45S":   45 * time.Second,
+		"PT":       -1,
+		"1H":       -1,
+		"PT5X":     -1,
+		"P1H":      -1,
+		"-PT1H":    -1,
+		"PT10":     -1,
+		"P2DT0H0M": 48 * time.Hour,
+	}
+	for in, want := range tests {
+		got, err := parseICSDuration(in)
+		if want < 0 {
+			if err == nil {
+				t.Errorf("parseICSDuration(%q) = %v, want error", in, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("parseICSDuration(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+}
+
+func TestImportICSEvents(t *testing.T) {
+	var paths []string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			// Only u1 is already on the calendar.
+			if r.URL.Query().Get("iCalUID") == "u1" {
+				w.Write([]byte(`{"items": [{"id": "old-1", "summary": "Old title"}]}`))
+			} else {
+				w.Write([]byte(`{"items": []}`))
+			}
+			return
+		}
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		var ev calendarapi.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Summary == "Broken" {
+			http.Error(w, `{"error":{"code":400,"message":"bad event"}}`, http.StatusBadRequest)
+			return
+		}
+		ev.Id = "id-" + strings.ReplaceAll(strings.ToLower(ev.Summary), " ", "-")
+		json.NewEncoder(w).Encode(ev)
+	})
+
+	start := &calendarapi.EventDateTime{Date: "2024-07-01"}
+	end := &calendarapi.EventDateTime{Date: "2024-07-02"}
+	events := []icsEvent{
+		{UID: "u1", Summary: "With UID", Start: start, End: end},
+		{Summary: "No UID", Start: start, End: end},
+		{Summary: "Unparsed", Err: fmt.Errorf("missing DTSTART")},
+		{Summary: "Broken", Start: start, End: end},
+		{UID: "u2", Summary: "Called off", Status: "cancelled", Start: start, End: end},
+		{UID: "u3", Summary: "New UID", Start: start, End: end},
+	}
+	existing, err := existingICSEvents(context.Background(), svc, "primary", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(existing) != 1 || existing["u1"].Id != "old-1" {
+		t.Errorf("existing = %v, want only u1", existing)
+	}
+
+	dry := formatICSDryRun(events, existing)
+	for _, want := range []string{
+		"Dry run: 4 of 6 events would be imported, 1 of them overwriting existing events; 1 cancelled events would be skipped.",
+		`1. With UID — 2024-07-01 (all day) — would overwrite existing event "Old title" (Event ID: old-1)`,
+		"5. Called off — 2024-07-01 (all day) — would be skipped: cancelled",
+	} {
+		if !strings.Contains(dry, want) {
+			t.Errorf("dry run missing %q:\n%s", want, dry)
+		}
+	}
+
+	results := importICSEvents(context.Background(), svc, "primary", events, existing)
+	wantPaths := []string{"POST /calendars/primary/events/import", "POST /calendars/primary/events", "POST /calendars/primary/events", "POST /calendars/primary/events/import"}
+	if strings.Join(paths, "\n") != strings.Join(wantPaths, "\n") {
+		t.Errorf("requests = %q, want %q", paths, wantPaths)
+	}
+
+	out := formatICSImport(results)
+	for _, want := range []string{
+		"Imported 3 of 6 events, 1 of them overwriting existing events; 1 cancelled events skipped.",
+		`1. With UID — 2024-07-01 (all day) — overwrote existing event "Old title" (Event ID: id-with-uid)`,
+		"2. No UID — 2024-07-01 (all day) — created (Event ID: id-no-uid)",
+		"3. Unparsed — failed: missing DTSTART",
+		"4. Broken — 2024-07-01 (all day) — failed:",
+		"5. Called off — 2024-07-01 (all day) — skipped: cancelled",
+		"6. New UID — 2024-07-01 (all day) — created (Event ID: id-new-uid)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestApplyEventType(t *testing.T) {
+	timed := func() *calendarapi.Event {
+		return &calendarapi.Event{Start: &calendarapi.EventDateTime{DateTime: "2026-10-16T13:00:00Z"}}
+	}
+
+	event := timed()
+	if err := applyEventType(event, eventTypeInput{EventType: "outOfOffice", DeclineMessage: "Away"}); err != nil {
+		t.Fatalf("outOfOffice: %v", err)
+	}
+	if event.EventType != "outOfOffice" || event.OutOfOfficeProperties == nil ||
+		event.OutOfOfficeProperties.AutoDeclineMode != "declineAllConflictingInvitations" ||
+		event.OutOfOfficeProperties.DeclineMessage != "Away" {
+		t.Errorf("outOfOffice event = %+v, props %+v", event, event.OutOfOfficeProperties)
+	}
+
+	event = timed()
+	if err := applyEventType(event, eventTypeInput{EventType: "focusTime", ChatStatus: "doNotDisturb"}); err != nil {
+		t.Fatalf("focusTime: %v", err)
+	}
+	if p := event.FocusTimeProperties; p == nil || p.AutoDeclineMode != "declineNone" || p.ChatStatus != "doNotDisturb" {
+		t.Errorf("focusTime props = %+v", p)
+	}
+
+	event = timed()
+	if err := applyEventType(event, eventTypeInput{}); err != nil || event.EventType != "" {
+		t.Errorf("default: err = %v, type = %q", err, event.EventType)
+	}
+
+	allDay := &calendarapi.Event{Start: &calendarapi.EventDateTime{Date: "2026-10-16"}}
+	for _, tc := range []struct {
+		event   *calendarapi.Event
+		in      eventTypeInput
+		wantErr string
+	}{
+		{allDay, eventTypeInput{EventType: "outOfOffice"}, "cannot be all-day"},
+		{timed(), eventTypeInput{EventType: "vacation"}, "invalid event_type"},
+		{timed(), eventTypeInput{EventType: "workingLocation"}, "not supported"},
+		{timed(), eventTypeInput{AutoDecline: "all"}, "require event_type"},
+		{timed(), eventTypeInput{EventType: "outOfOffice", AutoDecline: "some"}, "invalid auto_decline"},
+		{timed(), eventTypeInput{EventType: "focusTime", DeclineMessage: "Busy"}, "needs auto_decline"},
+		{timed(), eventTypeInput{EventType: "outOfOffice", ChatStatus: "available"}, "only supported for focusTime"},
+		{timed(), eventTypeInput{EventType: "focusTime", ChatStatus: "away"}, "invalid chat_status"},
+	} {
+		err := applyEventType(tc.event, tc.in)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("applyEventType(%+v) = %v, want error containing %q", tc.in, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFormatEventDetailed_EventType(t *testing.T) {
+	result := formatEventDetailed(&calendarapi.Event{
+		Summary:   "OOO",
+		EventType: "outOfOffice",
+		OutOfOfficeProperties: &calendarapi.EventOutOfOfficeProperties{
+			AutoDeclineMode: "declineOnlyNewConflictingInvitations",
+			DeclineMessage:  "Back Monday",
+		},
+	})
+	for _, want := range []string{"Type: outOfOffice\n", "Auto-decline: new conflicting invitations\n", "Decline message: Back Monday\n"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q:\n%s", want, result)
+		}
+	}
+
+	result = formatEventDetailed(&calendarapi.Event{
+		Summary:             "Deep work",
+		EventType:           "focusTime",
+		FocusTimeProperties: &calendarapi.EventFocusTimeProperties{AutoDeclineMode: "declineNone", ChatStatus: "doNotDisturb"},
+	})
+	for _, want := range []string{"Type: focusTime\n", "Auto-decline: none\n", "Chat status: doNotDisturb\n"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result should contain %q:\n%s", want, result)
+		}
+	}
+
+	if result := formatEventDetailed(&calendarapi.Event{Summary: "Plain", EventType: "default"}); strings.Contains(result, "Type:") {
+		t.Errorf("default event should not show a type:\n%s", result)
+	}
+}
+
+func TestEventTypeError(t *testing.T) {
+	bad := &googleapi.Error{Code: http.StatusBadRequest, Message: "Bad Request"}
+	if err := eventTypeError("focusTime", bad); !strings.Contains(err.Error(), "creating focusTime event") || !strings.Contains(err.Error(), "primary calendar") {
+		t.Errorf("focusTime error = %v", err)
+	}
+	if err := eventTypeError("", bad); strings.Contains(err.Error(), "primary calendar") {
+		t.Errorf("default event error should not carry the hint: %v", err)
+	}
+}
+
+func TestFindEventAttachment(t *testing.T) {
+	agenda := &calendarapi.EventAttachment{FileId: "doc-1", Title: "Agenda", MimeType: "application/vnd.google-apps.document"}
+	external := &calendarapi.EventAttachment{FileUrl: "https://example.com/slides.pdf"}
+
+	one := &calendarapi.Event{Id: "e1", Attachments: []*calendarapi.EventAttachment{agenda}}
+	if att, err := findEventAttachment(one, ""); err != nil || att != agenda {
+		t.Errorf("single attachment: %v, %v", att, err)
+	}
+
+	two := &calendarapi.Event{Id: "e2", Attachments: []*calendarapi.EventAttachment{agenda, external}}
+	if att, err := findEventAttachment(two, "doc-1"); err != nil || att != agenda {
+		t.Errorf("by file ID: %v, %v", att, err)
+	}
+	if _, err := findEventAttachment(two, ""); err == nil || !strings.Contains(err.Error(), "Agenda (doc-1), https://example.com/slides.pdf (not a Drive file)") {
+		t.Errorf("ambiguous: err = %v", err)
+	}
+	if _, err := findEventAttachment(two, "doc-9"); err == nil || !strings.Contains(err.Error(), "no attachment with file ID doc-9") {
+		t.Errorf("unknown file ID: err = %v", err)
+	}
+	if _, err := findEventAttachment(&calendarapi.Event{Id: "e3"}, ""); err == nil || !strings.Contains(err.Error(), "no attachments") {
+		t.Errorf("no attachments: err = %v", err)
+	}
+}
+
+func TestFormatAttachmentContent(t *testing.T) {
+	if got := formatAttachmentContent("Agenda", "text/plain", []byte("1. Intro")); got != "File: Agenda (text/plain)\n\n1. Intro" {
+		t.Errorf("text = %q", got)
+	}
+
+	bin := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	if got := formatAttachmentContent("a.png", "image/png", bin); got != "File: a.png (image/png, base64)\n\n"+base64.StdEncoding.EncodeToString(bin) {
+		t.Errorf("binary = %q", got)
+	}
+
+	// Truncated text whose cut splits a multi-byte character is still text.
+	long := []byte(strings.Repeat("a", maxAttachmentContent-1) + "é" + "tail")
+	got := formatAttachmentContent("notes.txt", "text/plain", long[:maxAttachmentContent+1])
+	if !strings.HasPrefix(got, "File: notes.txt (text/plain)\n\n") || !strings.HasSuffix(got, "[Content truncated at 512 KB]") {
+		t.Errorf("truncated text not formatted as text: %q...", got[:60])
+	}
+}
+
+// TestListAccountsShared checks that the Calendar server exposes list_accounts
+// exactly once, as server.RegisterAccountsListTool defines it for every
+// service, and keeps it under an --enable list that omits it.
+func TestEventColumns(t *testing.T) {
+	table, err := server.NewTable("csv", eventColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Add(
+		eventRow{Account: "work", Event: &calendarapi.Event{Id: "e1", Summary: "Standup, daily",
+			Start: &calendarapi.EventDateTime{DateTime: "2026-03-02T09:00:00Z"}, End: &calendarapi.EventDateTime{DateTime: "2026-03-02T09:15:00Z"},
+			Status: "confirmed"}},
+		eventRow{Account: "work", Event: &calendarapi.Event{Id: "e2", Summary: "Holiday",
+			Start: &calendarapi.EventDateTime{Date: "2026-03-03"}, End: &calendarapi.EventDateTime{Date: "2026-03-04"}}},
+	)
+	want := "account,event_id,summary,start,end,all_day,location,status,link\r\n" +
+		"work,e1,\"Standup, daily\",2026-03-02T09:00:00Z,2026-03-02T09:15:00Z,false,,confirmed,\r\n" +
+		"work,e2,Holiday,2026-03-03,2026-03-04,true,,,\r\n"
+	if got := table.String(); got != want {
+		t.Errorf("csv =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFormatSettings(t *testing.T) {
+	settings := []*calendarapi.Setting{
+		{Id: "defaultEventLength", Value: "30"},
+		{Id: "format24HourTime", Value: "true"},
+		{Id: "timezone", Value: "Europe/Berlin"},
+		{Id: "weekStart", Value: "1"},
+	}
+	now := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	want := "defaultEventLength: 30 minutes\n" +
+		"format24HourTime: true\n" +
+		"timezone: Europe/Berlin\n" +
+		"weekStart: 1 (Monday)\n" +
+		"Local now: 2026-03-02T09:30:00+01:00 (Monday, Europe/Berlin)\n"
+	if got := formatSettings(settings, now); got != want {
+		t.Errorf("formatSettings =\n%s\nwant\n%s", got, want)
+	}
+
+	got := formatSettings([]*calendarapi.Setting{{Id: "timezone", Value: "Mars/Olympus"}}, now)
+	if !strings.Contains(got, `Local now: unknown (timezone "Mars/Olympus" is not recognized)`) {
+		t.Errorf("unknown timezone not reported:\n%s", got)
+	}
+}
+
+func TestTimeZoneCache(t *testing.T) {
+	cache := &timeZoneCache{zones: make(map[string]*time.Location)}
+	calls := 0
+	fetch := func() (string, error) {
+		calls++
+		return "America/New_York", nil
+	}
+	for range 2 {
+		loc, err := cache.get("work", fetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc.String() != "America/New_York" {
+			t.Errorf("loc = %s", loc)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetched %d times, want 1", calls)
+	}
+
+	// Errors are not cached.
+	_, err := cache.get("home", func() (string, error) { return "", errors.New("offline") })
+	if err == nil {
+		t.Fatal("expected fetch error")
+	}
+	if _, err := cache.get("home", func() (string, error) { return "Bogus/Zone", nil }); err == nil || !strings.Contains(err.Error(), `"Bogus/Zone"`) {
+		t.Errorf("err = %v, want invalid timezone error", err)
+	}
+
+	// Timezones seen in get_calendar_settings are cached without a fetch.
+	cache.set("home", "Asia/Tokyo")
+	loc, err := cache.get("home", func() (string, error) { t.Error("unexpected fetch"); return "", nil })
+	if err != nil || loc.String() != "Asia/Tokyo" {
+		t.Errorf("loc = %v, err = %v", loc, err)
+	}
+}
+
+func TestValidatePrivateProperties(t *testing.T) {
+	tests := []struct {
+		props      map[string]string
+		allowEmpty bool
+		wantErr    string
+	}{
+		{props: nil},
+		{props: map[string]string{"ticket": "OPS-123", "source": "jira"}},
+		{props: map[string]string{strings.Repeat("k", 44): strings.Repeat("v", 1024)}},
+		{props: map[string]string{"": "x"}, wantErr: "keys must not be empty"},
+		{props: map[string]string{"a=b": "x"}, wantErr: "must not contain '='"},
+		{props: map[string]string{strings.Repeat("k", 45): "x"}, wantErr: "longer than 44 characters"},
+		{props: map[string]string{"ticket": strings.Repeat("v", 1025)}, wantErr: "longer than 1024 characters"},
+		{props: map[string]string{"ticket": ""}, wantErr: `value of "ticket" must not be empty`},
+		{props: map[string]string{"ticket": ""}, allowEmpty: true},
+		{props: map[string]string{idempotencyKeyProperty: "x"}, wantErr: "reserved"},
+	}
+	for _, tt := range tests {
+		err := validatePrivateProperties(tt.props, tt.allowEmpty)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("validatePrivateProperties(%v) = %v", tt.props, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("validatePrivateProperties(%v) = %v, want error containing %q", tt.props, err, tt.wantErr)
+		}
+	}
+
+	many := make(map[string]string)
+	for i := range maxEventProperties + 1 {
+		many[fmt.Sprintf("k%d", i)] = "v"
+	}
+	if err := validatePrivateProperties(many, false); err == nil || !strings.Contains(err.Error(), "at most 300") {
+		t.Errorf("err = %v, want count limit error", err)
+	}
+}
+
+func TestApplyPrivateProperties(t *testing.T) {
+	event := &calendarapi.Event{}
+	applyPrivateProperties(event, nil)
+	if event.ExtendedProperties != nil {
+		t.Error("no properties should leave ExtendedProperties unset")
+	}
+
+	applyPrivateProperties(event, map[string]string{"ticket": "OPS-1", "source": "jira"})
+	applyPrivateProperties(event, map[string]string{"ticket": "OPS-2", "source": ""})
+	if got := fmt.Sprint(event.ExtendedProperties.Private); got != "map[ticket:OPS-2]" {
+		t.Errorf("private = %s, want map[ticket:OPS-2]", got)
+	}
+
+	// The idempotency key is kept but not shown.
+	event.ExtendedProperties.Private[idempotencyKeyProperty] = "retry-1"
+	if got := formatPrivateProperties(event, "  "); got != "  Private properties:\n    ticket: OPS-2\n" {
+		t.Errorf("formatPrivateProperties = %q", got)
+	}
+	only := &calendarapi.Event{ExtendedProperties: &calendarapi.EventExtendedProperties{
+		Private: map[string]string{idempotencyKeyProperty: "retry-1"},
+	}}
+	if got := formatPrivateProperties(only, ""); got != "" {
+		t.Errorf("idempotency key alone should print nothing, got %q", got)
+	}
+}
+
+func TestFindEventsByProperties_Query(t *testing.T) {
+	var got url.Values
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"id":"e1","summary":"Deploy","extendedProperties":{"private":{"ticket":"OPS-123"}}}]}`))
+	})
+	events, err := findEventsByProperties(context.Background(), svc, "primary", map[string]string{"ticket": "OPS-123", "env": "prod"}, "2026-03-01T00:00:00Z", "", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ExtendedProperties.Private["ticket"] != "OPS-123" {
+		t.Errorf("events = %+v", events)
+	}
+	if filters := got["privateExtendedProperty"]; fmt.Sprint(filters) != "[env=prod ticket=OPS-123]" {
+		t.Errorf("privateExtendedProperty = %v", filters)
+	}
+	if got.Get("singleEvents") != "true" || got.Get("timeMin") != "2026-03-01T00:00:00Z" || got.Has("timeMax") {
+		t.Errorf("query = %v", got)
+	}
+	if !strings.Contains(got.Get("fields"), "extendedProperties(private)") {
+		t.Errorf("fields = %q", got.Get("fields"))
+	}
+}
+
+func TestParseProposal(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	zoneCalls := 0
+	zone := func() (*time.Location, error) {
+		zoneCalls++
+		return berlin, nil
+	}
+
+	start, end, err := parseProposal(&proposedTime{Start: "2026-03-02T10:00:00+01:00", End: "2026-03-02T10:30:00Z"}, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zoneCalls != 0 {
+		t.Errorf("zone looked up %d times for times with offsets", zoneCalls)
+	}
+	if got := end.Sub(start); got != 90*time.Minute {
+		t.Errorf("duration = %v, want 1h30m", got)
+	}
+
+	start, _, err = parseProposal(&proposedTime{Start: "2026-07-01T09:00", End: "2026-07-01T09:30:00"}, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := start.Format(time.RFC3339); got != "2026-07-01T09:00:00+02:00" {
+		t.Errorf("start = %s, want Berlin summer time", got)
+	}
+
+	failingZone := func() (*time.Location, error) { return nil, errors.New("no settings access") }
+	for _, tt := range []struct {
+		in   proposedTime
+		zone func() (*time.Location, error)
+		want string
+	}{
+		{proposedTime{Start: "2026-03-02T10:00:00Z"}, zone, "requires start and end"},
+		{proposedTime{Start: "tomorrow", End: "2026-03-02T10:00:00Z"}, zone, `invalid propose_new_time.start "tomorrow"`},
+		{proposedTime{Start: "2026-03-02T11:00:00Z", End: "2026-03-02T11:00:00Z"}, zone, "must be earlier than end"},
+		{proposedTime{Start: "2026-03-02T10:00:00Z", End: "2026-03-02T11:00"}, failingZone, "propose_new_time.end has no offset"},
+	} {
+		if _, _, err := parseProposal(&tt.in, tt.zone); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseProposal(%+v) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestProposalComment(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	end := start.Add(30 * time.Minute)
+
+	if got, want := proposalComment(start, end, ""), "Proposed new time: 2026-03-02T10:00:00+01:00 to 2026-03-02T10:30:00+01:00"; got != want {
+		t.Errorf("proposalComment() = %q, want %q", got, want)
+	}
+	got := proposalComment(start, end, "  Clashes with my 1:1, does this work?\n")
+	want := "Proposed new time: 2026-03-02T10:00:00+01:00 to 2026-03-02T10:30:00+01:00\nClashes with my 1:1, does this work?"
+	if got != want {
+		t.Errorf("proposalComment() = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(got, proposalPrefix) {
+		t.Errorf("comment should start with %q", proposalPrefix)
+	}
+}
+
+func TestProposalHold(t *testing.T) {
+	event := &calendarapi.Event{Id: "evt1", Summary: "Planning", HtmlLink: "https://calendar.google.com/event?eid=abc"}
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	hold := proposalHold(event, "team@example.com", start, start.Add(time.Hour))
+
+	if hold.Summary != "Hold: Planning" || hold.Status != "tentative" || hold.Transparency != "opaque" {
+		t.Errorf("hold = %+v", hold)
+	}
+	if hold.Start.DateTime != "2026-03-02T10:00:00Z" || hold.End.DateTime != "2026-03-02T11:00:00Z" {
+		t.Errorf("hold times = %s - %s", hold.Start.DateTime, hold.End.DateTime)
+	}
+	if got := hold.ExtendedProperties.Private; len(got) != 1 || got[proposalForProperty] != "evt1" {
+		t.Errorf("hold private properties = %v, want %s=evt1", got, proposalForProperty)
+	}
+	if !strings.Contains(hold.Description, "event evt1 on calendar team@example.com") || !strings.Contains(hold.Description, event.HtmlLink) {
+		t.Errorf("description = %q", hold.Description)
+	}
+	// Unlike idempotency keys, the link is listed with the hold's private
+	// properties, so get_event shows which event a hold belongs to.
+	if got := formatPrivateProperties(hold, ""); !strings.Contains(got, proposalForProperty) {
+		t.Errorf("formatPrivateProperties() = %q, want the hold link shown", got)
+	}
+}
+
+func TestUpsertProposalHold(t *testing.T) {
+	event := &calendarapi.Event{Id: "evt1", Summary: "Planning"}
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	var existing []*calendarapi.Event
+	var requests []string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			if got := r.URL.Query()["privateExtendedProperty"]; fmt.Sprint(got) != "["+proposalForProperty+"=evt1]" {
+				t.Errorf("privateExtendedProperty = %v", got)
+			}
+			json.NewEncoder(w).Encode(&calendarapi.Events{Items: existing})
+		case http.MethodPost, http.MethodPatch:
+			var e calendarapi.Event
+			json.NewDecoder(r.Body).Decode(&e)
+			if r.Method == http.MethodPost {
+				e.Id = "hold1"
+			} else {
+				e.Id = strings.TrimPrefix(r.URL.Path, "/calendars/primary/events/")
+			}
+			json.NewEncoder(w).Encode(&e)
+		}
+	})
+
+	hold, moved, err := upsertProposalHold(context.Background(), svc, event, "primary", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved || hold.Id != "hold1" || hold.ExtendedProperties.Private[proposalForProperty] != "evt1" {
+		t.Errorf("first proposal: hold = %+v, moved = %v", hold, moved)
+	}
+	if fmt.Sprint(requests) != "[GET /calendars/primary/events POST /calendars/primary/events]" {
+		t.Errorf("requests = %v", requests)
+	}
+
+	// Proposing again moves the existing hold.
+	existing = []*calendarapi.Event{{Id: "hold1"}}
+	requests = nil
+	hold, moved, err = upsertProposalHold(context.Background(), svc, event, "primary", start.Add(2*time.Hour), start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !moved || hold.Id != "hold1" || hold.Start.DateTime != "2026-03-02T12:00:00Z" {
+		t.Errorf("second proposal: hold = %+v, moved = %v", hold, moved)
+	}
+	if fmt.Sprint(requests) != "[GET /calendars/primary/events PATCH /calendars/primary/events/hold1]" {
+		t.Errorf("requests = %v", requests)
+	}
+}
+
+func TestFormatEventDetailed_AttendeeComment(t *testing.T) {
+	event := &calendarapi.Event{
+		Summary: "Planning",
+		Attendees: []*calendarapi.EventAttendee{
+			{Email: "bob@example.com", ResponseStatus: "declined", Comment: "Proposed new time: 2026-03-02T10:00:00Z to 2026-03-02T11:00:00Z\nDoes that work?"},
+			{Email: "carol@example.com", ResponseStatus: "accepted"},
+		},
+	}
+	got := formatEventDetailed(event)
+	want := "  - bob@example.com (declined)\n    Comment: Proposed new time: 2026-03-02T10:00:00Z to 2026-03-02T11:00:00Z\n    Does that work?\n  - carol@example.com (accepted)\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("formatEventDetailed() attendees:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAttendee_UnmarshalBothShapes(t *testing.T) {
+	var input createEventInput
+	data := `{"summary": "Sync", "attendees": ["bob@example.com", {"email": "carol@example.com", "optional": true}, {"email": "room-1@resource.calendar.google.com", "resource": true}]}`
+	if err := json.Unmarshal([]byte(data), &input); err != nil {
+		t.Fatal(err)
+	}
+	got, err := eventAttendees(input.Attendees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*calendarapi.EventAttendee{
+		{Email: "bob@example.com"},
+		{Email: "carol@example.com", Optional: true},
+		{Email: "room-1@resource.calendar.google.com", Resource: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d attendees, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Email != want[i].Email || got[i].Optional != want[i].Optional || got[i].Resource != want[i].Resource {
+			t.Errorf("attendee %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"attendees": [42]}`), &input); err == nil {
+		t.Error("a number was accepted as an attendee")
+	}
+	if _, err := eventAttendees([]attendee{{Resource: true}}); err == nil || !strings.Contains(err.Error(), "attendees[0]: email is required") {
+		t.Errorf("err = %v, want email required", err)
+	}
+}
+
+// TestCreateEventSchema_Attendees checks that create_event's input schema
+// accepts the plain email addresses older callers send as well as attendee
+// objects, and rejects anything else before the handler runs.
+func TestCreateEventSchema_Attendees(t *testing.T) {
+	session := connect(t, newTestServer(t))
+	call := func(attendees any) string {
+		t.Helper()
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+			Name: "create_event",
+			Arguments: map[string]any{
+				"summary": "Sync", "start_time": "2026-01-05T09:00:00Z", "end_time": "2026-01-05T10:00:00Z",
+				"attendees": attendees,
+			},
+		})
+		if err != nil {
+			return err.Error()
+		}
+		return res.Content[0].(*mcp.TextContent).Text
+	}
+
+	// Valid input reaches the handler, which fails for lack of accounts.
+	for _, attendees := range []any{
+		[]any{"bob@example.com"},
+		[]any{"bob@example.com", map[string]any{"email": "room-1@resource.calendar.google.com", "resource": true}},
+		[]any{map[string]any{"email": "carol@example.com", "optional": true}},
+	} {
+		if got := call(attendees); !strings.Contains(got, "no accounts configured") {
+			t.Errorf("attendees %v rejected: %s", attendees, got)
+		}
+	}
+	for _, attendees := range []any{
+		[]any{42},
+		[]any{map[string]any{"optional": true}},
+		[]any{map[string]any{"email": "bob@example.com", "room": true}},
+	} {
+		if got := call(attendees); !strings.Contains(got, "validating") {
+			t.Errorf("attendees %v accepted: %s", attendees, got)
+		}
+	}
+}
+
+func TestFormatRoomAvailability(t *testing.T) {
+	resp := &calendarapi.FreeBusyResponse{
+		TimeMin: "2026-01-05T09:00:00Z",
+		TimeMax: "2026-01-05T10:00:00Z",
+		Calendars: map[string]calendarapi.FreeBusyCalendar{
+			"a@resource.calendar.google.com": {},
+			"b@resource.calendar.google.com": {Busy: []*calendarapi.TimePeriod{{Start: "2026-01-05T09:30:00Z", End: "2026-01-05T10:30:00Z"}}},
+			"c@resource.calendar.google.com": {Errors: []*calendarapi.Error{{Domain: "calendar", Reason: "notFound"}}},
+			"d@resource.calendar.google.com": {},
+		},
+	}
+	rooms := []string{"d@resource.calendar.google.com", "b@resource.calendar.google.com", "c@resource.calendar.google.com", "a@resource.calendar.google.com"}
+	want := `Rooms free from 2026-01-05T09:00:00Z to 2026-01-05T10:00:00Z: 2 of 4
+  - d@resource.calendar.google.com
+  - a@resource.calendar.google.com
+
+Busy:
+  - b@resource.calendar.google.com
+      2026-01-05T09:30:00Z to 2026-01-05T10:30:00Z
+
+Unavailable to check:
+  - c@resource.calendar.google.com: notFound
+`
+	if got := formatRoomAvailability(rooms, resp); got != want {
+		t.Errorf("formatRoomAvailability() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestScheduleMeetingPrompt(t *testing.T) {
+	srv := newTestServer(t)
+	res, err := connect(t, srv).GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "schedule_meeting",
+		Arguments: map[string]string{"attendees": "bob@example.com, carol@example.com", "window": "next week"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Messages[0].Content.(*mcp.TextContent).Text
+	if !strings.HasPrefix(text, "Schedule a 30-minute meeting with bob@example.com, carol@example.com next week.") {
+		t.Errorf("prompt =\n%s", text)
+	}
+
+	// create_event is not read-only, so the prompt is hidden.
+	srv = newTestServer(t)
+	if err := srv.ApplyFilter(server.ToolFilter{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := connect(t, srv).ListPrompts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Prompts) != 0 {
+		t.Errorf("read-only prompts = %+v, want none", list.Prompts)
+	}
+}
+
+func TestDiffEvents(t *testing.T) {
+	base := func() *calendarapi.Event {
+		return &calendarapi.Event{
+			Summary:     "Planning",
+			Location:    "Room 1",
+			Description: "Agenda",
+			Start:       &calendarapi.EventDateTime{DateTime: "2024-03-01T09:00:00+01:00"},
+			End:         &calendarapi.EventDateTime{DateTime: "2024-03-01T10:00:00+01:00"},
+			Attendees:   []*calendarapi.EventAttendee{{Email: "alice@example.com"}, {Email: "carol@example.com"}},
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(e *calendarapi.Event)
+		want   string
+	}{
+		{"nothing", func(e *calendarapi.Event) {},
+			"Changed: nothing; Summary, Start, End, Location, Attendees, Description unchanged"},
+		{"time and attendees", func(e *calendarapi.Event) {
+			e.Start = &calendarapi.EventDateTime{DateTime: "2024-03-01T10:00:00+01:00"}
+			e.End = &calendarapi.EventDateTime{DateTime: "2024-03-01T11:00:00+01:00"}
+			e.Attendees = []*calendarapi.EventAttendee{{Email: "Alice@example.com"}, {Email: "bob@example.com"}}
+		}, "Changed: Start 09:00→10:00; End 10:00→11:00; Attendees +bob@example.com -carol@example.com; Summary, Location, Description unchanged"},
+		{"same instant in another offset", func(e *calendarapi.Event) {
+			e.Start = &calendarapi.EventDateTime{DateTime: "2024-03-01T08:00:00Z"}
+		}, "Changed: nothing; Summary, Start, End, Location, Attendees, Description unchanged"},
+		{"text fields", func(e *calendarapi.Event) {
+			e.Summary = "Planning v2"
+			e.Location = ""
+			e.Description = "New agenda"
+		}, `Changed: Summary "Planning"→"Planning v2"; Location "Room 1"→(none); Description changed; Start, End, Attendees unchanged`},
+		{"timed to all-day", func(e *calendarapi.Event) {
+			e.Start = &calendarapi.EventDateTime{Date: "2024-03-01"}
+			e.End = &calendarapi.EventDateTime{Date: "2024-03-02"}
+		}, "Changed: Start 2024-03-01 09:00→2024-03-01 (all day); End 2024-03-01 10:00→2024-03-02 (all day); Summary, Location, Attendees, Description unchanged"},
+		{"other date and offset", func(e *calendarapi.Event) {
+			e.Start = &calendarapi.EventDateTime{DateTime: "2024-03-04T09:00:00Z"}
+		}, "Changed: Start 2024-03-01 09:00+01:00→2024-03-04 09:00Z; Summary, End, Location, Attendees, Description unchanged"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base()
+			tt.change(after)
+			if got := diffEvents(base(), after); got != tt.want {
+				t.Errorf("diffEvents() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// All-day to timed, the other direction.
+	before := &calendarapi.Event{Start: &calendarapi.EventDateTime{Date: "2024-03-01"}, End: &calendarapi.EventDateTime{Date: "2024-03-02"}}
+	after := &calendarapi.Event{Start: &calendarapi.EventDateTime{DateTime: "2024-03-01T09:00:00Z"}, End: &calendarapi.EventDateTime{DateTime: "2024-03-01T09:30:00Z"}}
+	want := "Changed: Start 2024-03-01 (all day)→2024-03-01 09:00; End 2024-03-02 (all day)→2024-03-01 09:30; Summary, Location, Attendees, Description unchanged"
+	if got := diffEvents(before, after); got != want {
+		t.Errorf("diffEvents(all-day → timed) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildEvent(t *testing.T) {
+	event, err := buildEvent(createEventInput{
+		Summary:           "Standup",
+		Location:          "Room 1",
+		StartTime:         "2024-03-01T09:00:00Z",
+		EndTime:           "2024-03-01T09:15:00Z",
+		TimeZone:          "Europe/Berlin",
+		Attendees:         []attendee{{Email: "bob@example.com"}, {Email: "room@resource.calendar.google.com", Resource: true}},
+		IdempotencyKey:    "week-10-standup",
+		PrivateProperties: map[string]string{"ticket": "OPS-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Summary != "Standup" || event.Location != "Room 1" || event.Start.DateTime != "2024-03-01T09:00:00Z" || event.Start.TimeZone != "Europe/Berlin" || event.End.Date != "" {
+		t.Errorf("event = %+v, start %+v", event, event.Start)
+	}
+	if len(event.Attendees) != 2 || !event.Attendees[1].Resource {
+		t.Errorf("attendees = %+v", event.Attendees)
+	}
+	if p := event.ExtendedProperties.Private; p[idempotencyKeyProperty] != "week-10-standup" || p["ticket"] != "OPS-1" {
+		t.Errorf("private properties = %v", p)
+	}
+
+	allDay, err := buildEvent(createEventInput{Summary: "Offsite", StartTime: "2024-03-04", EndTime: "2024-03-06"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allDay.Start.Date != "2024-03-04" || allDay.End.Date != "2024-03-06" || allDay.Start.DateTime != "" {
+		t.Errorf("all-day start %+v, end %+v", allDay.Start, allDay.End)
+	}
+
+	for _, tt := range []struct {
+		input   createEventInput
+		wantErr string
+	}{
+		{createEventInput{Summary: "x", StartTime: "2024-03-01T09:00:00Z"}, "start_time and end_time are required"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01", EndTime: "2024-03-01T10:00:00Z"}, "must both be dates"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01T10:00:00Z", EndTime: "2024-03-01T09:00:00Z"}, "must be after start_time"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-02", EndTime: "2024-03-02"}, "must be after start_time"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01", EndTime: "2024-03-02", eventTypeInput: eventTypeInput{EventType: "focusTime"}}, "cannot be all-day"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01", EndTime: "2024-03-02", Visibility: "secret"}, "invalid visibility"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01", EndTime: "2024-03-02", Attendees: []attendee{{}}}, "attendees[0]: email is required"},
+		{createEventInput{Summary: "x", StartTime: "2024-03-01", EndTime: "2024-03-02", Description: "x", DescriptionFormat: "rtf"}, "invalid description_format"},
+	} {
+		if _, err := buildEvent(tt.input); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("buildEvent(%+v) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+
+	// Times without an offset are left to the API, which accepts them
+	// with time_zone.
+	if _, err := buildEvent(createEventInput{Summary: "x", StartTime: "2024-03-01T10:00:00", EndTime: "2024-03-01T09:00:00", TimeZone: "UTC"}); err != nil {
+		t.Errorf("buildEvent(no offset) = %v", err)
+	}
+}
+
+func TestBuildBulkEvents(t *testing.T) {
+	entries := bulkEntries(createEventsBulkInput{
+		Account: "work",
+		Events: []createEventInput{
+			{Summary: "ok", StartTime: "2024-03-01T09:00:00Z", EndTime: "2024-03-01T10:00:00Z"},
+			{Summary: "no end", StartTime: "2024-03-01T09:00:00Z"},
+			{Summary: "other calendar", CalendarID: "team@example.com", Account: "home", StartTime: "2024-03-01", EndTime: "2024-03-02"},
+			{Summary: "backwards", StartTime: "2024-03-01T10:00:00Z", EndTime: "2024-03-01T09:00:00Z"},
+		},
+	})
+	if entries[0].Account != "work" || entries[0].CalendarID != "primary" || entries[2].Account != "home" || entries[2].CalendarID != "team@example.com" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	events, errs := buildBulkEvents(entries)
+	if events[0] == nil || events[1] != nil || events[2] == nil || events[3] != nil {
+		t.Errorf("events = %v", events)
+	}
+	err := errors.Join(errs...)
+	for _, want := range []string{"events[1]: start_time and end_time are required", "events[3]: end_time"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("errors = %v, want %q", err, want)
+		}
+	}
+	if got := bulkEntryError(errs, 3); got == nil || strings.Contains(got.Error(), "events[3]") {
+		t.Errorf("bulkEntryError(3) = %v, want the bare validation error", got)
+	}
+	if got := bulkEntryError(errs, 0); got != nil {
+		t.Errorf("bulkEntryError(0) = %v, want nil", got)
+	}
+}
+
+func TestCheckBulkAccounts(t *testing.T) {
+	entries := bulkEntries(createEventsBulkInput{
+		Account: "work",
+		Events: []createEventInput{
+			{Summary: "unknown account", StartTime: "2024-03-01T09:00:00Z", EndTime: "2024-03-01T10:00:00Z"},
+			{Summary: "no end", StartTime: "2024-03-01T09:00:00Z"},
+			{Summary: "same account", StartTime: "2024-03-02T09:00:00Z", EndTime: "2024-03-02T10:00:00Z", ColorID: "5"},
+		},
+	})
+	events, errs := buildBulkEvents(entries)
+	services, errs := checkBulkAccounts(context.Background(), newTestManager(t), entries, events, errs)
+	if len(services) != 0 || events[0] != nil || events[2] != nil {
+		t.Errorf("services = %v, events = %v, want none for the unknown account", services, events)
+	}
+	var got []string
+	for _, err := range errs {
+		got = append(got, strings.SplitN(err.Error(), ":", 2)[0])
+	}
+	if strings.Join(got, ",") != "events[0],events[1],events[2]" {
+		t.Errorf("errors = %v, want one per entry in order", errs)
+	}
+	if err := bulkEntryError(errs, 2); err == nil || !strings.Contains(err.Error(), "creating Calendar service") {
+		t.Errorf("entry 2 error = %v, want the service error", err)
+	}
+
+	res, err := connect(t, newTestServer(t)).CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "create_events_bulk",
+		Arguments: map[string]any{
+			"account": "work",
+			"events":  []map[string]any{{"summary": "a", "start_time": "2024-03-01T09:00:00Z", "end_time": "2024-03-01T10:00:00Z"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, "events[0]: creating Calendar service") || !strings.Contains(text, "Nothing was created") {
+		t.Errorf("result = %q, want the per-entry service error", text)
+	}
+}
+
+func TestInsertEvent(t *testing.T) {
+	var inserted []string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			http.Error(w, "unexpected", http.StatusBadRequest)
+			return
+		}
+		var ev calendarapi.Event
+		json.NewDecoder(r.Body).Decode(&ev)
+		if ev.Summary == "fails" {
+			http.Error(w, `{"error":{"code":403,"message":"Calendar usage limits exceeded.","errors":[{"reason":"quotaExceeded"}]}}`, http.StatusForbidden)
+			return
+		}
+		inserted = append(inserted, ev.Summary)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"ev%d","summary":%q}`, len(inserted), ev.Summary)
+	})
+
+	input := createEventInput{Summary: "Planning", StartTime: "2024-03-01T09:00:00Z", EndTime: "2024-03-01T10:00:00Z"}
+	event, err := buildEvent(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, duplicate, _, _, err := insertEvent(context.Background(), nil, svc, "primary", input, event)
+	if err != nil || duplicate || created.Id != "ev1" {
+		t.Fatalf("insertEvent() = %v, %v, %v", created, duplicate, err)
+	}
+
+	input.Summary = "fails"
+	event, _ = buildEvent(input)
+	if _, _, _, _, err := insertEvent(context.Background(), nil, svc, "primary", input, event); err == nil || !strings.Contains(err.Error(), "creating event") {
+		t.Errorf("insertEvent(failing) error = %v", err)
+	}
+}
+
+func TestFindConflicts(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	timed := func(id, start, end string) *calendarapi.Event {
+		return &calendarapi.Event{Id: id, Summary: id, Start: &calendarapi.EventDateTime{DateTime: start}, End: &calendarapi.EventDateTime{DateTime: end}}
+	}
+	allDay := func(id, start, end string) *calendarapi.Event {
+		return &calendarapi.Event{Id: id, Summary: id, Start: &calendarapi.EventDateTime{Date: start}, End: &calendarapi.EventDateTime{Date: end}}
+	}
+	declined := timed("declined", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00")
+	declined.Attendees = []*calendarapi.EventAttendee{{Email: "me@example.com", Self: true, ResponseStatus: "declined"}}
+	free := timed("free", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00")
+	free.Transparency = "transparent"
+	cancelled := timed("cancelled", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00")
+	cancelled.Status = "cancelled"
+	instance := timed("self_20240115", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00")
+	instance.RecurringEventId = "self"
+	accepted := timed("accepted", "2024-01-15T10:45:00-05:00", "2024-01-15T11:15:00-05:00")
+	accepted.Attendees = []*calendarapi.EventAttendee{{Email: "me@example.com", Self: true, ResponseStatus: "accepted"}}
+
+	events := []*calendarapi.Event{
+		timed("utc-overlap", "2024-01-15T15:30:00Z", "2024-01-15T16:30:00Z"),
+		timed("touching", "2024-01-15T11:00:00-05:00", "2024-01-15T12:00:00-05:00"),
+		timed("self", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00"),
+		instance, declined, free, cancelled, accepted,
+		allDay("all-day-15", "2024-01-15", "2024-01-16"),
+		allDay("all-day-16", "2024-01-16", "2024-01-17"),
+	}
+
+	ids := func(events []*calendarapi.Event) string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Id)
+		}
+		return strings.Join(out, ",")
+	}
+	tests := []struct {
+		name       string
+		start, end string
+		want       string
+	}{
+		{"morning", "2024-01-15T10:00:00-05:00", "2024-01-15T11:00:00-05:00", "utc-overlap,accepted,all-day-15"},
+		// 01:00-02:00 UTC on the 16th is still the 15th in New York.
+		{"evening", "2024-01-15T20:00:00-05:00", "2024-01-15T21:00:00-05:00", "all-day-15"},
+		{"next day", "2024-01-16T09:00:00-05:00", "2024-01-16T09:30:00-05:00", "all-day-16"},
+	}
+	for _, tt := range tests {
+		start, _ := time.Parse(time.RFC3339, tt.start)
+		end, _ := time.Parse(time.RFC3339, tt.end)
+		if got := ids(findConflicts(events, start, end, "self", ny)); got != tt.want {
+			t.Errorf("%s: conflicts = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// In UTC the evening slot falls on the 16th.
+	start, _ := time.Parse(time.RFC3339, "2024-01-15T20:00:00-05:00")
+	if got := ids(findConflicts(events, start, start.Add(time.Hour), "self", time.UTC)); got != "all-day-16" {
+		t.Errorf("evening in UTC: conflicts = %s, want all-day-16", got)
+	}
+}
+
+func TestEventSpan(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	tests := []struct {
+		name       string
+		start, end *calendarapi.EventDateTime
+		want       string
+	}{
+		{"offset", &calendarapi.EventDateTime{DateTime: "2024-01-15T10:00:00+01:00"}, &calendarapi.EventDateTime{DateTime: "2024-01-15T11:00:00+01:00"}, "2024-01-15T09:00:00Z/2024-01-15T10:00:00Z"},
+		{"local time with zone", &calendarapi.EventDateTime{DateTime: "2024-07-01T09:00:00", TimeZone: "Europe/Berlin"}, &calendarapi.EventDateTime{DateTime: "2024-07-01T10:00:00", TimeZone: "Europe/Berlin"}, "2024-07-01T07:00:00Z/2024-07-01T08:00:00Z"},
+		{"local time in calendar zone", &calendarapi.EventDateTime{DateTime: "2024-07-01T09:00:00"}, &calendarapi.EventDateTime{DateTime: "2024-07-01T10:00:00"}, "2024-07-01T13:00:00Z/2024-07-01T14:00:00Z"},
+		{"all day", &calendarapi.EventDateTime{Date: "2024-01-15"}, &calendarapi.EventDateTime{Date: "2024-01-16"}, "2024-01-15T05:00:00Z/2024-01-16T05:00:00Z"},
+	}
+	for _, tt := range tests {
+		start, end, ok := eventSpan(&calendarapi.Event{Start: tt.start, End: tt.end}, ny)
+		if got := start.UTC().Format(time.RFC3339) + "/" + end.UTC().Format(time.RFC3339); !ok || got != tt.want {
+			t.Errorf("%s: span = %s (ok %v), want %s", tt.name, got, ok, tt.want)
+		}
+	}
+	if _, _, ok := eventSpan(&calendarapi.Event{Start: &calendarapi.EventDateTime{DateTime: "soon"}, End: &calendarapi.EventDateTime{Date: "2024-01-16"}}, ny); ok {
+		t.Error("eventSpan accepted an invalid time")
+	}
+}
+
+func TestDescribeConflict(t *testing.T) {
+	loc := time.FixedZone("EET", 2*3600)
+	tests := []struct {
+		event *calendarapi.Event
+		want  string
+	}{
+		{&calendarapi.Event{Summary: "Standup", Start: &calendarapi.EventDateTime{DateTime: "2024-01-15T08:00:00Z"}, End: &calendarapi.EventDateTime{DateTime: "2024-01-15T08:30:00Z"}}, "Standup (2024-01-15 10:00–10:30)"},
+		{&calendarapi.Event{Summary: "Offsite", Start: &calendarapi.EventDateTime{DateTime: "2024-01-15T20:00:00Z"}, End: &calendarapi.EventDateTime{DateTime: "2024-01-16T08:00:00Z"}}, "Offsite (2024-01-15 22:00 – 2024-01-16 10:00)"},
+		{&calendarapi.Event{Start: &calendarapi.EventDateTime{Date: "2024-01-15"}, End: &calendarapi.EventDateTime{Date: "2024-01-16"}}, "(no title) (all day 2024-01-15)"},
+		{&calendarapi.Event{Summary: "Trip", Start: &calendarapi.EventDateTime{Date: "2024-01-15"}, End: &calendarapi.EventDateTime{Date: "2024-01-18"}}, "Trip (all day 2024-01-15 – 2024-01-17)"},
+	}
+	for _, tt := range tests {
+		if got := describeConflict(tt.event, loc); got != tt.want {
+			t.Errorf("describeConflict = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("singleEvents") != "true" || q.Get("timeMin") != "2024-01-14T15:00:00Z" || q.Get("timeMax") != "2024-01-16T16:00:00Z" {
+			t.Errorf("query = %v, want single events of the event's time padded by a day", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"timeZone": "Europe/Athens", "items": [
+			{"id": "other", "summary": "Review", "start": {"dateTime": "2024-01-15T17:30:00+02:00"}, "end": {"dateTime": "2024-01-15T18:30:00+02:00"}},
+			{"id": "ev1", "summary": "Itself", "start": {"dateTime": "2024-01-15T17:00:00+02:00"}, "end": {"dateTime": "2024-01-15T18:00:00+02:00"}},
+			{"id": "later", "summary": "Later", "start": {"dateTime": "2024-01-15T19:00:00+02:00"}, "end": {"dateTime": "2024-01-15T20:00:00+02:00"}}
+		]}`))
+	})
+	event := &calendarapi.Event{Start: &calendarapi.EventDateTime{DateTime: "2024-01-15T15:00:00Z"}, End: &calendarapi.EventDateTime{DateTime: "2024-01-15T16:00:00Z"}}
+
+	if got, err := checkConflicts(context.Background(), svc, "primary", event, "ev1", conflictCheckInput{}); err != nil || got != nil {
+		t.Errorf("unchecked: conflicts = %v, %v; want no check", got, err)
+	}
+	got, err := checkConflicts(context.Background(), svc, "primary", event, "ev1", conflictCheckInput{CheckConflicts: true})
+	if err != nil || len(got) != 1 || got[0] != "Review (2024-01-15 17:30–18:30)" {
+		t.Errorf("conflicts = %q, %v; want Review in the calendar's timezone", got, err)
+	}
+	if w := conflictWarning(got); w != "\n\nWarning: conflicts with Review (2024-01-15 17:30–18:30)\n" {
+		t.Errorf("warning = %q", w)
+	}
+
+	_, err = checkConflicts(context.Background(), svc, "primary", event, "ev1", conflictCheckInput{FailOnConflict: true})
+	var cerr *conflictError
+	if !errors.As(err, &cerr) || !strings.Contains(err.Error(), "  - Review (2024-01-15 17:30–18:30)") || !strings.Contains(err.Error(), "not saved") {
+		t.Errorf("fail_on_conflict: err = %v, want a conflict error listing Review", err)
+	}
+}
+
+func TestFormatBulkResults(t *testing.T) {
+	entries := []createEventInput{
+		{Summary: "Mon", StartTime: "2024-03-04T09:00:00Z"},
+		{Summary: "Tue", StartTime: "2024-03-05T09:00:00Z"},
+		{Summary: "Wed", StartTime: "2024-03-06T09:00:00Z"},
+		{Summary: "Thu", StartTime: "2024-03-07T09:00:00Z"},
+	}
+	results := []bulkEventResult{
+		{Event: &calendarapi.Event{Id: "ev1"}},
+		{Event: &calendarapi.Event{Id: "ev0"}, Duplicate: true},
+		{Err: errors.New("creating event: rate limited")},
+		{Skipped: true},
+	}
+	got := formatBulkResults(entries, results)
+	for _, want := range []string{
+		"Created 1 of 4 events (1 already existed, 1 failed, 1 not attempted).\n",
+		"\n1. Mon (2024-03-04T09:00:00Z)\n   Event ID: ev1\n",
+		"\n2. Tue (2024-03-05T09:00:00Z)\n   Duplicate detected, not created\n   Event ID: ev0\n",
+		"\n3. Wed (2024-03-06T09:00:00Z)\n   Error: creating event: rate limited\n",
+		"\n4. Thu (2024-03-07T09:00:00Z)\n   Not attempted\n",
+		"The call stopped at the first failed insert.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := formatBulkResults(entries[:1], results[:1]); !strings.HasPrefix(got, "Created 1 of 1 events.\n") {
+		t.Errorf("all created = %q", got)
+	}
+}
+
+func TestDisplayDateTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name, dt, want string
+	}{
+		{"from UTC", "2024-01-15T15:00:00Z", "2024-01-15T10:00:00-05:00 EST (originally +00:00)"},
+		{"same offset", "2024-01-15T10:00:00-05:00", "2024-01-15T10:00:00-05:00 EST"},
+		{"from Pacific", "2024-01-15T07:00:00-08:00", "2024-01-15T10:00:00-05:00 EST (originally -08:00)"},
+		{"last minute of EST", "2024-03-10T06:59:00Z", "2024-03-10T01:59:00-05:00 EST (originally +00:00)"},
+		{"first minute of EDT", "2024-03-10T07:00:00Z", "2024-03-10T03:00:00-04:00 EDT (originally +00:00)"},
+		{"EST offset after the switch", "2024-03-10T03:30:00-05:00", "2024-03-10T04:30:00-04:00 EDT (originally -05:00)"},
+		{"back to EST", "2024-11-03T06:30:00Z", "2024-11-03T01:30:00-05:00 EST (originally +00:00)"},
+		{"unparseable", "tomorrow", "tomorrow"},
+	}
+	for _, tt := range tests {
+		if got := displayDateTime(tt.dt, newYork); got != tt.want {
+			t.Errorf("%s: displayDateTime(%q) = %q, want %q", tt.name, tt.dt, got, tt.want)
+		}
+	}
+	if got := displayDateTime("2024-01-15T15:00:00Z", nil); got != "2024-01-15T15:00:00Z" {
+		t.Errorf("nil location: got %q", got)
+	}
+}
+
+func TestEventInZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	timed := &calendarapi.Event{
+		Summary: "Sync",
+		Start:   &calendarapi.EventDateTime{DateTime: "2024-07-01T09:00:00-07:00", TimeZone: "America/Los_Angeles"},
+		End:     &calendarapi.EventDateTime{DateTime: "2024-07-01T09:30:00-07:00", TimeZone: "America/Los_Angeles"},
+	}
+	got := eventInZone(timed, berlin, true)
+	if got.Start.DateTime != "2024-07-01T18:00:00+02:00 CEST (originally -07:00)" || got.End.DateTime != "2024-07-01T18:30:00+02:00 CEST (originally -07:00)" {
+		t.Errorf("annotated = %q – %q", got.Start.DateTime, got.End.DateTime)
+	}
+	if timed.Start.DateTime != "2024-07-01T09:00:00-07:00" {
+		t.Errorf("eventInZone modified the event: %q", timed.Start.DateTime)
+	}
+	if got := eventInZone(timed, berlin, false); got.Start.DateTime != "2024-07-01T18:00:00+02:00" {
+		t.Errorf("plain = %q", got.Start.DateTime)
+	}
+
+	allDay := &calendarapi.Event{
+		Start: &calendarapi.EventDateTime{Date: "2024-07-01"},
+		End:   &calendarapi.EventDateTime{Date: "2024-07-02"},
+	}
+	if got := eventInZone(allDay, berlin, true); got.Start.Date != "2024-07-01" || got.Start.DateTime != "" || got.End.Date != "2024-07-02" {
+		t.Errorf("all-day event converted: %+v – %+v", got.Start, got.End)
+	}
+	if got := eventInZone(timed, nil, true); got != timed {
+		t.Error("nil location should return the event itself")
+	}
+	if !strings.Contains(formatEvent(eventInZone(allDay, berlin, true), "a"), "Start: 2024-07-01 (all day)") {
+		t.Error("all-day start not shown as a date")
+	}
+}
+
+func TestDisplayLocation(t *testing.T) {
+	if loc, err := displayLocation(""); loc != nil || err != nil {
+		t.Errorf("empty = %v, %v", loc, err)
+	}
+	if _, err := displayLocation("Mars/Olympus_Mons"); err == nil || !strings.Contains(err.Error(), "display_timezone") {
+		t.Errorf("invalid name error = %v", err)
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "Agenda for today", "Agenda for today"},
+		{"escapes html", "a < b & <script>x</script>", "a &lt; b &amp; &lt;script&gt;x&lt;/script&gt;"},
+		{"bold and italics", "**Important**: bring *your* __laptop__ and _charger_", "<b>Important</b>: bring <i>your</i> <b>laptop</b> and <i>charger</i>"},
+		{"nested emphasis", "*see **this** now*", "<i>see <b>this</b> now</i>"},
+		{"no emphasis around spaces", "2 * 3 * 4", "2 * 3 * 4"},
+		{"snake case", "set max_retry_count and {{start_time}}", "set max_retry_count and {{start_time}}"},
+		{"link", "Join [the call](https://meet.example.com/a_b_c?x=1&y=2)", `Join <a href="https://meet.example.com/a_b_c?x=1&amp;y=2">the call</a>`},
+		{"link with emphasis", "[**Doc**](https://example.com)", `<a href="https://example.com"><b>Doc</b></a>`},
+		{"script link kept as text", "[x](javascript:alert(1))", "[x](javascript:alert(1))"},
+		{"bare url", "Notes: https://docs.example.com/d/a_b_/edit*", "Notes: https://docs.example.com/d/a_b_/edit*"},
+		{"code span", "run `make **all**`", "run make **all**"},
+		{"backslash escape", `\*not italics\*`, "*not italics*"},
+		{"line breaks", "line one\nline two", "line one<br>line two"},
+		{"paragraphs", "para one\n\n\npara two", "para one<br><br>para two"},
+		{"crlf", "a\r\nb", "a<br>b"},
+		{"bullets", "Bring:\n- laptop\n* **charger**\n+ notes\nThanks", "Bring:<ul><li>laptop</li><li><b>charger</b></li><li>notes</li></ul>Thanks"},
+		{"numbered", "1. intro\n2) demo\n\nQ&A", "<ol><li>intro</li><li>demo</li></ol>Q&amp;A"},
+		{"list kinds switch", "- a\n1. b", "<ul><li>a</li></ul><ol><li>b</li></ol>"},
+		{"heading", "## Agenda ##\nitems", "<b>Agenda</b><br>items"},
+		{"thematic break", "above\n- - -\nbelow", "above<br>- - -<br>below"},
+		{"unclosed", "**bold and [link](", "**bold and [link]("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownToHTML(tt.in); got != tt.want {
+				t.Errorf("markdownToHTML(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"allowed markup", `<b>Bold</b> <i>it</i><br><a href="https://example.com/?a=1&amp;b=2">link</a>`, `<b>Bold</b> <i>it</i><br><a href="https://example.com/?a=1&amp;b=2">link</a>`},
+		{"script", `before<script>alert("x")</script>after`, "beforeafter"},
+		{"uppercase script", `a<SCRIPT type="text/javascript">x()</SCRIPT>b`, "ab"},
+		{"style", "<style>b { color: red }</style><b>x</b>", "<b>x</b>"},
+		{"iframe", `<iframe src="https://evil.test"><b>fallback</b></iframe>ok`, "ok"},
+		{"self-closing script", `<script src="https://evil.test/x.js"/>ok`, "ok"},
+		{"unclosed script", "ok<script>steal()", "ok"},
+		{"event handlers", `<b onclick="steal()" class="x">x</b><img src="a.png" ONERROR="steal()">`, `<b class="x">x</b><img src="a.png">`},
+		{"script urls", `<a href=" JavaScript:steal()">a</a><a href="data:text/html,x">b</a><a href="mailto:a@example.com">c</a>`, `<a>a</a><a>b</a><a href="mailto:a@example.com">c</a>`},
+		{"comments", "a<!-- <script>x</script> -->b", "ab"},
+		{"text entities", "Q&A &lt;draft&gt;", "Q&amp;A &lt;draft&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHTML(tt.in); got != tt.want {
+				t.Errorf("sanitizeHTML(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDescription(t *testing.T) {
+	tests := []struct {
+		name, in, format, want string
+	}{
+		{"as given", "<b>x</b> & *y*", "", "<b>x</b> & *y*"},
+		{"text", "<b>x</b> & *y*\nnext", "text", "&lt;b&gt;x&lt;/b&gt; &amp; *y*\nnext"},
+		{"markdown", "<b>x</b> & *y*", "markdown", "&lt;b&gt;x&lt;/b&gt; &amp; <i>y</i>"},
+		{"html", "<b>x</b><script>y</script>", "html", "<b>x</b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, note := formatDescription(tt.in, tt.format)
+			if got != tt.want || note != "" {
+				t.Errorf("formatDescription(%q, %q) = %q, %q, want %q", tt.in, tt.format, got, note, tt.want)
+			}
+		})
+	}
+
+	for _, err := range []error{validateDescriptionFormat("md"), validateDescriptionFormat("HTML")} {
+		if err == nil || !strings.Contains(err.Error(), "use text, markdown or html") {
+			t.Errorf("validateDescriptionFormat error = %v", err)
+		}
+	}
+}
+
+func TestFormatDescription_Truncates(t *testing.T) {
+	long := strings.Repeat("é", maxDescriptionLength+100)
+	got, note := formatDescription(long, "")
+	if n := utf8.RuneCountInString(got); n != maxDescriptionLength {
+		t.Errorf("truncated description has %d characters, want %d", n, maxDescriptionLength)
+	}
+	if !strings.HasSuffix(got, descriptionTruncatedMarker) {
+		t.Errorf("truncated description doesn't end with the marker: ...%q", got[len(got)-40:])
+	}
+	wantNote := fmt.Sprintf("the description was %d characters, more than the limit of %d, and was truncated to fit", maxDescriptionLength+100, maxDescriptionLength)
+	if !strings.Contains(note, wantNote) {
+		t.Errorf("note = %q, want %q", note, wantNote)
+	}
+
+	exact := strings.Repeat("x", maxDescriptionLength)
+	if got, note := formatDescription(exact, ""); got != exact || note != "" {
+		t.Errorf("description of exactly the limit was changed: %d characters, note %q", len(got), note)
+	}
+
+	// The cut doesn't split an escaped entity or a tag.
+	limit := maxDescriptionLength - utf8.RuneCountInString(descriptionTruncatedMarker)
+	for _, tt := range []struct {
+		name, in, format, wantTail string
+	}{
+		{"entity", strings.Repeat("x", limit-2) + strings.Repeat("&", 10), "text", "xx" + descriptionTruncatedMarker},
+		{"tag", strings.Repeat("x", limit-2) + strings.Repeat("<b>y</b>", 10), "html", "xx" + descriptionTruncatedMarker},
+	} {
+		got, note := formatDescription(tt.in, tt.format)
+		if note == "" || !strings.HasSuffix(got, tt.wantTail) {
+			t.Errorf("%s: truncated to ...%q (note %q), want suffix %q", tt.name, got[len(got)-30:], note, tt.wantTail)
+		}
+	}
+}
+
+func TestMatchResponseEvents(t *testing.T) {
+	attendees := func(status string) []*calendarapi.EventAttendee {
+		return []*calendarapi.EventAttendee{
+			{Email: "boss@example.com", Organizer: true, ResponseStatus: "accepted"},
+			{Email: "me@example.com", Self: true, ResponseStatus: status},
+		}
+	}
+	boss := &calendarapi.EventOrganizer{Email: "Boss@Example.com"}
+	other := &calendarapi.EventOrganizer{Email: "hr@example.com"}
+	events := []*calendarapi.Event{
+		{Id: "standup", Summary: "Daily Standup", Status: "confirmed", Organizer: boss, Attendees: attendees("accepted")},
+		{Id: "planning", Summary: "Sprint planning", Status: "confirmed", Organizer: boss, Attendees: attendees("needsAction")},
+		{Id: "hr", Summary: "Standup with HR", Status: "confirmed", Organizer: other, Attendees: attendees("tentative")},
+		{Id: "cancelled", Summary: "Standup", Status: "cancelled", Organizer: boss, Attendees: attendees("accepted")},
+		{Id: "own", Summary: "Standup", Status: "confirmed", Organizer: &calendarapi.EventOrganizer{Email: "me@example.com", Self: true}, Attendees: attendees("accepted")},
+		{Id: "not-invited", Summary: "Standup", Status: "confirmed", Organizer: boss},
+		{Id: "ooo", Summary: "Standup", EventType: "outOfOffice", Organizer: boss, Attendees: attendees("accepted")},
+	}
+	tests := []struct {
+		filter responseFilter
+		want   string
+	}{
+		{responseFilter{}, "standup,planning,hr"},
+		{responseFilter{Organizer: "boss@example.com"}, "standup,planning"},
+		{responseFilter{Organizer: " BOSS@example.com "}, "standup,planning"},
+		{responseFilter{SummaryContains: "standup"}, "standup,hr"},
+		{responseFilter{Organizer: "boss@example.com", SummaryContains: "STANDUP"}, "standup"},
+		{responseFilter{Organizer: "nobody@example.com"}, ""},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range matchResponseEvents(events, tt.filter) {
+			got = append(got, e.Id)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("matchResponseEvents(%+v) = %v, want %s", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestResponsePatch(t *testing.T) {
+	event := &calendarapi.Event{
+		Id: "e1",
+		Attendees: []*calendarapi.EventAttendee{
+			{Email: "boss@example.com", ResponseStatus: "accepted"},
+			{Email: "me@example.com", Self: true, ResponseStatus: "needsAction"},
+		},
+	}
+	patch, err := responsePatch(event, "declined", "On vacation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch.Attendees) != 2 || patch.Attendees[0].ResponseStatus != "accepted" {
+		t.Errorf("patch attendees = %+v, want every attendee with the others unchanged", patch.Attendees)
+	}
+	if self := patch.Attendees[1]; self.ResponseStatus != "declined" || self.Comment != "On vacation" {
+		t.Errorf("self attendee = %+v, want declined with the comment", self)
+	}
+
+	if _, err := responsePatch(&calendarapi.Event{Id: "e2"}, "declined", ""); err == nil || !strings.Contains(err.Error(), "not listed as an attendee") {
+		t.Errorf("responsePatch without self attendee: err = %v", err)
+	}
+	if _, err := responsePatch(&calendarapi.Event{Id: "e3", EventType: "focusTime"}, "declined", ""); err == nil || !strings.Contains(err.Error(), "focusTime") {
+		t.Errorf("responsePatch of a focus time event: err = %v", err)
+	}
+}
+
+func TestFormatResponseChange(t *testing.T) {
+	e := &calendarapi.Event{
+		Id: "e1", Summary: "Sprint planning",
+		Start:     &calendarapi.EventDateTime{DateTime: "2024-06-03T10:00:00+02:00"},
+		Organizer: &calendarapi.EventOrganizer{Email: "boss@example.com"},
+	}
+	got := formatResponseChange(responseChange{Event: e, Previous: "accepted"}, "declined")
+	want := "- Sprint planning\n  Event ID: e1\n  Start: 2024-06-03T10:00:00+02:00\n  Organizer: boss@example.com\n  Response: accepted -> declined\n"
+	if got != want {
+		t.Errorf("formatResponseChange =\n%s\nwant\n%s", got, want)
+	}
+	if got := formatResponseChange(responseChange{Event: e, Err: errors.New("forbidden")}, "declined"); !strings.Contains(got, "Failed: forbidden") {
+		t.Errorf("failed change = %q", got)
+	}
 }
@@ -0,0 +1,345 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"github.com/thegrumpylion/google-mcp/internal/statefile"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Calendar push notifications (Events.Watch) POST to a caller-provided
+// HTTPS webhook whenever events on a calendar change. Stopping a channel
+// needs both its ID and the resource ID Google assigned, so every channel
+// created by watch_events is recorded in a state file next to the tokens.
+
+// channelsFile is the name of the channel state file in the config directory.
+const channelsFile = "calendar_channels.json"
+
+// watchChannel is a push channel created by watch_events.
+type watchChannel struct {
+	Account    string    `json:"account"`
+	CalendarID string    `json:"calendar_id"`
+	ChannelID  string    `json:"channel_id"`
+	ResourceID string    `json:"resource_id"`
+	Address    string    `json:"address"`
+	Token      string    `json:"token"`
+	Expiration time.Time `json:"expiration,omitzero"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// channelStore persists watch channels to a JSON file. Every operation
+// reads and rewrites the file under a lock, so separate server processes
+// see the same channels.
+type channelStore struct {
+	file *statefile.File[watchChannel]
+}
+
+func newChannelStore(mgr *auth.Manager) *channelStore {
+	return &channelStore{file: statefile.New(filepath.Join(mgr.ConfigDir(), channelsFile), "channel state", func(a, b watchChannel) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})}
+}
+
+// list returns the recorded channels, oldest first.
+func (s *channelStore) list() ([]watchChannel, error) {
+	return s.file.Load()
+}
+
+// add records a new channel.
+func (s *channelStore) add(c watchChannel) error {
+	return s.file.Update(func(channels []watchChannel) ([]watchChannel, error) {
+		return append(channels, c), nil
+	})
+}
+
+// get returns the channel with the given ID.
+func (s *channelStore) get(channelID string) (*watchChannel, error) {
+	channels, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range channels {
+		if c.ChannelID == channelID {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// remove deletes a channel and reports whether it existed.
+func (s *channelStore) remove(channelID string) (bool, error) {
+	found := false
+	err := s.file.Update(func(channels []watchChannel) ([]watchChannel, error) {
+		out := channels[:0:0]
+		for _, c := range channels {
+			if c.ChannelID != channelID {
+				out = append(out, c)
+			}
+		}
+		found = len(out) != len(channels)
+		return out, nil
+	})
+	return found, err
+}
+
+// validateWebhookAddress checks that address is an absolute HTTPS URL;
+// Google only delivers notifications over HTTPS.
+func validateWebhookAddress(address string) error {
+	if address == "" {
+		return fmt.Errorf("address is required")
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid address %q: must be an https:// URL", address)
+	}
+	return nil
+}
+
+// channelExpiration converts a channel's expiration (Unix milliseconds) to
+// a time, or the zero time if Google didn't set one.
+func channelExpiration(c *calendar.Channel) time.Time {
+	if c.Expiration <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(c.Expiration).UTC()
+}
+
+// formatChannels renders the channels belonging to accounts.
+func formatChannels(channels []watchChannel, accounts []string, now time.Time) string {
+	include := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		include[a] = true
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, c := range channels {
+		if !include[c.Account] {
+			continue
+		}
+		n++
+		fmt.Fprintf(&sb, "- Channel ID: %s\n", c.ChannelID)
+		if len(accounts) > 1 {
+			fmt.Fprintf(&sb, "  Account: %s\n", c.Account)
+		}
+		fmt.Fprintf(&sb, "  Calendar: %s\n", c.CalendarID)
+		fmt.Fprintf(&sb, "  Address: %s\n", c.Address)
+		fmt.Fprintf(&sb, "  Resource ID: %s\n", c.ResourceID)
+		switch {
+		case c.Expiration.IsZero():
+		case c.Expiration.After(now):
+			fmt.Fprintf(&sb, "  Expires: %s\n", c.Expiration.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(&sb, "  Expired: %s\n", c.Expiration.Format(time.RFC3339))
+		}
+	}
+	if n == 0 {
+		return "No watch channels."
+	}
+	return fmt.Sprintf("Watch channels (%d):\n\n%s", n, sb.String())
+}
+
+// --- watch_events ---
+
+type watchEventsInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	CalendarID string `json:"calendar_id,omitempty" jsonschema:"Calendar ID (default: 'primary')"`
+	Address    string `json:"address" jsonschema:"HTTPS URL that receives the notifications"`
+	TTLSeconds int64  `json:"ttl_seconds,omitempty" jsonschema:"Requested channel lifetime in seconds (default: Google's default, about a week)"`
+}
+
+func registerWatchEvents(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "watch_events",
+		Description: `Start push notifications for changes to events on a calendar. Google POSTs to the HTTPS address whenever an event changes; the request carries the channel ID and token in the X-Goog-Channel-ID and X-Goog-Channel-Token headers.
+
+Returns the channel ID, resource ID, token, and expiration. Channels are not renewed: call watch_events again before the expiration. Use list_watch_channels to see registered channels and stop_channel to stop one.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input watchEventsInput) (*mcp.CallToolResult, any, error) {
+		if err := validateWebhookAddress(input.Address); err != nil {
+			return nil, nil, err
+		}
+		if input.TTLSeconds < 0 {
+			return nil, nil, fmt.Errorf("ttl_seconds must be positive")
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(accounts) != 1 {
+			return nil, nil, fmt.Errorf("a single account is required to watch a calendar")
+		}
+		account := accounts[0]
+
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+
+		calendarID := input.CalendarID
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+
+		channel := &calendar.Channel{
+			Id:      uuid.NewString(),
+			Token:   uuid.NewString(),
+			Type:    "web_hook",
+			Address: input.Address,
+		}
+		if input.TTLSeconds > 0 {
+			channel.Params = map[string]string{"ttl": strconv.FormatInt(input.TTLSeconds, 10)}
+		}
+
+		created, err := svc.Events.Watch(calendarID, channel).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "starting watch")
+		}
+
+		entry := watchChannel{
+			Account:    account,
+			CalendarID: calendarID,
+			ChannelID:  created.Id,
+			ResourceID: created.ResourceId,
+			Address:    input.Address,
+			Token:      channel.Token,
+			Expiration: channelExpiration(created),
+			CreatedAt:  time.Now().UTC(),
+		}
+
+		var sb strings.Builder
+		sb.WriteString("Watch started.\n\n")
+		fmt.Fprintf(&sb, "Channel ID: %s\n", entry.ChannelID)
+		fmt.Fprintf(&sb, "Resource ID: %s\n", entry.ResourceID)
+		fmt.Fprintf(&sb, "Token: %s\n", entry.Token)
+		if !entry.Expiration.IsZero() {
+			fmt.Fprintf(&sb, "Expires: %s\n", entry.Expiration.Format(time.RFC3339))
+		}
+		if err := newChannelStore(mgr).add(entry); err != nil {
+			fmt.Fprintf(&sb, "\nWarning: the channel could not be recorded (%v). Keep the channel and resource IDs to stop it.\n", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// --- list_watch_channels ---
+
+type listWatchChannelsInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+}
+
+func registerListWatchChannels(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_watch_channels",
+		Description: "List push notification channels created with watch_events, oldest first, including expired ones that haven't been stopped. Set account to 'all' to list channels for every account.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listWatchChannelsInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		channels, err := newChannelStore(mgr).list()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatChannels(channels, accounts, time.Now())},
+			},
+		}, nil, nil
+	})
+}
+
+// --- stop_channel ---
+
+type stopChannelInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name (default: the account that created the channel)"`
+	ChannelID  string `json:"channel_id" jsonschema:"Channel ID returned by watch_events"`
+	ResourceID string `json:"resource_id,omitempty" jsonschema:"Resource ID returned by watch_events (only needed for channels not listed by list_watch_channels)"`
+}
+
+func registerStopChannel(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "stop_channel",
+		Description: "Stop a push notification channel created with watch_events. The resource ID is looked up from list_watch_channels when not given.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input stopChannelInput) (*mcp.CallToolResult, any, error) {
+		if input.ChannelID == "" {
+			return nil, nil, fmt.Errorf("channel_id is required")
+		}
+
+		store := newChannelStore(mgr)
+		recorded, err := store.get(input.ChannelID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resourceID := input.ResourceID
+		account := input.Account
+		if recorded != nil {
+			if resourceID == "" {
+				resourceID = recorded.ResourceID
+			}
+			if account == "" {
+				account = recorded.Account
+			}
+		}
+		if resourceID == "" {
+			return nil, nil, fmt.Errorf("channel %q is not recorded; resource_id is required", input.ChannelID)
+		}
+
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Calendar service: %w", err)
+		}
+		if err := svc.Channels.Stop(&calendar.Channel{Id: input.ChannelID, ResourceId: resourceID}).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "stopping channel")
+		}
+
+		if _, err := store.remove(input.ChannelID); err != nil {
+			return nil, nil, fmt.Errorf("channel stopped, but removing it from the channel list failed: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Channel %s stopped.", input.ChannelID)},
+			},
+		}, nil, nil
+	})
+}
@@ -7,13 +7,14 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 )
 
 // --- get_about ---
 
 type getAboutInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerGetAbout(srv *server.Server, mgr *auth.Manager) {
@@ -21,7 +22,10 @@ func registerGetAbout(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_about",
 		Description: "Get Google Drive account information including storage quota, user details, and supported export formats.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getAboutInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -30,10 +34,10 @@ func registerGetAbout(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		about, err := svc.About.Get().
-			Fields("user,storageQuota,maxUploadSize,exportFormats,importFormats").
+			Fields("user,storageQuota,maxUploadSize,exportFormats,importFormats").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting about info: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting about info")
 		}
 
 		var sb strings.Builder
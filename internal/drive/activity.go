@@ -0,0 +1,281 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+)
+
+// defaultHistoryLimit is the number of history events get_file shows when
+// include_history is set without history_limit.
+const defaultHistoryLimit = 10
+
+// historyActivityFilter restricts Drive Activity queries to the events that
+// explain how a file got where it is: renames, moves and sharing changes.
+const historyActivityFilter = "detail.action_detail_case:(RENAME MOVE PERMISSION_CHANGE)"
+
+func newActivityService(ctx context.Context, mgr *auth.Manager, account string) (*driveactivity.Service, error) {
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), Scopes, ReadonlyScopes)
+	if err != nil {
+		return nil, err
+	}
+	return driveactivity.NewService(ctx, opt)
+}
+
+// queryFileActivity returns up to limit rename, move and permission-change
+// activities for a file, newest first.
+func queryFileActivity(ctx context.Context, svc *driveactivity.Service, fileID string, limit int) ([]*driveactivity.DriveActivity, error) {
+	var activities []*driveactivity.DriveActivity
+	pageToken := ""
+	for len(activities) < limit {
+		resp, err := svc.Activity.Query(&driveactivity.QueryDriveActivityRequest{
+			ItemName:  "items/" + fileID,
+			Filter:    historyActivityFilter,
+			PageSize:  int64(limit - len(activities)),
+			PageToken: pageToken,
+		}).Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, resp.Activities...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	if len(activities) > limit {
+		activities = activities[:limit]
+	}
+	return activities, nil
+}
+
+// personNames resolves Drive Activity person names ("people/<id>") to email
+// addresses. The account ID in a person name is the same as the Drive
+// permission ID, so the file's permissions are listed once per tool call
+// and reused for every actor and permission change in the history.
+type personNames struct {
+	listPermissions func() ([]*drive.Permission, error)
+	names           map[string]string
+}
+
+func newPersonNames(ctx context.Context, svc *drive.Service, fileID string) *personNames {
+	return &personNames{
+		listPermissions: func() ([]*drive.Permission, error) {
+			return listAllPermissions(ctx, svc, fileID)
+		},
+	}
+}
+
+func (p *personNames) lookup(personName string) string {
+	if p.names == nil {
+		p.names = make(map[string]string)
+		// A failed lookup just leaves people unresolved.
+		if perms, err := p.listPermissions(); err == nil {
+			for _, perm := range perms {
+				name := perm.EmailAddress
+				if name == "" {
+					name = perm.DisplayName
+				}
+				if name != "" {
+					p.names["people/"+perm.Id] = name
+				}
+			}
+		}
+	}
+	if name, ok := p.names[personName]; ok {
+		return name
+	}
+	return personName
+}
+
+func (p *personNames) user(u *driveactivity.User) string {
+	switch {
+	case u == nil:
+		return "unknown user"
+	case u.KnownUser != nil && u.KnownUser.IsCurrentUser:
+		return "you"
+	case u.KnownUser != nil:
+		return p.lookup(u.KnownUser.PersonName)
+	case u.DeletedUser != nil:
+		return "deleted user"
+	default:
+		return "unknown user"
+	}
+}
+
+func (p *personNames) actors(actors []*driveactivity.Actor) string {
+	var names []string
+	for _, a := range actors {
+		switch {
+		case a.User != nil:
+			names = append(names, p.user(a.User))
+		case a.Administrator != nil:
+			names = append(names, "an administrator")
+		case a.System != nil:
+			names = append(names, "Google Drive")
+		case a.Anonymous != nil:
+			names = append(names, "anonymous user")
+		}
+	}
+	if len(names) == 0 {
+		return "unknown actor"
+	}
+	return strings.Join(names, ", ")
+}
+
+func (p *personNames) permission(perm *driveactivity.Permission) string {
+	var who string
+	switch {
+	case perm.User != nil:
+		who = p.user(perm.User)
+	case perm.Group != nil:
+		who = "group " + perm.Group.Email
+	case perm.Domain != nil:
+		who = "domain " + perm.Domain.Name
+	case perm.Anyone != nil:
+		who = "anyone with the link"
+	default:
+		who = "unknown"
+	}
+	return fmt.Sprintf("%s (%s)", who, strings.ToLower(perm.Role))
+}
+
+// activityTime returns the timestamp of an activity, using the end of the
+// range for consolidated activities.
+func activityTime(a *driveactivity.DriveActivity) string {
+	if a.Timestamp != "" {
+		return a.Timestamp
+	}
+	if a.TimeRange != nil {
+		return a.TimeRange.EndTime
+	}
+	return "unknown time"
+}
+
+func targetTitles(refs []*driveactivity.TargetReference) string {
+	var titles []string
+	for _, r := range refs {
+		switch {
+		case r.DriveItem != nil && r.DriveItem.Title != "":
+			titles = append(titles, r.DriveItem.Title)
+		case r.Drive != nil && r.Drive.Title != "":
+			titles = append(titles, r.Drive.Title)
+		}
+	}
+	if len(titles) == 0 {
+		return "(unknown folder)"
+	}
+	return strings.Join(titles, ", ")
+}
+
+// describeActivity returns a one-line description of a rename, move or
+// permission change.
+func describeActivity(a *driveactivity.DriveActivity, people *personNames) string {
+	d := a.PrimaryActionDetail
+	var what string
+	switch {
+	case d == nil:
+		what = "changed"
+	case d.Rename != nil:
+		what = fmt.Sprintf("renamed %q → %q", d.Rename.OldTitle, d.Rename.NewTitle)
+	case d.Move != nil:
+		parts := []string{}
+		if len(d.Move.RemovedParents) > 0 {
+			parts = append(parts, "from "+targetTitles(d.Move.RemovedParents))
+		}
+		if len(d.Move.AddedParents) > 0 {
+			parts = append(parts, "to "+targetTitles(d.Move.AddedParents))
+		}
+		what = strings.TrimSpace("moved " + strings.Join(parts, " "))
+	case d.PermissionChange != nil:
+		var parts []string
+		for _, p := range d.PermissionChange.AddedPermissions {
+			parts = append(parts, "shared with "+people.permission(p))
+		}
+		for _, p := range d.PermissionChange.RemovedPermissions {
+			parts = append(parts, "unshared from "+people.permission(p))
+		}
+		what = "sharing changed"
+		if len(parts) > 0 {
+			what = strings.Join(parts, "; ")
+		}
+	default:
+		what = "changed"
+	}
+	return fmt.Sprintf("%s: %s by %s", activityTime(a), what, people.actors(a.Actors))
+}
+
+// formatActivityHistory renders the History section from Drive Activity.
+func formatActivityHistory(activities []*driveactivity.DriveActivity, people *personNames) string {
+	var sb strings.Builder
+	sb.WriteString("History (renames, moves, sharing changes; newest first):\n")
+	if len(activities) == 0 {
+		sb.WriteString("  No renames, moves or sharing changes recorded.\n")
+		return sb.String()
+	}
+	for _, a := range activities {
+		fmt.Fprintf(&sb, "  - %s\n", describeActivity(a, people))
+	}
+	return sb.String()
+}
+
+// formatFallbackHistory renders the History section from Drive metadata when
+// Drive Activity can't be queried. revisions is -1 when the revision count
+// is unknown.
+func formatFallbackHistory(file *drive.File, revisions int, reason string) string {
+	var sb strings.Builder
+	sb.WriteString("History:\n")
+	fmt.Fprintf(&sb, "  Rename/move/sharing history unavailable: %s\n", reason)
+	if revisions >= 0 {
+		fmt.Fprintf(&sb, "  Versions: %d\n", revisions)
+	}
+	if u := file.LastModifyingUser; u != nil {
+		who := u.DisplayName
+		if u.EmailAddress != "" {
+			who = fmt.Sprintf("%s <%s>", u.DisplayName, u.EmailAddress)
+		}
+		fmt.Fprintf(&sb, "  Last modified by: %s\n", strings.TrimSpace(who))
+	}
+	return sb.String()
+}
+
+// countRevisions returns the number of stored revisions of a file, or -1 if
+// they can't be listed (e.g. for folders).
+func countRevisions(ctx context.Context, svc *drive.Service, fileID string) int {
+	n := 0
+	pageToken := ""
+	for {
+		call := svc.Revisions.List(fileID).PageSize(1000).Fields("nextPageToken,revisions(id)")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return -1
+		}
+		n += len(resp.Revisions)
+		if resp.NextPageToken == "" {
+			return n
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// fileHistory returns the History section for get_file. It prefers Drive
+// Activity and falls back to version count and last modifier when the
+// Activity API is unavailable or not authorized.
+func fileHistory(ctx context.Context, mgr *auth.Manager, account string, svc *drive.Service, file *drive.File, limit int) string {
+	asvc, err := newActivityService(ctx, mgr, account)
+	if err == nil {
+		var activities []*driveactivity.DriveActivity
+		if activities, err = queryFileActivity(ctx, asvc, file.Id, limit); err == nil {
+			return formatActivityHistory(activities, newPersonNames(ctx, svc, file.Id))
+		}
+	}
+	return formatFallbackHistory(file, countRevisions(ctx, svc, file.Id), gerrors.Translate(err).Error())
+}
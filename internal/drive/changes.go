@@ -2,18 +2,25 @@ package drive
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 )
 
 // --- list_changes ---
 
 type listChangesInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	PageToken  string `json:"page_token" jsonschema:"Start page token from get_about or a previous list_changes response. Use 'start' to get the initial token."`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of changes to return (default 50, max 100)"`
 }
@@ -26,7 +33,10 @@ func registerListChanges(srv *server.Server, mgr *auth.Manager) {
 Use page_token="start" to get the initial start token (returns a token without changes).
 Then use the returned next_page_token or new_start_page_token in subsequent calls to track changes.`,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listChangesInput) (*mcp.CallToolResult, any, error) {
 		if input.PageToken == "" {
@@ -40,9 +50,9 @@ Then use the returned next_page_token or new_start_page_token in subsequent call
 
 		// Special case: "start" fetches the initial start page token.
 		if input.PageToken == "start" {
-			resp, err := svc.Changes.GetStartPageToken().Do()
+			resp, err := svc.Changes.GetStartPageToken().Context(ctx).Do()
 			if err != nil {
-				return nil, nil, fmt.Errorf("getting start page token: %w", err)
+				return nil, nil, gerrors.Wrap(err, "getting start page token")
 			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -63,10 +73,10 @@ Then use the returned next_page_token or new_start_page_token in subsequent call
 			PageSize(maxResults).
 			Fields("nextPageToken,newStartPageToken,changes(changeType,removed,fileId,file(id,name,mimeType,trashed,modifiedTime,lastModifyingUser),driveId,drive(id,name))").
 			IncludeItemsFromAllDrives(true).
-			SupportsAllDrives(true).
+			SupportsAllDrives(true).Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing changes: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing changes")
 		}
 
 		var sb strings.Builder
@@ -123,3 +133,213 @@ Then use the returned next_page_token or new_start_page_token in subsequent call
 		}, nil, nil
 	})
 }
+
+// --- check_file_changed ---
+
+type checkFileChangedInput struct {
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID       string `json:"file_id" jsonschema:"Google Drive file ID"`
+	MD5Checksum  string `json:"md5_checksum,omitempty" jsonschema:"MD5 checksum seen last time (from get_file or a previous check_file_changed). Google Docs, Sheets and Slides have none."`
+	Version      int64  `json:"version,omitempty" jsonschema:"Version seen last time. Drive increases it on every change to the file, content or metadata."`
+	ModifiedTime string `json:"modified_time,omitempty" jsonschema:"Modified time seen last time (RFC3339)"`
+	Name         string `json:"name,omitempty" jsonschema:"Name seen last time, to report renames"`
+	Size         int64  `json:"size,omitempty" jsonschema:"Size in bytes seen last time, to report the size difference"`
+}
+
+// fileBaseline is what check_file_changed compares: the values a caller
+// saw last time and stores for the next check.
+type fileBaseline struct {
+	Name         string
+	Size         int64
+	MD5Checksum  string
+	Version      int64
+	ModifiedTime string
+}
+
+// fileBaselineFields are the file fields check_file_changed reads.
+const fileBaselineFields = "id,name,size,md5Checksum,version,modifiedTime,trashed,lastModifyingUser(displayName,emailAddress)"
+
+func baselineOf(f *drive.File) fileBaseline {
+	return fileBaseline{
+		Name:         f.Name,
+		Size:         f.Size,
+		MD5Checksum:  f.Md5Checksum,
+		Version:      f.Version,
+		ModifiedTime: f.ModifiedTime,
+	}
+}
+
+func registerCheckFileChanged(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "check_file_changed",
+		Description: `Check whether a single Drive file has changed since it was last seen, without reading its content or the whole change feed.
+
+Pass the md5_checksum, version and modified_time from get_file or from the previous check_file_changed call; name and size are optional and make the report show renames and size differences. The result says whether the file changed, what changed, and the new baseline values to store for the next check. A deleted file, or one the account can no longer access, is reported as removed.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input checkFileChangedInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		if input.MD5Checksum == "" && input.Version == 0 && input.ModifiedTime == "" {
+			return nil, nil, fmt.Errorf("at least one of md5_checksum, version or modified_time is required (get them from get_file)")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		prev := fileBaseline{
+			Name:         input.Name,
+			Size:         input.Size,
+			MD5Checksum:  input.MD5Checksum,
+			Version:      input.Version,
+			ModifiedTime: input.ModifiedTime,
+		}
+		text, err := checkFileChanged(ctx, svc, input.FileID, prev)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// checkFileChanged fetches the file and reports how it differs from prev.
+func checkFileChanged(ctx context.Context, svc *drive.Service, fileID string, prev fileBaseline) (string, error) {
+	file, err := svc.Files.Get(fileID).
+		Fields(fileBaselineFields).
+		SupportsAllDrives(true).Context(ctx).
+		Do()
+	if err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusNotFound {
+			return formatFileCheck(fileID, prev, nil), nil
+		}
+		return "", gerrors.Wrap(err, "getting file")
+	}
+	return formatFileCheck(fileID, prev, file), nil
+}
+
+// fileChange is one difference between a baseline and the current file.
+type fileChange struct {
+	Field    string
+	From, To string
+	Detail   string // e.g. "+300 bytes"; may be empty
+}
+
+// compareBaseline lists how cur differs from prev. Fields not set in prev
+// are not compared, since the caller didn't see them.
+func compareBaseline(prev, cur fileBaseline) []fileChange {
+	var changes []fileChange
+	if prev.Name != "" && prev.Name != cur.Name {
+		changes = append(changes, fileChange{Field: "Name", From: prev.Name, To: cur.Name})
+	}
+	if prev.Version != 0 && prev.Version != cur.Version {
+		changes = append(changes, fileChange{
+			Field:  "Version",
+			From:   strconv.FormatInt(prev.Version, 10),
+			To:     strconv.FormatInt(cur.Version, 10),
+			Detail: fmt.Sprintf("%+d", cur.Version-prev.Version),
+		})
+	}
+	if prev.MD5Checksum != "" && prev.MD5Checksum != cur.MD5Checksum {
+		changes = append(changes, fileChange{Field: "Content (MD5)", From: prev.MD5Checksum, To: cur.MD5Checksum})
+	}
+	if prev.Size != 0 && prev.Size != cur.Size {
+		changes = append(changes, fileChange{
+			Field:  "Size",
+			From:   fmt.Sprintf("%d bytes", prev.Size),
+			To:     fmt.Sprintf("%d bytes", cur.Size),
+			Detail: fmt.Sprintf("%+d bytes", cur.Size-prev.Size),
+		})
+	}
+	if prev.ModifiedTime != "" && !sameTime(prev.ModifiedTime, cur.ModifiedTime) {
+		c := fileChange{Field: "Modified", From: prev.ModifiedTime, To: cur.ModifiedTime}
+		from, err1 := time.Parse(time.RFC3339, prev.ModifiedTime)
+		to, err2 := time.Parse(time.RFC3339, cur.ModifiedTime)
+		if err1 == nil && err2 == nil {
+			if d := to.Sub(from); d >= 0 {
+				c.Detail = d.Round(time.Second).String() + " later"
+			} else {
+				c.Detail = (-d).Round(time.Second).String() + " earlier"
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// sameTime reports whether two RFC3339 times are the same instant, so that
+// "2024-01-02T10:00:00Z" and "2024-01-02T10:00:00.000Z" don't count as a
+// change. Unparseable times are compared as strings.
+func sameTime(a, b string) bool {
+	ta, err1 := time.Parse(time.RFC3339, a)
+	tb, err2 := time.Parse(time.RFC3339, b)
+	if err1 != nil || err2 != nil {
+		return a == b
+	}
+	return ta.Equal(tb)
+}
+
+// formatFileCheck formats the result of check_file_changed. file is nil
+// when the file no longer exists or isn't accessible.
+func formatFileCheck(fileID string, prev fileBaseline, file *drive.File) string {
+	var sb strings.Builder
+	if file == nil {
+		sb.WriteString("Changed: yes — file removed\n")
+		fmt.Fprintf(&sb, "File ID: %s\n", fileID)
+		if prev.Name != "" {
+			fmt.Fprintf(&sb, "Name: %s\n", prev.Name)
+		}
+		sb.WriteString("\nThe file was deleted permanently, or this account can no longer access it. There is no new baseline.\n")
+		return sb.String()
+	}
+
+	cur := baselineOf(file)
+	changes := compareBaseline(prev, cur)
+	if len(changes) == 0 {
+		sb.WriteString("Changed: no\n")
+	} else {
+		sb.WriteString("Changed: yes\n")
+	}
+	fmt.Fprintf(&sb, "File ID: %s\n", file.Id)
+	fmt.Fprintf(&sb, "Name: %s\n", file.Name)
+	if file.Trashed {
+		sb.WriteString("Trashed: yes\n")
+	}
+	if len(changes) > 0 {
+		if u := file.LastModifyingUser; u != nil && u.DisplayName != "" {
+			fmt.Fprintf(&sb, "Last modified by: %s\n", formatCommentAuthor(u))
+		}
+		sb.WriteString("\nChanges:\n")
+		for _, c := range changes {
+			fmt.Fprintf(&sb, "  - %s: %s -> %s", c.Field, c.From, c.To)
+			if c.Detail != "" {
+				fmt.Fprintf(&sb, " (%s)", c.Detail)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("\nNew baseline (pass these to the next check_file_changed call):\n")
+	if cur.MD5Checksum != "" {
+		fmt.Fprintf(&sb, "  md5_checksum: %s\n", cur.MD5Checksum)
+	}
+	fmt.Fprintf(&sb, "  version: %d\n", cur.Version)
+	fmt.Fprintf(&sb, "  modified_time: %s\n", cur.ModifiedTime)
+	fmt.Fprintf(&sb, "  name: %s\n", cur.Name)
+	if cur.Size > 0 {
+		fmt.Fprintf(&sb, "  size: %d\n", cur.Size)
+	}
+	return sb.String()
+}
@@ -1,7 +1,247 @@
 package drive
 
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
 // TODO: Planned comment tools (from api-coverage.md):
-// - list_comments (Comments.List)
-// - create_comment (Comments.Create)
 // - update_comment (Comments.Update)
 // - delete_comment (Comments.Delete)
+
+// replyFields is the Fields mask for a single reply.
+const replyFields = "id,author(displayName,emailAddress),content,action,createdTime,modifiedTime,deleted"
+
+// commentFields is the Fields mask for a comment, including its reply tree.
+// The Comments API returns nothing beyond the ID unless fields are requested.
+const commentFields = "id,author(displayName,emailAddress),content,quotedFileContent,anchor,createdTime,modifiedTime,resolved,deleted,replies(" + replyFields + ")"
+
+// --- list_comments ---
+
+type listCommentsInput struct {
+	Account         string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID          string `json:"file_id" jsonschema:"Google Drive file ID"`
+	IncludeResolved bool   `json:"include_resolved,omitempty" jsonschema:"Include resolved comments (default false)"`
+	MaxResults      int64  `json:"max_results,omitempty" jsonschema:"Maximum number of comments to return (default 20, max 100)"`
+}
+
+func registerListComments(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_comments",
+		Description: "List comments on a Google Drive file, including the quoted text each comment is anchored to and all replies. Resolved comments are hidden unless include_resolved is set.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listCommentsInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		maxResults := input.MaxResults
+		if maxResults <= 0 {
+			maxResults = 20
+		}
+		if maxResults > 100 {
+			maxResults = 100
+		}
+
+		comments, err := listComments(ctx, svc, input.FileID, input.IncludeResolved, maxResults)
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing comments")
+		}
+
+		if len(comments) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "No comments found."},
+				},
+			}, nil, nil
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Found %d comments:\n\n", len(comments))
+		for _, c := range comments {
+			sb.WriteString(formatComment(c))
+			sb.WriteString("\n")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// listComments fetches up to maxResults non-deleted comments on a file,
+// skipping resolved ones unless includeResolved is set. Comments are
+// returned oldest first so the output is stable across calls.
+func listComments(ctx context.Context, svc *drive.Service, fileID string, includeResolved bool, maxResults int64) ([]*drive.Comment, error) {
+	var comments []*drive.Comment
+	pageToken := ""
+	for {
+		call := svc.Comments.List(fileID).
+			PageSize(100).
+			Fields("nextPageToken,comments(" + commentFields + ")")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range resp.Comments {
+			if c.Deleted || (c.Resolved && !includeResolved) {
+				continue
+			}
+			comments = append(comments, c)
+		}
+		if resp.NextPageToken == "" || int64(len(comments)) >= maxResults {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].CreatedTime != comments[j].CreatedTime {
+			return comments[i].CreatedTime < comments[j].CreatedTime
+		}
+		return comments[i].Id < comments[j].Id
+	})
+	if int64(len(comments)) > maxResults {
+		comments = comments[:maxResults]
+	}
+	return comments, nil
+}
+
+// --- add_comment ---
+
+type addCommentInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID     string `json:"file_id" jsonschema:"Google Drive file ID"`
+	Content    string `json:"content" jsonschema:"Comment text"`
+	QuotedText string `json:"quoted_text,omitempty" jsonschema:"Text in the file the comment refers to (shown as the quoted anchor)"`
+}
+
+func registerAddComment(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "add_comment",
+		Description: "Add a comment to a Google Drive file. Optionally include the quoted text the comment refers to.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input addCommentInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		if input.Content == "" {
+			return nil, nil, fmt.Errorf("content is required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		comment := &drive.Comment{Content: input.Content}
+		if input.QuotedText != "" {
+			comment.QuotedFileContent = &drive.CommentQuotedFileContent{
+				MimeType: "text/plain",
+				Value:    input.QuotedText,
+			}
+		}
+
+		created, err := svc.Comments.Create(input.FileID, comment).
+			Fields(commentFields).Context(ctx).
+			Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "creating comment")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Comment added.\n\n" + formatComment(created)},
+			},
+		}, nil, nil
+	})
+}
+
+// formatComment formats a comment and its replies for display.
+func formatComment(c *drive.Comment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- Comment ID: %s\n", c.Id)
+	if c.Author != nil {
+		fmt.Fprintf(&sb, "  Author: %s\n", formatCommentAuthor(c.Author))
+	}
+	if c.QuotedFileContent != nil && c.QuotedFileContent.Value != "" {
+		fmt.Fprintf(&sb, "  Quoted text: %q\n", c.QuotedFileContent.Value)
+	}
+	fmt.Fprintf(&sb, "  Content: %s\n", c.Content)
+	if c.CreatedTime != "" {
+		fmt.Fprintf(&sb, "  Created: %s\n", c.CreatedTime)
+	}
+	if c.ModifiedTime != "" && c.ModifiedTime != c.CreatedTime {
+		fmt.Fprintf(&sb, "  Modified: %s\n", c.ModifiedTime)
+	}
+	if c.Resolved {
+		sb.WriteString("  Resolved: yes\n")
+	}
+
+	var replies []*drive.Reply
+	for _, r := range c.Replies {
+		if !r.Deleted {
+			replies = append(replies, r)
+		}
+	}
+	if len(replies) > 0 {
+		fmt.Fprintf(&sb, "  Replies (%d):\n", len(replies))
+		for _, r := range replies {
+			fmt.Fprintf(&sb, "    - Reply ID: %s\n", r.Id)
+			if r.Author != nil {
+				fmt.Fprintf(&sb, "      Author: %s\n", formatCommentAuthor(r.Author))
+			}
+			if r.Action != "" {
+				fmt.Fprintf(&sb, "      Action: %s\n", r.Action)
+			}
+			if r.Content != "" {
+				fmt.Fprintf(&sb, "      Content: %s\n", r.Content)
+			}
+			if r.CreatedTime != "" {
+				fmt.Fprintf(&sb, "      Created: %s\n", r.CreatedTime)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// formatCommentAuthor renders a comment author as "Name <email>", falling
+// back to whichever part is available.
+func formatCommentAuthor(u *drive.User) string {
+	switch {
+	case u.DisplayName != "" && u.EmailAddress != "":
+		return fmt.Sprintf("%s <%s>", u.DisplayName, u.EmailAddress)
+	case u.DisplayName != "":
+		return u.DisplayName
+	default:
+		return u.EmailAddress
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	driveapi "google.golang.org/api/drive/v3"
 )
@@ -15,7 +16,7 @@ import (
 // --- list_shared_drives ---
 
 type listSharedDrivesInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Query      string `json:"query,omitempty" jsonschema:"Search query to filter shared drives (e.g. \"name contains 'Engineering'\")"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results (default 20, max 100)"`
 }
@@ -25,7 +26,10 @@ func registerListSharedDrives(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_shared_drives",
 		Description: "List shared drives the user has access to. Optionally filter by name using a search query.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listSharedDrivesInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -49,9 +53,9 @@ func registerListSharedDrives(srv *server.Server, mgr *auth.Manager) {
 			call = call.Q(input.Query)
 		}
 
-		resp, err := call.Do()
+		resp, err := call.Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing shared drives: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing shared drives")
 		}
 
 		if len(resp.Drives) == 0 {
@@ -86,7 +90,7 @@ func registerListSharedDrives(srv *server.Server, mgr *auth.Manager) {
 // --- get_shared_drive ---
 
 type getSharedDriveInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	DriveID string `json:"drive_id" jsonschema:"Shared drive ID"`
 }
 
@@ -95,7 +99,10 @@ func registerGetSharedDrive(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_shared_drive",
 		Description: "Get details of a specific shared drive including name, creation time, and restrictions.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getSharedDriveInput) (*mcp.CallToolResult, any, error) {
 		if input.DriveID == "" {
@@ -108,10 +115,10 @@ func registerGetSharedDrive(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		d, err := svc.Drives.Get(input.DriveID).
-			Fields("id,name,createdTime,hidden,colorRgb,restrictions,capabilities").
+			Fields("id,name,createdTime,hidden,colorRgb,restrictions,capabilities").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting shared drive: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting shared drive")
 		}
 
 		var sb strings.Builder
@@ -155,7 +162,7 @@ func registerGetSharedDrive(srv *server.Server, mgr *auth.Manager) {
 // --- create_shared_drive ---
 
 type createSharedDriveInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Name    string `json:"name" jsonschema:"Name for the new shared drive"`
 }
 
@@ -164,7 +171,10 @@ func registerCreateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 		Name:        "create_shared_drive",
 		Description: "Create a new shared drive for team collaboration.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createSharedDriveInput) (*mcp.CallToolResult, any, error) {
 		if input.Name == "" {
@@ -181,9 +191,9 @@ func registerCreateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 
 		d, err := svc.Drives.Create(requestID, &driveapi.Drive{
 			Name: input.Name,
-		}).Do()
+		}).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating shared drive: %w", err)
+			return nil, nil, gerrors.Wrap(err, "creating shared drive")
 		}
 
 		return &mcp.CallToolResult{
@@ -197,7 +207,7 @@ func registerCreateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 // --- update_shared_drive ---
 
 type updateSharedDriveInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	DriveID string `json:"drive_id" jsonschema:"Shared drive ID to update"`
 	Name    string `json:"name,omitempty" jsonschema:"New name for the shared drive (leave empty to keep current)"`
 }
@@ -207,7 +217,10 @@ func registerUpdateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 		Name:        "update_shared_drive",
 		Description: "Update a shared drive's name or settings.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateSharedDriveInput) (*mcp.CallToolResult, any, error) {
 		if input.DriveID == "" {
@@ -224,9 +237,9 @@ func registerUpdateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 			update.Name = input.Name
 		}
 
-		d, err := svc.Drives.Update(input.DriveID, update).Do()
+		d, err := svc.Drives.Update(input.DriveID, update).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating shared drive: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating shared drive")
 		}
 
 		return &mcp.CallToolResult{
@@ -240,7 +253,7 @@ func registerUpdateSharedDrive(srv *server.Server, mgr *auth.Manager) {
 // --- delete_shared_drive ---
 
 type deleteSharedDriveInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	DriveID string `json:"drive_id" jsonschema:"Shared drive ID to delete"`
 }
 
@@ -248,7 +261,12 @@ func registerDeleteSharedDrive(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_shared_drive",
 		Description: "Delete a shared drive. The shared drive must be empty (no files or folders) before it can be deleted. This action is permanent.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteSharedDriveInput) (*mcp.CallToolResult, any, error) {
 		if input.DriveID == "" {
 			return nil, nil, fmt.Errorf("drive_id is required")
@@ -259,8 +277,8 @@ func registerDeleteSharedDrive(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 		}
 
-		if err := svc.Drives.Delete(input.DriveID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting shared drive: %w", err)
+		if err := svc.Drives.Delete(input.DriveID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting shared drive")
 		}
 
 		return &mcp.CallToolResult{
@@ -0,0 +1,378 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// folderMIME is the MIME type of Drive folders.
+const folderMIME = "application/vnd.google-apps.folder"
+
+// Defaults and limits of find_duplicates' max_files.
+const (
+	defaultDuplicateScan = 5000
+	maxDuplicateScan     = 50000
+)
+
+// duplicateFileFields are the file fields find_duplicates requests.
+const duplicateFileFields = "nextPageToken,files(id,name,mimeType,size,md5Checksum,createdTime,modifiedTime)"
+
+// --- find_duplicates ---
+
+type findDuplicatesInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FolderID string `json:"folder_id,omitempty" jsonschema:"Only look in this folder and its subfolders (default: all files the account can see)"`
+	MaxFiles int    `json:"max_files,omitempty" jsonschema:"Maximum number of files to scan (default 5000, max 50000)"`
+}
+
+// duplicateGroup is a set of files with the same content.
+type duplicateGroup struct {
+	// Files are sorted oldest first, by creation time.
+	Files []*drive.File
+	// ByChecksum is false for groups matched by name and size because the
+	// files have no MD5 checksum.
+	ByChecksum bool
+	Size       int64
+}
+
+// Reclaimable returns the bytes freed by keeping only one copy.
+func (g duplicateGroup) Reclaimable() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// redundant returns the copies to trash when keeping the newest or the
+// oldest one.
+func (g duplicateGroup) redundant(keepNewest bool) []*drive.File {
+	if keepNewest {
+		return g.Files[:len(g.Files)-1]
+	}
+	return g.Files[1:]
+}
+
+func registerFindDuplicates(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "find_duplicates",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: `Find duplicate files in Google Drive, optionally only in a folder and its subfolders. Files with the same MD5 checksum are duplicates; files without a checksum are matched by name and size. Google Workspace files (Docs, Sheets, ...) are skipped, as they have no checksum.
+
+Reports each group of copies with the oldest and newest marked, and the total bytes reclaimable by keeping one copy of each. Scans at most max_files files. Use trash_duplicates to move the redundant copies to the trash.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input findDuplicatesInput) (*mcp.CallToolResult, any, error) {
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		groups, scan, err := findDuplicates(ctx, svc, input.FolderID, input.MaxFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatDuplicateGroups(groups, scan)},
+			},
+		}, nil, nil
+	})
+}
+
+// --- trash_duplicates ---
+
+type trashDuplicatesInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FolderID string `json:"folder_id,omitempty" jsonschema:"Only look in this folder and its subfolders (default: all files the account can see)"`
+	MaxFiles int    `json:"max_files,omitempty" jsonschema:"Maximum number of files to scan (default 5000, max 50000)"`
+	Keep     string `json:"keep" jsonschema:"Which copy of each group to keep: 'newest' or 'oldest' (by creation time)"`
+	Confirm  bool   `json:"confirm,omitempty" jsonschema:"Set to true to actually trash the copies. Without it, the tool only reports what would be trashed."`
+}
+
+func registerTrashDuplicates(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "trash_duplicates",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: `Move the redundant copies of duplicate files in Google Drive to the trash, keeping the newest or the oldest copy of each group. Duplicates are found as find_duplicates finds them, in the same folder_id and max_files.
+
+Without confirm: true it only lists what would be trashed, so call it once to review and again with confirm to trash. Trashed files can be restored from the trash.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input trashDuplicatesInput) (*mcp.CallToolResult, any, error) {
+		var keepNewest bool
+		switch input.Keep {
+		case "newest":
+			keepNewest = true
+		case "oldest":
+		case "":
+			return nil, nil, fmt.Errorf("keep is required: 'newest' or 'oldest'")
+		default:
+			return nil, nil, fmt.Errorf("invalid keep %q: use 'newest' or 'oldest'", input.Keep)
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		groups, scan, err := findDuplicates(ctx, svc, input.FolderID, input.MaxFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var sb strings.Builder
+		sb.WriteString(formatDuplicateGroups(groups, scan))
+		if len(groups) > 0 {
+			var redundant []*drive.File
+			for _, g := range groups {
+				redundant = append(redundant, g.redundant(keepNewest)...)
+			}
+			if !input.Confirm {
+				fmt.Fprintf(&sb, "\nWould trash %d copies (keeping the %s):\n", len(redundant), input.Keep)
+				for _, f := range redundant {
+					fmt.Fprintf(&sb, "  - %s (ID: %s)\n", f.Name, f.Id)
+				}
+				sb.WriteString("\nNothing was trashed. Call again with confirm: true to trash them.\n")
+			} else {
+				sb.WriteString("\n" + trashFiles(ctx, req, svc, redundant))
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	}, server.Timeout(server.BulkToolTimeout))
+}
+
+// findDuplicates scans up to maxFiles files, in folderID and its subfolders
+// or in the whole Drive when folderID is empty, and groups the duplicates.
+func findDuplicates(ctx context.Context, svc *drive.Service, folderID string, maxFiles int) ([]duplicateGroup, duplicateScan, error) {
+	if maxFiles <= 0 {
+		maxFiles = defaultDuplicateScan
+	}
+	if maxFiles > maxDuplicateScan {
+		maxFiles = maxDuplicateScan
+	}
+	var scan duplicateScan
+	var err error
+	if folderID != "" {
+		scan, err = scanFolderFiles(ctx, svc, folderID, maxFiles)
+	} else {
+		scan, err = scanFiles(ctx, svc, "trashed = false and mimeType != '"+folderMIME+"'", maxFiles)
+	}
+	if err != nil {
+		return nil, scan, err
+	}
+	return groupDuplicates(scan.Files), scan, nil
+}
+
+// duplicateScan is the result of listing files for find_duplicates.
+type duplicateScan struct {
+	Files   []*drive.File
+	Folders int  // folders walked, for folder scans
+	Capped  bool // stopped at max_files
+}
+
+// scanFiles lists the non-folder files matching q, following pages until
+// max files are collected.
+func scanFiles(ctx context.Context, svc *drive.Service, q string, max int) (duplicateScan, error) {
+	var scan duplicateScan
+	pageToken := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return scan, err
+		}
+		call := svc.Files.List().
+			Q(q).
+			PageSize(1000).
+			Fields(googleapi.Field(duplicateFileFields)).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return scan, gerrors.Wrap(err, "listing files")
+		}
+		for _, f := range resp.Files {
+			if f.MimeType == folderMIME {
+				continue
+			}
+			if len(scan.Files) == max {
+				scan.Capped = true
+				return scan, nil
+			}
+			scan.Files = append(scan.Files, f)
+		}
+		if resp.NextPageToken == "" {
+			return scan, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// scanFolderFiles lists the files in folderID and its subfolders until max
+// files are collected.
+func scanFolderFiles(ctx context.Context, svc *drive.Service, folderID string, max int) (duplicateScan, error) {
+	var scan duplicateScan
+	walk, err := walkFolderTree(ctx, svc, folderID, duplicateFileFields, 0, func(f *drive.File) bool {
+		if len(scan.Files) == max {
+			scan.Capped = true
+			return false
+		}
+		scan.Files = append(scan.Files, f)
+		return true
+	})
+	scan.Folders = walk.Folders + 1
+	return scan, err
+}
+
+// groupDuplicates groups files with the same MD5 checksum, or the same name
+// and size for files without one, and returns the groups with more than
+// one file. Google Workspace files, folders and shortcuts are skipped.
+// Groups are sorted by reclaimable bytes, largest first.
+func groupDuplicates(files []*drive.File) []duplicateGroup {
+	byKey := make(map[string]*duplicateGroup)
+	var keys []string
+	for _, f := range files {
+		if strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+			continue
+		}
+		key := "md5:" + f.Md5Checksum
+		if f.Md5Checksum == "" {
+			key = fmt.Sprintf("name:%s\x00%d", f.Name, f.Size)
+		}
+		g, ok := byKey[key]
+		if !ok {
+			g = &duplicateGroup{ByChecksum: f.Md5Checksum != "", Size: f.Size}
+			byKey[key] = g
+			keys = append(keys, key)
+		}
+		g.Files = append(g.Files, f)
+	}
+
+	var groups []duplicateGroup
+	for _, key := range keys {
+		g := byKey[key]
+		if len(g.Files) < 2 {
+			continue
+		}
+		sort.SliceStable(g.Files, func(i, j int) bool {
+			return fileCreated(g.Files[i]) < fileCreated(g.Files[j])
+		})
+		groups = append(groups, *g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Reclaimable() > groups[j].Reclaimable()
+	})
+	return groups
+}
+
+// fileCreated returns the creation time of f for ordering copies, falling
+// back to its modification time. Drive times are RFC 3339 in UTC, so they
+// sort as strings.
+func fileCreated(f *drive.File) string {
+	if f.CreatedTime != "" {
+		return f.CreatedTime
+	}
+	return f.ModifiedTime
+}
+
+// formatDuplicateGroups formats the groups found by find_duplicates and
+// trash_duplicates.
+func formatDuplicateGroups(groups []duplicateGroup, scan duplicateScan) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Scanned %d files", len(scan.Files))
+	if scan.Folders > 0 {
+		fmt.Fprintf(&sb, " in %d folders", scan.Folders)
+	}
+	sb.WriteString(".")
+	if scan.Capped {
+		sb.WriteString(" Stopped at max_files; more files were not scanned, so some duplicates may be missing.")
+	}
+	sb.WriteString("\n")
+	if len(groups) == 0 {
+		sb.WriteString("No duplicates found.\n")
+		return sb.String()
+	}
+
+	var copies int
+	var reclaimable int64
+	for _, g := range groups {
+		copies += len(g.Files) - 1
+		reclaimable += g.Reclaimable()
+	}
+	fmt.Fprintf(&sb, "Found %d groups of duplicates with %d redundant copies. Reclaimable: %s\n", len(groups), copies, formatBytes(reclaimable))
+
+	for i, g := range groups {
+		match := "same MD5 checksum"
+		if !g.ByChecksum {
+			match = "same name and size, no checksum"
+		}
+		fmt.Fprintf(&sb, "\n%d. %s — %d copies of %s (%s)\n", i+1, g.Files[0].Name, len(g.Files), formatBytes(g.Size), match)
+		for j, f := range g.Files {
+			var mark string
+			switch j {
+			case 0:
+				mark = " [oldest]"
+			case len(g.Files) - 1:
+				mark = " [newest]"
+			}
+			fmt.Fprintf(&sb, "   - %s (ID: %s, created %s)%s\n", f.Name, f.Id, fileCreated(f), mark)
+		}
+	}
+	return sb.String()
+}
+
+// trashFiles moves files to the trash one at a time and reports the IDs
+// trashed and the failures.
+func trashFiles(ctx context.Context, req *mcp.CallToolRequest, svc *drive.Service, files []*drive.File) string {
+	var trashed []string
+	var failed []string
+	for i, f := range files {
+		if ctx.Err() != nil {
+			failed = append(failed, fmt.Sprintf("%s: not attempted: %v", f.Id, ctx.Err()))
+			continue
+		}
+		_, err := svc.Files.Update(f.Id, &drive.File{
+			Trashed:         true,
+			ForceSendFields: []string{"Trashed"},
+		}).SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Id, gerrors.Wrap(err, "trashing file")))
+		} else {
+			trashed = append(trashed, f.Id)
+		}
+		if (i+1)%server.ProgressInterval == 0 || i+1 == len(files) {
+			server.Progress(ctx, req, i+1, len(files), fmt.Sprintf("Trashed %d of %d copies", i+1, len(files)))
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Trashed %d of %d copies.\n", len(trashed), len(files))
+	if len(trashed) > 0 {
+		fmt.Fprintf(&sb, "Trashed IDs: %s\n", strings.Join(trashed, ", "))
+	}
+	if len(failed) > 0 {
+		sb.WriteString("Failed:\n")
+		for _, f := range failed {
+			fmt.Fprintf(&sb, "  - %s\n", f)
+		}
+	}
+	return sb.String()
+}
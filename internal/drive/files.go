@@ -1,37 +1,68 @@
 package drive
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 )
 
 // --- search_files ---
 
 type searchInput struct {
-	Account    string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
-	Query      string `json:"query" jsonschema:"Drive search query (e.g. \"name contains 'report'\" or \"mimeType = 'application/pdf'\")"`
-	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 10, max 50)"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Query        string `json:"query,omitempty" jsonschema:"Drive search query (e.g. \"name contains 'report'\" or \"mimeType = 'application/pdf'\")"`
+	FullText     string `json:"full_text,omitempty" jsonschema:"Find files whose content, name or description contains this text. Quoting is handled for you."`
+	NameContains string `json:"name_contains,omitempty" jsonschema:"Find files whose name contains this text. Quoting is handled for you."`
+	MIMEType     string `json:"mime_type,omitempty" jsonschema:"Only files of this MIME type (e.g. 'application/pdf', 'application/vnd.google-apps.folder')"`
+	MaxResults   int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 10, max 50)"`
+	Rank         bool   `json:"rank,omitempty" jsonschema:"Sort results by relevance (name match, recency, owned by me) and show each file's score factors (default: false, API order)"`
+	Format       string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per file)"`
 }
 
 func registerSearch(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "search_files",
-		Description: "Search Google Drive files using Drive query syntax. Set account to 'all' to search across all accounts. Returns file IDs, names, and metadata.",
+		Name: "search_files",
+		Description: `Search Google Drive files. Set account to 'all' to search across all accounts. Returns file IDs, names, and metadata.
+
+Prefer full_text (searches file content), name_contains and mime_type over writing query clauses by hand: they are quoted and escaped for you. They are ANDed with each other and with query, which takes raw Drive query syntax for anything else (e.g. "modifiedTime > '2024-01-01T00:00:00'"). Drive returns full-text matches in no particular order; set rank to sort them by relevance. Set format to json or csv for machine-readable output.`,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input searchInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		query, err := buildSearchQuery(input.Query, input.FullText, input.NameContains, input.MIMEType)
+		if err != nil {
+			return nil, nil, err
+		}
+		structured := input.FullText != "" || input.NameContains != "" || input.MIMEType != ""
+
+		columns := fileColumns
+		if input.Rank {
+			columns = append(slices.Clip(columns), fileScoreColumn)
+		}
+		table, err := server.NewTable(input.Format, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -44,45 +75,84 @@ func registerSearch(srv *server.Server, mgr *auth.Manager) {
 			maxResults = 50
 		}
 
-		var sb strings.Builder
+		out := srv.NewOutputBuilder()
 		multiAccount := len(accounts) > 1
+		if structured && !input.Rank {
+			fmt.Fprintf(out, "Query: %s\n\n", query)
+		}
 
 		for _, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
 					continue
 				}
 				return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 			}
 
+			fields := "files(id,name,mimeType,size,modifiedTime,owners,webViewLink,shortcutDetails(targetId))"
+			if input.Rank {
+				fields = "files(id,name,mimeType,size,modifiedTime,owners,ownedByMe,webViewLink,shortcutDetails(targetId))"
+			}
 			resp, err := svc.Files.List().
-				Q(input.Query).
+				Q(query).
 				PageSize(maxResults).
-				Fields("files(id,name,mimeType,size,modifiedTime,owners,webViewLink)").
+				Fields(googleapi.Field(fields)).Context(ctx).
 				Do()
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: searching: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError searching: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError searching: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("searching files: %w", err)
+				return nil, nil, gerrors.Wrap(err, "searching files")
+			}
+
+			if table != nil {
+				if input.Rank {
+					for _, r := range rankFiles(resp.Files, query, time.Now()) {
+						table.Add(fileRow{Account: account, File: r.File, Score: r.Score})
+					}
+				} else {
+					for _, f := range resp.Files {
+						table.Add(fileRow{Account: account, File: f})
+					}
+				}
+				continue
 			}
 
 			if multiAccount {
-				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+				fmt.Fprintf(out, "=== Account: %s ===\n", account)
 			}
 
 			if len(resp.Files) == 0 {
-				sb.WriteString("No files found.\n\n")
+				out.WriteString("No files found.\n\n")
 				continue
 			}
 
-			sb.WriteString(formatFileList(resp.Files, account))
+			if input.Rank {
+				writeRankedFileList(out, rankFiles(resp.Files, query, time.Now()), query, account)
+			} else {
+				writeFileList(out, resp.Files, account)
+			}
 		}
 
-		text := sb.String()
+		if table != nil {
+			return table.Result(), nil, nil
+		}
+
+		text := out.String()
 		if text == "" {
 			text = "No files found."
 		}
@@ -95,24 +165,64 @@ func registerSearch(srv *server.Server, mgr *auth.Manager) {
 	})
 }
 
+// buildSearchQuery combines a raw Drive query with clauses built from the
+// search_files convenience inputs, ANDing them together. The raw query is
+// parenthesized when combined so its own "or" clauses keep their meaning.
+func buildSearchQuery(query, fullText, nameContains, mimeType string) (string, error) {
+	var clauses []string
+	if q := strings.TrimSpace(query); q != "" {
+		clauses = append(clauses, q)
+	}
+	if fullText != "" {
+		clauses = append(clauses, "fullText contains "+driveQuote(fullText))
+	}
+	if nameContains != "" {
+		clauses = append(clauses, "name contains "+driveQuote(nameContains))
+	}
+	if mimeType != "" {
+		clauses = append(clauses, "mimeType = "+driveQuote(strings.TrimSpace(mimeType)))
+	}
+	switch len(clauses) {
+	case 0:
+		return "", fmt.Errorf("one of query, full_text, name_contains or mime_type is required")
+	case 1:
+		return clauses[0], nil
+	}
+	if q := strings.TrimSpace(query); q != "" {
+		clauses[0] = "(" + q + ")"
+	}
+	return strings.Join(clauses, " and "), nil
+}
+
+// driveQuote returns s as a Drive query string literal, escaping
+// backslashes and single quotes.
+func driveQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
 // --- list_files ---
 
 type listInput struct {
-	Account    string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
 	FolderID   string `json:"folder_id,omitempty" jsonschema:"Folder ID to list contents of (default: root)"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 20, max 100)"`
 	OrderBy    string `json:"order_by,omitempty" jsonschema:"Sort order (e.g. 'modifiedTime desc', 'name'). Default: 'modifiedTime desc'"`
+	ShowPath   bool   `json:"show_path,omitempty" jsonschema:"Show each file's folder path (e.g. /Projects/2024/report.pdf)"`
+	Format     string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per file)"`
 }
 
 func registerList(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "list_files",
-		Description: "List files in Google Drive, optionally within a specific folder. Set account to 'all' to list from all accounts. Returns file IDs, names, and metadata.",
+		Description: "List files in Google Drive, optionally within a specific folder. Set account to 'all' to list from all accounts. Returns file IDs, names, and metadata. Set show_path to see where each file lives before moving or organizing files. Set format to json or csv for machine-readable output.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -130,12 +240,28 @@ func registerList(srv *server.Server, mgr *auth.Manager) {
 			orderBy = "modifiedTime desc"
 		}
 
+		columns := fileColumns
+		if input.ShowPath {
+			columns = append(slices.Clip(columns), filePathColumn)
+		}
+		table, err := server.NewTable(input.Format, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		var sb strings.Builder
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: %v", account, err)
+					continue
+				}
 				if multiAccount {
 					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
 					continue
@@ -143,10 +269,14 @@ func registerList(srv *server.Server, mgr *auth.Manager) {
 				return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 			}
 
+			fields := "files(id,name,mimeType,size,modifiedTime,owners,webViewLink,shortcutDetails(targetId))"
+			if input.ShowPath {
+				fields = "files(id,name,mimeType,size,modifiedTime,owners,webViewLink,shortcutDetails(targetId),parents)"
+			}
 			call := svc.Files.List().
 				PageSize(maxResults).
 				OrderBy(orderBy).
-				Fields("files(id,name,mimeType,size,modifiedTime,owners,webViewLink)")
+				Fields(googleapi.Field(fields))
 
 			if input.FolderID != "" {
 				call = call.Q(fmt.Sprintf("'%s' in parents and trashed = false", input.FolderID))
@@ -154,13 +284,29 @@ func registerList(srv *server.Server, mgr *auth.Manager) {
 				call = call.Q("trashed = false")
 			}
 
-			resp, err := call.Do()
+			resp, err := call.Context(ctx).Do()
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: listing: %v", account, err)
+					continue
+				}
 				if multiAccount {
 					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing files: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing files")
+			}
+
+			if table != nil {
+				paths := newPathResolver(ctx, svc)
+				for _, f := range resp.Files {
+					row := fileRow{Account: account, File: f}
+					if input.ShowPath {
+						row.Path = paths.path(f)
+					}
+					table.Add(row)
+				}
+				continue
 			}
 
 			if multiAccount {
@@ -172,7 +318,15 @@ func registerList(srv *server.Server, mgr *auth.Manager) {
 				continue
 			}
 
-			sb.WriteString(formatFileList(resp.Files, account))
+			if input.ShowPath {
+				sb.WriteString(formatFileListWithPaths(resp.Files, account, newPathResolver(ctx, svc)))
+			} else {
+				sb.WriteString(formatFileList(resp.Files, account))
+			}
+		}
+
+		if table != nil {
+			return table.Result(), nil, nil
 		}
 
 		text := sb.String()
@@ -191,16 +345,24 @@ func registerList(srv *server.Server, mgr *auth.Manager) {
 // --- get_file ---
 
 type getInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
-	FileID  string `json:"file_id" jsonschema:"Google Drive file ID"`
+	Account        string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID         string `json:"file_id" jsonschema:"Google Drive file ID"`
+	IncludeHistory bool   `json:"include_history,omitempty" jsonschema:"Append recent renames, moves and sharing changes with who made them and when"`
+	HistoryLimit   int    `json:"history_limit,omitempty" jsonschema:"Maximum number of history events to show (default 10)"`
+	ShowPath       bool   `json:"show_path,omitempty" jsonschema:"Show the file's folder path (e.g. /Projects/2024/report.pdf)"`
 }
 
 func registerGet(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "get_file",
-		Description: "Get metadata for a specific Google Drive file by ID.",
+		Name: "get_file",
+		Description: `Get metadata for a specific Google Drive file by ID.
+
+For shortcuts, the target's name and ID are shown. Set show_path to show the folder path the file lives in. Set include_history to also show the file's most recent renames, moves and sharing changes from Drive Activity. If Drive Activity isn't available for the account, the version count and last modifying user are shown instead. Pass the version, MD5 checksum and modified time to check_file_changed later to find out whether the file changed.`,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -208,17 +370,27 @@ func registerGet(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 		}
 
+		fields := "id,name,mimeType,size,md5Checksum,version,description,modifiedTime,createdTime,owners,parents,webViewLink,webContentLink,exportLinks,shortcutDetails(targetId)"
+		if input.IncludeHistory {
+			fields += ",lastModifyingUser(displayName,emailAddress)"
+		}
 		file, err := svc.Files.Get(input.FileID).
-			Fields("id,name,mimeType,size,description,modifiedTime,createdTime,owners,parents,webViewLink,webContentLink,exportLinks").
+			Fields(googleapi.Field(fields)).Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting file: %w", err)
+			return nil, nil, srv.CrossAccountHint(ctx, mgr, input.Account, "file "+input.FileID, gerrors.Wrap(err, "getting file"), fileProbe(mgr, input.FileID))
 		}
 
 		var sb strings.Builder
 		fmt.Fprintf(&sb, "Name: %s\n", file.Name)
 		fmt.Fprintf(&sb, "File ID: %s\n", file.Id)
 		fmt.Fprintf(&sb, "MIME Type: %s\n", file.MimeType)
+		if isShortcut(file) {
+			sb.WriteString(shortcutTarget(ctx, svc, file))
+		}
+		if input.ShowPath {
+			fmt.Fprintf(&sb, "Path: %s\n", newPathResolver(ctx, svc).path(file))
+		}
 		if file.Size > 0 {
 			fmt.Fprintf(&sb, "Size: %d bytes\n", file.Size)
 		}
@@ -227,6 +399,10 @@ func registerGet(srv *server.Server, mgr *auth.Manager) {
 		}
 		fmt.Fprintf(&sb, "Created: %s\n", file.CreatedTime)
 		fmt.Fprintf(&sb, "Modified: %s\n", file.ModifiedTime)
+		fmt.Fprintf(&sb, "Version: %d\n", file.Version)
+		if file.Md5Checksum != "" {
+			fmt.Fprintf(&sb, "MD5 Checksum: %s\n", file.Md5Checksum)
+		}
 		if file.WebViewLink != "" {
 			fmt.Fprintf(&sb, "Web Link: %s\n", file.WebViewLink)
 		}
@@ -243,6 +419,14 @@ func registerGet(srv *server.Server, mgr *auth.Manager) {
 				fmt.Fprintf(&sb, "  - %s\n", mime)
 			}
 		}
+		if input.IncludeHistory {
+			limit := input.HistoryLimit
+			if limit <= 0 {
+				limit = defaultHistoryLimit
+			}
+			sb.WriteString("\n")
+			sb.WriteString(fileHistory(ctx, mgr, input.Account, svc, file, limit))
+		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -252,13 +436,41 @@ func registerGet(srv *server.Server, mgr *auth.Manager) {
 	})
 }
 
+// fileProbe returns a probe for cross-account hints that reports whether
+// an account can see fileID.
+func fileProbe(mgr *auth.Manager, fileID string) server.AccountProbe {
+	return func(ctx context.Context, account string) (bool, error) {
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return false, err
+		}
+		return fileExists(ctx, svc, fileID)
+	}
+}
+
+// fileExists reports whether svc's account can see fileID, fetching only
+// its ID.
+func fileExists(ctx context.Context, svc *drive.Service, fileID string) (bool, error) {
+	_, err := svc.Files.Get(fileID).Fields("id").SupportsAllDrives(true).Context(ctx).Do()
+	var gerr *googleapi.Error
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &gerr) && gerr.Code == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
 // --- read_file ---
 
 type readInput struct {
-	Account        string `json:"account" jsonschema:"Account name"`
-	FileID         string `json:"file_id" jsonschema:"Google Drive file ID"`
-	ExportMIMEType string `json:"export_mime_type,omitempty" jsonschema:"MIME type to export Google Docs/Sheets/Slides as (e.g. 'text/plain', 'text/csv', 'application/pdf'). Required for Google Workspace files."`
-	SaveTo         string `json:"save_to,omitempty" jsonschema:"Save to a local file instead of returning content (path relative to an allowed directory). Requires --allow-write-dir. Content never enters the conversation."`
+	Account         string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID          string `json:"file_id" jsonschema:"Google Drive file ID"`
+	ExportMIMEType  string `json:"export_mime_type,omitempty" jsonschema:"MIME type to export Google Docs/Sheets/Slides as (e.g. 'text/plain', 'text/csv', 'application/pdf'). Required for Google Workspace files."`
+	SaveTo          string `json:"save_to,omitempty" jsonschema:"Save to a local file instead of returning content (path relative to an allowed directory). Requires --allow-write-dir. Content never enters the conversation."`
+	FollowShortcuts *bool  `json:"follow_shortcuts,omitempty" jsonschema:"Read the target of a shortcut instead of the shortcut itself (default: true)"`
 }
 
 func registerRead(srv *server.Server, mgr *auth.Manager) {
@@ -266,13 +478,17 @@ func registerRead(srv *server.Server, mgr *auth.Manager) {
 
 By default, returns content in the conversation (text directly, base64 for binary, truncated at 512 KB).
 Set save_to to write the file to a local directory instead — content never enters the conversation and there is no size limit.
-For Google Docs/Sheets/Slides, specify export_mime_type to choose the export format.` + srv.WriteDirsDescription()
+For Google Docs/Sheets/Slides, specify export_mime_type to choose the export format.
+Shortcuts are followed to their target unless follow_shortcuts is false.` + srv.WriteDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "read_file",
 		Description: desc,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input readInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -281,9 +497,29 @@ For Google Docs/Sheets/Slides, specify export_mime_type to choose the export for
 		}
 
 		// First, get file metadata to determine if it's a Google Workspace file.
-		file, err := svc.Files.Get(input.FileID).Fields("id,name,mimeType,size").Do()
+		const readFields = "id,name,mimeType,size,shortcutDetails(targetId)"
+		file, err := svc.Files.Get(input.FileID).Fields(readFields).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting file metadata: %w", err)
+			return nil, nil, srv.CrossAccountHint(ctx, mgr, input.Account, "file "+input.FileID, gerrors.Wrap(err, "getting file metadata"), fileProbe(mgr, input.FileID))
+		}
+
+		// Shortcuts have no content of their own.
+		var note string
+		if isShortcut(file) {
+			if input.FollowShortcuts != nil && !*input.FollowShortcuts {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("File: %s (%s)\n%s\nShortcuts have no content. Read the target ID, or set follow_shortcuts to true.",
+							file.Name, file.MimeType, shortcutTarget(ctx, svc, file))},
+					},
+				}, nil, nil
+			}
+			target, chain, err := followShortcut(ctx, svc, file, readFields)
+			if err != nil {
+				return nil, nil, err
+			}
+			note = shortcutNote(chain, target)
+			file = target
 		}
 
 		var body io.ReadCloser
@@ -294,15 +530,15 @@ For Google Docs/Sheets/Slides, specify export_mime_type to choose the export for
 			if exportMIME == "" {
 				exportMIME = defaultExportMIME(file.MimeType)
 			}
-			resp, err := svc.Files.Export(input.FileID, exportMIME).Download()
+			resp, err := svc.Files.Export(file.Id, exportMIME).Context(ctx).Download()
 			if err != nil {
-				return nil, nil, fmt.Errorf("exporting file: %w", err)
+				return nil, nil, gerrors.Wrap(err, "exporting file")
 			}
 			body = resp.Body
 		} else {
-			resp, err := svc.Files.Get(input.FileID).Download()
+			resp, err := svc.Files.Get(file.Id).Context(ctx).Download()
 			if err != nil {
-				return nil, nil, fmt.Errorf("downloading file: %w", err)
+				return nil, nil, gerrors.Wrap(err, "downloading file")
 			}
 			body = resp.Body
 		}
@@ -315,20 +551,24 @@ For Google Docs/Sheets/Slides, specify export_mime_type to choose the export for
 				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
 			}
 
-			data, err := io.ReadAll(body)
+			// Stream to disk so large files aren't held in memory.
+			f, dir, err := lfs.CreateFile(input.SaveTo)
 			if err != nil {
-				return nil, nil, fmt.Errorf("reading file content: %w", err)
+				return nil, nil, fmt.Errorf("saving file: %w", err)
+			}
+			defer f.Close()
+			n, err := io.Copy(f, body)
+			if err == nil {
+				err = f.Commit()
 			}
-
-			dir, err := lfs.WriteFile(input.SaveTo, data)
 			if err != nil {
-				return nil, nil, fmt.Errorf("saving file: %w", err)
+				return nil, nil, fmt.Errorf("saving file to %s/%s (stopped after %d bytes; nothing was saved): %w", dir, input.SaveTo, n, err)
 			}
 
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("File saved to local disk.\n\nName: %s\nMIME Type: %s\nSize: %d bytes\nSaved to: %s/%s",
-						file.Name, file.MimeType, len(data), dir, input.SaveTo)},
+					&mcp.TextContent{Text: fmt.Sprintf("%sFile saved to local disk.\n\nName: %s\nMIME Type: %s\nSize: %d bytes\nSaved to: %s/%s",
+						note, file.Name, file.MimeType, n, dir, input.SaveTo)},
 				},
 			}, nil, nil
 		}
@@ -354,22 +594,23 @@ For Google Docs/Sheets/Slides, specify export_mime_type to choose the export for
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("File: %s (%s)\n\n%s%s", file.Name, file.MimeType, text, suffix)},
+				&mcp.TextContent{Text: fmt.Sprintf("%sFile: %s (%s)\n\n%s%s", note, file.Name, file.MimeType, text, suffix)},
 			},
 		}, nil, nil
-	})
+	}, server.LocalWriteParams("save_to"))
 }
 
 // --- upload_file ---
 
 type uploadInput struct {
-	Account   string `json:"account" jsonschema:"Account name"`
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Name      string `json:"name,omitempty" jsonschema:"File name (e.g. 'report.txt'). Auto-detected from local_path if omitted."`
 	Content   string `json:"content,omitempty" jsonschema:"File content as text, or base64-encoded binary data. Not needed when using local_path."`
 	MIMEType  string `json:"mime_type,omitempty" jsonschema:"MIME type of the file (e.g. 'text/plain', 'application/pdf'). Auto-detected if omitted."`
 	FolderID  string `json:"folder_id,omitempty" jsonschema:"Parent folder ID to upload into (default: root)"`
 	Base64    bool   `json:"base64,omitempty" jsonschema:"Set to true if content is base64-encoded binary data"`
 	LocalPath string `json:"local_path,omitempty" jsonschema:"Path to a local file to upload (relative to an allowed directory). Requires --allow-read-dir to be configured."`
+	Convert   bool   `json:"convert,omitempty" jsonschema:"Convert the upload to a Google Docs, Sheets, or Slides file. mime_type (or the name's extension) is the source format."`
 }
 
 func registerUpload(srv *server.Server, mgr *auth.Manager) {
@@ -380,12 +621,22 @@ Content can be provided as:
 - Base64-encoded binary (content field + base64=true)
 - Local file path (local_path field, requires --allow-read-dir)
 
-For local files, the name is auto-detected from the filename if not specified.` + srv.ReadDirsDescription()
+For local files, the name is auto-detected from the filename if not specified.
+
+Content larger than 8 MB is sent with the resumable protocol in 8 MB chunks. Chunks that fail with transient errors are retried, progress is reported after each chunk, and a failed upload reports how many bytes were sent.
+
+Set convert=true to create a native Google Workspace file; Drive converts the content on upload. Setting mime_type to a Workspace type (e.g. application/vnd.google-apps.document) also converts. Supported conversions:
+- Google Docs: text/plain, text/html, text/markdown, .docx, .doc, .odt, .rtf
+- Google Sheets: text/csv, text/tab-separated-values, .xlsx, .xls, .ods
+- Google Slides: .pptx, .ppt, .odp` + srv.ReadDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name: "upload_file",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: desc,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input uploadInput) (*mcp.CallToolResult, any, error) {
@@ -395,6 +646,7 @@ For local files, the name is auto-detected from the filename if not specified.`
 		}
 
 		var reader io.Reader
+		size := int64(-1)
 
 		if input.LocalPath != "" {
 			// Read from local filesystem.
@@ -402,23 +654,29 @@ For local files, the name is auto-detected from the filename if not specified.`
 			if lfs == nil {
 				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
 			}
+			info, _, err := lfs.Stat(input.LocalPath)
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading local file: %w", err)
+			}
+			if info.IsDir() {
+				return nil, nil, fmt.Errorf("reading local file: %s is a directory", input.LocalPath)
+			}
 			rc, _, err := lfs.OpenFile(input.LocalPath)
 			if err != nil {
 				return nil, nil, fmt.Errorf("reading local file: %w", err)
 			}
 			defer rc.Close()
 			reader = rc
+			size = info.Size()
 			if input.Name == "" {
 				input.Name = filepath.Base(input.LocalPath)
 			}
 		} else if input.Content == "" {
 			return nil, nil, fmt.Errorf("either content or local_path is required")
 		} else if input.Base64 {
-			data, err := base64.StdEncoding.DecodeString(input.Content)
-			if err != nil {
-				return nil, nil, fmt.Errorf("decoding base64 content: %w", err)
-			}
-			reader = bytes.NewReader(data)
+			// Decode while uploading instead of materializing a second
+			// copy of the content.
+			reader = base64.NewDecoder(base64.StdEncoding, strings.NewReader(input.Content))
 		} else {
 			reader = strings.NewReader(input.Content)
 		}
@@ -427,28 +685,42 @@ For local files, the name is auto-detected from the filename if not specified.`
 			return nil, nil, fmt.Errorf("name is required")
 		}
 
+		source, target, err := uploadConversion(input.Name, input.MIMEType, input.Convert)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		file := &drive.File{Name: input.Name}
-		if input.MIMEType != "" {
+		mediaType := ""
+		switch {
+		case target != "":
+			file.MimeType = target
+			mediaType = source
+		case input.MIMEType != "":
 			file.MimeType = input.MIMEType
 		}
 		if input.FolderID != "" {
 			file.Parents = []string{input.FolderID}
 		}
 
-		created, err := svc.Files.Create(file).Media(reader).
-			Fields("id,name,mimeType,size,webViewLink").Do()
+		created, n, err := createFile(ctx, req, svc, file, reader, size, mediaType)
 		if err != nil {
-			return nil, nil, fmt.Errorf("uploading file: %w", err)
+			return nil, nil, err
 		}
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "File uploaded.\n\n")
+		if target != "" {
+			fmt.Fprintf(&sb, "File uploaded and converted to %s.\n\n", workspaceTypeNames[target])
+		} else {
+			fmt.Fprintf(&sb, "File uploaded.\n\n")
+		}
 		fmt.Fprintf(&sb, "Name: %s\n", created.Name)
 		fmt.Fprintf(&sb, "File ID: %s\n", created.Id)
 		fmt.Fprintf(&sb, "MIME Type: %s\n", created.MimeType)
-		if created.Size > 0 {
-			fmt.Fprintf(&sb, "Size: %d bytes\n", created.Size)
+		if target != "" {
+			fmt.Fprintf(&sb, "Converted from: %s\n", source)
 		}
+		fmt.Fprintf(&sb, "Size: %d bytes\n", n)
 		if created.WebViewLink != "" {
 			fmt.Fprintf(&sb, "Link: %s\n", created.WebViewLink)
 		}
@@ -458,13 +730,200 @@ For local files, the name is auto-detected from the filename if not specified.`
 				&mcp.TextContent{Text: sb.String()},
 			},
 		}, nil, nil
-	})
+	}, server.Timeout(uploadToolTimeout))
+}
+
+// createFile uploads r as the content of a new file and returns it with
+// the number of bytes read. A non-empty mediaType declares the content's
+// type instead of letting the client sniff it; Drive needs it to convert
+// the upload to the Workspace type set on file.
+//
+// Content larger than uploadChunkSize goes through the resumable protocol:
+// it is sent in chunks, each retried on transient errors until
+// uploadChunkRetryDeadline or the end of ctx, and progress is reported
+// after every chunk. size is the content length if known, else -1; it
+// only serves progress and error messages. If the upload fails, the error
+// tells how many bytes Drive had acknowledged.
+func createFile(ctx context.Context, req *mcp.CallToolRequest, svc *drive.Service, file *drive.File, r io.Reader, size int64, mediaType string) (*drive.File, int64, error) {
+	opts := []googleapi.MediaOption{
+		googleapi.ChunkSize(uploadChunkSize),
+		googleapi.ChunkRetryDeadline(uploadChunkRetryDeadline),
+	}
+	if mediaType != "" {
+		opts = append(opts, googleapi.ContentType(mediaType))
+	}
+	counter := &countingReader{r: r}
+	var sent int64
+	created, err := svc.Files.Create(file).Media(counter, opts...).
+		ProgressUpdater(func(current, _ int64) {
+			sent = current
+			server.Progress(ctx, req, int(current), int(max(size, 0)), uploadProgress(current, size))
+		}).
+		Fields("id,name,mimeType,size,webViewLink").Context(ctx).Do()
+	if err != nil {
+		var corrupt base64.CorruptInputError
+		if errors.As(counter.err, &corrupt) {
+			return nil, 0, fmt.Errorf("decoding base64 content: %w", counter.err)
+		}
+		if sent > 0 {
+			return nil, 0, gerrors.Wrapf(err, "uploading file (%s before the failure; the file was not created)", uploadProgress(sent, size))
+		}
+		return nil, 0, gerrors.Wrap(err, "uploading file")
+	}
+	return created, counter.n, nil
+}
+
+// uploadProgress describes how much of an upload of size bytes (-1 if
+// unknown) was sent, e.g. "Uploaded 8388608 of 20971520 bytes".
+func uploadProgress(sent, size int64) string {
+	if size < 0 {
+		return fmt.Sprintf("Uploaded %d bytes", sent)
+	}
+	return fmt.Sprintf("Uploaded %d of %d bytes", sent, size)
+}
+
+// Google Workspace MIME types that uploads can be converted to.
+const (
+	googleDocMIME    = "application/vnd.google-apps.document"
+	googleSheetMIME  = "application/vnd.google-apps.spreadsheet"
+	googleSlidesMIME = "application/vnd.google-apps.presentation"
+)
+
+var workspaceTypeNames = map[string]string{
+	googleDocMIME:    "Google Docs",
+	googleSheetMIME:  "Google Sheets",
+	googleSlidesMIME: "Google Slides",
+}
+
+// conversionTargets maps the source formats Drive can convert on upload to
+// the Workspace type they become.
+var conversionTargets = map[string]string{
+	"text/plain":         googleDocMIME,
+	"text/html":          googleDocMIME,
+	"text/markdown":      googleDocMIME,
+	"application/rtf":    googleDocMIME,
+	"application/msword": googleDocMIME,
+	"application/vnd.oasis.opendocument.text":                                 googleDocMIME,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": googleDocMIME,
+
+	"text/csv":                  googleSheetMIME,
+	"text/tab-separated-values": googleSheetMIME,
+	"application/vnd.ms-excel":  googleSheetMIME,
+	"application/vnd.oasis.opendocument.spreadsheet":                    googleSheetMIME,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": googleSheetMIME,
+
+	"application/vnd.ms-powerpoint":                                             googleSlidesMIME,
+	"application/vnd.oasis.opendocument.presentation":                           googleSlidesMIME,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": googleSlidesMIME,
+}
+
+// conversionSourceTypes maps file extensions to convertible source formats,
+// since the system MIME table often lacks the office types.
+var conversionSourceTypes = map[string]string{
+	".txt":  "text/plain",
+	".html": "text/html",
+	".htm":  "text/html",
+	".md":   "text/markdown",
+	".rtf":  "application/rtf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".csv":  "text/csv",
+	".tsv":  "text/tab-separated-values",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+}
+
+// uploadConversion works out whether an upload is converted to a Workspace
+// type. It returns empty strings for a plain upload. A conversion is
+// requested by convert (mimeType, or the extension of name, is then the
+// source format) or by a Workspace mimeType (the source comes from name,
+// defaulting to plain text for Docs and CSV for Sheets).
+func uploadConversion(name, mimeType string, convert bool) (source, target string, err error) {
+	byExtension := conversionSourceTypes[strings.ToLower(filepath.Ext(name))]
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+
+	if strings.HasPrefix(mimeType, "application/vnd.google-apps.") {
+		target = mimeType
+		if _, ok := workspaceTypeNames[target]; !ok {
+			return "", "", fmt.Errorf("uploads can't be converted to %s; supported targets are %s, %s and %s", target, googleDocMIME, googleSheetMIME, googleSlidesMIME)
+		}
+		source = byExtension
+		if source == "" {
+			switch target {
+			case googleDocMIME:
+				source = "text/plain"
+			case googleSheetMIME:
+				source = "text/csv"
+			default:
+				return "", "", fmt.Errorf("converting to Google Slides needs a .pptx, .ppt or .odp file; name the upload with its extension")
+			}
+		}
+		if conversionTargets[source] != target {
+			return "", "", fmt.Errorf("%s can't be converted to %s", source, workspaceTypeNames[target])
+		}
+		return source, target, nil
+	}
+
+	if !convert {
+		return "", "", nil
+	}
+	source = mimeType
+	if source == "" {
+		source = byExtension
+	}
+	if source == "" {
+		return "", "", fmt.Errorf("can't tell the format to convert from; set mime_type (e.g. text/csv) or use a name with a known extension")
+	}
+	if i := strings.IndexByte(source, ';'); i >= 0 {
+		source = strings.TrimSpace(source[:i])
+	}
+	target, ok := conversionTargets[source]
+	if !ok {
+		return "", "", fmt.Errorf("%s can't be converted to a Google Workspace file (see the tool description for supported formats)", source)
+	}
+	return source, target, nil
+}
+
+// uploadChunkSize is the chunk size for resumable uploads. Media larger than
+// one chunk is sent in chunks, so memory use is bounded by the chunk size
+// rather than the file size. It is a variable so tests can shrink it.
+var uploadChunkSize = 8 * 1024 * 1024
+
+// uploadChunkRetryDeadline bounds how long a chunk of a resumable upload is
+// retried before the upload fails.
+const uploadChunkRetryDeadline = 2 * time.Minute
+
+// uploadToolTimeout is the time limit for upload_file, which raises the
+// server's --tool-timeout: long enough for a chunk to be retried until
+// uploadChunkRetryDeadline with time left for the rest of a large file.
+const uploadToolTimeout = 5 * uploadChunkRetryDeadline
+
+// countingReader counts the bytes read from r and remembers the first read
+// error other than io.EOF.
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	return n, err
 }
 
 // --- update_file ---
 
 type updateInput struct {
-	Account     string `json:"account" jsonschema:"Account name"`
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID      string `json:"file_id" jsonschema:"Google Drive file ID to update"`
 	Name        string `json:"name,omitempty" jsonschema:"New file name (leave empty to keep current)"`
 	Description string `json:"description,omitempty" jsonschema:"New file description (leave empty to keep current)"`
@@ -475,7 +934,10 @@ func registerUpdate(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "update_file",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: "Update file metadata on Google Drive (rename, change description, change MIME type). Only specified fields are changed.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateInput) (*mcp.CallToolResult, any, error) {
@@ -496,9 +958,9 @@ func registerUpdate(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		updated, err := svc.Files.Update(input.FileID, file).
-			Fields("id,name,mimeType,size,description,modifiedTime,webViewLink").Do()
+			Fields("id,name,mimeType,size,description,modifiedTime,webViewLink").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating file: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating file")
 		}
 
 		var sb strings.Builder
@@ -524,18 +986,33 @@ func registerUpdate(srv *server.Server, mgr *auth.Manager) {
 // --- delete_file ---
 
 type deleteInput struct {
-	Account     string `json:"account" jsonschema:"Account name"`
-	FileID      string `json:"file_id" jsonschema:"Google Drive file ID to delete"`
-	Permanently bool   `json:"permanently,omitempty" jsonschema:"If true, permanently delete instead of moving to trash (default: false, moves to trash)"`
+	Account                     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID                      string `json:"file_id" jsonschema:"Google Drive file ID to delete"`
+	Permanently                 bool   `json:"permanently,omitempty" jsonschema:"If true, permanently delete instead of moving to trash (default: false, moves to trash)"`
+	RemoveFromMyDriveIfNotOwner bool   `json:"remove_from_my_drive_if_not_owner,omitempty" jsonschema:"If the file can't be trashed because someone else owns it, remove it from the folders you own instead; folders owned by others are left unchanged (default: false)"`
 }
 
+// insufficientFilePermissionsReason is the error reason Drive returns when the
+// user may not change a file, e.g. trash one owned by someone else.
+const insufficientFilePermissionsReason = "insufficientFilePermissions"
+
 func registerDelete(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "delete_file",
-		Annotations: &mcp.ToolAnnotations{},
+		Name: "delete_file",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 		Description: `Delete a file from Google Drive. By default, moves the file to trash.
 
-Set permanently=true to permanently delete the file (cannot be undone).`,
+Set permanently=true to permanently delete the file (cannot be undone).
+
+Only the owner can trash a file. For a file someone else owns, e.g. in a folder shared with you,
+set remove_from_my_drive_if_not_owner=true to remove it from the folders you own instead. Folders
+owned by someone else are left unchanged and listed in the result. The owner keeps the file, but
+people who only saw it through your folders lose access.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
@@ -543,8 +1020,8 @@ Set permanently=true to permanently delete the file (cannot be undone).`,
 		}
 
 		if input.Permanently {
-			if err := svc.Files.Delete(input.FileID).Do(); err != nil {
-				return nil, nil, fmt.Errorf("deleting file: %w", err)
+			if err := svc.Files.Delete(input.FileID).Context(ctx).Do(); err != nil {
+				return nil, nil, gerrors.Wrap(err, "deleting file")
 			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -553,27 +1030,126 @@ Set permanently=true to permanently delete the file (cannot be undone).`,
 			}, nil, nil
 		}
 
-		// Move to trash by updating the trashed property.
-		_, err = svc.Files.Update(input.FileID, &drive.File{
-			Trashed:         true,
-			ForceSendFields: []string{"Trashed"},
-		}).Do()
+		text, err := trashFile(ctx, svc, input.FileID, input.RemoveFromMyDriveIfNotOwner)
 		if err != nil {
-			return nil, nil, fmt.Errorf("trashing file: %w", err)
+			return nil, nil, err
 		}
-
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("File %s moved to trash.", input.FileID)},
+				&mcp.TextContent{Text: text},
 			},
 		}, nil, nil
 	})
 }
 
+// trashFile moves fileID to trash and returns the result text. If Drive
+// refuses because the user doesn't own the file, the error names the
+// owner; with removeIfNotOwner the file is instead removed from those of
+// its parent folders the user owns. Folders owned by someone else are
+// left alone: taking the file out of them would take it away from
+// everyone who sees it through that folder.
+func trashFile(ctx context.Context, svc *drive.Service, fileID string, removeIfNotOwner bool) (string, error) {
+	_, err := svc.Files.Update(fileID, &drive.File{
+		Trashed:         true,
+		ForceSendFields: []string{"Trashed"},
+	}).Context(ctx).Do()
+	if err == nil {
+		return fmt.Sprintf("File %s moved to trash.", fileID), nil
+	}
+	if !gerrors.HasReason(err, insufficientFilePermissionsReason) {
+		return "", gerrors.Wrap(err, "trashing file")
+	}
+
+	file, getErr := svc.Files.Get(fileID).Fields("id,name,parents,ownedByMe,owners(displayName,emailAddress)").Context(ctx).Do()
+	if getErr != nil || file.OwnedByMe {
+		// Not an ownership problem we can explain.
+		return "", gerrors.Wrap(err, "trashing file")
+	}
+	owners := describeOwners(file.Owners)
+	if !removeIfNotOwner {
+		return "", fmt.Errorf("only the owner can trash %q (owned by %s). Ask them to delete it, or call again with remove_from_my_drive_if_not_owner set to remove it from your own folders instead", file.Name, owners)
+	}
+
+	mine, others := splitParentsByOwner(ctx, svc, file.Parents)
+	if len(mine) == 0 {
+		if len(others) > 0 {
+			return "", fmt.Errorf("only the owner can trash %q (owned by %s), and it is only in folders you don't own (%s); removing it from those would remove it for everyone who uses them", file.Name, owners, folderNames(others))
+		}
+		return "", fmt.Errorf("only the owner can trash %q (owned by %s), and it isn't in any of your folders to remove it from", file.Name, owners)
+	}
+
+	ids := make([]string, len(mine))
+	for i, f := range mine {
+		ids[i] = f.Id
+	}
+	_, err = svc.Files.Update(fileID, &drive.File{}).RemoveParents(strings.Join(ids, ",")).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("only the owner can trash %q (owned by %s), and removing it from your folders failed too: %w", file.Name, owners, gerrors.Translate(err))
+	}
+	text := fmt.Sprintf("File %s (%s) is owned by %s, so instead of moving it to trash it was removed from your folders: %s. The owner still has the file, and so does everyone it is shared with directly; people who only had access through those folders lose it.", fileID, file.Name, owners, folderNames(mine))
+	if len(others) > 0 {
+		text += fmt.Sprintf(" It is still in folders owned by others, which were left unchanged: %s.", folderNames(others))
+	}
+	return text, nil
+}
+
+// splitParentsByOwner looks up each of parents and splits them into the
+// folders the user owns and the rest. A folder that can't be read counts
+// as someone else's, so it is never changed.
+func splitParentsByOwner(ctx context.Context, svc *drive.Service, parents []string) (mine, others []*drive.File) {
+	for _, id := range parents {
+		folder, err := svc.Files.Get(id).Fields("id,name,ownedByMe").SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			others = append(others, &drive.File{Id: id})
+			continue
+		}
+		if folder.OwnedByMe {
+			mine = append(mine, folder)
+		} else {
+			others = append(others, folder)
+		}
+	}
+	return mine, others
+}
+
+// folderNames lists folders as "Name (ID)", or just the ID when the name
+// isn't known.
+func folderNames(folders []*drive.File) string {
+	parts := make([]string, len(folders))
+	for i, f := range folders {
+		if f.Name == "" {
+			parts[i] = f.Id
+		} else {
+			parts[i] = fmt.Sprintf("%s (%s)", f.Name, f.Id)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeOwners lists owners as "Name <email>", or "someone else" when
+// Drive doesn't show them.
+func describeOwners(owners []*drive.User) string {
+	var parts []string
+	for _, o := range owners {
+		switch {
+		case o.DisplayName != "" && o.EmailAddress != "":
+			parts = append(parts, o.DisplayName+" <"+o.EmailAddress+">")
+		case o.EmailAddress != "":
+			parts = append(parts, o.EmailAddress)
+		case o.DisplayName != "":
+			parts = append(parts, o.DisplayName)
+		}
+	}
+	if len(parts) == 0 {
+		return "someone else"
+	}
+	return strings.Join(parts, ", ")
+}
+
 // --- create_folder ---
 
 type createFolderInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	Name     string `json:"name" jsonschema:"Folder name"`
 	FolderID string `json:"folder_id,omitempty" jsonschema:"Parent folder ID (default: root)"`
 }
@@ -582,7 +1158,10 @@ func registerCreateFolder(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "create_folder",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: "Create a new folder in Google Drive, optionally inside an existing folder.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createFolderInput) (*mcp.CallToolResult, any, error) {
@@ -599,9 +1178,9 @@ func registerCreateFolder(srv *server.Server, mgr *auth.Manager) {
 			folder.Parents = []string{input.FolderID}
 		}
 
-		created, err := svc.Files.Create(folder).Fields("id,name,webViewLink").Do()
+		created, err := svc.Files.Create(folder).Fields("id,name,webViewLink").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating folder: %w", err)
+			return nil, nil, gerrors.Wrap(err, "creating folder")
 		}
 
 		var sb strings.Builder
@@ -623,7 +1202,7 @@ func registerCreateFolder(srv *server.Server, mgr *auth.Manager) {
 // --- move_file ---
 
 type moveInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID   string `json:"file_id" jsonschema:"Google Drive file ID to move"`
 	FolderID string `json:"folder_id" jsonschema:"Destination folder ID"`
 }
@@ -632,7 +1211,10 @@ func registerMove(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "move_file",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: "Move a file to a different folder in Google Drive.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input moveInput) (*mcp.CallToolResult, any, error) {
@@ -642,9 +1224,9 @@ func registerMove(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// Get current parents to remove them.
-		file, err := svc.Files.Get(input.FileID).Fields("parents").Do()
+		file, err := svc.Files.Get(input.FileID).Fields("parents").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting file parents: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting file parents")
 		}
 
 		previousParents := strings.Join(file.Parents, ",")
@@ -652,9 +1234,9 @@ func registerMove(srv *server.Server, mgr *auth.Manager) {
 		updated, err := svc.Files.Update(input.FileID, &drive.File{}).
 			AddParents(input.FolderID).
 			RemoveParents(previousParents).
-			Fields("id,name,parents,webViewLink").Do()
+			Fields("id,name,parents,webViewLink").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("moving file: %w", err)
+			return nil, nil, gerrors.Wrap(err, "moving file")
 		}
 
 		return &mcp.CallToolResult{
@@ -669,7 +1251,7 @@ func registerMove(srv *server.Server, mgr *auth.Manager) {
 // --- copy_file ---
 
 type copyInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID   string `json:"file_id" jsonschema:"Google Drive file ID to copy"`
 	Name     string `json:"name,omitempty" jsonschema:"Name for the copy (default: 'Copy of <original>')"`
 	FolderID string `json:"folder_id,omitempty" jsonschema:"Destination folder ID for the copy (default: same folder)"`
@@ -679,7 +1261,10 @@ func registerCopy(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "copy_file",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: "Create a copy of a file in Google Drive, optionally with a new name or in a different folder.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input copyInput) (*mcp.CallToolResult, any, error) {
@@ -697,9 +1282,9 @@ func registerCopy(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		copied, err := svc.Files.Copy(input.FileID, copyFile).
-			Fields("id,name,mimeType,size,webViewLink").Do()
+			Fields("id,name,mimeType,size,webViewLink").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("copying file: %w", err)
+			return nil, nil, gerrors.Wrap(err, "copying file")
 		}
 
 		var sb strings.Builder
@@ -757,20 +1342,83 @@ func defaultExportMIME(mimeType string) string {
 // formatFileList formats a list of Drive files for display.
 // The account parameter is included in each file entry for multi-account context.
 func formatFileList(files []*drive.File, account string) string {
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "Found %d files:\n\n", len(files))
+	out := server.NewOutputBuilder(0)
+	writeFileList(out, files, account)
+	return out.String()
+}
+
+// writeFileList writes a file list to out, one item per file.
+func writeFileList(out *server.OutputBuilder, files []*drive.File, account string) {
+	fmt.Fprintf(out, "Found %d files:\n\n", len(files))
 	for _, f := range files {
-		fmt.Fprintf(&sb, "- Name: %s\n  File ID: %s\n  Account: %s\n  Type: %s\n", f.Name, f.Id, account, f.MimeType)
-		if f.Size > 0 {
-			fmt.Fprintf(&sb, "  Size: %d bytes\n", f.Size)
-		}
-		if f.ModifiedTime != "" {
-			fmt.Fprintf(&sb, "  Modified: %s\n", f.ModifiedTime)
+		var sb strings.Builder
+		writeFileEntry(&sb, f, account)
+		sb.WriteString("\n")
+		if !out.AddItem(sb.String()) {
+			return
 		}
-		if f.WebViewLink != "" {
-			fmt.Fprintf(&sb, "  Link: %s\n", f.WebViewLink)
+	}
+}
+
+// fileRow is a file listed by search_files or list_files in json or csv
+// format. Path and Score are only set when their column is shown.
+type fileRow struct {
+	Account string
+	File    *drive.File
+	Path    string
+	Score   float64
+}
+
+// fileColumns are the json and csv columns of search_files and list_files.
+var fileColumns = []server.Column[fileRow]{
+	{Name: "account", Value: func(r fileRow) any { return r.Account }},
+	{Name: "file_id", Value: func(r fileRow) any { return r.File.Id }},
+	{Name: "name", Value: func(r fileRow) any { return r.File.Name }},
+	{Name: "mime_type", Value: func(r fileRow) any { return r.File.MimeType }},
+	{Name: "size", Value: func(r fileRow) any { return r.File.Size }},
+	{Name: "modified", Value: func(r fileRow) any { return r.File.ModifiedTime }},
+	{Name: "owners", Value: func(r fileRow) any { return fileOwners(r.File) }},
+	{Name: "link", Value: func(r fileRow) any { return r.File.WebViewLink }},
+	{Name: "shortcut_to", Value: func(r fileRow) any {
+		if isShortcut(r.File) {
+			return r.File.ShortcutDetails.TargetId
 		}
-		sb.WriteString("\n")
+		return ""
+	}},
+}
+
+var (
+	filePathColumn   = server.Column[fileRow]{Name: "path", Value: func(r fileRow) any { return r.Path }}
+	fileScoreColumn  = server.Column[fileRow]{Name: "score", Value: func(r fileRow) any { return math.Round(r.Score*1000) / 1000 }}
+	fileViewedColumn = server.Column[fileRow]{Name: "viewed_by_me", Value: func(r fileRow) any { return r.File.ViewedByMeTime }}
+)
+
+// fileOwners returns the display names of a file's owners.
+func fileOwners(f *drive.File) []string {
+	owners := make([]string, 0, len(f.Owners))
+	for _, o := range f.Owners {
+		owners = append(owners, o.DisplayName)
+	}
+	return owners
+}
+
+// writeFileEntry writes the list entry for a single file, without the
+// trailing blank line.
+func writeFileEntry(sb *strings.Builder, f *drive.File, account string) {
+	fmt.Fprintf(sb, "- Name: %s\n  File ID: %s\n  Account: %s\n  Type: %s\n", f.Name, f.Id, account, f.MimeType)
+	if isShortcut(f) {
+		fmt.Fprintf(sb, "  Shortcut to: %s\n", f.ShortcutDetails.TargetId)
+	}
+	if f.Size > 0 {
+		fmt.Fprintf(sb, "  Size: %d bytes\n", f.Size)
+	}
+	if f.ModifiedTime != "" {
+		fmt.Fprintf(sb, "  Modified: %s\n", f.ModifiedTime)
+	}
+	if f.ViewedByMeTime != "" {
+		fmt.Fprintf(sb, "  Viewed by me: %s\n", f.ViewedByMeTime)
+	}
+	if f.WebViewLink != "" {
+		fmt.Fprintf(sb, "  Link: %s\n", f.WebViewLink)
 	}
-	return sb.String()
 }
@@ -0,0 +1,228 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
+// maxFolderPathSegments caps the number of folders create_folder_path
+// walks.
+const maxFolderPathSegments = 50
+
+// --- create_folder_path ---
+
+type createFolderPathInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Path     string `json:"path" jsonschema:"Slash-separated folder path to create (e.g. 'Projects/2024/Q3/Reports'). Write \\/ for a slash inside a folder name and \\\\ for a backslash."`
+	FolderID string `json:"folder_id,omitempty" jsonschema:"Folder the path starts from (default: root)"`
+}
+
+// folderPathSegment is a folder of a path walked by create_folder_path.
+type folderPathSegment struct {
+	Name    string
+	ID      string
+	Link    string
+	Created bool
+	// Matches counts the existing folders with the name, when there were
+	// several to choose from.
+	Matches int
+}
+
+func registerCreateFolderPath(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "create_folder_path",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: `Create a nested folder path in Google Drive in one call, like mkdir -p, and return the ID of the last folder.
+
+The path is walked one folder at a time from folder_id (default: root). A folder that already exists under its parent with the same name (and isn't trashed) is reused; the missing ones are created. Calling it again with the same path creates nothing. When several folders have the same name, the oldest is used.
+
+Folder names in the path are separated by "/". To put a slash inside a name, write it as \/ (e.g. "Clients/A\/B Testing" is the folder "A/B Testing" in "Clients"); write a backslash as \\. Leading, trailing and repeated slashes are ignored.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input createFolderPathInput) (*mcp.CallToolResult, any, error) {
+		names, err := splitFolderPath(input.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		root := input.FolderID
+		if root == "" {
+			root = "root"
+		}
+		segments, err := ensureFolderPath(ctx, svc, root, names)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatFolderPath(names, segments)},
+			},
+		}, nil, nil
+	})
+}
+
+// splitFolderPath splits a create_folder_path path into folder names,
+// undoing the \/ and \\ escapes. Empty names, from leading, trailing or
+// repeated slashes, are dropped.
+func splitFolderPath(path string) ([]string, error) {
+	var names []string
+	var name strings.Builder
+	flush := func() {
+		if n := strings.TrimSpace(name.String()); n != "" {
+			names = append(names, n)
+		}
+		name.Reset()
+	}
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '/':
+			flush()
+		case '\\':
+			if i+1 == len(path) || (path[i+1] != '/' && path[i+1] != '\\') {
+				return nil, fmt.Errorf("invalid path %q: a backslash must be followed by / or \\", path)
+			}
+			i++
+			name.WriteByte(path[i])
+		default:
+			name.WriteByte(c)
+		}
+	}
+	flush()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("path is required")
+	}
+	if len(names) > maxFolderPathSegments {
+		return nil, fmt.Errorf("path has %d folders, more than the limit of %d", len(names), maxFolderPathSegments)
+	}
+	return names, nil
+}
+
+// ensureFolderPath walks names from the folder root, reusing the existing
+// folder with each name under the current parent and creating the missing
+// ones. Once a folder has been created, its subfolders can't exist yet, so
+// the rest are created without looking them up. On error, the result
+// lists the folders walked so far.
+func ensureFolderPath(ctx context.Context, svc *drive.Service, root string, names []string) ([]folderPathSegment, error) {
+	var segments []folderPathSegment
+	parent := root
+	creating := false
+	for i, name := range names {
+		if !creating {
+			seg, found, err := findChildFolder(ctx, svc, parent, name)
+			if err != nil {
+				return segments, fmt.Errorf("%w%s", gerrors.Wrapf(err, "looking up folder %q (%d of %d)", name, i+1, len(names)), formatWalkedSegments(segments))
+			}
+			if found {
+				segments = append(segments, seg)
+				parent = seg.ID
+				continue
+			}
+			creating = true
+		}
+		folder := &drive.File{Name: name, MimeType: folderMIME, Parents: []string{parent}}
+		created, err := svc.Files.Create(folder).Fields("id,name,webViewLink").SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return segments, fmt.Errorf("%w%s", gerrors.Wrapf(err, "creating folder %q (%d of %d)", name, i+1, len(names)), formatWalkedSegments(segments))
+		}
+		segments = append(segments, folderPathSegment{Name: name, ID: created.Id, Link: created.WebViewLink, Created: true})
+		parent = created.Id
+	}
+	return segments, nil
+}
+
+// findChildFolder looks up the folder named name in parent, preferring the
+// oldest if there are several.
+func findChildFolder(ctx context.Context, svc *drive.Service, parent, name string) (folderPathSegment, bool, error) {
+	q := fmt.Sprintf("name = %s and %s in parents and mimeType = '%s' and trashed = false",
+		driveQuote(name), driveQuote(parent), folderMIME)
+	resp, err := svc.Files.List().Q(q).
+		OrderBy("createdTime").
+		PageSize(10).
+		Fields("files(id,name,webViewLink)").
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Context(ctx).Do()
+	if err != nil {
+		return folderPathSegment{}, false, err
+	}
+	if len(resp.Files) == 0 {
+		return folderPathSegment{}, false, nil
+	}
+	f := resp.Files[0]
+	seg := folderPathSegment{Name: name, ID: f.Id, Link: f.WebViewLink}
+	if len(resp.Files) > 1 {
+		seg.Matches = len(resp.Files)
+	}
+	return seg, true, nil
+}
+
+// formatFolderPath formats the create_folder_path result.
+func formatFolderPath(names []string, segments []folderPathSegment) string {
+	last := segments[len(segments)-1]
+	created := 0
+	for _, s := range segments {
+		if s.Created {
+			created++
+		}
+	}
+
+	var sb strings.Builder
+	switch created {
+	case 0:
+		sb.WriteString("Folder path already exists.\n\n")
+	default:
+		fmt.Fprintf(&sb, "Folder path ready (%d of %d folders created).\n\n", created, len(segments))
+	}
+	fmt.Fprintf(&sb, "Path: %s\n", strings.Join(names, " / "))
+	fmt.Fprintf(&sb, "Folder ID: %s\n", last.ID)
+	if last.Link != "" {
+		fmt.Fprintf(&sb, "Link: %s\n", last.Link)
+	}
+	sb.WriteString("\nFolders:\n")
+	for _, s := range segments {
+		sb.WriteString(formatFolderPathSegment(s))
+	}
+	return sb.String()
+}
+
+// formatWalkedSegments lists the folders walked before an error, or
+// returns "" if there are none.
+func formatWalkedSegments(segments []folderPathSegment) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nFolders walked before the error:\n")
+	for _, s := range segments {
+		sb.WriteString(formatFolderPathSegment(s))
+	}
+	return sb.String()
+}
+
+func formatFolderPathSegment(s folderPathSegment) string {
+	status := "existing"
+	if s.Created {
+		status = "created"
+	}
+	line := fmt.Sprintf("  - %s: %s (%s)", s.Name, status, s.ID)
+	if s.Matches > 1 {
+		line += fmt.Sprintf(" — %d folders have this name, used the oldest", s.Matches)
+	}
+	return line + "\n"
+}
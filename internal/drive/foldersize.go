@@ -0,0 +1,246 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Defaults and limits of folder_size's max_files and max_depth.
+const (
+	defaultFolderSizeFiles = 10000
+	maxFolderSizeFiles     = 100000
+	defaultFolderSizeDepth = 20
+	maxFolderSizeDepth     = 100
+)
+
+// folderSizeFields are the file fields folder_size requests.
+const folderSizeFields = "nextPageToken,files(id,name,mimeType,size)"
+
+// largestFilesShown is the number of largest files folder_size lists.
+const largestFilesShown = 10
+
+// --- folder_size ---
+
+type folderSizeInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FolderID string `json:"folder_id" jsonschema:"Folder ID to measure, including its subfolders"`
+	MaxFiles int    `json:"max_files,omitempty" jsonschema:"Stop after this many files (default 10000, max 100000)"`
+	MaxDepth int    `json:"max_depth,omitempty" jsonschema:"Levels of subfolders to descend into (default 20, max 100)"`
+}
+
+// folderSize is the result of walking a folder tree for folder_size.
+type folderSize struct {
+	// Bytes sums the sizes of the files with stored content.
+	Bytes int64
+	Files int
+	// WorkspaceFiles counts Google Docs, Sheets, shortcuts and other files
+	// that have no stored size.
+	WorkspaceFiles int
+	// Folders counts the subfolders walked.
+	Folders int
+	// Largest are the largest files, largest first.
+	Largest []*drive.File
+	// FilesCapped is set when the walk stopped at max_files.
+	FilesCapped bool
+	// SkippedFolders counts the subfolders below max_depth, not walked.
+	SkippedFolders int
+}
+
+func registerFolderSize(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "folder_size",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: `Compute the total size of a Drive folder with all its subfolders, which Drive doesn't report for folders.
+
+Reports the total bytes, the number of files and subfolders, and the 10 largest files. Google Docs, Sheets and other Workspace files have no stored size and are counted separately. The walk stops at max_files files or max_depth levels of subfolders; the result then says it is partial.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input folderSizeInput) (*mcp.CallToolResult, any, error) {
+		if input.FolderID == "" {
+			return nil, nil, fmt.Errorf("folder_id is required")
+		}
+		maxFiles := input.MaxFiles
+		if maxFiles <= 0 {
+			maxFiles = defaultFolderSizeFiles
+		}
+		if maxFiles > maxFolderSizeFiles {
+			maxFiles = maxFolderSizeFiles
+		}
+		maxDepth := input.MaxDepth
+		if maxDepth <= 0 {
+			maxDepth = defaultFolderSizeDepth
+		}
+		if maxDepth > maxFolderSizeDepth {
+			maxDepth = maxFolderSizeDepth
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		folder, err := svc.Files.Get(input.FolderID).Fields("id,name,mimeType").SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "getting folder")
+		}
+		if folder.MimeType != folderMIME {
+			return nil, nil, fmt.Errorf("%q is not a folder (%s)", folder.Name, folder.MimeType)
+		}
+
+		size, err := walkFolderSize(ctx, svc, folder.Id, maxDepth, maxFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatFolderSize(folder, size, maxFiles, maxDepth)},
+			},
+		}, nil, nil
+	})
+}
+
+// walkFolderSize sums the sizes of the files in the folder tree under
+// folderID. Subfolders more than maxDepth levels down are counted but not
+// walked, and the walk stops after maxFiles files.
+func walkFolderSize(ctx context.Context, svc *drive.Service, folderID string, maxDepth, maxFiles int) (folderSize, error) {
+	var size folderSize
+	var files []*drive.File
+	walk, err := walkFolderTree(ctx, svc, folderID, folderSizeFields, maxDepth, func(f *drive.File) bool {
+		if size.Files+size.WorkspaceFiles == maxFiles {
+			size.FilesCapped = true
+			return false
+		}
+		if strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+			size.WorkspaceFiles++
+			return true
+		}
+		size.Files++
+		size.Bytes += f.Size
+		files = append(files, f)
+		return true
+	})
+	size.Folders = walk.Folders
+	size.SkippedFolders = walk.SkippedFolders
+	if err != nil {
+		return size, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	size.Largest = files[:min(len(files), largestFilesShown)]
+	return size, nil
+}
+
+// folderWalk counts the subfolders seen by walkFolderTree.
+type folderWalk struct {
+	// Folders counts the subfolders walked, not including the top one.
+	Folders int
+	// SkippedFolders counts the subfolders below maxDepth, not walked.
+	SkippedFolders int
+}
+
+// walkFolderTree lists the folder tree under folderID breadth-first with a
+// queue, so deep trees don't grow the stack, and calls visit for each file
+// that isn't a folder until it returns false. fields is the Files.List
+// field mask and must include the files' id and mimeType. Subfolders more
+// than maxDepth levels down are counted but not walked; maxDepth 0 walks
+// every level. Folders reachable by more than one path are walked once.
+func walkFolderTree(ctx context.Context, svc *drive.Service, folderID, fields string, maxDepth int, visit func(*drive.File) bool) (folderWalk, error) {
+	type queued struct {
+		id    string
+		depth int
+	}
+	var walk folderWalk
+	queue := []queued{{folderID, 0}}
+	seen := map[string]bool{folderID: true}
+
+	for len(queue) > 0 {
+		folder := queue[0]
+		queue = queue[1:]
+		pageToken := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				return walk, err
+			}
+			call := svc.Files.List().
+				Q(driveQuote(folder.id) + " in parents and trashed = false").
+				PageSize(1000).
+				Fields(googleapi.Field(fields)).
+				SupportsAllDrives(true).
+				IncludeItemsFromAllDrives(true).
+				Context(ctx)
+			if pageToken != "" {
+				call = call.PageToken(pageToken)
+			}
+			resp, err := call.Do()
+			if err != nil {
+				return walk, gerrors.Wrapf(err, "listing folder %s", folder.id)
+			}
+			for _, f := range resp.Files {
+				if f.MimeType != folderMIME {
+					if !visit(f) {
+						return walk, nil
+					}
+					continue
+				}
+				switch {
+				case seen[f.Id]:
+				case maxDepth > 0 && folder.depth >= maxDepth:
+					seen[f.Id] = true
+					walk.SkippedFolders++
+				default:
+					seen[f.Id] = true
+					walk.Folders++
+					queue = append(queue, queued{f.Id, folder.depth + 1})
+				}
+			}
+			if resp.NextPageToken == "" {
+				break
+			}
+			pageToken = resp.NextPageToken
+		}
+	}
+	return walk, nil
+}
+
+// formatFolderSize formats the folder_size result for folder.
+func formatFolderSize(folder *drive.File, size folderSize, maxFiles, maxDepth int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Folder: %s\nFolder ID: %s\n\n", folder.Name, folder.Id)
+	fmt.Fprintf(&sb, "Total size: %s\n", formatBytes(size.Bytes))
+	fmt.Fprintf(&sb, "Files: %d\n", size.Files)
+	if size.WorkspaceFiles > 0 {
+		fmt.Fprintf(&sb, "Google Workspace files: %d (no stored size, not included in the total)\n", size.WorkspaceFiles)
+	}
+	fmt.Fprintf(&sb, "Subfolders: %d\n", size.Folders)
+
+	if size.FilesCapped || size.SkippedFolders > 0 {
+		sb.WriteString("\nPartial result:\n")
+		if size.FilesCapped {
+			fmt.Fprintf(&sb, "  - Stopped after %d files; raise max_files (up to %d) to count the rest.\n", maxFiles, maxFolderSizeFiles)
+		}
+		if size.SkippedFolders > 0 {
+			fmt.Fprintf(&sb, "  - %d subfolders more than %d levels deep were not scanned; raise max_depth to include them.\n", size.SkippedFolders, maxDepth)
+		}
+	}
+
+	if len(size.Largest) > 0 {
+		sb.WriteString("\nLargest files:\n")
+		for i, f := range size.Largest {
+			fmt.Fprintf(&sb, "%d. %s — %s\n   File ID: %s\n", i+1, f.Name, formatBytes(f.Size), f.Id)
+		}
+	}
+	return sb.String()
+}
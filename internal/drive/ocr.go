@@ -0,0 +1,175 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
+// maxExtractedText is the amount of extracted text extract_text returns in
+// the conversation.
+const maxExtractedText = 512 * 1024
+
+// ocrCleanupTimeout bounds the deletion of the temporary Doc, which runs
+// even when the tool call's context is done.
+const ocrCleanupTimeout = 30 * time.Second
+
+// --- extract_text ---
+
+type extractTextInput struct {
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID      string `json:"file_id" jsonschema:"Google Drive file ID of the image or PDF"`
+	OCRLanguage string `json:"ocr_language,omitempty" jsonschema:"ISO 639-1 code of the text's language (e.g. 'de', 'ja'), to improve recognition. Default: detected by Drive"`
+	SaveTo      string `json:"save_to,omitempty" jsonschema:"Save the text to a local file instead of returning it (path relative to an allowed directory). Requires --allow-write-dir."`
+}
+
+func registerExtractText(srv *server.Server, mgr *auth.Manager) {
+	desc := `Extract the text of a scanned PDF or an image (JPEG, PNG, GIF and other formats Drive imports) with Drive's OCR.
+
+read_file returns such files as binary data. extract_text copies the file as a Google Doc, which makes Drive recognize its text, exports the Doc as plain text and deletes it again. If deleting the temporary Doc fails, the result gives its ID so it can be removed with delete_file.
+
+The text is returned in the conversation, truncated at 512 KB, or written to a local file with save_to. Layout, tables and images are not kept, and recognition quality depends on the scan; set ocr_language when the text isn't in English. For Google Docs, the text is exported directly.` + srv.WriteDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "extract_text",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input extractTextInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		file, err := svc.Files.Get(input.FileID).Fields("id,name,mimeType").SupportsAllDrives(true).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, srv.CrossAccountHint(ctx, mgr, input.Account, "file "+input.FileID, gerrors.Wrap(err, "getting file metadata"), fileProbe(mgr, input.FileID))
+		}
+		if isGoogleWorkspaceFile(file.MimeType) && file.MimeType != googleDocMIME {
+			return nil, nil, fmt.Errorf("%s is a %s file; use read_file with export_mime_type to read it", file.Name, file.MimeType)
+		}
+
+		if input.SaveTo != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
+			}
+			var n int64
+			var dir string
+			note, err := withExtractedText(ctx, svc, file, input.OCRLanguage, func(text io.Reader) error {
+				f, d, err := lfs.CreateFile(input.SaveTo)
+				if err != nil {
+					return fmt.Errorf("saving text: %w", err)
+				}
+				defer f.Close()
+				dir = d
+				n, err = io.Copy(f, text)
+				if err == nil {
+					err = f.Commit()
+				}
+				if err != nil {
+					return fmt.Errorf("saving text to %s/%s (stopped after %d bytes; nothing was saved): %w", dir, input.SaveTo, n, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w%s", err, note)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Text extracted and saved to local disk.\n\nFile: %s (%s)\nSize: %d bytes\nSaved to: %s/%s%s",
+						file.Name, file.MimeType, n, dir, input.SaveTo, note)},
+				},
+			}, nil, nil
+		}
+
+		var data []byte
+		note, err := withExtractedText(ctx, svc, file, input.OCRLanguage, func(text io.Reader) error {
+			var err error
+			data, err = io.ReadAll(io.LimitReader(text, maxExtractedText+1))
+			if err != nil {
+				return fmt.Errorf("reading extracted text: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w%s", err, note)
+		}
+		suffix := ""
+		if len(data) > maxExtractedText {
+			data = data[:maxExtractedText]
+			suffix = "\n\n[Text truncated at 512 KB; use save_to for the full text]"
+		}
+		text := strings.TrimPrefix(string(data), "\ufeff")
+		if strings.TrimSpace(text) == "" {
+			text = "(no text recognized)"
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("File: %s (%s)\n\n%s%s%s", file.Name, file.MimeType, text, suffix, note)},
+			},
+		}, nil, nil
+	}, server.LocalWriteParams("save_to"))
+}
+
+// withExtractedText calls fn with the plain text of file. A Google Doc is
+// exported directly; any other file is first copied as a Google Doc, which
+// makes Drive run OCR on images and PDFs in language (if set), and the copy
+// is deleted once fn returns, whether or not the export succeeded. If the
+// deletion fails, note names the temporary Doc so it can be removed by
+// hand; it is meant to be appended to the result or the error.
+func withExtractedText(ctx context.Context, svc *drive.Service, file *drive.File, language string, fn func(io.Reader) error) (note string, err error) {
+	docID := file.Id
+	if file.MimeType != googleDocMIME {
+		call := svc.Files.Copy(file.Id, &drive.File{
+			Name:     "OCR of " + file.Name,
+			MimeType: googleDocMIME,
+		}).Fields("id").SupportsAllDrives(true).Context(ctx)
+		if language != "" {
+			call = call.OcrLanguage(language)
+		}
+		copied, err := call.Context(ctx).Do()
+		if err != nil {
+			return "", gerrors.Wrapf(err, "converting %s to a Google Doc for text recognition", file.MimeType)
+		}
+		docID = copied.Id
+		defer func() {
+			note = deleteOCRCopy(ctx, svc, docID)
+		}()
+	}
+
+	resp, err := svc.Files.Export(docID, "text/plain").Context(ctx).Download()
+	if err != nil {
+		return "", gerrors.Wrap(err, "exporting text")
+	}
+	defer resp.Body.Close()
+	return "", fn(resp.Body)
+}
+
+// deleteOCRCopy deletes the temporary Doc id made by withExtractedText,
+// returning a note naming it if that fails. It runs on its own deadline so
+// that a call that timed out still cleans up.
+func deleteOCRCopy(ctx context.Context, svc *drive.Service, id string) string {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ocrCleanupTimeout)
+	defer cancel()
+	if err := svc.Files.Delete(id).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+		return fmt.Sprintf("\n\nWarning: the temporary Google Doc %s could not be deleted (%v). Delete it with delete_file.", id, gerrors.Translate(err))
+	}
+	return ""
+}
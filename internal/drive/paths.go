@@ -0,0 +1,108 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// maxPathDepth caps how many ancestors pathResolver follows, so folder
+// graphs with cycles (possible with odd sharing setups) can't loop.
+const maxPathDepth = 20
+
+// pathResolver builds a file's folder path by walking its parents up to the
+// root. Folders are cached for the lifetime of the resolver, so resolving
+// many files in the same folder costs one Get per distinct ancestor.
+type pathResolver struct {
+	getFolder func(id string) (*drive.File, error)
+	folders   map[string]*drive.File
+	rootID    string
+}
+
+func newPathResolver(ctx context.Context, svc *drive.Service) *pathResolver {
+	return &pathResolver{
+		getFolder: func(id string) (*drive.File, error) {
+			return svc.Files.Get(id).Fields("id,name,parents").SupportsAllDrives(true).Context(ctx).Do()
+		},
+		folders: make(map[string]*drive.File),
+	}
+}
+
+// folder returns the folder with the given ID, from the cache if possible.
+func (r *pathResolver) folder(id string) (*drive.File, error) {
+	if f, ok := r.folders[id]; ok {
+		return f, nil
+	}
+	f, err := r.getFolder(id)
+	if err != nil {
+		return nil, err
+	}
+	r.folders[id] = f
+	return f, nil
+}
+
+// isMyDriveRoot reports whether id is the root folder of My Drive.
+func (r *pathResolver) isMyDriveRoot(id string) bool {
+	if r.rootID == "" {
+		root, err := r.folder("root")
+		if err != nil {
+			return false
+		}
+		r.rootID = root.Id
+	}
+	return id == r.rootID
+}
+
+// path returns the path of f, including its own name. Files in My Drive
+// get an absolute path ("/Projects/2024/report.pdf"); files elsewhere start
+// at their top-most visible folder, such as a shared drive
+// ("Marketing/Reports/report.pdf"). When the walk stops early because a
+// parent is inaccessible or the depth cap is hit, the path starts with
+// "…/".
+func (r *pathResolver) path(f *drive.File) string {
+	names := []string{f.Name}
+	parents := f.Parents
+	seen := map[string]bool{f.Id: true}
+	partial := false
+	for depth := 0; len(parents) > 0; depth++ {
+		if depth == maxPathDepth || seen[parents[0]] {
+			partial = true
+			break
+		}
+		seen[parents[0]] = true
+		parent, err := r.folder(parents[0])
+		if err != nil {
+			partial = true
+			break
+		}
+		if len(parent.Parents) == 0 && r.isMyDriveRoot(parent.Id) {
+			slices.Reverse(names)
+			return "/" + strings.Join(names, "/")
+		}
+		names = append(names, parent.Name)
+		parents = parent.Parents
+	}
+	slices.Reverse(names)
+	if partial {
+		return "…/" + strings.Join(names, "/")
+	}
+	if len(names) == 1 && r.isMyDriveRoot(f.Id) {
+		return "/"
+	}
+	return strings.Join(names, "/")
+}
+
+// formatFileListWithPaths formats files like formatFileList, adding each
+// file's path.
+func formatFileListWithPaths(files []*drive.File, account string, paths *pathResolver) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d files:\n\n", len(files))
+	for _, f := range files {
+		writeFileEntry(&sb, f, account)
+		fmt.Fprintf(&sb, "  Path: %s\n\n", paths.path(f))
+	}
+	return sb.String()
+}
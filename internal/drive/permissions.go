@@ -7,6 +7,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/drive/v3"
 )
@@ -14,7 +15,7 @@ import (
 // --- list_permissions ---
 
 type listPermissionsInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID  string `json:"file_id" jsonschema:"Google Drive file or folder ID"`
 }
 
@@ -23,7 +24,10 @@ func registerListPermissions(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_permissions",
 		Description: "List all permissions (sharing settings) for a Google Drive file or folder. Shows who has access and their role.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listPermissionsInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
@@ -37,10 +41,10 @@ func registerListPermissions(srv *server.Server, mgr *auth.Manager) {
 
 		resp, err := svc.Permissions.List(input.FileID).
 			SupportsAllDrives(true).
-			Fields("permissions(id,role,type,emailAddress,domain,displayName,expirationTime,deleted)").
+			Fields("permissions(id,role,type,emailAddress,domain,displayName,expirationTime,deleted)").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing permissions: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing permissions")
 		}
 
 		if len(resp.Permissions) == 0 {
@@ -69,7 +73,7 @@ func registerListPermissions(srv *server.Server, mgr *auth.Manager) {
 // --- get_permission ---
 
 type getPermissionInput struct {
-	Account      string `json:"account" jsonschema:"Account name"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID       string `json:"file_id" jsonschema:"Google Drive file or folder ID"`
 	PermissionID string `json:"permission_id" jsonschema:"Permission ID to inspect"`
 }
@@ -79,7 +83,10 @@ func registerGetPermission(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_permission",
 		Description: "Get details of a specific permission on a Google Drive file or folder.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getPermissionInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
@@ -96,10 +103,10 @@ func registerGetPermission(srv *server.Server, mgr *auth.Manager) {
 
 		perm, err := svc.Permissions.Get(input.FileID, input.PermissionID).
 			SupportsAllDrives(true).
-			Fields("id,role,type,emailAddress,domain,displayName,expirationTime,deleted,permissionDetails").
+			Fields("id,role,type,emailAddress,domain,displayName,expirationTime,deleted,permissionDetails").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting permission: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting permission")
 		}
 
 		return &mcp.CallToolResult{
@@ -113,7 +120,7 @@ func registerGetPermission(srv *server.Server, mgr *auth.Manager) {
 // --- update_permission ---
 
 type updatePermissionInput struct {
-	Account      string `json:"account" jsonschema:"Account name"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID       string `json:"file_id" jsonschema:"Google Drive file or folder ID"`
 	PermissionID string `json:"permission_id" jsonschema:"Permission ID to update"`
 	Role         string `json:"role" jsonschema:"New role: 'reader', 'commenter', 'writer', or 'organizer'"`
@@ -128,9 +135,13 @@ Roles:
   - "reader" — View only
   - "commenter" — View and comment
   - "writer" — Edit
-  - "organizer" — Manage (shared drives only)`,
+  - "organizer" — Manage (shared drives only)
+  - "owner" — Transfer ownership to a user (My Drive items only). You keep writer access. Where Google requires the new owner's consent, as for consumer accounts, the user is made a pending owner and becomes the owner once they accept.`,
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updatePermissionInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
@@ -157,10 +168,10 @@ Roles:
 
 		updated, err := svc.Permissions.Update(input.FileID, input.PermissionID, perm).
 			SupportsAllDrives(true).
-			Fields("id,role,type,emailAddress,domain,displayName").
+			Fields("id,role,type,emailAddress,domain,displayName").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating permission: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating permission")
 		}
 
 		var sb strings.Builder
@@ -178,7 +189,7 @@ Roles:
 // --- delete_permission ---
 
 type deletePermissionInput struct {
-	Account      string `json:"account" jsonschema:"Account name"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID       string `json:"file_id" jsonschema:"Google Drive file or folder ID"`
 	PermissionID string `json:"permission_id" jsonschema:"Permission ID to delete (revoke access)"`
 }
@@ -187,7 +198,12 @@ func registerDeletePermission(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_permission",
 		Description: "Delete a permission from a Google Drive file or folder, revoking access for that user, group, or domain.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deletePermissionInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
 			return nil, nil, fmt.Errorf("file_id is required")
@@ -202,9 +218,9 @@ func registerDeletePermission(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		if err := svc.Permissions.Delete(input.FileID, input.PermissionID).
-			SupportsAllDrives(true).
+			SupportsAllDrives(true).Context(ctx).
 			Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting permission: %w", err)
+			return nil, nil, gerrors.Wrap(err, "deleting permission")
 		}
 
 		return &mcp.CallToolResult{
@@ -218,13 +234,13 @@ func registerDeletePermission(srv *server.Server, mgr *auth.Manager) {
 // --- share_file ---
 
 type shareInput struct {
-	Account      string `json:"account" jsonschema:"Account name"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID       string `json:"file_id" jsonschema:"Google Drive file ID to share"`
 	EmailAddress string `json:"email_address,omitempty" jsonschema:"Email address to share with (required for 'user' and 'group' types)"`
-	Role         string `json:"role" jsonschema:"Permission role: 'reader', 'commenter', 'writer', or 'organizer'"`
+	Role         string `json:"role" jsonschema:"Permission role: 'reader', 'commenter', 'writer', 'organizer', or 'owner' (transfers ownership; type must be 'user')"`
 	Type         string `json:"type" jsonschema:"Permission type: 'user', 'group', 'domain', or 'anyone'"`
 	Domain       string `json:"domain,omitempty" jsonschema:"Domain to share with (required for 'domain' type)"`
-	SendEmail    bool   `json:"send_email,omitempty" jsonschema:"Send a notification email to the user (default: false)"`
+	SendEmail    bool   `json:"send_email,omitempty" jsonschema:"Send a notification email to the user (default: false; always sent for ownership transfers)"`
 	Message      string `json:"message,omitempty" jsonschema:"Custom message to include in the notification email"`
 }
 
@@ -232,8 +248,10 @@ func registerShare(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "share_file",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
 			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: `Share a Google Drive file by adding permissions.
 
@@ -249,25 +267,8 @@ Roles:
   - "writer" — Edit
   - "organizer" — Manage (shared drives only)`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input shareInput) (*mcp.CallToolResult, any, error) {
-		// Validate inputs.
-		switch input.Type {
-		case "user", "group":
-			if input.EmailAddress == "" {
-				return nil, nil, fmt.Errorf("email_address is required for type %q", input.Type)
-			}
-		case "domain":
-			if input.Domain == "" {
-				return nil, nil, fmt.Errorf("domain is required for type 'domain'")
-			}
-		case "anyone":
-		default:
-			return nil, nil, fmt.Errorf("invalid type %q: must be 'user', 'group', 'domain', or 'anyone'", input.Type)
-		}
-
-		switch input.Role {
-		case "reader", "commenter", "writer", "organizer":
-		default:
-			return nil, nil, fmt.Errorf("invalid role %q: must be 'reader', 'commenter', 'writer', or 'organizer'", input.Role)
+		if err := validateShareTarget(input.Type, input.Role, input.EmailAddress, input.Domain); err != nil {
+			return nil, nil, err
 		}
 
 		svc, err := newService(ctx, mgr, input.Account)
@@ -282,25 +283,26 @@ Roles:
 			Domain:       input.Domain,
 		}
 
-		call := svc.Permissions.Create(input.FileID, perm).
-			Fields("id,role,type,emailAddress,domain")
-
-		if input.SendEmail {
-			call = call.SendNotificationEmail(true)
-			if input.Message != "" {
-				call = call.EmailMessage(input.Message)
+		if perm.Role == "owner" {
+			if err := checkOwnershipTransfer(ctx, svc, input.FileID); err != nil {
+				return nil, nil, err
 			}
-		} else {
-			call = call.SendNotificationEmail(false)
 		}
 
-		created, err := call.Do()
+		created, err := createSharePermission(ctx, svc, input.FileID, perm, input.SendEmail, input.Message)
 		if err != nil {
-			return nil, nil, fmt.Errorf("sharing file: %w", err)
+			return nil, nil, gerrors.Wrap(err, "sharing file")
 		}
 
 		var sb strings.Builder
-		fmt.Fprintf(&sb, "File shared.\n\n")
+		switch {
+		case created.PendingOwner:
+			fmt.Fprintf(&sb, "Ownership transfer requested. %s was given writer access and must accept the transfer in Drive to become the owner; until then you remain the owner.\n\n", created.EmailAddress)
+		case created.Role == "owner":
+			fmt.Fprintf(&sb, "Ownership transferred. You keep writer access.\n\n")
+		default:
+			fmt.Fprintf(&sb, "File shared.\n\n")
+		}
 		fmt.Fprintf(&sb, "Permission ID: %s\n", created.Id)
 		fmt.Fprintf(&sb, "Role: %s\n", created.Role)
 		fmt.Fprintf(&sb, "Type: %s\n", created.Type)
@@ -319,6 +321,332 @@ Roles:
 	})
 }
 
+// sharePermissionFields is the Fields mask for permissions created by
+// share_file.
+const sharePermissionFields = "id,role,type,emailAddress,domain,pendingOwner"
+
+// consentRequiredReason is the error reason Drive returns when ownership
+// can't be transferred directly and the recipient has to accept it first.
+const consentRequiredReason = "consentRequiredForOwnershipTransfer"
+
+// checkOwnershipTransfer rejects ownership transfers of items in shared
+// drives, which are owned by the drive rather than by a user.
+func checkOwnershipTransfer(ctx context.Context, svc *drive.Service, fileID string) error {
+	file, err := svc.Files.Get(fileID).Fields("id,name,driveId").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return gerrors.Wrap(err, "getting file")
+	}
+	if file.DriveId != "" {
+		return fmt.Errorf("%q is in a shared drive, and items in shared drives are owned by the drive, not by a user, so ownership can't be transferred; share it with the 'organizer' role instead, or move it to My Drive first", file.Name)
+	}
+	return nil
+}
+
+// createSharePermission creates perm on fileID. An owner permission
+// transfers ownership; if Drive requires the recipient's consent, the user
+// is instead added as a writer with pendingOwner set, which offers them the
+// transfer, and the returned permission has PendingOwner set.
+func createSharePermission(ctx context.Context, svc *drive.Service, fileID string, perm *drive.Permission, notify bool, message string) (*drive.Permission, error) {
+	owner := perm.Role == "owner"
+	// Drive always notifies the new owner of a transfer.
+	notify = notify || owner
+
+	call := svc.Permissions.Create(fileID, perm).
+		Fields(sharePermissionFields).
+		SendNotificationEmail(notify)
+	if notify && message != "" {
+		call = call.EmailMessage(message)
+	}
+	if owner {
+		call = call.TransferOwnership(true)
+	}
+	created, err := call.Context(ctx).Do()
+	if err == nil || !owner || !gerrors.HasReason(err, consentRequiredReason) {
+		return created, err
+	}
+
+	pending := &drive.Permission{
+		Role:         "writer",
+		Type:         perm.Type,
+		EmailAddress: perm.EmailAddress,
+		PendingOwner: true,
+	}
+	call = svc.Permissions.Create(fileID, pending).
+		Fields(sharePermissionFields).
+		SendNotificationEmail(true)
+	if message != "" {
+		call = call.EmailMessage(message)
+	}
+	return call.Context(ctx).Do()
+}
+
+// --- copy_permissions ---
+
+type copyPermissionsInput struct {
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	SourceFileID string `json:"source_file_id" jsonschema:"File or folder ID to copy permissions from"`
+	TargetFileID string `json:"target_file_id" jsonschema:"File or folder ID to copy permissions to"`
+	DryRun       bool   `json:"dry_run,omitempty" jsonschema:"Only show which permissions would be added, without changing anything (default: false)"`
+	SendEmail    bool   `json:"send_email,omitempty" jsonschema:"Send notification emails to users and groups that are added (default: false)"`
+}
+
+func registerCopyPermissions(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "copy_permissions",
+		Description: `Copy the sharing settings of one Google Drive file or folder to another.
+
+Owner and inherited permissions on the source are never copied. Permissions the target already has are skipped; if the target grants the same user, group or domain a different role, it is reported as a conflict and left unchanged. Use dry_run to preview the changes first.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input copyPermissionsInput) (*mcp.CallToolResult, any, error) {
+		if input.SourceFileID == "" {
+			return nil, nil, fmt.Errorf("source_file_id is required")
+		}
+		if input.TargetFileID == "" {
+			return nil, nil, fmt.Errorf("target_file_id is required")
+		}
+		if input.SourceFileID == input.TargetFileID {
+			return nil, nil, fmt.Errorf("source_file_id and target_file_id must differ")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		source, err := listAllPermissions(ctx, svc, input.SourceFileID)
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing source permissions")
+		}
+		target, err := listAllPermissions(ctx, svc, input.TargetFileID)
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing target permissions")
+		}
+
+		diff := diffPermissions(source, target)
+
+		var sb strings.Builder
+		if input.DryRun {
+			sb.WriteString("Dry run: no permissions were changed.\n\n")
+		}
+
+		if len(diff.ToAdd) == 0 {
+			sb.WriteString("No permissions to add.\n")
+		} else if input.DryRun {
+			fmt.Fprintf(&sb, "Would add (%d):\n", len(diff.ToAdd))
+			for _, p := range diff.ToAdd {
+				fmt.Fprintf(&sb, "  + %s\n", describePermission(p))
+			}
+		} else {
+			added := 0
+			var results strings.Builder
+			for _, p := range diff.ToAdd {
+				perm := &drive.Permission{
+					Role:               p.Role,
+					Type:               p.Type,
+					EmailAddress:       p.EmailAddress,
+					Domain:             p.Domain,
+					AllowFileDiscovery: p.AllowFileDiscovery,
+				}
+				_, err := svc.Permissions.Create(input.TargetFileID, perm).
+					SupportsAllDrives(true).
+					SendNotificationEmail(input.SendEmail && (p.Type == "user" || p.Type == "group")).
+					Fields("id").Context(ctx).
+					Do()
+				if err != nil {
+					fmt.Fprintf(&results, "  ! %s: error: %v\n", describePermission(p), err)
+					continue
+				}
+				added++
+				fmt.Fprintf(&results, "  + %s: added\n", describePermission(p))
+			}
+			fmt.Fprintf(&sb, "Added %d of %d permissions:\n", added, len(diff.ToAdd))
+			sb.WriteString(results.String())
+		}
+
+		if len(diff.Conflicts) > 0 {
+			fmt.Fprintf(&sb, "\nRole conflicts, left unchanged (%d):\n", len(diff.Conflicts))
+			for _, c := range diff.Conflicts {
+				fmt.Fprintf(&sb, "  ~ %s (target has %s)\n", describePermission(c.Source), c.Target.Role)
+			}
+		}
+		if len(diff.Present) > 0 {
+			fmt.Fprintf(&sb, "\nAlready present (%d):\n", len(diff.Present))
+			for _, p := range diff.Present {
+				fmt.Fprintf(&sb, "  = %s\n", describePermission(p))
+			}
+		}
+		if len(diff.Skipped) > 0 {
+			fmt.Fprintf(&sb, "\nSkipped (%d):\n", len(diff.Skipped))
+			for _, s := range diff.Skipped {
+				fmt.Fprintf(&sb, "  - %s: %s\n", describePermission(s.Permission), s.Reason)
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// listAllPermissions fetches every permission on a file, following pages.
+func listAllPermissions(ctx context.Context, svc *drive.Service, fileID string) ([]*drive.Permission, error) {
+	var perms []*drive.Permission
+	pageToken := ""
+	for {
+		call := svc.Permissions.List(fileID).
+			SupportsAllDrives(true).
+			PageSize(100).
+			Fields("nextPageToken,permissions(id,role,type,emailAddress,domain,displayName,deleted,allowFileDiscovery,expirationTime,permissionDetails(inherited,inheritedFrom))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, resp.Permissions...)
+		if resp.NextPageToken == "" {
+			return perms, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// permissionDiff is the result of comparing source permissions against a
+// target's existing permissions.
+type permissionDiff struct {
+	ToAdd     []*drive.Permission  // missing on the target
+	Present   []*drive.Permission  // already granted with the same role
+	Conflicts []permissionConflict // granted on the target with a different role
+	Skipped   []skippedPermission  // never copied (owner, inherited, ...)
+}
+
+type permissionConflict struct {
+	Source, Target *drive.Permission
+}
+
+type skippedPermission struct {
+	Permission *drive.Permission
+	Reason     string
+}
+
+// diffPermissions determines which source permissions need to be created on
+// the target. Permissions are matched by grantee (type plus email or
+// domain) rather than by permission ID.
+func diffPermissions(source, target []*drive.Permission) permissionDiff {
+	existing := make(map[string]*drive.Permission, len(target))
+	for _, p := range target {
+		if !p.Deleted {
+			existing[permissionKey(p)] = p
+		}
+	}
+
+	var diff permissionDiff
+	seen := make(map[string]bool)
+	for _, p := range source {
+		if reason := skipReason(p); reason != "" {
+			diff.Skipped = append(diff.Skipped, skippedPermission{Permission: p, Reason: reason})
+			continue
+		}
+		key := permissionKey(p)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch t, ok := existing[key]; {
+		case !ok:
+			diff.ToAdd = append(diff.ToAdd, p)
+		case t.Role == p.Role:
+			diff.Present = append(diff.Present, p)
+		default:
+			diff.Conflicts = append(diff.Conflicts, permissionConflict{Source: p, Target: t})
+		}
+	}
+	return diff
+}
+
+// skipReason returns why a source permission must not be copied, or "" if
+// it may be.
+func skipReason(p *drive.Permission) string {
+	if p.Role == "owner" {
+		return "owner"
+	}
+	if p.Deleted {
+		return "deleted account"
+	}
+	for _, d := range p.PermissionDetails {
+		if d.Inherited {
+			return "inherited"
+		}
+	}
+	if err := validateShareTarget(p.Type, p.Role, p.EmailAddress, p.Domain); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// permissionKey identifies the grantee of a permission.
+func permissionKey(p *drive.Permission) string {
+	switch p.Type {
+	case "user", "group":
+		return p.Type + ":" + strings.ToLower(p.EmailAddress)
+	case "domain":
+		return p.Type + ":" + strings.ToLower(p.Domain)
+	default:
+		return p.Type
+	}
+}
+
+// describePermission renders a permission on one line, e.g.
+// "writer user alice@example.com".
+func describePermission(p *drive.Permission) string {
+	s := p.Role + " " + p.Type
+	switch {
+	case p.EmailAddress != "":
+		s += " " + p.EmailAddress
+	case p.Domain != "":
+		s += " " + p.Domain
+	}
+	return s
+}
+
+// validateShareTarget checks that a permission type/role combination is
+// valid and that the identifying field the type needs is present.
+func validateShareTarget(typ, role, email, domain string) error {
+	switch typ {
+	case "user", "group":
+		if email == "" {
+			return fmt.Errorf("email_address is required for type %q", typ)
+		}
+	case "domain":
+		if domain == "" {
+			return fmt.Errorf("domain is required for type 'domain'")
+		}
+	case "anyone":
+	default:
+		return fmt.Errorf("invalid type %q: must be 'user', 'group', 'domain', or 'anyone'", typ)
+	}
+
+	switch role {
+	case "reader", "commenter", "writer", "organizer":
+	case "owner":
+		if typ != "user" {
+			return fmt.Errorf("role 'owner' requires type 'user': ownership can only be transferred to a single user, not to type %q", typ)
+		}
+	default:
+		return fmt.Errorf("invalid role %q: must be 'reader', 'commenter', 'writer', 'organizer', or 'owner'", role)
+	}
+	return nil
+}
+
 // formatPermission formats a single permission for display.
 func formatPermission(p *drive.Permission) string {
 	var sb strings.Builder
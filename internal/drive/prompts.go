@@ -0,0 +1,31 @@
+package drive
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// registerPrompts registers the Drive prompt templates.
+func registerPrompts(srv *server.Server) {
+	server.AddPrompt(srv, server.PromptTemplate{
+		Name:        "organize_drive_folder",
+		Title:       "Organize a Drive folder",
+		Description: "Review the contents of a Drive folder and propose a structure, then create subfolders and move files after confirmation.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "folder", Description: "Folder ID, or the folder's name", Required: true},
+			{Name: "account", Description: "Account name (default: the default account)"},
+		},
+		Tools: []string{"search_files", "list_files"},
+		Template: `Organize the Google Drive folder "{{.folder}}"{{if .account}} of account "{{.account}}"{{end}}.
+
+1. If "{{.folder}}" isn't a folder ID, find the folder with search_files (name_contains "{{.folder}}", mime_type "application/vnd.google-apps.folder"). If several folders match, ask me which one.
+2. Call list_files with the folder_id and max_results 100 to see its contents, then list the subfolders the same way.
+3. Propose a structure: subfolders by project, type or date, and which files go where. Point out likely duplicates and files that look out of place.
+{{- if and (tool "create_folder") (tool "move_file")}}
+4. Once I approve the plan, create the missing subfolders with create_folder and move the files with move_file. Report each move with the file ID and its previous folder, so it can be undone.
+{{- else}}
+4. This server can't create folders or move files. Present the plan as a list of moves for me to make.
+{{- end}}
+`,
+	})
+}
@@ -0,0 +1,153 @@
+package drive
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
+// Weights of the ranking factors. Name match dominates; recency and
+// ownership break ties between similarly named files.
+const (
+	rankNameWeight    = 0.6
+	rankRecencyWeight = 0.3
+	rankOwnedWeight   = 0.1
+)
+
+// rankRecencyHalfLife is the file age at which the recency factor drops to 0.5.
+const rankRecencyHalfLife = 30 * 24 * time.Hour
+
+// rankTermPattern extracts the string literals of name and fullText clauses
+// from a Drive query, e.g. "name contains 'budget'".
+var rankTermPattern = regexp.MustCompile(`(?i)\b(?:name|fullText)\s*(?:contains|=)\s*'((?:[^'\\]|\\.)*)'`)
+
+// rankedFile is a search result with its relevance score.
+type rankedFile struct {
+	File    *drive.File
+	Score   float64
+	Name    float64 // name match proximity, 0..1
+	Recency float64 // 1 for just modified, 0.5 at rankRecencyHalfLife
+	Owned   bool
+}
+
+// rankTerms returns the lowercased search terms in a Drive query.
+func rankTerms(query string) []string {
+	var terms []string
+	for _, m := range rankTermPattern.FindAllStringSubmatch(query, -1) {
+		term := strings.ToLower(strings.NewReplacer(`\\`, `\`, `\'`, "'").Replace(m[1]))
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// nameScore rates how closely a file name matches the search terms:
+// 1 for an exact match (ignoring extension), 0.8 for a prefix, 0.6 for a
+// whole-word match, 0.4 for a substring, and 0 otherwise. Multiple terms
+// are averaged.
+func nameScore(name string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	lower := strings.ToLower(name)
+	base := lower
+	if i := strings.LastIndexByte(base, '.'); i > 0 {
+		base = base[:i]
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	total := 0.0
+	for _, term := range terms {
+		switch {
+		case lower == term || base == term:
+			total += 1
+		case strings.HasPrefix(lower, term):
+			total += 0.8
+		case containsWord(words, term):
+			total += 0.6
+		case strings.Contains(lower, term):
+			total += 0.4
+		}
+	}
+	return total / float64(len(terms))
+}
+
+func containsWord(words []string, term string) bool {
+	for _, w := range words {
+		if w == term {
+			return true
+		}
+	}
+	return false
+}
+
+// recencyScore decays from 1 towards 0 with the file's age. Files without
+// a parseable modification time score 0.
+func recencyScore(modified string, now time.Time) float64 {
+	t, err := time.Parse(time.RFC3339, modified)
+	if err != nil {
+		return 0
+	}
+	age := now.Sub(t)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(rankRecencyHalfLife))
+}
+
+// rankFiles scores files against the query and returns them best first.
+// Ties keep the order the API returned.
+func rankFiles(files []*drive.File, query string, now time.Time) []rankedFile {
+	terms := rankTerms(query)
+	ranked := make([]rankedFile, len(files))
+	for i, f := range files {
+		r := rankedFile{
+			File:    f,
+			Name:    nameScore(f.Name, terms),
+			Recency: recencyScore(f.ModifiedTime, now),
+			Owned:   f.OwnedByMe,
+		}
+		r.Score = rankNameWeight*r.Name + rankRecencyWeight*r.Recency
+		if r.Owned {
+			r.Score += rankOwnedWeight
+		}
+		ranked[i] = r
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	return ranked
+}
+
+// formatRankedFileList formats ranked search results, echoing the query and
+// the score factors of each file.
+func formatRankedFileList(ranked []rankedFile, query, account string) string {
+	out := server.NewOutputBuilder(0)
+	writeRankedFileList(out, ranked, query, account)
+	return out.String()
+}
+
+// writeRankedFileList writes a ranked file list to out, one item per file.
+func writeRankedFileList(out *server.OutputBuilder, ranked []rankedFile, query, account string) {
+	fmt.Fprintf(out, "Found %d files for query: %s\nRanked by name match, recency, and ownership (best first).\n\n", len(ranked), query)
+	for _, r := range ranked {
+		var sb strings.Builder
+		writeFileEntry(&sb, r.File, account)
+		owned := "no"
+		if r.Owned {
+			owned = "yes"
+		}
+		fmt.Fprintf(&sb, "  Score: %.2f (name match %.2f, recency %.2f, owned by me: %s)\n\n", r.Score, r.Name, r.Recency, owned)
+		if !out.AddItem(sb.String()) {
+			return
+		}
+	}
+}
@@ -1,5 +1,117 @@
 package drive
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
 // TODO: Planned reply tools (from api-coverage.md):
 // - list_replies (Replies.List)
-// - create_reply (Replies.Create)
+
+// --- reply_comment ---
+
+type replyCommentInput struct {
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID    string `json:"file_id" jsonschema:"Google Drive file ID"`
+	CommentID string `json:"comment_id" jsonschema:"Comment ID to reply to (from list_comments)"`
+	Content   string `json:"content" jsonschema:"Reply text"`
+}
+
+func registerReplyComment(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "reply_comment",
+		Description: "Reply to a comment on a Google Drive file.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input replyCommentInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		if input.CommentID == "" {
+			return nil, nil, fmt.Errorf("comment_id is required")
+		}
+		if input.Content == "" {
+			return nil, nil, fmt.Errorf("content is required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		reply, err := createReply(ctx, svc, input.FileID, input.CommentID, input.Content, "")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "creating reply")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Reply added.\nReply ID: %s\nComment ID: %s", reply.Id, input.CommentID)},
+			},
+		}, nil, nil
+	})
+}
+
+// --- resolve_comment ---
+
+type resolveCommentInput struct {
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID    string `json:"file_id" jsonschema:"Google Drive file ID"`
+	CommentID string `json:"comment_id" jsonschema:"Comment ID to resolve (from list_comments)"`
+	Content   string `json:"content,omitempty" jsonschema:"Optional closing message posted with the resolution"`
+}
+
+func registerResolveComment(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "resolve_comment",
+		Description: "Resolve a comment on a Google Drive file by posting a reply with the resolve action. Optionally include a closing message.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input resolveCommentInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		if input.CommentID == "" {
+			return nil, nil, fmt.Errorf("comment_id is required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		reply, err := createReply(ctx, svc, input.FileID, input.CommentID, input.Content, "resolve")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "resolving comment")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Comment resolved.\nComment ID: %s\nReply ID: %s", input.CommentID, reply.Id)},
+			},
+		}, nil, nil
+	})
+}
+
+// createReply posts a reply to a comment. action may be empty, "resolve",
+// or "reopen".
+func createReply(ctx context.Context, svc *drive.Service, fileID, commentID, content, action string) (*drive.Reply, error) {
+	return svc.Replies.Create(fileID, commentID, &drive.Reply{
+		Content: content,
+		Action:  action,
+	}).Fields(replyFields).Context(ctx).Do()
+}
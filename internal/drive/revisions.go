@@ -7,13 +7,14 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 )
 
 // --- list_revisions ---
 
 type listRevisionsInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID     string `json:"file_id" jsonschema:"Google Drive file ID"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of revisions to return (default 20, max 100)"`
 }
@@ -23,7 +24,10 @@ func registerListRevisions(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_revisions",
 		Description: "List revisions (version history) of a Google Drive file. Shows revision IDs, modification times, and authors.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listRevisionsInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
@@ -45,10 +49,10 @@ func registerListRevisions(srv *server.Server, mgr *auth.Manager) {
 
 		resp, err := svc.Revisions.List(input.FileID).
 			PageSize(maxResults).
-			Fields("revisions(id,mimeType,modifiedTime,size,lastModifyingUser,keepForever,publishAuto,published,publishedOutsideDomain)").
+			Fields("revisions(id,mimeType,modifiedTime,size,lastModifyingUser,keepForever,publishAuto,published,publishedOutsideDomain)").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing revisions: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing revisions")
 		}
 
 		if len(resp.Revisions) == 0 {
@@ -97,7 +101,7 @@ func registerListRevisions(srv *server.Server, mgr *auth.Manager) {
 // --- get_revision ---
 
 type getRevisionInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID     string `json:"file_id" jsonschema:"Google Drive file ID"`
 	RevisionID string `json:"revision_id" jsonschema:"Revision ID to retrieve"`
 }
@@ -107,7 +111,10 @@ func registerGetRevision(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_revision",
 		Description: "Get details of a specific file revision including modification time, author, size, and publishing status.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getRevisionInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
@@ -123,10 +130,10 @@ func registerGetRevision(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		r, err := svc.Revisions.Get(input.FileID, input.RevisionID).
-			Fields("id,mimeType,modifiedTime,size,lastModifyingUser,keepForever,publishAuto,published,publishedOutsideDomain,originalFilename,md5Checksum,exportLinks").
+			Fields("id,mimeType,modifiedTime,size,lastModifyingUser,keepForever,publishAuto,published,publishedOutsideDomain,originalFilename,md5Checksum,exportLinks").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting revision: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting revision")
 		}
 
 		var sb strings.Builder
@@ -178,7 +185,7 @@ func registerGetRevision(srv *server.Server, mgr *auth.Manager) {
 // --- delete_revision ---
 
 type deleteRevisionInput struct {
-	Account    string `json:"account" jsonschema:"Account name"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FileID     string `json:"file_id" jsonschema:"Google Drive file ID"`
 	RevisionID string `json:"revision_id" jsonschema:"Revision ID to delete"`
 }
@@ -187,7 +194,12 @@ func registerDeleteRevision(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_revision",
 		Description: "Delete a specific revision of a Google Drive file. The last remaining revision cannot be deleted.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteRevisionInput) (*mcp.CallToolResult, any, error) {
 		if input.FileID == "" {
 			return nil, nil, fmt.Errorf("file_id is required")
@@ -201,8 +213,8 @@ func registerDeleteRevision(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 		}
 
-		if err := svc.Revisions.Delete(input.FileID, input.RevisionID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting revision: %w", err)
+		if err := svc.Revisions.Delete(input.FileID, input.RevisionID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting revision")
 		}
 
 		return &mcp.CallToolResult{
@@ -0,0 +1,317 @@
+package drive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+)
+
+// linkPermissionFields is the Fields mask for the 'anyone' permissions
+// get_share_link creates and updates.
+const linkPermissionFields = "id,role,type,allowFileDiscovery,expirationTime"
+
+// --- get_share_link ---
+
+type getShareLinkInput struct {
+	Account       string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID        string `json:"file_id" jsonschema:"Google Drive file or folder ID"`
+	Role          string `json:"role,omitempty" jsonschema:"What people with the link can do: 'reader' (view, default), 'commenter' or 'writer' (edit)"`
+	ExpiresInDays int    `json:"expires_in_days,omitempty" jsonschema:"Not supported: Drive only lets permissions of specific users and groups expire, not link sharing, so setting this is an error"`
+	Revoke        bool   `json:"revoke,omitempty" jsonschema:"Turn link sharing off instead, removing the 'anyone with the link' permission (default: false)"`
+}
+
+// shareLinkResult is the outcome of setShareLink.
+type shareLinkResult struct {
+	File *drive.File
+	// Permission is the 'anyone' permission after the call; nil when the
+	// file isn't shared by link (any more).
+	Permission *drive.Permission
+	// Action is "created", "updated", "unchanged", "revoked", "partly
+	// revoked" or "not shared".
+	Action string
+	// Inherited are the file's 'anyone' permissions inherited from a
+	// parent folder, which can only be changed on that folder.
+	Inherited []*drive.Permission
+	// Removed has the outcome of each deletion with revoke.
+	Removed []linkRemoval
+}
+
+// linkRemoval is the outcome of deleting one 'anyone' permission.
+type linkRemoval struct {
+	Permission *drive.Permission
+	Err        error
+}
+
+func registerGetShareLink(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "get_share_link",
+		Description: `Turn on link sharing for a Google Drive file or folder and return its link, in one call. Anyone with the link gets the given role (reader by default) without signing in.
+
+The file's permissions are checked first: if it is already shared by link with that role, nothing changes; with another role, the existing link permission is updated instead of adding a second one. The result gives the link and who can use it. Set revoke to turn link sharing off again; people and groups the file is shared with keep their access, and the result reports each link permission removed or that failed to be.
+
+Link sharing a file inherits from a parent folder (in a shared drive) can only be changed on that folder; the result names it. Link sharing can be disabled by a Workspace administrator, in which case Drive refuses the change. Drive doesn't let link sharing expire. Use share_file to share with specific people instead.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input getShareLinkInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+		if input.ExpiresInDays != 0 {
+			return nil, nil, fmt.Errorf("expires_in_days is not supported: Drive only accepts an expiration on permissions for specific users and groups, not on link sharing")
+		}
+		role := input.Role
+		if input.Revoke {
+			if role != "" {
+				return nil, nil, fmt.Errorf("revoke turns link sharing off; don't set role with it")
+			}
+		} else if role == "" {
+			role = "reader"
+		}
+		switch role {
+		case "", "reader", "commenter", "writer":
+		default:
+			return nil, nil, fmt.Errorf("invalid role %q: must be 'reader', 'commenter', or 'writer'", role)
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		res, err := setShareLink(ctx, svc, input.FileID, role, input.Revoke)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatShareLink(res)},
+			},
+		}, nil, nil
+	})
+}
+
+// setShareLink makes fileID shared by link with role, or with revoke
+// removes link sharing. The file's permissions are listed first, so that
+// an existing 'anyone' permission is kept or updated rather than
+// duplicated. Permissions inherited from a parent folder are reported but
+// never changed, since Drive only allows that on the folder.
+func setShareLink(ctx context.Context, svc *drive.Service, fileID, role string, revoke bool) (*shareLinkResult, error) {
+	file, err := svc.Files.Get(fileID).Fields("id,name,mimeType,webViewLink").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return nil, gerrors.Wrap(err, "getting file")
+	}
+	perms, err := listAllPermissions(ctx, svc, fileID)
+	if err != nil {
+		return nil, gerrors.Wrap(err, "listing permissions")
+	}
+	links, inherited := linkPermissions(perms)
+	res := &shareLinkResult{File: file, Inherited: inherited}
+
+	if revoke {
+		return revokeShareLink(ctx, svc, res, links)
+	}
+
+	var existing *drive.Permission
+	if len(links) > 0 {
+		existing = links[0]
+	} else if i := slices.IndexFunc(inherited, func(p *drive.Permission) bool { return p.Role == role }); i >= 0 {
+		// The link already works as asked through the parent folder.
+		existing = inherited[i]
+	}
+	res.Action = planShareLink(existing, role)
+	switch res.Action {
+	case "created":
+		res.Permission, err = svc.Permissions.Create(fileID, &drive.Permission{
+			Type: "anyone",
+			Role: role,
+		}).Fields(linkPermissionFields).SupportsAllDrives(true).Context(ctx).Do()
+		err = gerrors.Wrap(err, "turning on link sharing")
+	case "updated":
+		res.Permission, err = svc.Permissions.Update(fileID, existing.Id, &drive.Permission{
+			Role: role,
+		}).Fields(linkPermissionFields).SupportsAllDrives(true).Context(ctx).Do()
+		err = gerrors.Wrap(err, "updating link sharing")
+	default:
+		res.Permission = existing
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// revokeShareLink deletes the file's own 'anyone' permissions one at
+// a time and records the outcome of each in res. It only fails when every
+// deletion did, so that nothing changed.
+func revokeShareLink(ctx context.Context, svc *drive.Service, res *shareLinkResult, links []*drive.Permission) (*shareLinkResult, error) {
+	var errs []error
+	for _, p := range links {
+		err := svc.Permissions.Delete(res.File.Id, p.Id).SupportsAllDrives(true).Context(ctx).Do()
+		err = gerrors.Wrapf(err, "removing link permission %s", p.Id)
+		res.Removed = append(res.Removed, linkRemoval{Permission: p, Err: err})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch {
+	case len(links) == 0:
+		res.Action = "not shared"
+	case len(errs) == len(links):
+		return nil, errors.Join(errs...)
+	case len(errs) > 0:
+		res.Action = "partly revoked"
+	default:
+		res.Action = "revoked"
+	}
+	return res, nil
+}
+
+// planShareLink returns what setShareLink does given the file's existing
+// 'anyone' permission: "created" when there is none, "updated" when its
+// role differs, and "unchanged" otherwise.
+func planShareLink(existing *drive.Permission, role string) string {
+	switch {
+	case existing == nil:
+		return "created"
+	case existing.Role != role:
+		return "updated"
+	default:
+		return "unchanged"
+	}
+}
+
+// linkPermissions returns the 'anyone' permissions of perms, the ones that
+// share a file by link: those set on the file itself, and those it only
+// inherits from a parent folder.
+func linkPermissions(perms []*drive.Permission) (own, inherited []*drive.Permission) {
+	for _, p := range perms {
+		if p.Type != "anyone" || p.Deleted {
+			continue
+		}
+		if onlyInherited(p) {
+			inherited = append(inherited, p)
+		} else {
+			own = append(own, p)
+		}
+	}
+	return own, inherited
+}
+
+// onlyInherited reports whether p applies to the file only because it is
+// set on a parent folder.
+func onlyInherited(p *drive.Permission) bool {
+	if len(p.PermissionDetails) == 0 {
+		return false
+	}
+	for _, d := range p.PermissionDetails {
+		if !d.Inherited {
+			return false
+		}
+	}
+	return true
+}
+
+// inheritedFrom returns the folder an inherited permission comes from, or
+// "" if Drive doesn't say.
+func inheritedFrom(p *drive.Permission) string {
+	for _, d := range p.PermissionDetails {
+		if d.Inherited && d.InheritedFrom != "" {
+			return d.InheritedFrom
+		}
+	}
+	return ""
+}
+
+// linkAccess describes who can use a file's link through the 'anyone'
+// permission p, e.g. "Anyone with the link can view".
+func linkAccess(p *drive.Permission) string {
+	verb := p.Role
+	switch p.Role {
+	case "reader":
+		verb = "view"
+	case "commenter":
+		verb = "comment"
+	case "writer":
+		verb = "edit"
+	}
+	who := "Anyone with the link"
+	if p.AllowFileDiscovery {
+		who = "Anyone on the internet (the file can be found by search)"
+	}
+	s := fmt.Sprintf("%s can %s", who, verb)
+	if p.ExpirationTime != "" {
+		s += " until " + p.ExpirationTime
+	}
+	return s
+}
+
+// formatShareLink formats the get_share_link result.
+func formatShareLink(res *shareLinkResult) string {
+	var sb strings.Builder
+	switch res.Action {
+	case "created":
+		sb.WriteString("Link sharing turned on.\n\n")
+	case "updated":
+		sb.WriteString("Link sharing updated.\n\n")
+	case "unchanged":
+		sb.WriteString("Link sharing was already on; nothing changed.\n\n")
+	case "revoked":
+		sb.WriteString("Link sharing turned off.\n\n")
+	case "partly revoked":
+		sb.WriteString("Link sharing was only partly turned off; see the errors below.\n\n")
+	case "not shared":
+		sb.WriteString("The file has no link sharing of its own; nothing changed.\n\n")
+	}
+	fmt.Fprintf(&sb, "Name: %s\nFile ID: %s\n", res.File.Name, res.File.Id)
+	for _, r := range res.Removed {
+		if r.Err != nil {
+			fmt.Fprintf(&sb, "Not removed: %s (Permission ID: %s): %v\n", linkAccess(r.Permission), r.Permission.Id, r.Err)
+		} else {
+			fmt.Fprintf(&sb, "Removed: %s (Permission ID: %s)\n", linkAccess(r.Permission), r.Permission.Id)
+		}
+	}
+	switch {
+	case res.Permission != nil:
+		fmt.Fprintf(&sb, "Link: %s\n", res.File.WebViewLink)
+		fmt.Fprintf(&sb, "Access: %s\n", linkAccess(res.Permission))
+		fmt.Fprintf(&sb, "Permission ID: %s\n", res.Permission.Id)
+	case len(res.Inherited) == 0 && res.Action != "partly revoked":
+		sb.WriteString("Access: only people and groups the file is shared with\n")
+		if res.File.WebViewLink != "" {
+			fmt.Fprintf(&sb, "Link (works for them only): %s\n", res.File.WebViewLink)
+		}
+	default:
+		fmt.Fprintf(&sb, "Link: %s\n", res.File.WebViewLink)
+	}
+	for _, p := range res.Inherited {
+		if p == res.Permission {
+			continue
+		}
+		from := "a parent folder"
+		if id := inheritedFrom(p); id != "" {
+			from = "folder " + id
+		}
+		fmt.Fprintf(&sb, "Inherited from %s: %s. Change it on that folder.\n", from, linkAccess(p))
+	}
+	if res.Permission != nil && onlyInherited(res.Permission) {
+		if id := inheritedFrom(res.Permission); id != "" {
+			fmt.Fprintf(&sb, "Inherited from folder %s; change it on that folder.\n", id)
+		} else {
+			sb.WriteString("Inherited from a parent folder; change it on that folder.\n")
+		}
+	}
+	return sb.String()
+}
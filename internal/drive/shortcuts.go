@@ -0,0 +1,151 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// shortcutMIME is the MIME type of Drive shortcuts.
+const shortcutMIME = "application/vnd.google-apps.shortcut"
+
+// maxShortcutDepth is how many shortcuts followShortcut follows before
+// giving up. Drive doesn't allow shortcuts to shortcuts, but files created
+// by older clients can still form chains.
+const maxShortcutDepth = 5
+
+// isShortcut reports whether f is a shortcut with a known target.
+func isShortcut(f *drive.File) bool {
+	return f.MimeType == shortcutMIME && f.ShortcutDetails != nil && f.ShortcutDetails.TargetId != ""
+}
+
+// followShortcut resolves file to the file its shortcut chain ends at,
+// fetching each target with fields (which must include mimeType and
+// shortcutDetails). It returns the target and the shortcuts followed, in
+// order; a file that isn't a shortcut is returned as-is.
+func followShortcut(ctx context.Context, svc *drive.Service, file *drive.File, fields string) (*drive.File, []*drive.File, error) {
+	var chain []*drive.File
+	for isShortcut(file) {
+		if len(chain) == maxShortcutDepth {
+			return nil, chain, fmt.Errorf("shortcut %s: more than %d shortcuts in a chain", chain[0].Id, maxShortcutDepth)
+		}
+		chain = append(chain, file)
+		target, err := svc.Files.Get(file.ShortcutDetails.TargetId).
+			Fields(googleapi.Field(fields)).
+			SupportsAllDrives(true).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return nil, chain, gerrors.Wrapf(err, "getting target %s of shortcut %s", file.ShortcutDetails.TargetId, file.Id)
+		}
+		file = target
+	}
+	return file, chain, nil
+}
+
+// shortcutNote describes the shortcuts read_file followed to reach target.
+func shortcutNote(chain []*drive.File, target *drive.File) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(chain)+1)
+	for _, f := range chain {
+		parts = append(parts, fmt.Sprintf("%s (%s)", f.Name, f.Id))
+	}
+	parts = append(parts, fmt.Sprintf("%s (%s)", target.Name, target.Id))
+	return fmt.Sprintf("[Followed shortcut: %s]\n\n", strings.Join(parts, " -> "))
+}
+
+// shortcutTarget returns the "Shortcut to:" line for a shortcut, looking up
+// the target's name. The target ID is still shown when the target can't be
+// read, for example because it was deleted or isn't shared with the account.
+func shortcutTarget(ctx context.Context, svc *drive.Service, f *drive.File) string {
+	id := f.ShortcutDetails.TargetId
+	target, err := svc.Files.Get(id).Fields("id,name").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return fmt.Sprintf("Shortcut to: %s (target not accessible: %v)\n", id, err)
+	}
+	return fmt.Sprintf("Shortcut to: %s (%s)\n", target.Name, id)
+}
+
+// --- create_shortcut ---
+
+type createShortcutInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID   string `json:"file_id" jsonschema:"ID of the file or folder the shortcut points to"`
+	FolderID string `json:"folder_id,omitempty" jsonschema:"Folder to create the shortcut in (default: root)"`
+	Name     string `json:"name,omitempty" jsonschema:"Shortcut name (default: the target's name)"`
+}
+
+func registerCreateShortcut(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "create_shortcut",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+		Description: "Create a Google Drive shortcut to a file or folder, so it also appears in another folder without being moved or copied.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input createShortcutInput) (*mcp.CallToolResult, any, error) {
+		if input.FileID == "" {
+			return nil, nil, fmt.Errorf("file_id is required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		name := input.Name
+		if name == "" {
+			target, err := svc.Files.Get(input.FileID).Fields("id,name").SupportsAllDrives(true).Context(ctx).Do()
+			if err != nil {
+				return nil, nil, gerrors.Wrap(err, "getting shortcut target")
+			}
+			name = target.Name
+		}
+
+		shortcut := &drive.File{
+			Name:            name,
+			MimeType:        shortcutMIME,
+			ShortcutDetails: &drive.FileShortcutDetails{TargetId: input.FileID},
+		}
+		if input.FolderID != "" {
+			shortcut.Parents = []string{input.FolderID}
+		}
+
+		created, err := svc.Files.Create(shortcut).
+			Fields("id,name,webViewLink,shortcutDetails(targetId,targetMimeType)").
+			SupportsAllDrives(true).Context(ctx).
+			Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "creating shortcut")
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Shortcut created.\n\n")
+		fmt.Fprintf(&sb, "Name: %s\n", created.Name)
+		fmt.Fprintf(&sb, "File ID: %s\n", created.Id)
+		fmt.Fprintf(&sb, "Target ID: %s\n", input.FileID)
+		if d := created.ShortcutDetails; d != nil && d.TargetMimeType != "" {
+			fmt.Fprintf(&sb, "Target Type: %s\n", d.TargetMimeType)
+		}
+		if created.WebViewLink != "" {
+			fmt.Fprintf(&sb, "Link: %s\n", created.WebViewLink)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
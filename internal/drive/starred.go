@@ -0,0 +1,291 @@
+package drive
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Defaults and limits of list_recent's days.
+const (
+	defaultRecentDays = 30
+	maxRecentDays     = 365
+)
+
+// fileViewFields is the Fields mask of list_starred and list_recent.
+const fileViewFields = "files(id,name,mimeType,size,modifiedTime,viewedByMeTime,owners,webViewLink,shortcutDetails(targetId))"
+
+// fileView is a fixed Drive listing such as Starred or Recent.
+type fileView struct {
+	query   string
+	orderBy string
+	// viewed adds the viewed_by_me column to json and csv output.
+	viewed bool
+	// empty is the text shown for an account with no files.
+	empty string
+}
+
+// --- list_starred ---
+
+type listStarredInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 20, max 100)"`
+	Format     string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per file)"`
+}
+
+func registerListStarred(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_starred",
+		Description: "List the starred files in Google Drive, as in Drive's Starred view, most recently modified first. Set account to 'all' to list from all accounts. Use star_file and unstar_file to change which files are starred. Set format to json or csv for machine-readable output.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listStarredInput) (*mcp.CallToolResult, any, error) {
+		view := fileView{
+			query:   "starred = true and trashed = false",
+			orderBy: "modifiedTime desc",
+			empty:   "No starred files.",
+		}
+		return listFileView(ctx, srv, mgr, input.Account, input.Format, input.MaxResults, view)
+	})
+}
+
+// --- list_recent ---
+
+type listRecentInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Days       int    `json:"days,omitempty" jsonschema:"Only files you opened in the last this many days (default 30, max 365)"`
+	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 20, max 100)"`
+	Format     string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per file)"`
+}
+
+func registerListRecent(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_recent",
+		Description: "List the Google Drive files you opened recently, most recent first, with the time you last viewed each one. Set account to 'all' to list from all accounts. Files you only received or that others changed don't show up until you open them; use list_changes or search_files for those. Set format to json or csv for machine-readable output.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listRecentInput) (*mcp.CallToolResult, any, error) {
+		days := input.Days
+		if days <= 0 {
+			days = defaultRecentDays
+		}
+		if days > maxRecentDays {
+			days = maxRecentDays
+		}
+		view := fileView{
+			query:   recentQuery(time.Now(), days),
+			orderBy: "viewedByMeTime desc",
+			viewed:  true,
+			empty:   fmt.Sprintf("No files opened in the last %d days.", days),
+		}
+		return listFileView(ctx, srv, mgr, input.Account, input.Format, input.MaxResults, view)
+	})
+}
+
+// recentQuery returns the list_recent query for files viewed in the days
+// before now.
+func recentQuery(now time.Time, days int) string {
+	since := now.UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+	return fmt.Sprintf("viewedByMeTime > '%s' and trashed = false", since)
+}
+
+// listFileView lists view for each account account resolves to, as the
+// list_starred and list_recent result.
+func listFileView(ctx context.Context, srv *server.Server, mgr *auth.Manager, account, format string, maxResults int64, view fileView) (*mcp.CallToolResult, any, error) {
+	accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, account))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if maxResults <= 0 {
+		maxResults = 20
+	}
+	if maxResults > 100 {
+		maxResults = 100
+	}
+
+	columns := fileColumns
+	if view.viewed {
+		columns = append(slices.Clip(columns), fileViewedColumn)
+	}
+	table, err := server.NewTable(format, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := srv.NewOutputBuilder()
+	multiAccount := len(accounts) > 1
+
+	for _, account := range accounts {
+		if out.Truncated() || ctx.Err() != nil {
+			break
+		}
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			if multiAccount && table != nil {
+				table.AddError("account %s: %v", account, err)
+				continue
+			}
+			if multiAccount {
+				fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
+				continue
+			}
+			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+		}
+
+		resp, err := svc.Files.List().
+			Q(view.query).
+			OrderBy(view.orderBy).
+			PageSize(maxResults).
+			Fields(googleapi.Field(fileViewFields)).
+			Context(ctx).
+			Do()
+		if err != nil {
+			if multiAccount && table != nil {
+				table.AddError("account %s: listing: %v", account, err)
+				continue
+			}
+			if multiAccount {
+				fmt.Fprintf(out, "=== Account: %s ===\nError listing: %v\n\n", account, err)
+				continue
+			}
+			return nil, nil, gerrors.Wrap(err, "listing files")
+		}
+
+		if table != nil {
+			for _, f := range resp.Files {
+				table.Add(fileRow{Account: account, File: f})
+			}
+			continue
+		}
+
+		if multiAccount {
+			fmt.Fprintf(out, "=== Account: %s ===\n", account)
+		}
+		if len(resp.Files) == 0 {
+			out.WriteString(view.empty + "\n\n")
+			continue
+		}
+		writeFileList(out, resp.Files, account)
+	}
+
+	if table != nil {
+		return table.Result(), nil, nil
+	}
+
+	text := out.String()
+	if text == "" {
+		text = view.empty
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}, nil, nil
+}
+
+// --- star_file ---
+
+type starFileInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID  string `json:"file_id" jsonschema:"Google Drive file ID to star"`
+}
+
+func registerStarFile(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "star_file",
+		Description: "Star a Google Drive file or folder so it shows in Drive's Starred view and in list_starred. Stars are personal: other people with access don't see them.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input starFileInput) (*mcp.CallToolResult, any, error) {
+		return setStarred(ctx, mgr, input.Account, input.FileID, true)
+	})
+}
+
+// --- unstar_file ---
+
+type unstarFileInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	FileID  string `json:"file_id" jsonschema:"Google Drive file ID to unstar"`
+}
+
+func registerUnstarFile(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "unstar_file",
+		Description: "Remove the star from a Google Drive file or folder, taking it out of Drive's Starred view.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input unstarFileInput) (*mcp.CallToolResult, any, error) {
+		return setStarred(ctx, mgr, input.Account, input.FileID, false)
+	})
+}
+
+// setStarred stars or unstars fileID, as the star_file and unstar_file
+// result.
+func setStarred(ctx context.Context, mgr *auth.Manager, account, fileID string, starred bool) (*mcp.CallToolResult, any, error) {
+	if fileID == "" {
+		return nil, nil, fmt.Errorf("file_id is required")
+	}
+	svc, err := newService(ctx, mgr, account)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating Drive service: %w", err)
+	}
+	file, err := updateStarred(ctx, svc, fileID, starred)
+	if err != nil {
+		return nil, nil, err
+	}
+	var sb strings.Builder
+	if file.Starred {
+		sb.WriteString("File starred.\n\n")
+	} else {
+		sb.WriteString("File unstarred.\n\n")
+	}
+	fmt.Fprintf(&sb, "Name: %s\nFile ID: %s\n", file.Name, file.Id)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
+}
+
+// updateStarred sets the starred field of fileID. ForceSendFields makes
+// unstarring send the false value, which omitempty would drop.
+func updateStarred(ctx context.Context, svc *drive.Service, fileID string, starred bool) (*drive.File, error) {
+	file, err := svc.Files.Update(fileID, &drive.File{
+		Starred:         starred,
+		ForceSendFields: []string{"Starred"},
+	}).Fields("id,name,starred").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		if starred {
+			return nil, gerrors.Wrap(err, "starring file")
+		}
+		return nil, gerrors.Wrap(err, "unstarring file")
+	}
+	return file, nil
+}
@@ -7,16 +7,32 @@ import (
 	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
 )
 
 // Scopes required by the Drive tools.
+// DriveActivityReadonlyScope is used by get_file's include_history option.
+// Accounts authorized without it still work; get_file then falls back to
+// Drive metadata for history.
 var Scopes = []string{
 	drive.DriveScope,
+	driveactivity.DriveActivityReadonlyScope,
+}
+
+// ReadonlyScopes is the scope set used with --readonly-scopes.
+var ReadonlyScopes = []string{
+	drive.DriveReadonlyScope,
+	driveactivity.DriveActivityReadonlyScope,
 }
 
 // RegisterTools registers all Drive MCP tools on the given server.
 func RegisterTools(srv *server.Server, mgr *auth.Manager) {
+	srv.SetAccountNames(mgr.AccountNames())
 	server.RegisterAccountsListTool(srv, mgr)
+	server.RegisterAccountsResource(srv, mgr)
+	// prompts.go
+	registerPrompts(srv)
+	server.RegisterMutationsTool(srv)
 	server.RegisterLocalFSTools(srv)
 	// files.go
 	registerSearch(srv, mgr)
@@ -29,14 +45,32 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerCreateFolder(srv, mgr)
 	registerMove(srv, mgr)
 	registerCopy(srv, mgr)
+	registerCreateShortcut(srv, mgr)
 	// permissions.go
 	registerShare(srv, mgr)
 	registerListPermissions(srv, mgr)
 	registerGetPermission(srv, mgr)
 	registerUpdatePermission(srv, mgr)
 	registerDeletePermission(srv, mgr)
+	registerCopyPermissions(srv, mgr)
+	// sharelink.go
+	registerGetShareLink(srv, mgr)
 	// trash.go
 	registerEmptyTrash(srv, mgr)
+	// duplicates.go
+	registerFindDuplicates(srv, mgr)
+	registerTrashDuplicates(srv, mgr)
+	// foldersize.go
+	registerFolderSize(srv, mgr)
+	// folderpath.go
+	registerCreateFolderPath(srv, mgr)
+	// starred.go
+	registerListStarred(srv, mgr)
+	registerListRecent(srv, mgr)
+	registerStarFile(srv, mgr)
+	registerUnstarFile(srv, mgr)
+	// ocr.go
+	registerExtractText(srv, mgr)
 	// about.go
 	registerGetAbout(srv, mgr)
 	// drives.go
@@ -51,10 +85,17 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerDeleteRevision(srv, mgr)
 	// changes.go
 	registerListChanges(srv, mgr)
+	registerCheckFileChanged(srv, mgr)
+	// comments.go
+	registerListComments(srv, mgr)
+	registerAddComment(srv, mgr)
+	// replies.go
+	registerReplyComment(srv, mgr)
+	registerResolveComment(srv, mgr)
 }
 
 func newService(ctx context.Context, mgr *auth.Manager, account string) (*drive.Service, error) {
-	opt, err := mgr.ClientOption(ctx, account, Scopes)
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), Scopes, ReadonlyScopes)
 	if err != nil {
 		return nil, err
 	}
@@ -65,3 +106,8 @@ func newService(ctx context.Context, mgr *auth.Manager, account string) (*drive.
 func AccountScopes() []string {
 	return Scopes
 }
+
+// AccountReadonlyScopes returns the scopes used by the read-only Drive tools.
+func AccountReadonlyScopes() []string {
+	return ReadonlyScopes
+}
@@ -1,18 +1,37 @@
 package drive
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	driveapi "google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 func newTestManager(t *testing.T) *auth.Manager {
@@ -257,29 +276,47 @@ func TestToolNames(t *testing.T) {
 	sort.Strings(got)
 
 	want := []string{
+		"add_comment",
+		"check_file_changed",
 		"copy_file",
+		"copy_permissions",
 		"create_folder",
+		"create_folder_path",
 		"create_shared_drive",
+		"create_shortcut",
 		"delete_file",
 		"delete_permission",
 		"delete_revision",
 		"delete_shared_drive",
 		"empty_trash",
+		"extract_text",
+		"find_duplicates",
+		"folder_size",
 		"get_about",
 		"get_file",
 		"get_permission",
 		"get_revision",
+		"get_share_link",
 		"get_shared_drive",
 		"list_accounts",
 		"list_changes",
+		"list_comments",
 		"list_files",
 		"list_permissions",
+		"list_recent",
+		"list_recent_mutations",
 		"list_revisions",
 		"list_shared_drives",
+		"list_starred",
 		"move_file",
 		"read_file",
+		"reply_comment",
+		"resolve_comment",
 		"search_files",
 		"share_file",
+		"star_file",
+		"trash_duplicates",
+		"unstar_file",
 		"update_file",
 		"update_permission",
 		"update_shared_drive",
@@ -309,7 +346,8 @@ func TestToolAnnotations(t *testing.T) {
 	readOnly := []string{
 		"list_accounts", "search_files", "list_files", "get_file", "read_file",
 		"list_permissions", "get_permission", "get_about", "list_shared_drives", "get_shared_drive",
-		"list_revisions", "get_revision", "list_changes",
+		"list_revisions", "get_revision", "list_changes", "list_comments", "list_recent_mutations",
+		"check_file_changed", "folder_size", "list_starred", "list_recent", "find_duplicates",
 	}
 	for _, name := range readOnly {
 		tool := toolMap[name]
@@ -325,8 +363,10 @@ func TestToolAnnotations(t *testing.T) {
 	mutations := []string{
 		"upload_file", "update_file", "delete_file",
 		"create_folder", "move_file", "copy_file", "share_file",
-		"update_permission", "delete_permission", "empty_trash",
+		"update_permission", "delete_permission", "copy_permissions", "empty_trash",
 		"delete_revision", "create_shared_drive", "update_shared_drive", "delete_shared_drive",
+		"add_comment", "reply_comment", "resolve_comment", "create_shortcut", "trash_duplicates",
+		"create_folder_path", "star_file", "unstar_file", "extract_text", "get_share_link",
 	}
 	for _, name := range mutations {
 		tool := toolMap[name]
@@ -364,9 +404,9 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 	}
 	sort.Strings(got)
 
-	// Should include all 27 base tools + 2 localfs tools = 29.
-	if len(got) != 29 {
-		t.Fatalf("got %d tools, want 29\ngot: %v", len(got), got)
+	// Should include all 45 base tools + 3 localfs tools = 48.
+	if len(got) != 48 {
+		t.Fatalf("got %d tools, want 48\ngot: %v", len(got), got)
 	}
 
 	names := make(map[string]bool)
@@ -379,4 +419,2219 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 	if !names["read_local_file"] {
 		t.Error("expected read_local_file tool")
 	}
+	if !names["stat_local_file"] {
+		t.Error("expected stat_local_file tool")
+	}
+}
+
+// newFakeService returns a Drive service that sends all requests to handler.
+func newFakeService(t *testing.T, handler http.HandlerFunc) *driveapi.Service {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	svc, err := driveapi.NewService(context.Background(),
+		option.WithHTTPClient(ts.Client()),
+		option.WithEndpoint(ts.URL+"/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestListComments_FieldsAndFiltering(t *testing.T) {
+	var gotFields string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		gotFields = r.URL.Query().Get("fields")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"comments":[
+			{"id":"c2","content":"second","createdTime":"2024-01-02T00:00:00Z"},
+			{"id":"c1","content":"first","createdTime":"2024-01-01T00:00:00Z",
+			 "replies":[{"id":"r1","content":"ack","createdTime":"2024-01-01T01:00:00Z"}]},
+			{"id":"c3","content":"done","resolved":true,"createdTime":"2024-01-03T00:00:00Z"},
+			{"id":"c4","deleted":true}
+		]}`))
+	})
+
+	comments, err := listComments(context.Background(), svc, "file-1", false, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"nextPageToken", "quotedFileContent", "resolved", "replies(", "replies(id,author(displayName,emailAddress),content,action"} {
+		if !strings.Contains(gotFields, want) {
+			t.Errorf("fields mask %q missing %q", gotFields, want)
+		}
+	}
+
+	if len(comments) != 2 {
+		t.Fatalf("got %d comments, want 2 (resolved and deleted filtered)", len(comments))
+	}
+	if comments[0].Id != "c1" || comments[1].Id != "c2" {
+		t.Errorf("comments not sorted by creation time: %s, %s", comments[0].Id, comments[1].Id)
+	}
+	if len(comments[0].Replies) != 1 || comments[0].Replies[0].Id != "r1" {
+		t.Errorf("reply tree not decoded: %+v", comments[0].Replies)
+	}
+
+	all, err := listComments(context.Background(), svc, "file-1", true, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("include_resolved: got %d comments, want 3", len(all))
+	}
+}
+
+func TestCreateReply_ResolveAction(t *testing.T) {
+	var gotFields string
+	var gotBody map[string]any
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		gotFields = r.URL.Query().Get("fields")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"r9","action":"resolve"}`))
+	})
+
+	reply, err := createReply(context.Background(), svc, "file-1", "c1", "", "resolve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Id != "r9" {
+		t.Errorf("reply ID = %q, want r9", reply.Id)
+	}
+	if gotBody["action"] != "resolve" {
+		t.Errorf("request action = %v, want resolve", gotBody["action"])
+	}
+	if !strings.Contains(gotFields, "action") {
+		t.Errorf("fields mask %q should include action", gotFields)
+	}
+}
+
+func TestFormatComment(t *testing.T) {
+	c := &driveapi.Comment{
+		Id:                "c1",
+		Author:            &driveapi.User{DisplayName: "Alice", EmailAddress: "alice@example.com"},
+		Content:           "Please rephrase",
+		QuotedFileContent: &driveapi.CommentQuotedFileContent{Value: "the quick fox"},
+		CreatedTime:       "2024-01-01T00:00:00Z",
+		ModifiedTime:      "2024-01-02T00:00:00Z",
+		Replies: []*driveapi.Reply{
+			{Id: "r1", Author: &driveapi.User{DisplayName: "Bob"}, Content: "Done", Action: "resolve"},
+			{Id: "r2", Deleted: true},
+		},
+	}
+
+	result := formatComment(c)
+	for _, want := range []string{
+		"Comment ID: c1",
+		"Author: Alice <alice@example.com>",
+		`Quoted text: "the quick fox"`,
+		"Content: Please rephrase",
+		"Created: 2024-01-01T00:00:00Z",
+		"Modified: 2024-01-02T00:00:00Z",
+		"Replies (1):",
+		"Reply ID: r1",
+		"Action: resolve",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "r2") {
+		t.Error("deleted reply should be omitted")
+	}
+}
+
+func TestDiffPermissions(t *testing.T) {
+	source := []*driveapi.Permission{
+		{Id: "p0", Role: "owner", Type: "user", EmailAddress: "owner@example.com"},
+		{Id: "p1", Role: "writer", Type: "user", EmailAddress: "Alice@Example.com"},
+		{Id: "p2", Role: "reader", Type: "user", EmailAddress: "bob@example.com"},
+		{Id: "p3", Role: "commenter", Type: "group", EmailAddress: "team@example.com"},
+		{Id: "p4", Role: "reader", Type: "domain", Domain: "example.com"},
+		{Id: "p5", Role: "reader", Type: "anyone"},
+		{Id: "p6", Role: "writer", Type: "user", EmailAddress: "inherited@example.com",
+			PermissionDetails: []*driveapi.PermissionPermissionDetails{{Inherited: true}}},
+		{Id: "p7", Role: "reader", Type: "user", EmailAddress: "gone@example.com", Deleted: true},
+		{Id: "p8", Role: "reader", Type: "domain"},
+	}
+	target := []*driveapi.Permission{
+		{Id: "t0", Role: "owner", Type: "user", EmailAddress: "other-owner@example.com"},
+		{Id: "t1", Role: "writer", Type: "user", EmailAddress: "alice@example.com"},
+		{Id: "t2", Role: "writer", Type: "user", EmailAddress: "bob@example.com"},
+		{Id: "t5", Role: "reader", Type: "anyone"},
+	}
+
+	diff := diffPermissions(source, target)
+
+	ids := func(perms []*driveapi.Permission) []string {
+		var out []string
+		for _, p := range perms {
+			out = append(out, p.Id)
+		}
+		return out
+	}
+
+	if got := strings.Join(ids(diff.ToAdd), ","); got != "p3,p4" {
+		t.Errorf("ToAdd = %s, want p3,p4", got)
+	}
+	if got := strings.Join(ids(diff.Present), ","); got != "p1,p5" {
+		t.Errorf("Present = %s, want p1,p5 (email match is case-insensitive)", got)
+	}
+	if len(diff.Conflicts) != 1 || diff.Conflicts[0].Source.Id != "p2" || diff.Conflicts[0].Target.Id != "t2" {
+		t.Errorf("Conflicts = %+v, want p2 vs t2", diff.Conflicts)
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range diff.Skipped {
+		reasons[s.Permission.Id] = s.Reason
+	}
+	if len(reasons) != 4 {
+		t.Errorf("got %d skipped, want 4: %v", len(reasons), reasons)
+	}
+	if reasons["p0"] != "owner" {
+		t.Errorf("p0 reason = %q, want owner", reasons["p0"])
+	}
+	if reasons["p6"] != "inherited" {
+		t.Errorf("p6 reason = %q, want inherited", reasons["p6"])
+	}
+	if reasons["p7"] == "" {
+		t.Error("deleted permission p7 should be skipped")
+	}
+	if !strings.Contains(reasons["p8"], "domain is required") {
+		t.Errorf("p8 reason = %q, want share_file validation error", reasons["p8"])
+	}
+}
+
+func TestDiffPermissions_EmptyTarget(t *testing.T) {
+	source := []*driveapi.Permission{
+		{Id: "p1", Role: "writer", Type: "user", EmailAddress: "alice@example.com"},
+		{Id: "p2", Role: "writer", Type: "user", EmailAddress: "alice@example.com"},
+	}
+
+	diff := diffPermissions(source, nil)
+	if len(diff.ToAdd) != 1 {
+		t.Errorf("got %d to add, want 1 (duplicates collapsed)", len(diff.ToAdd))
+	}
+	if len(diff.Present) != 0 || len(diff.Conflicts) != 0 || len(diff.Skipped) != 0 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
+
+func TestValidateShareTarget(t *testing.T) {
+	tests := []struct {
+		typ, role, email, domain string
+		wantErr                  string
+	}{
+		{"user", "reader", "a@example.com", "", ""},
+		{"anyone", "reader", "", "", ""},
+		{"domain", "writer", "", "example.com", ""},
+		{"user", "reader", "", "", "email_address is required"},
+		{"domain", "reader", "", "", "domain is required"},
+		{"robot", "reader", "", "", "invalid type"},
+		{"user", "owner", "a@example.com", "", ""},
+		{"group", "owner", "team@example.com", "", "requires type 'user'"},
+		{"domain", "owner", "", "example.com", "requires type 'user'"},
+		{"anyone", "owner", "", "", "requires type 'user'"},
+		{"user", "admin", "a@example.com", "", "invalid role"},
+	}
+	for _, tt := range tests {
+		err := validateShareTarget(tt.typ, tt.role, tt.email, tt.domain)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("validateShareTarget(%q, %q) = %v, want nil", tt.typ, tt.role, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("validateShareTarget(%q, %q) = %v, want error containing %q", tt.typ, tt.role, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRankTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"name contains 'Budget'", []string{"budget"}},
+		{"fullText contains 'q3 plan' and mimeType = 'application/pdf'", []string{"q3 plan"}},
+		{"name = 'Bob\\'s notes' or name contains 'draft'", []string{"bob's notes", "draft"}},
+		{"mimeType = 'application/pdf'", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := rankTerms(tt.query)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("rankTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNameScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  float64
+	}{
+		{"Budget.xlsx", []string{"budget"}, 1},
+		{"budget", []string{"budget"}, 1},
+		{"Budget 2024", []string{"budget"}, 0.8},
+		{"Q3 budget review", []string{"budget"}, 0.6},
+		{"Rebudgeting notes", []string{"budget"}, 0.4},
+		{"Meeting notes", []string{"budget"}, 0},
+		{"Q3 budget review", []string{"budget", "travel"}, 0.3},
+		{"Anything", nil, 0},
+	}
+	for _, tt := range tests {
+		if got := nameScore(tt.name, tt.terms); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nameScore(%q, %q) = %v, want %v", tt.name, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestRecencyScore(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		modified string
+		want     float64
+	}{
+		{"2024-06-01T00:00:00Z", 1},
+		{"2024-05-02T00:00:00Z", 0.5},
+		{"2024-04-02T00:00:00Z", 0.25},
+		{"2024-07-01T00:00:00Z", 1}, // clock skew: future times count as new
+		{"", 0},
+		{"not a time", 0},
+	}
+	for _, tt := range tests {
+		if got := recencyScore(tt.modified, now); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("recencyScore(%q) = %v, want %v", tt.modified, got, tt.want)
+		}
+	}
+}
+
+func TestRankFiles(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	files := []*driveapi.File{
+		{Id: "old-mention", Name: "Team notes", ModifiedTime: "2023-01-01T00:00:00Z"},
+		{Id: "exact", Name: "Budget.xlsx", ModifiedTime: "2024-01-01T00:00:00Z"},
+		{Id: "prefix-recent-owned", Name: "Budget 2024", ModifiedTime: "2024-05-31T00:00:00Z", OwnedByMe: true},
+		{Id: "prefix-recent", Name: "Budget 2023", ModifiedTime: "2024-05-31T00:00:00Z"},
+	}
+
+	ranked := rankFiles(files, "fullText contains 'budget'", now)
+
+	var order []string
+	for _, r := range ranked {
+		order = append(order, r.File.Id)
+	}
+	want := "prefix-recent-owned,prefix-recent,exact,old-mention"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+	if !ranked[0].Owned || ranked[1].Owned {
+		t.Error("Owned factor not recorded")
+	}
+}
+
+func TestFormatRankedFileList(t *testing.T) {
+	ranked := []rankedFile{{
+		File:    &driveapi.File{Id: "f1", Name: "Budget.xlsx", MimeType: "application/vnd.ms-excel"},
+		Score:   0.9,
+		Name:    1,
+		Recency: 0.5,
+		Owned:   true,
+	}}
+
+	got := formatRankedFileList(ranked, "name contains 'budget'", "work")
+	for _, want := range []string{
+		"query: name contains 'budget'",
+		"File ID: f1",
+		"Score: 0.90 (name match 1.00, recency 0.50, owned by me: yes)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestQueryFileActivity(t *testing.T) {
+	var requests []driveactivity.QueryDriveActivityRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req driveactivity.QueryDriveActivityRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.PageToken == "" {
+			json.NewEncoder(w).Encode(&driveactivity.QueryDriveActivityResponse{
+				Activities:    []*driveactivity.DriveActivity{{Timestamp: "t1"}, {Timestamp: "t2"}},
+				NextPageToken: "page2",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(&driveactivity.QueryDriveActivityResponse{
+			Activities:    []*driveactivity.DriveActivity{{Timestamp: "t3"}, {Timestamp: "t4"}},
+			NextPageToken: "page3",
+		})
+	}))
+	t.Cleanup(ts.Close)
+	svc, err := driveactivity.NewService(context.Background(),
+		option.WithHTTPClient(ts.Client()),
+		option.WithEndpoint(ts.URL+"/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := queryFileActivity(context.Background(), svc, "f1", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2].Timestamp != "t3" {
+		t.Errorf("got %d activities, want the first 3", len(got))
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if requests[0].ItemName != "items/f1" || requests[0].Filter != historyActivityFilter || requests[0].PageSize != 3 {
+		t.Errorf("first request = %+v", requests[0])
+	}
+	if requests[1].PageToken != "page2" || requests[1].PageSize != 1 {
+		t.Errorf("second request = %+v", requests[1])
+	}
+}
+
+func TestFormatActivityHistory(t *testing.T) {
+	permissionLists := 0
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/files/f1/permissions") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		permissionLists++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&driveapi.PermissionList{Permissions: []*driveapi.Permission{
+			{Id: "111", Type: "user", EmailAddress: "alice@example.com"},
+			{Id: "222", Type: "user", DisplayName: "Bob"},
+		}})
+	})
+
+	known := func(id string) *driveactivity.User {
+		return &driveactivity.User{KnownUser: &driveactivity.KnownUser{PersonName: "people/" + id}}
+	}
+	activities := []*driveactivity.DriveActivity{
+		{
+			Timestamp: "2026-03-02T10:00:00Z",
+			Actors:    []*driveactivity.Actor{{User: known("111")}},
+			PrimaryActionDetail: &driveactivity.ActionDetail{
+				Rename: &driveactivity.Rename{OldTitle: "DRAFT-final-v2", NewTitle: "Q3 Plan"},
+			},
+		},
+		{
+			TimeRange: &driveactivity.TimeRange{StartTime: "2026-03-01T09:00:00Z", EndTime: "2026-03-01T09:05:00Z"},
+			Actors:    []*driveactivity.Actor{{User: &driveactivity.User{KnownUser: &driveactivity.KnownUser{IsCurrentUser: true}}}},
+			PrimaryActionDetail: &driveactivity.ActionDetail{
+				Move: &driveactivity.Move{
+					RemovedParents: []*driveactivity.TargetReference{{DriveItem: &driveactivity.DriveItemReference{Title: "Drafts"}}},
+					AddedParents:   []*driveactivity.TargetReference{{DriveItem: &driveactivity.DriveItemReference{Title: "Planning"}}},
+				},
+			},
+		},
+		{
+			Timestamp: "2026-02-28T08:00:00Z",
+			Actors:    []*driveactivity.Actor{{User: known("222")}},
+			PrimaryActionDetail: &driveactivity.ActionDetail{
+				PermissionChange: &driveactivity.PermissionChange{
+					AddedPermissions:   []*driveactivity.Permission{{Role: "COMMENTER", User: known("111")}},
+					RemovedPermissions: []*driveactivity.Permission{{Role: "READER", Anyone: &driveactivity.Anyone{}}},
+				},
+			},
+		},
+		{
+			Timestamp: "2026-02-27T08:00:00Z",
+			Actors:    []*driveactivity.Actor{{User: known("999")}},
+			PrimaryActionDetail: &driveactivity.ActionDetail{
+				Rename: &driveactivity.Rename{OldTitle: "Untitled", NewTitle: "DRAFT-final-v2"},
+			},
+		},
+	}
+
+	got := formatActivityHistory(activities, newPersonNames(context.Background(), svc, "f1"))
+	for _, want := range []string{
+		`2026-03-02T10:00:00Z: renamed "DRAFT-final-v2" → "Q3 Plan" by alice@example.com`,
+		"2026-03-01T09:05:00Z: moved from Drafts to Planning by you",
+		"2026-02-28T08:00:00Z: shared with alice@example.com (commenter); unshared from anyone with the link (reader) by Bob",
+		`2026-02-27T08:00:00Z: renamed "Untitled" → "DRAFT-final-v2" by people/999`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("history missing %q:\n%s", want, got)
+		}
+	}
+	if permissionLists != 1 {
+		t.Errorf("permissions listed %d times, want 1 (cached per call)", permissionLists)
+	}
+
+	if got := formatActivityHistory(nil, newPersonNames(context.Background(), svc, "f1")); !strings.Contains(got, "No renames, moves or sharing changes") {
+		t.Errorf("empty history = %q", got)
+	}
+}
+
+func TestFormatFallbackHistory(t *testing.T) {
+	file := &driveapi.File{LastModifyingUser: &driveapi.User{DisplayName: "Alice", EmailAddress: "alice@example.com"}}
+	got := formatFallbackHistory(file, 7, "no access")
+	for _, want := range []string{
+		"Rename/move/sharing history unavailable: no access",
+		"Versions: 7",
+		"Last modified by: Alice <alice@example.com>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("fallback missing %q:\n%s", want, got)
+		}
+	}
+	if got := formatFallbackHistory(&driveapi.File{}, -1, "x"); strings.Contains(got, "Versions") || strings.Contains(got, "Last modified") {
+		t.Errorf("unknown fields should be omitted:\n%s", got)
+	}
+}
+
+// toolHints is the expected annotation of a tool. Every hint must be set
+// explicitly; clients disagree on the defaults of unset hints.
+type toolHints struct {
+	readOnly, destructive, idempotent, openWorld bool
+}
+
+var (
+	// Reads Google data.
+	readHints = toolHints{readOnly: true, idempotent: true, openWorld: true}
+	// Reads local configuration only.
+	localReadHints = toolHints{readOnly: true, idempotent: true}
+	// Creates or sends something new on each call.
+	createHints = toolHints{openWorld: true}
+	// Adds or restores state; repeating the call changes nothing further.
+	additiveHints = toolHints{idempotent: true, openWorld: true}
+	// Overwrites or removes existing state.
+	destructiveHints = toolHints{destructive: true, idempotent: true, openWorld: true}
+)
+
+func TestToolAnnotationMatrix(t *testing.T) {
+	want := map[string]toolHints{
+		"add_comment":           createHints,
+		"check_file_changed":    readHints,
+		"copy_file":             createHints,
+		"copy_permissions":      additiveHints,
+		"create_folder":         createHints,
+		"create_folder_path":    additiveHints,
+		"create_shared_drive":   createHints,
+		"create_shortcut":       createHints,
+		"delete_file":           destructiveHints,
+		"delete_permission":     destructiveHints,
+		"delete_revision":       destructiveHints,
+		"delete_shared_drive":   destructiveHints,
+		"empty_trash":           destructiveHints,
+		"extract_text":          additiveHints,
+		"find_duplicates":       readHints,
+		"folder_size":           readHints,
+		"get_about":             readHints,
+		"get_file":              readHints,
+		"get_permission":        readHints,
+		"get_revision":          readHints,
+		"get_share_link":        destructiveHints,
+		"get_shared_drive":      readHints,
+		"list_accounts":         localReadHints,
+		"list_changes":          readHints,
+		"list_comments":         readHints,
+		"list_files":            readHints,
+		"list_permissions":      readHints,
+		"list_recent":           readHints,
+		"list_recent_mutations": localReadHints,
+		"list_revisions":        readHints,
+		"list_shared_drives":    readHints,
+		"list_starred":          readHints,
+		"move_file":             destructiveHints,
+		"read_file":             readHints,
+		"reply_comment":         createHints,
+		"resolve_comment":       createHints,
+		"search_files":          readHints,
+		"share_file":            additiveHints,
+		"star_file":             additiveHints,
+		"trash_duplicates":      destructiveHints,
+		"unstar_file":           destructiveHints,
+		"update_file":           destructiveHints,
+		"update_permission":     destructiveHints,
+		"update_shared_drive":   destructiveHints,
+		"upload_file":           createHints,
+	}
+
+	tools := listTools(t, newTestServer(t))
+	if len(tools) != len(want) {
+		t.Errorf("got %d tools, matrix has %d; add new tools to the matrix", len(tools), len(want))
+	}
+	for _, tool := range tools {
+		w, ok := want[tool.Name]
+		if !ok {
+			t.Errorf("tool %q missing from the annotation matrix", tool.Name)
+			continue
+		}
+		a := tool.Annotations
+		if a == nil || a.DestructiveHint == nil || a.OpenWorldHint == nil {
+			t.Errorf("tool %q must set DestructiveHint and OpenWorldHint explicitly", tool.Name)
+			continue
+		}
+		got := toolHints{a.ReadOnlyHint, *a.DestructiveHint, a.IdempotentHint, *a.OpenWorldHint}
+		if got != w {
+			t.Errorf("tool %q annotations = %+v, want %+v", tool.Name, got, w)
+		}
+	}
+}
+
+func TestWriteFileList_Budget(t *testing.T) {
+	var files []*driveapi.File
+	for i := range 50 {
+		files = append(files, &driveapi.File{Id: fmt.Sprintf("id-%02d", i), Name: fmt.Sprintf("file-%02d.txt", i), MimeType: "text/plain"})
+	}
+
+	out := server.NewOutputBuilder(1024)
+	writeFileList(out, files, "work")
+	got := out.String()
+	if !strings.Contains(got, "[output truncated after ") {
+		t.Fatalf("expected truncation notice:\n%s", got)
+	}
+	if len(got) > 1024+100 {
+		t.Errorf("output is %d bytes, budget 1024", len(got))
+	}
+	// Entries are never cut: every listed file has its full entry.
+	body := got[:strings.Index(got, "\n[output truncated")]
+	if n, m := strings.Count(body, "- Name: "), strings.Count(body, "  Type: text/plain\n"); n != m || n == 0 {
+		t.Errorf("%d entries started, %d complete", n, m)
+	}
+
+	if got := formatFileList(files[:3], "work"); strings.Contains(got, "truncated") {
+		t.Errorf("formatFileList should not truncate:\n%s", got)
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	payload := strings.Repeat("0123456789", 300000) // 3 MB
+	c := &countingReader{r: strings.NewReader(payload)}
+	n, err := io.Copy(io.Discard, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.n != n || c.n != int64(len(payload)) {
+		t.Errorf("counted %d bytes, copied %d, want %d", c.n, n, len(payload))
+	}
+	if c.err != nil {
+		t.Errorf("err = %v, want nil at EOF", c.err)
+	}
+
+	// Streaming base64 decode errors surface through the reader.
+	c = &countingReader{r: base64.NewDecoder(base64.StdEncoding, strings.NewReader("aGV$bG8hIGhlbGxvIQ=="))}
+	io.Copy(io.Discard, c)
+	var corrupt base64.CorruptInputError
+	if !errors.As(c.err, &corrupt) {
+		t.Errorf("err = %v, want base64.CorruptInputError", c.err)
+	}
+}
+
+func TestReadFile_SaveToRejectedInReadOnlyMode(t *testing.T) {
+	dir := t.TempDir()
+	lfs, err := localfs.New([]localfs.Dir{{Path: dir, Mode: localfs.ModeReadWrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs.Close()
+
+	srv := server.NewServer(&mcp.Implementation{Name: "test-drive", Version: "test"}, nil)
+	srv.SetLocalFS(lfs)
+	RegisterTools(srv, newTestManager(t))
+	if err := srv.ApplyFilter(server.ToolFilter{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := connect(t, srv).CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "read_file",
+		Arguments: map[string]any{"file_id": "abc", "save_to": "out.bin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("read_file with save_to succeeded in read-only mode")
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "read-only mode") {
+		t.Errorf("error = %q, want read-only error", text)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.bin")); !os.IsNotExist(err) {
+		t.Errorf("out.bin was created: %v", err)
+	}
+}
+
+func TestUploadConversion(t *testing.T) {
+	tests := []struct {
+		name, fileName, mimeType string
+		convert                  bool
+		wantSource, wantTarget   string
+		wantErr                  string
+	}{
+		{name: "plain upload", fileName: "notes.txt", mimeType: "text/plain"},
+		{name: "plain upload keeps workspace-free mime", fileName: "a.pdf"},
+		{name: "convert text", fileName: "notes", mimeType: "text/plain", convert: true, wantSource: "text/plain", wantTarget: googleDocMIME},
+		{name: "convert html with charset", fileName: "page", mimeType: "text/html; charset=utf-8", convert: true, wantSource: "text/html", wantTarget: googleDocMIME},
+		{name: "convert csv by extension", fileName: "Budget.CSV", convert: true, wantSource: "text/csv", wantTarget: googleSheetMIME},
+		{name: "convert docx by extension", fileName: "report.docx", convert: true, wantSource: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", wantTarget: googleDocMIME},
+		{name: "convert xlsx", fileName: "q3.xlsx", convert: true, wantSource: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", wantTarget: googleSheetMIME},
+		{name: "convert pptx", fileName: "deck.pptx", convert: true, wantSource: "application/vnd.openxmlformats-officedocument.presentationml.presentation", wantTarget: googleSlidesMIME},
+		{name: "workspace mime defaults to text", fileName: "Meeting notes", mimeType: googleDocMIME, wantSource: "text/plain", wantTarget: googleDocMIME},
+		{name: "workspace mime uses extension", fileName: "report.docx", mimeType: googleDocMIME, wantSource: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", wantTarget: googleDocMIME},
+		{name: "sheet mime defaults to csv", fileName: "Data", mimeType: googleSheetMIME, wantSource: "text/csv", wantTarget: googleSheetMIME},
+		{name: "mismatched pair", fileName: "data.csv", mimeType: googleDocMIME, wantErr: "text/csv can't be converted to Google Docs"},
+		{name: "slides need a file", fileName: "Deck", mimeType: googleSlidesMIME, wantErr: "needs a .pptx"},
+		{name: "unsupported target", fileName: "x", mimeType: "application/vnd.google-apps.form", wantErr: "can't be converted to application/vnd.google-apps.form"},
+		{name: "unsupported source", fileName: "x.pdf", mimeType: "application/pdf", convert: true, wantErr: "application/pdf can't be converted"},
+		{name: "unknown source", fileName: "blob", convert: true, wantErr: "set mime_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, target, err := uploadConversion(tt.fileName, tt.mimeType, tt.convert)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source != tt.wantSource || target != tt.wantTarget {
+				t.Errorf("uploadConversion() = %q, %q, want %q, %q", source, target, tt.wantSource, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestCreateFile_ConversionMIMETypes(t *testing.T) {
+	var metadata driveapi.File
+	var mediaType, media string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("request Content-Type: %v", err)
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		meta, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.NewDecoder(meta).Decode(&metadata); err != nil {
+			t.Fatal(err)
+		}
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType = part.Header.Get("Content-Type")
+		data, _ := io.ReadAll(part)
+		media = string(data)
+
+		json.NewEncoder(w).Encode(&driveapi.File{
+			Id: "f1", Name: metadata.Name, MimeType: metadata.MimeType,
+			WebViewLink: "https://docs.google.com/spreadsheets/d/f1/edit",
+		})
+	})
+
+	file := &driveapi.File{Name: "Budget", MimeType: googleSheetMIME}
+	created, n, err := createFile(context.Background(), nil, svc, file, strings.NewReader("a,b\n1,2\n"), -1, "text/csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.MimeType != googleSheetMIME {
+		t.Errorf("metadata mimeType = %q, want %q", metadata.MimeType, googleSheetMIME)
+	}
+	if mediaType != "text/csv" {
+		t.Errorf("media Content-Type = %q, want text/csv", mediaType)
+	}
+	if media != "a,b\n1,2\n" || n != int64(len(media)) {
+		t.Errorf("media = %q (%d bytes counted)", media, n)
+	}
+	if created.WebViewLink == "" || created.MimeType != googleSheetMIME {
+		t.Errorf("created = %+v", created)
+	}
+}
+
+// fakeResumableUpload serves the resumable upload protocol for a single
+// file, failing the chunk at offset failAt with 503 the first time it is
+// sent, or with 403 every time if permanent is set. It records the offsets
+// of the chunks it receives.
+type fakeResumableUpload struct {
+	failAt    int64
+	permanent bool
+	failed    bool
+	data      bytes.Buffer
+	offsets   []int64
+}
+
+func (f *fakeResumableUpload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("uploadType") == "resumable" {
+		w.Header().Set("Location", "http://"+r.Host+"/upload/session/1")
+		return
+	}
+	var first, last int64
+	var total string
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%s", &first, &last, &total); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.offsets = append(f.offsets, first)
+	if first == f.failAt && f.permanent {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if first == f.failAt && !f.failed {
+		f.failed = true
+		http.Error(w, "backend error", http.StatusServiceUnavailable)
+		return
+	}
+	if first != int64(f.data.Len()) {
+		http.Error(w, "unexpected offset", http.StatusBadRequest)
+		return
+	}
+	f.data.Write(body)
+	if total == "*" {
+		w.Header().Set("X-Http-Status-Code-Override", "308")
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", last))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&driveapi.File{Id: "f1", Name: "big.bin", Size: int64(f.data.Len())})
+}
+
+func TestCreateFile_ResumableRetriesChunk(t *testing.T) {
+	defer func(n int) { uploadChunkSize = n }(uploadChunkSize)
+	uploadChunkSize = googleapi.MinUploadChunkSize
+
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 3*googleapi.MinUploadChunkSize/16+100)
+	fake := &fakeResumableUpload{failAt: googleapi.MinUploadChunkSize}
+	svc := newFakeService(t, fake.ServeHTTP)
+
+	created, n, err := createFile(context.Background(), nil, svc, &driveapi.File{Name: "big.bin"}, bytes.NewReader(payload), int64(len(payload)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fake.failed {
+		t.Error("the middle chunk never failed")
+	}
+	if !bytes.Equal(fake.data.Bytes(), payload) {
+		t.Errorf("uploaded %d bytes, want the %d byte payload", fake.data.Len(), len(payload))
+	}
+	c := int64(googleapi.MinUploadChunkSize)
+	want := []int64{0, c, c, 2 * c, 3 * c}
+	if fmt.Sprint(fake.offsets) != fmt.Sprint(want) {
+		t.Errorf("chunk offsets = %v, want %v", fake.offsets, want)
+	}
+	if n != int64(len(payload)) || created.Id != "f1" {
+		t.Errorf("created = %+v, %d bytes", created, n)
+	}
+}
+
+func TestCreateFile_ResumableFailureReportsBytesSent(t *testing.T) {
+	defer func(n int) { uploadChunkSize = n }(uploadChunkSize)
+	uploadChunkSize = googleapi.MinUploadChunkSize
+
+	payload := bytes.Repeat([]byte("x"), 2*googleapi.MinUploadChunkSize+1)
+	fake := &fakeResumableUpload{failAt: googleapi.MinUploadChunkSize, permanent: true}
+	svc := newFakeService(t, fake.ServeHTTP)
+
+	_, _, err := createFile(context.Background(), nil, svc, &driveapi.File{Name: "big.bin"}, bytes.NewReader(payload), int64(len(payload)), "")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := fmt.Sprintf("Uploaded %d of %d bytes before the failure", googleapi.MinUploadChunkSize, len(payload))
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestCreateFile_SlowUploadUnderToolTimeout(t *testing.T) {
+	srv := server.NewServer(&mcp.Implementation{Name: "test-drive", Version: "test"}, nil)
+	srv.SetToolTimeout(50 * time.Millisecond)
+	RegisterTools(srv, newTestManager(t))
+	i := slices.IndexFunc(srv.Tools(), func(info server.ToolInfo) bool { return info.Name == "upload_file" })
+	if i < 0 {
+		t.Fatal("upload_file not registered")
+	}
+	limit := srv.Tools()[i].Timeout
+	if limit < uploadChunkRetryDeadline {
+		t.Fatalf("upload_file timeout = %v, want at least the chunk retry deadline %v", limit, uploadChunkRetryDeadline)
+	}
+
+	// Drive takes longer than the server's tool timeout to take the upload.
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(200 * time.Millisecond):
+			w.Write([]byte(`{"id": "f1", "name": "slow.bin"}`))
+		case <-r.Context().Done():
+		}
+	})
+	upload := func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		created, n, err := createFile(ctx, req, svc, &driveapi.File{Name: "slow.bin"}, strings.NewReader("payload"), 7, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("%s %d", created.Id, n)}}}, nil, nil
+	}
+	server.AddTool(srv, &mcp.Tool{Name: "slow_upload"}, upload, server.Timeout(limit))
+	server.AddTool(srv, &mcp.Tool{Name: "slow_upload_default"}, upload)
+
+	session := connect(t, srv)
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "slow_upload"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; res.IsError || text != "f1 7" {
+		t.Errorf("upload with the upload_file timeout = %q (error %v), want it to finish", text, res.IsError)
+	}
+
+	res, err = session.CallTool(context.Background(), &mcp.CallToolParams{Name: "slow_upload_default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, "timed out after 50ms") {
+		t.Errorf("upload under the server's timeout = %q, want it to time out", text)
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	if got := uploadProgress(10, 30); got != "Uploaded 10 of 30 bytes" {
+		t.Errorf("uploadProgress(10, 30) = %q", got)
+	}
+	if got := uploadProgress(10, -1); got != "Uploaded 10 bytes" {
+		t.Errorf("uploadProgress(10, -1) = %q", got)
+	}
+}
+
+// fakeFolderGraph returns a pathResolver over an in-memory folder graph and
+// a map counting lookups per folder ID.
+func fakeFolderGraph(folders ...*driveapi.File) (*pathResolver, map[string]int) {
+	byID := make(map[string]*driveapi.File)
+	for _, f := range folders {
+		byID[f.Id] = f
+	}
+	gets := make(map[string]int)
+	r := &pathResolver{
+		getFolder: func(id string) (*driveapi.File, error) {
+			gets[id]++
+			if id == "root" {
+				id = "my-root"
+			}
+			f, ok := byID[id]
+			if !ok {
+				return nil, &googleapi.Error{Code: http.StatusNotFound, Message: "File not found"}
+			}
+			return f, nil
+		},
+		folders: make(map[string]*driveapi.File),
+	}
+	return r, gets
+}
+
+func TestPathResolver(t *testing.T) {
+	folder := func(id, name string, parents ...string) *driveapi.File {
+		return &driveapi.File{Id: id, Name: name, Parents: parents}
+	}
+	r, gets := fakeFolderGraph(
+		folder("my-root", "My Drive"),
+		folder("projects", "Projects", "my-root"),
+		folder("y2024", "2024", "projects"),
+		folder("reports", "Reports", "y2024"),
+		folder("shared-drive", "Marketing"),
+		folder("campaigns", "Campaigns", "shared-drive"),
+		folder("loop-a", "A", "loop-b"),
+		folder("loop-b", "B", "loop-a"),
+		folder("hidden-child", "Visible", "no-access"),
+	)
+
+	// A 25-level chain to hit the depth cap.
+	deep := []*driveapi.File{folder("d0", "d0", "my-root")}
+	for i := 1; i < 25; i++ {
+		deep = append(deep, folder(fmt.Sprintf("d%d", i), fmt.Sprintf("d%d", i), fmt.Sprintf("d%d", i-1)))
+	}
+	for _, f := range deep {
+		r.folders[f.Id] = f
+	}
+
+	tests := []struct {
+		name string
+		file *driveapi.File
+		want string
+	}{
+		{"nested in My Drive", folder("f1", "q3.pdf", "reports"), "/Projects/2024/Reports/q3.pdf"},
+		{"folder itself", folder("reports", "Reports", "y2024"), "/Projects/2024/Reports"},
+		{"top level", folder("f2", "notes.txt", "my-root"), "/notes.txt"},
+		{"root", folder("my-root", "My Drive"), "/"},
+		{"shared drive", folder("f3", "plan.doc", "campaigns"), "Marketing/Campaigns/plan.doc"},
+		{"shared with me, no parents", folder("f4", "shared.pdf"), "shared.pdf"},
+		{"inaccessible parent", folder("f5", "x.txt", "hidden-child"), "…/Visible/x.txt"},
+		{"cycle", folder("f6", "loop.txt", "loop-a"), "…/B/A/loop.txt"},
+		{"depth cap", folder("f7", "deep.txt", "d24"), "…/" + strings.Join(func() []string {
+			var names []string
+			for i := 5; i < 25; i++ {
+				names = append(names, fmt.Sprintf("d%d", i))
+			}
+			return names
+		}(), "/") + "/deep.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.path(tt.file); got != tt.want {
+				t.Errorf("path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Each folder is fetched at most once across all lookups.
+	for id, n := range gets {
+		if n > 1 {
+			t.Errorf("folder %s fetched %d times, want at most 1", id, n)
+		}
+	}
+	if gets["reports"] != 1 || gets["my-root"] != 1 {
+		t.Errorf("gets = %v, want reports and my-root fetched once", gets)
+	}
+}
+
+func TestFormatFileListWithPaths(t *testing.T) {
+	r, _ := fakeFolderGraph(
+		&driveapi.File{Id: "my-root", Name: "My Drive"},
+		&driveapi.File{Id: "docs", Name: "Docs", Parents: []string{"my-root"}},
+	)
+	got := formatFileListWithPaths([]*driveapi.File{
+		{Id: "f1", Name: "a.txt", MimeType: "text/plain", Parents: []string{"docs"}},
+	}, "work", r)
+	want := "Found 1 files:\n\n- Name: a.txt\n  File ID: f1\n  Account: work\n  Type: text/plain\n  Path: /Docs/a.txt\n\n"
+	if got != want {
+		t.Errorf("formatFileListWithPaths() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	tests := []struct {
+		name                               string
+		query, fullText, nameContains, mim string
+		want                               string
+		wantErr                            bool
+	}{
+		{name: "raw query only", query: "starred = true", want: "starred = true"},
+		{name: "full text", fullText: "budget", want: "fullText contains 'budget'"},
+		{name: "single quote", fullText: "Bob's plan", want: `fullText contains 'Bob\'s plan'`},
+		{name: "backslash", nameContains: `C:\temp`, want: `name contains 'C:\\temp'`},
+		{name: "backslash before quote", nameContains: `a\'b`, want: `name contains 'a\\\'b'`},
+		{name: "double quotes untouched", fullText: `say "hi"`, want: `fullText contains 'say "hi"'`},
+		{name: "unicode", fullText: "προϋπολογισμός 2024 – café", want: "fullText contains 'προϋπολογισμός 2024 – café'"},
+		{name: "mime type", mim: "application/pdf", want: "mimeType = 'application/pdf'"},
+		{
+			name:  "all combined",
+			query: "modifiedTime > '2024-01-01T00:00:00' or starred = true", fullText: "budget", nameContains: "Q3", mim: "application/pdf",
+			want: "(modifiedTime > '2024-01-01T00:00:00' or starred = true) and fullText contains 'budget' and name contains 'Q3' and mimeType = 'application/pdf'",
+		},
+		{name: "structured only", fullText: "budget", nameContains: "report", want: "fullText contains 'budget' and name contains 'report'"},
+		{name: "whitespace query ignored", query: "  ", nameContains: "x", want: "name contains 'x'"},
+		{name: "nothing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildSearchQuery(tt.query, tt.fullText, tt.nameContains, tt.mim)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildSearchQuery() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("buildSearchQuery() = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankTerms_EscapedLiterals(t *testing.T) {
+	q, err := buildSearchQuery("", `Bob's C:\notes`, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rankTerms(q); len(got) != 1 || got[0] != `bob's c:\notes` {
+		t.Errorf("rankTerms(%s) = %q", q, got)
+	}
+}
+
+// TestListAccountsShared checks that the Drive server exposes list_accounts
+// exactly once, as server.RegisterAccountsListTool defines it for every
+// service, and keeps it under an --enable list that omits it.
+// fakeShortcutFiles serves Files.Get metadata for files from memory and
+// records the IDs requested.
+func fakeShortcutFiles(t *testing.T, files map[string]*driveapi.File, got *[]string) *driveapi.Service {
+	return newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/files/")
+		*got = append(*got, id)
+		f, ok := files[id]
+		if !ok {
+			http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(f)
+	})
+}
+
+func shortcut(id, name, target string) *driveapi.File {
+	return &driveapi.File{Id: id, Name: name, MimeType: shortcutMIME,
+		ShortcutDetails: &driveapi.FileShortcutDetails{TargetId: target}}
+}
+
+func TestFollowShortcut(t *testing.T) {
+	doc := &driveapi.File{Id: "doc", Name: "Report", MimeType: "application/vnd.google-apps.document"}
+	files := map[string]*driveapi.File{
+		"doc":   doc,
+		"s1":    shortcut("s1", "Report shortcut", "doc"),
+		"s2":    shortcut("s2", "Shortcut to shortcut", "s1"),
+		"loop":  shortcut("loop", "Loop", "loop"),
+		"stale": shortcut("stale", "Stale", "deleted"),
+	}
+
+	t.Run("not a shortcut", func(t *testing.T) {
+		var got []string
+		svc := fakeShortcutFiles(t, files, &got)
+		target, chain, err := followShortcut(context.Background(), svc, doc, "id,name,mimeType,shortcutDetails(targetId)")
+		if err != nil || target != doc || len(chain) != 0 || len(got) != 0 {
+			t.Errorf("target = %v, chain = %v, requests = %v, err = %v", target, chain, got, err)
+		}
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		var got []string
+		svc := fakeShortcutFiles(t, files, &got)
+		target, chain, err := followShortcut(context.Background(), svc, files["s2"], "id,name,mimeType,shortcutDetails(targetId)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target.Id != "doc" || len(chain) != 2 || fmt.Sprint(got) != "[s1 doc]" {
+			t.Errorf("target = %s, chain length = %d, requests = %v", target.Id, len(chain), got)
+		}
+		want := "[Followed shortcut: Shortcut to shortcut (s2) -> Report shortcut (s1) -> Report (doc)]\n\n"
+		if note := shortcutNote(chain, target); note != want {
+			t.Errorf("note = %q, want %q", note, want)
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		var got []string
+		svc := fakeShortcutFiles(t, files, &got)
+		_, _, err := followShortcut(context.Background(), svc, files["loop"], "id,name,mimeType,shortcutDetails(targetId)")
+		if err == nil || !strings.Contains(err.Error(), "more than 5 shortcuts") {
+			t.Fatalf("err = %v, want depth limit error", err)
+		}
+		if len(got) != maxShortcutDepth {
+			t.Errorf("made %d requests, want %d", len(got), maxShortcutDepth)
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		var got []string
+		svc := fakeShortcutFiles(t, files, &got)
+		_, _, err := followShortcut(context.Background(), svc, files["stale"], "id,name,mimeType,shortcutDetails(targetId)")
+		if err == nil || !strings.Contains(err.Error(), "getting target deleted of shortcut stale") {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
+
+func TestShortcutTarget(t *testing.T) {
+	var got []string
+	svc := fakeShortcutFiles(t, map[string]*driveapi.File{
+		"doc": {Id: "doc", Name: "Report"},
+	}, &got)
+	if line := shortcutTarget(context.Background(), svc, shortcut("s1", "x", "doc")); line != "Shortcut to: Report (doc)\n" {
+		t.Errorf("line = %q", line)
+	}
+	if line := shortcutTarget(context.Background(), svc, shortcut("s2", "x", "gone")); !strings.HasPrefix(line, "Shortcut to: gone (target not accessible") {
+		t.Errorf("line = %q", line)
+	}
+
+	var sb strings.Builder
+	writeFileEntry(&sb, shortcut("s1", "Report", "doc"), "work")
+	if !strings.Contains(sb.String(), "  Shortcut to: doc\n") {
+		t.Errorf("list entry should show the target ID:\n%s", sb.String())
+	}
+}
+
+func TestFileColumns(t *testing.T) {
+	table, err := server.NewTable("csv", append(slices.Clip(fileColumns), filePathColumn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Add(
+		fileRow{Account: "work", Path: "/Finance/Budget, 2026.xlsx", File: &driveapi.File{Id: "f1", Name: "Budget, 2026.xlsx",
+			MimeType: "application/vnd.ms-excel", Size: 1024, ModifiedTime: "2026-03-01T10:00:00Z",
+			Owners: []*driveapi.User{{DisplayName: "Alice"}, {DisplayName: "Bob"}}}},
+		fileRow{Account: "work", Path: "/Budget", File: shortcut("s1", "Budget", "f1")},
+	)
+	want := "account,file_id,name,mime_type,size,modified,owners,link,shortcut_to,path\r\n" +
+		"work,f1,\"Budget, 2026.xlsx\",application/vnd.ms-excel,1024,2026-03-01T10:00:00Z,Alice; Bob,,,\"/Finance/Budget, 2026.xlsx\"\r\n" +
+		"work,s1,Budget,application/vnd.google-apps.shortcut,0,,,,f1,/Budget\r\n"
+	if got := table.String(); got != want {
+		t.Errorf("csv =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTrashFile(t *testing.T) {
+	const notOwner = `{"error": {"code": 403, "message": "The user does not have sufficient permissions for this file.", "errors": [{"reason": "insufficientFilePermissions"}]}}`
+	// newService fakes Drive for a file in folders p1 and p2, of which the
+	// user owns those in owned. Trash requests fail with trashErr (if set),
+	// and removing the parents with removeErr.
+	newService := func(t *testing.T, requests *[]string, trashErr, removeErr string, owned ...string) *driveapi.Service {
+		if owned == nil {
+			owned = []string{"p1", "p2"}
+		}
+		return newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			q := r.URL.Query()
+			switch {
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files/f1"):
+				*requests = append(*requests, "get")
+				w.Write([]byte(`{"id": "f1", "name": "Budget", "parents": ["p1", "p2"], "owners": [{"displayName": "Alice", "emailAddress": "alice@example.com"}]}`))
+				return
+			case r.Method == http.MethodGet:
+				id := path.Base(r.URL.Path)
+				*requests = append(*requests, "get "+id)
+				json.NewEncoder(w).Encode(map[string]any{"id": id, "name": "Folder " + id, "ownedByMe": slices.Contains(owned, id)})
+				return
+			case body["trashed"] == true:
+				*requests = append(*requests, "trash")
+				if trashErr != "" {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(trashErr))
+					return
+				}
+			case q.Has("removeParents"):
+				*requests = append(*requests, "removeParents="+q.Get("removeParents"))
+				if removeErr != "" {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(removeErr))
+					return
+				}
+			}
+			w.Write([]byte(`{"id": "f1"}`))
+		})
+	}
+
+	t.Run("owned", func(t *testing.T) {
+		var requests []string
+		text, err := trashFile(context.Background(), newService(t, &requests, "", ""), "f1", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "File f1 moved to trash." || strings.Join(requests, ",") != "trash" {
+			t.Errorf("text = %q, requests = %v", text, requests)
+		}
+	})
+
+	t.Run("not owner", func(t *testing.T) {
+		var requests []string
+		_, err := trashFile(context.Background(), newService(t, &requests, notOwner, ""), "f1", false)
+		if err == nil || !strings.Contains(err.Error(), `only the owner can trash "Budget" (owned by Alice <alice@example.com>)`) ||
+			!strings.Contains(err.Error(), "remove_from_my_drive_if_not_owner") {
+			t.Errorf("err = %v, want it to name the owner and the option", err)
+		}
+		if strings.Join(requests, ",") != "trash,get" {
+			t.Errorf("requests = %v, want trash and get only", requests)
+		}
+	})
+
+	t.Run("remove from my drive", func(t *testing.T) {
+		var requests []string
+		text, err := trashFile(context.Background(), newService(t, &requests, notOwner, ""), "f1", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(requests, ",") != "trash,get,get p1,get p2,removeParents=p1,p2" {
+			t.Errorf("requests = %v", requests)
+		}
+		if !strings.Contains(text, "owned by Alice <alice@example.com>, so instead of moving it to trash it was removed from your folders: Folder p1 (p1), Folder p2 (p2).") ||
+			strings.Contains(text, "left unchanged") {
+			t.Errorf("text = %q", text)
+		}
+	})
+
+	t.Run("parent owned by someone else is kept", func(t *testing.T) {
+		var requests []string
+		text, err := trashFile(context.Background(), newService(t, &requests, notOwner, "", "p2"), "f1", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(requests, ",") != "trash,get,get p1,get p2,removeParents=p2" {
+			t.Errorf("requests = %v, want only p2 removed", requests)
+		}
+		if !strings.Contains(text, "removed from your folders: Folder p2 (p2).") ||
+			!strings.Contains(text, "folders owned by others, which were left unchanged: Folder p1 (p1).") {
+			t.Errorf("text = %q", text)
+		}
+	})
+
+	t.Run("no parent owned", func(t *testing.T) {
+		var requests []string
+		_, err := trashFile(context.Background(), newService(t, &requests, notOwner, "", []string{}...), "f1", true)
+		if err == nil || !strings.Contains(err.Error(), "only in folders you don't own (Folder p1 (p1), Folder p2 (p2))") {
+			t.Errorf("err = %v", err)
+		}
+		if strings.Join(requests, ",") != "trash,get,get p1,get p2" {
+			t.Errorf("requests = %v, want no folder removed", requests)
+		}
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		var requests []string
+		_, err := trashFile(context.Background(), newService(t, &requests, notOwner, notOwner), "f1", true)
+		if err == nil || !strings.Contains(err.Error(), "removing it from your folders failed too") || !strings.Contains(err.Error(), "Alice") {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		var requests []string
+		_, err := trashFile(context.Background(), newService(t, &requests, `{"error": {"code": 403, "message": "Rate limit", "errors": [{"reason": "userRateLimitExceeded"}]}}`, ""), "f1", true)
+		if err == nil || !strings.HasPrefix(err.Error(), "trashing file") || strings.Contains(err.Error(), "owner") {
+			t.Errorf("err = %v, want the plain trash error", err)
+		}
+		if strings.Join(requests, ",") != "trash" {
+			t.Errorf("requests = %v, want no fallback", requests)
+		}
+	})
+}
+
+func TestFileExists(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("fields"); got != "id" {
+			t.Errorf("fields = %q, want id only", got)
+		}
+		switch path.Base(r.URL.Path) {
+		case "here":
+			w.Write([]byte(`{"id": "here"}`))
+		case "elsewhere":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "File not found: elsewhere."}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	for id, want := range map[string]bool{"here": true, "elsewhere": false} {
+		found, err := fileExists(context.Background(), svc, id)
+		if err != nil || found != want {
+			t.Errorf("fileExists(%s) = %v, %v; want %v", id, found, err, want)
+		}
+	}
+	if _, err := fileExists(context.Background(), svc, "broken"); err == nil {
+		t.Error("fileExists(broken) succeeded, want the server error")
+	}
+}
+
+func TestCreateSharePermission(t *testing.T) {
+	type request struct {
+		query url.Values
+		perm  driveapi.Permission
+	}
+	newService := func(t *testing.T, requests *[]request, consent bool) *driveapi.Service {
+		return newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+			var perm driveapi.Permission
+			json.NewDecoder(r.Body).Decode(&perm)
+			*requests = append(*requests, request{r.URL.Query(), perm})
+			if consent && perm.Role == "owner" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error": {"code": 403, "message": "Consent is required to transfer ownership of a file to another user.", "errors": [{"reason": "consentRequiredForOwnershipTransfer"}]}}`))
+				return
+			}
+			perm.Id = "p1"
+			json.NewEncoder(w).Encode(&perm)
+		})
+	}
+
+	t.Run("writer", func(t *testing.T) {
+		var requests []request
+		svc := newService(t, &requests, false)
+		if _, err := createSharePermission(context.Background(), svc, "f1", &driveapi.Permission{Role: "writer", Type: "user", EmailAddress: "bob@example.com"}, false, "hi"); err != nil {
+			t.Fatal(err)
+		}
+		q := requests[0].query
+		if q.Has("transferOwnership") || q.Get("sendNotificationEmail") != "false" || q.Has("emailMessage") {
+			t.Errorf("query = %v, want no transferOwnership, no notification and no message", q)
+		}
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		var requests []request
+		svc := newService(t, &requests, false)
+		created, err := createSharePermission(context.Background(), svc, "f1", &driveapi.Permission{Role: "owner", Type: "user", EmailAddress: "bob@example.com"}, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := requests[0].query
+		if len(requests) != 1 || q.Get("transferOwnership") != "true" || q.Get("sendNotificationEmail") != "true" {
+			t.Errorf("requests = %+v, want one with transferOwnership and a notification", requests)
+		}
+		if created.Role != "owner" || created.PendingOwner {
+			t.Errorf("created = %+v, want owner", created)
+		}
+	})
+
+	t.Run("consent required", func(t *testing.T) {
+		var requests []request
+		svc := newService(t, &requests, true)
+		created, err := createSharePermission(context.Background(), svc, "f1", &driveapi.Permission{Role: "owner", Type: "user", EmailAddress: "bob@example.com"}, false, "Over to you")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(requests) != 2 {
+			t.Fatalf("got %d requests, want the transfer and a pending-owner fallback", len(requests))
+		}
+		fallback := requests[1]
+		if fallback.perm.Role != "writer" || !fallback.perm.PendingOwner || fallback.perm.EmailAddress != "bob@example.com" {
+			t.Errorf("fallback permission = %+v, want pending owner writer", fallback.perm)
+		}
+		if fallback.query.Has("transferOwnership") || fallback.query.Get("emailMessage") != "Over to you" {
+			t.Errorf("fallback query = %v", fallback.query)
+		}
+		if !created.PendingOwner {
+			t.Errorf("created = %+v, want PendingOwner", created)
+		}
+	})
+}
+
+func TestCheckOwnershipTransfer(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		file := &driveapi.File{Id: path.Base(r.URL.Path), Name: "Budget"}
+		if file.Id == "shared" {
+			file.DriveId = "drive1"
+		}
+		json.NewEncoder(w).Encode(file)
+	})
+
+	if err := checkOwnershipTransfer(context.Background(), svc, "mine"); err != nil {
+		t.Errorf("My Drive item: %v", err)
+	}
+	if err := checkOwnershipTransfer(context.Background(), svc, "shared"); err == nil || !strings.Contains(err.Error(), "shared drive") || !strings.Contains(err.Error(), "organizer") {
+		t.Errorf("shared drive item: err = %v, want shared drive explanation", err)
+	}
+}
+
+func TestCheckFileChanged(t *testing.T) {
+	const current = `{"id":"f1","name":"Spec v2","size":"1500","md5Checksum":"new","version":"14",
+		"modifiedTime":"2024-03-01T12:30:00.000Z","lastModifyingUser":{"displayName":"Ana","emailAddress":"ana@example.com"}}`
+	var gotFields string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		gotFields = r.URL.Query().Get("fields")
+		if strings.HasSuffix(r.URL.Path, "/files/gone") {
+			http.Error(w, `{"error":{"code":404,"message":"File not found"}}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(current))
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		prev := fileBaseline{Name: "Spec", Size: 1200, MD5Checksum: "old", Version: 12, ModifiedTime: "2024-03-01T09:00:00Z"}
+		got, err := checkFileChanged(context.Background(), svc, "f1", prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotFields != fileBaselineFields {
+			t.Errorf("fields = %q, want %q", gotFields, fileBaselineFields)
+		}
+		for _, want := range []string{
+			"Changed: yes\n",
+			"Last modified by: Ana <ana@example.com>\n",
+			"  - Name: Spec -> Spec v2\n",
+			"  - Version: 12 -> 14 (+2)\n",
+			"  - Content (MD5): old -> new\n",
+			"  - Size: 1200 bytes -> 1500 bytes (+300 bytes)\n",
+			"  - Modified: 2024-03-01T09:00:00Z -> 2024-03-01T12:30:00.000Z (3h30m0s later)\n",
+			"  md5_checksum: new\n  version: 14\n  modified_time: 2024-03-01T12:30:00.000Z\n  name: Spec v2\n  size: 1500\n",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("result missing %q:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		// Only the fields the caller knows are compared, and equal times
+		// in another RFC3339 spelling are not a change.
+		prev := fileBaseline{MD5Checksum: "new", Version: 14, ModifiedTime: "2024-03-01T12:30:00Z"}
+		got, err := checkFileChanged(context.Background(), svc, "f1", prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(got, "Changed: no\n") || strings.Contains(got, "Changes:") {
+			t.Errorf("result =\n%s", got)
+		}
+		if !strings.Contains(got, "  version: 14\n") {
+			t.Errorf("result missing baseline:\n%s", got)
+		}
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		got, err := checkFileChanged(context.Background(), svc, "gone", fileBaseline{Name: "Old spec", Version: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "Changed: yes — file removed\nFile ID: gone\nName: Old spec\n"
+		if !strings.HasPrefix(got, want) || strings.Contains(got, "New baseline") {
+			t.Errorf("result =\n%s\nwant prefix\n%s", got, want)
+		}
+	})
+}
+
+func TestCompareBaseline_Earlier(t *testing.T) {
+	changes := compareBaseline(
+		fileBaseline{ModifiedTime: "2024-03-02T00:00:00Z"},
+		fileBaseline{ModifiedTime: "2024-03-01T23:00:00Z"},
+	)
+	if len(changes) != 1 || changes[0].Detail != "1h0m0s earlier" {
+		t.Errorf("compareBaseline() = %+v", changes)
+	}
+}
+
+func dupFile(id, name, md5 string, size int64, created string) *driveapi.File {
+	return &driveapi.File{Id: id, Name: name, MimeType: "application/pdf", Md5Checksum: md5, Size: size, CreatedTime: created}
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	files := []*driveapi.File{
+		dupFile("b", "report (1).pdf", "aaa", 1000, "2024-02-01T00:00:00Z"),
+		dupFile("a", "report.pdf", "aaa", 1000, "2024-01-01T00:00:00Z"),
+		dupFile("c", "report (2).pdf", "aaa", 1000, "2024-03-01T00:00:00Z"),
+		dupFile("d", "unique.pdf", "bbb", 5000, "2024-01-01T00:00:00Z"),
+		dupFile("e", "big.iso", "ccc", 9000, "2024-01-05T00:00:00Z"),
+		dupFile("f", "big copy.iso", "ccc", 9000, "2024-01-02T00:00:00Z"),
+		// No checksum: matched by name and size.
+		dupFile("g", "empty.txt", "", 0, "2024-01-01T00:00:00Z"),
+		dupFile("h", "empty.txt", "", 0, "2024-01-02T00:00:00Z"),
+		dupFile("i", "empty.txt", "", 10, "2024-01-03T00:00:00Z"),
+		// Workspace files have no checksum and are skipped.
+		{Id: "j", Name: "Notes", MimeType: "application/vnd.google-apps.document"},
+		{Id: "k", Name: "Notes", MimeType: "application/vnd.google-apps.document"},
+	}
+	groups := groupDuplicates(files)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(groups), groups)
+	}
+
+	ids := func(fs []*driveapi.File) string {
+		var out []string
+		for _, f := range fs {
+			out = append(out, f.Id)
+		}
+		return strings.Join(out, ",")
+	}
+	// Sorted by reclaimable bytes, copies oldest first.
+	for i, want := range []struct {
+		ids         string
+		byChecksum  bool
+		reclaimable int64
+	}{
+		{"f,e", true, 9000},
+		{"a,b,c", true, 2000},
+		{"g,h", false, 0},
+	} {
+		g := groups[i]
+		if got := ids(g.Files); got != want.ids || g.ByChecksum != want.byChecksum || g.Reclaimable() != want.reclaimable {
+			t.Errorf("group %d = %s (checksum %v, reclaimable %d), want %+v", i, got, g.ByChecksum, g.Reclaimable(), want)
+		}
+	}
+
+	if got := ids(groups[1].redundant(true)); got != "a,b" {
+		t.Errorf("redundant(keep newest) = %s, want a,b", got)
+	}
+	if got := ids(groups[1].redundant(false)); got != "b,c" {
+		t.Errorf("redundant(keep oldest) = %s, want b,c", got)
+	}
+
+	// Files without a creation time are ordered by modification time.
+	groups = groupDuplicates([]*driveapi.File{
+		{Id: "x", Name: "x", Md5Checksum: "m", ModifiedTime: "2024-05-01T00:00:00Z"},
+		{Id: "y", Name: "y", Md5Checksum: "m", ModifiedTime: "2024-04-01T00:00:00Z"},
+	})
+	if len(groups) != 1 || ids(groups[0].Files) != "y,x" {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestFormatDuplicateGroups(t *testing.T) {
+	groups := groupDuplicates([]*driveapi.File{
+		dupFile("a", "report.pdf", "aaa", 2048, "2024-01-01T00:00:00Z"),
+		dupFile("b", "report (1).pdf", "aaa", 2048, "2024-02-01T00:00:00Z"),
+		dupFile("c", "report (2).pdf", "aaa", 2048, "2024-03-01T00:00:00Z"),
+	})
+	got := formatDuplicateGroups(groups, duplicateScan{Files: make([]*driveapi.File, 3), Folders: 2, Capped: true})
+	for _, want := range []string{
+		"Scanned 3 files in 2 folders. Stopped at max_files",
+		"Found 1 groups of duplicates with 2 redundant copies. Reclaimable: 4.00 KB (4096 bytes)\n",
+		"\n1. report.pdf — 3 copies of 2.00 KB (2048 bytes) (same MD5 checksum)\n",
+		"   - report.pdf (ID: a, created 2024-01-01T00:00:00Z) [oldest]\n",
+		"   - report (1).pdf (ID: b, created 2024-02-01T00:00:00Z)\n",
+		"   - report (2).pdf (ID: c, created 2024-03-01T00:00:00Z) [newest]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := formatDuplicateGroups(nil, duplicateScan{Files: make([]*driveapi.File, 5)}); got != "Scanned 5 files.\nNo duplicates found.\n" {
+		t.Errorf("no duplicates = %q", got)
+	}
+}
+
+func TestWalkFolderSize(t *testing.T) {
+	// root > a > b > c, with a link from a back to root that must not be
+	// walked twice. root's listing has two pages.
+	pages := map[string]string{
+		"root":    `{"nextPageToken":"p2","files":[{"id":"a","mimeType":"application/vnd.google-apps.folder"},{"id":"f1","name":"one.pdf","mimeType":"application/pdf","size":"10"}]}`,
+		"root/p2": `{"files":[{"id":"doc","name":"Notes","mimeType":"application/vnd.google-apps.document"}]}`,
+		"a":       `{"files":[{"id":"b","mimeType":"application/vnd.google-apps.folder"},{"id":"f2","name":"two.mov","mimeType":"video/quicktime","size":"30"},{"id":"root","mimeType":"application/vnd.google-apps.folder"}]}`,
+		"b":       `{"files":[{"id":"c","mimeType":"application/vnd.google-apps.folder"},{"id":"f3","name":"three.jpg","mimeType":"image/jpeg","size":"20"}]}`,
+		"c":       `{"files":[{"id":"f4","name":"four.txt","mimeType":"text/plain","size":"5"}]}`,
+	}
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("fields") != folderSizeFields {
+			t.Errorf("fields = %q", q.Get("fields"))
+		}
+		folder, _, _ := strings.Cut(strings.TrimPrefix(q.Get("q"), "'"), "'")
+		key := folder
+		if tok := q.Get("pageToken"); tok != "" {
+			key += "/" + tok
+		}
+		body, ok := pages[key]
+		if !ok {
+			t.Errorf("unexpected request for %q", key)
+			http.Error(w, "unexpected", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+	ids := func(files []*driveapi.File) string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Id)
+		}
+		return strings.Join(out, ",")
+	}
+
+	size, err := walkFolderSize(context.Background(), svc, "root", 20, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size.Bytes != 65 || size.Files != 4 || size.WorkspaceFiles != 1 || size.Folders != 3 || size.FilesCapped || size.SkippedFolders != 0 {
+		t.Errorf("full walk = %+v", size)
+	}
+	if got := ids(size.Largest); got != "f2,f3,f1,f4" {
+		t.Errorf("largest = %s, want f2,f3,f1,f4", got)
+	}
+
+	size, err = walkFolderSize(context.Background(), svc, "root", 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size.Bytes != 40 || size.Folders != 1 || size.SkippedFolders != 1 || size.FilesCapped {
+		t.Errorf("depth 1 = %+v, want a walked and b skipped", size)
+	}
+
+	size, err = walkFolderSize(context.Background(), svc, "root", 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !size.FilesCapped || size.Files != 1 || size.WorkspaceFiles != 1 || size.Bytes != 10 {
+		t.Errorf("max 2 files = %+v, want capped after one.pdf and Notes", size)
+	}
+}
+
+func TestFormatFolderSize(t *testing.T) {
+	folder := &driveapi.File{Id: "root", Name: "Photos 2023"}
+	size := folderSize{
+		Bytes:          3 * 1024 * 1024,
+		Files:          2,
+		WorkspaceFiles: 1,
+		Folders:        4,
+		Largest: []*driveapi.File{
+			{Id: "f1", Name: "big.mov", Size: 2 * 1024 * 1024},
+			{Id: "f2", Name: "small.jpg", Size: 1024 * 1024},
+		},
+		FilesCapped:    true,
+		SkippedFolders: 2,
+	}
+	got := formatFolderSize(folder, size, 500, 3)
+	for _, want := range []string{
+		"Folder: Photos 2023\nFolder ID: root\n",
+		"Total size: 3.00 MB (3145728 bytes)\n",
+		"Files: 2\nGoogle Workspace files: 1 (no stored size, not included in the total)\nSubfolders: 4\n",
+		"Stopped after 500 files",
+		"2 subfolders more than 3 levels deep were not scanned",
+		"1. big.mov — 2.00 MB (2097152 bytes)\n   File ID: f1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+
+	got = formatFolderSize(folder, folderSize{}, 500, 3)
+	if strings.Contains(got, "Partial") || strings.Contains(got, "Largest") || strings.Contains(got, "Workspace") {
+		t.Errorf("empty folder output:\n%s", got)
+	}
+}
+
+func TestScanFolderFiles(t *testing.T) {
+	// root contains sub and two pages of files; sub contains a file and a
+	// link back to root, which must not be walked twice.
+	pages := map[string]string{
+		"root":    `{"nextPageToken":"p2","files":[{"id":"sub","mimeType":"application/vnd.google-apps.folder"},{"id":"f1","mimeType":"application/pdf"}]}`,
+		"root/p2": `{"files":[{"id":"f2","mimeType":"application/pdf"}]}`,
+		"sub":     `{"files":[{"id":"f3","mimeType":"application/pdf"},{"id":"root","mimeType":"application/vnd.google-apps.folder"}]}`,
+	}
+	var queries []string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("fields") != duplicateFileFields {
+			t.Errorf("fields = %q", q.Get("fields"))
+		}
+		queries = append(queries, q.Get("q"))
+		folder, _, _ := strings.Cut(strings.TrimPrefix(q.Get("q"), "'"), "'")
+		key := folder
+		if tok := q.Get("pageToken"); tok != "" {
+			key += "/" + tok
+		}
+		body, ok := pages[key]
+		if !ok {
+			t.Errorf("unexpected request for %q", key)
+			http.Error(w, "unexpected", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	scan, err := scanFolderFiles(context.Background(), svc, "root", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, f := range scan.Files {
+		ids = append(ids, f.Id)
+	}
+	if strings.Join(ids, ",") != "f1,f2,f3" || scan.Folders != 2 || scan.Capped {
+		t.Errorf("scan = %v, %d folders, capped %v", ids, scan.Folders, scan.Capped)
+	}
+	if len(queries) != 3 || queries[0] != "'root' in parents and trashed = false" {
+		t.Errorf("queries = %q", queries)
+	}
+
+	scan, err = scanFolderFiles(context.Background(), svc, "root", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scan.Files) != 2 || !scan.Capped {
+		t.Errorf("capped scan = %d files, capped %v", len(scan.Files), scan.Capped)
+	}
+}
+
+func TestSplitFolderPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"Projects/2024/Q3/Reports", []string{"Projects", "2024", "Q3", "Reports"}},
+		{"/Projects//2024/", []string{"Projects", "2024"}},
+		{`Clients/A\/B Testing`, []string{"Clients", "A/B Testing"}},
+		{`Back\\slash/x`, []string{`Back\slash`, "x"}},
+		{" Spaced / Out ", []string{"Spaced", "Out"}},
+	}
+	for _, tt := range tests {
+		got, err := splitFolderPath(tt.path)
+		if err != nil {
+			t.Errorf("splitFolderPath(%q): %v", tt.path, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitFolderPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "///", `trailing\`, `bad\escape`, strings.Repeat("a/", maxFolderPathSegments+1)} {
+		if _, err := splitFolderPath(bad); err == nil {
+			t.Errorf("splitFolderPath(%q): expected an error", bad)
+		}
+	}
+}
+
+// fakeFolderTree serves Files.List lookups of folders by name and parent
+// and Files.Create of folders over an in-memory tree.
+type fakeFolderTree struct {
+	folders    []*driveapi.File
+	failCreate bool
+	lookups    int
+	creates    int
+}
+
+var folderQuery = regexp.MustCompile(`^name = '((?:[^'\\]|\\.)*)' and '([^']*)' in parents and mimeType = '` + folderMIME + `' and trashed = false$`)
+
+func (f *fakeFolderTree) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+		m := folderQuery.FindStringSubmatch(r.URL.Query().Get("q"))
+		if m == nil {
+			http.Error(w, "unexpected query "+r.URL.Query().Get("q"), http.StatusBadRequest)
+			return
+		}
+		f.lookups++
+		name := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(m[1])
+		resp := &driveapi.FileList{}
+		for _, folder := range f.folders {
+			if folder.Name == name && slices.Contains(folder.Parents, m[2]) {
+				resp.Files = append(resp.Files, folder)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	case http.MethodPost:
+		if f.failCreate {
+			http.Error(w, `{"error":{"code":403,"message":"Insufficient permissions"}}`, http.StatusForbidden)
+			return
+		}
+		var folder driveapi.File
+		json.NewDecoder(r.Body).Decode(&folder)
+		f.creates++
+		folder.Id = fmt.Sprintf("new%d", f.creates)
+		f.folders = append(f.folders, &folder)
+		json.NewEncoder(w).Encode(&folder)
+	}
+}
+
+func TestEnsureFolderPath(t *testing.T) {
+	tree := &fakeFolderTree{folders: []*driveapi.File{
+		{Id: "projects", Name: "Projects", Parents: []string{"root"}},
+		{Id: "2024-old", Name: "2024", Parents: []string{"projects"}},
+		{Id: "2024-new", Name: "2024", Parents: []string{"projects"}},
+		{Id: "elsewhere", Name: "Q3", Parents: []string{"other"}},
+	}}
+	svc := newFakeService(t, tree.ServeHTTP)
+
+	names := []string{"Projects", "2024", "Q3", "Reports"}
+	segments, err := ensureFolderPath(context.Background(), svc, "root", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range segments {
+		got = append(got, fmt.Sprintf("%s=%s/%v", s.Name, s.ID, s.Created))
+	}
+	want := []string{"Projects=projects/false", "2024=2024-old/false", "Q3=new1/true", "Reports=new2/true"}
+	if !slices.Equal(got, want) {
+		t.Errorf("segments = %v, want %v", got, want)
+	}
+	if segments[1].Matches != 2 {
+		t.Errorf("2024 matches = %d, want 2", segments[1].Matches)
+	}
+	// Once Q3 was created, Reports is created without a lookup.
+	if tree.lookups != 3 || tree.creates != 2 {
+		t.Errorf("lookups = %d, creates = %d, want 3 and 2", tree.lookups, tree.creates)
+	}
+	if parents := tree.folders[len(tree.folders)-1].Parents; !slices.Equal(parents, []string{"new1"}) {
+		t.Errorf("Reports parents = %v, want [new1]", parents)
+	}
+
+	// Walking the same path again reuses every folder.
+	again, err := ensureFolderPath(context.Background(), svc, "root", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.creates != 2 || again[3].ID != "new2" || again[3].Created {
+		t.Errorf("second walk: creates = %d, last = %+v", tree.creates, again[3])
+	}
+	text := formatFolderPath(names, again)
+	for _, want := range []string{"Folder path already exists.", "Folder ID: new2", "  - 2024: existing (2024-old) — 2 folders have this name, used the oldest"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("result missing %q:\n%s", want, text)
+		}
+	}
+
+	// Names with quotes are escaped in the lookup.
+	if _, err := ensureFolderPath(context.Background(), svc, "root", []string{"Bob's"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ensureFolderPath(context.Background(), svc, "root", []string{"Bob's"}); err != nil || tree.creates != 3 {
+		t.Errorf("Bob's folder created %d times in total (err %v), want once", tree.creates-2, err)
+	}
+}
+
+func TestEnsureFolderPath_ErrorListsWalkedFolders(t *testing.T) {
+	tree := &fakeFolderTree{
+		folders:    []*driveapi.File{{Id: "projects", Name: "Projects", Parents: []string{"root"}}},
+		failCreate: true,
+	}
+	svc := newFakeService(t, tree.ServeHTTP)
+
+	segments, err := ensureFolderPath(context.Background(), svc, "root", []string{"Projects", "2024"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`creating folder "2024" (2 of 2)`, "Folders walked before the error:\n  - Projects: existing (projects)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q: %v", want, err)
+		}
+	}
+	if len(segments) != 1 {
+		t.Errorf("segments = %+v, want just Projects", segments)
+	}
+}
+
+func TestRecentQuery(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	want := "viewedByMeTime > '2024-03-01T10:00:00Z' and trashed = false"
+	if got := recentQuery(now, 30); got != want {
+		t.Errorf("recentQuery = %q, want %q", got, want)
+	}
+}
+
+func TestWriteFileEntry_ViewedByMe(t *testing.T) {
+	var sb strings.Builder
+	writeFileEntry(&sb, &driveapi.File{Id: "f1", Name: "Notes", ViewedByMeTime: "2024-03-30T08:00:00.000Z"}, "work")
+	if !strings.Contains(sb.String(), "  Viewed by me: 2024-03-30T08:00:00.000Z\n") {
+		t.Errorf("entry missing viewed time:\n%s", sb.String())
+	}
+	sb.Reset()
+	writeFileEntry(&sb, &driveapi.File{Id: "f1", Name: "Notes"}, "work")
+	if strings.Contains(sb.String(), "Viewed") {
+		t.Errorf("entry without viewed time shows one:\n%s", sb.String())
+	}
+}
+
+func TestUpdateStarred_SendsFalse(t *testing.T) {
+	var body map[string]any
+	var fields string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/files/f1" {
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		fields = r.URL.Query().Get("fields")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&driveapi.File{Id: "f1", Name: "Notes", Starred: body["starred"] == true})
+	})
+
+	file, err := updateStarred(context.Background(), svc, "f1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := body["starred"]; !ok || v != false {
+		t.Errorf("request body = %v, want starred: false", body)
+	}
+	if file.Starred || fields != "id,name,starred" {
+		t.Errorf("file = %+v, fields = %q", file, fields)
+	}
+
+	if file, err := updateStarred(context.Background(), svc, "f1", true); err != nil || !file.Starred || body["starred"] != true {
+		t.Errorf("star: file = %+v, err = %v, body = %v", file, err, body)
+	}
+}
+
+// fakeOCR serves the copy, export and delete calls of withExtractedText,
+// recording them in calls.
+type fakeOCR struct {
+	calls       []string
+	ocrLanguage string
+	copyMIME    string
+	failExport  bool
+	failDelete  bool
+}
+
+func (f *fakeOCR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/copy"):
+		var file driveapi.File
+		json.NewDecoder(r.Body).Decode(&file)
+		f.copyMIME = file.MimeType
+		f.ocrLanguage = r.URL.Query().Get("ocrLanguage")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"tmpdoc"}`))
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/export"):
+		if f.failExport {
+			http.Error(w, `{"error":{"code":500,"message":"Internal Error"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("\ufeffINVOICE 42\nTotal: 10 EUR\n"))
+	case r.Method == http.MethodDelete:
+		if f.failDelete {
+			http.Error(w, `{"error":{"code":403,"message":"Insufficient permissions"}}`, http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func TestWithExtractedText(t *testing.T) {
+	scan := &driveapi.File{Id: "scan", Name: "invoice.pdf", MimeType: "application/pdf"}
+	read := func(text *string) func(io.Reader) error {
+		return func(r io.Reader) error {
+			data, err := io.ReadAll(r)
+			*text = string(data)
+			return err
+		}
+	}
+
+	t.Run("converts, exports and deletes the copy", func(t *testing.T) {
+		fake := &fakeOCR{}
+		svc := newFakeService(t, fake.ServeHTTP)
+		var text string
+		note, err := withExtractedText(context.Background(), svc, scan, "de", read(&text))
+		if err != nil || note != "" {
+			t.Fatalf("note = %q, err = %v", note, err)
+		}
+		want := []string{"POST /files/scan/copy", "GET /files/tmpdoc/export", "DELETE /files/tmpdoc"}
+		if !slices.Equal(fake.calls, want) {
+			t.Errorf("calls = %q, want %q", fake.calls, want)
+		}
+		if fake.copyMIME != googleDocMIME || fake.ocrLanguage != "de" {
+			t.Errorf("copy mimeType = %q, ocrLanguage = %q", fake.copyMIME, fake.ocrLanguage)
+		}
+		if !strings.Contains(text, "INVOICE 42") {
+			t.Errorf("text = %q", text)
+		}
+	})
+
+	t.Run("deletes the copy when the export fails", func(t *testing.T) {
+		fake := &fakeOCR{failExport: true}
+		svc := newFakeService(t, fake.ServeHTTP)
+		var text string
+		if _, err := withExtractedText(context.Background(), svc, scan, "", read(&text)); err == nil {
+			t.Fatal("expected an error")
+		}
+		if last := fake.calls[len(fake.calls)-1]; last != "DELETE /files/tmpdoc" {
+			t.Errorf("calls = %q, want the copy deleted last", fake.calls)
+		}
+		if fake.ocrLanguage != "" {
+			t.Errorf("ocrLanguage = %q, want none", fake.ocrLanguage)
+		}
+	})
+
+	t.Run("reports a copy it couldn't delete", func(t *testing.T) {
+		fake := &fakeOCR{failDelete: true}
+		svc := newFakeService(t, fake.ServeHTTP)
+		var text string
+		note, err := withExtractedText(context.Background(), svc, scan, "", read(&text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(note, "temporary Google Doc tmpdoc could not be deleted") {
+			t.Errorf("note = %q", note)
+		}
+	})
+
+	t.Run("exports Google Docs directly", func(t *testing.T) {
+		fake := &fakeOCR{}
+		svc := newFakeService(t, fake.ServeHTTP)
+		var text string
+		doc := &driveapi.File{Id: "doc1", Name: "Notes", MimeType: googleDocMIME}
+		if _, err := withExtractedText(context.Background(), svc, doc, "", read(&text)); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"GET /files/doc1/export"}; !slices.Equal(fake.calls, want) {
+			t.Errorf("calls = %q, want %q", fake.calls, want)
+		}
+	})
+}
+
+func TestPlanShareLink(t *testing.T) {
+	reader := &driveapi.Permission{Id: "anyoneWithLink", Type: "anyone", Role: "reader"}
+	tests := []struct {
+		name     string
+		existing *driveapi.Permission
+		role     string
+		want     string
+	}{
+		{"no link permission", nil, "reader", "created"},
+		{"same role", reader, "reader", "unchanged"},
+		{"other role", reader, "writer", "updated"},
+	}
+	for _, tt := range tests {
+		if got := planShareLink(tt.existing, tt.role); got != tt.want {
+			t.Errorf("%s: planShareLink = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeSharingService serves file f1 with perms as its permissions and
+// records every request that changes them as "METHOD path body". Deleting
+// the permission "locked" fails.
+func fakeSharingService(t *testing.T, perms []*driveapi.Permission, writes *[]string) *driveapi.Service {
+	t.Helper()
+	return newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/files/f1":
+			json.NewEncoder(w).Encode(&driveapi.File{Id: "f1", Name: "Report", WebViewLink: "https://drive.google.com/file/d/f1/view"})
+		case r.Method == http.MethodGet && r.URL.Path == "/files/f1/permissions":
+			json.NewEncoder(w).Encode(&driveapi.PermissionList{Permissions: perms})
+		default:
+			body, _ := io.ReadAll(r.Body)
+			*writes = append(*writes, strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body)))
+			if r.Method == http.MethodDelete {
+				if strings.HasSuffix(r.URL.Path, "/locked") {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"error":{"code":403,"message":"The user does not have sufficient permissions for this file."}}`)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			var p driveapi.Permission
+			json.Unmarshal(body, &p)
+			p.Id = "anyoneWithLink"
+			p.Type = "anyone"
+			json.NewEncoder(w).Encode(&p)
+		}
+	})
+}
+
+func TestSetShareLink(t *testing.T) {
+	owner := &driveapi.Permission{Id: "p1", Type: "user", Role: "owner", EmailAddress: "me@example.com"}
+	link := &driveapi.Permission{Id: "anyoneWithLink", Type: "anyone", Role: "reader"}
+	inherited := &driveapi.Permission{Id: "anyoneWithLink", Type: "anyone", Role: "reader", PermissionDetails: []*driveapi.PermissionPermissionDetails{
+		{Inherited: true, InheritedFrom: "folder1", Role: "reader"},
+	}}
+	tests := []struct {
+		name   string
+		perms  []*driveapi.Permission
+		role   string
+		revoke bool
+		action string
+		writes []string
+		access string
+	}{
+		{
+			name: "create", perms: []*driveapi.Permission{owner}, role: "reader",
+			action: "created",
+			writes: []string{`POST /files/f1/permissions {"role":"reader","type":"anyone"}`},
+			access: "Anyone with the link can view",
+		},
+		{
+			name: "already shared", perms: []*driveapi.Permission{owner, link}, role: "reader",
+			action: "unchanged",
+			access: "Anyone with the link can view",
+		},
+		{
+			name: "inherited with the same role", perms: []*driveapi.Permission{owner, inherited}, role: "reader",
+			action: "unchanged",
+			access: "Anyone with the link can view",
+		},
+		{
+			name: "inherited with another role", perms: []*driveapi.Permission{owner, inherited}, role: "writer",
+			action: "created",
+			writes: []string{`POST /files/f1/permissions {"role":"writer","type":"anyone"}`},
+			access: "Anyone with the link can edit",
+		},
+		{
+			name: "role changed", perms: []*driveapi.Permission{owner, link}, role: "writer",
+			action: "updated",
+			writes: []string{`PATCH /files/f1/permissions/anyoneWithLink {"role":"writer"}`},
+			access: "Anyone with the link can edit",
+		},
+		{
+			name: "revoke", perms: []*driveapi.Permission{owner, link}, revoke: true,
+			action: "revoked",
+			writes: []string{"DELETE /files/f1/permissions/anyoneWithLink"},
+		},
+		{
+			name: "revoke unshared", perms: []*driveapi.Permission{owner, {Id: "old", Type: "anyone", Role: "reader", Deleted: true}}, revoke: true,
+			action: "not shared",
+		},
+		{
+			name: "revoke keeps inherited", perms: []*driveapi.Permission{owner, inherited}, revoke: true,
+			action: "not shared",
+		},
+		{
+			name: "revoke partly fails", perms: []*driveapi.Permission{owner, link, {Id: "locked", Type: "anyone", Role: "writer"}}, revoke: true,
+			action: "partly revoked",
+			writes: []string{"DELETE /files/f1/permissions/anyoneWithLink", "DELETE /files/f1/permissions/locked"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writes []string
+			svc := fakeSharingService(t, tt.perms, &writes)
+			res, err := setShareLink(context.Background(), svc, "f1", tt.role, tt.revoke)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Action != tt.action {
+				t.Errorf("action = %q, want %q", res.Action, tt.action)
+			}
+			if !slices.Equal(writes, tt.writes) {
+				t.Errorf("writes:\n got %q\nwant %q", writes, tt.writes)
+			}
+			if tt.access == "" {
+				if res.Permission != nil {
+					t.Errorf("permission = %+v, want none", res.Permission)
+				}
+				return
+			}
+			if res.Permission == nil {
+				t.Fatal("no link permission in the result")
+			}
+			if got := linkAccess(res.Permission); got != tt.access {
+				t.Errorf("access = %q, want %q", got, tt.access)
+			}
+			if text := formatShareLink(res); !strings.Contains(text, "Link: https://drive.google.com/file/d/f1/view\n") {
+				t.Errorf("result has no link:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestSetShareLink_Revoke(t *testing.T) {
+	inherited := &driveapi.Permission{Id: "anyoneWithLink", Type: "anyone", Role: "reader", PermissionDetails: []*driveapi.PermissionPermissionDetails{
+		{Inherited: true, InheritedFrom: "folder1", Role: "reader"},
+	}}
+	locked := &driveapi.Permission{Id: "locked", Type: "anyone", Role: "writer"}
+
+	t.Run("outcome of each permission", func(t *testing.T) {
+		var writes []string
+		svc := fakeSharingService(t, []*driveapi.Permission{{Id: "own", Type: "anyone", Role: "reader"}, locked, inherited}, &writes)
+		res, err := setShareLink(context.Background(), svc, "f1", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := formatShareLink(res)
+		for _, want := range []string{
+			"Removed: Anyone with the link can view (Permission ID: own)\n",
+			"Not removed: Anyone with the link can edit (Permission ID: locked): ",
+			"Inherited from folder folder1: Anyone with the link can view. Change it on that folder.\n",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("result has no %q:\n%s", want, text)
+			}
+		}
+	})
+
+	t.Run("every deletion fails", func(t *testing.T) {
+		var writes []string
+		svc := fakeSharingService(t, []*driveapi.Permission{locked}, &writes)
+		if _, err := setShareLink(context.Background(), svc, "f1", "", true); err == nil {
+			t.Fatal("want an error")
+		}
+	})
 }
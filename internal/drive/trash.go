@@ -6,28 +6,34 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 )
 
 // --- empty_trash ---
 
 type emptyTrashInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerEmptyTrash(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "empty_trash",
 		Description: "Permanently delete all files in the Google Drive trash. This action cannot be undone.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input emptyTrashInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Drive service: %w", err)
 		}
 
-		if err := svc.Files.EmptyTrash().Do(); err != nil {
-			return nil, nil, fmt.Errorf("emptying trash: %w", err)
+		if err := svc.Files.EmptyTrash().Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "emptying trash")
 		}
 
 		return &mcp.CallToolResult{
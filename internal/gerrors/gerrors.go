@@ -0,0 +1,188 @@
+// Package gerrors translates Google API errors into short, actionable
+// messages.
+//
+// The client libraries report failures as *googleapi.Error, whose text
+// ("googleapi: Error 403: ..., insufficientPermissions") hides the reason
+// that tells a caller what to do next. Wrap prefixes such errors with a hint
+// derived from the reason code, keeps the original message, and leaves the
+// *googleapi.Error reachable through errors.As.
+package gerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Hints for the failure classes callers can act on.
+const (
+	hintRateLimited  = "rate limited — retry later"
+	hintDailyLimit   = "daily quota exhausted — retry tomorrow or raise the project quota"
+	hintMissingScope = "missing scope — re-auth required: run 'google-mcp auth add <account>'"
+	hintUnauthorized = "authorization failed — re-auth required: run 'google-mcp auth add <account>'"
+	hintForbidden    = "permission denied — the account cannot access this item"
+	hintDelegation   = "no delegate access — the mailbox owner must grant this account access in Gmail settings (Accounts > Grant access to your account)"
+	hintNotFound     = "not found — the ID may be from another account, or the item was deleted"
+	hintConflict     = "already exists or was changed concurrently — fetch it again before retrying"
+	hintStorageQuota = "storage quota exceeded — free up space in the account"
+	hintBackend      = "temporary Google error — retry later"
+	hintAPIDisabled  = "API not enabled — enable it for the OAuth client's project in the Google Cloud console"
+)
+
+// reasonHints maps the reason codes of googleapi.Error.Errors, and of
+// google.rpc.ErrorInfo details, to hints. Codes that need more context,
+// like a 403 "forbidden", fall back to hintForStatus.
+var reasonHints = map[string]string{
+	"userRateLimitExceeded":           hintRateLimited,
+	"rateLimitExceeded":               hintRateLimited,
+	"RATE_LIMIT_EXCEEDED":             hintRateLimited,
+	"quotaExceeded":                   hintRateLimited,
+	"dailyLimitExceeded":              hintDailyLimit,
+	"insufficientPermissions":         hintMissingScope,
+	"ACCESS_TOKEN_SCOPE_INSUFFICIENT": hintMissingScope,
+	"authError":                       hintUnauthorized,
+	"notFound":                        hintNotFound,
+	"duplicate":                       hintConflict,
+	"conflict":                        hintConflict,
+	"storageQuotaExceeded":            hintStorageQuota,
+	"backendError":                    hintBackend,
+	"internalError":                   hintBackend,
+	"accessNotConfigured":             hintAPIDisabled,
+	"SERVICE_DISABLED":                hintAPIDisabled,
+}
+
+// scopeParam extracts the required scopes from a WWW-Authenticate header
+// like: Bearer error="insufficient_scope", scope="https://...".
+var scopeParam = regexp.MustCompile(`scope="([^"]+)"`)
+
+// apiError is an error from a Google API call with a hint prepended to its
+// message.
+type apiError struct {
+	hint string
+	err  error
+}
+
+func (e *apiError) Error() string { return e.hint + ": " + e.err.Error() }
+func (e *apiError) Unwrap() error { return e.err }
+
+// Wrap returns err prefixed with action, as fmt.Errorf("%s: %w", action, err)
+// does, with a hint added when err is a Google API error with a known reason.
+// It returns nil if err is nil.
+func Wrap(err error, action string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", action, Translate(err))
+}
+
+// Wrapf is Wrap with a formatted action.
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return Wrap(err, fmt.Sprintf(format, args...))
+}
+
+// Translate returns err with a hint added when it is a Google API error with
+// a known reason or status, and err unchanged otherwise. Errors that already
+// carry a hint are returned as they are, so wrapping twice is harmless.
+func Translate(err error) error {
+	var hinted *apiError
+	if errors.As(err, &hinted) {
+		return err
+	}
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return err
+	}
+	hint := Hint(gerr)
+	if hint == "" {
+		return err
+	}
+	return &apiError{hint: hint, err: err}
+}
+
+// Hint returns the actionable message for gerr, or "" if its reason and
+// status aren't recognized.
+func Hint(gerr *googleapi.Error) string {
+	for _, reason := range Reasons(gerr) {
+		if hint, ok := reasonHints[reason]; ok {
+			if hint == hintMissingScope {
+				return missingScopeHint(gerr)
+			}
+			return hint
+		}
+	}
+	return hintForStatus(gerr)
+}
+
+// Reasons returns the reason codes reported in gerr: those of its error
+// items followed by those of google.rpc.ErrorInfo details.
+func Reasons(gerr *googleapi.Error) []string {
+	var reasons []string
+	for _, item := range gerr.Errors {
+		if item.Reason != "" {
+			reasons = append(reasons, item.Reason)
+		}
+	}
+	for _, detail := range gerr.Details {
+		m, ok := detail.(map[string]any)
+		if !ok {
+			continue
+		}
+		if typ, _ := m["@type"].(string); !strings.HasSuffix(typ, "google.rpc.ErrorInfo") {
+			continue
+		}
+		if reason, _ := m["reason"].(string); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// HasReason reports whether err is a Google API error reporting reason.
+func HasReason(err error, reason string) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && slices.Contains(Reasons(gerr), reason)
+}
+
+// missingScopeHint names the scopes the call needed when Google reports
+// them in the WWW-Authenticate header.
+func missingScopeHint(gerr *googleapi.Error) string {
+	m := scopeParam.FindStringSubmatch(gerr.Header.Get("WWW-Authenticate"))
+	if m == nil {
+		return hintMissingScope
+	}
+	return "missing scope " + strings.Join(strings.Fields(m[1]), ", ") + " — re-auth required: run 'google-mcp auth add <account>'"
+}
+
+// hintForStatus returns the hint for errors without a recognized reason.
+func hintForStatus(gerr *googleapi.Error) string {
+	switch gerr.Code {
+	case http.StatusTooManyRequests:
+		return hintRateLimited
+	case http.StatusUnauthorized:
+		return hintUnauthorized
+	case http.StatusForbidden:
+		message := strings.ToLower(gerr.Message)
+		if strings.Contains(message, "insufficient") && strings.Contains(message, "scope") {
+			return missingScopeHint(gerr)
+		}
+		if strings.Contains(message, "delegation denied") {
+			return hintDelegation
+		}
+		return hintForbidden
+	case http.StatusNotFound:
+		return hintNotFound
+	case http.StatusConflict:
+		return hintConflict
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return hintBackend
+	}
+	return ""
+}
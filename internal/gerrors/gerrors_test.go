@@ -0,0 +1,122 @@
+package gerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func apiErr(code int, reason, message string) *googleapi.Error {
+	gerr := &googleapi.Error{Code: code, Message: message}
+	if reason != "" {
+		gerr.Errors = []googleapi.ErrorItem{{Reason: reason, Message: message}}
+	}
+	return gerr
+}
+
+func TestWrap(t *testing.T) {
+	scopeHeader := http.Header{}
+	scopeHeader.Set("WWW-Authenticate", `Bearer realm="https://accounts.google.com/", error="insufficient_scope", scope="https://www.googleapis.com/auth/gmail.settings.basic"`)
+	scopeErr := apiErr(403, "insufficientPermissions", "Request had insufficient authentication scopes.")
+	scopeErr.Header = scopeHeader
+
+	tests := []struct {
+		name string
+		err  error
+		want string // hint expected between the action and the original message; "" for none
+	}{
+		{"user rate limit", apiErr(403, "userRateLimitExceeded", "User Rate Limit Exceeded"), hintRateLimited},
+		{"rate limit", apiErr(403, "rateLimitExceeded", "Rate Limit Exceeded"), hintRateLimited},
+		{"too many requests", apiErr(429, "", "Too many concurrent requests for user"), hintRateLimited},
+		{"daily limit", apiErr(403, "dailyLimitExceeded", "Daily Limit Exceeded"), hintDailyLimit},
+		{"insufficient permissions", apiErr(403, "insufficientPermissions", "Insufficient Permission"), hintMissingScope},
+		{"scope from header", scopeErr, "missing scope https://www.googleapis.com/auth/gmail.settings.basic — re-auth required: run 'google-mcp auth add <account>'"},
+		{"scope from message", apiErr(403, "", "Request had insufficient authentication scopes."), hintMissingScope},
+		{"error info detail", &googleapi.Error{Code: 403, Message: "Request had insufficient authentication scopes.", Details: []any{
+			map[string]any{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "ACCESS_TOKEN_SCOPE_INSUFFICIENT"},
+		}}, hintMissingScope},
+		{"not found reason", apiErr(404, "notFound", "File not found: abc."), hintNotFound},
+		{"not found status", apiErr(404, "", "Not Found"), hintNotFound},
+		{"delegation denied", apiErr(403, "forbidden", "Delegation denied for assistant@example.com"), hintDelegation},
+		{"forbidden", apiErr(403, "forbidden", "The caller does not have permission"), hintForbidden},
+		{"unauthorized", apiErr(401, "authError", "Invalid Credentials"), hintUnauthorized},
+		{"duplicate", apiErr(409, "duplicate", "The requested identifier already exists."), hintConflict},
+		{"storage quota", apiErr(403, "storageQuotaExceeded", "The user's Drive storage quota has been exceeded."), hintStorageQuota},
+		{"backend", apiErr(503, "backendError", "Backend Error"), hintBackend},
+		{"api disabled", apiErr(403, "accessNotConfigured", "Drive Activity API has not been used in project 123 before or it is disabled."), hintAPIDisabled},
+		{"bad request", apiErr(400, "invalid", "Invalid value for: q"), ""},
+		{"not an API error", errors.New("connection reset"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(tt.err, "getting file")
+			want := "getting file: " + tt.err.Error()
+			if tt.want != "" {
+				want = "getting file: " + tt.want + ": " + tt.err.Error()
+			}
+			if err.Error() != want {
+				t.Errorf("Wrap() = %q, want %q", err, want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Wrap() does not wrap the original error")
+			}
+		})
+	}
+}
+
+func TestWrap_Nil(t *testing.T) {
+	if err := Wrap(nil, "getting file"); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+	if err := Wrapf(nil, "getting file %s", "abc"); err != nil {
+		t.Errorf("Wrapf(nil) = %v, want nil", err)
+	}
+}
+
+func TestWrap_KeepsAPIError(t *testing.T) {
+	inner := fmt.Errorf("listing labels: %w", apiErr(429, "rateLimitExceeded", "Quota exceeded"))
+	err := Wrapf(inner, "rule %d", 2)
+
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) || gerr.Code != 429 {
+		t.Fatalf("errors.As(*googleapi.Error) = %v, want the 429 error", gerr)
+	}
+	if !strings.HasPrefix(err.Error(), "rule 2: "+hintRateLimited+": listing labels: ") {
+		t.Errorf("Wrapf() = %q", err)
+	}
+
+	// Wrapping an already translated error doesn't repeat the hint.
+	again := Wrap(err, "applying rules")
+	if n := strings.Count(again.Error(), hintRateLimited); n != 1 {
+		t.Errorf("hint appears %d times in %q, want 1", n, again)
+	}
+}
+
+func TestReasons(t *testing.T) {
+	gerr := &googleapi.Error{
+		Code:   403,
+		Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}, {Reason: ""}},
+		Details: []any{
+			map[string]any{"@type": "type.googleapis.com/google.rpc.Help"},
+			map[string]any{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED"},
+		},
+	}
+	got := Reasons(gerr)
+	if len(got) != 2 || got[0] != "rateLimitExceeded" || got[1] != "RATE_LIMIT_EXCEEDED" {
+		t.Errorf("Reasons() = %v, want [rateLimitExceeded RATE_LIMIT_EXCEEDED]", got)
+	}
+}
+
+func TestHasReason(t *testing.T) {
+	err := fmt.Errorf("sharing file: %w", apiErr(403, "consentRequiredForOwnershipTransfer", "Consent is required to transfer ownership of a file to another user."))
+	if !HasReason(err, "consentRequiredForOwnershipTransfer") {
+		t.Error("HasReason() = false for a wrapped error with the reason")
+	}
+	if HasReason(err, "notFound") || HasReason(errors.New("boom"), "notFound") {
+		t.Error("HasReason() = true for another reason")
+	}
+}
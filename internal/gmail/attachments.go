@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"mime"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -18,40 +22,123 @@ type attachmentInfo struct {
 	mimeType     string
 	size         int64
 	attachmentID string
+	// contentID is the part's Content-ID without angle brackets, which an
+	// HTML body references as cid:<contentID>.
+	contentID string
+	// inline is set for parts shown within the body (Content-Disposition:
+	// inline, or a Content-ID without a disposition) rather than attached.
+	inline bool
 }
 
-// listAttachments recursively finds all attachments in a message payload.
+// listAttachments recursively finds all attachments in a message payload,
+// including inline parts such as images embedded in an HTML body. Unnamed
+// inline parts get synthetic names like inline-1.png.
 func listAttachments(part *gmailapi.MessagePart) []attachmentInfo {
-	if part == nil {
-		return nil
-	}
-
 	var result []attachmentInfo
+	unnamed := 0
+	var walk func(*gmailapi.MessagePart)
+	walk = func(part *gmailapi.MessagePart) {
+		if part == nil {
+			return
+		}
 
-	// A part is an attachment if it has a filename and an attachment ID.
-	if part.Filename != "" && part.Body != nil && part.Body.AttachmentId != "" {
-		result = append(result, attachmentInfo{
-			filename:     part.Filename,
-			mimeType:     part.MimeType,
-			size:         part.Body.Size,
-			attachmentID: part.Body.AttachmentId,
-		})
+		// A part is an attachment if it has an attachment ID and either a
+		// filename or a Content-ID that the body can reference.
+		if part.Body != nil && part.Body.AttachmentId != "" {
+			contentID := strings.Trim(strings.TrimSpace(partHeader(part, "Content-ID")), "<>")
+			disposition := strings.ToLower(strings.TrimSpace(partHeader(part, "Content-Disposition")))
+			inline := strings.HasPrefix(disposition, "inline") || (disposition == "" && contentID != "")
+			if part.Filename != "" || contentID != "" || inline {
+				name := part.Filename
+				if name == "" {
+					unnamed++
+					name = fmt.Sprintf("inline-%d%s", unnamed, extensionForMIME(part.MimeType))
+				}
+				result = append(result, attachmentInfo{
+					filename:     name,
+					mimeType:     part.MimeType,
+					size:         part.Body.Size,
+					attachmentID: part.Body.AttachmentId,
+					contentID:    contentID,
+					inline:       inline,
+				})
+			}
+		}
+
+		// Recurse into sub-parts.
+		for _, p := range part.Parts {
+			walk(p)
+		}
 	}
+	walk(part)
+	return result
+}
 
-	// Recurse into sub-parts.
-	for _, p := range part.Parts {
-		result = append(result, listAttachments(p)...)
+// partHeader returns the value of a part's header, matched
+// case-insensitively, or "".
+func partHeader(part *gmailapi.MessagePart, name string) string {
+	for _, h := range part.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
 	}
+	return ""
+}
 
-	return result
+// extensionForMIME returns a file extension for naming unnamed parts.
+func extensionForMIME(mimeType string) string {
+	switch strings.ToLower(mimeType) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/bmp":
+		return ".bmp"
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// findAttachmentByContentID returns the attachment with the given
+// Content-ID. The ID may be written with angle brackets or a cid: prefix.
+func findAttachmentByContentID(atts []attachmentInfo, contentID string) (attachmentInfo, error) {
+	id := strings.Trim(strings.TrimSpace(contentID), "<>")
+	if len(id) > 4 && strings.EqualFold(id[:4], "cid:") {
+		id = id[4:]
+	}
+	for _, a := range atts {
+		if a.contentID != "" && a.contentID == id {
+			return a, nil
+		}
+	}
+	var ids []string
+	for _, a := range atts {
+		if a.contentID != "" {
+			ids = append(ids, a.contentID)
+		}
+	}
+	if len(ids) == 0 {
+		return attachmentInfo{}, fmt.Errorf("message has no parts with a Content-ID")
+	}
+	return attachmentInfo{}, fmt.Errorf("no part with Content-ID %q (available: %s)", id, strings.Join(ids, ", "))
 }
 
 // --- gmail_get_attachment ---
 
 type getAttachmentInput struct {
-	Account      string `json:"account" jsonschema:"Account name"`
+	Account      string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox      string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	MessageID    string `json:"message_id" jsonschema:"Gmail message ID that contains the attachment"`
-	AttachmentID string `json:"attachment_id" jsonschema:"Attachment ID (from read or read_thread results)"`
+	AttachmentID string `json:"attachment_id,omitempty" jsonschema:"Attachment ID (from read_message or read_thread results). Set this or content_id."`
+	ContentID    string `json:"content_id,omitempty" jsonschema:"Content-ID of an inline part, as referenced by cid: in the HTML body (e.g. 'image001.png@01DA2B3C'). Set this or attachment_id."`
 	SaveTo       string `json:"save_to,omitempty" jsonschema:"Save to a local file instead of returning content (path relative to an allowed directory). Requires --allow-write-dir. Content never enters the conversation."`
 }
 
@@ -60,23 +147,46 @@ func registerGetAttachment(srv *server.Server, mgr *auth.Manager) {
 
 By default, returns content in the conversation (text for text-like files, base64 for binary).
 Set save_to to write the file to a local directory instead — content never enters the conversation.
-Use read_message to discover attachment IDs.` + srv.WriteDirsDescription()
+Use read_message to discover attachment IDs. Inline images can also be fetched by the Content-ID their cid: reference in the HTML body uses.` + srv.WriteDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "get_attachment",
 		Description: desc,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getAttachmentInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		att, err := svc.Users.Messages.Attachments.Get("me", input.MessageID, input.AttachmentID).Do()
+		if (input.AttachmentID == "") == (input.ContentID == "") {
+			return nil, nil, fmt.Errorf("exactly one of attachment_id or content_id is required")
+		}
+
+		attachmentID := input.AttachmentID
+		if input.ContentID != "" {
+			msg, err := svc.Users.Messages.Get(userID(input.Mailbox), input.MessageID).Format("full").Context(ctx).Do()
+			if err != nil {
+				return nil, nil, gerrors.Wrap(err, "getting message")
+			}
+			info, err := findAttachmentByContentID(listAttachments(msg.Payload), input.ContentID)
+			if err != nil {
+				return nil, nil, err
+			}
+			attachmentID = info.attachmentID
+		}
+
+		att, err := svc.Users.Messages.Attachments.Get(userID(input.Mailbox), input.MessageID, attachmentID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting attachment: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting attachment")
 		}
 
 		// The API returns URL-safe base64. Decode.
@@ -127,7 +237,7 @@ Use read_message to discover attachment IDs.` + srv.WriteDirsDescription()
 					len(data), base64.StdEncoding.EncodeToString(data))},
 			},
 		}, nil, nil
-	})
+	}, server.LocalWriteParams("save_to"))
 }
 
 // isLikelyText checks if data appears to be text content by looking for
@@ -156,17 +266,287 @@ func isLikelyText(data []byte) bool {
 	return float64(printable)/float64(len(check)) > 0.85
 }
 
-// formatAttachmentList formats attachments for a message, using the message ID for context.
-func formatAttachmentList(msgID string, parts []*gmailapi.MessagePart) string {
-	attachments := listAttachments(&gmailapi.MessagePart{Parts: parts})
-	if len(attachments) == 0 {
-		return ""
-	}
+// formatAttachmentList formats the attachments of a message for read
+// output, listing inline parts separately from regular attachments.
+func formatAttachmentList(atts []attachmentInfo) string {
 	var sb strings.Builder
-	sb.WriteString("Attachments:\n")
-	for _, a := range attachments {
+	var inline []attachmentInfo
+	for _, a := range atts {
+		if a.inline {
+			inline = append(inline, a)
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("Attachments:\n")
+		}
 		fmt.Fprintf(&sb, "  - %s (MIME: %s, Size: %d bytes, Attachment ID: %s)\n",
 			a.filename, a.mimeType, a.size, a.attachmentID)
 	}
+	if len(inline) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		images := 0
+		for _, a := range inline {
+			if strings.HasPrefix(strings.ToLower(a.mimeType), "image/") {
+				images++
+			}
+		}
+		switch {
+		case images == len(inline) && images == 1:
+			sb.WriteString("Contains 1 inline image:\n")
+		case images == len(inline):
+			fmt.Fprintf(&sb, "Contains %d inline images:\n", images)
+		default:
+			fmt.Fprintf(&sb, "Contains %d inline parts (%d images):\n", len(inline), images)
+		}
+		for _, a := range inline {
+			fmt.Fprintf(&sb, "  - %s (MIME: %s, Size: %d bytes", a.filename, a.mimeType, a.size)
+			if a.contentID != "" {
+				fmt.Fprintf(&sb, ", Content-ID: %s", a.contentID)
+			}
+			fmt.Fprintf(&sb, ", Attachment ID: %s)\n", a.attachmentID)
+		}
+	}
 	return sb.String()
 }
+
+// --- search_attachments ---
+
+type searchAttachmentsInput struct {
+	Account       string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox       string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	Query         string `json:"query,omitempty" jsonschema:"Gmail search query selecting the messages (has:attachment is added)"`
+	From          string `json:"from,omitempty" jsonschema:"Only messages from this sender (address or name)"`
+	After         string `json:"after,omitempty" jsonschema:"Only messages received at or after this time: RFC3339 timestamp or date 'YYYY-MM-DD' (midnight UTC)"`
+	Before        string `json:"before,omitempty" jsonschema:"Only messages received before this time: RFC3339 timestamp or date 'YYYY-MM-DD' (midnight UTC)"`
+	Filename      string `json:"filename,omitempty" jsonschema:"Only attachments whose filename matches this glob, case-insensitively (e.g. '*.pdf', 'invoice*')"`
+	MIMEType      string `json:"mime_type,omitempty" jsonschema:"Only attachments whose MIME type starts with this (e.g. 'image/', 'application/pdf')"`
+	MinSize       int64  `json:"min_size,omitempty" jsonschema:"Only attachments of at least this many bytes"`
+	MaxSize       int64  `json:"max_size,omitempty" jsonschema:"Only attachments of at most this many bytes"`
+	IncludeInline bool   `json:"include_inline,omitempty" jsonschema:"Also include inline parts such as images embedded in HTML bodies (default: false)"`
+	MaxMessages   int64  `json:"max_messages,omitempty" jsonschema:"Maximum number of messages to scan (default 50, max 500)"`
+	PageToken     string `json:"page_token,omitempty" jsonschema:"Next page token from a previous search_attachments result, to scan the following messages"`
+	Format        string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per attachment)"`
+}
+
+// attachmentFilter selects the attachments search_attachments returns.
+type attachmentFilter struct {
+	pattern       string // lowercased filename glob
+	mimePrefix    string // lowercased
+	minSize       int64
+	maxSize       int64 // 0 for no limit
+	includeInline bool
+}
+
+// newAttachmentFilter validates the filter inputs of search_attachments.
+func newAttachmentFilter(input searchAttachmentsInput) (attachmentFilter, error) {
+	f := attachmentFilter{
+		pattern:       strings.ToLower(strings.TrimSpace(input.Filename)),
+		mimePrefix:    strings.ToLower(strings.TrimSpace(input.MIMEType)),
+		minSize:       input.MinSize,
+		maxSize:       input.MaxSize,
+		includeInline: input.IncludeInline,
+	}
+	if _, err := path.Match(f.pattern, ""); err != nil {
+		return f, fmt.Errorf("filename: invalid pattern %q", input.Filename)
+	}
+	if f.minSize < 0 || f.maxSize < 0 {
+		return f, fmt.Errorf("min_size and max_size must not be negative")
+	}
+	if f.maxSize > 0 && f.minSize > f.maxSize {
+		return f, fmt.Errorf("min_size (%d) must not be larger than max_size (%d)", f.minSize, f.maxSize)
+	}
+	return f, nil
+}
+
+// match reports whether a passes the filter.
+func (f attachmentFilter) match(a attachmentInfo) bool {
+	if a.inline && !f.includeInline {
+		return false
+	}
+	if f.pattern != "" {
+		if ok, _ := path.Match(f.pattern, strings.ToLower(a.filename)); !ok {
+			return false
+		}
+	}
+	if f.mimePrefix != "" && !strings.HasPrefix(strings.ToLower(a.mimeType), f.mimePrefix) {
+		return false
+	}
+	if a.size < f.minSize || (f.maxSize > 0 && a.size > f.maxSize) {
+		return false
+	}
+	return true
+}
+
+// attachmentRow is an attachment found by search_attachments.
+type attachmentRow struct {
+	MessageID string
+	From      string
+	Date      string
+	attachmentInfo
+}
+
+// matchingAttachments flattens the attachments of msg and returns those
+// passing f.
+func matchingAttachments(msg *gmailapi.Message, f attachmentFilter) []attachmentRow {
+	var rows []attachmentRow
+	for _, a := range listAttachments(msg.Payload) {
+		if !f.match(a) {
+			continue
+		}
+		headers := searchResultHeaders(msg)
+		rows = append(rows, attachmentRow{MessageID: msg.Id, From: headers["From"], Date: headers["Date"], attachmentInfo: a})
+	}
+	return rows
+}
+
+// attachmentColumns are the json and csv columns of search_attachments.
+var attachmentColumns = []server.Column[attachmentRow]{
+	{Name: "filename", Value: func(r attachmentRow) any { return r.filename }},
+	{Name: "size", Value: func(r attachmentRow) any { return r.size }},
+	{Name: "mime_type", Value: func(r attachmentRow) any { return r.mimeType }},
+	{Name: "message_id", Value: func(r attachmentRow) any { return r.MessageID }},
+	{Name: "attachment_id", Value: func(r attachmentRow) any { return r.attachmentID }},
+	{Name: "from", Value: func(r attachmentRow) any { return r.From }},
+	{Name: "date", Value: func(r attachmentRow) any { return r.Date }},
+}
+
+// formatAttachmentRow formats an attachment for the text output of
+// search_attachments.
+func formatAttachmentRow(r attachmentRow) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- %s (MIME: %s, Size: %d bytes)\n", r.filename, r.mimeType, r.size)
+	fmt.Fprintf(&sb, "  From: %s\n", r.From)
+	fmt.Fprintf(&sb, "  Date: %s\n", r.Date)
+	fmt.Fprintf(&sb, "  Message ID: %s\n", r.MessageID)
+	fmt.Fprintf(&sb, "  Attachment ID: %s\n", r.attachmentID)
+	return sb.String()
+}
+
+// attachmentSearchQuery builds the Gmail query of search_attachments,
+// adding has:attachment unless the query already has it.
+func attachmentSearchQuery(input searchAttachmentsInput) (string, error) {
+	hasTerm := slices.ContainsFunc(strings.Fields(input.Query), func(term string) bool {
+		return strings.EqualFold(term, "has:attachment")
+	})
+	query, err := buildQuery(input.Query, queryFilters{From: input.From, HasAttachment: !hasTerm})
+	if err != nil {
+		return "", err
+	}
+	return dateRangeQuery(query, input.After, input.Before)
+}
+
+func registerSearchAttachments(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "search_attachments",
+		Description: `Find attachments across messages, e.g. "the PDF Maria sent me in March". Runs a Gmail search (has:attachment is added), looks at the attachments of each matching message, and returns one entry per attachment: filename, size, MIME type, message ID, attachment ID, sender and date. Pass the message and attachment IDs to get_attachment to download one.
+
+Filter by filename glob (e.g. '*.pdf'), MIME type prefix (e.g. 'image/') and size. Inline parts such as signature images are skipped unless include_inline is set.
+
+Scans at most max_messages messages per call; when more match, the result ends with a next page token to scan the following ones. Set format to json or csv for machine-readable output.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input searchAttachmentsInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		filter, err := newAttachmentFilter(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		query, err := attachmentSearchQuery(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		table, err := server.NewTable(input.Format, attachmentColumns)
+		if err != nil {
+			return nil, nil, err
+		}
+		maxMessages := input.MaxMessages
+		if maxMessages <= 0 {
+			maxMessages = 50
+		}
+		if maxMessages > 500 {
+			maxMessages = 500
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		user := userID(input.Mailbox)
+
+		call := svc.Users.Messages.List(user).Q(query).MaxResults(maxMessages).Fields("messages(id),nextPageToken").Context(ctx)
+		if input.PageToken != "" {
+			call = call.PageToken(input.PageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "searching messages")
+		}
+		ids := make([]string, len(resp.Messages))
+		for i, m := range resp.Messages {
+			ids[i] = m.Id
+		}
+		details := fetchDetails(ctx, ids, func(id string) (*gmailapi.Message, error) {
+			return svc.Users.Messages.Get(user, id).Format("full").Fields("id,payload").Context(ctx).Do()
+		}, func(done int) {
+			server.Progress(ctx, req, done, len(ids), fmt.Sprintf("Fetched %d of %d messages", done, len(ids)))
+		})
+
+		var rows []attachmentRow
+		var errs []string
+		for i, d := range details {
+			if d.Err != nil {
+				errs = append(errs, fmt.Sprintf("message %s: fetching: %v", ids[i], d.Err))
+				continue
+			}
+			rows = append(rows, matchingAttachments(d.Value, filter)...)
+		}
+
+		var next string
+		if resp.NextPageToken != "" {
+			next = fmt.Sprintf("Next page token: %s\n(More messages match. Call again with this page_token to scan them.)\n", resp.NextPageToken)
+		}
+
+		if table != nil {
+			table.Add(rows...)
+			for _, e := range errs {
+				table.AddError("%s", e)
+			}
+			res := table.Result()
+			if next != "" {
+				res.Content = append(res.Content, &mcp.TextContent{Text: next})
+			}
+			return res, nil, nil
+		}
+
+		out := srv.NewOutputBuilder()
+		fmt.Fprintf(out, "Query: %s\n", query)
+		fmt.Fprintf(out, "Scanned %d messages, found %d attachments.\n\n", len(ids), len(rows))
+		for _, r := range rows {
+			if !out.AddItem(formatAttachmentRow(r) + "\n") {
+				break
+			}
+		}
+		for _, e := range errs {
+			if !out.AddItem("Error: " + e + "\n") {
+				break
+			}
+		}
+		text := out.String()
+		if next != "" {
+			text += "\n" + next
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
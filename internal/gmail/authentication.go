@@ -0,0 +1,451 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	gmailapi "google.golang.org/api/gmail/v1"
+)
+
+// authHeaders are the headers check_authentication fetches.
+var authHeaders = []string{"From", "Return-Path", "Authentication-Results", "Received-SPF", "DKIM-Signature"}
+
+// --- check_authentication ---
+
+type checkAuthenticationInput struct {
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox   string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	MessageID string `json:"message_id" jsonschema:"Gmail message ID (from search results)"`
+}
+
+func registerCheckAuthentication(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "check_authentication",
+		Description: `Check whether a Gmail message passed SPF, DKIM and DMARC, to investigate spoofing, phishing or deliverability.
+
+Summarizes the Authentication-Results headers (the checks the receiving servers made, with the domain each one authenticated), the Received-SPF header and the DKIM signatures of the message. The results of the first Authentication-Results header are those of the server that delivered the message to this mailbox (mx.google.com for mail received by Gmail); headers further down were added before, possibly by the sender, and can be forged.
+
+Messages sent from this mailbox carry no results. Use read_message with include_all_headers for the raw headers.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input checkAuthenticationInput) (*mcp.CallToolResult, any, error) {
+		if input.MessageID == "" {
+			return nil, nil, fmt.Errorf("message_id is required")
+		}
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		msg, err := svc.Users.Messages.Get(userID(input.Mailbox), input.MessageID).
+			Format("metadata").
+			MetadataHeaders(authHeaders...).
+			Fields("id,payload(headers)").
+			Context(ctx).
+			Do()
+		if err != nil {
+			err = gerrors.Wrap(err, "getting message")
+			if input.Mailbox == "" {
+				err = srv.CrossAccountHint(ctx, mgr, input.Account, "message "+input.MessageID, err, messageProbe(mgr, input.MessageID))
+			}
+			return nil, nil, err
+		}
+		var headers []*gmailapi.MessagePartHeader
+		if msg.Payload != nil {
+			headers = msg.Payload.Headers
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Message ID: %s\n%s", msg.Id, formatAuthentication(headers))},
+			},
+		}, nil, nil
+	})
+}
+
+// formatAuthentication summarizes the authentication headers of a message
+// for check_authentication.
+func formatAuthentication(headers []*gmailapi.MessagePartHeader) string {
+	var sb strings.Builder
+	var results []authResults
+	var unparsed []string
+	var spf []authResult
+	var sigs []dkimSignature
+	for _, h := range headers {
+		v := unfoldHeader(h.Value)
+		switch strings.ToLower(h.Name) {
+		case "from", "return-path":
+			fmt.Fprintf(&sb, "%s: %s\n", h.Name, v)
+		case "authentication-results":
+			if ar, ok := parseAuthResults(v); ok {
+				results = append(results, ar)
+			} else {
+				unparsed = append(unparsed, v)
+			}
+		case "received-spf":
+			if r, ok := parseReceivedSPF(v); ok {
+				spf = append(spf, r)
+			}
+		case "dkim-signature":
+			sigs = append(sigs, parseDKIMSignature(v))
+		}
+	}
+
+	if len(results) == 0 && len(spf) == 0 {
+		sb.WriteString("\nNo Authentication-Results or Received-SPF header: no receiving server checked this message (messages sent from this mailbox or imported into it aren't checked).\n")
+	}
+	for i, ar := range results {
+		name := ar.AuthServID
+		if name == "" {
+			name = "an unnamed server"
+		}
+		switch i {
+		case 0:
+			fmt.Fprintf(&sb, "\nResults of %s (the receiving server):\n", name)
+		case 1:
+			sb.WriteString("\nEarlier results, added by other servers (the sender can forge these):\n")
+			fallthrough
+		default:
+			fmt.Fprintf(&sb, "Results of %s:\n", name)
+		}
+		if len(ar.Results) == 0 {
+			sb.WriteString("  (none)\n")
+		}
+		for _, r := range ar.Results {
+			fmt.Fprintf(&sb, "  %s\n", r)
+		}
+	}
+	for _, v := range unparsed {
+		fmt.Fprintf(&sb, "\nUnparseable Authentication-Results: %s\n", v)
+	}
+	if len(spf) > 0 {
+		sb.WriteString("\nReceived-SPF:\n")
+		for _, r := range spf {
+			fmt.Fprintf(&sb, "  %s\n", r)
+		}
+	}
+	if len(sigs) > 0 {
+		sb.WriteString("\nDKIM signatures:\n")
+		for _, sig := range sigs {
+			fmt.Fprintf(&sb, "  %s\n", sig)
+		}
+	} else {
+		sb.WriteString("\nDKIM signatures: none\n")
+	}
+	return sb.String()
+}
+
+// authResults is a parsed Authentication-Results header (RFC 8601).
+type authResults struct {
+	AuthServID string // the server that made the checks, e.g. mx.google.com
+	Results    []authResult
+}
+
+// authResult is the result of one authentication method.
+type authResult struct {
+	Method  string // e.g. "spf", "dkim", "dmarc"
+	Result  string // e.g. "pass", "fail", "softfail", "none"
+	Reason  string
+	Comment string // the first comment, e.g. Gmail's DMARC policy "p=REJECT sp=REJECT dis=NONE"
+	// Props are the properties by name, e.g. "smtp.mailfrom" or "header.d".
+	Props map[string]string
+}
+
+// domain returns the domain r authenticated: the envelope sender's for SPF,
+// the signing domain for DKIM and the From domain for DMARC.
+func (r authResult) domain() string {
+	var keys []string
+	switch r.Method {
+	case "spf":
+		keys = []string{"smtp.mailfrom", "envelope-from", "smtp.helo", "helo"}
+	case "dkim", "dkim-atps":
+		if d := r.Props["header.d"]; d != "" {
+			return strings.ToLower(d)
+		}
+		keys = []string{"header.i"}
+	case "dmarc", "bimi":
+		keys = []string{"header.from", "header.d"}
+	}
+	for _, k := range keys {
+		if v := r.Props[k]; v != "" {
+			return domainOf(v)
+		}
+	}
+	return ""
+}
+
+// String formats r as "SPF: pass, domain example.com (comment)".
+func (r authResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", strings.ToUpper(r.Method), r.Result)
+	if d := r.domain(); d != "" {
+		fmt.Fprintf(&sb, ", domain %s", d)
+	}
+	if r.Method == "dkim" && r.Props["header.s"] != "" {
+		fmt.Fprintf(&sb, ", selector %s", r.Props["header.s"])
+	}
+	if ip := r.Props["client-ip"]; ip != "" {
+		fmt.Fprintf(&sb, ", client IP %s", ip)
+	}
+	if r.Reason != "" {
+		fmt.Fprintf(&sb, ", reason: %s", r.Reason)
+	}
+	if r.Comment != "" {
+		fmt.Fprintf(&sb, " (%s)", r.Comment)
+	}
+	return sb.String()
+}
+
+// domainOf returns the domain of an address or an @domain identity, or s
+// when it is a domain already.
+func domainOf(s string) string {
+	s = strings.Trim(s, "<>")
+	if i := strings.LastIndexByte(s, '@'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToLower(s)
+}
+
+// parseAuthResults parses an Authentication-Results value:
+//
+//	authserv-id [version] ; method[/version]=result [reason=...] [ptype.property=value ...] ; ...
+//
+// Comments and quoted strings are understood; results that don't start
+// with method=result are skipped, so a partly malformed header still
+// yields the others. It fails only when v is empty or starts with neither
+// an authserv-id nor a result.
+func parseAuthResults(v string) (authResults, bool) {
+	var ar authResults
+	segments := splitAuthTokens(authTokens(v))
+	if len(segments) == 0 || len(segments[0]) == 0 || segments[0][0].kind != authWord {
+		return ar, false
+	}
+	// Exchange Online leaves out the authserv-id.
+	if len(segments[0]) > 1 && segments[0][1].kind == authEquals {
+		segments = append([][]authToken{nil}, segments...)
+	} else {
+		ar.AuthServID = segments[0][0].text
+	}
+	for _, seg := range segments[1:] {
+		r, ok := parseResinfo(seg)
+		if ok {
+			ar.Results = append(ar.Results, r)
+		}
+	}
+	return ar, true
+}
+
+// parseResinfo parses the tokens of one result of an Authentication-Results
+// header. "none", which says there are no results, is not one.
+func parseResinfo(seg []authToken) (authResult, bool) {
+	var r authResult
+	pairs, comment := authPairs(seg)
+	if len(pairs) == 0 {
+		return r, false
+	}
+	r.Method, _, _ = strings.Cut(strings.ToLower(pairs[0][0]), "/")
+	r.Result = strings.ToLower(pairs[0][1])
+	r.Comment = comment
+	r.Props = make(map[string]string)
+	for _, p := range pairs[1:] {
+		key := strings.ToLower(p[0])
+		if key == "reason" {
+			r.Reason = p[1]
+			continue
+		}
+		if _, dup := r.Props[key]; !dup {
+			r.Props[key] = p[1]
+		}
+	}
+	return r, true
+}
+
+// parseReceivedSPF parses a Received-SPF value (RFC 7208 section 9.1):
+//
+//	result (comment) client-ip=...; envelope-from=...; helo=...
+func parseReceivedSPF(v string) (authResult, bool) {
+	tokens := authTokens(v)
+	if len(tokens) == 0 || tokens[0].kind != authWord {
+		return authResult{}, false
+	}
+	r := authResult{Method: "spf", Result: strings.ToLower(tokens[0].text), Props: make(map[string]string)}
+	pairs, comment := authPairs(tokens[1:])
+	r.Comment = comment
+	for _, p := range pairs {
+		r.Props[strings.ToLower(p[0])] = p[1]
+	}
+	return r, true
+}
+
+// dkimSignature is the part of a DKIM-Signature header check_authentication
+// shows.
+type dkimSignature struct {
+	Domain, Selector, Algorithm string
+}
+
+// parseDKIMSignature reads the d=, s= and a= tags of a DKIM-Signature
+// value (RFC 6376 section 3.5).
+func parseDKIMSignature(v string) dkimSignature {
+	var sig dkimSignature
+	for _, tag := range strings.Split(v, ";") {
+		name, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Join(strings.Fields(value), "")
+		switch strings.TrimSpace(name) {
+		case "d":
+			sig.Domain = strings.ToLower(value)
+		case "s":
+			sig.Selector = value
+		case "a":
+			sig.Algorithm = value
+		}
+	}
+	return sig
+}
+
+// String formats sig as "example.com (selector s1, rsa-sha256)".
+func (sig dkimSignature) String() string {
+	var details []string
+	if sig.Selector != "" {
+		details = append(details, "selector "+sig.Selector)
+	}
+	if sig.Algorithm != "" {
+		details = append(details, sig.Algorithm)
+	}
+	domain := sig.Domain
+	if domain == "" {
+		domain = "(no d= tag)"
+	}
+	if len(details) == 0 {
+		return domain
+	}
+	return fmt.Sprintf("%s (%s)", domain, strings.Join(details, ", "))
+}
+
+// Kinds of authToken.
+const (
+	authWord = iota
+	authQuoted
+	authComment
+	authEquals
+	authSemicolon
+)
+
+// authToken is a lexical token of an Authentication-Results or
+// Received-SPF value.
+type authToken struct {
+	kind int
+	text string
+}
+
+// authTokens splits v into words, quoted strings, comments (without their
+// parentheses, which may nest), "=" and ";".
+func authTokens(v string) []authToken {
+	var tokens []authToken
+	for i := 0; i < len(v); {
+		c := v[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '=':
+			tokens = append(tokens, authToken{authEquals, "="})
+			i++
+		case c == ';':
+			tokens = append(tokens, authToken{authSemicolon, ";"})
+			i++
+		case c == '(':
+			depth, j := 0, i
+			var sb strings.Builder
+		comment:
+			for ; j < len(v); j++ {
+				switch v[j] {
+				case '\\':
+					if j+1 < len(v) {
+						j++
+						sb.WriteByte(v[j])
+					}
+					continue
+				case '(':
+					depth++
+					if depth == 1 {
+						continue
+					}
+				case ')':
+					depth--
+					if depth == 0 {
+						break comment
+					}
+				}
+				sb.WriteByte(v[j])
+			}
+			tokens = append(tokens, authToken{authComment, strings.Join(strings.Fields(sb.String()), " ")})
+			i = j + 1
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(v) && v[j] != '"'; j++ {
+				if v[j] == '\\' && j+1 < len(v) {
+					j++
+				}
+				sb.WriteByte(v[j])
+			}
+			tokens = append(tokens, authToken{authQuoted, sb.String()})
+			i = j + 1
+		default:
+			j := i
+			for j < len(v) && !strings.ContainsRune(" \t\r\n=;(\"", rune(v[j])) {
+				j++
+			}
+			tokens = append(tokens, authToken{authWord, v[i:j]})
+			i = j
+		}
+	}
+	return tokens
+}
+
+// splitAuthTokens splits tokens at each ";".
+func splitAuthTokens(tokens []authToken) [][]authToken {
+	var segments [][]authToken
+	start := 0
+	for i, t := range tokens {
+		if t.kind == authSemicolon {
+			segments = append(segments, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, tokens[start:])
+}
+
+// authPairs returns the name=value pairs of tokens in order, and the first
+// comment among them. Other tokens are skipped.
+func authPairs(tokens []authToken) (pairs [][2]string, comment string) {
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind == authComment {
+			if comment == "" {
+				comment = t.text
+			}
+			continue
+		}
+		if t.kind == authWord && i+2 < len(tokens) && tokens[i+1].kind == authEquals &&
+			(tokens[i+2].kind == authWord || tokens[i+2].kind == authQuoted) {
+			pairs = append(pairs, [2]string{t.text, tokens[i+2].text})
+			i += 2
+		}
+	}
+	return pairs, comment
+}
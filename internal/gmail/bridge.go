@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"html"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/bridge"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
 	"github.com/thegrumpylion/google-mcp/internal/server"
+	gmailapi "google.golang.org/api/gmail/v1"
 )
 
 // resolveDriveAttachments fetches Drive files server-side and appends them
@@ -69,7 +76,7 @@ func resolveLocalAttachments(lfs *localfs.FS, input *composeInput) error {
 // --- save_attachment_to_drive ---
 
 type saveAttachmentToDriveInput struct {
-	GmailAccount string `json:"account" jsonschema:"Gmail account name (source)"`
+	GmailAccount string `json:"account,omitempty" jsonschema:"Gmail account name (source; optional when only one account is configured or a default is set)"`
 	MessageID    string `json:"message_id" jsonschema:"Gmail message ID that contains the attachment"`
 	AttachmentID string `json:"attachment_id" jsonschema:"Attachment ID (from read_message or read_thread results)"`
 	DriveAccount string `json:"drive_account" jsonschema:"Drive account name (destination)"`
@@ -85,7 +92,10 @@ func registerSaveAttachmentToDrive(srv *server.Server, mgr *auth.Manager) {
 This transfers the file server-side — the attachment data never enters the conversation.
 Use read_message to discover attachment IDs, then use this tool to save them to Drive.`,
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input saveAttachmentToDriveInput) (*mcp.CallToolResult, any, error) {
 		result, err := bridge.SaveAttachmentToDrive(ctx, mgr, bridge.SaveAttachmentToDriveParams{
@@ -115,3 +125,447 @@ Use read_message to discover attachment IDs, then use this tool to save them to
 		}, nil, nil
 	})
 }
+
+// --- export_thread_to_drive ---
+
+type exportThreadToDriveInput struct {
+	Account            string `json:"account,omitempty" jsonschema:"Gmail account name (source; optional when only one account is configured or a default is set)"`
+	ThreadID           string `json:"thread_id" jsonschema:"Thread ID to export"`
+	DriveAccount       string `json:"drive_account" jsonschema:"Drive account name (destination)"`
+	FolderID           string `json:"folder_id,omitempty" jsonschema:"Drive folder ID to save into (default: root)"`
+	Format             string `json:"format,omitempty" jsonschema:"Document format: 'text' (default) or 'html'"`
+	IncludeAttachments bool   `json:"include_attachments,omitempty" jsonschema:"Also save the thread's attachments to Drive, in a subfolder next to the document (default: false)"`
+}
+
+// exportedAttachment is the outcome of saving one attachment in
+// export_thread_to_drive.
+type exportedAttachment struct {
+	Name   string
+	FileID string
+	Err    error
+}
+
+func registerExportThreadToDrive(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "export_thread_to_drive",
+		Description: `Export a Gmail thread to Google Drive as a single document, e.g. to archive a conversation or share it outside email.
+
+The thread is rendered as plain text (the same layout read_thread shows) or simple HTML, with each
+message's headers and body, and saved as "Thread - <subject> - <date>.txt" (or .html). With
+include_attachments, the attachments are saved server-side into a subfolder next to the document;
+their data never enters the conversation. The result lists the IDs of the created files.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input exportThreadToDriveInput) (*mcp.CallToolResult, any, error) {
+		if input.ThreadID == "" {
+			return nil, nil, fmt.Errorf("thread_id is required")
+		}
+		format := input.Format
+		if format == "" {
+			format = "text"
+		}
+		if format != "text" && format != "html" {
+			return nil, nil, fmt.Errorf("invalid format %q: must be 'text' or 'html'", input.Format)
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		thread, err := svc.Users.Threads.Get("me", input.ThreadID).Format("full").Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "getting thread")
+		}
+
+		content, mimeType := renderThreadExport(thread, format)
+		name := threadExportName(thread, format)
+		doc, err := bridge.CreateDriveFile(ctx, mgr, bridge.CreateDriveFileParams{
+			DriveAccount: input.DriveAccount,
+			Name:         name,
+			MIMEType:     mimeType,
+			FolderID:     input.FolderID,
+			Content:      []byte(content),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Thread exported to Drive.\n\nFile ID: %s\nName: %s\nMessages: %d\n", doc.FileID, doc.FileName, len(thread.Messages))
+		if doc.WebViewLink != "" {
+			fmt.Fprintf(&sb, "Link: %s\n", doc.WebViewLink)
+		}
+		if !input.IncludeAttachments {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: sb.String()},
+				},
+			}, nil, nil
+		}
+
+		type pending struct {
+			messageID string
+			attachmentInfo
+		}
+		var atts []pending
+		for _, msg := range thread.Messages {
+			for _, a := range listAttachments(msg.Payload) {
+				if !a.inline {
+					atts = append(atts, pending{msg.Id, a})
+				}
+			}
+		}
+		if len(atts) == 0 {
+			sb.WriteString("\nThe thread has no attachments.\n")
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: sb.String()},
+				},
+			}, nil, nil
+		}
+
+		folder, err := bridge.CreateDriveFolder(ctx, mgr, bridge.CreateDriveFolderParams{
+			DriveAccount: input.DriveAccount,
+			Name:         strings.TrimSuffix(name, path.Ext(name)) + " - attachments",
+			FolderID:     input.FolderID,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("document %s was created, but creating the attachments folder failed: %w", doc.FileID, err)
+		}
+
+		results := make([]exportedAttachment, len(atts))
+		for i, a := range atts {
+			results[i].Name = a.filename
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				continue
+			}
+			saved, err := bridge.SaveAttachmentToDrive(ctx, mgr, bridge.SaveAttachmentToDriveParams{
+				GmailAccount: input.Account,
+				MessageID:    a.messageID,
+				AttachmentID: a.attachmentID,
+				DriveAccount: input.DriveAccount,
+				FileName:     a.filename,
+				FolderID:     folder.FileID,
+			})
+			if err != nil {
+				results[i].Err = err
+			} else {
+				results[i].FileID = saved.FileID
+			}
+			server.Progress(ctx, req, i+1, len(atts), fmt.Sprintf("Saved %d of %d attachments", i+1, len(atts)))
+		}
+		sb.WriteString(formatExportedAttachments(folder.FileID, results))
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// renderThreadExport renders a full-format thread as a document in format
+// ("text" or "html") and returns it with its MIME type. The text rendering
+// is the one read_thread shows.
+func renderThreadExport(thread *gmailapi.Thread, format string) (content, mimeType string) {
+	subject := threadSubject(thread)
+	if format == "html" {
+		return renderThreadHTML(thread, subject), "text/html"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Subject: %s\nThread ID: %s\nMessages: %d\n\n", subject, thread.Id, len(thread.Messages))
+	for i, msg := range thread.Messages {
+		sb.WriteString(formatThreadMessage(msg, i, len(thread.Messages), true))
+	}
+	return sb.String(), "text/plain"
+}
+
+// renderThreadHTML renders a thread as a standalone HTML page, one section
+// per message with its headers, body and attachment names. Bodies are
+// shown as preformatted text, so HTML-only messages appear as source.
+func renderThreadHTML(thread *gmailapi.Thread, subject string) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n</head>\n<body>\n", html.EscapeString(subject))
+	fmt.Fprintf(&sb, "<h1>%s</h1>\n<p>Thread ID: %s<br>Messages: %d</p>\n",
+		html.EscapeString(subject), html.EscapeString(thread.Id), len(thread.Messages))
+	for i, msg := range thread.Messages {
+		fmt.Fprintf(&sb, "<hr>\n<h2>Message %d/%d</h2>\n<table>\n", i+1, len(thread.Messages))
+		headers := messageHeaders(msg)
+		for _, name := range threadHeaders {
+			if v, ok := headers[name]; ok {
+				fmt.Fprintf(&sb, "<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", name, html.EscapeString(v))
+			}
+		}
+		sb.WriteString("</table>\n")
+		body := extractBody(msg.Payload)
+		if body == "" {
+			body = "(no text content)"
+		}
+		fmt.Fprintf(&sb, "<pre>%s</pre>\n", html.EscapeString(body))
+
+		var names []string
+		for _, a := range listAttachments(msg.Payload) {
+			if !a.inline {
+				names = append(names, a.filename)
+			}
+		}
+		if len(names) > 0 {
+			sb.WriteString("<p>Attachments:</p>\n<ul>\n")
+			for _, name := range names {
+				fmt.Fprintf(&sb, "<li>%s</li>\n", html.EscapeString(name))
+			}
+			sb.WriteString("</ul>\n")
+		}
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// threadSubject returns the subject of the first message of thread that
+// has one, or "(no subject)".
+func threadSubject(thread *gmailapi.Thread) string {
+	for _, msg := range thread.Messages {
+		if subject := strings.TrimSpace(messageHeaders(msg)["Subject"]); subject != "" {
+			return subject
+		}
+	}
+	return "(no subject)"
+}
+
+// threadExportName returns the Drive file name for an exported thread,
+// "Thread - <subject> - <date>.<ext>", dated by its first message in UTC.
+// Whitespace runs in the subject, such as folded header lines, become
+// single spaces.
+func threadExportName(thread *gmailapi.Thread, format string) string {
+	name := "Thread - " + strings.Join(strings.Fields(threadSubject(thread)), " ")
+	if len(thread.Messages) > 0 && thread.Messages[0].InternalDate > 0 {
+		name += " - " + time.UnixMilli(thread.Messages[0].InternalDate).UTC().Format("2006-01-02")
+	}
+	if format == "html" {
+		return name + ".html"
+	}
+	return name + ".txt"
+}
+
+// formatExportedAttachments formats the attachments saved by
+// export_thread_to_drive into folderID.
+func formatExportedAttachments(folderID string, results []exportedAttachment) string {
+	saved := 0
+	for _, r := range results {
+		if r.Err == nil {
+			saved++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\nAttachments folder ID: %s\nSaved %d of %d attachments:\n", folderID, saved, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(&sb, "  - %s: error: %v\n", r.Name, r.Err)
+		} else {
+			fmt.Fprintf(&sb, "  - %s (File ID: %s)\n", r.Name, r.FileID)
+		}
+	}
+	return sb.String()
+}
+
+// --- forward_attachment ---
+
+// maxForwardAttachmentSize is Gmail's practical attachment limit.
+const maxForwardAttachmentSize = 25 * 1024 * 1024
+
+type forwardAttachmentInput struct {
+	SourceAccount string `json:"source_account,omitempty" jsonschema:"Gmail account that has the attachment (optional when only one account is configured or a default is set)"`
+	MessageID     string `json:"message_id" jsonschema:"Message ID that contains the attachment (in source_account)"`
+	AttachmentID  string `json:"attachment_id,omitempty" jsonschema:"Attachment ID (from read_message). Either attachment_id or filename is required unless the message has exactly one attachment."`
+	Filename      string `json:"filename,omitempty" jsonschema:"Attachment filename to forward (case-insensitive)"`
+	Account       string `json:"account,omitempty" jsonschema:"Account to send from (destination; may differ from source_account; optional when only one account is configured or a default is set)"`
+	composeInput
+	Force bool `json:"force,omitempty" jsonschema:"Send even if an identical message (same To, subject and body) was sent recently and the server would refuse it as a duplicate (default: false)"`
+}
+
+func registerForwardAttachment(srv *server.Server, mgr *auth.Manager, o *options) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "forward_attachment",
+		Description: `Send an attachment from one Gmail message in a new email, possibly from a different account.
+
+The attachment is fetched and attached server-side — its bytes never enter the conversation.
+Select it with attachment_id or filename (from read_message). Additional attachments can be
+added as in send_message. Attachments over 25 MB are rejected.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input forwardAttachmentInput) (*mcp.CallToolResult, any, error) {
+		if input.MessageID == "" {
+			return nil, nil, fmt.Errorf("message_id is required")
+		}
+		if input.To == "" && input.ToGroup == "" {
+			return nil, nil, fmt.Errorf("to is required (or set to_group)")
+		}
+
+		srcSvc, err := newService(ctx, mgr, input.SourceAccount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service for source account: %w", err)
+		}
+
+		att, err := fetchAttachment(ctx, srcSvc, input.MessageID, input.AttachmentID, input.Filename)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Additional attachments are appended after the forwarded one.
+		inline := input.Attachments
+		input.Attachments = []attachment{att}
+		input.Attachments = append(input.Attachments, inline...)
+		if len(input.LocalAttachments) > 0 {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
+			}
+			if err := resolveLocalAttachments(lfs, &input.composeInput); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := resolveDriveAttachments(ctx, mgr, &input.composeInput); err != nil {
+			return nil, nil, err
+		}
+		if err := resolveURLAttachments(ctx, o.urls, &input.composeInput); err != nil {
+			return nil, nil, err
+		}
+		group, err := resolveToGroup(ctx, mgr, input.Account, &input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		result, err := buildMessage(ctx, svc, "me", input.composeInput, "", o.compose)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		release, err := o.guard.admit(messageDigest(input.To, input.Subject, input.Body), input.Force)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent, err := svc.Users.Messages.Send("me", &gmailapi.Message{Raw: result.Raw}).Context(ctx).Do()
+		if err != nil {
+			release()
+			return nil, nil, gerrors.Wrap(err, "sending message")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Attachment forwarded.\n\nAttachment: %s (%s)\nMessage ID: %s\nThread ID: %s",
+					att.Name, att.MIMEType, sent.Id, sent.ThreadId) + group.note() + result.note()},
+			},
+		}, nil, nil
+	})
+}
+
+// fetchAttachment downloads the selected attachment of a message and
+// returns it ready to attach to an outgoing message.
+func fetchAttachment(ctx context.Context, svc *gmailapi.Service, messageID, attachmentID, filename string) (attachment, error) {
+	msg, err := svc.Users.Messages.Get("me", messageID).Format("full").Context(ctx).Do()
+	if err != nil {
+		return attachment{}, gerrors.Wrap(err, "getting message")
+	}
+
+	info, err := selectAttachment(listAttachments(msg.Payload), attachmentID, filename)
+	if err != nil {
+		return attachment{}, err
+	}
+	if info.size > maxForwardAttachmentSize {
+		return attachment{}, fmt.Errorf("attachment %s is %d bytes, over the 25 MB limit", info.filename, info.size)
+	}
+
+	body, err := svc.Users.Messages.Attachments.Get("me", messageID, info.attachmentID).Context(ctx).Do()
+	if err != nil {
+		return attachment{}, gerrors.Wrap(err, "getting attachment")
+	}
+	data, err := base64.URLEncoding.DecodeString(body.Data)
+	if err != nil {
+		return attachment{}, fmt.Errorf("decoding attachment %s: %w", info.filename, err)
+	}
+	if len(data) > maxForwardAttachmentSize {
+		return attachment{}, fmt.Errorf("attachment %s is %d bytes, over the 25 MB limit", info.filename, len(data))
+	}
+
+	mimeType := info.mimeType
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = guessMIMEType(info.filename)
+	}
+	return attachment{
+		Name:     info.filename,
+		MIMEType: mimeType,
+		Content:  base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// selectAttachment picks one attachment by ID or filename. Gmail may issue
+// a different attachment ID each time a message is fetched, so an ID that
+// matches nothing falls back to the filename, or to the only attachment.
+// The original ID is kept in that case since it is still valid for
+// download.
+func selectAttachment(atts []attachmentInfo, attachmentID, filename string) (attachmentInfo, error) {
+	if len(atts) == 0 {
+		return attachmentInfo{}, fmt.Errorf("message has no attachments")
+	}
+
+	if attachmentID != "" {
+		for _, a := range atts {
+			if a.attachmentID == attachmentID {
+				return a, nil
+			}
+		}
+	}
+	if filename != "" {
+		var matches []attachmentInfo
+		for _, a := range atts {
+			if strings.EqualFold(a.filename, filename) {
+				matches = append(matches, a)
+			}
+		}
+		switch len(matches) {
+		case 1:
+			return matches[0], nil
+		case 0:
+		default:
+			return attachmentInfo{}, fmt.Errorf("%d attachments are named %q; use attachment_id", len(matches), filename)
+		}
+	}
+	// Inline parts (signature logos and the like) don't count when picking
+	// the only attachment, unless there is nothing else.
+	regular := atts
+	if r := slices.DeleteFunc(slices.Clone(atts), func(a attachmentInfo) bool { return a.inline }); len(r) > 0 {
+		regular = r
+	}
+	if len(regular) == 1 && (attachmentID != "" || filename == "") {
+		a := regular[0]
+		if attachmentID != "" {
+			a.attachmentID = attachmentID
+		}
+		return a, nil
+	}
+
+	names := make([]string, len(atts))
+	for i, a := range atts {
+		names[i] = a.filename
+	}
+	if attachmentID == "" && filename == "" {
+		return attachmentInfo{}, fmt.Errorf("message has %d attachments; specify attachment_id or filename (available: %s)", len(atts), strings.Join(names, ", "))
+	}
+	return attachmentInfo{}, fmt.Errorf("no matching attachment on message (available: %s)", strings.Join(names, ", "))
+}
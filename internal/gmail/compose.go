@@ -1,11 +1,15 @@
 package gmail
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"math/rand/v2"
+	"net/mail"
+	"slices"
 	"strings"
 
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
 
@@ -32,7 +36,9 @@ type localAttachment struct {
 
 // composeInput holds the common fields for composing an email message.
 type composeInput struct {
-	To               string            `json:"to" jsonschema:"Recipient email address"`
+	From             string            `json:"from,omitempty" jsonschema:"Send from this address instead of the primary one. Must be a verified send-as alias (see list_send_as)."`
+	To               string            `json:"to,omitempty" jsonschema:"Recipient email addresses (comma-separated). Optional when to_group is set."`
+	ToGroup          string            `json:"to_group,omitempty" jsonschema:"Contact group (Gmail contact label) whose members are added to To, by name (e.g. 'family') or resource name ('contactGroups/...'). Groups of more than 50 members are rejected."`
 	Subject          string            `json:"subject" jsonschema:"Email subject line"`
 	Body             string            `json:"body" jsonschema:"Email body (plain text)"`
 	Cc               string            `json:"cc,omitempty" jsonschema:"CC recipients (comma-separated email addresses)"`
@@ -40,6 +46,7 @@ type composeInput struct {
 	Attachments      []attachment      `json:"attachments,omitempty" jsonschema:"File attachments (base64-encoded content)"`
 	DriveAttachments []driveAttachment `json:"drive_attachments,omitempty" jsonschema:"Google Drive files to attach (fetched server-side, content never enters conversation)"`
 	LocalAttachments []localAttachment `json:"local_attachments,omitempty" jsonschema:"Local files to attach (read from directories allowed via --allow-read-dir). Requires local file access to be enabled."`
+	URLAttachments   []urlAttachment   `json:"url_attachments,omitempty" jsonschema:"Files to download from http(s) URLs and attach (fetched server-side, content never enters conversation). Only URLs under a prefix allowed via --allow-attachment-url-prefix."`
 }
 
 // composeResult holds the result of building an email message.
@@ -48,13 +55,55 @@ type composeResult struct {
 	Raw string
 	// ThreadID is set when replying to an existing message.
 	ThreadID string
+	// Policy lists what the server's compose policy added, for the
+	// tool's confirmation output.
+	Policy []string
+}
+
+// note describes the compose policy additions for the tool's confirmation
+// output.
+func (r *composeResult) note() string {
+	if len(r.Policy) == 0 {
+		return ""
+	}
+	return "\n\nAdded by server policy:\n  " + strings.Join(r.Policy, "\n  ")
+}
+
+// preparedMessage is a message whose headers and recipients are final but
+// which is not encoded yet. Only inline attachments are part of it: Drive,
+// local and URL attachments must be resolved into input.Attachments before
+// prepareMessage, or, for a preview, only described.
+type preparedMessage struct {
+	// input is the compose input with the From alias resolved and the
+	// policy's Bcc addresses added.
+	input composeInput
+	// headers are the reply and policy headers, in RFC 2822 form.
+	headers  string
+	threadID string
+	policy   []string
 }
 
 // buildMessage builds an RFC 2822 message from the compose input.
 // If replyToMsgID is non-empty, the original message is fetched to set
 // In-Reply-To/References headers and resolve the thread ID.
 // When attachments are present, the message is built as multipart/mixed.
-func buildMessage(svc *gmailapi.Service, input composeInput, replyToMsgID string) (*composeResult, error) {
+// policy, if non-nil, adds its Bcc addresses and headers. Send-as aliases
+// and the reply-to message are looked up in the mailbox of user.
+func buildMessage(ctx context.Context, svc *gmailapi.Service, user string, input composeInput, replyToMsgID string, policy *ComposePolicy) (*composeResult, error) {
+	msg, err := prepareMessage(ctx, svc, user, input, replyToMsgID, policy)
+	if err != nil {
+		return nil, err
+	}
+	return msg.encode(), nil
+}
+
+// prepareMessage validates the compose input and computes the message's
+// headers and recipients, as described for buildMessage.
+func prepareMessage(ctx context.Context, svc *gmailapi.Service, user string, input composeInput, replyToMsgID string, policy *ComposePolicy) (*preparedMessage, error) {
+	if strings.TrimSpace(input.To) == "" {
+		return nil, fmt.Errorf("to is required (or set to_group)")
+	}
+
 	// Validate attachments upfront.
 	for i, att := range input.Attachments {
 		if att.Name == "" {
@@ -69,45 +118,116 @@ func buildMessage(svc *gmailapi.Service, input composeInput, replyToMsgID string
 		}
 	}
 
+	// Resolve the From header from the account's send-as aliases. The
+	// caller's value is replaced by the full "Name <address>" header.
+	if input.From != "" {
+		resp, err := svc.Users.Settings.SendAs.List(user).Context(ctx).Do()
+		if err != nil {
+			return nil, gerrors.Wrap(err, "listing send-as aliases")
+		}
+		from, err := resolveFromAlias(resp.SendAs, input.From)
+		if err != nil {
+			return nil, err
+		}
+		input.From = from
+	}
+
 	var threadID string
 
 	// Resolve reply-to headers and thread ID.
 	var replyHeaders string
 	if replyToMsgID != "" {
-		origMsg, err := svc.Users.Messages.Get("me", replyToMsgID).
+		origMsg, err := svc.Users.Messages.Get(user, replyToMsgID).
 			Format("metadata").
-			MetadataHeaders("Message-Id").
+			MetadataHeaders("Message-Id", "References").Context(ctx).
 			Do()
 		if err != nil {
-			return nil, fmt.Errorf("fetching reply-to message %s: %w", replyToMsgID, err)
-		}
-		if origMsg.Payload != nil {
-			for _, h := range origMsg.Payload.Headers {
-				if h.Name == "Message-Id" {
-					replyHeaders = fmt.Sprintf("In-Reply-To: %s\r\nReferences: %s\r\n", h.Value, h.Value)
-				}
-			}
+			return nil, gerrors.Wrapf(err, "fetching reply-to message %s", replyToMsgID)
 		}
+		replyHeaders = referenceHeaders(origMsg)
+		// The reply joins the original's thread, which has its own ID
+		// distinct from the message ID.
 		threadID = origMsg.ThreadId
 	}
 
+	// Policy headers go after everything the input controls.
+	applied := policy.applyBcc(&input)
+	var extra strings.Builder
+	extra.WriteString(replyHeaders)
+	applied = append(applied, policy.writeHeaders(&extra)...)
+
+	return &preparedMessage{
+		input:    input,
+		headers:  extra.String(),
+		threadID: threadID,
+		policy:   applied,
+	}, nil
+}
+
+// maxReferences is the number of message IDs a reply's References keeps:
+// the first of the thread and the most recent ones.
+const maxReferences = 20
+
+// referenceHeaders returns the In-Reply-To and References headers of a
+// reply to orig, a message fetched with at least its Message-Id and
+// References headers. References is the original's chain followed by its
+// Message-Id, so that clients threading by References keep the whole
+// conversation together. Long chains are cut to the first ID and the most
+// recent ones, as RFC 5322 allows, and the header is folded to keep its
+// lines short. Header names are matched case-insensitively:
+// senders write both Message-Id and Message-ID. It returns "" if orig has
+// no Message-Id.
+func referenceHeaders(orig *gmailapi.Message) string {
+	if orig.Payload == nil {
+		return ""
+	}
+	var msgID string
+	var refs []string
+	for _, h := range orig.Payload.Headers {
+		switch {
+		case strings.EqualFold(h.Name, "Message-Id"):
+			msgID = strings.TrimSpace(h.Value)
+		case strings.EqualFold(h.Name, "References"):
+			// Folded headers keep their line breaks; split on any whitespace.
+			refs = strings.Fields(h.Value)
+		}
+	}
+	if msgID == "" {
+		return ""
+	}
+	msgID = singleLine(msgID)
+	if !slices.Contains(refs, msgID) {
+		refs = append(refs, msgID)
+	}
+	if len(refs) > maxReferences {
+		refs = append(refs[:1], refs[len(refs)-maxReferences+1:]...)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "In-Reply-To: %s\r\n", msgID)
+	writeFoldedHeader(&sb, "References", strings.Join(refs, " "))
+	return sb.String()
+}
+
+// encode builds the raw message.
+func (m *preparedMessage) encode() *composeResult {
 	var raw string
-	if len(input.Attachments) == 0 {
-		raw = buildPlainMessage(input, replyHeaders)
+	if len(m.input.Attachments) == 0 {
+		raw = buildPlainMessage(m.input, m.headers)
 	} else {
-		raw = buildMultipartMessage(input, replyHeaders)
+		raw = buildMultipartMessage(m.input, m.headers)
 	}
 
 	return &composeResult{
 		Raw:      base64.URLEncoding.EncodeToString([]byte(raw)),
-		ThreadID: threadID,
-	}, nil
+		ThreadID: m.threadID,
+		Policy:   m.policy,
+	}
 }
 
 // buildPlainMessage builds a simple text/plain RFC 2822 message.
-func buildPlainMessage(input composeInput, replyHeaders string) string {
+func buildPlainMessage(input composeInput, extraHeaders string) string {
 	var raw strings.Builder
-	writeCommonHeaders(&raw, input, replyHeaders)
+	writeCommonHeaders(&raw, input, extraHeaders)
 	fmt.Fprintf(&raw, "Content-Type: text/plain; charset=\"UTF-8\"\r\n")
 	raw.WriteString("\r\n")
 	raw.WriteString(input.Body)
@@ -115,11 +235,11 @@ func buildPlainMessage(input composeInput, replyHeaders string) string {
 }
 
 // buildMultipartMessage builds a multipart/mixed RFC 2822 message with attachments.
-func buildMultipartMessage(input composeInput, replyHeaders string) string {
+func buildMultipartMessage(input composeInput, extraHeaders string) string {
 	boundary := generateBoundary()
 
 	var raw strings.Builder
-	writeCommonHeaders(&raw, input, replyHeaders)
+	writeCommonHeaders(&raw, input, extraHeaders)
 	fmt.Fprintf(&raw, "MIME-Version: 1.0\r\n")
 	fmt.Fprintf(&raw, "Content-Type: multipart/mixed; boundary=\"%s\"\r\n", boundary)
 	raw.WriteString("\r\n")
@@ -152,19 +272,60 @@ func buildMultipartMessage(input composeInput, replyHeaders string) string {
 	return raw.String()
 }
 
-// writeCommonHeaders writes the shared headers (To, Cc, Bcc, Subject, reply).
-func writeCommonHeaders(w *strings.Builder, input composeInput, replyHeaders string) {
-	fmt.Fprintf(w, "To: %s\r\n", input.To)
+// writeCommonHeaders writes the shared headers (From, To, Cc, Bcc, Subject)
+// followed by extraHeaders (reply and policy headers). Line breaks in input
+// values are flattened so they cannot add headers.
+func writeCommonHeaders(w *strings.Builder, input composeInput, extraHeaders string) {
+	if input.From != "" {
+		fmt.Fprintf(w, "From: %s\r\n", singleLine(input.From))
+	}
+	fmt.Fprintf(w, "To: %s\r\n", singleLine(input.To))
 	if input.Cc != "" {
-		fmt.Fprintf(w, "Cc: %s\r\n", input.Cc)
+		fmt.Fprintf(w, "Cc: %s\r\n", singleLine(input.Cc))
 	}
 	if input.Bcc != "" {
-		fmt.Fprintf(w, "Bcc: %s\r\n", input.Bcc)
+		fmt.Fprintf(w, "Bcc: %s\r\n", singleLine(input.Bcc))
+	}
+	fmt.Fprintf(w, "Subject: %s\r\n", mime2047Encode(singleLine(input.Subject)))
+	if extraHeaders != "" {
+		w.WriteString(extraHeaders)
 	}
-	fmt.Fprintf(w, "Subject: %s\r\n", mime2047Encode(input.Subject))
-	if replyHeaders != "" {
-		w.WriteString(replyHeaders)
+}
+
+// resolveFromAlias finds from among the account's send-as aliases and
+// returns the From header value for it, including the alias's display name.
+// Gmail silently rewrites a From address the account may not use, so the
+// alias must exist and be verified (the primary address always is).
+func resolveFromAlias(aliases []*gmailapi.SendAs, from string) (string, error) {
+	addr := from
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		addr = parsed.Address
+	}
+
+	var match *gmailapi.SendAs
+	var valid []string
+	for _, sa := range aliases {
+		if sendAsVerified(sa) {
+			valid = append(valid, sa.SendAsEmail)
+		}
+		if strings.EqualFold(sa.SendAsEmail, addr) {
+			match = sa
+		}
 	}
+
+	switch {
+	case match == nil:
+		return "", fmt.Errorf("%s is not a send-as alias of this account; verified aliases: %s", addr, strings.Join(valid, ", "))
+	case !sendAsVerified(match):
+		return "", fmt.Errorf("send-as alias %s is not verified (status: %s); verified aliases: %s",
+			match.SendAsEmail, match.VerificationStatus, strings.Join(valid, ", "))
+	}
+	return (&mail.Address{Name: match.DisplayName, Address: match.SendAsEmail}).String(), nil
+}
+
+// sendAsVerified reports whether mail may be sent from a send-as alias.
+func sendAsVerified(sa *gmailapi.SendAs) bool {
+	return sa.IsPrimary || sa.VerificationStatus == "accepted"
 }
 
 // generateBoundary creates a random MIME boundary string.
@@ -0,0 +1,225 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"google.golang.org/api/people/v1"
+)
+
+// maxGroupRecipients caps how many addresses a to_group expands to. Larger
+// groups are rejected rather than truncated, so a message never silently
+// misses part of a group.
+const maxGroupRecipients = 50
+
+func newPeopleService(ctx context.Context, mgr *auth.Manager, account string) (*people.Service, error) {
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), Scopes)
+	if err != nil {
+		return nil, err
+	}
+	return people.NewService(ctx, opt)
+}
+
+// groupExpansion is the result of expanding a contact group into To.
+type groupExpansion struct {
+	Group *people.ContactGroup
+	// Added are the member addresses added to To, in member order.
+	Added []string
+	// Skipped counts members without an email address or already listed
+	// as a recipient.
+	Skipped int
+}
+
+// note describes the expansion for the tool's confirmation output.
+func (g *groupExpansion) note() string {
+	if g == nil {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n\nGroup %q (%s) added %d recipients to To:\n", groupName(g.Group), g.Group.ResourceName, len(g.Added))
+	for _, addr := range g.Added {
+		fmt.Fprintf(&sb, "  - %s\n", addr)
+	}
+	if g.Skipped > 0 {
+		fmt.Fprintf(&sb, "(%d members skipped: no email address or already a recipient)\n", g.Skipped)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// resolveToGroup expands input.ToGroup into member email addresses and
+// merges them into input.To. It does nothing when ToGroup is empty.
+func resolveToGroup(ctx context.Context, mgr *auth.Manager, account string, input *composeInput) (*groupExpansion, error) {
+	if input.ToGroup == "" {
+		return nil, nil
+	}
+	svc, err := newPeopleService(ctx, mgr, account)
+	if err != nil {
+		return nil, fmt.Errorf("creating People service: %w", err)
+	}
+	group, emails, err := expandContactGroup(ctx, svc, input.ToGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := &groupExpansion{Group: group}
+	input.To, exp.Added = mergeRecipients(input.To, []string{input.To, input.Cc, input.Bcc}, emails)
+	exp.Skipped = len(group.MemberResourceNames) - len(exp.Added)
+	return exp, nil
+}
+
+// expandContactGroup resolves ref to a contact group and returns the primary
+// email address of each member that has one.
+func expandContactGroup(ctx context.Context, svc *people.Service, ref string) (*people.ContactGroup, []string, error) {
+	resourceName := ref
+	if !strings.HasPrefix(ref, "contactGroups/") {
+		var groups []*people.ContactGroup
+		pageToken := ""
+		for {
+			call := svc.ContactGroups.List().PageSize(1000)
+			if pageToken != "" {
+				call = call.PageToken(pageToken)
+			}
+			resp, err := call.Context(ctx).Do()
+			if err != nil {
+				return nil, nil, gerrors.Wrap(err, "listing contact groups")
+			}
+			groups = append(groups, resp.ContactGroups...)
+			if resp.NextPageToken == "" {
+				break
+			}
+			pageToken = resp.NextPageToken
+		}
+		group, err := findContactGroup(groups, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		resourceName = group.ResourceName
+	}
+
+	// Ask for one more member than the cap to detect oversized groups.
+	group, err := svc.ContactGroups.Get(resourceName).MaxMembers(maxGroupRecipients + 1).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, gerrors.Wrapf(err, "getting contact group %s", resourceName)
+	}
+	if group.MemberCount > maxGroupRecipients || len(group.MemberResourceNames) > maxGroupRecipients {
+		return nil, nil, fmt.Errorf("contact group %q has %d members; at most %d can be expanded into recipients", groupName(group), max(group.MemberCount, int64(len(group.MemberResourceNames))), maxGroupRecipients)
+	}
+
+	// maxGroupRecipients is well below the GetBatchGet limit of 200
+	// resource names, so one call fetches every member.
+	var emails []string
+	if len(group.MemberResourceNames) > 0 {
+		resp, err := svc.People.GetBatchGet().ResourceNames(group.MemberResourceNames...).PersonFields("emailAddresses").Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrapf(err, "getting members of contact group %q", groupName(group))
+		}
+		for _, r := range resp.Responses {
+			if addr := primaryEmail(r.Person); addr != "" {
+				emails = append(emails, addr)
+			}
+		}
+	}
+	if len(emails) == 0 {
+		return nil, nil, fmt.Errorf("contact group %q has no members with an email address", groupName(group))
+	}
+	return group, emails, nil
+}
+
+// findContactGroup finds the group whose name or formatted name (the
+// localized name of system groups such as "Family") equals name,
+// case-insensitively.
+func findContactGroup(groups []*people.ContactGroup, name string) (*people.ContactGroup, error) {
+	var matches []*people.ContactGroup
+	for _, g := range groups {
+		if strings.EqualFold(g.Name, name) || strings.EqualFold(g.FormattedName, name) {
+			matches = append(matches, g)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		names := make([]string, 0, len(groups))
+		for _, g := range groups {
+			names = append(names, groupName(g))
+		}
+		return nil, fmt.Errorf("no contact group named %q (available: %s)", name, strings.Join(names, ", "))
+	default:
+		refs := make([]string, 0, len(matches))
+		for _, g := range matches {
+			refs = append(refs, g.ResourceName)
+		}
+		return nil, fmt.Errorf("%d contact groups are named %q; use the resource name instead: %s", len(matches), name, strings.Join(refs, ", "))
+	}
+}
+
+// groupName returns a contact group's display name.
+func groupName(g *people.ContactGroup) string {
+	if g.FormattedName != "" {
+		return g.FormattedName
+	}
+	return g.Name
+}
+
+// primaryEmail returns a person's primary email address, or the first one
+// if none is marked primary.
+func primaryEmail(p *people.Person) string {
+	if p == nil || len(p.EmailAddresses) == 0 {
+		return ""
+	}
+	for _, e := range p.EmailAddresses {
+		if e.Metadata != nil && e.Metadata.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	return p.EmailAddresses[0].Value
+}
+
+// mergeRecipients appends the addresses in add to the comma-separated to
+// list, skipping any already present in the existing recipient lists
+// (compared case-insensitively by address). It returns the new To value and
+// the addresses that were added.
+func mergeRecipients(to string, existing []string, add []string) (string, []string) {
+	seen := make(map[string]bool)
+	for _, list := range existing {
+		for _, r := range strings.Split(list, ",") {
+			if addr := recipientAddress(r); addr != "" {
+				seen[addr] = true
+			}
+		}
+	}
+
+	var added []string
+	for _, a := range add {
+		addr := recipientAddress(a)
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		added = append(added, a)
+	}
+	if len(added) == 0 {
+		return to, nil
+	}
+	if strings.TrimSpace(to) == "" {
+		return strings.Join(added, ", "), added
+	}
+	return to + ", " + strings.Join(added, ", "), added
+}
+
+// recipientAddress returns the lowercased address of a recipient written as
+// "addr" or "Name <addr>".
+func recipientAddress(r string) string {
+	r = strings.TrimSpace(r)
+	if r == "" {
+		return ""
+	}
+	if parsed, err := mail.ParseAddress(r); err == nil {
+		return strings.ToLower(parsed.Address)
+	}
+	return strings.ToLower(r)
+}
@@ -2,11 +2,13 @@ package gmail
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -14,21 +16,28 @@ import (
 // --- gmail_draft_create ---
 
 type draftCreateInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	composeInput
 	ReplyToMessageID string `json:"reply_to_message_id,omitempty" jsonschema:"Message ID to reply to (sets In-Reply-To and References headers, keeps thread)"`
 }
 
-func registerDraftCreate(srv *server.Server, mgr *auth.Manager) {
+func registerDraftCreate(srv *server.Server, mgr *auth.Manager, o *options) {
 	desc := "Create a Gmail draft. The draft is saved but not sent. Use send_draft to send it later, or list_drafts to see all drafts." + srv.ReadDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name: "create_draft",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: desc,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftCreateInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		if len(input.LocalAttachments) > 0 {
 			lfs := srv.LocalFS()
 			if lfs == nil {
@@ -42,13 +51,20 @@ func registerDraftCreate(srv *server.Server, mgr *auth.Manager) {
 		if err := resolveDriveAttachments(ctx, mgr, &input.composeInput); err != nil {
 			return nil, nil, err
 		}
+		if err := resolveURLAttachments(ctx, o.urls, &input.composeInput); err != nil {
+			return nil, nil, err
+		}
+		group, err := resolveToGroup(ctx, mgr, input.Account, &input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		result, err := buildMessage(svc, input.composeInput, input.ReplyToMessageID)
+		result, err := buildMessage(ctx, svc, userID(input.Mailbox), input.composeInput, input.ReplyToMessageID, o.compose)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -60,15 +76,15 @@ func registerDraftCreate(srv *server.Server, mgr *auth.Manager) {
 			},
 		}
 
-		created, err := svc.Users.Drafts.Create("me", draft).Do()
+		created, err := svc.Users.Drafts.Create(userID(input.Mailbox), draft).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating draft: %w", err)
+			return nil, nil, gerrors.Wrap(err, "creating draft")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: fmt.Sprintf("Draft created.\n\nDraft ID: %s\nMessage ID: %s",
-					created.Id, created.Message.Id)},
+					created.Id, created.Message.Id) + group.note() + result.note()},
 			},
 		}, nil, nil
 	})
@@ -77,7 +93,8 @@ func registerDraftCreate(srv *server.Server, mgr *auth.Manager) {
 // --- gmail_draft_list ---
 
 type draftListInput struct {
-	Account    string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Mailbox    string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of drafts per account (default 20, max 100)"`
 }
 
@@ -86,13 +103,22 @@ func registerDraftList(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_drafts",
 		Description: "List Gmail drafts. Set account to 'all' to list from all accounts. Returns draft IDs and message snippets.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftListInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
+		if err := checkMailboxAccounts(input.Mailbox, accounts); err != nil {
+			return nil, nil, err
+		}
 
 		maxResults := input.MaxResults
 		if maxResults <= 0 {
@@ -106,6 +132,9 @@ func registerDraftList(srv *server.Server, mgr *auth.Manager) {
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
 				if multiAccount {
@@ -115,13 +144,13 @@ func registerDraftList(srv *server.Server, mgr *auth.Manager) {
 				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 			}
 
-			resp, err := svc.Users.Drafts.List("me").MaxResults(maxResults).Do()
+			resp, err := svc.Users.Drafts.List(userID(input.Mailbox)).MaxResults(maxResults).Context(ctx).Do()
 			if err != nil {
 				if multiAccount {
 					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing drafts: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing drafts: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing drafts")
 			}
 
 			if multiAccount {
@@ -134,12 +163,19 @@ func registerDraftList(srv *server.Server, mgr *auth.Manager) {
 			}
 
 			fmt.Fprintf(&sb, "Found %d drafts:\n\n", len(resp.Drafts))
-			for _, draft := range resp.Drafts {
+			// Fetch snippets for the draft messages.
+			ids := make([]string, len(resp.Drafts))
+			for i, draft := range resp.Drafts {
+				ids[i] = draft.Message.Id
+			}
+			details := fetchDetails(ctx, ids, func(id string) (*gmailapi.Message, error) {
+				return svc.Users.Messages.Get(userID(input.Mailbox), id).Format("metadata").MetadataHeaders("To", "Subject").Context(ctx).Do()
+			}, nil)
+			for i, draft := range resp.Drafts {
 				fmt.Fprintf(&sb, "- Draft ID: %s\n  Message ID: %s\n  Account: %s\n",
 					draft.Id, draft.Message.Id, account)
 
-				// Fetch snippet for the draft message.
-				detail, err := svc.Users.Messages.Get("me", draft.Message.Id).Format("metadata").MetadataHeaders("To", "Subject").Do()
+				detail, err := details[i].Value, details[i].Err
 				if err == nil {
 					headers := make(map[string]string)
 					if detail.Payload != nil {
@@ -177,7 +213,8 @@ func registerDraftList(srv *server.Server, mgr *auth.Manager) {
 // --- gmail_draft_get ---
 
 type draftGetInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	DraftID string `json:"draft_id" jsonschema:"Draft ID to read (from draft_list or draft_create)"`
 }
 
@@ -186,17 +223,23 @@ func registerDraftGet(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_draft",
 		Description: "Read the full content of a Gmail draft by ID. Returns headers, body text, and draft metadata.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftGetInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		draft, err := svc.Users.Drafts.Get("me", input.DraftID).Format("full").Do()
+		draft, err := svc.Users.Drafts.Get(userID(input.Mailbox), input.DraftID).Format("full").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting draft: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting draft")
 		}
 
 		var sb strings.Builder
@@ -231,21 +274,28 @@ func registerDraftGet(srv *server.Server, mgr *auth.Manager) {
 // --- gmail_draft_update ---
 
 type draftUpdateInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	DraftID string `json:"draft_id" jsonschema:"Draft ID to update (from draft_list or draft_create)"`
 	composeInput
 }
 
-func registerDraftUpdate(srv *server.Server, mgr *auth.Manager) {
+func registerDraftUpdate(srv *server.Server, mgr *auth.Manager, o *options) {
 	desc := "Update an existing Gmail draft with new content. Replaces the draft message entirely." + srv.ReadDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name: "update_draft",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: desc,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftUpdateInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		if len(input.LocalAttachments) > 0 {
 			lfs := srv.LocalFS()
 			if lfs == nil {
@@ -259,13 +309,20 @@ func registerDraftUpdate(srv *server.Server, mgr *auth.Manager) {
 		if err := resolveDriveAttachments(ctx, mgr, &input.composeInput); err != nil {
 			return nil, nil, err
 		}
+		if err := resolveURLAttachments(ctx, o.urls, &input.composeInput); err != nil {
+			return nil, nil, err
+		}
+		group, err := resolveToGroup(ctx, mgr, input.Account, &input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		result, err := buildMessage(svc, input.composeInput, "")
+		result, err := buildMessage(ctx, svc, userID(input.Mailbox), input.composeInput, "", o.compose)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -276,15 +333,15 @@ func registerDraftUpdate(srv *server.Server, mgr *auth.Manager) {
 			},
 		}
 
-		updated, err := svc.Users.Drafts.Update("me", input.DraftID, draft).Do()
+		updated, err := svc.Users.Drafts.Update(userID(input.Mailbox), input.DraftID, draft).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating draft: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating draft")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
 				&mcp.TextContent{Text: fmt.Sprintf("Draft updated.\n\nDraft ID: %s\nMessage ID: %s",
-					updated.Id, updated.Message.Id)},
+					updated.Id, updated.Message.Id) + group.note() + result.note()},
 			},
 		}, nil, nil
 	})
@@ -293,23 +350,32 @@ func registerDraftUpdate(srv *server.Server, mgr *auth.Manager) {
 // --- gmail_draft_delete ---
 
 type draftDeleteInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	DraftID string `json:"draft_id" jsonschema:"Draft ID to delete (from draft_list or draft_create)"`
 }
 
 func registerDraftDelete(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "delete_draft",
-		Annotations: &mcp.ToolAnnotations{},
+		Name: "delete_draft",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 		Description: "Delete a Gmail draft permanently. The draft message is removed and cannot be recovered.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftDeleteInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		if err := svc.Users.Drafts.Delete("me", input.DraftID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting draft: %w", err)
+		if err := svc.Users.Drafts.Delete(userID(input.Mailbox), input.DraftID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting draft")
 		}
 
 		return &mcp.CallToolResult{
@@ -323,24 +389,46 @@ func registerDraftDelete(srv *server.Server, mgr *auth.Manager) {
 // --- gmail_draft_send ---
 
 type draftSendInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	DraftID string `json:"draft_id" jsonschema:"Draft ID to send (from draft_list or draft_create)"`
+	Force   bool   `json:"force,omitempty" jsonschema:"Send even if an identical message (same To, subject and body) was sent recently and the server would refuse it as a duplicate (default: false)"`
 }
 
-func registerDraftSend(srv *server.Server, mgr *auth.Manager) {
+func registerDraftSend(srv *server.Server, mgr *auth.Manager, o *options) {
 	server.AddTool(srv, &mcp.Tool{
-		Name:        "send_draft",
-		Annotations: &mcp.ToolAnnotations{},
+		Name: "send_draft",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 		Description: "Send an existing Gmail draft. The draft is removed after sending.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input draftSendInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		sent, err := svc.Users.Drafts.Send("me", &gmailapi.Draft{Id: input.DraftID}).Do()
+		// The draft's content is only needed to spot duplicates.
+		var sum [sha256.Size]byte
+		if o.guard.checksDuplicates() {
+			if sum, err = draftDigest(ctx, svc, userID(input.Mailbox), input.DraftID); err != nil {
+				return nil, nil, err
+			}
+		}
+		release, err := o.guard.admit(sum, input.Force)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent, err := svc.Users.Drafts.Send(userID(input.Mailbox), &gmailapi.Draft{Id: input.DraftID}).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("sending draft: %w", err)
+			release()
+			return nil, nil, gerrors.Wrap(err, "sending draft")
 		}
 
 		return &mcp.CallToolResult{
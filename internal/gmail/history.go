@@ -7,13 +7,14 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 )
 
 // --- list_history ---
 
 type listHistoryInput struct {
-	Account        string   `json:"account" jsonschema:"Account name"`
+	Account        string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	StartHistoryID uint64   `json:"start_history_id" jsonschema:"Returns history records after this ID. Obtain from get_profile, read_message, or a previous list_history response."`
 	HistoryTypes   []string `json:"history_types,omitempty" jsonschema:"Filter by history types: 'messageAdded', 'messageDeleted', 'labelAdded', 'labelRemoved'"`
 	LabelID        string   `json:"label_id,omitempty" jsonschema:"Only return messages with this label ID"`
@@ -27,7 +28,10 @@ func registerListHistory(srv *server.Server, mgr *auth.Manager) {
 
 Get the starting history ID from get_profile (historyId field) or from a previous list_history response.`,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listHistoryInput) (*mcp.CallToolResult, any, error) {
 		if input.StartHistoryID == 0 {
@@ -58,9 +62,9 @@ Get the starting history ID from get_profile (historyId field) or from a previou
 			call = call.LabelId(input.LabelID)
 		}
 
-		resp, err := call.Do()
+		resp, err := call.Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing history: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing history")
 		}
 
 		var sb strings.Builder
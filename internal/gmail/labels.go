@@ -3,10 +3,13 @@ package gmail
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -14,26 +17,40 @@ import (
 // --- list_labels ---
 
 type listLabelsInput struct {
-	Account string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	Tree    bool   `json:"tree,omitempty" jsonschema:"Show user labels as a tree, nested labels indented under their parents (default: a flat list)"`
 }
 
 func registerListLabels(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "list_labels",
-		Description: "List all Gmail labels for an account. Set account to 'all' to list labels from all accounts. Useful for filtering searches.",
+		Description: "List all Gmail labels for an account. Set account to 'all' to list labels from all accounts. Useful for filtering searches. Set tree to show nested labels (e.g. 'Clients/Acme/Invoices') indented under their parents.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listLabelsInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
+		if err := checkMailboxAccounts(input.Mailbox, accounts); err != nil {
+			return nil, nil, err
+		}
 
 		var sb strings.Builder
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
 				if multiAccount {
@@ -43,21 +60,25 @@ func registerListLabels(srv *server.Server, mgr *auth.Manager) {
 				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 			}
 
-			resp, err := svc.Users.Labels.List("me").Do()
+			resp, err := svc.Users.Labels.List(userID(input.Mailbox)).Context(ctx).Do()
 			if err != nil {
 				if multiAccount {
 					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing labels: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing labels: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing labels")
 			}
 
 			if multiAccount {
 				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
 			}
 			sb.WriteString("Gmail labels:\n")
-			for _, label := range resp.Labels {
-				fmt.Fprintf(&sb, "  - %s (Label ID: %s, type: %s)\n", label.Name, label.Id, label.Type)
+			if input.Tree {
+				sb.WriteString(formatLabelTree(resp.Labels))
+			} else {
+				for _, label := range resp.Labels {
+					fmt.Fprintf(&sb, "  - %s (Label ID: %s, type: %s)\n", label.Name, label.Id, label.Type)
+				}
 			}
 			sb.WriteString("\n")
 		}
@@ -73,7 +94,8 @@ func registerListLabels(srv *server.Server, mgr *auth.Manager) {
 // --- get_label ---
 
 type getLabelInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	LabelID string `json:"label_id" jsonschema:"Label ID (from list_labels)"`
 }
 
@@ -82,17 +104,23 @@ func registerGetLabel(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_label",
 		Description: "Get details of a Gmail label including unread and total message/thread counts. Use list_labels to discover label IDs.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getLabelInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		label, err := svc.Users.Labels.Get("me", input.LabelID).Do()
+		label, err := svc.Users.Labels.Get(userID(input.Mailbox), input.LabelID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting label: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting label")
 		}
 
 		text := fmt.Sprintf("Label ID: %s\nName: %s\nType: %s\nMessages total: %d\nMessages unread: %d\nThreads total: %d\nThreads unread: %d\nLabel list visibility: %s\nMessage list visibility: %s",
@@ -112,28 +140,58 @@ func registerGetLabel(srv *server.Server, mgr *auth.Manager) {
 // --- create_label ---
 
 type createLabelInput struct {
-	Account               string `json:"account" jsonschema:"Account name"`
+	Account               string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox               string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	Name                  string `json:"name" jsonschema:"Label name (use '/' for nested labels, e.g. 'Projects/Work')"`
 	LabelListVisibility   string `json:"label_list_visibility,omitempty" jsonschema:"Visibility in label list: labelShow, labelShowIfUnread, or labelHide (default: labelShow)"`
 	MessageListVisibility string `json:"message_list_visibility,omitempty" jsonschema:"Visibility in message list: show or hide (default: show)"`
+	CreateParents         bool   `json:"create_parents,omitempty" jsonschema:"Also create missing parent labels of a nested name, e.g. 'Clients' and 'Clients/Acme' for 'Clients/Acme/Invoices' (default: false)"`
 }
 
 func registerCreateLabel(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "create_label",
-		Description: "Create a custom Gmail label for organizing email. Use '/' in the name for nested labels (e.g. 'Projects/Work').",
+		Description: "Create a custom Gmail label for organizing email. Use '/' in the name for nested labels (e.g. 'Projects/Work'). Set create_parents to create missing parent labels first.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createLabelInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		if input.Name == "" {
 			return nil, nil, fmt.Errorf("name is required")
 		}
+		if _, err := splitLabelPath(input.Name); err != nil {
+			return nil, nil, err
+		}
 
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
+		user := userID(input.Mailbox)
+
+		var parents []string
+		if input.CreateParents {
+			names, err := labelNames(ctx, svc, user)
+			if err != nil {
+				return nil, nil, gerrors.Wrap(err, "listing labels")
+			}
+			for _, name := range missingLabelAncestors(input.Name, names) {
+				if _, err := svc.Users.Labels.Create(user, &gmailapi.Label{Name: name}).Context(ctx).Do(); err != nil {
+					err = gerrors.Wrapf(err, "creating parent label %q", name)
+					if len(parents) > 0 {
+						err = fmt.Errorf("%w (parent labels already created: %s)", err, strings.Join(parents, ", "))
+					}
+					return nil, nil, err
+				}
+				parents = append(parents, name)
+			}
+		}
 
 		label := &gmailapi.Label{
 			Name: input.Name,
@@ -145,15 +203,22 @@ func registerCreateLabel(srv *server.Server, mgr *auth.Manager) {
 			label.MessageListVisibility = input.MessageListVisibility
 		}
 
-		created, err := svc.Users.Labels.Create("me", label).Do()
+		created, err := svc.Users.Labels.Create(user, label).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating label: %w", err)
+			err = gerrors.Wrap(err, "creating label")
+			if len(parents) > 0 {
+				err = fmt.Errorf("%w (parent labels already created: %s)", err, strings.Join(parents, ", "))
+			}
+			return nil, nil, err
 		}
 
+		text := fmt.Sprintf("Label created.\n\nLabel ID: %s\nName: %s", created.Id, created.Name)
+		if len(parents) > 0 {
+			text += "\nCreated parent labels: " + strings.Join(parents, ", ")
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Label created.\n\nLabel ID: %s\nName: %s",
-					created.Id, created.Name)},
+				&mcp.TextContent{Text: text},
 			},
 		}, nil, nil
 	})
@@ -162,7 +227,8 @@ func registerCreateLabel(srv *server.Server, mgr *auth.Manager) {
 // --- delete_label ---
 
 type deleteLabelInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	LabelID string `json:"label_id" jsonschema:"Label ID to delete (from list_labels). System labels cannot be deleted."`
 }
 
@@ -170,15 +236,23 @@ func registerDeleteLabel(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_label",
 		Description: "Delete a custom Gmail label. System labels (INBOX, SENT, etc.) cannot be deleted. Messages with this label are not deleted, only the label is removed.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteLabelInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		if err := svc.Users.Labels.Delete("me", input.LabelID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting label: %w", err)
+		if err := svc.Users.Labels.Delete(userID(input.Mailbox), input.LabelID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting label")
 		}
 
 		return &mcp.CallToolResult{
@@ -192,7 +266,8 @@ func registerDeleteLabel(srv *server.Server, mgr *auth.Manager) {
 // --- update_label ---
 
 type updateLabelInput struct {
-	Account               string `json:"account" jsonschema:"Account name"`
+	Account               string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox               string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	LabelID               string `json:"label_id" jsonschema:"Label ID to update (from list_labels). System labels cannot be updated."`
 	Name                  string `json:"name,omitempty" jsonschema:"New label name (leave empty to keep current)"`
 	LabelListVisibility   string `json:"label_list_visibility,omitempty" jsonschema:"Visibility in label list: labelShow, labelShowIfUnread, or labelHide (leave empty to keep current)"`
@@ -204,9 +279,15 @@ func registerUpdateLabel(srv *server.Server, mgr *auth.Manager) {
 		Name:        "update_label",
 		Description: "Update a custom Gmail label. Can rename labels and change visibility settings. System labels (INBOX, SENT, etc.) cannot be updated.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateLabelInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		if input.LabelID == "" {
 			return nil, nil, fmt.Errorf("label_id is required")
 		}
@@ -227,9 +308,9 @@ func registerUpdateLabel(srv *server.Server, mgr *auth.Manager) {
 			label.MessageListVisibility = input.MessageListVisibility
 		}
 
-		updated, err := svc.Users.Labels.Patch("me", input.LabelID, label).Do()
+		updated, err := svc.Users.Labels.Patch(userID(input.Mailbox), input.LabelID, label).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating label: %w", err)
+			return nil, nil, gerrors.Wrap(err, "updating label")
 		}
 
 		return &mcp.CallToolResult{
@@ -240,3 +321,469 @@ func registerUpdateLabel(srv *server.Server, mgr *auth.Manager) {
 		}, nil, nil
 	})
 }
+
+// --- rename_label_tree ---
+
+type renameLabelTreeInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	Name    string `json:"name" jsonschema:"Current full name of the label to rename (e.g. 'Clients/Acme')"`
+	NewName string `json:"new_name" jsonschema:"New full name (e.g. 'Archive/Clients/Acme'). Missing parent labels of the new name are created."`
+}
+
+func registerRenameLabelTree(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "rename_label_tree",
+		Description: `Rename or move a Gmail label together with all its nested labels. Nested labels are names with slashes, so renaming 'Clients/Acme' to 'Archive/Acme' with update_label would leave 'Clients/Acme/Invoices' behind; this tool renames every descendant to the new prefix as well (e.g. 'Archive/Acme/Invoices'), parents first, creating missing parent labels of the new name. Messages keep their labels.
+
+Labels are renamed one at a time. If a rename fails, the ones already done are listed with how to revert them.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input renameLabelTreeInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		if input.Name == "" || input.NewName == "" {
+			return nil, nil, fmt.Errorf("name and new_name are required")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		user := userID(input.Mailbox)
+
+		names, err := labelNames(ctx, svc, user)
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing labels")
+		}
+		plan, err := planLabelRename(names, input.Name, input.NewName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var parents []string
+		for _, name := range missingLabelAncestors(input.NewName, names) {
+			if _, err := svc.Users.Labels.Create(user, &gmailapi.Label{Name: name}).Context(ctx).Do(); err != nil {
+				return nil, nil, gerrors.Wrapf(err, "creating parent label %q", name)
+			}
+			parents = append(parents, name)
+		}
+
+		var done []labelRename
+		for i, r := range plan {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, fmt.Errorf("%w%s", err, renameRollbackNote(done, plan[i:]))
+			}
+			if _, err := svc.Users.Labels.Patch(user, r.ID, &gmailapi.Label{Name: r.To}).Context(ctx).Do(); err != nil {
+				return nil, nil, fmt.Errorf("%w%s", gerrors.Wrapf(err, "renaming label %q", r.From), renameRollbackNote(done, plan[i:]))
+			}
+			done = append(done, r)
+			server.Progress(ctx, req, i+1, len(plan), fmt.Sprintf("Renamed %d of %d labels", i+1, len(plan)))
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Renamed %d labels.\n", len(done))
+		for _, r := range done {
+			fmt.Fprintf(&sb, "  - %s -> %s (Label ID: %s)\n", r.From, r.To, r.ID)
+		}
+		if len(parents) > 0 {
+			fmt.Fprintf(&sb, "Created parent labels: %s\n", strings.Join(parents, ", "))
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: sb.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// splitLabelPath splits a nested label name into its segments, rejecting
+// names with empty segments such as "A//B" or "A/".
+func splitLabelPath(name string) ([]string, error) {
+	parts := strings.Split(name, "/")
+	for _, p := range parts {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("invalid label name %q: nested label names need a non-empty name between slashes", name)
+		}
+	}
+	return parts, nil
+}
+
+// missingLabelAncestors returns the ancestors of a nested label name that
+// aren't among names (label ID -> name), outermost first: for
+// "Clients/Acme/Invoices" with only "Clients" existing, ["Clients/Acme"].
+// Names are compared case-insensitively, as Gmail does.
+func missingLabelAncestors(name string, names map[string]string) []string {
+	parts, err := splitLabelPath(name)
+	if err != nil {
+		return nil
+	}
+	existing := make(map[string]bool, len(names))
+	for _, n := range names {
+		existing[strings.ToLower(n)] = true
+	}
+	var missing []string
+	for i := 1; i < len(parts); i++ {
+		ancestor := strings.Join(parts[:i], "/")
+		if !existing[strings.ToLower(ancestor)] {
+			missing = append(missing, ancestor)
+		}
+	}
+	return missing
+}
+
+// labelRename is one step of a label tree rename.
+type labelRename struct {
+	ID       string
+	From, To string
+}
+
+// planLabelRename returns the renames that move the label named oldName and
+// all labels nested under it to newName, parents before children. names maps
+// label IDs to names. It fails if oldName doesn't exist or a new name is
+// taken by a label outside the tree.
+func planLabelRename(names map[string]string, oldName, newName string) ([]labelRename, error) {
+	if _, err := splitLabelPath(newName); err != nil {
+		return nil, err
+	}
+	oldKey, newKey := strings.ToLower(oldName), strings.ToLower(newName)
+	if oldKey == newKey {
+		return nil, fmt.Errorf("new_name is the same as name")
+	}
+	if strings.HasPrefix(newKey, oldKey+"/") {
+		return nil, fmt.Errorf("cannot move label %q under itself", oldName)
+	}
+
+	var plan []labelRename
+	inTree := make(map[string]bool)
+	for id, name := range names {
+		key := strings.ToLower(name)
+		if key != oldKey && !strings.HasPrefix(key, oldKey+"/") {
+			continue
+		}
+		plan = append(plan, labelRename{ID: id, From: name, To: newName + name[len(oldName):]})
+		inTree[key] = true
+	}
+	found := false
+	for _, r := range plan {
+		if strings.ToLower(r.From) == oldKey {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("label %q not found (use list_labels to see available labels)", oldName)
+	}
+	for _, name := range names {
+		if key := strings.ToLower(name); inTree[key] {
+			continue
+		}
+		for _, r := range plan {
+			if strings.EqualFold(name, r.To) {
+				return nil, fmt.Errorf("cannot rename %q to %q: a label with that name already exists", r.From, r.To)
+			}
+		}
+	}
+
+	// Parents first, so every renamed label's new parent already exists.
+	sort.Slice(plan, func(i, j int) bool {
+		di, dj := strings.Count(plan[i].From, "/"), strings.Count(plan[j].From, "/")
+		if di != dj {
+			return di < dj
+		}
+		return strings.ToLower(plan[i].From) < strings.ToLower(plan[j].From)
+	})
+	return plan, nil
+}
+
+// renameRollbackNote describes a partially applied rename for the error of
+// rename_label_tree: what was renamed, how to revert it, and what wasn't.
+func renameRollbackNote(done, pending []labelRename) string {
+	var sb strings.Builder
+	if len(done) > 0 {
+		sb.WriteString("\nAlready renamed (revert with update_label, children first):")
+		for i := len(done) - 1; i >= 0; i-- {
+			fmt.Fprintf(&sb, "\n  - label_id=%s name=%q (now %q)", done[i].ID, done[i].From, done[i].To)
+		}
+	}
+	if len(pending) > 0 {
+		sb.WriteString("\nNot renamed:")
+		for _, r := range pending {
+			fmt.Fprintf(&sb, "\n  - %s (Label ID: %s)", r.From, r.ID)
+		}
+	}
+	return sb.String()
+}
+
+// formatLabelTree formats labels for list_labels with tree set: system
+// labels first, then user labels nested under their parents. Path segments
+// without a label of their own are marked as such.
+func formatLabelTree(labels []*gmailapi.Label) string {
+	var sb strings.Builder
+	var user []*gmailapi.Label
+	for _, label := range labels {
+		if label.Type == "system" {
+			fmt.Fprintf(&sb, "  - %s (Label ID: %s, type: system)\n", label.Name, label.Id)
+			continue
+		}
+		user = append(user, label)
+	}
+	sort.Slice(user, func(i, j int) bool {
+		return labelPathLess(user[i].Name, user[j].Name)
+	})
+
+	byName := make(map[string]bool, len(user))
+	for _, label := range user {
+		byName[strings.ToLower(label.Name)] = true
+	}
+	shown := make(map[string]bool)
+	for _, label := range user {
+		parts := strings.Split(label.Name, "/")
+		for depth := range parts {
+			path := strings.ToLower(strings.Join(parts[:depth+1], "/"))
+			if shown[path] {
+				continue
+			}
+			shown[path] = true
+			indent := strings.Repeat("  ", depth+1)
+			if depth == len(parts)-1 {
+				fmt.Fprintf(&sb, "%s- %s (Label ID: %s, type: user)\n", indent, parts[depth], label.Id)
+			} else if !byName[path] {
+				fmt.Fprintf(&sb, "%s- %s (no label)\n", indent, parts[depth])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// labelPathLess orders nested label names segment by segment, ignoring
+// case, so that children sort right after their parent.
+func labelPathLess(a, b string) bool {
+	pa, pb := strings.Split(strings.ToLower(a), "/"), strings.Split(strings.ToLower(b), "/")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+// labelNames returns a map of label ID to label name for the mailbox of
+// user ("me" for the account's own).
+// Callers should fetch it once per tool call and reuse it across messages.
+func labelNames(ctx context.Context, svc *gmailapi.Service, user string) (map[string]string, error) {
+	resp, err := svc.Users.Labels.List(user).Fields("labels(id,name)").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(resp.Labels))
+	for _, l := range resp.Labels {
+		names[l.Id] = l.Name
+	}
+	return names, nil
+}
+
+// labelResolver maps label references, which may be label names or IDs, to
+// label IDs on an account, optionally creating user labels that don't exist
+// yet. Callers should create one per tool call and reuse it for every
+// reference.
+type labelResolver struct {
+	svc         *gmailapi.Service
+	create      bool
+	createParam string              // input that enables create, named in errors
+	names       map[string]string   // label ID -> name
+	byName      map[string][]string // lowercased label name -> IDs
+	created     []string
+}
+
+func newLabelResolver(ctx context.Context, svc *gmailapi.Service, create bool, createParam string) (*labelResolver, error) {
+	names, err := labelNames(ctx, svc, "me")
+	if err != nil {
+		return nil, err
+	}
+	r := &labelResolver{
+		svc:         svc,
+		create:      create,
+		createParam: createParam,
+		names:       names,
+		byName:      make(map[string][]string, len(names)),
+	}
+	for id, name := range names {
+		key := strings.ToLower(name)
+		r.byName[key] = append(r.byName[key], id)
+	}
+	for _, ids := range r.byName {
+		slices.Sort(ids)
+	}
+	return r, nil
+}
+
+// resolve returns the label IDs for refs. A ref that is a label ID is used
+// as-is; otherwise it is matched against label names case-insensitively, as
+// Gmail does. Names matching more than one label are rejected.
+func (r *labelResolver) resolve(ctx context.Context, refs []string) ([]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		id, err := r.lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			ids[i] = id
+			continue
+		}
+		label, err := r.svc.Users.Labels.Create("me", &gmailapi.Label{Name: ref}).Context(ctx).Do()
+		if err != nil {
+			return nil, gerrors.Wrapf(err, "creating label %q", ref)
+		}
+		r.names[label.Id] = label.Name
+		r.byName[strings.ToLower(ref)] = []string{label.Id}
+		r.created = append(r.created, ref)
+		ids[i] = label.Id
+	}
+	return ids, nil
+}
+
+// check reports the error resolve would return for refs without creating
+// any label, so a batch of references can be validated before the first
+// label is created.
+func (r *labelResolver) check(refs []string) error {
+	for _, ref := range refs {
+		if _, err := r.lookup(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the ID of the label ref names, or "" when there is no
+// such label and the resolver may create it.
+func (r *labelResolver) lookup(ref string) (string, error) {
+	if _, ok := r.names[ref]; ok {
+		return ref, nil
+	}
+	switch matches := r.byName[strings.ToLower(ref)]; len(matches) {
+	case 0:
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("label name %q is ambiguous; use one of these label IDs instead: %s", ref, r.describe(matches))
+	}
+	if !r.create {
+		return "", fmt.Errorf("label %q not found (use list_labels to see available labels, or set %s to create it)", ref, r.createParam)
+	}
+	return "", nil
+}
+
+// known reports whether ref is the ID or name of an existing label.
+func (r *labelResolver) known(ref string) bool {
+	if _, ok := r.names[ref]; ok {
+		return true
+	}
+	return len(r.byName[strings.ToLower(ref)]) > 0
+}
+
+// describe lists labels as "Name (ID)", or just the ID for system labels
+// whose name is their ID.
+func (r *labelResolver) describe(ids []string) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		name := r.names[id]
+		if name == "" || name == id {
+			parts[i] = id
+		} else {
+			parts[i] = fmt.Sprintf("%s (%s)", name, id)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// note summarizes the labels a tool applied for its confirmation output.
+func (r *labelResolver) note(add, remove []string) string {
+	var sb strings.Builder
+	if len(add) > 0 {
+		fmt.Fprintf(&sb, "\nAdded labels: %s", r.describe(add))
+	}
+	if len(remove) > 0 {
+		fmt.Fprintf(&sb, "\nRemoved labels: %s", r.describe(remove))
+	}
+	if len(r.created) > 0 {
+		fmt.Fprintf(&sb, "\nCreated labels: %s", strings.Join(r.created, ", "))
+	}
+	return sb.String()
+}
+
+// labelShortcuts are the boolean inputs of modify_messages and
+// modify_thread for the common label changes.
+type labelShortcuts struct {
+	Archive  bool `json:"archive,omitempty" jsonschema:"Archive: remove the INBOX label (default: false)"`
+	MarkRead bool `json:"mark_read,omitempty" jsonschema:"Mark as read: remove the UNREAD label (default: false)"`
+	Star     bool `json:"star,omitempty" jsonschema:"Star: add the STARRED label (default: false)"`
+}
+
+// set reports whether a shortcut is set.
+func (s labelShortcuts) set() bool {
+	return s.Archive || s.MarkRead || s.Star
+}
+
+// expandLabelShortcuts returns add and remove with the label changes of the
+// shortcuts in s appended, skipping labels already listed. A shortcut that
+// contradicts add or remove, like archive with INBOX in add, is an error.
+// Labels are compared case-insensitively, as the resolver matches them.
+func expandLabelShortcuts(s labelShortcuts, add, remove []string) ([]string, []string, error) {
+	contains := func(refs []string, label string) bool {
+		return slices.ContainsFunc(refs, func(ref string) bool { return strings.EqualFold(ref, label) })
+	}
+	expand := func(set bool, name, label string, into, against *[]string, againstParam string) error {
+		if !set {
+			return nil
+		}
+		if contains(*against, label) {
+			return fmt.Errorf("%s conflicts with %s in %s", name, label, againstParam)
+		}
+		if !contains(*into, label) {
+			*into = append(*into, label)
+		}
+		return nil
+	}
+	add, remove = slices.Clone(add), slices.Clone(remove)
+	if err := expand(s.Archive, "archive", "INBOX", &remove, &add, "add_labels"); err != nil {
+		return nil, nil, err
+	}
+	if err := expand(s.MarkRead, "mark_read", "UNREAD", &remove, &add, "add_labels"); err != nil {
+		return nil, nil, err
+	}
+	if err := expand(s.Star, "star", "STARRED", &add, &remove, "remove_labels"); err != nil {
+		return nil, nil, err
+	}
+	return add, remove, nil
+}
+
+// immutableLabels are the system labels Gmail sets itself and rejects in
+// label changes, with the reason given in errors.
+var immutableLabels = map[string]string{
+	"SENT":  "Gmail sets it on messages sent from the account",
+	"DRAFT": "it marks drafts, which are managed with the draft tools",
+	"CHAT":  "it marks Google Chat history",
+}
+
+// checkModifiableLabels rejects label changes that add or remove an
+// immutable system label, which the API refuses with an unhelpful
+// "Invalid label" error.
+func checkModifiableLabels(add, remove []string) error {
+	for _, refs := range [][]string{add, remove} {
+		for _, ref := range refs {
+			if reason, ok := immutableLabels[strings.ToUpper(ref)]; ok {
+				return fmt.Errorf("the %s label can't be added or removed: %s", strings.ToUpper(ref), reason)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package gmail
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Gmail delegation lets an account read, send and organize mail in another
+// user's mailbox. The Gmail API acts on a delegated mailbox when the
+// delegator's address is used as the userId instead of "me". When the
+// account isn't a delegate, Google answers 403 "Delegation denied", which
+// gerrors turns into a hint about granting access.
+
+// userID returns the Gmail API userId for the mailbox input: "me" for the
+// account's own mailbox, or the delegator's address.
+func userID(mailbox string) string {
+	if mailbox == "" {
+		return "me"
+	}
+	return mailbox
+}
+
+// checkMailbox validates the mailbox input, which must be a bare email
+// address.
+func checkMailbox(mailbox string) error {
+	if mailbox == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(mailbox)
+	if err != nil || addr.Address != mailbox || addr.Name != "" || !strings.Contains(addr.Address, "@") {
+		return fmt.Errorf("invalid mailbox %q: use the email address of the delegated mailbox, e.g. boss@example.com", mailbox)
+	}
+	return nil
+}
+
+// checkMailboxAccounts rejects a mailbox combined with several accounts:
+// delegate access belongs to one account, so fanning out is meaningless.
+func checkMailboxAccounts(mailbox string, accounts []string) error {
+	if mailbox != "" && len(accounts) > 1 {
+		return fmt.Errorf("mailbox can only be used with a single account, not account=\"all\"")
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package gmail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	gmailapi "google.golang.org/api/gmail/v1"
+)
+
+// Default and limit of export_mbox's max_messages.
+const (
+	defaultMboxMessages = 1000
+	maxMboxMessages     = 50000
+)
+
+// maxMboxFailuresShown is the number of failed message IDs export_mbox
+// lists.
+const maxMboxFailuresShown = 10
+
+// --- export_mbox ---
+
+type exportMboxInput struct {
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Query       string `json:"query" jsonschema:"Gmail search query selecting the messages to export (e.g. 'from:vendor before:2023/01/01')"`
+	SaveTo      string `json:"save_to" jsonschema:"Local mbox file to write (path relative to an allowed directory); an existing file is replaced when the export ends. Requires --allow-write-dir."`
+	MaxMessages int    `json:"max_messages,omitempty" jsonschema:"Stop after this many messages (default 1000, max 50000)"`
+}
+
+func registerExportMbox(srv *server.Server, mgr *auth.Manager) {
+	desc := `Export the Gmail messages matching a query to a local mbox file, for backups or e-discovery.
+
+Each message is fetched in its original RFC 2822 form and appended to the file as it arrives, so memory use doesn't grow with the export. The file uses the mboxrd format read by Thunderbird, mutt and most mail tools: every message starts with a "From " line, and body lines starting with "From " (after any ">") get an extra ">". Messages are written newest first, as Gmail lists them.
+
+The file is written under a temporary name and replaces an existing file at save_to when the export ends. The result reports the number of messages and bytes written and the time taken. An export stopped by the tool timeout or a cancelled call keeps the messages written so far and says so; raise --tool-timeout or export in smaller date ranges for the rest.` + srv.WriteDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "export_mbox",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input exportMboxInput) (*mcp.CallToolResult, any, error) {
+		if input.Query == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+		if input.SaveTo == "" {
+			return nil, nil, fmt.Errorf("save_to is required")
+		}
+		lfs := srv.LocalFS()
+		if lfs == nil {
+			return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
+		}
+		maxMessages := input.MaxMessages
+		if maxMessages <= 0 {
+			maxMessages = defaultMboxMessages
+		}
+		if maxMessages > maxMboxMessages {
+			maxMessages = maxMboxMessages
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		start := time.Now()
+		ids, truncated, err := listMessageIDs(ctx, svc, input.Query, maxMessages)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		f, dir, err := lfs.CreateFile(input.SaveTo)
+		if err != nil {
+			return nil, nil, fmt.Errorf("saving mbox: %w", err)
+		}
+		defer f.Close()
+		buf := bufio.NewWriter(f)
+		mw := newMboxWriter(buf)
+		failed, err := exportMessages(ctx, req, svc, ids, mw)
+		// A cancelled or timed-out export keeps what it has written.
+		stopped := ctx.Err()
+		if err == nil {
+			err = buf.Flush()
+		}
+		if err == nil {
+			err = f.Commit()
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("saving mbox to %s/%s (stopped after %d messages, %d bytes; nothing was saved): %w", dir, input.SaveTo, mw.Messages(), mw.Bytes(), err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatMboxExport(input.Query, dir+"/"+input.SaveTo, mw, time.Since(start), truncated, maxMessages, failed, stopped)},
+			},
+		}, nil, nil
+	}, server.Timeout(server.BulkToolTimeout))
+}
+
+// exportMessages fetches the messages ids one at a time and writes them to
+// mw, reporting progress every ProgressInterval messages. Messages that
+// can't be fetched are skipped and returned as "id (error)" entries. The
+// end of ctx stops the export without an error, leaving the messages
+// written so far; a write error stops it with the error.
+func exportMessages(ctx context.Context, req *mcp.CallToolRequest, svc *gmailapi.Service, ids []string, mw *mboxWriter) (failed []string, err error) {
+	for i, id := range ids {
+		if ctx.Err() != nil {
+			return failed, nil
+		}
+		msg, raw, err := fetchRawMessage(ctx, svc, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return failed, nil
+			}
+			failed = append(failed, fmt.Sprintf("%s (%v)", id, err))
+			continue
+		}
+		if err := mw.WriteMessage(mboxSender(raw), time.UnixMilli(msg.InternalDate), raw); err != nil {
+			return failed, err
+		}
+		if done := i + 1; done%server.ProgressInterval == 0 || done == len(ids) {
+			server.Progress(ctx, req, done, len(ids), fmt.Sprintf("Exported %d of %d messages", done, len(ids)))
+		}
+	}
+	return failed, nil
+}
+
+// fetchRawMessage fetches message id in raw format and decodes it.
+func fetchRawMessage(ctx context.Context, svc *gmailapi.Service, id string) (*gmailapi.Message, []byte, error) {
+	msg, err := svc.Users.Messages.Get("me", id).Format("raw").Fields("id,internalDate,raw").Context(ctx).Do()
+	if err != nil {
+		return nil, nil, gerrors.Wrap(err, "getting message")
+	}
+	raw, err := base64.URLEncoding.DecodeString(msg.Raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding message: %w", err)
+	}
+	return msg, raw, nil
+}
+
+// formatMboxExport formats the export_mbox result.
+// stopped is the error that ended the export early, if any.
+func formatMboxExport(query, path string, mw *mboxWriter, elapsed time.Duration, truncated bool, maxMessages int, failed []string, stopped error) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Exported %d messages to mbox.\n\nQuery: %s\nSaved to: %s\nSize: %d bytes\nElapsed: %s\n",
+		mw.Messages(), query, path, mw.Bytes(), elapsed.Round(time.Millisecond))
+	if stopped != nil {
+		fmt.Fprintf(&sb, "\nStopped early (%v): the file has only the first %d messages. Export the rest with a narrower query, or raise --tool-timeout.\n", stopped, mw.Messages())
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\nStopped at max_messages (%d); more messages match. Raise max_messages (up to %d) or narrow the query and export the rest to another file.\n", maxMessages, maxMboxMessages)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&sb, "\n%d messages could not be fetched and are missing from the file:\n", len(failed))
+		for i, f := range failed {
+			if i == maxMboxFailuresShown {
+				fmt.Fprintf(&sb, "  ... and %d more\n", len(failed)-i)
+				break
+			}
+			fmt.Fprintf(&sb, "  - %s\n", f)
+		}
+	}
+	return sb.String()
+}
+
+// mboxWriter writes messages to an mbox file in the mboxrd format: each
+// message is preceded by a "From sender date" separator line and followed
+// by a blank line, and lines matching ^>*From get one more ">" so readers
+// can't mistake them for separators and the quoting can be undone.
+// Messages are written with LF line endings.
+type mboxWriter struct {
+	w        io.Writer
+	bytes    int64
+	messages int
+}
+
+// newMboxWriter returns an mboxWriter appending to w.
+func newMboxWriter(w io.Writer) *mboxWriter {
+	return &mboxWriter{w: w}
+}
+
+// mboxDateLayout is the asctime date format of mbox separator lines.
+const mboxDateLayout = "Mon Jan _2 15:04:05 2006"
+
+// WriteMessage appends the RFC 2822 message raw, received from sender at
+// date. An empty sender is written as MAILER-DAEMON.
+func (m *mboxWriter) WriteMessage(sender string, date time.Time, raw []byte) error {
+	if sender == "" {
+		sender = "MAILER-DAEMON"
+	}
+	if err := m.write([]byte("From " + sender + " " + date.UTC().Format(mboxDateLayout) + "\n")); err != nil {
+		return err
+	}
+	for len(raw) > 0 {
+		line := raw
+		if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+			line, raw = raw[:i], raw[i+1:]
+		} else {
+			raw = nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if isFromLine(line) {
+			if err := m.write([]byte(">")); err != nil {
+				return err
+			}
+		}
+		if err := m.write(line); err != nil {
+			return err
+		}
+		if err := m.write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+	if err := m.write([]byte("\n")); err != nil {
+		return err
+	}
+	m.messages++
+	return nil
+}
+
+// Messages returns the number of messages written.
+func (m *mboxWriter) Messages() int { return m.messages }
+
+// Bytes returns the number of bytes written.
+func (m *mboxWriter) Bytes() int64 { return m.bytes }
+
+func (m *mboxWriter) write(p []byte) error {
+	n, err := m.w.Write(p)
+	m.bytes += int64(n)
+	return err
+}
+
+// isFromLine reports whether line matches ^>*From , the lines mboxrd quotes.
+func isFromLine(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From "))
+}
+
+// mboxSender returns the envelope sender for the separator line of raw:
+// its Return-Path, or else the address of its From header, or "" if
+// neither can be parsed.
+func mboxSender(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	if rp := strings.Trim(strings.TrimSpace(msg.Header.Get("Return-Path")), "<>"); rp != "" && !strings.ContainsAny(rp, " \t") {
+		return rp
+	}
+	if addr, err := mail.ParseAddress(msg.Header.Get("From")); err == nil && addr.Address != "" {
+		return addr.Address
+	}
+	return ""
+}
@@ -3,10 +3,15 @@ package gmail
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -14,24 +19,90 @@ import (
 // --- search_messages ---
 
 type searchInput struct {
-	Account    string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
-	Query      string `json:"query" jsonschema:"Gmail search query (same syntax as Gmail search bar)"`
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Mailbox    string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	Query      string `json:"query,omitempty" jsonschema:"Gmail search query (same syntax as Gmail search bar)"`
+	After      string `json:"after,omitempty" jsonschema:"Only messages received at or after this time: RFC3339 timestamp (e.g. '2024-06-01T09:00:00+02:00') or date 'YYYY-MM-DD' (midnight UTC)"`
+	Before     string `json:"before,omitempty" jsonschema:"Only messages received before this time: RFC3339 timestamp or date 'YYYY-MM-DD' (midnight UTC)"`
 	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 10, max 500)"`
+	Format     string `json:"format,omitempty" jsonschema:"Output format: text (default), json (compact JSON array), or csv (header line and one row per message)"`
+	queryFilters
+}
+
+// parseSearchTime parses an RFC3339 timestamp or a YYYY-MM-DD date, which is
+// taken as midnight UTC.
+func parseSearchTime(field, value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s: invalid time %q (use an RFC3339 timestamp like 2024-06-01T09:00:00+02:00 or a date like 2024-06-01)", field, value)
+}
+
+// dateRangeQuery appends after:/before: terms for the given bounds to query.
+// Gmail interprets after:YYYY/MM/DD in the account's timezone, so the bounds
+// are sent as epoch seconds, which Gmail treats as exact instants.
+func dateRangeQuery(query, after, before string) (string, error) {
+	var afterTime, beforeTime time.Time
+	var err error
+	terms := []string{}
+	if q := strings.TrimSpace(query); q != "" {
+		terms = append(terms, q)
+	}
+	if after != "" {
+		if afterTime, err = parseSearchTime("after", after); err != nil {
+			return "", err
+		}
+		terms = append(terms, fmt.Sprintf("after:%d", afterTime.Unix()))
+	}
+	if before != "" {
+		if beforeTime, err = parseSearchTime("before", before); err != nil {
+			return "", err
+		}
+		terms = append(terms, fmt.Sprintf("before:%d", beforeTime.Unix()))
+	}
+	if after != "" && before != "" && !afterTime.Before(beforeTime) {
+		return "", fmt.Errorf("after (%s) must be earlier than before (%s)", after, before)
+	}
+	return strings.Join(terms, " "), nil
 }
 
 func registerSearch(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "search_messages",
-		Description: "Search Gmail messages using Gmail query syntax. Set account to 'all' to search across all accounts. Returns message IDs and snippets. Use read to get full message content.",
+		Description: "Search Gmail messages using Gmail query syntax. Set account to 'all' to search across all accounts. Returns message IDs and snippets. Use read to get full message content.\n\nPrefer after and before over after:/before: in the query for date ranges: they take RFC3339 timestamps or YYYY-MM-DD dates, are validated, and are sent to Gmail as exact instants instead of dates in the account's timezone.\n\nSet format to json or csv for machine-readable output.\n\n" + queryFiltersHelp,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input searchInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		query, err := buildQuery(input.Query, input.queryFilters)
+		if err != nil {
+			return nil, nil, err
+		}
+		if query, err = dateRangeQuery(query, input.After, input.Before); err != nil {
+			return nil, nil, err
+		}
+		table, err := server.NewTable(input.Format, messageColumns)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkMailboxAccounts(input.Mailbox, accounts); err != nil {
+			return nil, nil, err
+		}
+
 		maxResults := input.MaxResults
 		if maxResults <= 0 {
 			maxResults = 10
@@ -40,87 +111,364 @@ func registerSearch(srv *server.Server, mgr *auth.Manager) {
 			maxResults = 500
 		}
 
-		var sb strings.Builder
+		out := srv.NewOutputBuilder()
 		multiAccount := len(accounts) > 1
+		if query != strings.TrimSpace(input.Query) {
+			fmt.Fprintf(out, "Query: %s\n\n", query)
+		}
 
-		for _, account := range accounts {
+		fetched := 0
+		for n, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
 					continue
 				}
 				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 			}
 
-			resp, err := svc.Users.Messages.List("me").Q(input.Query).MaxResults(maxResults).Do()
+			resp, err := svc.Users.Messages.List(userID(input.Mailbox)).Q(query).MaxResults(maxResults).Context(ctx).Do()
 			if err != nil {
+				if multiAccount && table != nil {
+					table.AddError("account %s: searching: %v", account, err)
+					continue
+				}
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError searching: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError searching: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("searching messages: %w", err)
+				return nil, nil, gerrors.Wrap(err, "searching messages")
 			}
 
-			if multiAccount {
-				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
-			}
-
-			if len(resp.Messages) == 0 {
-				sb.WriteString("No messages found.\n\n")
-				continue
+			if table == nil {
+				if multiAccount {
+					fmt.Fprintf(out, "=== Account: %s ===\n", account)
+				}
+				if len(resp.Messages) == 0 {
+					out.WriteString("No messages found.\n\n")
+					continue
+				}
+				fmt.Fprintf(out, "Found %d messages (estimated total: %d):\n\n", len(resp.Messages), resp.ResultSizeEstimate)
 			}
 
-			fmt.Fprintf(&sb, "Found %d messages (estimated total: %d):\n\n", len(resp.Messages), resp.ResultSizeEstimate)
+			// Fetch the label map once per account so label IDs can be shown
+			// by name without a lookup per message. On failure, IDs are shown.
+			labels, _ := labelNames(ctx, svc, userID(input.Mailbox))
 
-			for _, msg := range resp.Messages {
-				detail, err := svc.Users.Messages.Get("me", msg.Id).Format("metadata").MetadataHeaders("From", "Subject", "Date").Do()
-				if err != nil {
-					fmt.Fprintf(&sb, "- Message ID: %s (error fetching details: %v)\n", msg.Id, err)
+			ids := make([]string, len(resp.Messages))
+			for i, msg := range resp.Messages {
+				ids[i] = msg.Id
+			}
+			details := fetchDetails(ctx, ids, func(id string) (*gmailapi.Message, error) {
+				return svc.Users.Messages.Get(userID(input.Mailbox), id).
+					Format("metadata").
+					MetadataHeaders("From", "Subject", "Date").
+					Fields(searchResultFields).Context(ctx).
+					Do()
+			}, func(done int) {
+				reportFetchProgress(ctx, req, accounts, n, fetched+done, done, len(ids), "messages")
+			})
+			fetched += len(ids)
+
+			for i, msg := range resp.Messages {
+				detail, err := details[i].Value, details[i].Err
+				if table != nil {
+					if err != nil {
+						table.AddError("message %s (account %s): fetching details: %v", msg.Id, account, err)
+					} else {
+						table.Add(messageRow{Account: account, Msg: detail, Labels: labels})
+					}
 					continue
 				}
-				headers := make(map[string]string)
-				if detail.Payload != nil {
-					for _, h := range detail.Payload.Headers {
-						headers[h.Name] = h.Value
+				if err != nil {
+					if !out.AddItem(fmt.Sprintf("- Message ID: %s (error fetching details: %v)\n", msg.Id, err)) {
+						break
 					}
+					continue
+				}
+				if !out.AddItem(formatSearchResult(detail, account, labels) + "\n") {
+					break
 				}
-				fmt.Fprintf(&sb, "- Message ID: %s\n  Account: %s\n  From: %s\n  Subject: %s\n  Date: %s\n  Snippet: %s\n\n",
-					msg.Id, account, headers["From"], headers["Subject"], headers["Date"], detail.Snippet)
 			}
 		}
 
+		if table != nil {
+			return table.Result(), nil, nil
+		}
+
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: sb.String()},
+				&mcp.TextContent{Text: out.String()},
 			},
 		}, nil, nil
 	})
 }
 
+// reportFetchProgress reports progress of a per-item fetch loop in a
+// possibly multi-account tool. fetched is the number of items processed
+// across all accounts so far and is the progress value; the total is only
+// known for a single account. n indexes the current account.
+func reportFetchProgress(ctx context.Context, req *mcp.CallToolRequest, accounts []string, n, fetched, done, count int, noun string) {
+	if len(accounts) == 1 {
+		server.Progress(ctx, req, fetched, count, fmt.Sprintf("Fetched %d of %d %s", done, count, noun))
+		return
+	}
+	server.Progress(ctx, req, fetched, 0, fmt.Sprintf("Account %s (%d of %d): fetched %d of %d %s", accounts[n], n+1, len(accounts), done, count, noun))
+}
+
+// detailWorkers is the number of detail fetches list tools keep in flight
+// per account.
+const detailWorkers = 8
+
+// detailResult is the outcome of fetching the details of one list item.
+type detailResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// fetchDetails calls fetch for each of ids with up to detailWorkers calls
+// in flight, returning the results in the order of ids. A failed fetch only
+// sets the Err of its result, so one bad item doesn't fail the listing.
+// Once ctx is done, remaining items fail with its error. progress, if not
+// nil, is called with the number of completed fetches at each
+// server.ProgressInterval and at the end, one call at a time.
+func fetchDetails[T any](ctx context.Context, ids []string, fetch func(id string) (T, error), progress func(done int)) []detailResult[T] {
+	results := make([]detailResult[T], len(ids))
+	sem := make(chan struct{}, detailWorkers)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for i, id := range ids {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			v, err := fetch(id)
+			results[i] = detailResult[T]{Value: v, Err: err}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if progress != nil && (done%server.ProgressInterval == 0 || done == len(ids)) {
+				progress(done)
+			}
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// searchResultFields is the Fields mask for the per-message metadata fetch
+// in search_messages. payload.mimeType is used to detect attachments.
+const searchResultFields = "id,threadId,labelIds,snippet,sizeEstimate,payload(mimeType,headers)"
+
+// formatSearchResult formats a metadata-format message as a search result
+// entry. labels maps label IDs to display names; unknown IDs are shown as-is.
+func formatSearchResult(msg *gmailapi.Message, account string, labels map[string]string) string {
+	headers := searchResultHeaders(msg)
+	unread := "no"
+	if slices.Contains(msg.LabelIds, "UNREAD") {
+		unread = "yes"
+	}
+	names := searchResultLabels(msg, labels)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- Message ID: %s\n  Account: %s\n  From: %s\n  Subject: %s\n  Date: %s\n",
+		msg.Id, account, headers["From"], headers["Subject"], headers["Date"])
+	if len(names) > 0 {
+		fmt.Fprintf(&sb, "  Labels: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "  Unread: %s\n", unread)
+	if msg.SizeEstimate > 0 {
+		fmt.Fprintf(&sb, "  Size: %d bytes\n", msg.SizeEstimate)
+	}
+	if hasAttachments(msg) {
+		sb.WriteString("  Has attachments: yes\n")
+	}
+	fmt.Fprintf(&sb, "  Snippet: %s\n", msg.Snippet)
+	return sb.String()
+}
+
+// searchResultHeaders returns the headers of a metadata-format message.
+func searchResultHeaders(msg *gmailapi.Message) map[string]string {
+	headers := make(map[string]string)
+	if msg.Payload != nil {
+		for _, h := range msg.Payload.Headers {
+			headers[h.Name] = h.Value
+		}
+	}
+	return headers
+}
+
+// searchResultLabels returns the display names of a message's labels.
+// labels maps label IDs to names; unknown IDs are returned as-is.
+func searchResultLabels(msg *gmailapi.Message, labels map[string]string) []string {
+	names := make([]string, 0, len(msg.LabelIds))
+	for _, id := range msg.LabelIds {
+		if name, ok := labels[id]; ok && name != "" {
+			names = append(names, name)
+		} else {
+			names = append(names, id)
+		}
+	}
+	return names
+}
+
+// hasAttachments reports whether a metadata-format message is likely to
+// have attachments, judging by its top-level MIME type.
+func hasAttachments(msg *gmailapi.Message) bool {
+	return msg.Payload != nil && msg.Payload.MimeType == "multipart/mixed"
+}
+
+// messageRow is a search_messages result in json or csv format.
+type messageRow struct {
+	Account string
+	Msg     *gmailapi.Message
+	Labels  map[string]string // label ID -> name for the account
+}
+
+// messageColumns are the json and csv columns of search_messages.
+var messageColumns = []server.Column[messageRow]{
+	{Name: "account", Value: func(r messageRow) any { return r.Account }},
+	{Name: "message_id", Value: func(r messageRow) any { return r.Msg.Id }},
+	{Name: "thread_id", Value: func(r messageRow) any { return r.Msg.ThreadId }},
+	{Name: "from", Value: func(r messageRow) any { return searchResultHeaders(r.Msg)["From"] }},
+	{Name: "subject", Value: func(r messageRow) any { return searchResultHeaders(r.Msg)["Subject"] }},
+	{Name: "date", Value: func(r messageRow) any { return searchResultHeaders(r.Msg)["Date"] }},
+	{Name: "labels", Value: func(r messageRow) any { return searchResultLabels(r.Msg, r.Labels) }},
+	{Name: "unread", Value: func(r messageRow) any { return slices.Contains(r.Msg.LabelIds, "UNREAD") }},
+	{Name: "size", Value: func(r messageRow) any { return r.Msg.SizeEstimate }},
+	{Name: "has_attachments", Value: func(r messageRow) any { return hasAttachments(r.Msg) }},
+	{Name: "snippet", Value: func(r messageRow) any { return r.Msg.Snippet }},
+}
+
 // --- read_message ---
 
 type readInput struct {
-	Account   string `json:"account" jsonschema:"Account name"`
-	MessageID string `json:"message_id" jsonschema:"Gmail message ID (from search results)"`
+	Account     string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox     string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	MessageID   string `json:"message_id" jsonschema:"Gmail message ID (from search results)"`
+	HeadersOnly bool   `json:"headers_only,omitempty" jsonschema:"Only return the headers, skipping the body and attachment list (much smaller fetch)"`
+	AllHeaders  bool   `json:"include_all_headers,omitempty" jsonschema:"Print every header (Received, Return-Path, Authentication-Results, DKIM-Signature, ...) in message order instead of just From, To, Cc, Bcc, Subject, Date and Reply-To"`
+}
+
+// readHeaders are the headers shown by read_message.
+var readHeaders = []string{"From", "To", "Cc", "Bcc", "Subject", "Date", "Reply-To"}
+
+// readHeadersFields is the Fields mask for read_message with headers_only.
+const readHeadersFields = "threadId,payload(headers)"
+
+// getMessageForRead fetches a message for read_message. With headersOnly
+// only the metadata format is requested, instead of the full MIME tree with
+// every body part, restricted to readHeaders unless allHeaders is set.
+func getMessageForRead(ctx context.Context, svc *gmailapi.Service, user, messageID string, headersOnly, allHeaders bool) (*gmailapi.Message, error) {
+	call := svc.Users.Messages.Get(user, messageID)
+	if headersOnly {
+		call = call.Format("metadata")
+		if !allHeaders {
+			call = call.MetadataHeaders(readHeaders...)
+		}
+		return call.Fields(readHeadersFields).Context(ctx).Do()
+	}
+	return call.Format("full").Context(ctx).Do()
+}
+
+// writeHeaders writes the readHeaders of headers, or all of them with
+// their folded values unfolded, one per line.
+func writeHeaders(w io.Writer, headers []*gmailapi.MessagePartHeader, all bool) {
+	for _, h := range headers {
+		if all {
+			fmt.Fprintf(w, "%s: %s\n", h.Name, unfoldHeader(h.Value))
+		} else if slices.Contains(readHeaders, h.Name) {
+			fmt.Fprintf(w, "%s: %s\n", h.Name, h.Value)
+		}
+	}
+}
+
+// unfoldHeader joins the lines of a folded header value (RFC 5322 section
+// 2.2.3), as long Received and DKIM-Signature values come, into one.
+func unfoldHeader(v string) string {
+	if !strings.ContainsAny(v, "\r\n") {
+		return v
+	}
+	lines := strings.FieldsFunc(v, func(r rune) bool { return r == '\r' || r == '\n' })
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, " ")
+}
+
+// messageProbe returns a probe for cross-account hints that reports
+// whether an account's mailbox has messageID.
+func messageProbe(mgr *auth.Manager, messageID string) server.AccountProbe {
+	return func(ctx context.Context, account string) (bool, error) {
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return false, err
+		}
+		return messageExists(ctx, svc, messageID)
+	}
+}
+
+// messageExists reports whether the mailbox of svc's account has
+// messageID, fetching only its ID.
+func messageExists(ctx context.Context, svc *gmailapi.Service, messageID string) (bool, error) {
+	_, err := svc.Users.Messages.Get("me", messageID).Format("minimal").Fields("id").Context(ctx).Do()
+	switch {
+	case err == nil:
+		return true, nil
+	case isNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
 }
 
 func registerRead(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "read_message",
-		Description: "Read the full content of a Gmail message by ID. Returns headers, body text, and attachment list. Use get_attachment to download attachments.",
+		Description: "Read the full content of a Gmail message by ID. Returns headers, body text, and attachment list. Use get_attachment to download attachments. Set include_all_headers to see every header, e.g. Received and Authentication-Results when tracing where a message came from; check_authentication summarizes its SPF, DKIM and DMARC results. Reading does not mark the message as read; use modify_messages with remove_labels=[\"UNREAD\"] for that.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input readInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		msg, err := svc.Users.Messages.Get("me", input.MessageID).Format("full").Do()
+		msg, err := getMessageForRead(ctx, svc, userID(input.Mailbox), input.MessageID, input.HeadersOnly, input.AllHeaders)
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting message: %w", err)
+			err = gerrors.Wrap(err, "getting message")
+			// Message IDs of delegated mailboxes aren't in the accounts' own.
+			if input.Mailbox == "" {
+				err = srv.CrossAccountHint(ctx, mgr, input.Account, "message "+input.MessageID, err, messageProbe(mgr, input.MessageID))
+			}
+			return nil, nil, err
 		}
 
 		var sb strings.Builder
@@ -128,12 +476,14 @@ func registerRead(srv *server.Server, mgr *auth.Manager) {
 		// Write headers.
 		fmt.Fprintf(&sb, "Thread ID: %s\n", msg.ThreadId)
 		if msg.Payload != nil {
-			for _, h := range msg.Payload.Headers {
-				switch h.Name {
-				case "From", "To", "Cc", "Bcc", "Subject", "Date", "Reply-To":
-					fmt.Fprintf(&sb, "%s: %s\n", h.Name, h.Value)
-				}
-			}
+			writeHeaders(&sb, msg.Payload.Headers, input.AllHeaders)
+		}
+		if input.HeadersOnly {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: sb.String()},
+				},
+			}, nil, nil
 		}
 		sb.WriteString("\n")
 
@@ -146,14 +496,10 @@ func registerRead(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// List attachments.
-		attachments := listAttachments(msg.Payload)
-		if len(attachments) > 0 {
-			sb.WriteString("\n\nAttachments:\n")
-			for _, a := range attachments {
-				fmt.Fprintf(&sb, "  - %s (MIME: %s, Size: %d bytes, Attachment ID: %s)\n",
-					a.filename, a.mimeType, a.size, a.attachmentID)
-			}
-			sb.WriteString("\nUse get_attachment with the message ID and attachment ID to download.")
+		if list := formatAttachmentList(listAttachments(msg.Payload)); list != "" {
+			sb.WriteString("\n\n")
+			sb.WriteString(list)
+			sb.WriteString("\nUse get_attachment with the message ID and attachment ID (or the Content-ID of an inline part) to download.")
 		}
 
 		return &mcp.CallToolResult{
@@ -167,26 +513,35 @@ func registerRead(srv *server.Server, mgr *auth.Manager) {
 // --- send_message ---
 
 type sendInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
 	composeInput
 	ReplyToMessageID string `json:"reply_to_message_id,omitempty" jsonschema:"Message ID to reply to (sets In-Reply-To and References headers, keeps thread)"`
+	Force            bool   `json:"force,omitempty" jsonschema:"Send even if an identical message (same To, subject and body) was sent recently and the server would refuse it as a duplicate (default: false)"`
 }
 
-func registerSend(srv *server.Server, mgr *auth.Manager) {
+func registerSend(srv *server.Server, mgr *auth.Manager, o *options) {
 	desc := `Send an email via Gmail. Supports To, CC, BCC, and replying to existing messages.
 
 Attachments can be provided:
 - Inline (base64-encoded content in the attachments field)
 - From Google Drive (by file ID — content is fetched server-side)
+- From http(s) URLs (content is fetched server-side; requires --allow-attachment-url-prefix to be configured)
 - From local files (requires --allow-read-dir to be configured)` + srv.ReadDirsDescription()
 
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "send_message",
 		Description: desc,
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input sendInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
 		// Resolve local attachments from allowed directories.
 		if len(input.LocalAttachments) > 0 {
 			lfs := srv.LocalFS()
@@ -202,13 +557,20 @@ Attachments can be provided:
 		if err := resolveDriveAttachments(ctx, mgr, &input.composeInput); err != nil {
 			return nil, nil, err
 		}
+		if err := resolveURLAttachments(ctx, o.urls, &input.composeInput); err != nil {
+			return nil, nil, err
+		}
+		group, err := resolveToGroup(ctx, mgr, input.Account, &input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		result, err := buildMessage(svc, input.composeInput, input.ReplyToMessageID)
+		result, err := buildMessage(ctx, svc, userID(input.Mailbox), input.composeInput, input.ReplyToMessageID, o.compose)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -218,14 +580,19 @@ Attachments can be provided:
 			ThreadId: result.ThreadID,
 		}
 
-		sent, err := svc.Users.Messages.Send("me", msg).Do()
+		release, err := o.guard.admit(messageDigest(input.To, input.Subject, input.Body), input.Force)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent, err := svc.Users.Messages.Send(userID(input.Mailbox), msg).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("sending message: %w", err)
+			release()
+			return nil, nil, gerrors.Wrap(err, "sending message")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Message sent.\n\nMessage ID: %s\nThread ID: %s", sent.Id, sent.ThreadId)},
+				&mcp.TextContent{Text: fmt.Sprintf("Message sent.\n\nMessage ID: %s\nThread ID: %s", sent.Id, sent.ThreadId) + group.note() + result.note()},
 			},
 		}, nil, nil
 	})
@@ -234,10 +601,12 @@ Attachments can be provided:
 // --- modify_messages ---
 
 type modifyInput struct {
-	Account      string   `json:"account" jsonschema:"Account name"`
-	MessageIDs   []string `json:"message_ids" jsonschema:"Gmail message IDs to modify (one or more)"`
-	AddLabels    []string `json:"add_labels,omitempty" jsonschema:"Label IDs to add (e.g. 'STARRED', 'IMPORTANT', 'TRASH', or custom label IDs from list_labels)"`
-	RemoveLabels []string `json:"remove_labels,omitempty" jsonschema:"Label IDs to remove (e.g. 'UNREAD', 'INBOX', 'STARRED')"`
+	Account       string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	MessageIDs    []string `json:"message_ids" jsonschema:"Gmail message IDs to modify (one or more)"`
+	AddLabels     []string `json:"add_labels,omitempty" jsonschema:"Label names or IDs to add (e.g. 'STARRED', 'TRASH', or a custom label name like 'Projects/Work')"`
+	RemoveLabels  []string `json:"remove_labels,omitempty" jsonschema:"Label names or IDs to remove (e.g. 'UNREAD', 'INBOX', 'STARRED')"`
+	CreateMissing bool     `json:"create_missing,omitempty" jsonschema:"Create user labels that don't exist yet (default: unknown labels are an error)"`
+	labelShortcuts
 }
 
 // Common label operations as a reference:
@@ -248,31 +617,46 @@ type modifyInput struct {
 //   Star:        add STARRED
 //   Unstar:      remove STARRED
 
+// batchModifyLimit is the Messages.BatchModify limit on message IDs.
+const batchModifyLimit = 1000
+
 func registerModify(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "modify_messages",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 		Description: `Modify labels on one or more Gmail messages. Use this to archive, trash, star, or mark messages as read/unread.
 
-Accepts one or more message IDs in message_ids. Uses Gmail batch API for efficiency.
+Accepts one or more message IDs in message_ids. Uses Gmail batch API for efficiency, sending up to 1000 IDs per call.
 
 Common operations:
-  - Archive: remove_labels=["INBOX"]
+  - Archive: archive=true (same as remove_labels=["INBOX"])
+  - Mark read: mark_read=true (same as remove_labels=["UNREAD"])
+  - Star: star=true (same as add_labels=["STARRED"])
   - Trash: add_labels=["TRASH"]
-  - Mark read: remove_labels=["UNREAD"]
   - Mark unread: add_labels=["UNREAD"]
-  - Star: add_labels=["STARRED"]
   - Unstar: remove_labels=["STARRED"]
 
-Use list_labels to discover custom label IDs.`,
+Archiving a message leaves the other messages of its thread in the inbox, so Gmail keeps showing the conversation there; use modify_thread to archive whole conversations.
+
+Labels can be given by name (case-insensitive, e.g. "Projects/Work") or by ID. Set create_missing to create user labels that don't exist yet. SENT, DRAFT and CHAT are set by Gmail and can't be changed.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input modifyInput) (*mcp.CallToolResult, any, error) {
 		if len(input.MessageIDs) == 0 {
 			return nil, nil, fmt.Errorf("message_ids must contain at least one message ID")
 		}
-		if len(input.AddLabels) == 0 && len(input.RemoveLabels) == 0 {
-			return nil, nil, fmt.Errorf("at least one of add_labels or remove_labels must be specified")
+		if len(input.AddLabels) == 0 && len(input.RemoveLabels) == 0 && !input.labelShortcuts.set() {
+			return nil, nil, fmt.Errorf("at least one of add_labels, remove_labels, archive, mark_read, or star must be specified")
+		}
+		addRefs, removeRefs, err := expandLabelShortcuts(input.labelShortcuts, input.AddLabels, input.RemoveLabels)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkModifiableLabels(addRefs, removeRefs); err != nil {
+			return nil, nil, err
 		}
 
 		svc, err := newService(ctx, mgr, input.Account)
@@ -280,28 +664,86 @@ Use list_labels to discover custom label IDs.`,
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		batchReq := &gmailapi.BatchModifyMessagesRequest{
-			Ids:            input.MessageIDs,
-			AddLabelIds:    input.AddLabels,
-			RemoveLabelIds: input.RemoveLabels,
+		resolver, err := newLabelResolver(ctx, svc, input.CreateMissing, "create_missing")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing labels")
+		}
+		add, err := resolver.resolve(ctx, addRefs)
+		if err != nil {
+			return nil, nil, err
+		}
+		remove, err := resolver.resolve(ctx, removeRefs)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if err := svc.Users.Messages.BatchModify("me", batchReq).Do(); err != nil {
-			return nil, nil, fmt.Errorf("modifying messages: %w", err)
+		if err := batchModify(ctx, req, svc, input.MessageIDs, add, remove); err != nil {
+			return nil, nil, err
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Modified %d messages.", len(input.MessageIDs))},
+				&mcp.TextContent{Text: fmt.Sprintf("Modified %d messages.", len(input.MessageIDs)) + resolver.note(add, remove)},
 			},
 		}, nil, nil
 	})
 }
 
+// batchModify applies the label changes to ids in BatchModify calls of at
+// most batchModifyLimit IDs, reporting progress after each call.
+func batchModify(ctx context.Context, req *mcp.CallToolRequest, svc *gmailapi.Service, ids, add, remove []string) error {
+	total := len(ids)
+	for start := 0; start < total; start += batchModifyLimit {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("modifying messages: stopped after %d of %d: %w", start, total, err)
+		}
+		end := min(start+batchModifyLimit, total)
+		batchReq := &gmailapi.BatchModifyMessagesRequest{
+			Ids:            ids[start:end],
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}
+		if err := svc.Users.Messages.BatchModify("me", batchReq).Context(ctx).Do(); err != nil {
+			if start > 0 {
+				return fmt.Errorf("modifying messages: %d of %d were modified before the error: %w", start, total, err)
+			}
+			return gerrors.Wrap(err, "modifying messages")
+		}
+		server.Progress(ctx, req, end, total, fmt.Sprintf("Modified %d of %d messages", end, total))
+	}
+	return nil
+}
+
+// listMessageIDs returns the IDs of up to limit messages matching query,
+// following result pages. truncated reports whether more messages matched.
+func listMessageIDs(ctx context.Context, svc *gmailapi.Service, query string, limit int) (ids []string, truncated bool, err error) {
+	pageToken := ""
+	for {
+		call := svc.Users.Messages.List("me").Q(query).MaxResults(500).Fields("messages(id),nextPageToken").Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Do()
+		if err != nil {
+			return ids, false, gerrors.Wrap(err, "searching messages")
+		}
+		for _, m := range resp.Messages {
+			if len(ids) == limit {
+				return ids, true, nil
+			}
+			ids = append(ids, m.Id)
+		}
+		if resp.NextPageToken == "" {
+			return ids, false, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
 // --- delete_message ---
 
 type deleteMessageInput struct {
-	Account   string `json:"account" jsonschema:"Account name"`
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	MessageID string `json:"message_id" jsonschema:"Gmail message ID to permanently delete"`
 }
 
@@ -309,15 +751,20 @@ func registerDeleteMessage(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_message",
 		Description: "Permanently delete a Gmail message. This action bypasses the trash and is irreversible. The message cannot be recovered.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteMessageInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		if err := svc.Users.Messages.Delete("me", input.MessageID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting message: %w", err)
+		if err := svc.Users.Messages.Delete("me", input.MessageID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting message")
 		}
 
 		return &mcp.CallToolResult{
@@ -331,7 +778,7 @@ func registerDeleteMessage(srv *server.Server, mgr *auth.Manager) {
 // --- trash_message ---
 
 type trashMessageInput struct {
-	Account   string `json:"account" jsonschema:"Account name"`
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	MessageID string `json:"message_id" jsonschema:"Gmail message ID to move to trash"`
 }
 
@@ -339,16 +786,21 @@ func registerTrashMessage(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "trash_message",
 		Description: "Move a Gmail message to the trash. The message will be permanently deleted after 30 days. Use untrash_message to restore.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input trashMessageInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		msg, err := svc.Users.Messages.Trash("me", input.MessageID).Do()
+		msg, err := svc.Users.Messages.Trash("me", input.MessageID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("trashing message: %w", err)
+			return nil, nil, gerrors.Wrap(err, "trashing message")
 		}
 
 		return &mcp.CallToolResult{
@@ -362,7 +814,7 @@ func registerTrashMessage(srv *server.Server, mgr *auth.Manager) {
 // --- untrash_message ---
 
 type untrashMessageInput struct {
-	Account   string `json:"account" jsonschema:"Account name"`
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	MessageID string `json:"message_id" jsonschema:"Gmail message ID to restore from trash"`
 }
 
@@ -371,7 +823,10 @@ func registerUntrashMessage(srv *server.Server, mgr *auth.Manager) {
 		Name:        "untrash_message",
 		Description: "Restore a Gmail message from the trash back to the inbox.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input untrashMessageInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -379,9 +834,9 @@ func registerUntrashMessage(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		msg, err := svc.Users.Messages.Untrash("me", input.MessageID).Do()
+		msg, err := svc.Users.Messages.Untrash("me", input.MessageID).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("untrashing message: %w", err)
+			return nil, nil, gerrors.Wrap(err, "untrashing message")
 		}
 
 		return &mcp.CallToolResult{
@@ -395,7 +850,7 @@ func registerUntrashMessage(srv *server.Server, mgr *auth.Manager) {
 // --- batch_delete_messages ---
 
 type batchDeleteMessagesInput struct {
-	Account    string   `json:"account" jsonschema:"Account name"`
+	Account    string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	MessageIDs []string `json:"message_ids" jsonschema:"Gmail message IDs to permanently delete (irreversible)"`
 }
 
@@ -403,7 +858,12 @@ func registerBatchDeleteMessages(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "batch_delete_messages",
 		Description: "Permanently delete multiple Gmail messages in a single operation. This action bypasses the trash and is irreversible. The messages cannot be recovered.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input batchDeleteMessagesInput) (*mcp.CallToolResult, any, error) {
 		if len(input.MessageIDs) == 0 {
 			return nil, nil, fmt.Errorf("message_ids must contain at least one message ID")
@@ -418,8 +878,8 @@ func registerBatchDeleteMessages(srv *server.Server, mgr *auth.Manager) {
 			Ids: input.MessageIDs,
 		}
 
-		if err := svc.Users.Messages.BatchDelete("me", batchReq).Do(); err != nil {
-			return nil, nil, fmt.Errorf("batch deleting messages: %w", err)
+		if err := svc.Users.Messages.BatchDelete("me", batchReq).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "batch deleting messages")
 		}
 
 		return &mcp.CallToolResult{
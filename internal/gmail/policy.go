@@ -0,0 +1,190 @@
+package gmail
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Option configures the Gmail tools registered by RegisterTools.
+type Option func(*options)
+
+type options struct {
+	compose *ComposePolicy
+	guard   *sendGuard
+	urls    *URLAttachmentPolicy
+}
+
+// WithComposePolicy applies p to every message and draft the tools build.
+func WithComposePolicy(p *ComposePolicy) Option {
+	return func(o *options) { o.compose = p }
+}
+
+// WithSendGuard limits sends to maxPerHour per hour (0 for no limit) and
+// refuses a send identical to one made within duplicateWindow (0 disables
+// the check) unless the tool call sets force. The limits apply across all
+// accounts and tools of the server.
+func WithSendGuard(maxPerHour int, duplicateWindow time.Duration) Option {
+	return func(o *options) { o.guard = newSendGuard(maxPerHour, duplicateWindow) }
+}
+
+// WithURLAttachmentPolicy allows url_attachments to be fetched from the
+// URLs p allows. Without it, or with a nil p, url_attachments are refused.
+func WithURLAttachmentPolicy(p *URLAttachmentPolicy) Option {
+	return func(o *options) { o.urls = p }
+}
+
+// ComposePolicy holds additions the server makes to every outgoing message
+// and draft, such as an archive Bcc or headers marking mail as machine-sent.
+// Tool inputs cannot remove or override them.
+type ComposePolicy struct {
+	// AlwaysBcc are added to Bcc unless already a recipient.
+	AlwaysBcc []string
+	// Headers are written after the message's own headers, in order.
+	Headers []Header
+}
+
+// Header is a message header set by a ComposePolicy.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// reservedHeaders are set from tool inputs or by buildMessage itself, so a
+// policy may not set them.
+var reservedHeaders = []string{
+	"From", "Sender", "To", "Cc", "Bcc", "Reply-To", "Subject", "Date",
+	"Message-Id", "In-Reply-To", "References",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding", "Content-Disposition",
+}
+
+// NewComposePolicy builds a policy from the --always-bcc addresses and the
+// --extra-header values (name=value).
+func NewComposePolicy(alwaysBcc, headers []string) (*ComposePolicy, error) {
+	p := &ComposePolicy{}
+	for _, a := range alwaysBcc {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(a); err != nil {
+			return nil, fmt.Errorf("invalid --always-bcc address %q: %w", a, err)
+		}
+		p.AlwaysBcc = append(p.AlwaysBcc, a)
+	}
+	for _, h := range headers {
+		parsed, err := parseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		p.Headers = append(p.Headers, parsed)
+	}
+	return p, nil
+}
+
+// parseHeader parses an --extra-header value of the form name=value.
+func parseHeader(s string) (Header, error) {
+	name, value, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Header{}, fmt.Errorf("invalid --extra-header %q: want name=value", s)
+	}
+	for _, r := range name {
+		// RFC 5322 field names are printable ASCII other than colon.
+		if r <= ' ' || r > '~' || r == ':' {
+			return Header{}, fmt.Errorf("invalid --extra-header name %q: use printable ASCII without spaces or colons", name)
+		}
+	}
+	for _, reserved := range reservedHeaders {
+		if strings.EqualFold(name, reserved) {
+			return Header{}, fmt.Errorf("--extra-header cannot set %s; it is set from the tool input", reserved)
+		}
+	}
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "\r\n") {
+		return Header{}, fmt.Errorf("invalid --extra-header %q: value must be a single line", name)
+	}
+	return Header{Name: name, Value: value}, nil
+}
+
+// applyBcc merges the policy's Bcc addresses into input and returns the
+// audit lines describing them.
+func (p *ComposePolicy) applyBcc(input *composeInput) []string {
+	if p == nil || len(p.AlwaysBcc) == 0 {
+		return nil
+	}
+	var added []string
+	input.Bcc, added = mergeRecipients(input.Bcc, []string{input.To, input.Cc, input.Bcc}, p.AlwaysBcc)
+	var lines []string
+	for _, a := range p.AlwaysBcc {
+		if containsRecipient(added, a) {
+			lines = append(lines, "Bcc: "+a)
+		} else {
+			lines = append(lines, "Bcc: "+a+" (already a recipient)")
+		}
+	}
+	return lines
+}
+
+// containsRecipient reports whether list holds the address of r.
+func containsRecipient(list []string, r string) bool {
+	addr := recipientAddress(r)
+	for _, l := range list {
+		if recipientAddress(l) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHeaders writes the policy headers and returns their audit lines.
+func (p *ComposePolicy) writeHeaders(w *strings.Builder) []string {
+	if p == nil {
+		return nil
+	}
+	var lines []string
+	for _, h := range p.Headers {
+		writeFoldedHeader(w, h.Name, h.Value)
+		lines = append(lines, h.Name+": "+h.Value)
+	}
+	return lines
+}
+
+// maxHeaderLine is the RFC 5322 recommended line length, excluding CRLF.
+const maxHeaderLine = 78
+
+// writeFoldedHeader writes "name: value" encoding non-ASCII values as
+// RFC 2047 encoded-words and folding at spaces to keep lines within
+// maxHeaderLine where possible.
+func writeFoldedHeader(w *strings.Builder, name, value string) {
+	// The B encoder splits long values into several encoded-words
+	// separated by spaces, which gives the folding below places to break.
+	value = mime.BEncoding.Encode("UTF-8", value)
+
+	line := len(name) + 1
+	w.WriteString(name)
+	w.WriteString(":")
+	for i, word := range strings.Split(value, " ") {
+		// The first word may fold too, right after the colon, if that
+		// makes it fit.
+		if line+1+len(word) > maxHeaderLine && (i > 0 || 1+len(word) <= maxHeaderLine) {
+			w.WriteString("\r\n")
+			line = 0
+		}
+		w.WriteString(" ")
+		w.WriteString(word)
+		line += 1 + len(word)
+	}
+	w.WriteString("\r\n")
+}
+
+// singleLine replaces line breaks in a header value taken from tool input
+// with spaces, so the input cannot start headers of its own.
+func singleLine(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+}
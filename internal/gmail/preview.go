@@ -0,0 +1,296 @@
+package gmail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/bridge"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// --- preview_message ---
+
+type previewInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	composeInput
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty" jsonschema:"Message ID to reply to (sets In-Reply-To and References headers, keeps thread)"`
+}
+
+// previewAttachment describes an attachment of a previewed message.
+type previewAttachment struct {
+	Name     string
+	MIMEType string
+	// Size is in bytes; 0 for Google Workspace files and URL attachments,
+	// whose size is only known once they are exported or downloaded.
+	Size int64
+	// Source is where the content comes from, e.g. "inline" or
+	// "local reports/q4.pdf".
+	Source string
+}
+
+// attachmentMetadata looks up Drive and local attachments without reading
+// their content: driveFile returns Drive file metadata and statLocal the
+// file info of a path in an allowed directory. URL attachments are only
+// checked against urls, not downloaded.
+type attachmentMetadata struct {
+	driveFile func(da driveAttachment) (*bridge.GetDriveFileMetadataResult, error)
+	statLocal func(path string) (os.FileInfo, error)
+	urls      *URLAttachmentPolicy
+}
+
+func registerPreviewMessage(srv *server.Server, mgr *auth.Manager, o *options) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "preview_message",
+		Description: `Show exactly what send_message would send, without sending anything: the rendered headers (From, To, Cc, Bcc, Subject, reply headers and any headers added by the server's compose policy), the computed recipients, the attachments with their sizes, and the body. Takes the same input as send_message.
+
+Drive and local attachments are only looked up, not downloaded or read, so previews stay fast; URL attachments are only checked against the allowed prefixes, and their name and type may change once downloaded. Use it to confirm a message with the user before calling send_message with the same input.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input previewInput) (*mcp.CallToolResult, any, error) {
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+		meta := attachmentMetadata{
+			driveFile: func(da driveAttachment) (*bridge.GetDriveFileMetadataResult, error) {
+				return bridge.GetDriveFileMetadata(ctx, mgr, bridge.GetDriveFileMetadataParams{
+					DriveAccount: da.DriveAccount,
+					FileID:       da.FileID,
+				})
+			},
+			statLocal: func(path string) (os.FileInfo, error) {
+				lfs := srv.LocalFS()
+				if lfs == nil {
+					return nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
+				}
+				info, _, err := lfs.Stat(path)
+				return info, err
+			},
+			urls: o.urls,
+		}
+		attachments, err := meta.describe(input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
+		group, err := resolveToGroup(ctx, mgr, input.Account, &input.composeInput)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		msg, err := prepareMessage(ctx, svc, userID(input.Mailbox), input.composeInput, input.ReplyToMessageID, o.compose)
+		if err != nil {
+			return nil, nil, err
+		}
+		text, err := formatPreview(msg, attachments)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text + group.note()},
+			},
+		}, nil, nil
+	})
+}
+
+// describe lists the attachments of input: inline ones from their content,
+// Drive and local ones from their metadata only.
+func (m attachmentMetadata) describe(input composeInput) ([]previewAttachment, error) {
+	var out []previewAttachment
+	for i, att := range input.Attachments {
+		data, err := base64.StdEncoding.DecodeString(att.Content)
+		if err != nil {
+			return nil, fmt.Errorf("attachment[%d] (%s): invalid base64 content: %w", i, att.Name, err)
+		}
+		mimeType := att.MIMEType
+		if mimeType == "" {
+			mimeType = guessMIMEType(att.Name)
+		}
+		out = append(out, previewAttachment{Name: att.Name, MIMEType: mimeType, Size: int64(len(data)), Source: "inline"})
+	}
+	for i, da := range input.DriveAttachments {
+		if da.DriveAccount == "" {
+			return nil, fmt.Errorf("drive_attachments[%d]: drive_account is required", i)
+		}
+		if da.FileID == "" {
+			return nil, fmt.Errorf("drive_attachments[%d]: file_id is required", i)
+		}
+		file, err := m.driveFile(da)
+		if err != nil {
+			return nil, fmt.Errorf("drive_attachments[%d] (%s): %w", i, da.FileID, err)
+		}
+		out = append(out, previewAttachment{Name: file.FileName, MIMEType: file.MIMEType, Size: file.Size, Source: "Drive file " + da.FileID})
+	}
+	for i, la := range input.LocalAttachments {
+		if la.Path == "" {
+			return nil, fmt.Errorf("local_attachments[%d]: path is required", i)
+		}
+		info, err := m.statLocal(la.Path)
+		if err != nil {
+			return nil, fmt.Errorf("local_attachments[%d] (%s): %w", i, la.Path, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("local_attachments[%d] (%s): is a directory", i, la.Path)
+		}
+		name := filepath.Base(la.Path)
+		out = append(out, previewAttachment{Name: name, MIMEType: guessMIMEType(name), Size: info.Size(), Source: "local " + la.Path})
+	}
+	if len(input.URLAttachments) > 0 && m.urls == nil {
+		return nil, fmt.Errorf("url_attachments are not enabled (use --allow-attachment-url-prefix)")
+	}
+	for i, ua := range input.URLAttachments {
+		if ua.URL == "" {
+			return nil, fmt.Errorf("url_attachments[%d]: url is required", i)
+		}
+		u, err := m.urls.parse(ua.URL)
+		if err != nil {
+			return nil, fmt.Errorf("url_attachments[%d] (%s): %w", i, ua.URL, err)
+		}
+		name := ua.Name
+		if name == "" {
+			if name = path.Base(u.Path); name == "/" || name == "." {
+				name = "attachment"
+			}
+		}
+		mimeType := ua.MIMEType
+		if mimeType == "" {
+			mimeType = guessMIMEType(name)
+		}
+		out = append(out, previewAttachment{Name: name, MIMEType: mimeType, Source: "URL " + ua.URL})
+	}
+	return out, nil
+}
+
+// previewSkipHeaders are the MIME structure headers the preview leaves out.
+var previewSkipHeaders = map[string]bool{
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// formatPreview renders msg for preview_message from its encoded form, so
+// the headers shown are the ones that would be sent. attachments lists all
+// attachments, including those not yet part of msg.
+func formatPreview(msg *preparedMessage, attachments []previewAttachment) (string, error) {
+	encoded := msg.encode()
+	raw, err := base64.URLEncoding.DecodeString(encoded.Raw)
+	if err != nil {
+		return "", fmt.Errorf("decoding message: %w", err)
+	}
+	headers, err := headerLines(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("parsing message headers: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("NOT SENT — preview only. Call send_message with the same input to send it.\n\n")
+	dec := new(mime.WordDecoder)
+	for _, h := range headers {
+		if previewSkipHeaders[h[0]] {
+			continue
+		}
+		value, err := dec.DecodeHeader(h[1])
+		if err != nil {
+			value = h[1]
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", h[0], value)
+	}
+	if msg.threadID != "" {
+		fmt.Fprintf(&sb, "Thread ID: %s\n", msg.threadID)
+	}
+
+	recipients := previewRecipients(msg.input)
+	fmt.Fprintf(&sb, "\nRecipients (%d):\n", len(recipients))
+	for _, r := range recipients {
+		fmt.Fprintf(&sb, "  - %s\n", r)
+	}
+
+	if len(attachments) > 0 {
+		var total int64
+		for _, a := range attachments {
+			total += a.Size
+		}
+		fmt.Fprintf(&sb, "\nAttachments (%d, %d bytes):\n", len(attachments), total)
+		for _, a := range attachments {
+			size := fmt.Sprintf("%d bytes", a.Size)
+			if a.Size == 0 && strings.HasPrefix(a.MIMEType, "application/vnd.google-apps.") {
+				size = "size known after export"
+			}
+			if strings.HasPrefix(a.Source, "URL ") {
+				size = "size known after download"
+			}
+			fmt.Fprintf(&sb, "  - %s (MIME: %s, Size: %s, from %s)\n", a.Name, a.MIMEType, size, a.Source)
+		}
+	}
+
+	sb.WriteString("\nBody:\n")
+	if msg.input.Body != "" {
+		sb.WriteString(msg.input.Body)
+	} else {
+		sb.WriteString("(empty)")
+	}
+	sb.WriteString("\n")
+	return sb.String() + encoded.note(), nil
+}
+
+// headerLines returns the header fields of a raw message in order, with
+// folded lines joined.
+func headerLines(raw string) ([][2]string, error) {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(raw)))
+	var out [][2]string
+	for {
+		line, err := r.ReadContinuedLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			return out, nil
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header line %q", line)
+		}
+		out = append(out, [2]string{textproto.CanonicalMIMEHeaderKey(name), strings.TrimSpace(value)})
+	}
+}
+
+// previewRecipients lists every address the message goes to, To, Cc and
+// Bcc, without duplicates.
+func previewRecipients(input composeInput) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, field := range []struct{ name, value string }{{"To", input.To}, {"Cc", input.Cc}, {"Bcc", input.Bcc}} {
+		for _, a := range splitAddressList(field.value) {
+			key := strings.ToLower(a.Address)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			display := a.Address
+			if a.Name != "" {
+				display = a.Name + " <" + a.Address + ">"
+			}
+			out = append(out, display+" ("+field.name+")")
+		}
+	}
+	return out
+}
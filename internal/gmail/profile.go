@@ -7,61 +7,109 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
+	gmailapi "google.golang.org/api/gmail/v1"
 )
 
 // --- gmail_get_profile ---
 
 type getProfileInput struct {
-	Account string `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+}
+
+// accountProfile is one account's entry in get_profile's structured output.
+type accountProfile struct {
+	Account       string `json:"account"`
+	EmailAddress  string `json:"email_address,omitempty"`
+	MessagesTotal int64  `json:"messages_total"`
+	ThreadsTotal  int64  `json:"threads_total"`
+	HistoryID     uint64 `json:"history_id,omitempty"`
+	Error         string `json:"error,omitempty" jsonschema:"Set when the profile couldn't be fetched for this account"`
+}
+
+// getProfileOutput is the structured output of get_profile, so clients can
+// read the totals without parsing text.
+type getProfileOutput struct {
+	Profiles []accountProfile `json:"profiles"`
+}
+
+func newAccountProfile(account string, p *gmailapi.Profile) accountProfile {
+	return accountProfile{
+		Account:       account,
+		EmailAddress:  p.EmailAddress,
+		MessagesTotal: p.MessagesTotal,
+		ThreadsTotal:  p.ThreadsTotal,
+		HistoryID:     p.HistoryId,
+	}
+}
+
+// formatProfiles renders profiles as text, with a section per account when
+// there is more than one.
+func formatProfiles(profiles []accountProfile) string {
+	var sb strings.Builder
+	multiAccount := len(profiles) > 1
+	for _, p := range profiles {
+		if multiAccount {
+			fmt.Fprintf(&sb, "=== Account: %s ===\n", p.Account)
+		}
+		if p.Error != "" {
+			fmt.Fprintf(&sb, "Error: %s\n\n", p.Error)
+			continue
+		}
+		fmt.Fprintf(&sb, "Email: %s\nTotal messages: %d\nTotal threads: %d\nHistory ID: %d\n\n",
+			p.EmailAddress, p.MessagesTotal, p.ThreadsTotal, p.HistoryID)
+	}
+	return sb.String()
 }
 
 func registerGetProfile(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "get_profile",
-		Description: "Get the authenticated user's Gmail profile. Returns email address, total messages, total threads, and current history ID.",
+		Description: "Get the authenticated user's Gmail profile. Returns email address, total messages, total threads, and current history ID. Set account to 'all' for every account (one API call each; an account that fails is reported without failing the others). Also returned as structured content.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
-	}, func(ctx context.Context, req *mcp.CallToolRequest, input getProfileInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input getProfileInput) (*mcp.CallToolResult, getProfileOutput, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
-			return nil, nil, err
+			return nil, getProfileOutput{}, err
 		}
 
-		var sb strings.Builder
+		out := getProfileOutput{Profiles: []accountProfile{}}
 		multiAccount := len(accounts) > 1
 
 		for _, account := range accounts {
+			if ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					out.Profiles = append(out.Profiles, accountProfile{Account: account, Error: err.Error()})
 					continue
 				}
-				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+				return nil, getProfileOutput{}, fmt.Errorf("creating Gmail service: %w", err)
 			}
 
-			profile, err := svc.Users.GetProfile("me").Do()
+			profile, err := svc.Users.GetProfile("me").Context(ctx).Do()
 			if err != nil {
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError getting profile: %v\n\n", account, err)
+					out.Profiles = append(out.Profiles, accountProfile{Account: account, Error: fmt.Sprintf("getting profile: %v", err)})
 					continue
 				}
-				return nil, nil, fmt.Errorf("getting profile: %w", err)
-			}
-
-			if multiAccount {
-				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+				return nil, getProfileOutput{}, gerrors.Wrap(err, "getting profile")
 			}
-			fmt.Fprintf(&sb, "Email: %s\nTotal messages: %d\nTotal threads: %d\nHistory ID: %d\n\n",
-				profile.EmailAddress, profile.MessagesTotal, profile.ThreadsTotal, profile.HistoryId)
+			out.Profiles = append(out.Profiles, newAccountProfile(account, profile))
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: sb.String()},
+				&mcp.TextContent{Text: formatProfiles(out.Profiles)},
 			},
-		}, nil, nil
+		}, out, nil
 	})
 }
@@ -0,0 +1,32 @@
+package gmail
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// registerPrompts registers the Gmail prompt templates.
+func registerPrompts(srv *server.Server) {
+	server.AddPrompt(srv, server.PromptTemplate{
+		Name:        "triage_inbox",
+		Title:       "Triage inbox",
+		Description: "Sort recent inbox mail into what needs a reply, what needs action and what can be archived, then clean up after confirmation.",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "account", Description: "Account name (default: the default account)"},
+			{Name: "days", Description: "How many days back to look (default 7)"},
+		},
+		Defaults: map[string]string{"days": "7"},
+		Tools:    []string{"search_messages", "read_message"},
+		Template: `Triage the inbox{{if .account}} of account "{{.account}}"{{end}} for the last {{.days}} days.
+
+1. Call search_messages with query "in:inbox newer_than:{{.days}}d"{{if .account}} and account "{{.account}}"{{end}} and max_results 50.
+2. Call read_message for messages whose subject and snippet don't make clear what they need.
+3. Group the messages into: needs a reply, needs action, FYI, and can be archived. For each message give the sender, the subject and a one-line summary, and for replies and actions what is asked and by when.
+{{- if tool "modify_messages"}}
+4. Propose which messages to archive and which to mark as read, and wait for my confirmation. Then call modify_messages with their message IDs: remove_labels ["INBOX"] to archive, remove_labels ["UNREAD"] to mark as read. Don't change anything before I confirm.
+{{- else}}
+4. This server can't modify messages. List the message IDs you would archive or mark as read so I can do it myself.
+{{- end}}
+`,
+	})
+}
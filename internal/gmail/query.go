@@ -0,0 +1,95 @@
+package gmail
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// queryFilters are structured search inputs compiled into Gmail query
+// terms by buildQuery, so callers don't have to get Gmail's operator and
+// quoting syntax right. All set fields must match (they AND together).
+type queryFilters struct {
+	From          string `json:"from,omitempty" jsonschema:"Only messages from this sender (address or name)"`
+	To            string `json:"to,omitempty" jsonschema:"Only messages sent to this recipient (address or name)"`
+	Subject       string `json:"subject,omitempty" jsonschema:"Only messages whose subject contains these words"`
+	Label         string `json:"label,omitempty" jsonschema:"Only messages with this label name (e.g. 'Work/Projects')"`
+	HasAttachment bool   `json:"has_attachment,omitempty" jsonschema:"Only messages with attachments"`
+	IsUnread      bool   `json:"is_unread,omitempty" jsonschema:"Only unread messages"`
+	InFolder      string `json:"in_folder,omitempty" jsonschema:"Only messages in this folder: inbox, sent, drafts, spam, trash, snoozed, chats or anywhere (includes spam and trash)"`
+	LargerThan    string `json:"larger_than,omitempty" jsonschema:"Only messages larger than this size in bytes, or with a K or M suffix (e.g. '5M')"`
+	SmallerThan   string `json:"smaller_than,omitempty" jsonschema:"Only messages smaller than this size in bytes, or with a K or M suffix (e.g. '100K')"`
+}
+
+// queryFiltersHelp documents queryFilters in tool descriptions.
+const queryFiltersHelp = "Prefer the structured filters (from, to, subject, label, has_attachment, is_unread, in_folder, larger_than, smaller_than) over writing operators into query: values are quoted for you. All filters AND together and with query. The compiled query is shown at the top of the results."
+
+// querySizePattern matches the sizes Gmail accepts for larger: and smaller:.
+var querySizePattern = regexp.MustCompile(`^[0-9]+[KkMm]?$`)
+
+// queryFolders are the values accepted for in_folder.
+var queryFolders = []string{"inbox", "sent", "drafts", "spam", "trash", "snoozed", "chats", "anywhere"}
+
+// buildQuery compiles f into Gmail query terms and joins them to the
+// free-form query. A query containing OR is parenthesized so it doesn't
+// combine with the filter terms.
+func buildQuery(query string, f queryFilters) (string, error) {
+	var terms []string
+	add := func(op, value string) {
+		if v := strings.TrimSpace(value); v != "" {
+			terms = append(terms, op+":"+quoteQueryValue(v))
+		}
+	}
+	add("from", f.From)
+	add("to", f.To)
+	add("subject", f.Subject)
+	add("label", f.Label)
+	if f.HasAttachment {
+		terms = append(terms, "has:attachment")
+	}
+	if f.IsUnread {
+		terms = append(terms, "is:unread")
+	}
+	if folder := strings.ToLower(strings.TrimSpace(f.InFolder)); folder != "" {
+		if !slices.Contains(queryFolders, folder) {
+			return "", fmt.Errorf("in_folder: unknown folder %q (use one of %s)", f.InFolder, strings.Join(queryFolders, ", "))
+		}
+		terms = append(terms, "in:"+folder)
+	}
+	for _, size := range []struct{ name, op, value string }{
+		{"larger_than", "larger", f.LargerThan},
+		{"smaller_than", "smaller", f.SmallerThan},
+	} {
+		v := strings.TrimSpace(size.value)
+		if v == "" {
+			continue
+		}
+		if !querySizePattern.MatchString(v) {
+			return "", fmt.Errorf("%s: invalid size %q (use bytes, or a number with K or M, e.g. 5M)", size.name, size.value)
+		}
+		terms = append(terms, size.op+":"+strings.ToUpper(v))
+	}
+
+	q := strings.TrimSpace(query)
+	if len(terms) == 0 {
+		return q, nil
+	}
+	if q != "" {
+		if strings.Contains(q, " OR ") && !(strings.HasPrefix(q, "(") && strings.HasSuffix(q, ")")) {
+			q = "(" + q + ")"
+		}
+		terms = append([]string{q}, terms...)
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// quoteQueryValue quotes a value that contains spaces or characters Gmail
+// treats as syntax. Gmail has no escape for double quotes inside a quoted
+// value, so they are dropped.
+func quoteQueryValue(v string) string {
+	if !strings.ContainsAny(v, " \t\"(){}:-") {
+		return v
+	}
+	return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+}
@@ -0,0 +1,315 @@
+package gmail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// labelRules is a rules document for apply_rules: Gmail searches and the
+// label changes to apply to the messages they match.
+//
+//	{"rules": [{"name": "Receipts", "query": "receipt OR invoice", "add_labels": ["Receipts"], "remove_labels": ["INBOX"]}]}
+type labelRules struct {
+	Rules []labelRule `json:"rules"`
+}
+
+// labelRule is one rule of a rules document. Labels are given by name or
+// ID, as in modify_messages.
+type labelRule struct {
+	Name         string   `json:"name,omitempty"`
+	Query        string   `json:"query"`
+	AddLabels    []string `json:"add_labels,omitempty"`
+	RemoveLabels []string `json:"remove_labels,omitempty"`
+}
+
+// title returns the rule's name, or its query when it has none.
+func (r labelRule) title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Query
+}
+
+// parseLabelRules decodes and validates a rules document. Unknown fields
+// are rejected so misspelled keys don't silently turn into no-op rules.
+func parseLabelRules(data []byte) ([]labelRule, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var doc labelRules
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing rules: %w", err)
+	}
+	if len(doc.Rules) == 0 {
+		return nil, fmt.Errorf("rules document contains no rules")
+	}
+	for i, r := range doc.Rules {
+		if strings.TrimSpace(r.Query) == "" {
+			return nil, fmt.Errorf("rule %d: query is required", i+1)
+		}
+		if len(r.AddLabels) == 0 && len(r.RemoveLabels) == 0 {
+			return nil, fmt.Errorf("rule %d (%s): at least one of add_labels or remove_labels must be specified", i+1, r.title())
+		}
+	}
+	return doc.Rules, nil
+}
+
+// ruleOptions controls how applyLabelRules runs.
+type ruleOptions struct {
+	After       time.Time // only messages received after this time; zero for all mail
+	MaxMessages int       // messages modified per rule at most
+	DryRun      bool      // count matches without modifying or creating labels
+}
+
+// ruleResult is the outcome of one rule.
+type ruleResult struct {
+	Rule      labelRule
+	Add       []string // resolved label IDs
+	Remove    []string
+	Pending   []string // labels that would be created (dry run only)
+	Matched   int
+	Truncated bool // more than MaxMessages matched
+	Modified  int
+	Err       error
+}
+
+// resolveRuleLabels resolves the labels of every rule before any rule runs,
+// so a typo in one rule doesn't leave the mailbox half-relabeled. Every
+// label reference is checked before the first missing label is created,
+// so an invalid rule doesn't leave labels behind either. In a dry run with
+// create set, labels that don't exist are returned as pending instead of
+// being created.
+func resolveRuleLabels(ctx context.Context, resolver *labelResolver, rules []labelRule, dryRun bool) ([]ruleResult, error) {
+	for i, rule := range rules {
+		for _, refs := range [][]string{rule.AddLabels, rule.RemoveLabels} {
+			if err := resolver.check(refs); err != nil {
+				return nil, fmt.Errorf("rule %d (%s): %w", i+1, rule.title(), err)
+			}
+		}
+	}
+
+	results := make([]ruleResult, len(rules))
+	for i, rule := range rules {
+		res := ruleResult{Rule: rule}
+		for _, refs := range []struct {
+			in  []string
+			out *[]string
+		}{{rule.AddLabels, &res.Add}, {rule.RemoveLabels, &res.Remove}} {
+			existing := refs.in
+			if dryRun && resolver.create {
+				existing = nil
+				for _, ref := range refs.in {
+					if resolver.known(ref) {
+						existing = append(existing, ref)
+					} else {
+						res.Pending = append(res.Pending, ref)
+					}
+				}
+			}
+			ids, err := resolver.resolve(ctx, existing)
+			if err != nil {
+				err = fmt.Errorf("rule %d (%s): %w", i+1, rule.title(), err)
+				if len(resolver.created) > 0 {
+					err = fmt.Errorf("%w (labels already created: %s)", err, strings.Join(resolver.created, ", "))
+				}
+				return nil, err
+			}
+			*refs.out = ids
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// ruleQuery returns the search query of a rule limited to mail received
+// after the given time.
+func ruleQuery(query string, after time.Time) string {
+	if after.IsZero() {
+		return query
+	}
+	return fmt.Sprintf("(%s) after:%d", query, after.Unix())
+}
+
+// applyLabelRules runs each rule's search and applies its label changes to
+// the matches. Rules run in order and independently: a failing rule is
+// recorded and the next one still runs. The returned error is only set when
+// the rules couldn't be started, for example because a label doesn't exist.
+func applyLabelRules(ctx context.Context, req *mcp.CallToolRequest, resolver *labelResolver, rules []labelRule, opts ruleOptions) ([]ruleResult, error) {
+	results, err := resolveRuleLabels(ctx, resolver, rules, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		res := &results[i]
+		if err := ctx.Err(); err != nil {
+			res.Err = fmt.Errorf("not run: %w", err)
+			continue
+		}
+		ids, truncated, err := listMessageIDs(ctx, resolver.svc, ruleQuery(res.Rule.Query, opts.After), opts.MaxMessages)
+		res.Matched, res.Truncated = len(ids), truncated
+		if err != nil {
+			res.Err = err
+			continue
+		}
+		if opts.DryRun || len(ids) == 0 {
+			continue
+		}
+		if err := batchModify(ctx, req, resolver.svc, ids, res.Add, res.Remove); err != nil {
+			res.Err = err
+			continue
+		}
+		res.Modified = len(ids)
+	}
+	return results, nil
+}
+
+// formatRuleResults formats the per-rule report of apply_rules.
+func formatRuleResults(results []ruleResult, resolver *labelResolver, opts ruleOptions) string {
+	var sb strings.Builder
+	if opts.DryRun {
+		sb.WriteString("Dry run: no messages were modified.\n")
+	}
+	if opts.After.IsZero() {
+		sb.WriteString("Searched all mail.\n\n")
+	} else {
+		fmt.Fprintf(&sb, "Searched mail received after %s.\n\n", opts.After.Format(time.RFC3339))
+	}
+
+	var matched, modified, failed int
+	for i, r := range results {
+		matched += r.Matched
+		modified += r.Modified
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, r.Rule.title())
+		if r.Rule.Name != "" {
+			fmt.Fprintf(&sb, "   Query: %s\n", r.Rule.Query)
+		}
+		if len(r.Add) > 0 {
+			fmt.Fprintf(&sb, "   Add labels: %s\n", resolver.describe(r.Add))
+		}
+		if len(r.Remove) > 0 {
+			fmt.Fprintf(&sb, "   Remove labels: %s\n", resolver.describe(r.Remove))
+		}
+		if len(r.Pending) > 0 {
+			fmt.Fprintf(&sb, "   Labels to create: %s\n", strings.Join(r.Pending, ", "))
+		}
+		fmt.Fprintf(&sb, "   Matched: %d", r.Matched)
+		if r.Truncated {
+			fmt.Fprintf(&sb, " (limit reached; more messages match)")
+		}
+		sb.WriteString("\n")
+		if !opts.DryRun {
+			fmt.Fprintf(&sb, "   Modified: %d\n", r.Modified)
+		}
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(&sb, "   Error: %v\n", r.Err)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\n%d rules, %d messages matched", len(results), matched)
+	if !opts.DryRun {
+		fmt.Fprintf(&sb, ", %d modified", modified)
+	}
+	if failed > 0 {
+		fmt.Fprintf(&sb, ", %d rules failed", failed)
+	}
+	sb.WriteString(".\n")
+	if len(resolver.created) > 0 {
+		fmt.Fprintf(&sb, "Created labels: %s\n", strings.Join(resolver.created, ", "))
+	}
+	return sb.String()
+}
+
+// --- apply_rules ---
+
+type applyRulesInput struct {
+	Account       string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Rules         string `json:"rules,omitempty" jsonschema:"Rules JSON document, e.g. {\"rules\": [{\"name\": \"Receipts\", \"query\": \"receipt OR invoice\", \"add_labels\": [\"Receipts\"], \"remove_labels\": [\"INBOX\"]}]}. Set this or local_path."`
+	LocalPath     string `json:"local_path,omitempty" jsonschema:"Read the rules document from a local file (path relative to an allowed directory). Requires --allow-read-dir."`
+	Days          int    `json:"days,omitempty" jsonschema:"Only apply rules to mail received in the last N days (default 30; 0 for the default, -1 for all mail)"`
+	MaxMessages   int    `json:"max_messages,omitempty" jsonschema:"Maximum number of messages modified per rule (default 5000)"`
+	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"Only report how many messages each rule matches, without modifying messages or creating labels"`
+	CreateMissing bool   `json:"create_missing,omitempty" jsonschema:"Create user labels that don't exist yet (default: rules using them fail validation)"`
+}
+
+func registerApplyRules(srv *server.Server, mgr *auth.Manager) {
+	desc := `Apply a set of labeling rules to existing Gmail messages. Each rule is a Gmail search query with labels to add and/or remove; matching messages are relabeled in batches. Use this to apply rules retroactively, since Gmail filters only act on incoming mail.
+
+The rules document is JSON (YAML is not supported):
+  {"rules": [{"name": "Receipts", "query": "receipt OR invoice", "add_labels": ["Receipts"], "remove_labels": ["INBOX"]}]}
+
+Labels can be given by name (case-insensitive) or by ID. All rules are validated before any message is modified or label created. Rules run in order; a failing rule is reported and the others still run. Use dry_run to see how many messages each rule would change.` + srv.ReadDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "apply_rules",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input applyRulesInput) (*mcp.CallToolResult, any, error) {
+		if (input.Rules == "") == (input.LocalPath == "") {
+			return nil, nil, fmt.Errorf("exactly one of rules or local_path is required")
+		}
+
+		data := []byte(input.Rules)
+		if input.LocalPath != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
+			}
+			var err error
+			if data, _, err = lfs.ReadFile(input.LocalPath); err != nil {
+				return nil, nil, fmt.Errorf("reading rules: %w", err)
+			}
+		}
+
+		rules, err := parseLabelRules(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts := ruleOptions{MaxMessages: input.MaxMessages, DryRun: input.DryRun}
+		if opts.MaxMessages <= 0 {
+			opts.MaxMessages = 5000
+		}
+		switch {
+		case input.Days < 0:
+		case input.Days == 0:
+			opts.After = time.Now().AddDate(0, 0, -30)
+		default:
+			opts.After = time.Now().AddDate(0, 0, -input.Days)
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		resolver, err := newLabelResolver(ctx, svc, input.CreateMissing, "create_missing")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing labels")
+		}
+
+		results, err := applyLabelRules(ctx, req, resolver, rules, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatRuleResults(results, resolver, opts)},
+			},
+		}, nil, nil
+	})
+}
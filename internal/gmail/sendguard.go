@@ -0,0 +1,155 @@
+package gmail
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	gmailapi "google.golang.org/api/gmail/v1"
+)
+
+// DefaultDuplicateSendWindow is how long an identical send is refused by
+// default.
+const DefaultDuplicateSendWindow = 10 * time.Minute
+
+// sendGuardHistory is how many recent sends are remembered for duplicate
+// detection.
+const sendGuardHistory = 50
+
+// sendWindow is the period --max-sends-per-hour counts over.
+const sendWindow = time.Hour
+
+// sendGuard refuses sends that look like a runaway agent: more than a set
+// number per hour, or a repeat of a recent identical message. It is shared
+// by every tool that sends mail and keeps its state in memory only.
+type sendGuard struct {
+	maxPerHour      int
+	duplicateWindow time.Duration
+	now             func() time.Time
+
+	mu     sync.Mutex
+	sends  []time.Time  // admitted sends within sendWindow, oldest first
+	recent []sentDigest // the last sendGuardHistory sends, oldest first
+}
+
+// sentDigest is an admitted send remembered for duplicate detection.
+type sentDigest struct {
+	sum [sha256.Size]byte
+	at  time.Time
+}
+
+// newSendGuard returns a guard allowing maxPerHour sends per hour (0 for no
+// limit) that refuses identical sends within duplicateWindow (0 disables
+// the check). It returns nil, a guard that allows everything, when both
+// are off.
+func newSendGuard(maxPerHour int, duplicateWindow time.Duration) *sendGuard {
+	if maxPerHour <= 0 && duplicateWindow <= 0 {
+		return nil
+	}
+	return &sendGuard{
+		maxPerHour:      max(maxPerHour, 0),
+		duplicateWindow: max(duplicateWindow, 0),
+		now:             time.Now,
+	}
+}
+
+// checksDuplicates reports whether admit needs the message content.
+func (g *sendGuard) checksDuplicates() bool {
+	return g != nil && g.duplicateWindow > 0
+}
+
+// messageDigest hashes the parts of a message that make two sends
+// identical: the set of To addresses, the subject and the body.
+func messageDigest(to, subject, body string) [sha256.Size]byte {
+	var addrs []string
+	for _, r := range strings.Split(to, ",") {
+		if addr := recipientAddress(r); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+	// Gmail returns stored bodies with CRLF line endings.
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	return sha256.Sum256([]byte(strings.Join(addrs, ",") + "\x00" + strings.TrimSpace(subject) + "\x00" + body))
+}
+
+// admit checks a send of the message with the given digest and records it
+// if allowed. force skips the duplicate check but not the hourly limit.
+// The returned release undoes the record and must be called if the send
+// then fails.
+func (g *sendGuard) admit(sum [sha256.Size]byte, force bool) (release func(), err error) {
+	if g == nil {
+		return func() {}, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	cutoff := now.Add(-sendWindow)
+	g.sends = slices.DeleteFunc(g.sends, func(t time.Time) bool { return !t.After(cutoff) })
+	if g.maxPerHour > 0 && len(g.sends) >= g.maxPerHour {
+		next := g.sends[len(g.sends)-g.maxPerHour].Add(sendWindow)
+		return nil, fmt.Errorf("send refused: %d messages were sent in the last hour, the limit set by --max-sends-per-hour. The next send is allowed in %s (at %s)",
+			len(g.sends), next.Sub(now).Round(time.Second), next.Format(time.RFC3339))
+	}
+
+	if g.duplicateWindow > 0 && !force {
+		for i := len(g.recent) - 1; i >= 0; i-- {
+			d := g.recent[i]
+			if d.sum == sum && now.Sub(d.at) < g.duplicateWindow {
+				return nil, fmt.Errorf("send refused: an identical message (same To recipients, subject and body) was sent %s ago, within the %s duplicate window. If sending it again is intended, retry with force: true",
+					now.Sub(d.at).Round(time.Second), g.duplicateWindow)
+			}
+		}
+	}
+
+	g.sends = append(g.sends, now)
+	g.recent = append(g.recent, sentDigest{sum: sum, at: now})
+	if len(g.recent) > sendGuardHistory {
+		g.recent = slices.Delete(g.recent, 0, len(g.recent)-sendGuardHistory)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if i := slices.Index(g.sends, now); i >= 0 {
+				g.sends = slices.Delete(g.sends, i, i+1)
+			}
+			if i := slices.IndexFunc(g.recent, func(d sentDigest) bool { return d.sum == sum && d.at.Equal(now) }); i >= 0 {
+				g.recent = slices.Delete(g.recent, i, i+1)
+			}
+		})
+	}, nil
+}
+
+// draftDigest fetches a draft and returns its send digest, for send_draft.
+func draftDigest(ctx context.Context, svc *gmailapi.Service, user, draftID string) ([sha256.Size]byte, error) {
+	draft, err := svc.Users.Drafts.Get(user, draftID).Format("full").Context(ctx).Do()
+	if err != nil {
+		return [sha256.Size]byte{}, gerrors.Wrap(err, "getting draft")
+	}
+	var to, subject string
+	var payload *gmailapi.MessagePart
+	if draft.Message != nil {
+		payload = draft.Message.Payload
+	}
+	if payload != nil {
+		for _, h := range payload.Headers {
+			switch h.Name {
+			case "To":
+				to = h.Value
+			case "Subject":
+				subject = h.Value
+			}
+		}
+	}
+	return messageDigest(to, subject, extractBody(payload)), nil
+}
@@ -1,13 +1,16 @@
 package gmail
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -15,7 +18,7 @@ import (
 // --- get_vacation ---
 
 type getVacationInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerGetVacation(srv *server.Server, mgr *auth.Manager) {
@@ -23,7 +26,10 @@ func registerGetVacation(srv *server.Server, mgr *auth.Manager) {
 		Name:        "get_vacation",
 		Description: "Get the Gmail vacation/out-of-office auto-reply settings for an account.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input getVacationInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -31,42 +37,57 @@ func registerGetVacation(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		settings, err := svc.Users.Settings.GetVacation("me").Do()
+		settings, err := svc.Users.Settings.GetVacation("me").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting vacation settings: %w", err)
-		}
-
-		enabled := "disabled"
-		if settings.EnableAutoReply {
-			enabled = "enabled"
-		}
-
-		text := fmt.Sprintf("Auto-reply: %s\nSubject: %s\nBody:\n%s\nRestrict to contacts: %v\nRestrict to domain: %v",
-			enabled, settings.ResponseSubject, settings.ResponseBodyPlainText,
-			settings.RestrictToContacts, settings.RestrictToDomain)
-
-		if settings.StartTime > 0 {
-			text += fmt.Sprintf("\nStart: %s", time.UnixMilli(settings.StartTime).UTC().Format(time.RFC3339))
-		}
-		if settings.EndTime > 0 {
-			text += fmt.Sprintf("\nEnd: %s", time.UnixMilli(settings.EndTime).UTC().Format(time.RFC3339))
+			return nil, nil, gerrors.Wrap(err, "getting vacation settings")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: text},
+				&mcp.TextContent{Text: formatVacation(settings)},
 			},
 		}, nil, nil
 	})
 }
 
+// formatVacation formats vacation responder settings for display.
+func formatVacation(settings *gmailapi.VacationSettings) string {
+	enabled := "disabled"
+	if settings.EnableAutoReply {
+		enabled = "enabled"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Auto-reply: %s\n", enabled)
+	fmt.Fprintf(&sb, "Subject: %s\n", settings.ResponseSubject)
+	fmt.Fprintf(&sb, "Body:\n%s\n", settings.ResponseBodyPlainText)
+	if settings.ResponseBodyHtml != "" {
+		fmt.Fprintf(&sb, "HTML body:\n%s\n", settings.ResponseBodyHtml)
+	}
+	fmt.Fprintf(&sb, "Restrict to contacts: %v\nRestrict to domain: %v", settings.RestrictToContacts, settings.RestrictToDomain)
+
+	if settings.StartTime > 0 {
+		fmt.Fprintf(&sb, "\nStart: %s", time.UnixMilli(settings.StartTime).UTC().Format(time.RFC3339))
+	}
+	if settings.EndTime > 0 {
+		fmt.Fprintf(&sb, "\nEnd: %s", time.UnixMilli(settings.EndTime).UTC().Format(time.RFC3339))
+	}
+	if settings.StartTime == 0 && settings.EndTime == 0 {
+		sb.WriteString("\nSchedule: none (active whenever enabled)")
+	}
+	return sb.String()
+}
+
 // --- gmail_update_vacation ---
 
 type updateVacationInput struct {
-	Account            string `json:"account" jsonschema:"Account name"`
+	Account            string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	EnableAutoReply    *bool  `json:"enable_auto_reply,omitempty" jsonschema:"Enable or disable the auto-reply"`
 	ResponseSubject    string `json:"response_subject,omitempty" jsonschema:"Subject line for auto-reply (empty to keep current)"`
 	ResponseBody       string `json:"response_body,omitempty" jsonschema:"Plain text body for auto-reply (empty to keep current)"`
+	ResponseBodyHTML   string `json:"response_body_html,omitempty" jsonschema:"HTML body for auto-reply (empty to keep current). Clients that render HTML show this instead of the plain text body."`
+	ClearSubject       bool   `json:"clear_subject,omitempty" jsonschema:"Remove the auto-reply subject so Gmail uses its default"`
+	ClearBody          bool   `json:"clear_body,omitempty" jsonschema:"Remove both the plain text and HTML auto-reply bodies"`
 	StartTime          string `json:"start_time,omitempty" jsonschema:"Start date/time in RFC3339 format (e.g. 2026-03-01T00:00:00Z)"`
 	EndTime            string `json:"end_time,omitempty" jsonschema:"End date/time in RFC3339 format (e.g. 2026-03-15T00:00:00Z)"`
 	RestrictToContacts *bool  `json:"restrict_to_contacts,omitempty" jsonschema:"Only send auto-reply to contacts"`
@@ -76,9 +97,12 @@ type updateVacationInput struct {
 func registerUpdateVacation(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "update_vacation",
-		Description: "Update Gmail vacation/out-of-office auto-reply settings. Set enable_auto_reply to true/false to toggle. Provide response_subject and response_body for the auto-reply message.",
+		Description: "Update Gmail vacation/out-of-office auto-reply settings. Set enable_auto_reply to true/false to toggle. Provide response_subject and response_body (and optionally response_body_html) for the auto-reply message. Omitted fields keep their current values; use clear_subject or clear_body to remove them.",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input updateVacationInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -87,64 +111,98 @@ func registerUpdateVacation(srv *server.Server, mgr *auth.Manager) {
 		}
 
 		// Fetch current settings to merge with updates.
-		current, err := svc.Users.Settings.GetVacation("me").Do()
+		current, err := svc.Users.Settings.GetVacation("me").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting current vacation settings: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting current vacation settings")
 		}
 
-		if input.EnableAutoReply != nil {
-			current.EnableAutoReply = *input.EnableAutoReply
-		}
-		if input.ResponseSubject != "" {
-			current.ResponseSubject = input.ResponseSubject
-		}
-		if input.ResponseBody != "" {
-			current.ResponseBodyPlainText = input.ResponseBody
-		}
-		if input.RestrictToContacts != nil {
-			current.RestrictToContacts = *input.RestrictToContacts
-		}
-		if input.RestrictToDomain != nil {
-			current.RestrictToDomain = *input.RestrictToDomain
-		}
-		if input.StartTime != "" {
-			t, err := time.Parse(time.RFC3339, input.StartTime)
-			if err != nil {
-				return nil, nil, fmt.Errorf("parsing start_time: %w", err)
-			}
-			current.StartTime = t.UnixMilli()
-		}
-		if input.EndTime != "" {
-			t, err := time.Parse(time.RFC3339, input.EndTime)
-			if err != nil {
-				return nil, nil, fmt.Errorf("parsing end_time: %w", err)
-			}
-			current.EndTime = t.UnixMilli()
+		if err := mergeVacation(current, input); err != nil {
+			return nil, nil, err
 		}
 
-		updated, err := svc.Users.Settings.UpdateVacation("me", current).Do()
+		updated, err := svc.Users.Settings.UpdateVacation("me", current).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("updating vacation settings: %w", err)
-		}
-
-		enabled := "disabled"
-		if updated.EnableAutoReply {
-			enabled = "enabled"
+			return nil, nil, gerrors.Wrap(err, "updating vacation settings")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Vacation auto-reply updated.\n\nAuto-reply: %s\nSubject: %s",
-					enabled, updated.ResponseSubject)},
+				&mcp.TextContent{Text: "Vacation auto-reply updated.\n\n" + formatVacation(updated)},
 			},
 		}, nil, nil
 	})
 }
 
+// mergeVacation applies the fields set in input to current. Empty strings
+// keep the current value; clear_subject and clear_body remove one. The
+// schedule is only touched when start_time or end_time is given.
+func mergeVacation(current *gmailapi.VacationSettings, input updateVacationInput) error {
+	if input.ClearSubject && input.ResponseSubject != "" {
+		return fmt.Errorf("clear_subject cannot be combined with response_subject")
+	}
+	if input.ClearBody && (input.ResponseBody != "" || input.ResponseBodyHTML != "") {
+		return fmt.Errorf("clear_body cannot be combined with response_body or response_body_html")
+	}
+
+	var start, end time.Time
+	if input.StartTime != "" {
+		t, err := time.Parse(time.RFC3339, input.StartTime)
+		if err != nil {
+			return fmt.Errorf("parsing start_time: %w", err)
+		}
+		start = t
+	}
+	if input.EndTime != "" {
+		t, err := time.Parse(time.RFC3339, input.EndTime)
+		if err != nil {
+			return fmt.Errorf("parsing end_time: %w", err)
+		}
+		end = t
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return fmt.Errorf("start_time (%s) must be before end_time (%s)", input.StartTime, input.EndTime)
+	}
+
+	if input.EnableAutoReply != nil {
+		current.EnableAutoReply = *input.EnableAutoReply
+	}
+	if input.ResponseSubject != "" {
+		current.ResponseSubject = input.ResponseSubject
+	}
+	if input.ClearSubject {
+		current.ResponseSubject = ""
+		current.ForceSendFields = append(current.ForceSendFields, "ResponseSubject")
+	}
+	if input.ResponseBody != "" {
+		current.ResponseBodyPlainText = input.ResponseBody
+	}
+	if input.ResponseBodyHTML != "" {
+		current.ResponseBodyHtml = input.ResponseBodyHTML
+	}
+	if input.ClearBody {
+		current.ResponseBodyPlainText = ""
+		current.ResponseBodyHtml = ""
+		current.ForceSendFields = append(current.ForceSendFields, "ResponseBodyPlainText", "ResponseBodyHtml")
+	}
+	if input.RestrictToContacts != nil {
+		current.RestrictToContacts = *input.RestrictToContacts
+	}
+	if input.RestrictToDomain != nil {
+		current.RestrictToDomain = *input.RestrictToDomain
+	}
+	if !start.IsZero() {
+		current.StartTime = start.UnixMilli()
+	}
+	if !end.IsZero() {
+		current.EndTime = end.UnixMilli()
+	}
+	return nil
+}
+
 // --- list_filters ---
 
 type listFiltersInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerListFilters(srv *server.Server, mgr *auth.Manager) {
@@ -152,7 +210,10 @@ func registerListFilters(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_filters",
 		Description: "List all Gmail filters (inbox rules) for an account. Shows matching criteria and actions for each filter.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listFiltersInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -160,9 +221,9 @@ func registerListFilters(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		resp, err := svc.Users.Settings.Filters.List("me").Do()
+		resp, err := svc.Users.Settings.Filters.List("me").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing filters: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing filters")
 		}
 
 		var sb strings.Builder
@@ -225,17 +286,76 @@ func registerListFilters(srv *server.Server, mgr *auth.Manager) {
 
 // --- create_filter ---
 
+// filterSpec holds the criteria and actions of a filter. It is shared by
+// create_filter and the export_filters/import_filters document format.
+type filterSpec struct {
+	From           string   `json:"from,omitempty" jsonschema:"Match sender email or name"`
+	To             string   `json:"to,omitempty" jsonschema:"Match recipient email or name"`
+	Subject        string   `json:"subject,omitempty" jsonschema:"Match subject (case-insensitive)"`
+	Query          string   `json:"query,omitempty" jsonschema:"Match using Gmail search query syntax"`
+	NegatedQuery   string   `json:"negated_query,omitempty" jsonschema:"Exclude messages matching this query"`
+	HasAttachment  *bool    `json:"has_attachment,omitempty" jsonschema:"Match messages with attachments"`
+	Size           int64    `json:"size,omitempty" jsonschema:"Match messages larger or smaller than this many bytes (requires size_comparison)"`
+	SizeComparison string   `json:"size_comparison,omitempty" jsonschema:"How size is compared: 'larger' or 'smaller'"`
+	ExcludeChats   bool     `json:"exclude_chats,omitempty" jsonschema:"Don't match chat messages"`
+	AddLabels      []string `json:"add_labels,omitempty" jsonschema:"Label names or IDs to add to matching messages"`
+	RemoveLabels   []string `json:"remove_labels,omitempty" jsonschema:"Label names or IDs to remove from matching messages"`
+	Forward        string   `json:"forward,omitempty" jsonschema:"Email address to forward matching messages to"`
+}
+
+// validate checks that the spec has at least one criteria and one action,
+// and that size and size_comparison are set together.
+func (f filterSpec) validate() error {
+	hasCriteria := f.From != "" || f.To != "" || f.Subject != "" ||
+		f.Query != "" || f.NegatedQuery != "" || f.HasAttachment != nil || f.Size != 0
+	if !hasCriteria {
+		return fmt.Errorf("at least one criteria field is required (from, to, subject, query, negated_query, has_attachment, or size)")
+	}
+	switch {
+	case f.Size < 0:
+		return fmt.Errorf("size must be a positive number of bytes")
+	case f.Size > 0 && f.SizeComparison != "larger" && f.SizeComparison != "smaller":
+		return fmt.Errorf("size_comparison must be 'larger' or 'smaller' when size is set")
+	case f.Size == 0 && f.SizeComparison != "":
+		return fmt.Errorf("size_comparison requires size")
+	}
+	hasAction := len(f.AddLabels) > 0 || len(f.RemoveLabels) > 0 || f.Forward != ""
+	if !hasAction {
+		return fmt.Errorf("at least one action is required (add_labels, remove_labels, or forward)")
+	}
+	return nil
+}
+
+// toFilter converts the spec to an API filter. Label fields are used as-is,
+// so they must already be resolved to label IDs.
+func (f filterSpec) toFilter() *gmailapi.Filter {
+	filter := &gmailapi.Filter{
+		Criteria: &gmailapi.FilterCriteria{
+			From:           f.From,
+			To:             f.To,
+			Subject:        f.Subject,
+			Query:          f.Query,
+			NegatedQuery:   f.NegatedQuery,
+			Size:           f.Size,
+			SizeComparison: f.SizeComparison,
+			ExcludeChats:   f.ExcludeChats,
+		},
+		Action: &gmailapi.FilterAction{
+			AddLabelIds:    f.AddLabels,
+			RemoveLabelIds: f.RemoveLabels,
+			Forward:        f.Forward,
+		},
+	}
+	if f.HasAttachment != nil {
+		filter.Criteria.HasAttachment = *f.HasAttachment
+	}
+	return filter
+}
+
 type createFilterInput struct {
-	Account       string   `json:"account" jsonschema:"Account name"`
-	From          string   `json:"from,omitempty" jsonschema:"Match sender email or name"`
-	To            string   `json:"to,omitempty" jsonschema:"Match recipient email or name"`
-	Subject       string   `json:"subject,omitempty" jsonschema:"Match subject (case-insensitive)"`
-	Query         string   `json:"query,omitempty" jsonschema:"Match using Gmail search query syntax"`
-	NegatedQuery  string   `json:"negated_query,omitempty" jsonschema:"Exclude messages matching this query"`
-	HasAttachment *bool    `json:"has_attachment,omitempty" jsonschema:"Match messages with attachments"`
-	AddLabels     []string `json:"add_labels,omitempty" jsonschema:"Label IDs to add to matching messages"`
-	RemoveLabels  []string `json:"remove_labels,omitempty" jsonschema:"Label IDs to remove from matching messages"`
-	Forward       string   `json:"forward,omitempty" jsonschema:"Email address to forward matching messages to"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	filterSpec
+	CreateMissing bool `json:"create_missing,omitempty" jsonschema:"Create user labels that don't exist yet (default: unknown labels are an error)"`
 }
 
 func registerCreateFilter(srv *server.Server, mgr *auth.Manager) {
@@ -244,26 +364,20 @@ func registerCreateFilter(srv *server.Server, mgr *auth.Manager) {
 		Description: `Create a Gmail filter (inbox rule). Filters automatically apply actions to incoming messages that match the criteria.
 
 At least one criteria field must be set. Common patterns:
-  - Auto-label: from="notifications@github.com", add_labels=["Label_123"]
+  - Auto-label: from="notifications@github.com", add_labels=["GitHub"]
   - Auto-archive: from="noreply@example.com", remove_labels=["INBOX"]
   - Auto-star: query="is:important", add_labels=["STARRED"]
 
-Use list_labels to discover label IDs.`,
+Labels can be given by name (case-insensitive) or by ID. Set create_missing to create user labels that don't exist yet.`,
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input createFilterInput) (*mcp.CallToolResult, any, error) {
-		// Validate that at least one criteria is set.
-		hasCriteria := input.From != "" || input.To != "" || input.Subject != "" ||
-			input.Query != "" || input.NegatedQuery != "" || input.HasAttachment != nil
-		if !hasCriteria {
-			return nil, nil, fmt.Errorf("at least one criteria field is required (from, to, subject, query, negated_query, or has_attachment)")
-		}
-
-		// Validate that at least one action is set.
-		hasAction := len(input.AddLabels) > 0 || len(input.RemoveLabels) > 0 || input.Forward != ""
-		if !hasAction {
-			return nil, nil, fmt.Errorf("at least one action is required (add_labels, remove_labels, or forward)")
+		if err := input.validate(); err != nil {
+			return nil, nil, err
 		}
 
 		svc, err := newService(ctx, mgr, input.Account)
@@ -271,32 +385,26 @@ Use list_labels to discover label IDs.`,
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		filter := &gmailapi.Filter{
-			Criteria: &gmailapi.FilterCriteria{
-				From:         input.From,
-				To:           input.To,
-				Subject:      input.Subject,
-				Query:        input.Query,
-				NegatedQuery: input.NegatedQuery,
-			},
-			Action: &gmailapi.FilterAction{
-				AddLabelIds:    input.AddLabels,
-				RemoveLabelIds: input.RemoveLabels,
-				Forward:        input.Forward,
-			},
+		resolver, err := newLabelResolver(ctx, svc, input.CreateMissing, "create_missing")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing labels")
 		}
-		if input.HasAttachment != nil {
-			filter.Criteria.HasAttachment = *input.HasAttachment
+		spec := input.filterSpec
+		if spec.AddLabels, err = resolver.resolve(ctx, spec.AddLabels); err != nil {
+			return nil, nil, err
+		}
+		if spec.RemoveLabels, err = resolver.resolve(ctx, spec.RemoveLabels); err != nil {
+			return nil, nil, err
 		}
 
-		created, err := svc.Users.Settings.Filters.Create("me", filter).Do()
+		created, err := svc.Users.Settings.Filters.Create("me", spec.toFilter()).Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating filter: %w", err)
+			return nil, nil, gerrors.Wrap(err, "creating filter")
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Filter created.\n\nFilter ID: %s", created.Id)},
+				&mcp.TextContent{Text: fmt.Sprintf("Filter created.\n\nFilter ID: %s", created.Id) + resolver.note(spec.AddLabels, spec.RemoveLabels)},
 			},
 		}, nil, nil
 	})
@@ -305,7 +413,7 @@ Use list_labels to discover label IDs.`,
 // --- delete_filter ---
 
 type deleteFilterInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	FilterID string `json:"filter_id" jsonschema:"Filter ID to delete (from list_filters)"`
 }
 
@@ -313,7 +421,12 @@ func registerDeleteFilter(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_filter",
 		Description: "Delete a Gmail filter (inbox rule) by ID. Use list_filters to discover filter IDs.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteFilterInput) (*mcp.CallToolResult, any, error) {
 		if input.FilterID == "" {
 			return nil, nil, fmt.Errorf("filter_id is required")
@@ -324,8 +437,8 @@ func registerDeleteFilter(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		if err := svc.Users.Settings.Filters.Delete("me", input.FilterID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting filter: %w", err)
+		if err := svc.Users.Settings.Filters.Delete("me", input.FilterID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting filter")
 		}
 
 		return &mcp.CallToolResult{
@@ -336,10 +449,315 @@ func registerDeleteFilter(srv *server.Server, mgr *auth.Manager) {
 	})
 }
 
+// --- export_filters / import_filters ---
+
+// filterExportVersion is the version of the export_filters document format.
+const filterExportVersion = 1
+
+// filterExport is the JSON document written by export_filters and read by
+// import_filters. Labels are stored by name so the document can be imported
+// into another account, where label IDs differ.
+type filterExport struct {
+	Version    int          `json:"version"`
+	ExportedAt string       `json:"exported_at,omitempty"`
+	Filters    []filterSpec `json:"filters"`
+}
+
+// exportFilters converts the account's filters to an export document,
+// with every criteria the API reports.
+func exportFilters(filters []*gmailapi.Filter, names map[string]string, now time.Time) *filterExport {
+	doc := &filterExport{
+		Version:    filterExportVersion,
+		ExportedAt: now.UTC().Format(time.RFC3339),
+		Filters:    []filterSpec{},
+	}
+	for _, f := range filters {
+		var spec filterSpec
+		if c := f.Criteria; c != nil {
+			spec.From = c.From
+			spec.To = c.To
+			spec.Subject = c.Subject
+			spec.Query = c.Query
+			spec.NegatedQuery = c.NegatedQuery
+			if c.HasAttachment {
+				spec.HasAttachment = server.BoolPtr(true)
+			}
+			if c.Size > 0 {
+				spec.Size = c.Size
+				spec.SizeComparison = c.SizeComparison
+			}
+			spec.ExcludeChats = c.ExcludeChats
+		}
+		if a := f.Action; a != nil {
+			spec.AddLabels = labelRefs(a.AddLabelIds, names)
+			spec.RemoveLabels = labelRefs(a.RemoveLabelIds, names)
+			spec.Forward = a.Forward
+		}
+		doc.Filters = append(doc.Filters, spec)
+	}
+	return doc
+}
+
+// labelRefs maps label IDs to names, keeping the ID when the name is unknown.
+func labelRefs(ids []string, names map[string]string) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = id
+		if name, ok := names[id]; ok && name != "" {
+			refs[i] = name
+		}
+	}
+	return refs
+}
+
+// parseFilterExport decodes and checks an export document. Unknown fields
+// are rejected so that typos in hand-edited documents aren't silently ignored.
+func parseFilterExport(data []byte) (*filterExport, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var doc filterExport
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing filter export: %w", err)
+	}
+	if doc.Version != filterExportVersion {
+		return nil, fmt.Errorf("unsupported filter export version %d (expected %d)", doc.Version, filterExportVersion)
+	}
+	if len(doc.Filters) == 0 {
+		return nil, fmt.Errorf("filter export contains no filters")
+	}
+	return &doc, nil
+}
+
+// filterImportResult is the outcome of importing one filter.
+type filterImportResult struct {
+	Spec     filterSpec
+	FilterID string
+	Err      error
+}
+
+// importFilters creates each filter in doc on the account. Failures are
+// recorded per filter; the returned error is only set when the import
+// couldn't start at all.
+func importFilters(ctx context.Context, svc *gmailapi.Service, doc *filterExport, createMissingLabels bool) ([]filterImportResult, []string, error) {
+	resolver, err := newLabelResolver(ctx, svc, createMissingLabels, "create_missing_labels")
+	if err != nil {
+		return nil, nil, gerrors.Wrap(err, "listing labels")
+	}
+
+	results := make([]filterImportResult, 0, len(doc.Filters))
+	for _, spec := range doc.Filters {
+		res := filterImportResult{Spec: spec}
+		res.FilterID, res.Err = importFilter(ctx, svc, resolver, spec)
+		results = append(results, res)
+	}
+	return results, resolver.created, nil
+}
+
+func importFilter(ctx context.Context, svc *gmailapi.Service, resolver *labelResolver, spec filterSpec) (string, error) {
+	if err := spec.validate(); err != nil {
+		return "", err
+	}
+	var err error
+	if spec.AddLabels, err = resolver.resolve(ctx, spec.AddLabels); err != nil {
+		return "", err
+	}
+	if spec.RemoveLabels, err = resolver.resolve(ctx, spec.RemoveLabels); err != nil {
+		return "", err
+	}
+	created, err := svc.Users.Settings.Filters.Create("me", spec.toFilter()).Context(ctx).Do()
+	if err != nil {
+		return "", gerrors.Wrap(err, "creating filter")
+	}
+	return created.Id, nil
+}
+
+// describeFilterSpec returns a one-line summary of a filter's criteria.
+func describeFilterSpec(f filterSpec) string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", name, value))
+		}
+	}
+	add("from", f.From)
+	add("to", f.To)
+	add("subject", f.Subject)
+	add("query", f.Query)
+	add("negated_query", f.NegatedQuery)
+	if f.HasAttachment != nil {
+		parts = append(parts, fmt.Sprintf("has_attachment=%t", *f.HasAttachment))
+	}
+	if f.Size > 0 {
+		parts = append(parts, fmt.Sprintf("size=%s:%d", f.SizeComparison, f.Size))
+	}
+	if f.ExcludeChats {
+		parts = append(parts, "exclude_chats=true")
+	}
+	if len(parts) == 0 {
+		return "(no criteria)"
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatFilterImport(results []filterImportResult, createdLabels []string) string {
+	var sb strings.Builder
+	imported := 0
+	for _, r := range results {
+		if r.Err == nil {
+			imported++
+		}
+	}
+	fmt.Fprintf(&sb, "Imported %d of %d filters.\n", imported, len(results))
+	if len(createdLabels) > 0 {
+		fmt.Fprintf(&sb, "Created labels: %s\n", strings.Join(createdLabels, ", "))
+	}
+	sb.WriteString("\n")
+	for i, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(&sb, "%d. %s — failed: %v\n", i+1, describeFilterSpec(r.Spec), r.Err)
+		} else {
+			fmt.Fprintf(&sb, "%d. %s — created (Filter ID: %s)\n", i+1, describeFilterSpec(r.Spec), r.FilterID)
+		}
+	}
+	return sb.String()
+}
+
+// --- export_filters ---
+
+type exportFiltersInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	SaveTo  string `json:"save_to,omitempty" jsonschema:"Save the JSON document to a local file instead of returning it (path relative to an allowed directory). Requires --allow-write-dir."`
+}
+
+func registerExportFilters(srv *server.Server, mgr *auth.Manager) {
+	desc := `Export all Gmail filters (inbox rules) of an account as a JSON document, for use with import_filters.
+
+Labels are exported by name so the document can be imported into a different account. All criteria are exported, including size and exclude_chats.` + srv.WriteDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "export_filters",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input exportFiltersInput) (*mcp.CallToolResult, any, error) {
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		resp, err := svc.Users.Settings.Filters.List("me").Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing filters")
+		}
+		names, err := labelNames(ctx, svc, "me")
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing labels")
+		}
+
+		doc := exportFilters(resp.Filter, names, time.Now())
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding filters: %w", err)
+		}
+
+		if input.SaveTo != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-write-dir)")
+			}
+			dir, err := lfs.WriteFile(input.SaveTo, append(data, '\n'))
+			if err != nil {
+				return nil, nil, fmt.Errorf("saving filters: %w", err)
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("Exported %d filters.\n\nSaved to: %s/%s", len(doc.Filters), dir, input.SaveTo)},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	}, server.LocalWriteParams("save_to"))
+}
+
+// --- import_filters ---
+
+type importFiltersInput struct {
+	Account             string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Document            string `json:"document,omitempty" jsonschema:"Filter export JSON document (from export_filters). Set this or local_path."`
+	LocalPath           string `json:"local_path,omitempty" jsonschema:"Read the JSON document from a local file (path relative to an allowed directory). Requires --allow-read-dir."`
+	CreateMissingLabels bool   `json:"create_missing_labels,omitempty" jsonschema:"Create labels that don't exist on the account (default: filters using them fail)"`
+}
+
+func registerImportFilters(srv *server.Server, mgr *auth.Manager) {
+	desc := `Create Gmail filters from a JSON document produced by export_filters.
+
+Label names in the document are mapped to the account's label IDs (case-insensitive). Set create_missing_labels to create labels that don't exist yet. Each filter is validated and created independently; the result lists which filters succeeded and why others failed. Forwarding actions require the forwarding address to be verified on the account.` + srv.ReadDirsDescription()
+
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "import_filters",
+		Description: desc,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input importFiltersInput) (*mcp.CallToolResult, any, error) {
+		if (input.Document == "") == (input.LocalPath == "") {
+			return nil, nil, fmt.Errorf("exactly one of document or local_path is required")
+		}
+
+		data := []byte(input.Document)
+		if input.LocalPath != "" {
+			lfs := srv.LocalFS()
+			if lfs == nil {
+				return nil, nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir)")
+			}
+			var err error
+			if data, _, err = lfs.ReadFile(input.LocalPath); err != nil {
+				return nil, nil, fmt.Errorf("reading filter export: %w", err)
+			}
+		}
+
+		doc, err := parseFilterExport(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		results, created, err := importFilters(ctx, svc, doc, input.CreateMissingLabels)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatFilterImport(results, created)},
+			},
+		}, nil, nil
+	})
+}
+
 // --- list_send_as ---
 
 type listSendAsInput struct {
-	Account string `json:"account" jsonschema:"Account name"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 }
 
 func registerListSendAs(srv *server.Server, mgr *auth.Manager) {
@@ -347,7 +765,10 @@ func registerListSendAs(srv *server.Server, mgr *auth.Manager) {
 		Name:        "list_send_as",
 		Description: "List send-as aliases for a Gmail account. Shows all email addresses the account can send from, including the primary address and any configured aliases.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listSendAsInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
@@ -355,9 +776,9 @@ func registerListSendAs(srv *server.Server, mgr *auth.Manager) {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		resp, err := svc.Users.Settings.SendAs.List("me").Do()
+		resp, err := svc.Users.Settings.SendAs.List("me").Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("listing send-as aliases: %w", err)
+			return nil, nil, gerrors.Wrap(err, "listing send-as aliases")
 		}
 
 		var sb strings.Builder
@@ -393,3 +814,230 @@ func registerListSendAs(srv *server.Server, mgr *auth.Manager) {
 		}, nil, nil
 	})
 }
+
+// settingsAuditInput is the input for the read-only mailbox settings
+// audit tools.
+type settingsAuditInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+}
+
+// auditAccounts calls fetch for each account resolved from account and
+// joins the results. With several accounts each result is placed under an
+// "=== Account: name ===" header and errors are reported inline; with a
+// single account an error is returned.
+func auditAccounts(ctx context.Context, mgr *auth.Manager, account, what string, fetch func(svc *gmailapi.Service) (string, error)) (string, error) {
+	accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, account))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	multiAccount := len(accounts) > 1
+
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			break
+		}
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			if multiAccount {
+				fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+				continue
+			}
+			return "", fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		text, err := fetch(svc)
+		if err != nil {
+			err = gerrors.Wrapf(err, "getting %s", what)
+			if multiAccount {
+				fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+				continue
+			}
+			return "", err
+		}
+
+		if multiAccount {
+			fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+		}
+		sb.WriteString(text)
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// --- get_auto_forwarding ---
+
+func registerGetAutoForwarding(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "get_auto_forwarding",
+		Description: "Get the automatic forwarding setting for a Gmail account: whether all incoming mail is forwarded, to which address, and what happens to the original. Set account to 'all' to audit every configured mailbox.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input settingsAuditInput) (*mcp.CallToolResult, any, error) {
+		text, err := auditAccounts(ctx, mgr, input.Account, "auto-forwarding settings", func(svc *gmailapi.Service) (string, error) {
+			fwd, err := svc.Users.Settings.GetAutoForwarding("me").Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return formatAutoForwarding(fwd), nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// formatAutoForwarding formats the auto-forwarding setting for display.
+func formatAutoForwarding(fwd *gmailapi.AutoForwarding) string {
+	if !fwd.Enabled {
+		return "Auto-forwarding: disabled"
+	}
+	return fmt.Sprintf("Auto-forwarding: enabled\nForwarding to: %s\nDisposition: %s", fwd.EmailAddress, fwd.Disposition)
+}
+
+// --- list_forwarding_addresses ---
+
+func registerListForwardingAddresses(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_forwarding_addresses",
+		Description: "List the forwarding addresses registered on a Gmail account and their verification status. Set account to 'all' to audit every configured mailbox.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input settingsAuditInput) (*mcp.CallToolResult, any, error) {
+		text, err := auditAccounts(ctx, mgr, input.Account, "forwarding addresses", func(svc *gmailapi.Service) (string, error) {
+			resp, err := svc.Users.Settings.ForwardingAddresses.List("me").Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return formatForwardingAddresses(resp.ForwardingAddresses), nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// formatForwardingAddresses formats forwarding addresses for display.
+func formatForwardingAddresses(addrs []*gmailapi.ForwardingAddress) string {
+	if len(addrs) == 0 {
+		return "No forwarding addresses."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d forwarding addresses:\n", len(addrs))
+	for _, a := range addrs {
+		fmt.Fprintf(&sb, "- %s (%s)\n", a.ForwardingEmail, a.VerificationStatus)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// --- get_imap ---
+
+func registerGetIMAP(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "get_imap",
+		Description: "Get the IMAP access settings for a Gmail account. Set account to 'all' to audit every configured mailbox.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input settingsAuditInput) (*mcp.CallToolResult, any, error) {
+		text, err := auditAccounts(ctx, mgr, input.Account, "IMAP settings", func(svc *gmailapi.Service) (string, error) {
+			imap, err := svc.Users.Settings.GetImap("me").Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return formatIMAP(imap), nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// formatIMAP formats IMAP settings for display.
+func formatIMAP(imap *gmailapi.ImapSettings) string {
+	if !imap.Enabled {
+		return "IMAP: disabled"
+	}
+	var sb strings.Builder
+	sb.WriteString("IMAP: enabled\n")
+	fmt.Fprintf(&sb, "Auto-expunge: %v\n", imap.AutoExpunge)
+	if imap.ExpungeBehavior != "" {
+		fmt.Fprintf(&sb, "Expunge behavior: %s\n", imap.ExpungeBehavior)
+	}
+	if imap.MaxFolderSize > 0 {
+		fmt.Fprintf(&sb, "Max folder size: %d messages\n", imap.MaxFolderSize)
+	} else {
+		sb.WriteString("Max folder size: no limit\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// --- get_pop ---
+
+func registerGetPOP(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "get_pop",
+		Description: "Get the POP access settings for a Gmail account. Set account to 'all' to audit every configured mailbox.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input settingsAuditInput) (*mcp.CallToolResult, any, error) {
+		text, err := auditAccounts(ctx, mgr, input.Account, "POP settings", func(svc *gmailapi.Service) (string, error) {
+			pop, err := svc.Users.Settings.GetPop("me").Context(ctx).Do()
+			if err != nil {
+				return "", err
+			}
+			return formatPOP(pop), nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+// formatPOP formats POP settings for display.
+func formatPOP(pop *gmailapi.PopSettings) string {
+	if pop.AccessWindow == "" || pop.AccessWindow == "disabled" {
+		return "POP: disabled"
+	}
+	return fmt.Sprintf("POP: enabled\nAccess window: %s\nDisposition: %s", pop.AccessWindow, pop.Disposition)
+}
@@ -0,0 +1,358 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"github.com/thegrumpylion/google-mcp/internal/statefile"
+	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Gmail's API has no snooze. These tools emulate it: snoozing archives the
+// message and records it in a state file next to the tokens; a background
+// scheduler (RunSnoozeScheduler) moves it back to the inbox as unread at
+// the wake time. Snoozes only wake while a Gmail server process is running,
+// and they do not appear in Gmail's own "Snoozed" view.
+
+// snoozeFile is the name of the snooze state file in the config directory.
+const snoozeFile = "snoozed.json"
+
+// snoozeCheckInterval is how often the scheduler looks for due snoozes.
+const snoozeCheckInterval = time.Minute
+
+const snoozeCaveat = "Note: this is emulated — the message only returns while a google-mcp Gmail server is running, and it won't show in Gmail's native Snoozed view."
+
+// snoozeEntry is a single snoozed message.
+type snoozeEntry struct {
+	Account   string    `json:"account"`
+	MessageID string    `json:"message_id"`
+	WakeAt    time.Time `json:"wake_at"`
+	SnoozedAt time.Time `json:"snoozed_at"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// snoozeStore persists snoozed messages to a JSON file. Every operation
+// reads and rewrites the file under a lock, so the tools and the scheduler
+// (possibly in different server processes) always see the same state and
+// nothing is lost across restarts.
+type snoozeStore struct {
+	file *statefile.File[snoozeEntry]
+}
+
+func newSnoozeStore(mgr *auth.Manager) *snoozeStore {
+	return &snoozeStore{file: statefile.New(filepath.Join(mgr.ConfigDir(), snoozeFile), "snooze state", func(a, b snoozeEntry) int {
+		return a.WakeAt.Compare(b.WakeAt)
+	})}
+}
+
+// update loads the entries, applies fn, and saves the result.
+func (s *snoozeStore) update(fn func([]snoozeEntry) ([]snoozeEntry, error)) error {
+	return s.file.Update(fn)
+}
+
+// list returns the snoozed entries, soonest first.
+func (s *snoozeStore) list() ([]snoozeEntry, error) {
+	entries, err := s.file.Load()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].WakeAt.Before(entries[j].WakeAt) })
+	return entries, err
+}
+
+// add records a snooze, replacing any existing snooze of the same message.
+func (s *snoozeStore) add(e snoozeEntry) error {
+	return s.update(func(entries []snoozeEntry) ([]snoozeEntry, error) {
+		entries = removeSnooze(entries, e.Account, e.MessageID)
+		return append(entries, e), nil
+	})
+}
+
+// remove deletes a snooze and reports whether it existed.
+func (s *snoozeStore) remove(account, messageID string) (bool, error) {
+	found := false
+	err := s.update(func(entries []snoozeEntry) ([]snoozeEntry, error) {
+		out := removeSnooze(entries, account, messageID)
+		found = len(out) != len(entries)
+		return out, nil
+	})
+	return found, err
+}
+
+func removeSnooze(entries []snoozeEntry, account, messageID string) []snoozeEntry {
+	out := entries[:0:0]
+	for _, e := range entries {
+		if e.Account != account || e.MessageID != messageID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// snoozeWaker returns a snoozed message to the inbox.
+type snoozeWaker func(ctx context.Context, account, messageID string) error
+
+// wakeDue wakes every snooze whose wake time is at or before now, including
+// ones that fell due while no server was running. Woken entries are
+// removed; entries whose message no longer exists are dropped; other
+// failures are kept with the error recorded and retried on the next call.
+// It returns the entries that were woken.
+func (s *snoozeStore) wakeDue(ctx context.Context, now time.Time, wake snoozeWaker) ([]snoozeEntry, error) {
+	var woken []snoozeEntry
+	err := s.update(func(entries []snoozeEntry) ([]snoozeEntry, error) {
+		var keep []snoozeEntry
+		for _, e := range entries {
+			if e.WakeAt.After(now) {
+				keep = append(keep, e)
+				continue
+			}
+			if err := wake(ctx, e.Account, e.MessageID); err != nil {
+				if isNotFound(err) {
+					continue
+				}
+				e.LastError = err.Error()
+				keep = append(keep, e)
+				continue
+			}
+			woken = append(woken, e)
+		}
+		return keep, nil
+	})
+	return woken, err
+}
+
+// isNotFound reports whether err is a Google API 404.
+func isNotFound(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusNotFound
+}
+
+// unsnoozeMessage moves a message back to the inbox and marks it unread.
+func unsnoozeMessage(ctx context.Context, mgr *auth.Manager, account, messageID string) error {
+	svc, err := newService(ctx, mgr, account)
+	if err != nil {
+		return fmt.Errorf("creating Gmail service: %w", err)
+	}
+	_, err = svc.Users.Messages.Modify("me", messageID, &gmailapi.ModifyMessageRequest{
+		AddLabelIds: []string{"INBOX", "UNREAD"},
+	}).Context(ctx).Do()
+	return err
+}
+
+// RunSnoozeScheduler wakes snoozed messages until ctx is cancelled. Snoozes
+// that came due while the server was stopped are woken immediately.
+// Failures are retried on the next check.
+func RunSnoozeScheduler(ctx context.Context, mgr *auth.Manager) {
+	store := newSnoozeStore(mgr)
+	wake := func(ctx context.Context, account, messageID string) error {
+		return unsnoozeMessage(ctx, mgr, account, messageID)
+	}
+
+	ticker := time.NewTicker(snoozeCheckInterval)
+	defer ticker.Stop()
+	for {
+		store.wakeDue(ctx, time.Now(), wake)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// --- snooze_message ---
+
+type snoozeMessageInput struct {
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	MessageID string `json:"message_id" jsonschema:"Gmail message ID to snooze"`
+	Until     string `json:"until" jsonschema:"Wake time in RFC3339 format (e.g. '2024-01-15T09:00:00-05:00')"`
+}
+
+func registerSnoozeMessage(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "snooze_message",
+		Description: `Snooze a Gmail message: archive it now and move it back to the inbox, marked unread, at the given time.
+
+` + snoozeCaveat,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input snoozeMessageInput) (*mcp.CallToolResult, any, error) {
+		if input.MessageID == "" {
+			return nil, nil, fmt.Errorf("message_id is required")
+		}
+		if input.Until == "" {
+			return nil, nil, fmt.Errorf("until is required")
+		}
+		wakeAt, err := time.Parse(time.RFC3339, input.Until)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid until %q: must be RFC3339 (e.g. '2024-01-15T09:00:00Z')", input.Until)
+		}
+		if !wakeAt.After(time.Now()) {
+			return nil, nil, fmt.Errorf("until must be in the future")
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(accounts) != 1 {
+			return nil, nil, fmt.Errorf("a single account is required to snooze a message")
+		}
+		account := accounts[0]
+
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		if _, err := svc.Users.Messages.Modify("me", input.MessageID, &gmailapi.ModifyMessageRequest{
+			RemoveLabelIds: []string{"INBOX"},
+		}).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "archiving message")
+		}
+
+		if err := newSnoozeStore(mgr).add(snoozeEntry{
+			Account:   account,
+			MessageID: input.MessageID,
+			WakeAt:    wakeAt,
+			SnoozedAt: time.Now().UTC(),
+		}); err != nil {
+			return nil, nil, fmt.Errorf("message was archived but the snooze could not be saved: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Message snoozed until %s.\nMessage ID: %s\n\n%s",
+					wakeAt.Format(time.RFC3339), input.MessageID, snoozeCaveat)},
+			},
+		}, nil, nil
+	})
+}
+
+// --- list_snoozed ---
+
+type listSnoozedInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+}
+
+func registerListSnoozed(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_snoozed",
+		Description: "List messages snoozed with snooze_message, soonest wake time first. Set account to 'all' to list snoozes for every account.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listSnoozedInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entries, err := newSnoozeStore(mgr).list()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatSnoozed(entries, accounts)},
+			},
+		}, nil, nil
+	})
+}
+
+// formatSnoozed renders the snoozes belonging to accounts.
+func formatSnoozed(entries []snoozeEntry, accounts []string) string {
+	include := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		include[a] = true
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, e := range entries {
+		if !include[e.Account] {
+			continue
+		}
+		n++
+		fmt.Fprintf(&sb, "- Message ID: %s\n", e.MessageID)
+		if len(accounts) > 1 {
+			fmt.Fprintf(&sb, "  Account: %s\n", e.Account)
+		}
+		fmt.Fprintf(&sb, "  Wakes: %s\n", e.WakeAt.Format(time.RFC3339))
+		if e.LastError != "" {
+			fmt.Fprintf(&sb, "  Last wake attempt failed: %s\n", e.LastError)
+		}
+	}
+	if n == 0 {
+		return "No snoozed messages."
+	}
+	return fmt.Sprintf("Snoozed messages (%d):\n\n%s\n%s", n, sb.String(), snoozeCaveat)
+}
+
+// --- unsnooze ---
+
+type unsnoozeInput struct {
+	Account   string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	MessageID string `json:"message_id" jsonschema:"Gmail message ID to unsnooze"`
+}
+
+func registerUnsnooze(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "unsnooze",
+		Description: "Cancel a snooze created with snooze_message and move the message back to the inbox now, marked unread.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input unsnoozeInput) (*mcp.CallToolResult, any, error) {
+		if input.MessageID == "" {
+			return nil, nil, fmt.Errorf("message_id is required")
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(accounts) != 1 {
+			return nil, nil, fmt.Errorf("a single account is required to unsnooze a message")
+		}
+		account := accounts[0]
+
+		if err := unsnoozeMessage(ctx, mgr, account, input.MessageID); err != nil {
+			return nil, nil, gerrors.Wrap(err, "moving message to inbox")
+		}
+
+		found, err := newSnoozeStore(mgr).remove(account, input.MessageID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		text := fmt.Sprintf("Message moved back to the inbox.\nMessage ID: %s", input.MessageID)
+		if !found {
+			text += "\n\nThe message was not snoozed."
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,347 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	gmailapi "google.golang.org/api/gmail/v1"
+)
+
+// batchDeleteLimit is the Messages.BatchDelete limit on message IDs.
+const batchDeleteLimit = 1000
+
+// chunkResult is the outcome of one chunk of a chunked batch operation.
+type chunkResult struct {
+	Start, End int // IDs [Start, End) of the batch
+	Err        error
+}
+
+// forEachChunk calls fn with consecutive chunks of at most size ids,
+// reporting progress after each. A failed chunk is recorded and the next
+// one still runs; chunks left when ctx is done are recorded as not run.
+func forEachChunk(ctx context.Context, req *mcp.CallToolRequest, ids []string, size int, verb string, fn func(chunk []string) error) []chunkResult {
+	var results []chunkResult
+	total := len(ids)
+	for start := 0; start < total; start += size {
+		end := min(start+size, total)
+		if err := ctx.Err(); err != nil {
+			results = append(results, chunkResult{Start: start, End: end, Err: fmt.Errorf("not run: %w", err)})
+			continue
+		}
+		results = append(results, chunkResult{Start: start, End: end, Err: fn(ids[start:end])})
+		server.Progress(ctx, req, end, total, fmt.Sprintf("%s %d of %d messages", verb, end, total))
+	}
+	return results
+}
+
+// formatChunkResults summarizes a chunked batch operation, listing each
+// chunk when there was more than one or any failed. It returns the number
+// of messages in successful chunks.
+func formatChunkResults(action string, results []chunkResult) (string, int) {
+	var total, done int
+	var failed bool
+	for _, r := range results {
+		total += r.End - r.Start
+		if r.Err == nil {
+			done += r.End - r.Start
+		} else {
+			failed = true
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d messages %s.\n", done, total, action)
+	if len(results) > 1 || failed {
+		sb.WriteString("\n")
+		for i, r := range results {
+			fmt.Fprintf(&sb, "Chunk %d (messages %d-%d): ", i+1, r.Start+1, r.End)
+			if r.Err != nil {
+				fmt.Fprintf(&sb, "failed: %v\n", r.Err)
+			} else {
+				sb.WriteString("ok\n")
+			}
+		}
+	}
+	return sb.String(), done
+}
+
+// spamLabelResult runs a chunked label change for not_spam and report_spam.
+// A single message uses Messages.Modify; larger selections use BatchModify.
+func spamLabelResult(ctx context.Context, req *mcp.CallToolRequest, svc *gmailapi.Service, ids, add, remove []string, action string) (*mcp.CallToolResult, any, error) {
+	results := forEachChunk(ctx, req, ids, batchModifyLimit, "Modified", func(chunk []string) error {
+		if len(chunk) == 1 {
+			_, err := svc.Users.Messages.Modify("me", chunk[0], &gmailapi.ModifyMessageRequest{
+				AddLabelIds:    add,
+				RemoveLabelIds: remove,
+			}).Context(ctx).Do()
+			return err
+		}
+		return svc.Users.Messages.BatchModify("me", &gmailapi.BatchModifyMessagesRequest{
+			Ids:            chunk,
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}).Context(ctx).Do()
+	})
+	text, done := formatChunkResults(action, results)
+	if done == 0 {
+		return nil, nil, fmt.Errorf("%s", strings.TrimSpace(text))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}, nil, nil
+}
+
+// --- list_spam ---
+
+type listSpamInput struct {
+	Account    string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
+	Query      string `json:"query,omitempty" jsonschema:"Additional Gmail search terms to narrow the spam folder (e.g. 'from:legal@example.com contract')"`
+	MaxResults int64  `json:"max_results,omitempty" jsonschema:"Maximum number of messages per account (default 20, max 100)"`
+}
+
+func registerListSpam(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "list_spam",
+		Description: "List messages in the Gmail spam folder, newest first, optionally narrowed with Gmail search terms in query. Set account to 'all' to check all accounts. Use not_spam to move a message back to the inbox.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listSpamInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		maxResults := input.MaxResults
+		if maxResults <= 0 {
+			maxResults = 20
+		}
+		if maxResults > 100 {
+			maxResults = 100
+		}
+		query := "in:spam"
+		if q := strings.TrimSpace(input.Query); q != "" {
+			query += " " + q
+		}
+
+		out := srv.NewOutputBuilder()
+		multiAccount := len(accounts) > 1
+
+		for _, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
+			svc, err := newService(ctx, mgr, account)
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+			}
+
+			resp, err := svc.Users.Messages.List("me").Q(query).MaxResults(maxResults).Context(ctx).Do()
+			if err != nil {
+				if multiAccount {
+					fmt.Fprintf(out, "=== Account: %s ===\nError listing spam: %v\n\n", account, err)
+					continue
+				}
+				return nil, nil, gerrors.Wrap(err, "listing spam")
+			}
+
+			if multiAccount {
+				fmt.Fprintf(out, "=== Account: %s ===\n", account)
+			}
+			if len(resp.Messages) == 0 {
+				out.WriteString("No spam messages found.\n\n")
+				continue
+			}
+			fmt.Fprintf(out, "Found %d spam messages (estimated total: %d):\n\n", len(resp.Messages), resp.ResultSizeEstimate)
+
+			labels, _ := labelNames(ctx, svc, "me")
+			ids := make([]string, len(resp.Messages))
+			for i, msg := range resp.Messages {
+				ids[i] = msg.Id
+			}
+			details := fetchDetails(ctx, ids, func(id string) (*gmailapi.Message, error) {
+				return svc.Users.Messages.Get("me", id).
+					Format("metadata").
+					MetadataHeaders("From", "Subject", "Date").
+					Fields(searchResultFields).Context(ctx).
+					Do()
+			}, nil)
+			for i, msg := range resp.Messages {
+				detail, err := details[i].Value, details[i].Err
+				if err != nil {
+					if !out.AddItem(fmt.Sprintf("- Message ID: %s (error fetching details: %v)\n", msg.Id, err)) {
+						break
+					}
+					continue
+				}
+				if !out.AddItem(formatSearchResult(detail, account, labels) + "\n") {
+					break
+				}
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: out.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// --- not_spam ---
+
+type spamMessagesInput struct {
+	Account    string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	MessageIDs []string `json:"message_ids" jsonschema:"Gmail message IDs"`
+}
+
+func registerNotSpam(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "not_spam",
+		Description: "Mark Gmail messages as not spam: removes the SPAM label and moves them back to the inbox. Large selections are sent in batches of 1000; the result reports each batch.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input spamMessagesInput) (*mcp.CallToolResult, any, error) {
+		if len(input.MessageIDs) == 0 {
+			return nil, nil, fmt.Errorf("message_ids must contain at least one message ID")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		return spamLabelResult(ctx, req, svc, input.MessageIDs, []string{"INBOX"}, []string{"SPAM"}, "moved from spam to the inbox")
+	})
+}
+
+// --- report_spam ---
+
+func registerReportSpam(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "report_spam",
+		Description: "Report Gmail messages as spam: adds the SPAM label and removes them from the inbox. Gmail deletes spam automatically after 30 days. Use not_spam to undo. Large selections are sent in batches of 1000; the result reports each batch.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input spamMessagesInput) (*mcp.CallToolResult, any, error) {
+		if len(input.MessageIDs) == 0 {
+			return nil, nil, fmt.Errorf("message_ids must contain at least one message ID")
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		return spamLabelResult(ctx, req, svc, input.MessageIDs, []string{"SPAM"}, []string{"INBOX"}, "reported as spam")
+	})
+}
+
+// --- delete_messages_in_trash ---
+
+type deleteTrashInput struct {
+	Account       string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	OlderThanDays int    `json:"older_than_days,omitempty" jsonschema:"Only delete messages older than this many days (default 0: everything in the trash)"`
+	MaxMessages   int    `json:"max_messages,omitempty" jsonschema:"Maximum number of messages to delete (default 500, max 10000)"`
+	Confirm       bool   `json:"confirm,omitempty" jsonschema:"Set to true to actually delete. Without it, the tool only reports how many messages would be deleted."`
+}
+
+// trashQuery returns the search query for trashed messages older than days.
+func trashQuery(days int) string {
+	if days <= 0 {
+		return "in:trash"
+	}
+	return fmt.Sprintf("in:trash older_than:%dd", days)
+}
+
+func registerDeleteMessagesInTrash(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "delete_messages_in_trash",
+		Description: "Permanently delete messages in the Gmail trash, optionally only those older than older_than_days. This is irreversible. Without confirm: true the tool only counts the matching messages, so call it once to preview and again with confirm to delete. Deletes at most max_messages per call, in batches of 1000; the result reports each batch.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteTrashInput) (*mcp.CallToolResult, any, error) {
+		if input.OlderThanDays < 0 {
+			return nil, nil, fmt.Errorf("older_than_days must not be negative")
+		}
+		maxMessages := input.MaxMessages
+		if maxMessages <= 0 {
+			maxMessages = 500
+		}
+		if maxMessages > 10000 {
+			maxMessages = 10000
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+
+		query := trashQuery(input.OlderThanDays)
+		ids, truncated, err := listMessageIDs(ctx, svc, query, maxMessages)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var more string
+		if truncated {
+			more = " More messages match; run again (or raise max_messages, up to 10000) to delete the rest."
+		}
+		if len(ids) == 0 {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("No messages match %q.", query)},
+				},
+			}, nil, nil
+		}
+		if !input.Confirm {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("%d messages match %q and would be permanently deleted.%s\n\nNothing was deleted. Call again with confirm: true to delete them.", len(ids), query, more)},
+				},
+			}, nil, nil
+		}
+
+		results := forEachChunk(ctx, req, ids, batchDeleteLimit, "Deleted", func(chunk []string) error {
+			return svc.Users.Messages.BatchDelete("me", &gmailapi.BatchDeleteMessagesRequest{Ids: chunk}).Context(ctx).Do()
+		})
+		text, done := formatChunkResults("permanently deleted", results)
+		if done == 0 {
+			return nil, nil, fmt.Errorf("%s", strings.TrimSpace(text))
+		}
+		if more != "" {
+			text += "\n" + strings.TrimSpace(more) + "\n"
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Query: %s\n%s", query, text)},
+			},
+		}, nil, nil
+	})
+}
@@ -2,11 +2,17 @@ package gmail
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/mail"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
@@ -14,21 +20,30 @@ import (
 // --- gmail_list_threads ---
 
 type listThreadsInput struct {
-	Account    string   `json:"account" jsonschema:"Account name or 'all' for all accounts"`
+	Account    string   `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts (optional when only one account is configured or a default is set)"`
 	Query      string   `json:"query,omitempty" jsonschema:"Gmail search query to filter threads (same syntax as Gmail search bar)"`
 	MaxResults int64    `json:"max_results,omitempty" jsonschema:"Maximum number of results per account (default 10, max 500)"`
 	LabelIDs   []string `json:"label_ids,omitempty" jsonschema:"Only return threads with all of these label IDs"`
+	queryFilters
 }
 
 func registerListThreads(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "list_threads",
-		Description: "List Gmail threads. Supports query filtering with Gmail search syntax, label filtering, and multi-account search. Returns thread IDs, snippets, and message counts.",
+		Description: "List Gmail threads. Supports query filtering with Gmail search syntax, label filtering, and multi-account search. Returns thread IDs, snippets, and message counts.\n\n" + queryFiltersHelp,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listThreadsInput) (*mcp.CallToolResult, any, error) {
-		accounts, err := mgr.ResolveAccounts(input.Account)
+		query, err := buildQuery(input.Query, input.queryFilters)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -41,55 +56,76 @@ func registerListThreads(srv *server.Server, mgr *auth.Manager) {
 			maxResults = 500
 		}
 
-		var sb strings.Builder
+		out := srv.NewOutputBuilder()
 		multiAccount := len(accounts) > 1
+		if query != strings.TrimSpace(input.Query) {
+			fmt.Fprintf(out, "Query: %s\n\n", query)
+		}
 
-		for _, account := range accounts {
+		fetched := 0
+		for n, account := range accounts {
+			if out.Truncated() || ctx.Err() != nil {
+				break
+			}
 			svc, err := newService(ctx, mgr, account)
 			if err != nil {
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError: %v\n\n", account, err)
 					continue
 				}
 				return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 			}
 
 			call := svc.Users.Threads.List("me").MaxResults(maxResults)
-			if input.Query != "" {
-				call = call.Q(input.Query)
+			if query != "" {
+				call = call.Q(query)
 			}
 			if len(input.LabelIDs) > 0 {
 				call = call.LabelIds(input.LabelIDs...)
 			}
 
-			resp, err := call.Do()
+			resp, err := call.Context(ctx).Do()
 			if err != nil {
 				if multiAccount {
-					fmt.Fprintf(&sb, "=== Account: %s ===\nError listing threads: %v\n\n", account, err)
+					fmt.Fprintf(out, "=== Account: %s ===\nError listing threads: %v\n\n", account, err)
 					continue
 				}
-				return nil, nil, fmt.Errorf("listing threads: %w", err)
+				return nil, nil, gerrors.Wrap(err, "listing threads")
 			}
 
 			if multiAccount {
-				fmt.Fprintf(&sb, "=== Account: %s ===\n", account)
+				fmt.Fprintf(out, "=== Account: %s ===\n", account)
 			}
 
 			if len(resp.Threads) == 0 {
-				sb.WriteString("No threads found.\n\n")
+				out.WriteString("No threads found.\n\n")
 				continue
 			}
 
-			fmt.Fprintf(&sb, "Found %d threads (estimated total: %d):\n\n", len(resp.Threads), resp.ResultSizeEstimate)
+			fmt.Fprintf(out, "Found %d threads (estimated total: %d):\n\n", len(resp.Threads), resp.ResultSizeEstimate)
 
-			for _, thread := range resp.Threads {
-				// Threads.List returns minimal info; fetch metadata for the first message.
-				detail, err := svc.Users.Threads.Get("me", thread.Id).Format("metadata").MetadataHeaders("From", "Subject", "Date").Do()
+			// Threads.List returns minimal info; fetch metadata for the first message.
+			ids := make([]string, len(resp.Threads))
+			for i, thread := range resp.Threads {
+				ids[i] = thread.Id
+			}
+			details := fetchDetails(ctx, ids, func(id string) (*gmailapi.Thread, error) {
+				return getThreadSummary(ctx, svc, id)
+			}, func(done int) {
+				reportFetchProgress(ctx, req, accounts, n, fetched+done, done, len(ids), "threads")
+			})
+			fetched += len(ids)
+
+			for i, thread := range resp.Threads {
+				detail, err := details[i].Value, details[i].Err
 				if err != nil {
-					fmt.Fprintf(&sb, "- Thread ID: %s (error fetching details: %v)\n\n", thread.Id, err)
+					if !out.AddItem(fmt.Sprintf("- Thread ID: %s (error fetching details: %v)\n\n", thread.Id, err)) {
+						break
+					}
 					continue
 				}
 
+				var sb strings.Builder
 				fmt.Fprintf(&sb, "- Thread ID: %s\n  Account: %s\n  Messages: %d\n  Snippet: %s\n",
 					thread.Id, account, len(detail.Messages), thread.Snippet)
 
@@ -110,115 +146,675 @@ func registerListThreads(srv *server.Server, mgr *auth.Manager) {
 					}
 				}
 				sb.WriteString("\n")
+				if !out.AddItem(sb.String()) {
+					break
+				}
 			}
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: sb.String()},
+				&mcp.TextContent{Text: out.String()},
 			},
 		}, nil, nil
 	})
 }
 
+// threadSummaryFields is the Fields mask for the per-thread fetch in
+// list_threads, which only shows the message count and the first message's
+// headers.
+const threadSummaryFields = "messages(payload(headers))"
+
+// getThreadSummary fetches the From, Subject and Date headers of every
+// message in a thread.
+func getThreadSummary(ctx context.Context, svc *gmailapi.Service, threadID string) (*gmailapi.Thread, error) {
+	return svc.Users.Threads.Get("me", threadID).
+		Format("metadata").
+		MetadataHeaders("From", "Subject", "Date").
+		Fields(threadSummaryFields).Context(ctx).
+		Do()
+}
+
 // --- gmail_read_thread ---
 
 type readThreadInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	ThreadID string `json:"thread_id" jsonschema:"Gmail thread ID (from search or read results)"`
+	Mode     string `json:"mode,omitempty" jsonschema:"What to return: full (default; every message with its body), latest (headers of every message and the body of only the last one), or summary (participants, date range, subject, labels and attachments; no bodies)"`
 }
 
+// threadHeaders are the headers read_thread shows for each message.
+var threadHeaders = []string{"From", "To", "Cc", "Subject", "Date"}
+
 func registerReadThread(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "read_thread",
-		Description: "Read all messages in a Gmail thread/conversation by thread ID. Returns each message with headers and body text in chronological order.",
+		Description: "Read all messages in a Gmail thread/conversation by thread ID. Returns each message with headers and body text in chronological order.\n\nFor long threads, use mode=latest to get only the newest message body, or mode=summary for who is on the thread and what state it is in without any bodies.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input readThreadInput) (*mcp.CallToolResult, any, error) {
+		mode := strings.ToLower(strings.TrimSpace(input.Mode))
+		switch mode {
+		case "":
+			mode = "full"
+		case "full", "latest", "summary":
+		default:
+			return nil, nil, fmt.Errorf("invalid mode %q: must be full, latest, or summary", input.Mode)
+		}
+
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		thread, err := svc.Users.Threads.Get("me", input.ThreadID).Format("full").Do()
+		call := svc.Users.Threads.Get("me", input.ThreadID)
+		if mode == "full" {
+			call = call.Format("full")
+		} else {
+			call = call.Format("metadata").MetadataHeaders(threadHeaders...)
+		}
+		thread, err := call.Context(ctx).Do()
 		if err != nil {
-			return nil, nil, fmt.Errorf("getting thread: %w", err)
+			return nil, nil, gerrors.Wrap(err, "getting thread")
+		}
+
+		out := srv.NewOutputBuilder()
+		if mode == "summary" {
+			// On failure, label IDs are shown instead of names.
+			labels, _ := labelNames(ctx, svc, "me")
+			out.WriteString(formatThreadSummary(summarizeThread(thread), labels))
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: out.String()},
+				},
+			}, nil, nil
+		}
+
+		if mode == "latest" && len(thread.Messages) > 0 {
+			last := thread.Messages[len(thread.Messages)-1]
+			full, err := svc.Users.Messages.Get("me", last.Id).Format("full").Context(ctx).Do()
+			if err != nil {
+				return nil, nil, gerrors.Wrap(err, "getting latest message")
+			}
+			thread.Messages[len(thread.Messages)-1] = full
 		}
 
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "Thread ID: %s\nMessages: %d\n\n", thread.Id, len(thread.Messages))
+		fmt.Fprintf(out, "Thread ID: %s\nMessages: %d\n\n", thread.Id, len(thread.Messages))
 
 		for i, msg := range thread.Messages {
-			fmt.Fprintf(&sb, "--- Message %d/%d (Message ID: %s) ---\n", i+1, len(thread.Messages), msg.Id)
-
-			// Write headers.
-			if msg.Payload != nil {
-				for _, h := range msg.Payload.Headers {
-					switch h.Name {
-					case "From", "To", "Cc", "Subject", "Date":
-						fmt.Fprintf(&sb, "%s: %s\n", h.Name, h.Value)
-					}
+			withBody := mode == "full" || i == len(thread.Messages)-1
+			if !out.AddItem(formatThreadMessage(msg, i, len(thread.Messages), withBody)) {
+				break
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: out.String()},
+			},
+		}, nil, nil
+	})
+}
+
+// --- reply_to_thread ---
+
+type replyThreadInput struct {
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	Mailbox  string `json:"mailbox,omitempty" jsonschema:"Email address of a mailbox this account has delegate access to (default: the account's own mailbox)"`
+	ThreadID string `json:"thread_id" jsonschema:"Gmail thread ID to reply to (from search or read results)"`
+	Body     string `json:"body" jsonschema:"Reply body (plain text)"`
+	ReplyAll bool   `json:"reply_all,omitempty" jsonschema:"Also Cc the other To and Cc recipients of the message replied to (default: reply to its sender only)"`
+	Force    bool   `json:"force,omitempty" jsonschema:"Send even if an identical message (same To, subject and body) was sent recently and the server would refuse it as a duplicate (default: false)"`
+}
+
+// replyThreadHeaders are the headers reply_to_thread reads from each
+// message of the thread.
+var replyThreadHeaders = []string{"From", "To", "Cc", "Reply-To", "Subject"}
+
+func registerReplyToThread(srv *server.Server, mgr *auth.Manager, o *options) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "reply_to_thread",
+		Description: `Reply to a Gmail thread in one call. The reply goes to the most recent message in the thread not sent by the account itself (drafts are ignored), to its Reply-To or From address, with In-Reply-To and References set so it stays in the thread. Set reply_all to also Cc the message's other recipients. The account's own addresses and send-as aliases are never added as recipients, and when the message was addressed to one of its aliases the reply is sent from that alias.
+
+The subject is the original one with "Re: " prepended. Use send_message with reply_to_message_id to reply to a specific message or to choose recipients yourself.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  false,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input replyThreadInput) (*mcp.CallToolResult, any, error) {
+		if input.ThreadID == "" {
+			return nil, nil, fmt.Errorf("thread_id is required")
+		}
+		if strings.TrimSpace(input.Body) == "" {
+			return nil, nil, fmt.Errorf("body is required")
+		}
+		if err := checkMailbox(input.Mailbox); err != nil {
+			return nil, nil, err
+		}
+
+		svc, err := newService(ctx, mgr, input.Account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		user := userID(input.Mailbox)
+
+		thread, err := svc.Users.Threads.Get(user, input.ThreadID).
+			Format("metadata").
+			MetadataHeaders(replyThreadHeaders...).Context(ctx).
+			Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "getting thread")
+		}
+		aliases, err := svc.Users.Settings.SendAs.List(user).Context(ctx).Do()
+		if err != nil {
+			return nil, nil, gerrors.Wrap(err, "listing send-as aliases")
+		}
+
+		plan, err := planThreadReply(thread, aliases.SendAs, input.ReplyAll)
+		if err != nil {
+			return nil, nil, err
+		}
+		compose := composeInput{
+			From:    plan.From,
+			To:      plan.To,
+			Cc:      plan.Cc,
+			Subject: plan.Subject,
+			Body:    input.Body,
+		}
+		result, err := buildMessage(ctx, svc, user, compose, plan.Message.Id, o.compose)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		release, err := o.guard.admit(messageDigest(compose.To, compose.Subject, compose.Body), input.Force)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent, err := svc.Users.Messages.Send(user, &gmailapi.Message{Raw: result.Raw, ThreadId: thread.Id}).Context(ctx).Do()
+		if err != nil {
+			release()
+			return nil, nil, gerrors.Wrap(err, "sending reply")
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatThreadReply(plan, sent) + result.note()},
+			},
+		}, nil, nil
+	})
+}
+
+// threadReply is what reply_to_thread sends: the message it replies to and
+// the derived headers.
+type threadReply struct {
+	// Message is the message replied to.
+	Message *gmailapi.Message
+	// MessageFrom is the From header of Message.
+	MessageFrom string
+	// Skipped counts the newer messages in the thread sent by the account.
+	Skipped int
+	// From is the send-as alias to reply from, or "" for the default.
+	From    string
+	To, Cc  string
+	Subject string
+}
+
+// planThreadReply picks the message of a metadata-format thread to reply
+// to and derives the reply's recipients and subject. aliases are the
+// account's send-as addresses, primary included: messages from them count
+// as the account's own, and they are never used as recipients.
+func planThreadReply(thread *gmailapi.Thread, aliases []*gmailapi.SendAs, replyAll bool) (*threadReply, error) {
+	own := make(map[string]bool)
+	for _, sa := range aliases {
+		own[strings.ToLower(sa.SendAsEmail)] = true
+	}
+	isOwn := func(a *mail.Address) bool { return own[strings.ToLower(a.Address)] }
+
+	plan := &threadReply{}
+	var headers map[string]string
+	for i := len(thread.Messages) - 1; i >= 0; i-- {
+		msg := thread.Messages[i]
+		if slices.Contains(msg.LabelIds, "DRAFT") {
+			continue
+		}
+		headers = messageHeaders(msg)
+		from := splitAddressList(headers["From"])
+		if slices.Contains(msg.LabelIds, "SENT") || (len(from) > 0 && isOwn(from[0])) {
+			plan.Skipped++
+			continue
+		}
+		plan.Message = msg
+		break
+	}
+	if plan.Message == nil {
+		return nil, fmt.Errorf("thread %s has no message from anyone else to reply to; use send_message to write to its participants", thread.Id)
+	}
+	plan.MessageFrom = headers["From"]
+
+	// recipients collects addresses in order, without duplicates or the
+	// account's own addresses.
+	seen := make(map[string]bool)
+	recipients := func(headers ...string) string {
+		var out []string
+		for _, h := range headers {
+			for _, a := range splitAddressList(h) {
+				key := strings.ToLower(a.Address)
+				if isOwn(a) || seen[key] {
+					continue
 				}
+				seen[key] = true
+				out = append(out, a.String())
 			}
-			sb.WriteString("\n")
+		}
+		return strings.Join(out, ", ")
+	}
+	replyTo := headers["Reply-To"]
+	if replyTo == "" {
+		replyTo = headers["From"]
+	}
+	plan.To = recipients(replyTo)
+	if plan.To == "" {
+		return nil, fmt.Errorf("message %s has no sender other than this account to reply to", plan.Message.Id)
+	}
+	if replyAll {
+		plan.Cc = recipients(headers["To"], headers["Cc"])
+	}
+
+	// Reply from the alias the message was sent to, as Gmail does.
+	for _, a := range append(splitAddressList(headers["To"]), splitAddressList(headers["Cc"])...) {
+		for _, sa := range aliases {
+			if !sa.IsPrimary && sendAsVerified(sa) && strings.EqualFold(sa.SendAsEmail, a.Address) {
+				plan.From = sa.SendAsEmail
+			}
+		}
+		if plan.From != "" {
+			break
+		}
+	}
 
-			// Extract body text.
-			body := extractBody(msg.Payload)
-			if body != "" {
-				sb.WriteString(body)
-			} else {
-				sb.WriteString("(no text content)")
+	plan.Subject = strings.TrimSpace(headers["Subject"])
+	if !strings.HasPrefix(strings.ToLower(plan.Subject), "re:") {
+		plan.Subject = strings.TrimSpace("Re: " + plan.Subject)
+	}
+	return plan, nil
+}
+
+// messageHeaders returns the top-level headers of msg by name.
+func messageHeaders(msg *gmailapi.Message) map[string]string {
+	headers := make(map[string]string)
+	if msg.Payload != nil {
+		for _, h := range msg.Payload.Headers {
+			headers[h.Name] = h.Value
+		}
+	}
+	return headers
+}
+
+// formatThreadReply formats the reply_to_thread confirmation.
+func formatThreadReply(plan *threadReply, sent *gmailapi.Message) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Reply sent to message %s from %s.\n", plan.Message.Id, plan.MessageFrom)
+	if plan.Skipped > 0 {
+		fmt.Fprintf(&sb, "Skipped %d newer messages sent by this account.\n", plan.Skipped)
+	}
+	sb.WriteString("\n")
+	if plan.From != "" {
+		fmt.Fprintf(&sb, "From: %s\n", plan.From)
+	}
+	fmt.Fprintf(&sb, "To: %s\n", plan.To)
+	if plan.Cc != "" {
+		fmt.Fprintf(&sb, "Cc: %s\n", plan.Cc)
+	}
+	fmt.Fprintf(&sb, "Subject: %s\n", plan.Subject)
+	fmt.Fprintf(&sb, "Message ID: %s\nThread ID: %s", sent.Id, sent.ThreadId)
+	return sb.String()
+}
+
+// formatThreadMessage formats message i of a thread of n messages for
+// read_thread: its headers and, if withBody is set, its body and
+// attachments.
+func formatThreadMessage(msg *gmailapi.Message, i, n int, withBody bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- Message %d/%d (Message ID: %s) ---\n", i+1, n, msg.Id)
+
+	if msg.Payload != nil {
+		for _, h := range msg.Payload.Headers {
+			if slices.Contains(threadHeaders, h.Name) {
+				fmt.Fprintf(&sb, "%s: %s\n", h.Name, h.Value)
 			}
+		}
+	}
+	if !withBody {
+		sb.WriteString("\n")
+		return sb.String()
+	}
+	sb.WriteString("\n")
+
+	body := extractBody(msg.Payload)
+	if body != "" {
+		sb.WriteString(body)
+	} else {
+		sb.WriteString("(no text content)")
+	}
+
+	if list := formatAttachmentList(listAttachments(msg.Payload)); list != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(list)
+	}
+
+	sb.WriteString("\n\n")
+	return sb.String()
+}
 
-			// List attachments.
-			attachments := listAttachments(msg.Payload)
-			if len(attachments) > 0 {
-				sb.WriteString("\n\nAttachments:\n")
-				for _, a := range attachments {
-					fmt.Fprintf(&sb, "  - %s (MIME: %s, Size: %d bytes, Attachment ID: %s)\n",
-						a.filename, a.mimeType, a.size, a.attachmentID)
-				}
+// threadParticipant is one address on a thread.
+type threadParticipant struct {
+	// Address is the participant as first seen in a header, e.g.
+	// "Alice <alice@example.com>".
+	Address string
+	// Sent counts the messages the participant sent.
+	Sent int
+	// Received counts the messages addressed to the participant (To or Cc).
+	Received int
+}
+
+// threadSummary aggregates a metadata-format thread for mode=summary.
+type threadSummary struct {
+	ID       string
+	Subject  string
+	Messages int
+	// Participants are ordered by messages sent, then first appearance.
+	Participants []threadParticipant
+	First, Last  time.Time
+	// Labels are the label IDs on any message, in first-seen order.
+	Labels []string
+	Unread int
+	// WithAttachments counts messages that appear to carry attachments.
+	WithAttachments int
+	LatestFrom      string
+	LatestSnippet   string
+}
+
+// summarizeThread aggregates the messages of a thread fetched in metadata
+// format with threadHeaders.
+func summarizeThread(thread *gmailapi.Thread) threadSummary {
+	sum := threadSummary{ID: thread.Id, Messages: len(thread.Messages)}
+	index := make(map[string]int)
+	participant := func(a *mail.Address) *threadParticipant {
+		key := strings.ToLower(a.Address)
+		i, ok := index[key]
+		if !ok {
+			i = len(sum.Participants)
+			index[key] = i
+			display := a.Address
+			if a.Name != "" {
+				display = a.Name + " <" + a.Address + ">"
+			}
+			sum.Participants = append(sum.Participants, threadParticipant{Address: display})
+		}
+		return &sum.Participants[i]
+	}
+
+	for _, msg := range thread.Messages {
+		headers := make(map[string]string)
+		if msg.Payload != nil {
+			for _, h := range msg.Payload.Headers {
+				headers[h.Name] = h.Value
+			}
+			if msg.Payload.MimeType == "multipart/mixed" || len(listAttachments(msg.Payload)) > 0 {
+				sum.WithAttachments++
+			}
+		}
+		if sum.Subject == "" {
+			sum.Subject = headers["Subject"]
+		}
+		for _, a := range splitAddressList(headers["From"]) {
+			participant(a).Sent++
+		}
+		for _, a := range append(splitAddressList(headers["To"]), splitAddressList(headers["Cc"])...) {
+			participant(a).Received++
+		}
+
+		if msg.InternalDate > 0 {
+			t := time.UnixMilli(msg.InternalDate)
+			if sum.First.IsZero() || t.Before(sum.First) {
+				sum.First = t
+			}
+			if t.After(sum.Last) {
+				sum.Last = t
+			}
+		}
+		for _, id := range msg.LabelIds {
+			if id == "UNREAD" {
+				sum.Unread++
+			}
+			if !slices.Contains(sum.Labels, id) {
+				sum.Labels = append(sum.Labels, id)
+			}
+		}
+		sum.LatestFrom = headers["From"]
+		sum.LatestSnippet = msg.Snippet
+	}
+
+	// Stable, so participants who sent equally many keep first-seen order.
+	sort.SliceStable(sum.Participants, func(i, j int) bool {
+		return sum.Participants[i].Sent > sum.Participants[j].Sent
+	})
+	return sum
+}
+
+// splitAddressList parses an address header. Commas inside quoted display
+// names do not split; unparsable headers are split on commas, keeping each
+// entry as the address.
+func splitAddressList(header string) []*mail.Address {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	if addrs, err := mail.ParseAddressList(header); err == nil {
+		return addrs
+	}
+	var out []*mail.Address
+	for _, r := range strings.Split(header, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			out = append(out, &mail.Address{Address: r})
+		}
+	}
+	return out
+}
+
+// formatThreadSummary formats a thread summary. labels maps label IDs to
+// display names; unknown IDs are shown as-is.
+func formatThreadSummary(sum threadSummary, labels map[string]string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Thread ID: %s\nSubject: %s\nMessages: %d\n", sum.ID, sum.Subject, sum.Messages)
+	if !sum.First.IsZero() {
+		fmt.Fprintf(&sb, "Dates: %s to %s\n", sum.First.UTC().Format(time.RFC3339), sum.Last.UTC().Format(time.RFC3339))
+	}
+	if len(sum.Labels) > 0 {
+		names := make([]string, len(sum.Labels))
+		for i, id := range sum.Labels {
+			if name, ok := labels[id]; ok && name != "" {
+				names[i] = name
+			} else {
+				names[i] = id
 			}
+		}
+		fmt.Fprintf(&sb, "Labels: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&sb, "Unread: %d of %d\n", sum.Unread, sum.Messages)
+	fmt.Fprintf(&sb, "Messages with attachments: %d\n", sum.WithAttachments)
+
+	fmt.Fprintf(&sb, "\nParticipants (%d):\n", len(sum.Participants))
+	for _, p := range sum.Participants {
+		fmt.Fprintf(&sb, "  - %s: sent %d, received %d\n", p.Address, p.Sent, p.Received)
+	}
+
+	if sum.Messages > 0 {
+		fmt.Fprintf(&sb, "\nLatest message from %s:\n  %s\n", sum.LatestFrom, sum.LatestSnippet)
+	}
+	return sb.String()
+}
+
+// maxThreadBatch is the maximum number of threads modify_thread,
+// trash_thread and untrash_thread accept in one call.
+const maxThreadBatch = 100
+
+// threadSelection is the set of threads a batch thread tool acts on.
+type threadSelection struct {
+	ThreadIDs []string `json:"thread_ids,omitempty" jsonschema:"Gmail thread IDs (one or more, max 100)"`
+	ThreadID  string   `json:"thread_id,omitempty" jsonschema:"Deprecated: use thread_ids. A single Gmail thread ID."`
+}
+
+// foldDeprecated moves the deprecated thread_id into thread_ids. It is
+// called from the inputs' UnmarshalJSON so handlers only see thread_ids.
+func (s *threadSelection) foldDeprecated() {
+	if s.ThreadID != "" {
+		s.ThreadIDs = append([]string{s.ThreadID}, s.ThreadIDs...)
+		s.ThreadID = ""
+	}
+}
+
+// ids returns the selected thread IDs without duplicates, in input order.
+func (s *threadSelection) ids() ([]string, error) {
+	s.foldDeprecated()
+	seen := make(map[string]bool, len(s.ThreadIDs))
+	var ids []string
+	for _, id := range s.ThreadIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("thread_ids must contain at least one thread ID")
+	}
+	if len(ids) > maxThreadBatch {
+		return nil, fmt.Errorf("too many threads: %d given, at most %d per call", len(ids), maxThreadBatch)
+	}
+	return ids, nil
+}
+
+// threadFailure records a thread a batch operation failed on.
+type threadFailure struct {
+	ThreadID string
+	Err      error
+}
 
-			sb.WriteString("\n\n")
+// forEachThread calls fn for every thread ID, collecting failures instead of
+// stopping at the first one.
+func forEachThread(ids []string, fn func(id string) error) (succeeded []string, failed []threadFailure) {
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			failed = append(failed, threadFailure{ThreadID: id, Err: err})
+			continue
 		}
+		succeeded = append(succeeded, id)
+	}
+	return succeeded, failed
+}
 
+// threadBatchResult turns the outcome of a batch thread operation into a
+// tool result. A single thread keeps the old single-ID behavior: its
+// failure is returned as an error. For batches, failures are listed in the
+// summary and only a batch where every thread failed is an error.
+func threadBatchResult(action, detail string, ids, succeeded []string, failed []threadFailure) (*mcp.CallToolResult, any, error) {
+	if len(ids) == 1 {
+		if len(failed) == 1 {
+			return nil, nil, failed[0].Err
+		}
+		text := fmt.Sprintf("Thread %s %s.", ids[0], action)
+		if detail != "" {
+			text = fmt.Sprintf("Thread %s %s (%s).", ids[0], action, detail)
+		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: sb.String()},
+				&mcp.TextContent{Text: text},
 			},
 		}, nil, nil
-	})
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d threads %s", len(succeeded), len(ids), action)
+	if detail != "" {
+		fmt.Fprintf(&sb, " (%s)", detail)
+	}
+	sb.WriteString(".\n")
+	if len(failed) > 0 {
+		fmt.Fprintf(&sb, "\nFailed (%d):\n", len(failed))
+		for _, f := range failed {
+			fmt.Fprintf(&sb, "  - %s: %v\n", f.ThreadID, f.Err)
+		}
+	}
+	if len(succeeded) == 0 {
+		return nil, nil, fmt.Errorf("%s", strings.TrimSpace(sb.String()))
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: sb.String()},
+		},
+	}, nil, nil
 }
 
 // --- gmail_thread_modify ---
 
 type threadModifyInput struct {
-	Account      string   `json:"account" jsonschema:"Account name"`
-	ThreadID     string   `json:"thread_id" jsonschema:"Gmail thread ID to modify"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	threadSelection
 	AddLabels    []string `json:"add_labels,omitempty" jsonschema:"Label IDs to add (e.g. 'STARRED', 'IMPORTANT', 'TRASH', or custom label IDs from list_labels)"`
 	RemoveLabels []string `json:"remove_labels,omitempty" jsonschema:"Label IDs to remove (e.g. 'UNREAD', 'INBOX', 'STARRED')"`
+	labelShortcuts
+}
+
+func (in *threadModifyInput) UnmarshalJSON(data []byte) error {
+	type plain threadModifyInput
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.foldDeprecated()
+	return nil
 }
 
 func registerThreadModify(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name: "modify_thread",
 		Annotations: &mcp.ToolAnnotations{
-			IdempotentHint: true,
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
-		Description: `Modify labels on all messages in a Gmail thread. Use this to archive, trash, star, or mark entire conversations as read/unread.
+		Description: `Modify labels on all messages in one or more Gmail threads. Use this to archive, trash, star, or mark entire conversations as read/unread.
+
+Accepts up to 100 thread IDs in thread_ids. A failure on one thread doesn't stop the others; failures are listed in the result.
 
 Common operations:
-  - Archive thread: remove_labels=["INBOX"]
+  - Archive thread: archive=true (same as remove_labels=["INBOX"])
+  - Mark thread read: mark_read=true (same as remove_labels=["UNREAD"])
+  - Star thread: star=true (same as add_labels=["STARRED"])
   - Trash thread: add_labels=["TRASH"]
-  - Mark thread read: remove_labels=["UNREAD"]
-  - Star thread: add_labels=["STARRED"]
 
-Use list_labels to discover custom label IDs.`,
+Use list_labels to discover custom label IDs. SENT, DRAFT and CHAT are set by Gmail and can't be changed.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input threadModifyInput) (*mcp.CallToolResult, any, error) {
-		if len(input.AddLabels) == 0 && len(input.RemoveLabels) == 0 {
-			return nil, nil, fmt.Errorf("at least one of add_labels or remove_labels must be specified")
+		ids, err := input.ids()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(input.AddLabels) == 0 && len(input.RemoveLabels) == 0 && !input.labelShortcuts.set() {
+			return nil, nil, fmt.Errorf("at least one of add_labels, remove_labels, archive, mark_read, or star must be specified")
+		}
+		add, remove, err := expandLabelShortcuts(input.labelShortcuts, input.AddLabels, input.RemoveLabels)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkModifiableLabels(add, remove); err != nil {
+			return nil, nil, err
 		}
 
 		svc, err := newService(ctx, mgr, input.Account)
@@ -227,92 +823,121 @@ Use list_labels to discover custom label IDs.`,
 		}
 
 		modReq := &gmailapi.ModifyThreadRequest{
-			AddLabelIds:    input.AddLabels,
-			RemoveLabelIds: input.RemoveLabels,
-		}
-
-		thread, err := svc.Users.Threads.Modify("me", input.ThreadID, modReq).Do()
-		if err != nil {
-			return nil, nil, fmt.Errorf("modifying thread: %w", err)
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Thread %s modified (%d messages affected).",
-					thread.Id, len(thread.Messages))},
-			},
-		}, nil, nil
+		messages := 0
+		succeeded, failed := forEachThread(ids, func(id string) error {
+			thread, err := svc.Users.Threads.Modify("me", id, modReq).Context(ctx).Do()
+			if err != nil {
+				return gerrors.Wrap(err, "modifying thread")
+			}
+			messages += len(thread.Messages)
+			return nil
+		})
+		return threadBatchResult("modified", fmt.Sprintf("%d messages affected", messages), ids, succeeded, failed)
 	})
 }
 
 // --- gmail_trash_thread ---
 
 type trashThreadInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
-	ThreadID string `json:"thread_id" jsonschema:"Gmail thread ID to trash"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	threadSelection
+}
+
+func (in *trashThreadInput) UnmarshalJSON(data []byte) error {
+	type plain trashThreadInput
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.foldDeprecated()
+	return nil
 }
 
 func registerTrashThread(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "trash_thread",
-		Description: "Move a Gmail thread to the trash. The thread will be permanently deleted after 30 days. Use untrash_thread to restore.",
-		Annotations: &mcp.ToolAnnotations{},
+		Description: "Move one or more Gmail threads (up to 100 in thread_ids) to the trash. Trashed threads are permanently deleted after 30 days. Use untrash_thread to restore. A failure on one thread doesn't stop the others.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input trashThreadInput) (*mcp.CallToolResult, any, error) {
-		svc, err := newService(ctx, mgr, input.Account)
+		ids, err := input.ids()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+			return nil, nil, err
 		}
 
-		thread, err := svc.Users.Threads.Trash("me", input.ThreadID).Do()
+		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
-			return nil, nil, fmt.Errorf("trashing thread: %w", err)
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Thread %s moved to trash.", thread.Id)},
-			},
-		}, nil, nil
+		succeeded, failed := forEachThread(ids, func(id string) error {
+			if _, err := svc.Users.Threads.Trash("me", id).Context(ctx).Do(); err != nil {
+				return gerrors.Wrap(err, "trashing thread")
+			}
+			return nil
+		})
+		return threadBatchResult("moved to trash", "", ids, succeeded, failed)
 	})
 }
 
 // --- gmail_untrash_thread ---
 
 type untrashThreadInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
-	ThreadID string `json:"thread_id" jsonschema:"Gmail thread ID to restore from trash"`
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	threadSelection
+}
+
+func (in *untrashThreadInput) UnmarshalJSON(data []byte) error {
+	type plain untrashThreadInput
+	if err := json.Unmarshal(data, (*plain)(in)); err != nil {
+		return err
+	}
+	in.foldDeprecated()
+	return nil
 }
 
 func registerUntrashThread(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "untrash_thread",
-		Description: "Restore a Gmail thread from the trash back to the inbox.",
+		Description: "Restore one or more Gmail threads (up to 100 in thread_ids) from the trash back to the inbox. A failure on one thread doesn't stop the others.",
 		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
 			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input untrashThreadInput) (*mcp.CallToolResult, any, error) {
-		svc, err := newService(ctx, mgr, input.Account)
+		ids, err := input.ids()
 		if err != nil {
-			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+			return nil, nil, err
 		}
 
-		thread, err := svc.Users.Threads.Untrash("me", input.ThreadID).Do()
+		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
-			return nil, nil, fmt.Errorf("untrashing thread: %w", err)
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: fmt.Sprintf("Thread %s restored from trash.", thread.Id)},
-			},
-		}, nil, nil
+		succeeded, failed := forEachThread(ids, func(id string) error {
+			if _, err := svc.Users.Threads.Untrash("me", id).Context(ctx).Do(); err != nil {
+				return gerrors.Wrap(err, "untrashing thread")
+			}
+			return nil
+		})
+		return threadBatchResult("restored from trash", "", ids, succeeded, failed)
 	})
 }
 
 // --- delete_thread ---
 
 type deleteThreadInput struct {
-	Account  string `json:"account" jsonschema:"Account name"`
+	Account  string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
 	ThreadID string `json:"thread_id" jsonschema:"Gmail thread ID to permanently delete"`
 }
 
@@ -320,15 +945,20 @@ func registerDeleteThread(srv *server.Server, mgr *auth.Manager) {
 	server.AddTool(srv, &mcp.Tool{
 		Name:        "delete_thread",
 		Description: "Permanently delete a Gmail thread and all its messages. This action bypasses the trash and is irreversible. The thread cannot be recovered.",
-		Annotations: &mcp.ToolAnnotations{},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input deleteThreadInput) (*mcp.CallToolResult, any, error) {
 		svc, err := newService(ctx, mgr, input.Account)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
 		}
 
-		if err := svc.Users.Threads.Delete("me", input.ThreadID).Do(); err != nil {
-			return nil, nil, fmt.Errorf("deleting thread: %w", err)
+		if err := svc.Users.Threads.Delete("me", input.ThreadID).Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "deleting thread")
 		}
 
 		return &mcp.CallToolResult{
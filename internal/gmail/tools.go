@@ -11,41 +11,71 @@ import (
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/people/v1"
 )
 
 // Scopes required by the Gmail tools.
 // MailGoogleComScope grants full access to the mailbox including permanent
 // deletion, settings, send, and all read/write operations. It is a superset
 // of GmailModifyScope, GmailSendScope, and GmailSettingsBasicScope.
-// GmailSettingsBasicScope is added explicitly because the Filters API
-// requires it even when MailGoogleComScope is present.
+// GmailSettingsBasicScope is added explicitly because the Filters API and
+// the forwarding/IMAP/POP settings require it even when MailGoogleComScope
+// is present.
 // DriveScope is included for bridge tools (save_attachment_to_drive,
 // get_drive_file_content) that transfer data between Gmail and Drive
 // server-side.
+// ContactsReadonlyScope is used to expand contact groups into recipients
+// (to_group when composing).
 var Scopes = []string{
 	gmail.MailGoogleComScope,
 	gmail.GmailSettingsBasicScope,
 	drive.DriveScope,
+	people.ContactsReadonlyScope,
+}
+
+// ReadonlyScopes is the scope set used with --readonly-scopes. The read-only
+// tools only read the mailbox and its settings.
+var ReadonlyScopes = []string{
+	gmail.GmailReadonlyScope,
 }
 
 // RegisterTools registers all Gmail MCP tools on the given server.
-func RegisterTools(srv *server.Server, mgr *auth.Manager) {
+func RegisterTools(srv *server.Server, mgr *auth.Manager, opts ...Option) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	srv.SetAccountNames(mgr.AccountNames())
 	server.RegisterAccountsListTool(srv, mgr)
+	server.RegisterAccountsResource(srv, mgr)
+	// prompts.go
+	registerPrompts(srv)
+	server.RegisterMutationsTool(srv)
 	server.RegisterLocalFSTools(srv)
 	// profile.go
 	registerGetProfile(srv, mgr)
 	// messages.go
 	registerSearch(srv, mgr)
 	registerRead(srv, mgr)
-	registerSend(srv, mgr)
+	registerSend(srv, mgr, &o)
+	// preview.go
+	registerPreviewMessage(srv, mgr, &o)
 	registerModify(srv, mgr)
 	registerDeleteMessage(srv, mgr)
 	registerTrashMessage(srv, mgr)
 	registerUntrashMessage(srv, mgr)
 	registerBatchDeleteMessages(srv, mgr)
+	// authentication.go
+	registerCheckAuthentication(srv, mgr)
+	// spam.go
+	registerListSpam(srv, mgr)
+	registerNotSpam(srv, mgr)
+	registerReportSpam(srv, mgr)
+	registerDeleteMessagesInTrash(srv, mgr)
 	// threads.go
 	registerListThreads(srv, mgr)
 	registerReadThread(srv, mgr)
+	registerReplyToThread(srv, mgr, &o)
 	registerThreadModify(srv, mgr)
 	registerTrashThread(srv, mgr)
 	registerUntrashThread(srv, mgr)
@@ -56,15 +86,17 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerCreateLabel(srv, mgr)
 	registerDeleteLabel(srv, mgr)
 	registerUpdateLabel(srv, mgr)
+	registerRenameLabelTree(srv, mgr)
 	// attachments.go
 	registerGetAttachment(srv, mgr)
+	registerSearchAttachments(srv, mgr)
 	// drafts.go
-	registerDraftCreate(srv, mgr)
+	registerDraftCreate(srv, mgr, &o)
 	registerDraftList(srv, mgr)
 	registerDraftGet(srv, mgr)
-	registerDraftUpdate(srv, mgr)
+	registerDraftUpdate(srv, mgr, &o)
 	registerDraftDelete(srv, mgr)
-	registerDraftSend(srv, mgr)
+	registerDraftSend(srv, mgr, &o)
 	// history.go
 	registerListHistory(srv, mgr)
 	// settings.go
@@ -73,13 +105,32 @@ func RegisterTools(srv *server.Server, mgr *auth.Manager) {
 	registerListFilters(srv, mgr)
 	registerCreateFilter(srv, mgr)
 	registerDeleteFilter(srv, mgr)
+	registerExportFilters(srv, mgr)
+	registerImportFilters(srv, mgr)
 	registerListSendAs(srv, mgr)
+	registerGetAutoForwarding(srv, mgr)
+	registerListForwardingAddresses(srv, mgr)
+	registerGetIMAP(srv, mgr)
+	registerGetPOP(srv, mgr)
+	// mbox.go
+	registerExportMbox(srv, mgr)
+	// rules.go
+	registerApplyRules(srv, mgr)
+	// snooze.go
+	registerSnoozeMessage(srv, mgr)
+	registerListSnoozed(srv, mgr)
+	registerUnsnooze(srv, mgr)
+	// watch.go
+	registerWatchMailbox(srv, mgr)
+	registerStopWatch(srv, mgr)
 	// bridge.go
 	registerSaveAttachmentToDrive(srv, mgr)
+	registerExportThreadToDrive(srv, mgr)
+	registerForwardAttachment(srv, mgr, &o)
 }
 
 func newService(ctx context.Context, mgr *auth.Manager, account string) (*gmail.Service, error) {
-	opt, err := mgr.ClientOption(ctx, account, Scopes)
+	opt, err := mgr.ClientOption(ctx, auth.ResolveAccount(ctx, account), Scopes, ReadonlyScopes)
 	if err != nil {
 		return nil, err
 	}
@@ -148,3 +199,8 @@ func mime2047Encode(s string) string {
 func AccountScopes() []string {
 	return Scopes
 }
+
+// AccountReadonlyScopes returns the scopes used by the read-only Gmail tools.
+func AccountReadonlyScopes() []string {
+	return ReadonlyScopes
+}
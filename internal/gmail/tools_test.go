@@ -1,19 +1,37 @@
 package gmail
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/bridge"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
 	"github.com/thegrumpylion/google-mcp/internal/server"
 	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/people/v1"
 )
 
 // connect creates an in-memory client session connected to the given server.
@@ -95,7 +113,9 @@ func TestToolNames(t *testing.T) {
 	got := listToolNames(t, server)
 
 	want := []string{
+		"apply_rules",
 		"batch_delete_messages",
+		"check_authentication",
 		"create_draft",
 		"create_filter",
 		"create_label",
@@ -103,34 +123,57 @@ func TestToolNames(t *testing.T) {
 		"delete_filter",
 		"delete_label",
 		"delete_message",
+		"delete_messages_in_trash",
 		"delete_thread",
+		"export_filters",
+		"export_mbox",
+		"export_thread_to_drive",
+		"forward_attachment",
 		"get_attachment",
+		"get_auto_forwarding",
 		"get_draft",
+		"get_imap",
 		"get_label",
+		"get_pop",
 		"get_profile",
 		"get_vacation",
+		"import_filters",
 		"list_accounts",
 		"list_drafts",
 		"list_filters",
+		"list_forwarding_addresses",
 		"list_history",
 		"list_labels",
+		"list_recent_mutations",
 		"list_send_as",
+		"list_snoozed",
+		"list_spam",
 		"list_threads",
 		"modify_messages",
 		"modify_thread",
+		"not_spam",
+		"preview_message",
 		"read_message",
 		"read_thread",
+		"rename_label_tree",
+		"reply_to_thread",
+		"report_spam",
 		"save_attachment_to_drive",
+		"search_attachments",
 		"search_messages",
 		"send_draft",
 		"send_message",
+		"snooze_message",
+		"stop_watch",
 		"trash_message",
 		"trash_thread",
+		"unsnooze",
 		"untrash_message",
 		"untrash_thread",
 		"update_draft",
 		"update_label",
 		"update_vacation",
+		"watch_mailbox",
 	}
 
 	if len(got) != len(want) {
@@ -157,7 +200,10 @@ func TestToolAnnotations(t *testing.T) {
 		"list_accounts", "search_messages", "read_message", "read_thread",
 		"list_labels", "get_attachment", "list_drafts", "get_draft",
 		"get_profile", "get_label", "get_vacation", "list_threads",
-		"list_history", "list_filters", "list_send_as",
+		"list_history", "list_filters", "list_send_as", "list_snoozed",
+		"get_auto_forwarding", "list_forwarding_addresses", "get_imap", "get_pop",
+		"export_filters", "list_spam", "list_recent_mutations", "preview_message",
+		"search_attachments", "check_authentication",
 	}
 	for _, name := range readOnly {
 		tool := toolMap[name]
@@ -177,7 +223,10 @@ func TestToolAnnotations(t *testing.T) {
 		"trash_thread", "untrash_thread", "delete_thread",
 		"trash_message", "untrash_message", "batch_delete_messages",
 		"update_vacation", "create_filter", "delete_filter",
-		"save_attachment_to_drive",
+		"save_attachment_to_drive", "snooze_message", "unsnooze", "forward_attachment",
+		"watch_mailbox", "stop_watch",
+		"import_filters", "apply_rules", "not_spam", "report_spam", "delete_messages_in_trash",
+		"reply_to_thread", "rename_label_tree", "export_thread_to_drive", "export_mbox",
 	}
 	for _, name := range mutations {
 		tool := toolMap[name]
@@ -406,6 +455,118 @@ func TestListAttachments_NoAttachments(t *testing.T) {
 	}
 }
 
+// inlineImageMessage is a payload as Gmail returns it for an HTML message
+// with embedded images: mixed > related > alternative, plus a PDF.
+func inlineImageMessage() *gmailapi.MessagePart {
+	hdr := func(kv ...string) []*gmailapi.MessagePartHeader {
+		var hs []*gmailapi.MessagePartHeader
+		for i := 0; i < len(kv); i += 2 {
+			hs = append(hs, &gmailapi.MessagePartHeader{Name: kv[i], Value: kv[i+1]})
+		}
+		return hs
+	}
+	return &gmailapi.MessagePart{
+		MimeType: "multipart/mixed",
+		Parts: []*gmailapi.MessagePart{
+			{
+				MimeType: "multipart/related",
+				Parts: []*gmailapi.MessagePart{
+					{
+						MimeType: "multipart/alternative",
+						Parts: []*gmailapi.MessagePart{
+							{MimeType: "text/plain", Body: &gmailapi.MessagePartBody{Data: "aGVsbG8="}},
+							// Large HTML bodies are stored as attachments but
+							// are not attachments.
+							{MimeType: "text/html", Body: &gmailapi.MessagePartBody{AttachmentId: "body-html", Size: 90000}},
+						},
+					},
+					{
+						MimeType: "image/png",
+						Headers:  hdr("Content-Type", "image/png", "Content-ID", "<logo@example.com>"),
+						Body:     &gmailapi.MessagePartBody{AttachmentId: "att-logo", Size: 4000},
+					},
+					{
+						MimeType: "image/jpeg",
+						Filename: "image001.jpg",
+						Headers:  hdr("Content-Disposition", `inline; filename="image001.jpg"`, "content-id", "<image001.jpg@01DA2B3C>"),
+						Body:     &gmailapi.MessagePartBody{AttachmentId: "att-photo", Size: 50000},
+					},
+					{
+						MimeType: "image/gif",
+						Headers:  hdr("Content-Disposition", "inline"),
+						Body:     &gmailapi.MessagePartBody{AttachmentId: "att-gif", Size: 300},
+					},
+				},
+			},
+			{
+				MimeType: "application/pdf",
+				Filename: "invoice.pdf",
+				Headers:  hdr("Content-Disposition", `attachment; filename="invoice.pdf"`),
+				Body:     &gmailapi.MessagePartBody{AttachmentId: "att-pdf", Size: 1024},
+			},
+		},
+	}
+}
+
+func TestListAttachments_InlineImages(t *testing.T) {
+	got := listAttachments(inlineImageMessage())
+	want := []attachmentInfo{
+		{filename: "inline-1.png", mimeType: "image/png", size: 4000, attachmentID: "att-logo", contentID: "logo@example.com", inline: true},
+		{filename: "image001.jpg", mimeType: "image/jpeg", size: 50000, attachmentID: "att-photo", contentID: "image001.jpg@01DA2B3C", inline: true},
+		{filename: "inline-2.gif", mimeType: "image/gif", size: 300, attachmentID: "att-gif", inline: true},
+		{filename: "invoice.pdf", mimeType: "application/pdf", size: 1024, attachmentID: "att-pdf"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("listAttachments() = %+v, want %d entries", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("attachment %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatAttachmentList_Inline(t *testing.T) {
+	got := formatAttachmentList(listAttachments(inlineImageMessage()))
+	want := `Attachments:
+  - invoice.pdf (MIME: application/pdf, Size: 1024 bytes, Attachment ID: att-pdf)
+
+Contains 3 inline images:
+  - inline-1.png (MIME: image/png, Size: 4000 bytes, Content-ID: logo@example.com, Attachment ID: att-logo)
+  - image001.jpg (MIME: image/jpeg, Size: 50000 bytes, Content-ID: image001.jpg@01DA2B3C, Attachment ID: att-photo)
+  - inline-2.gif (MIME: image/gif, Size: 300 bytes, Attachment ID: att-gif)
+`
+	if got != want {
+		t.Errorf("formatAttachmentList() =\n%s\nwant:\n%s", got, want)
+	}
+	if got := formatAttachmentList(nil); got != "" {
+		t.Errorf("formatAttachmentList(nil) = %q, want empty", got)
+	}
+}
+
+func TestFindAttachmentByContentID(t *testing.T) {
+	atts := listAttachments(inlineImageMessage())
+	for _, ref := range []string{"logo@example.com", "<logo@example.com>", "cid:logo@example.com", "CID:logo@example.com"} {
+		got, err := findAttachmentByContentID(atts, ref)
+		if err != nil || got.attachmentID != "att-logo" {
+			t.Errorf("findAttachmentByContentID(%q) = %+v, %v", ref, got, err)
+		}
+	}
+	if _, err := findAttachmentByContentID(atts, "missing@example.com"); err == nil || !strings.Contains(err.Error(), "available: logo@example.com, image001.jpg@01DA2B3C") {
+		t.Errorf("missing Content-ID error = %v", err)
+	}
+	if _, err := findAttachmentByContentID(nil, "x"); err == nil {
+		t.Error("message without Content-IDs should fail")
+	}
+}
+
+func TestSelectAttachment_IgnoresInlineForSingle(t *testing.T) {
+	got, err := selectAttachment(listAttachments(inlineImageMessage()), "", "")
+	if err != nil || got.attachmentID != "att-pdf" {
+		t.Errorf("selectAttachment() = %+v, %v, want invoice.pdf", got, err)
+	}
+}
+
 func TestAccountScopes(t *testing.T) {
 	scopes := AccountScopes()
 	if len(scopes) == 0 {
@@ -462,6 +623,124 @@ func TestBuildPlainMessage_WithReplyHeaders(t *testing.T) {
 	}
 }
 
+// longRefs returns a References value with n message IDs <r00@example.com>,
+// <r01@example.com>, ...
+func longRefs(n int) string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("<r%02d@example.com>", i)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestReferenceHeaders(t *testing.T) {
+	msg := func(kv ...string) *gmailapi.Message {
+		m := &gmailapi.Message{Id: "m2", ThreadId: "t1", Payload: &gmailapi.MessagePart{}}
+		for i := 0; i < len(kv); i += 2 {
+			m.Payload.Headers = append(m.Payload.Headers, &gmailapi.MessagePartHeader{Name: kv[i], Value: kv[i+1]})
+		}
+		return m
+	}
+	for _, tc := range []struct {
+		name string
+		orig *gmailapi.Message
+		want string
+	}{
+		{
+			name: "first reply",
+			orig: msg("Message-Id", "<b@example.com>"),
+			want: "In-Reply-To: <b@example.com>\r\nReferences: <b@example.com>\r\n",
+		},
+		{
+			name: "accumulates references",
+			orig: msg("References", "<root@example.com>\r\n <a@example.com>", "Message-ID", "<b@example.com>"),
+			want: "In-Reply-To: <b@example.com>\r\nReferences: <root@example.com> <a@example.com> <b@example.com>\r\n",
+		},
+		{
+			name: "long chain",
+			orig: msg("References", longRefs(25), "Message-Id", "<b@example.com>"),
+			want: "In-Reply-To: <b@example.com>\r\nReferences: <r00@example.com> <r07@example.com> <r08@example.com>\r\n" +
+				" <r09@example.com> <r10@example.com> <r11@example.com> <r12@example.com>\r\n" +
+				" <r13@example.com> <r14@example.com> <r15@example.com> <r16@example.com>\r\n" +
+				" <r17@example.com> <r18@example.com> <r19@example.com> <r20@example.com>\r\n" +
+				" <r21@example.com> <r22@example.com> <r23@example.com> <r24@example.com>\r\n" +
+				" <b@example.com>\r\n",
+		},
+		{
+			name: "message id already referenced",
+			orig: msg("Message-Id", "<b@example.com>", "References", "<a@example.com> <b@example.com>"),
+			want: "In-Reply-To: <b@example.com>\r\nReferences: <a@example.com> <b@example.com>\r\n",
+		},
+		{
+			name: "no message id",
+			orig: msg("References", "<a@example.com>"),
+			want: "",
+		},
+		{
+			name: "no payload",
+			orig: &gmailapi.Message{Id: "m2"},
+			want: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := referenceHeaders(tc.orig); got != tc.want {
+				t.Errorf("referenceHeaders = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildMessage_ReplyUsesThreadID(t *testing.T) {
+	var query url.Values
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"id": "m2", "threadId": "t1", "payload": {"headers": [
+			{"name": "Message-ID", "value": "<b@example.com>"},
+			{"name": "References", "value": "<a@example.com>"}
+		]}}`))
+	})
+	result, err := buildMessage(context.Background(), svc, "me", composeInput{To: "bob@example.com", Subject: "Re: Plan", Body: "ok"}, "m2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ThreadID != "t1" {
+		t.Errorf("ThreadID = %q, want the original's thread t1, not its message ID", result.ThreadID)
+	}
+	if got := query["metadataHeaders"]; !slices.Contains(got, "References") {
+		t.Errorf("metadataHeaders = %v, want References fetched", got)
+	}
+	raw, _ := base64.URLEncoding.DecodeString(result.Raw)
+	if !strings.Contains(string(raw), "References: <a@example.com> <b@example.com>\r\n") {
+		t.Errorf("raw message lacks the accumulated References:\n%s", raw)
+	}
+}
+
+func TestMessageExists(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("format") != "minimal" || q.Get("fields") != "id" {
+			t.Errorf("query = %v, want format=minimal and fields=id", q)
+		}
+		switch path.Base(r.URL.Path) {
+		case "here":
+			w.Write([]byte(`{"id": "here"}`))
+		case "elsewhere":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "Requested entity was not found."}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	for id, want := range map[string]bool{"here": true, "elsewhere": false} {
+		found, err := messageExists(context.Background(), svc, id)
+		if err != nil || found != want {
+			t.Errorf("messageExists(%s) = %v, %v; want %v", id, found, err, want)
+		}
+	}
+	if _, err := messageExists(context.Background(), svc, "broken"); err == nil {
+		t.Error("messageExists(broken) succeeded, want the server error")
+	}
+}
+
 func TestBuildMultipartMessage(t *testing.T) {
 	pdfContent := base64.StdEncoding.EncodeToString([]byte("fake pdf content"))
 	input := composeInput{
@@ -681,7 +960,7 @@ func TestBuildMessage_AttachmentValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// buildMessage requires a gmail service for reply-to, but nil is fine
 			// when replyToMsgID is empty and we expect validation to fail first.
-			_, err := buildMessage(nil, tt.input, "")
+			_, err := buildMessage(context.Background(), nil, "me", tt.input, "", nil)
 			if err == nil {
 				t.Fatal("expected error, got nil")
 			}
@@ -698,7 +977,7 @@ func TestBuildMessage_NoAttachments_PlainOutput(t *testing.T) {
 		Subject: "Plain",
 		Body:    "No attachments here.",
 	}
-	result, err := buildMessage(nil, input, "")
+	result, err := buildMessage(context.Background(), nil, "me", input, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -727,7 +1006,7 @@ func TestBuildMessage_WithAttachments_MultipartOutput(t *testing.T) {
 			{Name: "test.txt", MIMEType: "text/plain", Content: content},
 		},
 	}
-	result, err := buildMessage(nil, input, "")
+	result, err := buildMessage(context.Background(), nil, "me", input, "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -767,9 +1046,9 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 
 	got := listToolNames(t, srv)
 
-	// Should include all 36 base tools + 2 localfs tools = 38.
-	if len(got) != 38 {
-		t.Fatalf("got %d tools, want 38\ngot: %v", len(got), got)
+	// Should include all 61 base tools + 3 localfs tools = 64.
+	if len(got) != 64 {
+		t.Fatalf("got %d tools, want 64\ngot: %v", len(got), got)
 	}
 
 	// Verify the localfs tools are present.
@@ -783,4 +1062,3753 @@ func TestToolNames_WithLocalFS(t *testing.T) {
 	if !names["read_local_file"] {
 		t.Error("expected read_local_file tool")
 	}
+	if !names["stat_local_file"] {
+		t.Error("expected stat_local_file tool")
+	}
+}
+
+func TestFormatSearchResult(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id:           "msg-1",
+		LabelIds:     []string{"INBOX", "UNREAD", "Label_7", "Label_missing"},
+		Snippet:      "See attached",
+		SizeEstimate: 20480,
+		Payload: &gmailapi.MessagePart{
+			MimeType: "multipart/mixed",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "From", Value: "bob@example.com"},
+				{Name: "Subject", Value: "Report"},
+				{Name: "Date", Value: "Mon, 15 Jan 2024 10:00:00 +0000"},
+			},
+		},
+	}
+	labels := map[string]string{"INBOX": "INBOX", "UNREAD": "UNREAD", "Label_7": "Work/Reports"}
+
+	result := formatSearchResult(msg, "work", labels)
+	for _, want := range []string{
+		"Message ID: msg-1",
+		"Account: work",
+		"From: bob@example.com",
+		"Subject: Report",
+		"Labels: INBOX, UNREAD, Work/Reports, Label_missing",
+		"Unread: yes",
+		"Size: 20480 bytes",
+		"Has attachments: yes",
+		"Snippet: See attached",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatSearchResult_ReadNoAttachments(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id:       "msg-2",
+		LabelIds: []string{"INBOX"},
+		Payload:  &gmailapi.MessagePart{MimeType: "multipart/alternative"},
+	}
+
+	result := formatSearchResult(msg, "personal", nil)
+	if !strings.Contains(result, "Unread: no") {
+		t.Errorf("result should report Unread: no:\n%s", result)
+	}
+	if strings.Contains(result, "Has attachments") {
+		t.Error("multipart/alternative should not report attachments")
+	}
+	if !strings.Contains(result, "Labels: INBOX") {
+		t.Error("label IDs should be shown when no label map is available")
+	}
+}
+
+func TestSearchResultFields(t *testing.T) {
+	for _, want := range []string{"labelIds", "sizeEstimate", "snippet", "payload(mimeType,headers)"} {
+		if !strings.Contains(searchResultFields, want) {
+			t.Errorf("searchResultFields missing %q", want)
+		}
+	}
+}
+
+func TestSnoozeStore_AddListRemove(t *testing.T) {
+	store := newSnoozeStore(newTestManager(t))
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	if entries, err := store.list(); err != nil || len(entries) != 0 {
+		t.Fatalf("empty store: list() = %v, %v", entries, err)
+	}
+
+	for _, e := range []snoozeEntry{
+		{Account: "personal", MessageID: "m2", WakeAt: now.Add(2 * time.Hour)},
+		{Account: "personal", MessageID: "m1", WakeAt: now.Add(time.Hour)},
+		{Account: "work", MessageID: "m3", WakeAt: now.Add(3 * time.Hour)},
+		// Re-snoozing replaces the earlier entry.
+		{Account: "personal", MessageID: "m2", WakeAt: now.Add(4 * time.Hour)},
+	} {
+		if err := store.add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := store.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.MessageID)
+	}
+	if got := strings.Join(ids, ","); got != "m1,m3,m2" {
+		t.Errorf("list() order = %s, want m1,m3,m2", got)
+	}
+
+	found, err := store.remove("personal", "m1")
+	if err != nil || !found {
+		t.Fatalf("remove(m1) = %v, %v; want true, nil", found, err)
+	}
+	found, err = store.remove("work", "m1")
+	if err != nil || found {
+		t.Errorf("remove of absent snooze = %v, %v; want false, nil", found, err)
+	}
+	if entries, _ := store.list(); len(entries) != 2 {
+		t.Errorf("after remove: %d entries, want 2", len(entries))
+	}
+}
+
+func TestSnoozeStore_WakeDueAfterRestart(t *testing.T) {
+	mgr := newTestManager(t)
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	// Snoozes recorded by a previous server process.
+	first := newSnoozeStore(mgr)
+	for _, e := range []snoozeEntry{
+		{Account: "personal", MessageID: "overdue", WakeAt: now.Add(-time.Hour)},
+		{Account: "personal", MessageID: "due", WakeAt: now},
+		{Account: "personal", MessageID: "gone", WakeAt: now.Add(-time.Minute)},
+		{Account: "personal", MessageID: "flaky", WakeAt: now.Add(-time.Minute)},
+		{Account: "personal", MessageID: "later", WakeAt: now.Add(time.Hour)},
+	} {
+		if err := first.add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A fresh store stands in for the restarted server.
+	var woke []string
+	wake := func(ctx context.Context, account, messageID string) error {
+		switch messageID {
+		case "gone":
+			return &googleapi.Error{Code: http.StatusNotFound}
+		case "flaky":
+			return &googleapi.Error{Code: http.StatusServiceUnavailable, Message: "backend error"}
+		}
+		woke = append(woke, messageID)
+		return nil
+	}
+	woken, err := newSnoozeStore(mgr).wakeDue(context.Background(), now, wake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(woken) != 2 || strings.Join(woke, ",") != "overdue,due" {
+		t.Errorf("woke %v, want overdue,due", woke)
+	}
+
+	entries, err := newSnoozeStore(mgr).list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].MessageID != "flaky" || entries[1].MessageID != "later" {
+		t.Fatalf("remaining entries = %+v, want flaky (retry) and later", entries)
+	}
+	if !strings.Contains(entries[0].LastError, "backend error") {
+		t.Errorf("flaky LastError = %q, want recorded error", entries[0].LastError)
+	}
+}
+
+func TestFormatSnoozed(t *testing.T) {
+	wake := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	entries := []snoozeEntry{
+		{Account: "personal", MessageID: "m1", WakeAt: wake},
+		{Account: "work", MessageID: "m2", WakeAt: wake},
+	}
+
+	got := formatSnoozed(entries, []string{"work"})
+	if !strings.Contains(got, "m2") || strings.Contains(got, "m1") {
+		t.Errorf("formatSnoozed filtered by account:\n%s", got)
+	}
+	if !strings.Contains(got, "2024-01-15T09:00:00Z") {
+		t.Errorf("formatSnoozed should show wake time:\n%s", got)
+	}
+	if !strings.Contains(got, "emulated") {
+		t.Errorf("formatSnoozed should include the emulation caveat:\n%s", got)
+	}
+
+	if got := formatSnoozed(entries, []string{"other"}); got != "No snoozed messages." {
+		t.Errorf("formatSnoozed with no matches = %q", got)
+	}
+}
+
+func TestMergeVacation_PartialKeepsSchedule(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	end := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC).UnixMilli()
+	current := &gmailapi.VacationSettings{
+		EnableAutoReply:       true,
+		ResponseSubject:       "Out of office",
+		ResponseBodyPlainText: "Back soon",
+		StartTime:             start,
+		EndTime:               end,
+	}
+
+	if err := mergeVacation(current, updateVacationInput{ResponseBodyHTML: "<p>Back soon</p>"}); err != nil {
+		t.Fatal(err)
+	}
+	if current.StartTime != start || current.EndTime != end {
+		t.Errorf("schedule changed: %d-%d, want %d-%d", current.StartTime, current.EndTime, start, end)
+	}
+	if current.ResponseSubject != "Out of office" || current.ResponseBodyPlainText != "Back soon" {
+		t.Errorf("text fields changed: %q / %q", current.ResponseSubject, current.ResponseBodyPlainText)
+	}
+	if current.ResponseBodyHtml != "<p>Back soon</p>" {
+		t.Errorf("ResponseBodyHtml = %q", current.ResponseBodyHtml)
+	}
+	if !current.EnableAutoReply {
+		t.Error("EnableAutoReply changed")
+	}
+}
+
+func TestMergeVacation_Clear(t *testing.T) {
+	current := &gmailapi.VacationSettings{
+		ResponseSubject:       "Out of office",
+		ResponseBodyPlainText: "Back soon",
+		ResponseBodyHtml:      "<p>Back soon</p>",
+	}
+
+	if err := mergeVacation(current, updateVacationInput{ClearSubject: true, ClearBody: true}); err != nil {
+		t.Fatal(err)
+	}
+	if current.ResponseSubject != "" || current.ResponseBodyPlainText != "" || current.ResponseBodyHtml != "" {
+		t.Errorf("fields not cleared: %+v", current)
+	}
+
+	// The cleared fields must be sent explicitly, or the API keeps them.
+	data, err := current.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"responseSubject":""`, `"responseBodyPlainText":""`, `"responseBodyHtml":""`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("request body %s missing %s", data, field)
+		}
+	}
+}
+
+func TestMergeVacation_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   updateVacationInput
+		wantErr string
+	}{
+		{"start after end", updateVacationInput{StartTime: "2026-03-15T00:00:00Z", EndTime: "2026-03-01T00:00:00Z"}, "must be before"},
+		{"start equals end", updateVacationInput{StartTime: "2026-03-01T00:00:00Z", EndTime: "2026-03-01T00:00:00Z"}, "must be before"},
+		{"bad start", updateVacationInput{StartTime: "tomorrow"}, "parsing start_time"},
+		{"clear and set subject", updateVacationInput{ClearSubject: true, ResponseSubject: "x"}, "clear_subject"},
+		{"clear and set body", updateVacationInput{ClearBody: true, ResponseBodyHTML: "<p>x</p>"}, "clear_body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &gmailapi.VacationSettings{ResponseSubject: "keep"}
+			err := mergeVacation(current, tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+			if current.ResponseSubject != "keep" {
+				t.Error("settings modified despite validation error")
+			}
+		})
+	}
+}
+
+func TestFormatVacation_Schedule(t *testing.T) {
+	settings := &gmailapi.VacationSettings{
+		EnableAutoReply: true,
+		StartTime:       time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		EndTime:         time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC).UnixMilli(),
+	}
+	got := formatVacation(settings)
+	for _, want := range []string{"Auto-reply: enabled", "Start: 2026-03-01T00:00:00Z", "End: 2026-03-15T00:00:00Z"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatVacation missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := formatVacation(&gmailapi.VacationSettings{}); !strings.Contains(got, "Schedule: none") {
+		t.Errorf("formatVacation without schedule:\n%s", got)
+	}
+}
+
+// newFakeService returns a Gmail service that sends all requests to handler.
+func newFakeService(t *testing.T, handler http.HandlerFunc) *gmailapi.Service {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	svc, err := gmailapi.NewService(context.Background(),
+		option.WithHTTPClient(ts.Client()),
+		option.WithEndpoint(ts.URL+"/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+var testSendAs = []*gmailapi.SendAs{
+	{SendAsEmail: "alice@example.com", DisplayName: "Alice", IsPrimary: true},
+	{SendAsEmail: "support@example.com", DisplayName: "Example Support", VerificationStatus: "accepted"},
+	{SendAsEmail: "alias@other.example", VerificationStatus: "pending"},
+}
+
+func TestResolveFromAlias(t *testing.T) {
+	tests := []struct {
+		from    string
+		want    string
+		wantErr string
+	}{
+		{from: "alice@example.com", want: `"Alice" <alice@example.com>`},
+		{from: "Support@Example.com", want: `"Example Support" <support@example.com>`},
+		{from: "Someone <support@example.com>", want: `"Example Support" <support@example.com>`},
+		{from: "alias@other.example", wantErr: "not verified (status: pending)"},
+		{from: "nobody@example.com", wantErr: "not a send-as alias"},
+	}
+	for _, tt := range tests {
+		got, err := resolveFromAlias(testSendAs, tt.from)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolveFromAlias(%q) error = %v, want containing %q", tt.from, err, tt.wantErr)
+				continue
+			}
+			if !strings.Contains(err.Error(), "verified aliases: alice@example.com, support@example.com") {
+				t.Errorf("resolveFromAlias(%q) error %q should list the valid aliases", tt.from, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("resolveFromAlias(%q) error: %v", tt.from, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveFromAlias(%q) = %q, want %q", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestBuildMessage_From(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/settings/sendAs") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&gmailapi.ListSendAsResponse{SendAs: testSendAs})
+	})
+
+	result, err := buildMessage(context.Background(), svc, "me", composeInput{
+		From:    "support@example.com",
+		To:      "bob@example.com",
+		Subject: "Hi",
+		Body:    "Hello",
+	}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.URLEncoding.DecodeString(result.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(raw), "From: \"Example Support\" <support@example.com>\r\nTo: bob@example.com\r\n") {
+		t.Errorf("message headers:\n%s", raw)
+	}
+
+	if _, err := buildMessage(context.Background(), svc, "me", composeInput{From: "alias@other.example", To: "bob@example.com"}, "", nil); err == nil {
+		t.Error("buildMessage with unverified alias should fail")
+	}
+}
+
+func TestSelectAttachment(t *testing.T) {
+	atts := []attachmentInfo{
+		{filename: "report.pdf", attachmentID: "a1"},
+		{filename: "photo.jpg", attachmentID: "a2"},
+	}
+	tests := []struct {
+		name, id, filename string
+		wantID             string
+		wantErr            string
+	}{
+		{name: "by id", id: "a2", wantID: "a2"},
+		{name: "by filename", filename: "REPORT.pdf", wantID: "a1"},
+		{name: "stale id falls back to filename", id: "stale", filename: "photo.jpg", wantID: "a2"},
+		{name: "no selector", wantErr: "specify attachment_id or filename"},
+		{name: "no match", filename: "missing.txt", wantErr: "available: report.pdf, photo.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectAttachment(atts, tt.id, tt.filename)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.attachmentID != tt.wantID {
+				t.Errorf("attachmentID = %q, want %q", got.attachmentID, tt.wantID)
+			}
+		})
+	}
+
+	// A single attachment is selected without a selector, and a stale ID
+	// is kept for the download.
+	single := []attachmentInfo{{filename: "only.pdf", attachmentID: "fresh"}}
+	if got, err := selectAttachment(single, "", ""); err != nil || got.filename != "only.pdf" {
+		t.Errorf("single attachment: %+v, %v", got, err)
+	}
+	if got, err := selectAttachment(single, "stale", ""); err != nil || got.attachmentID != "stale" {
+		t.Errorf("single attachment with stale id: %+v, %v", got, err)
+	}
+	if _, err := selectAttachment(nil, "a1", ""); err == nil {
+		t.Error("message without attachments should fail")
+	}
+}
+
+func TestFetchAttachment(t *testing.T) {
+	content := []byte("%PDF-1.4 fake")
+	size := int64(len(content))
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/attachments/att-1"):
+			json.NewEncoder(w).Encode(&gmailapi.MessagePartBody{
+				Data: base64.URLEncoding.EncodeToString(content),
+				Size: size,
+			})
+		case strings.HasSuffix(r.URL.Path, "/messages/m1"):
+			json.NewEncoder(w).Encode(&gmailapi.Message{Id: "m1", Payload: &gmailapi.MessagePart{
+				MimeType: "multipart/mixed",
+				Parts: []*gmailapi.MessagePart{
+					{MimeType: "text/plain", Body: &gmailapi.MessagePartBody{Data: "aGk="}},
+					{Filename: "invoice.pdf", MimeType: "application/octet-stream", Body: &gmailapi.MessagePartBody{AttachmentId: "att-1", Size: size}},
+					{Filename: "huge.zip", MimeType: "application/zip", Body: &gmailapi.MessagePartBody{AttachmentId: "att-2", Size: maxForwardAttachmentSize + 1}},
+				},
+			}})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	att, err := fetchAttachment(context.Background(), svc, "m1", "", "invoice.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "invoice.pdf" || att.MIMEType != "application/pdf" {
+		t.Errorf("attachment = %s (%s), want invoice.pdf (application/pdf)", att.Name, att.MIMEType)
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(att.Content); string(decoded) != string(content) {
+		t.Errorf("content = %q, want %q", decoded, content)
+	}
+
+	if _, err := fetchAttachment(context.Background(), svc, "m1", "", "huge.zip"); err == nil || !strings.Contains(err.Error(), "25 MB") {
+		t.Errorf("oversized attachment: err = %v, want size limit error", err)
+	}
+}
+
+func TestFormatMailboxAccessSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"forwarding off", formatAutoForwarding(&gmailapi.AutoForwarding{}), []string{"Auto-forwarding: disabled"}},
+		{"forwarding on", formatAutoForwarding(&gmailapi.AutoForwarding{Enabled: true, EmailAddress: "x@evil.example", Disposition: "trash"}),
+			[]string{"Auto-forwarding: enabled", "Forwarding to: x@evil.example", "Disposition: trash"}},
+		{"no addresses", formatForwardingAddresses(nil), []string{"No forwarding addresses."}},
+		{"addresses", formatForwardingAddresses([]*gmailapi.ForwardingAddress{{ForwardingEmail: "a@example.com", VerificationStatus: "accepted"}}),
+			[]string{"Found 1 forwarding addresses", "a@example.com (accepted)"}},
+		{"imap off", formatIMAP(&gmailapi.ImapSettings{}), []string{"IMAP: disabled"}},
+		{"imap on", formatIMAP(&gmailapi.ImapSettings{Enabled: true, AutoExpunge: true, ExpungeBehavior: "archive"}),
+			[]string{"IMAP: enabled", "Auto-expunge: true", "Expunge behavior: archive", "no limit"}},
+		{"pop off", formatPOP(&gmailapi.PopSettings{AccessWindow: "disabled"}), []string{"POP: disabled"}},
+		{"pop on", formatPOP(&gmailapi.PopSettings{AccessWindow: "allMail", Disposition: "leaveInInbox"}),
+			[]string{"POP: enabled", "Access window: allMail", "Disposition: leaveInInbox"}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.got, want) {
+				t.Errorf("%s: output missing %q:\n%s", tt.name, want, tt.got)
+			}
+		}
+	}
+}
+
+func TestCreateFilterSchema(t *testing.T) {
+	server := newTestServer(t)
+	for _, tool := range listTools(t, server) {
+		if tool.Name != "create_filter" {
+			continue
+		}
+		data, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, prop := range []string{"account", "from", "has_attachment", "add_labels", "forward"} {
+			if !strings.Contains(string(data), fmt.Sprintf("%q", prop)) {
+				t.Errorf("create_filter schema missing %q: %s", prop, data)
+			}
+		}
+		return
+	}
+	t.Fatal("create_filter not found")
+}
+
+// fakeFilterMailbox serves the labels and filters endpoints from memory.
+type fakeFilterMailbox struct {
+	t       *testing.T
+	labels  []*gmailapi.Label
+	filters []*gmailapi.Filter
+}
+
+func (m *fakeFilterMailbox) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/labels") && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(&gmailapi.ListLabelsResponse{Labels: m.labels})
+	case strings.HasSuffix(r.URL.Path, "/labels") && r.Method == http.MethodPost:
+		var l gmailapi.Label
+		json.NewDecoder(r.Body).Decode(&l)
+		l.Id = fmt.Sprintf("Label_new%d", len(m.labels))
+		m.labels = append(m.labels, &l)
+		json.NewEncoder(w).Encode(&l)
+	case strings.HasSuffix(r.URL.Path, "/settings/filters") && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(&gmailapi.ListFiltersResponse{Filter: m.filters})
+	case strings.HasSuffix(r.URL.Path, "/settings/filters") && r.Method == http.MethodPost:
+		var f gmailapi.Filter
+		json.NewDecoder(r.Body).Decode(&f)
+		if f.Action != nil && f.Action.Forward == "unverified@example.com" {
+			http.Error(w, `{"error":{"code":400,"message":"Unverified forwarding address"}}`, http.StatusBadRequest)
+			return
+		}
+		f.Id = fmt.Sprintf("filter-%d", len(m.filters)+1)
+		m.filters = append(m.filters, &f)
+		json.NewEncoder(w).Encode(&f)
+	default:
+		m.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+func TestExportImportFilters_RoundTrip(t *testing.T) {
+	source := &fakeFilterMailbox{t: t,
+		labels: []*gmailapi.Label{
+			{Id: "INBOX", Name: "INBOX"},
+			{Id: "STARRED", Name: "STARRED"},
+			{Id: "Label_1", Name: "Work"},
+			{Id: "Label_2", Name: "Receipts"},
+		},
+		filters: []*gmailapi.Filter{
+			{Id: "f1", Criteria: &gmailapi.FilterCriteria{From: "boss@example.com"},
+				Action: &gmailapi.FilterAction{AddLabelIds: []string{"Label_1", "STARRED"}}},
+			{Id: "f2", Criteria: &gmailapi.FilterCriteria{Query: "receipt OR invoice", HasAttachment: true},
+				Action: &gmailapi.FilterAction{AddLabelIds: []string{"Label_2"}, RemoveLabelIds: []string{"INBOX"}}},
+			{Id: "f3", Criteria: &gmailapi.FilterCriteria{To: "list@example.com", Subject: "[dev]", NegatedQuery: "urgent"},
+				Action: &gmailapi.FilterAction{Forward: "me@other.example"}},
+		},
+	}
+	srcSvc := newFakeService(t, source.handle)
+
+	resp, err := srcSvc.Users.Settings.Filters.List("me").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := labelNames(context.Background(), srcSvc, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported := exportFilters(resp.Filter, names, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"add_labels":["Work","STARRED"]`) {
+		t.Errorf("export should reference labels by name: %s", data)
+	}
+
+	doc, err := parseFilterExport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target has "work" under a different ID and no "Receipts" label.
+	target := &fakeFilterMailbox{t: t,
+		labels: []*gmailapi.Label{
+			{Id: "INBOX", Name: "INBOX"},
+			{Id: "STARRED", Name: "STARRED"},
+			{Id: "Label_9", Name: "work"},
+		},
+	}
+	results, created, err := importFilters(context.Background(), newFakeService(t, target.handle), doc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("filter %d: %v", i+1, r.Err)
+		}
+	}
+	if len(created) != 1 || created[0] != "Receipts" {
+		t.Errorf("created labels = %v, want [Receipts]", created)
+	}
+	if len(target.filters) != len(source.filters) {
+		t.Fatalf("imported %d filters, want %d", len(target.filters), len(source.filters))
+	}
+
+	targetNames := make(map[string]string)
+	for _, l := range target.labels {
+		targetNames[l.Id] = l.Name
+	}
+	for i, got := range target.filters {
+		want := source.filters[i]
+		gotCriteria, _ := json.Marshal(got.Criteria)
+		wantCriteria, _ := json.Marshal(want.Criteria)
+		if string(gotCriteria) != string(wantCriteria) {
+			t.Errorf("filter %d criteria = %s, want %s", i+1, gotCriteria, wantCriteria)
+		}
+		// Label names are case-insensitive, so "Work" matches "work".
+		gotAdd := fmt.Sprint(labelRefs(got.Action.AddLabelIds, targetNames))
+		wantAdd := fmt.Sprint(labelRefs(want.Action.AddLabelIds, names))
+		if !strings.EqualFold(gotAdd, wantAdd) {
+			t.Errorf("filter %d add labels = %s, want %s", i+1, gotAdd, wantAdd)
+		}
+		if fmt.Sprint(got.Action.RemoveLabelIds) != fmt.Sprint(want.Action.RemoveLabelIds) {
+			t.Errorf("filter %d remove labels = %v, want %v", i+1, got.Action.RemoveLabelIds, want.Action.RemoveLabelIds)
+		}
+		if got.Action.Forward != want.Action.Forward {
+			t.Errorf("filter %d forward = %q, want %q", i+1, got.Action.Forward, want.Action.Forward)
+		}
+	}
+	if ids := target.filters[0].Action.AddLabelIds; ids[0] != "Label_9" {
+		t.Errorf("Work should map to existing Label_9, got %v", ids)
+	}
+}
+
+func TestImportFilters_PerFilterFailures(t *testing.T) {
+	target := &fakeFilterMailbox{t: t, labels: []*gmailapi.Label{{Id: "INBOX", Name: "INBOX"}}}
+	doc := &filterExport{Version: filterExportVersion, Filters: []filterSpec{
+		{From: "a@example.com", RemoveLabels: []string{"INBOX"}},
+		{From: "b@example.com", AddLabels: []string{"Missing"}},
+		{AddLabels: []string{"INBOX"}},
+		{From: "c@example.com", Forward: "unverified@example.com"},
+	}}
+
+	results, created, err := importFilters(context.Background(), newFakeService(t, target.handle), doc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 0 {
+		t.Errorf("no labels should be created, got %v", created)
+	}
+	wantErrs := []string{"", `label "Missing" not found`, "at least one criteria", "Unverified forwarding address"}
+	for i, want := range wantErrs {
+		got := results[i].Err
+		switch {
+		case want == "" && got != nil:
+			t.Errorf("filter %d: unexpected error %v", i+1, got)
+		case want != "" && (got == nil || !strings.Contains(got.Error(), want)):
+			t.Errorf("filter %d: error = %v, want containing %q", i+1, got, want)
+		}
+	}
+	if len(target.filters) != 1 {
+		t.Errorf("created %d filters, want 1", len(target.filters))
+	}
+
+	out := formatFilterImport(results, created)
+	for _, s := range []string{
+		"Imported 1 of 4 filters.",
+		`1. from="a@example.com" — created (Filter ID: filter-1)`,
+		`2. from="b@example.com" — failed: label "Missing" not found`,
+		"3. (no criteria) — failed:",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestLabelResolver(t *testing.T) {
+	newMailbox := func() *fakeFilterMailbox {
+		return &fakeFilterMailbox{t: t, labels: []*gmailapi.Label{
+			{Id: "INBOX", Name: "INBOX"},
+			{Id: "STARRED", Name: "STARRED"},
+			{Id: "Label_1", Name: "Projects/Work"},
+			{Id: "Label_2", Name: "Receipts"},
+			{Id: "Label_3", Name: "receipts"},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		refs    []string
+		create  bool
+		want    string
+		wantErr string
+		created []string
+	}{
+		{name: "ids", refs: []string{"INBOX", "Label_1"}, want: "[INBOX Label_1]"},
+		{name: "names case-insensitive", refs: []string{"projects/work", "starred", "inbox"}, want: "[Label_1 STARRED INBOX]"},
+		{name: "id beats name", refs: []string{"Label_2"}, want: "[Label_2]"},
+		{name: "ambiguous", refs: []string{"RECEIPTS"}, wantErr: "Receipts (Label_2), receipts (Label_3)"},
+		{name: "missing", refs: []string{"Travel"}, wantErr: `label "Travel" not found (use list_labels to see available labels, or set create_missing to create it)`},
+		{name: "create once", refs: []string{"Travel", "travel"}, create: true, want: "[Label_new5 Label_new5]", created: []string{"Travel"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailbox := newMailbox()
+			r, err := newLabelResolver(context.Background(), newFakeService(t, mailbox.handle), tt.create, "create_missing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids, err := r.resolve(context.Background(), tt.refs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(ids); got != tt.want {
+				t.Errorf("ids = %s, want %s", got, tt.want)
+			}
+			if fmt.Sprint(r.created) != fmt.Sprint(tt.created) {
+				t.Errorf("created = %v, want %v", r.created, tt.created)
+			}
+			if len(mailbox.labels) != 5+len(tt.created) {
+				t.Errorf("mailbox has %d labels, want %d", len(mailbox.labels), 5+len(tt.created))
+			}
+		})
+	}
+}
+
+func TestLabelResolver_Note(t *testing.T) {
+	mailbox := &fakeFilterMailbox{t: t, labels: []*gmailapi.Label{
+		{Id: "INBOX", Name: "INBOX"},
+		{Id: "Label_1", Name: "Projects/Work"},
+	}}
+	r, err := newLabelResolver(context.Background(), newFakeService(t, mailbox.handle), true, "create_missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	add, err := r.resolve(context.Background(), []string{"projects/work", "Travel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	remove, err := r.resolve(context.Background(), []string{"INBOX"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\nAdded labels: Projects/Work (Label_1), Travel (Label_new2)\nRemoved labels: INBOX\nCreated labels: Travel"
+	if got := r.note(add, remove); got != want {
+		t.Errorf("note = %q, want %q", got, want)
+	}
+	if got := r.note(nil, nil); got != "\nCreated labels: Travel" {
+		t.Errorf("note without changes = %q", got)
+	}
+}
+
+func TestSplitLabelPath(t *testing.T) {
+	parts, err := splitLabelPath("Clients/Acme/Invoices")
+	if err != nil || strings.Join(parts, "|") != "Clients|Acme|Invoices" {
+		t.Errorf("splitLabelPath() = %v, %v", parts, err)
+	}
+	for _, name := range []string{"", "Clients/", "/Acme", "Clients//Acme", "Clients/ /Acme"} {
+		if _, err := splitLabelPath(name); err == nil {
+			t.Errorf("splitLabelPath(%q) succeeded, want error", name)
+		}
+	}
+}
+
+func TestMissingLabelAncestors(t *testing.T) {
+	names := map[string]string{"Label_1": "clients", "Label_2": "Other/Acme"}
+	got := missingLabelAncestors("Clients/Acme/Invoices", names)
+	if strings.Join(got, "|") != "Clients/Acme" {
+		t.Errorf("missingLabelAncestors() = %v, want [Clients/Acme]", got)
+	}
+	if got := missingLabelAncestors("Travel", names); len(got) != 0 {
+		t.Errorf("missingLabelAncestors(top level) = %v, want none", got)
+	}
+}
+
+func TestPlanLabelRename(t *testing.T) {
+	names := map[string]string{
+		"L1": "Clients/Acme",
+		"L2": "Clients/Acme/Invoices",
+		"L3": "Clients/Acme/Invoices/2024",
+		"L4": "Clients/Acme Corp",
+		"L5": "Clients",
+		"L6": "clients/acme/Contracts",
+	}
+	plan, err := planLabelRename(names, "Clients/Acme", "Archive/Acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range plan {
+		got = append(got, r.ID+":"+r.From+"->"+r.To)
+	}
+	want := []string{
+		"L1:Clients/Acme->Archive/Acme",
+		"L6:clients/acme/Contracts->Archive/Acme/Contracts",
+		"L2:Clients/Acme/Invoices->Archive/Acme/Invoices",
+		"L3:Clients/Acme/Invoices/2024->Archive/Acme/Invoices/2024",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("plan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for _, tt := range []struct{ oldName, newName, wantErr string }{
+		{"Clients/Missing", "Archive/Missing", "not found"},
+		{"Clients/Acme", "Clients/Acme/Old", "under itself"},
+		{"Clients/Acme", "CLIENTS/ACME", "same"},
+		{"Clients/Acme/Invoices", "Clients/Acme Corp", "already exists"},
+		{"Clients/Acme", "Archive//Acme", "invalid label name"},
+	} {
+		if _, err := planLabelRename(names, tt.oldName, tt.newName); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("planLabelRename(%q, %q) error = %v, want %q", tt.oldName, tt.newName, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRenameRollbackNote(t *testing.T) {
+	done := []labelRename{{ID: "L1", From: "A", To: "B"}, {ID: "L2", From: "A/x", To: "B/x"}}
+	pending := []labelRename{{ID: "L3", From: "A/y", To: "B/y"}}
+	want := "\nAlready renamed (revert with update_label, children first):" +
+		"\n  - label_id=L2 name=\"A/x\" (now \"B/x\")" +
+		"\n  - label_id=L1 name=\"A\" (now \"B\")" +
+		"\nNot renamed:\n  - A/y (Label ID: L3)"
+	if got := renameRollbackNote(done, pending); got != want {
+		t.Errorf("renameRollbackNote() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLabelTree(t *testing.T) {
+	labels := []*gmailapi.Label{
+		{Id: "L2", Name: "Clients/Acme/Invoices", Type: "user"},
+		{Id: "INBOX", Name: "INBOX", Type: "system"},
+		{Id: "L3", Name: "Travel", Type: "user"},
+		{Id: "L1", Name: "Clients", Type: "user"},
+	}
+	want := "  - INBOX (Label ID: INBOX, type: system)\n" +
+		"  - Clients (Label ID: L1, type: user)\n" +
+		"    - Acme (no label)\n" +
+		"      - Invoices (Label ID: L2, type: user)\n" +
+		"  - Travel (Label ID: L3, type: user)\n"
+	if got := formatLabelTree(labels); got != want {
+		t.Errorf("formatLabelTree() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportFilters_SizeAndChats(t *testing.T) {
+	doc := exportFilters([]*gmailapi.Filter{
+		{Criteria: &gmailapi.FilterCriteria{From: "a@example.com", Size: 1 << 20, SizeComparison: "larger"}, Action: &gmailapi.FilterAction{RemoveLabelIds: []string{"INBOX"}}},
+		{Criteria: &gmailapi.FilterCriteria{ExcludeChats: true, Query: "x"}, Action: &gmailapi.FilterAction{AddLabelIds: []string{"STARRED"}}},
+		{Criteria: &gmailapi.FilterCriteria{Subject: "ok"}, Action: &gmailapi.FilterAction{AddLabelIds: []string{"Label_gone"}}},
+	}, map[string]string{}, time.Now())
+	if f := doc.Filters[0]; f.Size != 1<<20 || f.SizeComparison != "larger" {
+		t.Errorf("size criteria not exported: %+v", f)
+	}
+	if !doc.Filters[1].ExcludeChats {
+		t.Errorf("exclude_chats not exported: %+v", doc.Filters[1])
+	}
+	if got := doc.Filters[2].AddLabels; len(got) != 1 || got[0] != "Label_gone" {
+		t.Errorf("unknown label IDs should be kept, got %v", got)
+	}
+
+	// The exported criteria survive the document format and come back
+	// in the filter to create.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseFilterExport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := parsed.Filters[0].toFilter().Criteria; c.Size != 1<<20 || c.SizeComparison != "larger" {
+		t.Errorf("imported criteria = %+v", c)
+	}
+	if c := parsed.Filters[1].toFilter().Criteria; !c.ExcludeChats {
+		t.Errorf("imported criteria = %+v", c)
+	}
+	if got := describeFilterSpec(parsed.Filters[0]); got != `from="a@example.com" size=larger:1048576` {
+		t.Errorf("describeFilterSpec = %s", got)
+	}
+}
+
+func TestFilterSpec_ValidateSize(t *testing.T) {
+	action := []string{"STARRED"}
+	for _, tt := range []struct {
+		spec filterSpec
+		want string
+	}{
+		{filterSpec{Size: 1000, SizeComparison: "smaller", AddLabels: action}, ""},
+		{filterSpec{Size: 1000, AddLabels: action}, "size_comparison must be 'larger' or 'smaller'"},
+		{filterSpec{From: "a", SizeComparison: "larger", AddLabels: action}, "size_comparison requires size"},
+		{filterSpec{Size: -1, SizeComparison: "larger", AddLabels: action}, "positive"},
+	} {
+		err := tt.spec.validate()
+		if (tt.want == "") != (err == nil) || err != nil && !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("validate(%+v) = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestParseFilterExport_Errors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`not json`, "parsing filter export"},
+		{`{"version":2,"filters":[{"from":"a"}]}`, "unsupported filter export version 2"},
+		{`{"version":1,"filters":[]}`, "no filters"},
+		{`{"version":1,"filters":[{"form":"a"}]}`, `unknown field "form"`},
+	}
+	for _, tt := range tests {
+		_, err := parseFilterExport([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseFilterExport(%s) error = %v, want containing %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+// toolHints is the expected annotation of a tool. Every hint must be set
+// explicitly; clients disagree on the defaults of unset hints.
+type toolHints struct {
+	readOnly, destructive, idempotent, openWorld bool
+}
+
+var (
+	// Reads Google data.
+	readHints = toolHints{readOnly: true, idempotent: true, openWorld: true}
+	// Reads local configuration only.
+	localReadHints = toolHints{readOnly: true, idempotent: true}
+	// Creates or sends something new on each call.
+	createHints = toolHints{openWorld: true}
+	// Adds or restores state; repeating the call changes nothing further.
+	additiveHints = toolHints{idempotent: true, openWorld: true}
+	// Overwrites or removes existing state.
+	destructiveHints = toolHints{destructive: true, idempotent: true, openWorld: true}
+)
+
+func TestToolAnnotationMatrix(t *testing.T) {
+	want := map[string]toolHints{
+		"apply_rules":               destructiveHints,
+		"batch_delete_messages":     destructiveHints,
+		"check_authentication":      readHints,
+		"create_draft":              createHints,
+		"create_filter":             createHints,
+		"create_label":              createHints,
+		"delete_draft":              destructiveHints,
+		"delete_filter":             destructiveHints,
+		"delete_label":              destructiveHints,
+		"delete_message":            destructiveHints,
+		"delete_messages_in_trash":  destructiveHints,
+		"delete_thread":             destructiveHints,
+		"export_filters":            readHints,
+		"export_mbox":               destructiveHints,
+		"export_thread_to_drive":    createHints,
+		"forward_attachment":        createHints,
+		"get_attachment":            readHints,
+		"get_auto_forwarding":       readHints,
+		"get_draft":                 readHints,
+		"get_imap":                  readHints,
+		"get_label":                 readHints,
+		"get_pop":                   readHints,
+		"get_profile":               readHints,
+		"get_vacation":              readHints,
+		"import_filters":            createHints,
+		"list_accounts":             localReadHints,
+		"list_drafts":               readHints,
+		"list_filters":              readHints,
+		"list_forwarding_addresses": readHints,
+		"list_history":              readHints,
+		"list_labels":               readHints,
+		"list_recent_mutations":     localReadHints,
+		"list_send_as":              readHints,
+		"list_snoozed":              readHints,
+		"list_spam":                 readHints,
+		"list_threads":              readHints,
+		"modify_messages":           destructiveHints,
+		"modify_thread":             destructiveHints,
+		"not_spam":                  additiveHints,
+		"preview_message":           readHints,
+		"read_message":              readHints,
+		"read_thread":               readHints,
+		"rename_label_tree":         destructiveHints,
+		"reply_to_thread":           createHints,
+		"report_spam":               destructiveHints,
+		"save_attachment_to_drive":  createHints,
+		"search_attachments":        readHints,
+		"search_messages":           readHints,
+		"send_draft":                createHints,
+		"send_message":              createHints,
+		"snooze_message":            destructiveHints,
+		"stop_watch":                destructiveHints,
+		"trash_message":             destructiveHints,
+		"trash_thread":              destructiveHints,
+		"unsnooze":                  additiveHints,
+		"untrash_message":           additiveHints,
+		"untrash_thread":            additiveHints,
+		"update_draft":              destructiveHints,
+		"update_label":              destructiveHints,
+		"update_vacation":           destructiveHints,
+		"watch_mailbox":             additiveHints,
+	}
+
+	tools := listTools(t, newTestServer(t))
+	if len(tools) != len(want) {
+		t.Errorf("got %d tools, matrix has %d; add new tools to the matrix", len(tools), len(want))
+	}
+	for _, tool := range tools {
+		w, ok := want[tool.Name]
+		if !ok {
+			t.Errorf("tool %q missing from the annotation matrix", tool.Name)
+			continue
+		}
+		a := tool.Annotations
+		if a == nil || a.DestructiveHint == nil || a.OpenWorldHint == nil {
+			t.Errorf("tool %q must set DestructiveHint and OpenWorldHint explicitly", tool.Name)
+			continue
+		}
+		got := toolHints{a.ReadOnlyHint, *a.DestructiveHint, a.IdempotentHint, *a.OpenWorldHint}
+		if got != w {
+			t.Errorf("tool %q annotations = %+v, want %+v", tool.Name, got, w)
+		}
+	}
+}
+
+func TestThreadBatchSchema(t *testing.T) {
+	server := newTestServer(t)
+	found := 0
+	for _, tool := range listTools(t, server) {
+		switch tool.Name {
+		case "modify_thread", "trash_thread", "untrash_thread":
+		default:
+			continue
+		}
+		found++
+		data, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema struct {
+			Properties map[string]any `json:"properties"`
+			Required   []string       `json:"required"`
+		}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		for _, prop := range []string{"thread_ids", "thread_id"} {
+			if _, ok := schema.Properties[prop]; !ok {
+				t.Errorf("%s schema missing %q: %s", tool.Name, prop, data)
+			}
+		}
+		if len(schema.Required) != 0 {
+			t.Errorf("%s should not require any property, got %v", tool.Name, schema.Required)
+		}
+	}
+	if found != 3 {
+		t.Fatalf("found %d thread batch tools, want 3", found)
+	}
+}
+
+func TestThreadSelection_DeprecatedAlias(t *testing.T) {
+	var in trashThreadInput
+	if err := json.Unmarshal([]byte(`{"account":"work","thread_id":"t1","thread_ids":["t2","t1"]}`), &in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Account != "work" || in.ThreadID != "" {
+		t.Errorf("account = %q, thread_id = %q", in.Account, in.ThreadID)
+	}
+	ids, err := in.ids()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[t1 t2]" {
+		t.Errorf("ids = %v, want [t1 t2]", ids)
+	}
+
+	var mod threadModifyInput
+	if err := json.Unmarshal([]byte(`{"thread_id":"t9","remove_labels":["INBOX"]}`), &mod); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(mod.ThreadIDs) != "[t9]" || fmt.Sprint(mod.RemoveLabels) != "[INBOX]" {
+		t.Errorf("thread_ids = %v, remove_labels = %v", mod.ThreadIDs, mod.RemoveLabels)
+	}
+}
+
+func TestThreadSelection_Limits(t *testing.T) {
+	if _, err := (&threadSelection{}).ids(); err == nil {
+		t.Error("expected error for no thread IDs")
+	}
+	var many threadSelection
+	for i := range maxThreadBatch + 1 {
+		many.ThreadIDs = append(many.ThreadIDs, fmt.Sprintf("t%d", i))
+	}
+	if _, err := many.ids(); err == nil || !strings.Contains(err.Error(), "at most 100") {
+		t.Errorf("expected batch cap error, got %v", err)
+	}
+	many.ThreadIDs = many.ThreadIDs[:maxThreadBatch]
+	if ids, err := many.ids(); err != nil || len(ids) != maxThreadBatch {
+		t.Errorf("100 threads: %d ids, err %v", len(ids), err)
+	}
+}
+
+func TestTrashThreads_PartialFailure(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Path, "/threads/missing/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found."}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"ok"}`))
+	})
+
+	ids := []string{"a", "missing", "b"}
+	succeeded, failed := forEachThread(ids, func(id string) error {
+		_, err := svc.Users.Threads.Trash("me", id).Do()
+		return err
+	})
+	if fmt.Sprint(succeeded) != "[a b]" || len(failed) != 1 || failed[0].ThreadID != "missing" {
+		t.Fatalf("succeeded = %v, failed = %v", succeeded, failed)
+	}
+
+	res, _, err := threadBatchResult("moved to trash", "", ids, succeeded, failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, "2 of 3 threads moved to trash") || !strings.Contains(text, "missing:") {
+		t.Errorf("unexpected summary:\n%s", text)
+	}
+
+	// A batch where everything failed is an error.
+	if _, _, err := threadBatchResult("moved to trash", "", []string{"x", "y"}, nil, []threadFailure{{"x", errors.New("boom")}, {"y", errors.New("boom")}}); err == nil {
+		t.Error("expected error when every thread failed")
+	}
+
+	// A single thread keeps the single-ID messages.
+	res, _, err = threadBatchResult("modified", "3 messages affected", []string{"a"}, []string{"a"}, nil)
+	if err != nil || res.Content[0].(*mcp.TextContent).Text != "Thread a modified (3 messages affected)." {
+		t.Errorf("single thread result = %v, %v", res, err)
+	}
+}
+
+func TestDateRangeQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		after, before string
+		want          string
+	}{
+		{"query only", "from:bob", "", "", "from:bob"},
+		{"date only is midnight UTC", "", "2024-06-01", "", "after:1717200000"},
+		{"utc timestamp", "", "2024-06-01T00:00:00Z", "", "after:1717200000"},
+		{"positive offset", "", "2024-06-01T02:00:00+02:00", "", "after:1717200000"},
+		{"negative offset", "", "", "2024-05-31T19:00:00-05:00", "before:1717200000"},
+		{"range with query", " has:attachment ", "2024-06-01", "2024-06-02", "has:attachment after:1717200000 before:1717286400"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dateRangeQuery(tt.query, tt.after, tt.before)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateRangeQuery_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		after, before string
+	}{
+		{"invalid month", "2024-13-45", ""},
+		{"slash date", "2024/06/01", ""},
+		{"invalid before", "", "yesterday"},
+		{"after equals before", "2024-06-01", "2024-06-01T00:00:00Z"},
+		{"after later than before", "2024-06-02", "2024-06-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := dateRangeQuery("", tt.after, tt.before); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestGetProfile_Structured(t *testing.T) {
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/users/me/profile") {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"emailAddress":"alice@example.com","messagesTotal":1200,"threadsTotal":800,"historyId":"98765"}`))
+	})
+	profile, err := svc.Users.GetProfile("me").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := newAccountProfile("work", profile)
+	want := accountProfile{Account: "work", EmailAddress: "alice@example.com", MessagesTotal: 1200, ThreadsTotal: 800, HistoryID: 98765}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	single := formatProfiles([]accountProfile{got})
+	if strings.Contains(single, "=== Account") || !strings.Contains(single, "Total messages: 1200") {
+		t.Errorf("single-account output:\n%s", single)
+	}
+
+	multi := formatProfiles([]accountProfile{got, {Account: "broken", Error: "getting profile: 401"}})
+	for _, s := range []string{"=== Account: work ===", "History ID: 98765", "=== Account: broken ===\nError: getting profile: 401"} {
+		if !strings.Contains(multi, s) {
+			t.Errorf("multi-account output missing %q:\n%s", s, multi)
+		}
+	}
+}
+
+func TestGetProfile_OutputSchema(t *testing.T) {
+	server := newTestServer(t)
+	for _, tool := range listTools(t, server) {
+		if tool.Name != "get_profile" {
+			continue
+		}
+		if tool.OutputSchema == nil {
+			t.Fatal("get_profile should declare an output schema")
+		}
+		data, _ := json.Marshal(tool.OutputSchema)
+		for _, prop := range []string{"profiles", "messages_total", "threads_total", "history_id", "email_address"} {
+			if !strings.Contains(string(data), fmt.Sprintf("%q", prop)) {
+				t.Errorf("output schema missing %q: %s", prop, data)
+			}
+		}
+		return
+	}
+	t.Fatal("get_profile not found")
+}
+
+func TestValidateTopicName(t *testing.T) {
+	for _, topic := range []string{"projects/my-project/topics/gmail-push", "projects/acme.com:mail/topics/inbox_events"} {
+		if err := validateTopicName(topic); err != nil {
+			t.Errorf("validateTopicName(%q) = %v", topic, err)
+		}
+	}
+	for _, topic := range []string{"", "gmail-push", "projects/my-project/gmail-push", "projects//topics/x", "projects/p/topics/x/extra", "https://pubsub.googleapis.com/projects/p/topics/t"} {
+		if err := validateTopicName(topic); err == nil {
+			t.Errorf("validateTopicName(%q): expected error", topic)
+		}
+	}
+}
+
+func TestWatchError(t *testing.T) {
+	permErr := &googleapi.Error{Code: http.StatusForbidden, Message: "Error sending test message to Cloud PubSub projects/p/topics/t : User not authorized to perform this action."}
+	got := watchError("projects/p/topics/t", permErr)
+	if !strings.Contains(got.Error(), gmailPushServiceAccount) || !strings.Contains(got.Error(), "Publisher") {
+		t.Errorf("permission error should explain publisher rights: %v", got)
+	}
+	if !errors.Is(got, permErr) {
+		t.Error("permission error should wrap the original error")
+	}
+
+	other := &googleapi.Error{Code: http.StatusInternalServerError, Message: "backend error"}
+	if got := watchError("projects/p/topics/t", other); strings.Contains(got.Error(), gmailPushServiceAccount) {
+		t.Errorf("unrelated error got the permission explanation: %v", got)
+	}
+}
+
+func TestWatchStore_PutRemove(t *testing.T) {
+	store := newWatchStore(newTestManager(t))
+	exp := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	if err := store.put(watchEntry{Account: "work", TopicName: "projects/p/topics/a", Expiration: exp}); err != nil {
+		t.Fatal(err)
+	}
+	// A second watch on the same account replaces the first.
+	if err := store.put(watchEntry{Account: "work", TopicName: "projects/p/topics/b", Expiration: exp}); err != nil {
+		t.Fatal(err)
+	}
+	store.put(watchEntry{Account: "home", TopicName: "projects/p/topics/c", Expiration: exp})
+
+	entries, err := store.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Account != "work" || entries[1].TopicName != "projects/p/topics/b" {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	if found, err := store.remove("work"); !found || err != nil {
+		t.Errorf("remove = %v, %v; want true, nil", found, err)
+	}
+	if found, err := store.remove("work"); found || err != nil {
+		t.Errorf("remove of absent watch = %v, %v; want false, nil", found, err)
+	}
+}
+
+func TestWatchStore_RenewIfNeeded(t *testing.T) {
+	mgr := newTestManager(t)
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	first := newWatchStore(mgr)
+	for _, e := range []watchEntry{
+		{Account: "expired", TopicName: "projects/p/topics/t", LabelIDs: []string{"INBOX"}, Expiration: now.Add(-time.Hour)},
+		{Account: "soon", TopicName: "projects/p/topics/t", Expiration: now.Add(23 * time.Hour)},
+		{Account: "later", TopicName: "projects/p/topics/t", Expiration: now.Add(72 * time.Hour)},
+		{Account: "failing", TopicName: "projects/p/topics/t", Expiration: now.Add(time.Hour)},
+		{Account: "replaced", TopicName: "projects/p/topics/t", Expiration: now.Add(time.Hour)},
+	} {
+		if err := first.put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var issued []string
+	issue := func(_ context.Context, account string, req *gmailapi.WatchRequest) (*gmailapi.WatchResponse, error) {
+		issued = append(issued, account)
+		if account == "failing" {
+			return nil, errors.New("publish denied")
+		}
+		if account == "replaced" {
+			// watch_mailbox runs while the renewal is in flight; the state
+			// file must not be locked, and its new watch must be kept.
+			if err := first.put(watchEntry{Account: "replaced", TopicName: "projects/p/topics/new", Expiration: now.Add(7 * 24 * time.Hour)}); err != nil {
+				t.Error(err)
+			}
+		}
+		if account == "expired" && fmt.Sprint(req.LabelIds) != "[INBOX]" {
+			t.Errorf("renewal lost label filter: %v", req.LabelIds)
+		}
+		return &gmailapi.WatchResponse{HistoryId: 42, Expiration: now.Add(7 * 24 * time.Hour).UnixMilli()}, nil
+	}
+
+	// A new store sees the watches recorded by a previous process.
+	renewed, err := newWatchStore(mgr).renewIfNeeded(context.Background(), now, issue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(issued)
+	if fmt.Sprint(issued) != "[expired failing replaced soon]" {
+		t.Errorf("issued = %v, want [expired failing replaced soon]", issued)
+	}
+	if len(renewed) != 2 {
+		t.Errorf("renewed %d watches, want 2", len(renewed))
+	}
+
+	entries, _ := newWatchStore(mgr).load()
+	for _, e := range entries {
+		switch e.Account {
+		case "expired", "soon":
+			if !e.Expiration.Equal(now.Add(7*24*time.Hour)) || e.HistoryID != 42 {
+				t.Errorf("%s not updated: %+v", e.Account, e)
+			}
+		case "failing":
+			if e.LastError != "publish denied" {
+				t.Errorf("failing watch should record the error: %+v", e)
+			}
+		case "later":
+			if !e.Expiration.Equal(now.Add(72 * time.Hour)) {
+				t.Errorf("later watch should not be renewed: %+v", e)
+			}
+		case "replaced":
+			if e.TopicName != "projects/p/topics/new" || e.HistoryID != 0 {
+				t.Errorf("watch replaced during renewal was overwritten: %+v", e)
+			}
+		}
+	}
+}
+
+func TestMboxWriter(t *testing.T) {
+	var buf bytes.Buffer
+	mw := newMboxWriter(&buf)
+	date := time.Date(2023, 3, 5, 9, 4, 5, 0, time.FixedZone("CET", 3600))
+	first := "From: Ann <ann@example.com>\r\nSubject: hi\r\n\r\nFrom the start\r\n>From quoted\r\n>>From twice\r\nFrom:no space\r\n From indented\r\nlast line"
+	if err := mw.WriteMessage("ann@example.com", date, []byte(first)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.WriteMessage("", date, []byte("Subject: second\n\nbody\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "From ann@example.com Sun Mar  5 08:04:05 2023\n" +
+		"From: Ann <ann@example.com>\nSubject: hi\n\n" +
+		">From the start\n>>From quoted\n>>>From twice\nFrom:no space\n From indented\nlast line\n\n" +
+		"From MAILER-DAEMON Sun Mar  5 08:04:05 2023\n" +
+		"Subject: second\n\nbody\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("mbox =\n%q\nwant\n%q", got, want)
+	}
+	if mw.Messages() != 2 || mw.Bytes() != int64(len(want)) {
+		t.Errorf("Messages, Bytes = %d, %d; want 2, %d", mw.Messages(), mw.Bytes(), len(want))
+	}
+}
+
+func TestMboxSender(t *testing.T) {
+	tests := []struct {
+		name, raw, want string
+	}{
+		{"return path", "Return-Path: <bounce@lists.example.com>\r\nFrom: Ann <ann@example.com>\r\n\r\nbody", "bounce@lists.example.com"},
+		{"from header", "From: \"Ann B\" <ann@example.com>\r\n\r\nbody", "ann@example.com"},
+		{"null return path", "Return-Path: <>\r\nFrom: ann@example.com\r\n\r\nbody", "ann@example.com"},
+		{"no sender", "Subject: hi\r\n\r\nbody", ""},
+		{"unparsable", "not a message", ""},
+	}
+	for _, tt := range tests {
+		if got := mboxSender([]byte(tt.raw)); got != tt.want {
+			t.Errorf("%s: mboxSender = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExportMessages(t *testing.T) {
+	raw := "From: ann@example.com\r\nSubject: hi\r\n\r\nFrom here on\r\n"
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") != "raw" {
+			t.Errorf("format = %q, want raw", r.URL.Query().Get("format"))
+		}
+		switch path.Base(r.URL.Path) {
+		case "m1":
+			fmt.Fprintf(w, `{"id": "m1", "internalDate": "1678003445000", "raw": %q}`, base64.URLEncoding.EncodeToString([]byte(raw)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": 404, "message": "Requested entity was not found."}}`))
+		}
+	})
+
+	var buf bytes.Buffer
+	mw := newMboxWriter(&buf)
+	failed, err := exportMessages(context.Background(), nil, svc, []string{"m1", "gone"}, mw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || !strings.HasPrefix(failed[0], "gone (") {
+		t.Errorf("failed = %v, want the missing message", failed)
+	}
+	want := "From ann@example.com Sun Mar  5 08:04:05 2023\nFrom: ann@example.com\nSubject: hi\n\n>From here on\n\n"
+	if buf.String() != want {
+		t.Errorf("mbox = %q, want %q", buf.String(), want)
+	}
+
+	out := formatMboxExport("from:ann", "backup/ann.mbox", mw, 1500*time.Millisecond, true, 1, failed, nil)
+	for _, s := range []string{"Exported 1 messages", "Saved to: backup/ann.mbox", fmt.Sprintf("Size: %d bytes", len(want)), "Elapsed: 1.5s", "Stopped at max_messages (1)", "1 messages could not be fetched", "  - gone ("} {
+		if !strings.Contains(out, s) {
+			t.Errorf("result missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestExportMessages_Cancelled(t *testing.T) {
+	raw := "From: ann@example.com\r\nSubject: hi\r\n\r\nhello\r\n"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) != "m1" {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprintf(w, `{"id": "m1", "internalDate": "1678003445000", "raw": %q}`, base64.URLEncoding.EncodeToString([]byte(raw)))
+	})
+
+	var buf bytes.Buffer
+	mw := newMboxWriter(&buf)
+	failed, err := exportMessages(ctx, nil, svc, []string{"m1", "m2", "m3"}, mw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 || mw.Messages() != 1 {
+		t.Errorf("exported %d, failed %v; want 1 exported and none failed", mw.Messages(), failed)
+	}
+	out := formatMboxExport("from:ann", "ann.mbox", mw, time.Second, false, 3, failed, ctx.Err())
+	if !strings.Contains(out, "Stopped early (context canceled): the file has only the first 1 messages.") {
+		t.Errorf("result doesn't say the export stopped:\n%s", out)
+	}
+}
+
+func TestLocalWriteParams(t *testing.T) {
+	want := map[string]string{
+		"get_attachment": "save_to",
+		"export_filters": "save_to",
+	}
+	for _, ti := range newTestServer(t).Tools() {
+		param, ok := want[ti.Name]
+		if !ok {
+			if len(ti.LocalWriteParams) > 0 {
+				t.Errorf("tool %q has unexpected local write params %v", ti.Name, ti.LocalWriteParams)
+			}
+			continue
+		}
+		if len(ti.LocalWriteParams) != 1 || ti.LocalWriteParams[0] != param {
+			t.Errorf("tool %q local write params = %v, want [%s]", ti.Name, ti.LocalWriteParams, param)
+		}
+	}
+}
+
+// fieldsMask is a parsed Fields parameter: each selected field maps to its
+// selected subfields, or nil when the whole field is selected.
+type fieldsMask map[string]fieldsMask
+
+// parseFieldsMask parses a Fields parameter such as "id,payload(headers)".
+func parseFieldsMask(s string) fieldsMask {
+	m, _ := parseFieldsList(s)
+	return m
+}
+
+func parseFieldsList(s string) (fieldsMask, string) {
+	m := fieldsMask{}
+	for s != "" {
+		i := strings.IndexAny(s, ",()")
+		if i < 0 {
+			m[s] = nil
+			return m, ""
+		}
+		name := s[:i]
+		switch s[i] {
+		case '(':
+			m[name], s = parseFieldsList(s[i+1:])
+			if strings.HasPrefix(s, ",") {
+				s = s[1:]
+			}
+		case ')':
+			if name != "" {
+				m[name] = nil
+			}
+			return m, s[i+1:]
+		default:
+			m[name] = nil
+			s = s[i+1:]
+		}
+	}
+	return m, ""
+}
+
+// applyFieldsMask prunes a decoded JSON value to the fields in mask, the
+// way the Google APIs do for the Fields parameter.
+func applyFieldsMask(v any, mask fieldsMask) any {
+	if mask == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		for k, sub := range mask {
+			if val, ok := v[k]; ok {
+				out[k] = applyFieldsMask(val, sub)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = applyFieldsMask(e, mask)
+		}
+		return out
+	}
+	return v
+}
+
+// fixtureMessage is a recorded full-format message: a multipart/mixed
+// message with a large text body and an attachment.
+func fixtureMessage(id string) map[string]any {
+	body := base64.URLEncoding.EncodeToString([]byte(strings.Repeat("Quarterly numbers attached. ", 800)))
+	return map[string]any{
+		"id": id, "threadId": "t1", "labelIds": []any{"INBOX", "UNREAD"},
+		"snippet": "Quarterly numbers attached.", "sizeEstimate": 48213, "historyId": "991",
+		"payload": map[string]any{
+			"mimeType": "multipart/mixed",
+			"headers": []any{
+				map[string]any{"name": "From", "value": "alice@example.com"},
+				map[string]any{"name": "To", "value": "bob@example.com"},
+				map[string]any{"name": "Subject", "value": "Q3 report"},
+				map[string]any{"name": "Date", "value": "Mon, 3 Jun 2024 09:00:00 +0000"},
+				map[string]any{"name": "Received", "value": "from mail.example.com by mx.google.com"},
+				map[string]any{"name": "DKIM-Signature", "value": strings.Repeat("x", 400)},
+			},
+			"parts": []any{
+				map[string]any{"partId": "0", "mimeType": "text/plain", "body": map[string]any{"size": 22400, "data": body}},
+				map[string]any{"partId": "1", "mimeType": "application/pdf", "filename": "q3.pdf",
+					"body": map[string]any{"size": 120000, "attachmentId": "att-1"}},
+			},
+		},
+	}
+}
+
+// metadataFormat reduces a full-format message to Gmail's metadata format:
+// no body parts, and only the requested headers if any were named.
+func metadataFormat(msg map[string]any, headers []string) map[string]any {
+	payload := msg["payload"].(map[string]any)
+	var kept []any
+	for _, h := range payload["headers"].([]any) {
+		if len(headers) == 0 || slices.Contains(headers, h.(map[string]any)["name"].(string)) {
+			kept = append(kept, h)
+		}
+	}
+	out := maps.Clone(msg)
+	out["payload"] = map[string]any{"mimeType": payload["mimeType"], "headers": kept}
+	return out
+}
+
+// newFixtureService serves fixtureMessage for message and thread GETs,
+// honoring format, metadataHeaders and fields, and records the response
+// size of every call.
+func newFixtureService(t *testing.T, sizes *[]int) *gmailapi.Service {
+	t.Helper()
+	return newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		prep := func(msg map[string]any) map[string]any {
+			if q.Get("format") == "metadata" {
+				return metadataFormat(msg, q["metadataHeaders"])
+			}
+			return msg
+		}
+		var resp any
+		switch {
+		case strings.Contains(r.URL.Path, "/messages/"):
+			resp = prep(fixtureMessage("m1"))
+		case strings.Contains(r.URL.Path, "/threads/"):
+			resp = map[string]any{"id": "t1", "historyId": "991", "messages": []any{
+				prep(fixtureMessage("m1")), prep(fixtureMessage("m2")), prep(fixtureMessage("m3")),
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if f := q.Get("fields"); f != "" {
+			resp = applyFieldsMask(resp, parseFieldsMask(f))
+		}
+		data, _ := json.Marshal(resp)
+		*sizes = append(*sizes, len(data))
+		w.Write(data)
+	})
+}
+
+func TestParseFieldsMask(t *testing.T) {
+	got := parseFieldsMask("id,messages(id,payload(headers)),snippet")
+	want := fieldsMask{"id": nil, "snippet": nil, "messages": {"id": nil, "payload": {"headers": nil}}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("parseFieldsMask = %v, want %v", got, want)
+	}
+}
+
+func TestGetMessageForRead_HeadersOnlyPayload(t *testing.T) {
+	var sizes []int
+	svc := newFixtureService(t, &sizes)
+
+	full, err := getMessageForRead(context.Background(), svc, "me", "m1", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, err := getMessageForRead(context.Background(), svc, "me", "m1", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("read_message payload: full %d bytes, headers_only %d bytes", sizes[0], sizes[1])
+	if sizes[1]*20 > sizes[0] {
+		t.Errorf("headers_only payload %d bytes, want under 5%% of full (%d bytes)", sizes[1], sizes[0])
+	}
+
+	// Every header read_message prints survives the mask.
+	got := map[string]string{}
+	for _, h := range headers.Payload.Headers {
+		got[h.Name] = h.Value
+	}
+	for _, h := range full.Payload.Headers {
+		if slices.Contains(readHeaders, h.Name) && got[h.Name] != h.Value {
+			t.Errorf("header %s = %q, want %q", h.Name, got[h.Name], h.Value)
+		}
+	}
+	if _, ok := got["DKIM-Signature"]; ok {
+		t.Error("unrequested header returned")
+	}
+	if headers.ThreadId != "t1" || len(headers.Payload.Parts) != 0 {
+		t.Errorf("headers_only message = %+v", headers)
+	}
+}
+
+func TestFetchDetails_ConcurrentAndOrdered(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	var inFlight, maxInFlight atomic.Int32
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		defer inFlight.Add(-1)
+		if n := inFlight.Add(1); n > maxInFlight.Load() {
+			maxInFlight.Store(n)
+		}
+		id := path.Base(r.URL.Path)
+		// Later messages answer faster, so completion order differs from
+		// request order.
+		n, _ := strconv.Atoi(strings.TrimPrefix(id, "m"))
+		time.Sleep(delay - time.Duration(n)*time.Millisecond)
+		if id == "m3" {
+			http.Error(w, `{"error":{"code":500,"message":"backend error"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%q,"threadId":"t-%s"}`, id, id)
+	})
+
+	var ids []string
+	for i := range 24 {
+		ids = append(ids, fmt.Sprintf("m%d", i))
+	}
+	var progress []int
+	start := time.Now()
+	results := fetchDetails(context.Background(), ids, func(id string) (*gmailapi.Message, error) {
+		return svc.Users.Messages.Get("me", id).Format("metadata").Do()
+	}, func(done int) {
+		progress = append(progress, done)
+	})
+	elapsed := time.Since(start)
+
+	// 24 fetches of up to 50ms take over 600ms one at a time.
+	serial := time.Duration(len(ids)) * (delay - time.Duration(len(ids))*time.Millisecond)
+	t.Logf("%d fetches took %v (serial: at least %v), %d in flight at most", len(ids), elapsed, serial, maxInFlight.Load())
+	if elapsed >= serial/2 {
+		t.Errorf("fetches took %v, want well under the serial %v", elapsed, serial)
+	}
+	if got := maxInFlight.Load(); got > detailWorkers {
+		t.Errorf("%d fetches in flight, want at most %d", got, detailWorkers)
+	}
+
+	for i, r := range results {
+		if i == 3 {
+			if r.Err == nil {
+				t.Error("results[3]: want the fetch error")
+			}
+			continue
+		}
+		if r.Err != nil || r.Value.Id != ids[i] {
+			t.Errorf("results[%d] = %v, %v, want message %s", i, r.Value, r.Err, ids[i])
+		}
+	}
+	if len(progress) != 1 || progress[0] != len(ids) {
+		t.Errorf("progress = %v, want [%d]", progress, len(ids))
+	}
+}
+
+func TestFetchDetails_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	results := fetchDetails(ctx, []string{"a", "b"}, func(id string) (string, error) {
+		calls++
+		return id, nil
+	}, nil)
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("fetch called %d times after cancellation", calls)
+	}
+}
+
+func TestGetThreadSummary_Payload(t *testing.T) {
+	var sizes []int
+	svc := newFixtureService(t, &sizes)
+
+	summary, err := getThreadSummary(context.Background(), svc, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same fetch without the Fields mask, for comparison.
+	if _, err := svc.Users.Threads.Get("me", "t1").Format("metadata").MetadataHeaders("From", "Subject", "Date").Do(); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("list_threads per-thread payload: masked %d bytes, unmasked %d bytes", sizes[0], sizes[1])
+	if sizes[0] >= sizes[1] {
+		t.Errorf("masked payload %d bytes, want less than unmasked %d bytes", sizes[0], sizes[1])
+	}
+
+	if len(summary.Messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(summary.Messages))
+	}
+	first := summary.Messages[0].Payload
+	if first == nil || len(first.Headers) != 3 || first.Headers[0].Name != "From" {
+		t.Errorf("first message payload = %+v, want From, Subject and Date headers", first)
+	}
+}
+
+func TestFindContactGroup(t *testing.T) {
+	groups := []*people.ContactGroup{
+		{ResourceName: "contactGroups/family", Name: "family", FormattedName: "Family", GroupType: "SYSTEM_CONTACT_GROUP"},
+		{ResourceName: "contactGroups/a1", Name: "Book club", FormattedName: "Book club"},
+		{ResourceName: "contactGroups/a2", Name: "Team", FormattedName: "Team"},
+		{ResourceName: "contactGroups/a3", Name: "team", FormattedName: "team"},
+	}
+
+	for _, name := range []string{"family", "FAMILY", "book CLUB"} {
+		g, err := findContactGroup(groups, name)
+		if err != nil {
+			t.Errorf("findContactGroup(%q): %v", name, err)
+			continue
+		}
+		if !strings.EqualFold(groupName(g), name) {
+			t.Errorf("findContactGroup(%q) = %s", name, g.ResourceName)
+		}
+	}
+
+	if _, err := findContactGroup(groups, "team"); err == nil || !strings.Contains(err.Error(), "contactGroups/a2, contactGroups/a3") {
+		t.Errorf("ambiguous name err = %v, want both resource names", err)
+	}
+	if _, err := findContactGroup(groups, "friends"); err == nil || !strings.Contains(err.Error(), "available: Family, Book club") {
+		t.Errorf("missing name err = %v, want available groups", err)
+	}
+}
+
+func TestMergeRecipients(t *testing.T) {
+	to, added := mergeRecipients("Mom <MOM@example.com>",
+		[]string{"Mom <MOM@example.com>", "", "dad@example.com"},
+		[]string{"mom@example.com", "dad@example.com", "sis@example.com", "Sis@Example.com", "bro@example.com"})
+	if to != "Mom <MOM@example.com>, sis@example.com, bro@example.com" {
+		t.Errorf("to = %q", to)
+	}
+	if strings.Join(added, " ") != "sis@example.com bro@example.com" {
+		t.Errorf("added = %v", added)
+	}
+
+	if to, added := mergeRecipients("", nil, []string{"a@example.com", "b@example.com"}); to != "a@example.com, b@example.com" || len(added) != 2 {
+		t.Errorf("empty to: to = %q, added = %v", to, added)
+	}
+	if to, added := mergeRecipients("a@example.com", []string{"a@example.com"}, []string{"A@example.com"}); to != "a@example.com" || added != nil {
+		t.Errorf("all duplicates: to = %q, added = %v", to, added)
+	}
+}
+
+func newFakePeopleService(t *testing.T, handler http.HandlerFunc) *people.Service {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	svc, err := people.NewService(context.Background(),
+		option.WithHTTPClient(ts.Client()),
+		option.WithEndpoint(ts.URL+"/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestExpandContactGroup(t *testing.T) {
+	svc := newFakePeopleService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/contactGroups":
+			json.NewEncoder(w).Encode(map[string]any{"contactGroups": []any{
+				map[string]any{"resourceName": "contactGroups/family", "name": "family", "formattedName": "Family"},
+				map[string]any{"resourceName": "contactGroups/empty", "name": "Empty"},
+			}})
+		case r.URL.Path == "/v1/contactGroups/family":
+			if r.URL.Query().Get("maxMembers") != "51" {
+				t.Errorf("maxMembers = %q, want 51", r.URL.Query().Get("maxMembers"))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"resourceName": "contactGroups/family", "formattedName": "Family",
+				"memberCount": 3, "memberResourceNames": []string{"people/1", "people/2", "people/3"}})
+		case r.URL.Path == "/v1/contactGroups/empty":
+			json.NewEncoder(w).Encode(map[string]any{"resourceName": "contactGroups/empty", "name": "Empty",
+				"memberCount": 1, "memberResourceNames": []string{"people/3"}})
+		case r.URL.Path == "/v1/contactGroups/big":
+			json.NewEncoder(w).Encode(map[string]any{"resourceName": "contactGroups/big", "name": "Big", "memberCount": 120})
+		case r.URL.Path == "/v1/people:batchGet":
+			persons := map[string]any{
+				"people/1": map[string]any{"emailAddresses": []any{
+					map[string]any{"value": "mom.work@example.com"},
+					map[string]any{"value": "mom@example.com", "metadata": map[string]any{"primary": true}},
+				}},
+				"people/2": map[string]any{"emailAddresses": []any{map[string]any{"value": "dad@example.com"}}},
+				"people/3": map[string]any{"names": []any{map[string]any{"displayName": "Grandpa"}}},
+			}
+			var responses []any
+			for _, name := range r.URL.Query()["resourceNames"] {
+				responses = append(responses, map[string]any{"requestedResourceName": name, "person": persons[name]})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"responses": responses})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	for _, ref := range []string{"family", "contactGroups/family"} {
+		group, emails, err := expandContactGroup(context.Background(), svc, ref)
+		if err != nil {
+			t.Fatalf("expandContactGroup(context.Background(), %q): %v", ref, err)
+		}
+		if group.ResourceName != "contactGroups/family" || strings.Join(emails, " ") != "mom@example.com dad@example.com" {
+			t.Errorf("expandContactGroup(context.Background(), %q) = %s, %v", ref, group.ResourceName, emails)
+		}
+	}
+
+	if _, _, err := expandContactGroup(context.Background(), svc, "empty"); err == nil || !strings.Contains(err.Error(), "no members with an email address") {
+		t.Errorf("empty group err = %v", err)
+	}
+	if _, _, err := expandContactGroup(context.Background(), svc, "contactGroups/big"); err == nil || !strings.Contains(err.Error(), "120 members; at most 50") {
+		t.Errorf("oversized group err = %v", err)
+	}
+}
+
+func TestExpandContactGroup_ScopeError(t *testing.T) {
+	svc := newFakePeopleService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":403,"message":"Request had insufficient authentication scopes.","errors":[{"reason":"insufficientPermissions"}]}}`))
+	})
+	_, _, err := expandContactGroup(context.Background(), svc, "family")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := strings.Count(err.Error(), "re-auth required"); n != 1 {
+		t.Errorf("error gives the re-auth advice %d times, want once: %v", n, err)
+	}
+}
+
+func TestBuildMessageRequiresRecipient(t *testing.T) {
+	if _, err := buildMessage(context.Background(), nil, "me", composeInput{Subject: "hi", Body: "x"}, "", nil); err == nil || !strings.Contains(err.Error(), "to_group") {
+		t.Errorf("buildMessage without to = %v, want to is required error", err)
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		filters queryFilters
+		want    string
+		wantErr string
+	}{
+		{name: "empty", want: ""},
+		{name: "free text only", query: "  invoice ", want: "invoice"},
+		{name: "from address", filters: queryFilters{From: "alice@example.com"}, want: "from:alice@example.com"},
+		{name: "multi-word name quoted", filters: queryFilters{From: "Alice Smith"}, want: `from:"Alice Smith"`},
+		{name: "subject with quotes", filters: queryFilters{Subject: `the "Q3" review`}, want: `subject:"the Q3 review"`},
+		{name: "subject with parens", filters: queryFilters{Subject: "budget(final)"}, want: `subject:"budget(final)"`},
+		{name: "leading dash quoted", filters: queryFilters{To: "-team"}, want: `to:"-team"`},
+		{name: "nested label", filters: queryFilters{Label: "Work/Projects"}, want: "label:Work/Projects"},
+		{name: "label with space", filters: queryFilters{Label: "Follow up"}, want: `label:"Follow up"`},
+		{name: "flags", filters: queryFilters{HasAttachment: true, IsUnread: true}, want: "has:attachment is:unread"},
+		{name: "folder", filters: queryFilters{InFolder: "Inbox"}, want: "in:inbox"},
+		{name: "unknown folder", filters: queryFilters{InFolder: "archive"}, wantErr: "unknown folder"},
+		{name: "sizes", filters: queryFilters{LargerThan: "5m", SmallerThan: "20000000"}, want: "larger:5M smaller:20000000"},
+		{name: "bad size", filters: queryFilters{LargerThan: "5 MB"}, wantErr: "larger_than: invalid size"},
+		{name: "unicode value", filters: queryFilters{Subject: "Ρεπορτάζ"}, want: "subject:Ρεπορτάζ"},
+		{
+			name: "free text and filters", query: "project update",
+			filters: queryFilters{From: "bob@example.com", HasAttachment: true},
+			want:    "project update from:bob@example.com has:attachment",
+		},
+		{
+			name: "OR query grouped", query: "invoice OR receipt",
+			filters: queryFilters{IsUnread: true},
+			want:    "(invoice OR receipt) is:unread",
+		},
+		{
+			name: "already grouped OR query", query: "(invoice OR receipt)",
+			filters: queryFilters{IsUnread: true},
+			want:    "(invoice OR receipt) is:unread",
+		},
+		{name: "OR query alone untouched", query: "invoice OR receipt", want: "invoice OR receipt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildQuery(tt.query, tt.filters)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("buildQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchModify_Chunks(t *testing.T) {
+	var batches []int
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		var body gmailapi.BatchModifyMessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if len(batches) == 2 {
+			http.Error(w, `{"error":{"code":500,"message":"backend error"}}`, http.StatusInternalServerError)
+			return
+		}
+		batches = append(batches, len(body.Ids))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ids := make([]string, 2500)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("m%d", i)
+	}
+	err := batchModify(context.Background(), nil, svc, ids, []string{"STARRED"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "2000 of 2500 were modified") {
+		t.Errorf("err = %v, want partial failure after 2000", err)
+	}
+	if len(batches) != 2 || batches[0] != batchModifyLimit || batches[1] != batchModifyLimit {
+		t.Errorf("batches = %v, want two of %d", batches, batchModifyLimit)
+	}
+
+	batches = nil
+	if err := batchModify(context.Background(), nil, svc, ids[:1500], nil, []string{"UNREAD"}); err != nil {
+		t.Fatalf("batchModify: %v", err)
+	}
+	if len(batches) != 2 || batches[1] != 500 {
+		t.Errorf("batches = %v, want [1000 500]", batches)
+	}
+}
+
+// metadataThread is a thread as Threads.Get returns it in metadata format
+// with threadHeaders.
+func metadataThread() *gmailapi.Thread {
+	msg := func(id string, ms int64, mimeType string, labels []string, snippet string, kv ...string) *gmailapi.Message {
+		var hs []*gmailapi.MessagePartHeader
+		for i := 0; i < len(kv); i += 2 {
+			hs = append(hs, &gmailapi.MessagePartHeader{Name: kv[i], Value: kv[i+1]})
+		}
+		return &gmailapi.Message{
+			Id:           id,
+			InternalDate: ms,
+			LabelIds:     labels,
+			Snippet:      snippet,
+			Payload:      &gmailapi.MessagePart{MimeType: mimeType, Headers: hs},
+		}
+	}
+	return &gmailapi.Thread{
+		Id: "t1",
+		Messages: []*gmailapi.Message{
+			msg("m1", 1767261600000, "multipart/alternative", []string{"INBOX"}, "Kickoff",
+				"From", "Alice <alice@example.com>", "To", "bob@example.com, \"Carol, PM\" <carol@example.com>", "Subject", "Q3 plan"),
+			msg("m2", 1767348000000, "multipart/mixed", []string{"INBOX", "Label_1"}, "Draft attached",
+				"From", "Bob <bob@example.com>", "To", "alice@example.com", "Cc", "carol@example.com", "Subject", "Re: Q3 plan"),
+			msg("m3", 1767434400000, "text/plain", []string{"INBOX", "UNREAD"}, "Looks good",
+				"From", "BOB@example.com", "To", "Alice <alice@example.com>", "Subject", "Re: Q3 plan"),
+		},
+	}
+}
+
+func TestSummarizeThread(t *testing.T) {
+	sum := summarizeThread(metadataThread())
+
+	if sum.Subject != "Q3 plan" || sum.Messages != 3 {
+		t.Errorf("subject/messages = %q/%d, want Q3 plan/3", sum.Subject, sum.Messages)
+	}
+	want := []threadParticipant{
+		{Address: "bob@example.com", Sent: 2, Received: 1},
+		{Address: "Alice <alice@example.com>", Sent: 1, Received: 2},
+		{Address: "Carol, PM <carol@example.com>", Sent: 0, Received: 2},
+	}
+	if len(sum.Participants) != len(want) {
+		t.Fatalf("participants = %+v, want %+v", sum.Participants, want)
+	}
+	for i := range want {
+		if sum.Participants[i] != want[i] {
+			t.Errorf("participant %d = %+v, want %+v", i, sum.Participants[i], want[i])
+		}
+	}
+	if got, want := sum.First.UTC().Format(time.RFC3339), "2026-01-01T10:00:00Z"; got != want {
+		t.Errorf("First = %s, want %s", got, want)
+	}
+	if got, want := sum.Last.UTC().Format(time.RFC3339), "2026-01-03T10:00:00Z"; got != want {
+		t.Errorf("Last = %s, want %s", got, want)
+	}
+	if strings.Join(sum.Labels, ",") != "INBOX,Label_1,UNREAD" {
+		t.Errorf("Labels = %v", sum.Labels)
+	}
+	if sum.Unread != 1 || sum.WithAttachments != 1 {
+		t.Errorf("unread/attachments = %d/%d, want 1/1", sum.Unread, sum.WithAttachments)
+	}
+	if sum.LatestFrom != "BOB@example.com" || sum.LatestSnippet != "Looks good" {
+		t.Errorf("latest = %q/%q", sum.LatestFrom, sum.LatestSnippet)
+	}
+
+	got := formatThreadSummary(sum, map[string]string{"Label_1": "Projects"})
+	for _, want := range []string{
+		"Subject: Q3 plan\n",
+		"Dates: 2026-01-01T10:00:00Z to 2026-01-03T10:00:00Z\n",
+		"Labels: INBOX, Projects, UNREAD\n",
+		"Unread: 1 of 3\n",
+		"Messages with attachments: 1\n",
+		"Participants (3):\n  - bob@example.com: sent 2, received 1\n",
+		"Latest message from BOB@example.com:\n  Looks good\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatThreadSummary missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatThreadMessage_HeadersOnly(t *testing.T) {
+	msg := metadataThread().Messages[1]
+	got := formatThreadMessage(msg, 1, 3, false)
+	want := "--- Message 2/3 (Message ID: m2) ---\nFrom: Bob <bob@example.com>\nTo: alice@example.com\nCc: carol@example.com\nSubject: Re: Q3 plan\n\n"
+	if got != want {
+		t.Errorf("formatThreadMessage =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func exportThreadFixture() *gmailapi.Thread {
+	first := &gmailapi.Message{
+		Id:           "m1",
+		InternalDate: 1710234000000, // 2024-03-12T09:00:00Z
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "From", Value: "Bob <bob@example.com>"},
+				{Name: "To", Value: "maria@example.com"},
+				{Name: "Subject", Value: "Q1 <draft>\r\n report"},
+				{Name: "X-Ignored", Value: "x"},
+			},
+			Body: &gmailapi.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte("Numbers & notes"))},
+		},
+	}
+	return &gmailapi.Thread{Id: "t1", Messages: []*gmailapi.Message{first, attachmentFixture()}}
+}
+
+func TestRenderThreadExport_Text(t *testing.T) {
+	thread := exportThreadFixture()
+	got, mimeType := renderThreadExport(thread, "text")
+	if mimeType != "text/plain" {
+		t.Errorf("MIME type = %q, want text/plain", mimeType)
+	}
+	want := "Subject: Q1 <draft>\r\n report\nThread ID: t1\nMessages: 2\n\n" +
+		formatThreadMessage(thread.Messages[0], 0, 2, true) +
+		formatThreadMessage(thread.Messages[1], 1, 2, true)
+	if got != want {
+		t.Errorf("renderThreadExport =\n%s\nwant\n%s", got, want)
+	}
+	if !strings.Contains(got, "Numbers & notes") || !strings.Contains(got, "Attachment ID: a-pdf") {
+		t.Errorf("text export is missing the body or attachments:\n%s", got)
+	}
+}
+
+func TestRenderThreadExport_HTML(t *testing.T) {
+	got, mimeType := renderThreadExport(exportThreadFixture(), "html")
+	if mimeType != "text/html" {
+		t.Errorf("MIME type = %q, want text/html", mimeType)
+	}
+	for _, want := range []string{
+		"<title>Q1 &lt;draft&gt;\r\n report</title>",
+		"<h2>Message 1/2</h2>",
+		"<h2>Message 2/2</h2>",
+		"<tr><th align=\"left\">From</th><td>Bob &lt;bob@example.com&gt;</td></tr>",
+		"<pre>Numbers &amp; notes</pre>",
+		"<li>Q1 Report.PDF</li>",
+		"<li>budget.xlsx</li>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML export missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "X-Ignored") {
+		t.Error("HTML export shows headers read_thread doesn't")
+	}
+	// Inline images are part of the body, not attachments.
+	if strings.Count(got, "<li>") != 3 {
+		t.Errorf("want 3 attachments listed:\n%s", got)
+	}
+	if strings.Count(got, "<hr>") != 2 {
+		t.Errorf("want a separator before each message:\n%s", got)
+	}
+}
+
+func TestThreadExportName(t *testing.T) {
+	thread := exportThreadFixture()
+	if got, want := threadExportName(thread, "text"), "Thread - Q1 <draft> report - 2024-03-12.txt"; got != want {
+		t.Errorf("name = %q, want %q", got, want)
+	}
+	if got, want := threadExportName(thread, "html"), "Thread - Q1 <draft> report - 2024-03-12.html"; got != want {
+		t.Errorf("name = %q, want %q", got, want)
+	}
+
+	undated := &gmailapi.Thread{Messages: []*gmailapi.Message{{Payload: &gmailapi.MessagePart{}}}}
+	if got, want := threadExportName(undated, "text"), "Thread - (no subject).txt"; got != want {
+		t.Errorf("name = %q, want %q", got, want)
+	}
+}
+
+func TestFormatExportedAttachments(t *testing.T) {
+	got := formatExportedAttachments("f1", []exportedAttachment{
+		{Name: "a.pdf", FileID: "d1"},
+		{Name: "b.png", Err: errors.New("quota exceeded")},
+	})
+	want := "\nAttachments folder ID: f1\nSaved 1 of 2 attachments:\n  - a.pdf (File ID: d1)\n  - b.png: error: quota exceeded\n"
+	if got != want {
+		t.Errorf("formatExportedAttachments =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestNewComposePolicy(t *testing.T) {
+	p, err := NewComposePolicy([]string{"archive@example.com", " "}, []string{"X-Agent=google-mcp", "X-Mailer = bot 1.0 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.AlwaysBcc) != 1 || p.AlwaysBcc[0] != "archive@example.com" {
+		t.Errorf("AlwaysBcc = %v", p.AlwaysBcc)
+	}
+	want := []Header{{"X-Agent", "google-mcp"}, {"X-Mailer", "bot 1.0"}}
+	if len(p.Headers) != 2 || p.Headers[0] != want[0] || p.Headers[1] != want[1] {
+		t.Errorf("Headers = %+v, want %+v", p.Headers, want)
+	}
+
+	for _, tc := range []struct {
+		bcc, header, wantErr string
+	}{
+		{bcc: "not an address", wantErr: "invalid --always-bcc"},
+		{header: "X-Agent", wantErr: "want name=value"},
+		{header: "=x", wantErr: "want name=value"},
+		{header: "X Agent=x", wantErr: "printable ASCII"},
+		{header: "bcc=spy@example.com", wantErr: "cannot set Bcc"},
+		{header: "Content-Type=text/html", wantErr: "cannot set Content-Type"},
+	} {
+		var bcc, headers []string
+		if tc.bcc != "" {
+			bcc = []string{tc.bcc}
+		}
+		if tc.header != "" {
+			headers = []string{tc.header}
+		}
+		if _, err := NewComposePolicy(bcc, headers); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("NewComposePolicy(%q, %q) = %v, want error containing %q", tc.bcc, tc.header, err, tc.wantErr)
+		}
+	}
+}
+
+func TestBuildMessage_ComposePolicy(t *testing.T) {
+	policy := &ComposePolicy{
+		AlwaysBcc: []string{"archive@example.com"},
+		Headers:   []Header{{"X-Agent", "google-mcp"}},
+	}
+	decode := func(r *composeResult) string {
+		raw, err := base64.URLEncoding.DecodeString(r.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input composeInput
+	}{
+		{"plain", composeInput{To: "bob@example.com", Bcc: "carol@example.com", Subject: "Hi", Body: "Hello"}},
+		{"multipart", composeInput{To: "bob@example.com", Bcc: "carol@example.com", Subject: "Hi", Body: "Hello",
+			Attachments: []attachment{{Name: "a.txt", Content: base64.StdEncoding.EncodeToString([]byte("x"))}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := buildMessage(context.Background(), nil, "me", tc.input, "", policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw := decode(result)
+			headers, _, _ := strings.Cut(raw, "\r\n\r\n")
+			for _, want := range []string{"\r\nBcc: carol@example.com, archive@example.com\r\n", "\r\nX-Agent: google-mcp\r\n"} {
+				if !strings.Contains("\r\n"+headers+"\r\n", want) {
+					t.Errorf("headers missing %q:\n%s", want, headers)
+				}
+			}
+			if strings.Count(raw, "X-Agent:") != 1 {
+				t.Errorf("X-Agent should appear once:\n%s", raw)
+			}
+			if got := result.note(); !strings.Contains(got, "Bcc: archive@example.com\n  X-Agent: google-mcp") {
+				t.Errorf("note = %q", got)
+			}
+		})
+	}
+
+	// An input already Bcc'ing the archive is not given a second copy, and
+	// line breaks in input cannot add headers.
+	result, err := buildMessage(context.Background(), nil, "me", composeInput{
+		To:      "bob@example.com",
+		Bcc:     "Archive <ARCHIVE@example.com>",
+		Subject: "Hi\r\nX-Agent: spoofed",
+		Body:    "Hello",
+	}, "", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := decode(result)
+	if strings.Contains(raw, "\r\nX-Agent: spoofed") || strings.Count(raw, "archive@example.com") != 0 || !strings.Contains(raw, "Bcc: Archive <ARCHIVE@example.com>\r\n") {
+		t.Errorf("unexpected message:\n%s", raw)
+	}
+	if got := result.note(); !strings.Contains(got, "Bcc: archive@example.com (already a recipient)") {
+		t.Errorf("note = %q", got)
+	}
+
+	if result, _ := buildMessage(context.Background(), nil, "me", composeInput{To: "bob@example.com", Subject: "Hi", Body: "x"}, "", nil); result.note() != "" {
+		t.Errorf("note without policy = %q", result.note())
+	}
+}
+
+func TestWriteFoldedHeader(t *testing.T) {
+	value := strings.Repeat("Ünïcödé agent header value ", 6)
+	var sb strings.Builder
+	writeFoldedHeader(&sb, "X-Agent", value)
+	out := sb.String()
+
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatalf("header not terminated: %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Errorf("long header not folded: %q", out)
+	}
+	for i, l := range lines {
+		if len(l) > maxHeaderLine {
+			t.Errorf("line %d is %d bytes: %q", i, len(l), l)
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d does not start with a space: %q", i, l)
+		}
+	}
+
+	unfolded := strings.TrimPrefix(strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n", ""), "X-Agent: ")
+	decoded, err := new(mime.WordDecoder).DecodeHeader(unfolded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != strings.TrimSpace(value) && decoded != value {
+		t.Errorf("decoded = %q, want %q", decoded, value)
+	}
+
+	sb.Reset()
+	writeFoldedHeader(&sb, "X-Mailer", "bot 1.0")
+	if sb.String() != "X-Mailer: bot 1.0\r\n" {
+		t.Errorf("short header = %q", sb.String())
+	}
+}
+
+// newTestSendGuard returns a guard driven by the returned clock.
+func newTestSendGuard(maxPerHour int, duplicateWindow time.Duration) (*sendGuard, *time.Time) {
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	g := newSendGuard(maxPerHour, duplicateWindow)
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestSendGuard_HourlyLimit(t *testing.T) {
+	g, now := newTestSendGuard(3, 0)
+	for i := range 3 {
+		if _, err := g.admit(messageDigest("bob@example.com", fmt.Sprint("msg ", i), "x"), false); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+		*now = now.Add(10 * time.Minute)
+	}
+
+	// 09:30: three sends in the last hour.
+	_, err := g.admit(messageDigest("bob@example.com", "more", "x"), true)
+	if err == nil || !strings.Contains(err.Error(), "3 messages were sent in the last hour") || !strings.Contains(err.Error(), "in 30m0s") {
+		t.Fatalf("fourth send: err = %v, want hourly limit refusal", err)
+	}
+
+	// 10:00:01: the 09:00 send has left the window.
+	*now = now.Add(30*time.Minute + time.Second)
+	release, err := g.admit(messageDigest("bob@example.com", "more", "x"), false)
+	if err != nil {
+		t.Fatalf("send after window slid: %v", err)
+	}
+	if _, err := g.admit(messageDigest("bob@example.com", "again", "x"), false); err == nil {
+		t.Fatal("send beyond limit allowed")
+	}
+
+	// A failed send gives its slot back.
+	release()
+	release()
+	if _, err := g.admit(messageDigest("bob@example.com", "again", "x"), false); err != nil {
+		t.Fatalf("send after release: %v", err)
+	}
+}
+
+func TestSendGuard_Duplicates(t *testing.T) {
+	g, now := newTestSendGuard(0, 10*time.Minute)
+	sum := messageDigest("Bob <bob@example.com>, carol@example.com", "Invoice", "Please pay.\n")
+	if _, err := g.admit(sum, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same recipients in another order and form, same content.
+	*now = now.Add(2 * time.Minute)
+	again := messageDigest("CAROL@example.com,bob@example.com", " Invoice", "Please pay.")
+	_, err := g.admit(again, false)
+	if err == nil || !strings.Contains(err.Error(), "sent 2m0s ago") || !strings.Contains(err.Error(), "force: true") {
+		t.Fatalf("duplicate: err = %v, want duplicate refusal", err)
+	}
+	if _, err := g.admit(again, true); err != nil {
+		t.Fatalf("forced duplicate: %v", err)
+	}
+
+	if _, err := g.admit(messageDigest("bob@example.com", "Invoice", "Please pay."), false); err != nil {
+		t.Errorf("different recipients refused: %v", err)
+	}
+	if _, err := g.admit(messageDigest("bob@example.com, carol@example.com", "Invoice", "Please pay twice."), false); err != nil {
+		t.Errorf("different body refused: %v", err)
+	}
+
+	// Outside the window the same message is allowed again.
+	*now = now.Add(10 * time.Minute)
+	if _, err := g.admit(sum, false); err != nil {
+		t.Errorf("send after window: %v", err)
+	}
+
+	// Only the last sendGuardHistory sends are remembered.
+	g, _ = newTestSendGuard(0, time.Hour)
+	for i := range sendGuardHistory + 1 {
+		if _, err := g.admit(messageDigest("bob@example.com", fmt.Sprint(i), "x"), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(g.recent) != sendGuardHistory {
+		t.Errorf("remembered %d sends, want %d", len(g.recent), sendGuardHistory)
+	}
+	if _, err := g.admit(messageDigest("bob@example.com", "0", "x"), false); err != nil {
+		t.Errorf("forgotten send refused: %v", err)
+	}
+}
+
+func TestSendGuard_Disabled(t *testing.T) {
+	if g := newSendGuard(0, 0); g != nil {
+		t.Fatalf("newSendGuard(0, 0) = %+v, want nil", g)
+	}
+	var g *sendGuard
+	if g.checksDuplicates() {
+		t.Error("nil guard checks duplicates")
+	}
+	for range 3 {
+		if _, err := g.admit(messageDigest("bob@example.com", "s", "b"), false); err != nil {
+			t.Fatalf("nil guard refused: %v", err)
+		}
+	}
+}
+
+func TestSendGuard_DraftMatchesSentMessage(t *testing.T) {
+	// The draft as Gmail returns it: decoded headers, CRLF body.
+	body := base64.URLEncoding.EncodeToString([]byte("Hi Bob,\r\nsee you.\r\n"))
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/drafts/d1") || r.URL.Query().Get("format") != "full" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&gmailapi.Draft{Id: "d1", Message: &gmailapi.Message{Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "To", Value: "Bob <bob@example.com>"},
+				{Name: "Subject", Value: "Lunch"},
+			},
+			Body: &gmailapi.MessagePartBody{Data: body},
+		}}})
+	})
+
+	g, _ := newTestSendGuard(0, 10*time.Minute)
+	if _, err := g.admit(messageDigest("bob@example.com", "Lunch", "Hi Bob,\nsee you."), false); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := draftDigest(context.Background(), svc, "me", "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.admit(sum, false); err == nil || !strings.Contains(err.Error(), "identical message") {
+		t.Errorf("send_draft of a just-sent message: err = %v, want duplicate refusal", err)
+	}
+}
+
+// TestListAccountsShared checks that the Gmail server exposes list_accounts
+// exactly once, as server.RegisterAccountsListTool defines it for every
+// service, and keeps it under an --enable list that omits it.
+func TestMessageColumns(t *testing.T) {
+	table, err := server.NewTable("json", messageColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Add(messageRow{
+		Account: "work",
+		Msg: &gmailapi.Message{Id: "m1", ThreadId: "t1", LabelIds: []string{"INBOX", "UNREAD", "Label_7"},
+			Snippet: "See attached", SizeEstimate: 2048,
+			Payload: &gmailapi.MessagePart{MimeType: "multipart/mixed", Headers: []*gmailapi.MessagePartHeader{
+				{Name: "From", Value: `"Doe, Jane" <jane@example.com>`},
+				{Name: "Subject", Value: "Q3 report"},
+				{Name: "Date", Value: "Mon, 2 Mar 2026 09:00:00 +0000"},
+			}}},
+		Labels: map[string]string{"INBOX": "INBOX", "UNREAD": "UNREAD", "Label_7": "Work/Reports"},
+	})
+	want := `[{"account":"work","message_id":"m1","thread_id":"t1","from":"\"Doe, Jane\" <jane@example.com>","subject":"Q3 report",` +
+		`"date":"Mon, 2 Mar 2026 09:00:00 +0000","labels":["INBOX","UNREAD","Work/Reports"],"unread":true,"size":2048,"has_attachments":true,"snippet":"See attached"}]`
+	if got := table.String(); got != want {
+		t.Errorf("json =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseLabelRules(t *testing.T) {
+	rules, err := parseLabelRules([]byte(`{"rules": [
+		{"name": "Receipts", "query": "receipt OR invoice", "add_labels": ["Receipts"], "remove_labels": ["INBOX"]},
+		{"query": "from:news@example.com", "remove_labels": ["UNREAD"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].title() != "Receipts" || rules[1].title() != "from:news@example.com" {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	for _, tt := range []struct {
+		doc, want string
+	}{
+		{`{"rules": []}`, "no rules"},
+		{`{"rules": [{"query": " ", "add_labels": ["A"]}]}`, "rule 1: query is required"},
+		{`{"rules": [{"name": "x", "query": "a"}]}`, "rule 1 (x): at least one of add_labels or remove_labels"},
+		{`{"rules": [{"query": "a", "add_label": ["A"]}]}`, "unknown field"},
+		{`rules: []`, "parsing rules"},
+	} {
+		if _, err := parseLabelRules([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseLabelRules(%s) error = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+// fakeRulesMailbox serves labels, paginated message searches and batch
+// modifies from memory. matches maps a search query to the IDs it returns.
+type fakeRulesMailbox struct {
+	t       *testing.T
+	labels  []*gmailapi.Label
+	matches map[string][]string
+	batches []gmailapi.BatchModifyMessagesRequest
+	fail    string // query whose search fails
+}
+
+func (m *fakeRulesMailbox) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/labels") && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(&gmailapi.ListLabelsResponse{Labels: m.labels})
+	case strings.HasSuffix(r.URL.Path, "/labels") && r.Method == http.MethodPost:
+		var l gmailapi.Label
+		json.NewDecoder(r.Body).Decode(&l)
+		l.Id = fmt.Sprintf("Label_new%d", len(m.labels))
+		m.labels = append(m.labels, &l)
+		json.NewEncoder(w).Encode(&l)
+	case strings.HasSuffix(r.URL.Path, "/messages") && r.Method == http.MethodGet:
+		q := r.URL.Query().Get("q")
+		if q == m.fail {
+			http.Error(w, `{"error":{"code":400,"message":"Invalid query"}}`, http.StatusBadRequest)
+			return
+		}
+		// Two messages per page, to exercise pagination.
+		ids := m.matches[q]
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		end := min(start+2, len(ids))
+		resp := &gmailapi.ListMessagesResponse{}
+		for _, id := range ids[start:end] {
+			resp.Messages = append(resp.Messages, &gmailapi.Message{Id: id})
+		}
+		if end < len(ids) {
+			resp.NextPageToken = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(resp)
+	case strings.HasSuffix(r.URL.Path, "/messages/batchModify"):
+		var body gmailapi.BatchModifyMessagesRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		m.batches = append(m.batches, body)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		m.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+func TestApplyLabelRules(t *testing.T) {
+	after := time.Unix(1767225600, 0)
+	newMailbox := func() *fakeRulesMailbox {
+		return &fakeRulesMailbox{t: t,
+			labels: []*gmailapi.Label{
+				{Id: "INBOX", Name: "INBOX"},
+				{Id: "UNREAD", Name: "UNREAD"},
+				{Id: "Label_1", Name: "Receipts"},
+			},
+			matches: map[string][]string{
+				"(receipt) after:1767225600":   {"m1", "m2", "m3"},
+				"(from:news) after:1767225600": {"m4", "m5", "m6", "m7", "m8"},
+			},
+			fail: "(bad:query) after:1767225600",
+		}
+	}
+	rules := []labelRule{
+		{Name: "Receipts", Query: "receipt", AddLabels: []string{"receipts"}, RemoveLabels: []string{"INBOX"}},
+		{Query: "bad:query", AddLabels: []string{"Receipts"}},
+		{Name: "News", Query: "from:news", AddLabels: []string{"Newsletters"}, RemoveLabels: []string{"UNREAD"}},
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		mb := newMailbox()
+		resolver, err := newLabelResolver(context.Background(), newFakeService(t, mb.handle), true, "create_missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := ruleOptions{After: after, MaxMessages: 4}
+		results, err := applyLabelRules(context.Background(), nil, resolver, rules, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := results[0]; r.Matched != 3 || r.Modified != 3 || r.Err != nil || r.Truncated {
+			t.Errorf("rule 1 = %+v, want 3 matched and modified", r)
+		}
+		if r := results[1]; r.Err == nil || !strings.Contains(r.Err.Error(), "Invalid query") || r.Modified != 0 {
+			t.Errorf("rule 2 = %+v, want search error", r)
+		}
+		if r := results[2]; r.Matched != 4 || r.Modified != 4 || !r.Truncated {
+			t.Errorf("rule 3 = %+v, want 4 matched (truncated)", r)
+		}
+		if len(mb.batches) != 2 {
+			t.Fatalf("batches = %+v, want 2", mb.batches)
+		}
+		if got := fmt.Sprint(mb.batches[0].Ids, mb.batches[0].AddLabelIds, mb.batches[0].RemoveLabelIds); got != "[m1 m2 m3] [Label_1] [INBOX]" {
+			t.Errorf("batch 1 = %s", got)
+		}
+		if got := fmt.Sprint(mb.batches[1].Ids, mb.batches[1].AddLabelIds); got != "[m4 m5 m6 m7] [Label_new3]" {
+			t.Errorf("batch 2 = %s", got)
+		}
+
+		out := formatRuleResults(results, resolver, opts)
+		for _, want := range []string{
+			"1. Receipts\n   Query: receipt\n   Add labels: Receipts (Label_1)\n   Remove labels: INBOX\n   Matched: 3\n   Modified: 3\n",
+			"2. bad:query\n",
+			"   Error: searching messages:",
+			"   Matched: 4 (limit reached; more messages match)\n",
+			"3 rules, 7 messages matched, 7 modified, 1 rules failed.\n",
+			"Created labels: Newsletters\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("report missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mb := newMailbox()
+		resolver, err := newLabelResolver(context.Background(), newFakeService(t, mb.handle), true, "create_missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := ruleOptions{After: after, MaxMessages: 10, DryRun: true}
+		results, err := applyLabelRules(context.Background(), nil, resolver, rules, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mb.batches) != 0 || len(mb.labels) != 3 {
+			t.Errorf("dry run modified the mailbox: batches %d, labels %d", len(mb.batches), len(mb.labels))
+		}
+		if r := results[2]; r.Matched != 5 || fmt.Sprint(r.Pending) != "[Newsletters]" {
+			t.Errorf("rule 3 = %+v, want 5 matched and Newsletters pending", r)
+		}
+		out := formatRuleResults(results, resolver, opts)
+		for _, want := range []string{"Dry run: no messages were modified.", "Labels to create: Newsletters", "3 rules, 8 messages matched, 1 rules failed."} {
+			if !strings.Contains(out, want) {
+				t.Errorf("report missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "Modified:") {
+			t.Errorf("dry run report should not list modified counts:\n%s", out)
+		}
+	})
+
+	t.Run("unknown label", func(t *testing.T) {
+		mb := newMailbox()
+		resolver, err := newLabelResolver(context.Background(), newFakeService(t, mb.handle), false, "create_missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = applyLabelRules(context.Background(), nil, resolver, rules, ruleOptions{After: after, MaxMessages: 10})
+		if err == nil || !strings.Contains(err.Error(), `rule 3 (News): label "Newsletters" not found`) {
+			t.Errorf("err = %v, want rule 3 validation error", err)
+		}
+		if len(mb.batches) != 0 {
+			t.Errorf("no rule should run when validation fails, got %d batches", len(mb.batches))
+		}
+	})
+
+	t.Run("invalid rule creates no labels", func(t *testing.T) {
+		mb := newMailbox()
+		mb.labels = append(mb.labels, &gmailapi.Label{Id: "Label_2", Name: "Work"}, &gmailapi.Label{Id: "Label_3", Name: "work"})
+		resolver, err := newLabelResolver(context.Background(), newFakeService(t, mb.handle), true, "create_missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		invalid := []labelRule{
+			{Query: "receipt", AddLabels: []string{"Newsletters"}},
+			{Name: "Work", Query: "from:boss", AddLabels: []string{"work"}},
+		}
+		_, err = applyLabelRules(context.Background(), nil, resolver, invalid, ruleOptions{After: after, MaxMessages: 10})
+		if err == nil || !strings.Contains(err.Error(), `rule 2 (Work): label name "work" is ambiguous`) {
+			t.Errorf("err = %v, want rule 2 validation error", err)
+		}
+		if len(mb.labels) != 5 || len(resolver.created) != 0 {
+			t.Errorf("labels were created before validation failed: %v", resolver.created)
+		}
+	})
+}
+
+func TestForEachChunk(t *testing.T) {
+	ids := make([]string, 2500)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("m%d", i)
+	}
+	var sizes []int
+	results := forEachChunk(context.Background(), nil, ids, batchDeleteLimit, "Deleted", func(chunk []string) error {
+		sizes = append(sizes, len(chunk))
+		if len(sizes) == 2 {
+			return errors.New("backend error")
+		}
+		return nil
+	})
+	if fmt.Sprint(sizes) != "[1000 1000 500]" {
+		t.Errorf("chunk sizes = %v", sizes)
+	}
+
+	text, done := formatChunkResults("permanently deleted", results)
+	if done != 1500 {
+		t.Errorf("done = %d, want 1500", done)
+	}
+	want := "1500 of 2500 messages permanently deleted.\n\n" +
+		"Chunk 1 (messages 1-1000): ok\n" +
+		"Chunk 2 (messages 1001-2000): failed: backend error\n" +
+		"Chunk 3 (messages 2001-2500): ok\n"
+	if text != want {
+		t.Errorf("formatChunkResults() =\n%s\nwant:\n%s", text, want)
+	}
+
+	// A single successful chunk needs no breakdown.
+	text, _ = formatChunkResults("reported as spam", forEachChunk(context.Background(), nil, ids[:3], batchModifyLimit, "Modified", func([]string) error { return nil }))
+	if text != "3 of 3 messages reported as spam.\n" {
+		t.Errorf("single chunk = %q", text)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = forEachChunk(ctx, nil, ids, batchDeleteLimit, "Deleted", func([]string) error {
+		t.Error("fn called after cancellation")
+		return nil
+	})
+	if len(results) != 3 || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "not run") {
+		t.Errorf("results after cancel = %+v", results)
+	}
+}
+
+func TestSpamLabelResult(t *testing.T) {
+	var calls []string
+	svc := newFakeService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/messages/batchModify"):
+			var body gmailapi.BatchModifyMessagesRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			calls = append(calls, fmt.Sprintf("batchModify %d +%v -%v", len(body.Ids), body.AddLabelIds, body.RemoveLabelIds))
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(r.URL.Path, "/modify"):
+			var body gmailapi.ModifyMessageRequest
+			json.NewDecoder(r.Body).Decode(&body)
+			calls = append(calls, fmt.Sprintf("modify %s +%v -%v", path.Base(path.Dir(r.URL.Path)), body.AddLabelIds, body.RemoveLabelIds))
+			w.Write([]byte(`{"id":"m1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	res, _, err := spamLabelResult(context.Background(), nil, svc, []string{"m1"}, []string{"INBOX"}, []string{"SPAM"}, "moved from spam to the inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Content[0].(*mcp.TextContent).Text; got != "1 of 1 messages moved from spam to the inbox.\n" {
+		t.Errorf("result = %q", got)
+	}
+
+	ids := make([]string, 1001)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("m%d", i)
+	}
+	if _, _, err := spamLabelResult(context.Background(), nil, svc, ids, []string{"SPAM"}, []string{"INBOX"}, "reported as spam"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[modify m1 +[INBOX] -[SPAM] batchModify 1000 +[SPAM] -[INBOX] modify m1000 +[SPAM] -[INBOX]]"
+	if fmt.Sprint(calls) != want {
+		t.Errorf("calls = %v\nwant %s", calls, want)
+	}
+}
+
+func TestTrashQuery(t *testing.T) {
+	if got := trashQuery(0); got != "in:trash" {
+		t.Errorf("trashQuery(0) = %q", got)
+	}
+	if got := trashQuery(30); got != "in:trash older_than:30d" {
+		t.Errorf("trashQuery(30) = %q", got)
+	}
+}
+
+func TestCheckMailbox(t *testing.T) {
+	for _, mailbox := range []string{"", "boss@example.com", "first.last+tag@sub.example.org"} {
+		if err := checkMailbox(mailbox); err != nil {
+			t.Errorf("checkMailbox(%q) = %v, want nil", mailbox, err)
+		}
+	}
+	for _, mailbox := range []string{"me", "boss", "Boss <boss@example.com>", " boss@example.com", "boss@example.com, other@example.com"} {
+		if err := checkMailbox(mailbox); err == nil || !strings.Contains(err.Error(), "invalid mailbox") {
+			t.Errorf("checkMailbox(%q) = %v, want invalid mailbox error", mailbox, err)
+		}
+	}
+
+	if err := checkMailboxAccounts("boss@example.com", []string{"work", "personal"}); err == nil {
+		t.Error("checkMailboxAccounts with two accounts succeeded")
+	}
+	if err := checkMailboxAccounts("", []string{"work", "personal"}); err != nil {
+		t.Errorf("checkMailboxAccounts without mailbox = %v", err)
+	}
+}
+
+// capturingTransport records the path of every request and serves it with
+// handler, so tests can check which mailbox a call addressed.
+type capturingTransport struct {
+	handler http.HandlerFunc
+	paths   []string
+}
+
+func (c *capturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.paths = append(c.paths, req.URL.Path)
+	rec := httptest.NewRecorder()
+	c.handler(rec, req)
+	return rec.Result(), nil
+}
+
+func TestMailbox_UserIDInRequestPath(t *testing.T) {
+	capture := &capturingTransport{handler: func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/labels"):
+			json.NewEncoder(w).Encode(&gmailapi.ListLabelsResponse{Labels: []*gmailapi.Label{{Id: "INBOX", Name: "INBOX"}}})
+		case strings.HasSuffix(r.URL.Path, "/settings/sendAs"):
+			json.NewEncoder(w).Encode(&gmailapi.ListSendAsResponse{SendAs: []*gmailapi.SendAs{{SendAsEmail: "boss@example.com", IsPrimary: true}}})
+		case strings.Contains(r.URL.Path, "/drafts/"):
+			json.NewEncoder(w).Encode(&gmailapi.Draft{Id: "d1", Message: &gmailapi.Message{Id: "m1"}})
+		default:
+			json.NewEncoder(w).Encode(&gmailapi.Message{Id: "m1", ThreadId: "t1"})
+		}
+	}}
+	svc, err := gmailapi.NewService(context.Background(),
+		option.WithHTTPClient(&http.Client{Transport: capture}),
+		option.WithEndpoint("https://gmail.test/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := userID("boss@example.com")
+	if _, err := getMessageForRead(context.Background(), svc, user, "m1", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := labelNames(context.Background(), svc, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := buildMessage(context.Background(), svc, user, composeInput{From: "boss@example.com", To: "bob@example.com", Subject: "Hi", Body: "x"}, "m1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := draftDigest(context.Background(), svc, user, "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Users.Messages.List(userID("")).Do(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/gmail/v1/users/boss@example.com/messages/m1",
+		"/gmail/v1/users/boss@example.com/labels",
+		"/gmail/v1/users/boss@example.com/settings/sendAs",
+		"/gmail/v1/users/boss@example.com/messages/m1",
+		"/gmail/v1/users/boss@example.com/drafts/d1",
+		"/gmail/v1/users/me/messages",
+	}
+	if !slices.Equal(capture.paths, want) {
+		t.Errorf("request paths:\n got %q\nwant %q", capture.paths, want)
+	}
+}
+
+func TestMailbox_InvalidRejectedByTools(t *testing.T) {
+	session := connect(t, newTestServer(t))
+	tools := map[string]map[string]any{
+		"search_messages":      {},
+		"read_message":         {"message_id": "m1"},
+		"check_authentication": {"message_id": "m1"},
+		"get_attachment":       {"message_id": "m1", "attachment_id": "a1"},
+		"send_message":         {"to": "bob@example.com", "subject": "Hi", "body": "x"},
+		"reply_to_thread":      {"thread_id": "t1", "body": "x"},
+		"preview_message":      {"to": "bob@example.com", "subject": "Hi", "body": "x"},
+		"list_drafts":          {},
+		"get_draft":            {"draft_id": "d1"},
+		"list_labels":          {},
+		"create_label":         {"name": "Projects"},
+	}
+	for name, args := range tools {
+		args["mailbox"] = "Boss <boss@example.com>"
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s): %v", name, err)
+		}
+		if !res.IsError {
+			t.Errorf("%s accepted an invalid mailbox", name)
+			continue
+		}
+		if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "invalid mailbox") {
+			t.Errorf("%s error = %q, want invalid mailbox", name, text)
+		}
+	}
+}
+
+// newMultiAccountServer creates a test server whose manager has two
+// accounts, so that account "all" resolves to both.
+func newMultiAccountServer(t *testing.T) *server.Server {
+	t.Helper()
+	mgr := newTestManager(t)
+	tokens := `{"accounts":{"work":{"email":"me@work.example","token":{"refresh_token":"r"}},"home":{"email":"me@home.example","token":{"refresh_token":"r"}}}}`
+	if err := os.WriteFile(filepath.Join(mgr.ConfigDir(), "tokens.json"), []byte(tokens), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := auth.NewManager(mgr.ConfigDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(&mcp.Implementation{Name: "test-gmail", Version: "test"}, nil)
+	RegisterTools(srv, mgr)
+	return srv
+}
+
+func TestSingleAccountTools_RejectAll(t *testing.T) {
+	session := connect(t, newMultiAccountServer(t))
+	tools := map[string]map[string]any{
+		"snooze_message": {"message_id": "m1", "until": time.Now().Add(time.Hour).Format(time.RFC3339)},
+		"unsnooze":       {"message_id": "m1"},
+		"watch_mailbox":  {"topic_name": "projects/my-project/topics/gmail-push"},
+		"stop_watch":     {},
+	}
+	for name, args := range tools {
+		args["account"] = "all"
+		res, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatalf("CallTool(%s): %v", name, err)
+		}
+		if !res.IsError {
+			t.Errorf("%s accepted account 'all'", name)
+			continue
+		}
+		if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "a single account is required") {
+			t.Errorf("%s error = %q, want a single account required", name, text)
+		}
+	}
+}
+
+// replyFixtureThread is a thread between alice@example.com, who owns the
+// account (with the support@example.com alias), Bob and Carol. Its last
+// message was sent by the account and the one before is an unsent draft.
+func replyFixtureThread() *gmailapi.Thread {
+	msg := func(id string, labels []string, kv ...string) *gmailapi.Message {
+		var hs []*gmailapi.MessagePartHeader
+		for i := 0; i < len(kv); i += 2 {
+			hs = append(hs, &gmailapi.MessagePartHeader{Name: kv[i], Value: kv[i+1]})
+		}
+		return &gmailapi.Message{Id: id, LabelIds: labels, Payload: &gmailapi.MessagePart{Headers: hs}}
+	}
+	return &gmailapi.Thread{
+		Id: "t1",
+		Messages: []*gmailapi.Message{
+			msg("m1", []string{"INBOX"},
+				"From", "Carol <carol@example.com>", "To", "support@example.com", "Subject", "Order 42"),
+			msg("m2", []string{"INBOX", "UNREAD"},
+				"From", "Bob <bob@example.com>", "To", "Example Support <SUPPORT@example.com>, carol@example.com",
+				"Cc", "alice@example.com, dave@example.com, Carol <carol@example.com>", "Subject", "Re: Order 42"),
+			msg("m3", []string{"DRAFT"},
+				"From", "alice@example.com", "To", "bob@example.com", "Subject", "Re: Order 42"),
+			msg("m4", []string{"SENT"},
+				"From", "Example Support <support@example.com>", "To", "bob@example.com", "Subject", "Re: Order 42"),
+		},
+	}
+}
+
+func TestPlanThreadReply(t *testing.T) {
+	plan, err := planThreadReply(replyFixtureThread(), testSendAs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m4 is the account's own and m3 a draft, so the reply goes to m2.
+	if plan.Message.Id != "m2" || plan.Skipped != 1 {
+		t.Errorf("replying to %s, skipped %d; want m2, 1", plan.Message.Id, plan.Skipped)
+	}
+	if plan.To != `"Bob" <bob@example.com>` || plan.Cc != "" {
+		t.Errorf("To = %q, Cc = %q; want Bob only", plan.To, plan.Cc)
+	}
+	if plan.From != "support@example.com" {
+		t.Errorf("From = %q, want the support@example.com alias m2 was sent to", plan.From)
+	}
+	if plan.Subject != "Re: Order 42" {
+		t.Errorf("Subject = %q", plan.Subject)
+	}
+
+	plan, err = planThreadReply(replyFixtureThread(), testSendAs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The aliases alice@ and support@ are left out, and Carol is listed
+	// once.
+	if want := "<carol@example.com>, <dave@example.com>"; plan.Cc != want {
+		t.Errorf("reply_all Cc = %q, want %q", plan.Cc, want)
+	}
+}
+
+func TestPlanThreadReply_ReplyToAndSubject(t *testing.T) {
+	thread := replyFixtureThread()
+	thread.Messages = thread.Messages[:1]
+	thread.Messages[0].Payload.Headers = append(thread.Messages[0].Payload.Headers,
+		&gmailapi.MessagePartHeader{Name: "Reply-To", Value: "orders@example.com, alice@example.com"})
+	plan, err := planThreadReply(thread, testSendAs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Message.Id != "m1" || plan.Skipped != 0 {
+		t.Errorf("replying to %s, skipped %d; want m1, 0", plan.Message.Id, plan.Skipped)
+	}
+	if plan.To != "<orders@example.com>" || plan.Cc != "" {
+		t.Errorf("To = %q, Cc = %q; want the Reply-To address without the account's own", plan.To, plan.Cc)
+	}
+	if plan.Subject != "Re: Order 42" {
+		t.Errorf("Subject = %q", plan.Subject)
+	}
+}
+
+func TestPlanThreadReply_OnlyOwnMessages(t *testing.T) {
+	thread := replyFixtureThread()
+	thread.Messages = thread.Messages[2:]
+	if _, err := planThreadReply(thread, testSendAs, false); err == nil || !strings.Contains(err.Error(), "no message from anyone else") {
+		t.Errorf("err = %v, want no message to reply to", err)
+	}
+
+	// A message from an alias counts as the account's own even without
+	// the SENT label, e.g. when it was sent from another client.
+	thread = replyFixtureThread()
+	thread.Messages = thread.Messages[:1]
+	thread.Messages[0].Payload.Headers[0].Value = "Alice <ALICE@example.com>"
+	if _, err := planThreadReply(thread, testSendAs, false); err == nil {
+		t.Error("planThreadReply() replied to a message from the account's own address")
+	}
+}
+
+// TestAttachmentMetadata_NoContentReads checks that preview_message
+// describes Drive and local attachments from their metadata alone: the
+// lookups it is given can only return metadata, and the previewed message
+// doesn't carry their content.
+func TestAttachmentMetadata_NoContentReads(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "q4.pdf"), []byte("%PDF-local-content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lfs, err := localfs.New([]localfs.Dir{{Path: dir, Mode: localfs.ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs.Close()
+
+	var driveLookups, stats []string
+	meta := attachmentMetadata{
+		driveFile: func(da driveAttachment) (*bridge.GetDriveFileMetadataResult, error) {
+			driveLookups = append(driveLookups, da.FileID)
+			if da.FileID == "doc" {
+				return &bridge.GetDriveFileMetadataResult{FileID: "doc", FileName: "Plan", MIMEType: "application/vnd.google-apps.document"}, nil
+			}
+			return &bridge.GetDriveFileMetadataResult{FileID: da.FileID, FileName: "spec.pdf", MIMEType: "application/pdf", Size: 4096}, nil
+		},
+		statLocal: func(path string) (os.FileInfo, error) {
+			stats = append(stats, path)
+			info, _, err := lfs.Stat(path)
+			return info, err
+		},
+	}
+	input := composeInput{
+		To: "bob@example.com", Subject: "Reports", Body: "See attached.",
+		Attachments:      []attachment{{Name: "notes.txt", Content: base64.StdEncoding.EncodeToString([]byte("hello"))}},
+		DriveAttachments: []driveAttachment{{DriveAccount: "work", FileID: "f1"}, {DriveAccount: "work", FileID: "doc"}},
+		LocalAttachments: []localAttachment{{Path: "q4.pdf"}},
+	}
+	atts, err := meta.describe(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(driveLookups, []string{"f1", "doc"}) || !slices.Equal(stats, []string{"q4.pdf"}) {
+		t.Errorf("lookups: drive %v, local %v", driveLookups, stats)
+	}
+	want := []previewAttachment{
+		{Name: "notes.txt", MIMEType: "text/plain", Size: 5, Source: "inline"},
+		{Name: "spec.pdf", MIMEType: "application/pdf", Size: 4096, Source: "Drive file f1"},
+		{Name: "Plan", MIMEType: "application/vnd.google-apps.document", Source: "Drive file doc"},
+		{Name: "q4.pdf", MIMEType: "application/pdf", Size: 18, Source: "local q4.pdf"},
+	}
+	if !slices.Equal(atts, want) {
+		t.Errorf("describe() =\n%+v\nwant\n%+v", atts, want)
+	}
+
+	msg, err := prepareMessage(context.Background(), nil, "me", input, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.input.Attachments) != 1 {
+		t.Errorf("prepared message has %d attachments, want only the inline one", len(msg.input.Attachments))
+	}
+	text, err := formatPreview(msg, atts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "PDF-local-content") || strings.Contains(text, base64.StdEncoding.EncodeToString([]byte("%PDF-local-content"))) {
+		t.Errorf("preview contains the local file content:\n%s", text)
+	}
+	for _, want := range []string{
+		"Attachments (4, 4119 bytes):",
+		"  - Plan (MIME: application/vnd.google-apps.document, Size: size known after export, from Drive file doc)\n",
+		"  - q4.pdf (MIME: application/pdf, Size: 18 bytes, from local q4.pdf)\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("preview missing %q:\n%s", want, text)
+		}
+	}
+
+	if _, err := meta.describe(composeInput{LocalAttachments: []localAttachment{{Path: "missing.pdf"}}}); err == nil {
+		t.Error("describe() accepted a missing local file")
+	}
+}
+
+func TestFormatPreview(t *testing.T) {
+	policy := &ComposePolicy{
+		AlwaysBcc: []string{"archive@example.com"},
+		Headers:   []Header{{"X-Agent", "google-mcp"}},
+	}
+	input := composeInput{
+		To:      "Bob <bob@example.com>, carol@example.com",
+		Cc:      "BOB@example.com",
+		Subject: "Grüße aus Berlin",
+		Body:    "Hallo!",
+	}
+	msg, err := prepareMessage(context.Background(), nil, "me", input, "", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := formatPreview(msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `NOT SENT — preview only. Call send_message with the same input to send it.
+
+To: Bob <bob@example.com>, carol@example.com
+Cc: BOB@example.com
+Bcc: archive@example.com
+Subject: Grüße aus Berlin
+X-Agent: google-mcp
+
+Recipients (3):
+  - Bob <bob@example.com> (To)
+  - carol@example.com (To)
+  - archive@example.com (Bcc)
+
+Body:
+Hallo!
+
+
+Added by server policy:
+  Bcc: archive@example.com
+  X-Agent: google-mcp`
+	if got != want {
+		t.Errorf("formatPreview() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTriageInboxPrompt(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		srv := newTestServer(t)
+		if err := srv.ApplyFilter(server.ToolFilter{ReadOnly: readOnly}); err != nil {
+			t.Fatal(err)
+		}
+		res, err := connect(t, srv).GetPrompt(context.Background(), &mcp.GetPromptParams{
+			Name:      "triage_inbox",
+			Arguments: map[string]string{"account": "work", "days": "3"},
+		})
+		if err != nil {
+			t.Fatalf("read-only=%v: %v", readOnly, err)
+		}
+		text := res.Messages[0].Content.(*mcp.TextContent).Text
+		if !strings.Contains(text, `query "in:inbox newer_than:3d" and account "work"`) {
+			t.Errorf("read-only=%v: prompt missing search arguments:\n%s", readOnly, text)
+		}
+		if got := strings.Contains(text, "call modify_messages"); got == readOnly {
+			t.Errorf("read-only=%v: prompt mentions modify_messages = %v:\n%s", readOnly, got, text)
+		}
+	}
+}
+
+// attachmentFixture returns a message with a nested payload: a
+// multipart/mixed with an alternative body (plain text and an HTML part
+// with an inline image), a PDF, a forwarded message with its own
+// attachment, and a spreadsheet.
+func attachmentFixture() *gmailapi.Message {
+	att := func(name, mimeType, id string, size int64, headers ...*gmailapi.MessagePartHeader) *gmailapi.MessagePart {
+		return &gmailapi.MessagePart{Filename: name, MimeType: mimeType, Headers: headers, Body: &gmailapi.MessagePartBody{AttachmentId: id, Size: size}}
+	}
+	return &gmailapi.Message{
+		Id: "m1",
+		Payload: &gmailapi.MessagePart{
+			MimeType: "multipart/mixed",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "From", Value: "Maria <maria@example.com>"},
+				{Name: "Date", Value: "Tue, 12 Mar 2024 10:00:00 +0100"},
+			},
+			Parts: []*gmailapi.MessagePart{
+				{MimeType: "multipart/alternative", Parts: []*gmailapi.MessagePart{
+					{MimeType: "text/plain", Body: &gmailapi.MessagePartBody{Data: "aGk="}},
+					{MimeType: "multipart/related", Parts: []*gmailapi.MessagePart{
+						{MimeType: "text/html", Body: &gmailapi.MessagePartBody{Data: "aGk="}},
+						att("", "image/png", "a-logo", 800, &gmailapi.MessagePartHeader{Name: "Content-ID", Value: "<logo@x>"}),
+					}},
+				}},
+				att("Q1 Report.PDF", "application/pdf", "a-pdf", 120000),
+				{MimeType: "message/rfc822", Parts: []*gmailapi.MessagePart{
+					att("invoice-march.pdf", "application/pdf", "a-inv", 30000),
+				}},
+				att("budget.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "a-xls", 9000),
+			},
+		},
+	}
+}
+
+func TestMatchingAttachments(t *testing.T) {
+	msg := attachmentFixture()
+	ids := func(rows []attachmentRow) string {
+		var out []string
+		for _, r := range rows {
+			out = append(out, r.attachmentID)
+		}
+		return strings.Join(out, ",")
+	}
+	for _, tt := range []struct {
+		name  string
+		input searchAttachmentsInput
+		want  string
+	}{
+		{"all but inline", searchAttachmentsInput{}, "a-pdf,a-inv,a-xls"},
+		{"include inline", searchAttachmentsInput{IncludeInline: true}, "a-logo,a-pdf,a-inv,a-xls"},
+		{"glob is case-insensitive", searchAttachmentsInput{Filename: "*.pdf"}, "a-pdf,a-inv"},
+		{"glob prefix", searchAttachmentsInput{Filename: "invoice*"}, "a-inv"},
+		{"mime prefix", searchAttachmentsInput{MIMEType: "application/vnd.", IncludeInline: true}, "a-xls"},
+		{"image prefix", searchAttachmentsInput{MIMEType: "Image/", IncludeInline: true}, "a-logo"},
+		{"min size", searchAttachmentsInput{MinSize: 10000}, "a-pdf,a-inv"},
+		{"size range", searchAttachmentsInput{MinSize: 5000, MaxSize: 50000}, "a-inv,a-xls"},
+		{"no match", searchAttachmentsInput{Filename: "*.docx"}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newAttachmentFilter(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(matchingAttachments(msg, f)); got != tt.want {
+				t.Errorf("attachments = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	f, _ := newAttachmentFilter(searchAttachmentsInput{Filename: "invoice*"})
+	rows := matchingAttachments(msg, f)
+	want := "- invoice-march.pdf (MIME: application/pdf, Size: 30000 bytes)\n  From: Maria <maria@example.com>\n  Date: Tue, 12 Mar 2024 10:00:00 +0100\n  Message ID: m1\n  Attachment ID: a-inv\n"
+	if got := formatAttachmentRow(rows[0]); got != want {
+		t.Errorf("formatAttachmentRow() = %q, want %q", got, want)
+	}
+}
+
+func TestNewAttachmentFilter_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		input   searchAttachmentsInput
+		wantErr string
+	}{
+		{searchAttachmentsInput{Filename: "[a"}, "invalid pattern"},
+		{searchAttachmentsInput{MinSize: -1}, "must not be negative"},
+		{searchAttachmentsInput{MinSize: 10, MaxSize: 5}, "must not be larger than max_size"},
+	} {
+		if _, err := newAttachmentFilter(tt.input); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("newAttachmentFilter(%+v) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAttachmentSearchQuery(t *testing.T) {
+	for _, tt := range []struct {
+		input searchAttachmentsInput
+		want  string
+	}{
+		{searchAttachmentsInput{}, "has:attachment"},
+		{searchAttachmentsInput{Query: "invoice", From: "Maria"}, "invoice from:Maria has:attachment"},
+		{searchAttachmentsInput{Query: "has:attachment label:work"}, "has:attachment label:work"},
+		{searchAttachmentsInput{After: "2024-03-01", Before: "2024-04-01"}, "has:attachment after:1709251200 before:1711929600"},
+	} {
+		got, err := attachmentSearchQuery(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("attachmentSearchQuery(%+v) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExpandLabelShortcuts(t *testing.T) {
+	tests := []struct {
+		name                string
+		shortcuts           labelShortcuts
+		add, remove         []string
+		wantAdd, wantRemove []string
+		wantErr             string
+	}{
+		{name: "none", add: []string{"Work"}, wantAdd: []string{"Work"}},
+		{name: "archive", shortcuts: labelShortcuts{Archive: true}, wantRemove: []string{"INBOX"}},
+		{
+			name:      "all three",
+			shortcuts: labelShortcuts{Archive: true, MarkRead: true, Star: true},
+			add:       []string{"Work"},
+			wantAdd:   []string{"Work", "STARRED"}, wantRemove: []string{"INBOX", "UNREAD"},
+		},
+		{
+			name:      "already listed",
+			shortcuts: labelShortcuts{Archive: true, Star: true},
+			add:       []string{"starred"}, remove: []string{"inbox"},
+			wantAdd: []string{"starred"}, wantRemove: []string{"inbox"},
+		},
+		{name: "archive vs add INBOX", shortcuts: labelShortcuts{Archive: true}, add: []string{"Inbox"}, wantErr: "archive conflicts with INBOX in add_labels"},
+		{name: "mark_read vs add UNREAD", shortcuts: labelShortcuts{MarkRead: true}, add: []string{"UNREAD"}, wantErr: "mark_read conflicts with UNREAD in add_labels"},
+		{name: "star vs remove STARRED", shortcuts: labelShortcuts{Star: true}, remove: []string{"STARRED"}, wantErr: "star conflicts with STARRED in remove_labels"},
+	}
+	for _, tt := range tests {
+		add, remove, err := expandLabelShortcuts(tt.shortcuts, tt.add, tt.remove)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(add, tt.wantAdd) || !slices.Equal(remove, tt.wantRemove) {
+			t.Errorf("%s: add = %q, remove = %q, want %q and %q", tt.name, add, remove, tt.wantAdd, tt.wantRemove)
+		}
+	}
+
+	// The inputs are not modified.
+	add := make([]string, 1, 4)
+	add[0] = "Work"
+	expandLabelShortcuts(labelShortcuts{Star: true}, add, nil)
+	if got := add[:cap(add)][1]; got != "" {
+		t.Errorf("expandLabelShortcuts wrote %q past the caller's slice", got)
+	}
+}
+
+func TestCheckModifiableLabels(t *testing.T) {
+	if err := checkModifiableLabels([]string{"STARRED", "IMPORTANT", "UNREAD", "Work"}, []string{"INBOX"}); err != nil {
+		t.Errorf("regular labels: %v", err)
+	}
+	for _, tt := range []struct{ add, remove []string }{
+		{add: []string{"SENT"}},
+		{add: []string{"draft"}},
+		{remove: []string{"CHAT"}},
+	} {
+		err := checkModifiableLabels(tt.add, tt.remove)
+		if err == nil || !strings.Contains(err.Error(), "can't be added or removed") {
+			t.Errorf("add %q remove %q: error = %v", tt.add, tt.remove, err)
+		}
+	}
+}
+
+func TestNewURLAttachmentPolicy(t *testing.T) {
+	p, err := NewURLAttachmentPolicy(nil)
+	if err != nil || p != nil {
+		t.Errorf("NewURLAttachmentPolicy(nil) = %v, %v, want nil policy", p, err)
+	}
+	for _, tc := range []struct {
+		prefix, wantErr string
+	}{
+		{"ftp://files.example.com/", "http or https"},
+		{"/artifacts/", "http or https"},
+		{"https://user:pw@ci.example.com/", "credentials"},
+		{"https://ci.example.com/?token=x", "query"},
+	} {
+		if _, err := NewURLAttachmentPolicy([]string{tc.prefix}); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("NewURLAttachmentPolicy(%q) = %v, want error containing %q", tc.prefix, err, tc.wantErr)
+		}
+	}
+}
+
+func TestURLAttachmentPolicy_Check(t *testing.T) {
+	p, err := NewURLAttachmentPolicy([]string{"https://CI.example.com/artifacts/", "http://reports.internal:8080"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		url     string
+		wantErr string
+	}{
+		{"https://ci.example.com/artifacts/build-42.log", ""},
+		{"https://CI.EXAMPLE.COM/artifacts/a/b.zip?x=1", ""},
+		{"http://reports.internal:8080/q4.pdf", ""},
+		{"http://ci.example.com/artifacts/build-42.log", "not under a URL prefix"},
+		{"https://ci.example.com/secrets/key", "not under a URL prefix"},
+		{"https://ci.example.com.evil.test/artifacts/x", "not under a URL prefix"},
+		{"http://reports.internal/q4.pdf", "not under a URL prefix"},
+		{"http://169.254.169.254/latest/meta-data/", "not under a URL prefix"},
+		{"https://ci.example.com/artifacts/../admin", ". or .."},
+		{"https://ci.example.com/artifacts/%2e%2e/admin", ". or .."},
+		{"https://u:p@ci.example.com/artifacts/x", "credentials"},
+		{"file:///etc/passwd", "not a valid http or https URL"},
+	} {
+		_, err := p.parse(tc.url)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("parse(%q) = %v, want allowed", tc.url, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("parse(%q) = %v, want error containing %q", tc.url, err, tc.wantErr)
+		}
+	}
+}
+
+func TestResolveURLAttachments(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/artifacts/build.log", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, "build ok")
+	})
+	mux.HandleFunc("/artifacts/report", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="q4.pdf"`)
+		fmt.Fprint(w, "%PDF")
+	})
+	mux.HandleFunc("/artifacts/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write(bytes.Repeat([]byte("x"), 100))
+	})
+	mux.HandleFunc("/artifacts/big-stream", func(w http.ResponseWriter, r *http.Request) {
+		for range 10 {
+			w.Write(bytes.Repeat([]byte("x"), 10))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/artifacts/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/private/key", http.StatusFound)
+	})
+	mux.HandleFunc("/artifacts/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	var privateHits atomic.Int32
+	mux.HandleFunc("/private/", func(w http.ResponseWriter, r *http.Request) {
+		privateHits.Add(1)
+		fmt.Fprint(w, "secret")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	p, err := NewURLAttachmentPolicy([]string{ts.URL + "/artifacts/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.maxSize = 50
+	p.timeout = 200 * time.Millisecond
+
+	input := composeInput{URLAttachments: []urlAttachment{
+		{URL: ts.URL + "/artifacts/build.log"},
+		{URL: ts.URL + "/artifacts/report"},
+		{URL: ts.URL + "/artifacts/build.log", Name: "log.txt", MIMEType: "application/octet-stream"},
+	}}
+	if err := resolveURLAttachments(context.Background(), p, &input); err != nil {
+		t.Fatal(err)
+	}
+	want := []attachment{
+		{Name: "build.log", MIMEType: "text/plain", Content: base64.StdEncoding.EncodeToString([]byte("build ok"))},
+		{Name: "q4.pdf", MIMEType: "application/pdf", Content: base64.StdEncoding.EncodeToString([]byte("%PDF"))},
+		{Name: "log.txt", MIMEType: "application/octet-stream", Content: base64.StdEncoding.EncodeToString([]byte("build ok"))},
+	}
+	if !slices.Equal(input.Attachments, want) {
+		t.Errorf("Attachments =\n%+v\nwant\n%+v", input.Attachments, want)
+	}
+
+	for _, tc := range []struct {
+		path, wantErr string
+	}{
+		{"/artifacts/big", "file is 100 bytes, more than the limit of 50"},
+		{"/artifacts/big-stream", "file is more than the limit of 50 bytes"},
+		{"/private/key", "is not under a URL prefix allowed via --allow-attachment-url-prefix"},
+		{"/artifacts/moved", "redirected to " + ts.URL + "/private/key, which is not under a URL prefix"},
+		{"/artifacts/missing", "server returned 404 Not Found"},
+		{"/artifacts/slow", "no response within 200ms"},
+	} {
+		input := composeInput{URLAttachments: []urlAttachment{{URL: ts.URL + tc.path}}}
+		err := resolveURLAttachments(context.Background(), p, &input)
+		wantErr := fmt.Sprintf("url_attachments[0] (%s): %s", ts.URL+tc.path, tc.wantErr)
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: err = %v, want %q", tc.path, err, wantErr)
+		}
+		if len(input.Attachments) != 0 {
+			t.Errorf("%s: attached %d files after an error", tc.path, len(input.Attachments))
+		}
+	}
+	if n := privateHits.Load(); n != 0 {
+		t.Errorf("the server outside the prefix was requested %d times", n)
+	}
+
+	disabled := composeInput{URLAttachments: []urlAttachment{{URL: ts.URL + "/artifacts/build.log"}}}
+	if err := resolveURLAttachments(context.Background(), nil, &disabled); err == nil || !strings.Contains(err.Error(), "--allow-attachment-url-prefix") {
+		t.Errorf("resolveURLAttachments without a policy = %v, want not enabled error", err)
+	}
+}
+
+func TestAttachmentMetadata_URLAttachmentsNotDownloaded(t *testing.T) {
+	p, err := NewURLAttachmentPolicy([]string{"https://ci.example.com/artifacts/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A preview that downloaded the file would panic on the nil client.
+	p.client = nil
+	meta := attachmentMetadata{urls: p}
+	atts, err := meta.describe(composeInput{URLAttachments: []urlAttachment{{URL: "https://ci.example.com/artifacts/build-42.log"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []previewAttachment{{Name: "build-42.log", MIMEType: guessMIMEType("build-42.log"), Source: "URL https://ci.example.com/artifacts/build-42.log"}}
+	if !slices.Equal(atts, want) {
+		t.Errorf("describe() = %+v, want %+v", atts, want)
+	}
+
+	if _, err := meta.describe(composeInput{URLAttachments: []urlAttachment{{URL: "https://ci.example.com/private"}}}); err == nil || !strings.Contains(err.Error(), "url_attachments[0] (https://ci.example.com/private)") {
+		t.Errorf("describe() of a URL outside the prefixes = %v", err)
+	}
+	if _, err := (attachmentMetadata{}).describe(composeInput{URLAttachments: []urlAttachment{{URL: "https://ci.example.com/artifacts/x"}}}); err == nil {
+		t.Error("describe() accepted a URL attachment without a policy")
+	}
+}
+
+func TestUnfoldHeader(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Hello", "Hello"},
+		{"from mail.example.com (mail.example.com. [203.0.113.5])\r\n        by mx.google.com with ESMTPS id a1\r\n        for <bob@example.com>", "from mail.example.com (mail.example.com. [203.0.113.5]) by mx.google.com with ESMTPS id a1 for <bob@example.com>"},
+		{"v=1; a=rsa-sha256;\n\td=example.com; s=s1;", "v=1; a=rsa-sha256; d=example.com; s=s1;"},
+	}
+	for _, tt := range tests {
+		if got := unfoldHeader(tt.in); got != tt.want {
+			t.Errorf("unfoldHeader(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	headers := []*gmailapi.MessagePartHeader{
+		{Name: "Return-Path", Value: "<bounce@example.com>"},
+		{Name: "Received", Value: "from mail.example.com\r\n by mx.google.com"},
+		{Name: "From", Value: "alice@example.com"},
+		{Name: "Subject", Value: "Hi"},
+	}
+	var sb strings.Builder
+	writeHeaders(&sb, headers, false)
+	if want := "From: alice@example.com\nSubject: Hi\n"; sb.String() != want {
+		t.Errorf("writeHeaders = %q, want %q", sb.String(), want)
+	}
+	sb.Reset()
+	writeHeaders(&sb, headers, true)
+	want := "Return-Path: <bounce@example.com>\nReceived: from mail.example.com by mx.google.com\nFrom: alice@example.com\nSubject: Hi\n"
+	if sb.String() != want {
+		t.Errorf("writeHeaders(all) = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestGetMessageForRead_AllHeaders(t *testing.T) {
+	var sizes []int
+	svc := newFixtureService(t, &sizes)
+	msg, err := getMessageForRead(context.Background(), svc, "me", "m1", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, h := range msg.Payload.Headers {
+		names = append(names, h.Name)
+	}
+	if !slices.Contains(names, "Received") || !slices.Contains(names, "DKIM-Signature") {
+		t.Errorf("headers = %v, want all of them", names)
+	}
+}
+
+func TestParseAuthResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		server string
+		want   []string // authResult.String() of each result
+	}{
+		{
+			name: "gmail",
+			in: "mx.google.com;\r\n       dkim=pass header.i=@github.com header.s=pf2023 header.b=Kz8QbY1v;\r\n" +
+				"       spf=pass (google.com: domain of noreply@github.com designates 192.30.252.201 as permitted sender) smtp.mailfrom=noreply@github.com;\r\n" +
+				"       dmarc=pass (p=REJECT sp=REJECT dis=NONE) header.from=github.com",
+			server: "mx.google.com",
+			want: []string{
+				"DKIM: pass, domain github.com, selector pf2023",
+				"SPF: pass, domain github.com (google.com: domain of noreply@github.com designates 192.30.252.201 as permitted sender)",
+				"DMARC: pass, domain github.com (p=REJECT sp=REJECT dis=NONE)",
+			},
+		},
+		{
+			name: "gmail failures",
+			in: "mx.google.com; spf=softfail (google.com: domain of transitioning ceo@example.org does not designate 198.51.100.7 as permitted sender) smtp.mailfrom=ceo@example.org;" +
+				" dmarc=fail (p=QUARANTINE sp=QUARANTINE dis=SPAM) header.from=example.org",
+			server: "mx.google.com",
+			want: []string{
+				"SPF: softfail, domain example.org (google.com: domain of transitioning ceo@example.org does not designate 198.51.100.7 as permitted sender)",
+				"DMARC: fail, domain example.org (p=QUARANTINE sp=QUARANTINE dis=SPAM)",
+			},
+		},
+		{
+			name: "exchange online without authserv-id",
+			in: "spf=pass (sender IP is 40.107.22.52) smtp.mailfrom=contoso.com; dkim=pass (signature was verified) header.d=Contoso.com;" +
+				"dmarc=pass action=none header.from=contoso.com;compauth=pass reason=100",
+			want: []string{
+				"SPF: pass, domain contoso.com (sender IP is 40.107.22.52)",
+				"DKIM: pass, domain contoso.com (signature was verified)",
+				"DMARC: pass, domain contoso.com",
+				"COMPAUTH: pass, reason: 100",
+			},
+		},
+		{
+			name:   "rfc 8601 with version, quoted values and method versions",
+			in:     `example.com 1; auth=pass (cram-md5) smtp.auth=sender@example.net; dkim/1=fail reason="bad signature (body hash)" header.d=newyork.example.com; spf=pass smtp.mailfrom=example.net`,
+			server: "example.com",
+			want: []string{
+				"AUTH: pass (cram-md5)",
+				"DKIM: fail, domain newyork.example.com, reason: bad signature (body hash)",
+				"SPF: pass, domain example.net",
+			},
+		},
+		{
+			name:   "nested comment",
+			in:     "mail.example.org; dkim=neutral (no key (yet)) header.i=@Example.ORG",
+			server: "mail.example.org",
+			want:   []string{"DKIM: neutral, domain example.org (no key (yet))"},
+		},
+		{
+			name:   "no results",
+			in:     "mx.example.org; none",
+			server: "mx.example.org",
+		},
+		{
+			name:   "malformed result skipped",
+			in:     "mx.example.org; garbage; spf=none smtp.helo=relay.example.org",
+			server: "mx.example.org",
+			want:   []string{"SPF: none, domain relay.example.org"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ar, ok := parseAuthResults(unfoldHeader(tt.in))
+			if !ok {
+				t.Fatal("parseAuthResults failed")
+			}
+			if ar.AuthServID != tt.server {
+				t.Errorf("authserv-id = %q, want %q", ar.AuthServID, tt.server)
+			}
+			var got []string
+			for _, r := range ar.Results {
+				got = append(got, r.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("results:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := parseAuthResults("  "); ok {
+		t.Error("empty header parsed")
+	}
+}
+
+func TestParseReceivedSPF(t *testing.T) {
+	r, ok := parseReceivedSPF("pass (google.com: domain of noreply@github.com designates 192.30.252.201 as permitted sender) client-ip=192.30.252.201;")
+	if !ok {
+		t.Fatal("parseReceivedSPF failed")
+	}
+	if want := "SPF: pass, client IP 192.30.252.201 (google.com: domain of noreply@github.com designates 192.30.252.201 as permitted sender)"; r.String() != want {
+		t.Errorf("got %q, want %q", r, want)
+	}
+
+	r, ok = parseReceivedSPF(`Fail (mybox.example.org: domain of bad@example.com does not designate 192.0.2.1 as permitted sender) receiver=mybox.example.org; client-ip=192.0.2.1; envelope-from="bad@example.com"; helo=foo.example.com;`)
+	if !ok {
+		t.Fatal("parseReceivedSPF failed")
+	}
+	if r.Result != "fail" || r.domain() != "example.com" {
+		t.Errorf("got result %q, domain %q; want fail, example.com", r.Result, r.domain())
+	}
+}
+
+func TestParseDKIMSignature(t *testing.T) {
+	v := unfoldHeader("v=1; a=rsa-sha256; c=relaxed/relaxed;\r\n        d=GitHub.com; s=pf2023; t=1717405200;\r\n        bh=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=;\r\n        h=Date:From:To:Subject; b=Kz8QbY1v\r\n         AbCd==")
+	got := parseDKIMSignature(v)
+	want := dkimSignature{Domain: "github.com", Selector: "pf2023", Algorithm: "rsa-sha256"}
+	if got != want {
+		t.Errorf("parseDKIMSignature = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "github.com (selector pf2023, rsa-sha256)" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+func TestFormatAuthentication(t *testing.T) {
+	headers := []*gmailapi.MessagePartHeader{
+		{Name: "Return-Path", Value: "<ceo@example.org>"},
+		{Name: "Authentication-Results", Value: "mx.google.com; spf=fail smtp.mailfrom=ceo@example.org; dmarc=fail (p=REJECT sp=REJECT dis=REJECT) header.from=example.org"},
+		{Name: "Authentication-Results", Value: "relay.example.net; spf=pass smtp.mailfrom=example.org; dkim=pass header.d=example.org"},
+		{Name: "From", Value: "CEO <ceo@example.org>"},
+	}
+	got := formatAuthentication(headers)
+	for _, want := range []string{
+		"Return-Path: <ceo@example.org>\n",
+		"From: CEO <ceo@example.org>\n",
+		"Results of mx.google.com (the receiving server):\n  SPF: fail, domain example.org\n  DMARC: fail, domain example.org (p=REJECT sp=REJECT dis=REJECT)\n",
+		"Earlier results, added by other servers (the sender can forge these):\nResults of relay.example.net:\n  SPF: pass",
+		"DKIM signatures: none",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatAuthentication missing %q:\n%s", want, got)
+		}
+	}
+
+	got = formatAuthentication([]*gmailapi.MessagePartHeader{{Name: "From", Value: "me@example.com"}})
+	if !strings.Contains(got, "no receiving server checked this message") {
+		t.Errorf("formatAuthentication without results:\n%s", got)
+	}
 }
@@ -0,0 +1,219 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Limits of url_attachments, per URL.
+const (
+	maxURLAttachmentSize = 25 << 20
+	urlAttachmentTimeout = 30 * time.Second
+	maxURLAttachmentHops = 5
+)
+
+// urlAttachment references a file on a web server to attach to an email.
+// The server downloads it; the content never enters the LLM context.
+type urlAttachment struct {
+	URL      string `json:"url" jsonschema:"http(s) URL of the file; must start with a prefix allowed via --allow-attachment-url-prefix"`
+	Name     string `json:"name,omitempty" jsonschema:"Filename for the attachment (default: from the response's Content-Disposition or the URL path)"`
+	MIMEType string `json:"mime_type,omitempty" jsonschema:"MIME type (default: the response's Content-Type, or detected from the name when that is missing or application/octet-stream)"`
+}
+
+// URLAttachmentPolicy is the allowlist of URL prefixes url_attachments may
+// be fetched from. A URL is allowed when its scheme and host equal those of
+// a prefix and its path starts with the prefix's path, so that
+// https://ci.example.com does not allow https://ci.example.com.evil.test.
+// Redirects are followed only to allowed URLs.
+type URLAttachmentPolicy struct {
+	prefixes []*url.URL
+	client   *http.Client
+	maxSize  int64
+	timeout  time.Duration
+}
+
+// NewURLAttachmentPolicy builds the policy from the
+// --allow-attachment-url-prefix values. It returns nil when there are no
+// prefixes, which leaves url_attachments disabled.
+func NewURLAttachmentPolicy(prefixes []string) (*URLAttachmentPolicy, error) {
+	p := &URLAttachmentPolicy{maxSize: maxURLAttachmentSize, timeout: urlAttachmentTimeout}
+	for _, s := range prefixes {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --allow-attachment-url-prefix %q: %w", s, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid --allow-attachment-url-prefix %q: must be an http or https URL with a host", s)
+		}
+		if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid --allow-attachment-url-prefix %q: must not contain credentials, a query or a fragment", s)
+		}
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		p.prefixes = append(p.prefixes, u)
+	}
+	if len(p.prefixes) == 0 {
+		return nil, nil
+	}
+	p.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxURLAttachmentHops {
+				return fmt.Errorf("more than %d redirects", maxURLAttachmentHops)
+			}
+			if err := p.check(req.URL); err != nil {
+				return fmt.Errorf("redirected to %s, which %w", req.URL.Redacted(), err)
+			}
+			return nil
+		},
+	}
+	return p, nil
+}
+
+// errURLNotAllowed is returned by check for URLs outside the allowlist.
+var errURLNotAllowed = errors.New("is not under a URL prefix allowed via --allow-attachment-url-prefix")
+
+// check returns an error unless u is allowed by p. URLs with credentials
+// or with . or .. path segments, which a server may resolve to a path
+// outside the prefix, are refused.
+func (p *URLAttachmentPolicy) check(u *url.URL) error {
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg == "." || seg == ".." {
+			return errors.New("must not contain . or .. path segments")
+		}
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	for _, prefix := range p.prefixes {
+		if scheme == prefix.Scheme && host == prefix.Host && strings.HasPrefix(u.EscapedPath(), prefix.EscapedPath()) {
+			return nil
+		}
+	}
+	return errURLNotAllowed
+}
+
+// parse parses rawURL and checks it against p.
+func (p *URLAttachmentPolicy) parse(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("not a valid http or https URL")
+	}
+	if err := p.check(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// fetch downloads ua as an attachment, within p's size cap and timeout.
+func (p *URLAttachmentPolicy) fetch(ctx context.Context, ua urlAttachment) (attachment, error) {
+	u, err := p.parse(ua.URL)
+	if err != nil {
+		return attachment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return attachment{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return attachment{}, fmt.Errorf("no response within %s", p.timeout)
+		}
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return attachment{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return attachment{}, fmt.Errorf("server returned %s", resp.Status)
+	}
+	if resp.ContentLength > p.maxSize {
+		return attachment{}, fmt.Errorf("file is %d bytes, more than the limit of %d", resp.ContentLength, p.maxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.maxSize+1))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return attachment{}, fmt.Errorf("download not complete within %s", p.timeout)
+		}
+		return attachment{}, fmt.Errorf("reading response: %w", err)
+	}
+	if int64(len(data)) > p.maxSize {
+		return attachment{}, fmt.Errorf("file is more than the limit of %d bytes", p.maxSize)
+	}
+
+	name := ua.Name
+	if name == "" {
+		name = urlAttachmentName(resp)
+	}
+	mimeType := ua.MIMEType
+	if mimeType == "" {
+		if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mt != "application/octet-stream" {
+			mimeType = mt
+		}
+	}
+	if mimeType == "" {
+		mimeType = guessMIMEType(name)
+	}
+	return attachment{
+		Name:     name,
+		MIMEType: mimeType,
+		Content:  base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// urlAttachmentName names a downloaded attachment after the response's
+// Content-Disposition filename, else the last segment of the final URL's
+// path.
+func urlAttachmentName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(params["filename"]); params["filename"] != "" && name != "/" && name != "." {
+			return name
+		}
+	}
+	if name := path.Base(resp.Request.URL.Path); name != "/" && name != "." {
+		return name
+	}
+	return "attachment"
+}
+
+// resolveURLAttachments downloads the url_attachments of input and appends
+// them to its Attachments slice. The content flows through server memory
+// only.
+func resolveURLAttachments(ctx context.Context, p *URLAttachmentPolicy, input *composeInput) error {
+	if len(input.URLAttachments) == 0 {
+		return nil
+	}
+	if p == nil {
+		return fmt.Errorf("url_attachments are not enabled (use --allow-attachment-url-prefix)")
+	}
+	for i, ua := range input.URLAttachments {
+		if ua.URL == "" {
+			return fmt.Errorf("url_attachments[%d]: url is required", i)
+		}
+		att, err := p.fetch(ctx, ua)
+		if err != nil {
+			return fmt.Errorf("url_attachments[%d] (%s): %w", i, ua.URL, err)
+		}
+		input.Attachments = append(input.Attachments, att)
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/gerrors"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+	"github.com/thegrumpylion/google-mcp/internal/statefile"
+	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Gmail push notifications (Users.Watch) publish mailbox changes to a
+// Cloud Pub/Sub topic. A watch expires after 7 days unless it is re-issued,
+// so active watches are recorded in a state file next to the tokens and a
+// background renewer (RunWatchRenewer) re-issues each one when it is within
+// watchRenewBefore of expiring.
+
+// watchFile is the name of the watch state file in the config directory.
+const watchFile = "gmail_watches.json"
+
+// watchRenewBefore is how long before expiry a watch is renewed.
+const watchRenewBefore = 24 * time.Hour
+
+// watchCheckInterval is how often the renewer looks for expiring watches.
+const watchCheckInterval = time.Hour
+
+// gmailPushServiceAccount is the account Gmail publishes notifications as.
+// It needs the Pub/Sub Publisher role on the topic.
+const gmailPushServiceAccount = "gmail-api-push@system.gserviceaccount.com"
+
+// pubsubTopicPattern matches a fully qualified Pub/Sub topic name.
+var pubsubTopicPattern = regexp.MustCompile(`^projects/[a-z][a-z0-9.:-]*[a-z0-9]/topics/[A-Za-z][A-Za-z0-9._~%+-]*$`)
+
+// watchEntry is the last watch issued for an account.
+type watchEntry struct {
+	Account             string    `json:"account"`
+	TopicName           string    `json:"topic_name"`
+	LabelIDs            []string  `json:"label_ids,omitempty"`
+	LabelFilterBehavior string    `json:"label_filter_behavior,omitempty"`
+	HistoryID           uint64    `json:"history_id"`
+	Expiration          time.Time `json:"expiration"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// request returns the Users.Watch request that (re-)issues the watch.
+func (e watchEntry) request() *gmailapi.WatchRequest {
+	return &gmailapi.WatchRequest{
+		TopicName:           e.TopicName,
+		LabelIds:            e.LabelIDs,
+		LabelFilterBehavior: e.LabelFilterBehavior,
+	}
+}
+
+// apply records the result of a successful Users.Watch call.
+func (e *watchEntry) apply(resp *gmailapi.WatchResponse) {
+	e.HistoryID = resp.HistoryId
+	e.Expiration = time.UnixMilli(resp.Expiration).UTC()
+	e.LastError = ""
+}
+
+// watchStore persists the active watch of each account to a JSON file,
+// read and rewritten under a lock on every operation like the snooze
+// store.
+type watchStore struct {
+	file *statefile.File[watchEntry]
+}
+
+func newWatchStore(mgr *auth.Manager) *watchStore {
+	return &watchStore{file: statefile.New(filepath.Join(mgr.ConfigDir(), watchFile), "watch state", func(a, b watchEntry) int {
+		return strings.Compare(a.Account, b.Account)
+	})}
+}
+
+func (s *watchStore) load() ([]watchEntry, error) {
+	return s.file.Load()
+}
+
+// update loads the entries, applies fn, and saves the result.
+func (s *watchStore) update(fn func([]watchEntry) ([]watchEntry, error)) error {
+	return s.file.Update(fn)
+}
+
+// put records the watch for an account, replacing any previous one; Gmail
+// keeps a single watch per mailbox.
+func (s *watchStore) put(e watchEntry) error {
+	return s.update(func(entries []watchEntry) ([]watchEntry, error) {
+		return append(removeWatch(entries, e.Account), e), nil
+	})
+}
+
+// remove deletes an account's watch and reports whether it existed.
+func (s *watchStore) remove(account string) (bool, error) {
+	found := false
+	err := s.update(func(entries []watchEntry) ([]watchEntry, error) {
+		out := removeWatch(entries, account)
+		found = len(out) != len(entries)
+		return out, nil
+	})
+	return found, err
+}
+
+func removeWatch(entries []watchEntry, account string) []watchEntry {
+	out := entries[:0:0]
+	for _, e := range entries {
+		if e.Account != account {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// watchIssuer issues a Users.Watch request for an account.
+type watchIssuer func(ctx context.Context, account string, req *gmailapi.WatchRequest) (*gmailapi.WatchResponse, error)
+
+// renewIfNeeded re-issues every watch that expires within watchRenewBefore
+// of now, including ones that already expired while no server was running.
+// Failures are recorded on the entry and retried on the next call. It
+// returns the entries that were renewed.
+//
+// The Users.Watch calls are made without holding the state file lock, so
+// other tools using the file don't wait on the network. An entry replaced
+// or removed in the meantime (by watch_mailbox or stop_watch) is left as
+// it is then.
+func (s *watchStore) renewIfNeeded(ctx context.Context, now time.Time, issue watchIssuer) ([]watchEntry, error) {
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	var due []watchEntry
+	for _, e := range entries {
+		if e.Expiration.Sub(now) <= watchRenewBefore {
+			due = append(due, e)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	results := make([]watchEntry, len(due))
+	for i, e := range due {
+		results[i] = e
+		resp, err := issue(ctx, e.Account, e.request())
+		if err != nil {
+			results[i].LastError = err.Error()
+			continue
+		}
+		results[i].apply(resp)
+	}
+
+	var renewed []watchEntry
+	err = s.update(func(entries []watchEntry) ([]watchEntry, error) {
+		for i, old := range due {
+			j := slices.IndexFunc(entries, func(e watchEntry) bool { return e.Account == old.Account })
+			if j < 0 || !sameWatch(entries[j], old) {
+				continue
+			}
+			entries[j] = results[i]
+			if results[i].LastError == "" {
+				renewed = append(renewed, results[i])
+			}
+		}
+		return entries, nil
+	})
+	return renewed, err
+}
+
+// sameWatch reports whether a and b record the same issued watch.
+func sameWatch(a, b watchEntry) bool {
+	return a.TopicName == b.TopicName && a.LabelFilterBehavior == b.LabelFilterBehavior &&
+		slices.Equal(a.LabelIDs, b.LabelIDs) && a.Expiration.Equal(b.Expiration)
+}
+
+// issueWatch calls Users.Watch for an account.
+func issueWatch(ctx context.Context, mgr *auth.Manager, account string, req *gmailapi.WatchRequest) (*gmailapi.WatchResponse, error) {
+	svc, err := newService(ctx, mgr, account)
+	if err != nil {
+		return nil, fmt.Errorf("creating Gmail service: %w", err)
+	}
+	resp, err := svc.Users.Watch("me", req).Context(ctx).Do()
+	if err != nil {
+		return nil, watchError(req.TopicName, err)
+	}
+	return resp, nil
+}
+
+// RunWatchRenewer renews Gmail push watches recorded by watch_mailbox
+// before they expire, until ctx is cancelled.
+func RunWatchRenewer(ctx context.Context, mgr *auth.Manager) {
+	store := newWatchStore(mgr)
+	issue := func(ctx context.Context, account string, req *gmailapi.WatchRequest) (*gmailapi.WatchResponse, error) {
+		return issueWatch(ctx, mgr, account, req)
+	}
+
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+	for {
+		store.renewIfNeeded(ctx, time.Now(), issue)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// validateTopicName checks that topic is a fully qualified Pub/Sub topic.
+func validateTopicName(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("topic_name is required")
+	}
+	if !pubsubTopicPattern.MatchString(topic) {
+		return fmt.Errorf("invalid topic_name %q: must be a full Pub/Sub topic name like 'projects/my-project/topics/gmail-push'", topic)
+	}
+	return nil
+}
+
+// watchError explains the usual cause of a failed Users.Watch call: Gmail
+// can't publish to the topic because its push service account lacks the
+// Publisher role.
+func watchError(topic string, err error) error {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && (gerr.Code == http.StatusForbidden || gerr.Code == http.StatusBadRequest) {
+		msg := strings.ToLower(gerr.Message)
+		if strings.Contains(msg, "not authorized") || strings.Contains(msg, "permission") || strings.Contains(msg, "pubsub") {
+			return fmt.Errorf("Gmail can't publish to %s: grant %s the Pub/Sub Publisher role (roles/pubsub.publisher) on the topic, and check that the topic exists in a project with the Pub/Sub API enabled: %w",
+				topic, gmailPushServiceAccount, err)
+		}
+	}
+	return gerrors.Wrap(err, "starting watch")
+}
+
+// --- watch_mailbox ---
+
+type watchMailboxInput struct {
+	Account             string   `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+	TopicName           string   `json:"topic_name" jsonschema:"Pub/Sub topic to publish notifications to, e.g. 'projects/my-project/topics/gmail-push'"`
+	LabelIDs            []string `json:"label_ids,omitempty" jsonschema:"Only notify about changes to these label IDs (e.g. ['INBOX']). Default: all changes."`
+	LabelFilterBehavior string   `json:"label_filter_behavior,omitempty" jsonschema:"include (notify only for label_ids, the default) or exclude (notify for everything except label_ids)"`
+}
+
+func registerWatchMailbox(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name: "watch_mailbox",
+		Description: `Start Gmail push notifications: mailbox changes are published to a Cloud Pub/Sub topic instead of having to poll.
+
+Returns the current history ID (use it with list_history when a notification arrives) and the watch expiration. Calling again replaces the account's watch. The topic must grant ` + gmailPushServiceAccount + ` the Pub/Sub Publisher role.
+Watches expire after 7 days; while a google-mcp Gmail server is running, recorded watches are renewed automatically within 24 hours of expiring.`,
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input watchMailboxInput) (*mcp.CallToolResult, any, error) {
+		if err := validateTopicName(input.TopicName); err != nil {
+			return nil, nil, err
+		}
+		switch input.LabelFilterBehavior {
+		case "", "include", "exclude":
+		default:
+			return nil, nil, fmt.Errorf("invalid label_filter_behavior %q: must be include or exclude", input.LabelFilterBehavior)
+		}
+		if input.LabelFilterBehavior != "" && len(input.LabelIDs) == 0 {
+			return nil, nil, fmt.Errorf("label_filter_behavior requires label_ids")
+		}
+
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(accounts) != 1 {
+			return nil, nil, fmt.Errorf("a single account is required to watch a mailbox")
+		}
+		account := accounts[0]
+
+		entry := watchEntry{
+			Account:             account,
+			TopicName:           input.TopicName,
+			LabelIDs:            input.LabelIDs,
+			LabelFilterBehavior: input.LabelFilterBehavior,
+		}
+		resp, err := issueWatch(ctx, mgr, account, entry.request())
+		if err != nil {
+			return nil, nil, err
+		}
+		entry.apply(resp)
+
+		text := formatWatch(entry)
+		if err := newWatchStore(mgr).put(entry); err != nil {
+			text += fmt.Sprintf("\nWarning: the watch could not be recorded for automatic renewal: %v\n", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+func formatWatch(e watchEntry) string {
+	var sb strings.Builder
+	sb.WriteString("Watch started.\n\n")
+	fmt.Fprintf(&sb, "Topic: %s\n", e.TopicName)
+	if len(e.LabelIDs) > 0 {
+		behavior := e.LabelFilterBehavior
+		if behavior == "" {
+			behavior = "include"
+		}
+		fmt.Fprintf(&sb, "Labels (%s): %s\n", behavior, strings.Join(e.LabelIDs, ", "))
+	}
+	fmt.Fprintf(&sb, "History ID: %d\n", e.HistoryID)
+	fmt.Fprintf(&sb, "Expires: %s\n", e.Expiration.Format(time.RFC3339))
+	return sb.String()
+}
+
+// --- stop_watch ---
+
+type stopWatchInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name (optional when only one account is configured or a default is set)"`
+}
+
+func registerStopWatch(srv *server.Server, mgr *auth.Manager) {
+	server.AddTool(srv, &mcp.Tool{
+		Name:        "stop_watch",
+		Description: "Stop Gmail push notifications for an account started with watch_mailbox, and stop renewing the watch.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: server.BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   server.BoolPtr(true),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input stopWatchInput) (*mcp.CallToolResult, any, error) {
+		accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(accounts) != 1 {
+			return nil, nil, fmt.Errorf("a single account is required to stop a mailbox watch")
+		}
+		account := accounts[0]
+
+		svc, err := newService(ctx, mgr, account)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating Gmail service: %w", err)
+		}
+		if err := svc.Users.Stop("me").Context(ctx).Do(); err != nil {
+			return nil, nil, gerrors.Wrap(err, "stopping watch")
+		}
+
+		if _, err := newWatchStore(mgr).remove(account); err != nil {
+			return nil, nil, fmt.Errorf("watch stopped, but removing it from the renewal list failed: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "Push notifications stopped."},
+			},
+		}, nil, nil
+	})
+}
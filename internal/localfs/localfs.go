@@ -6,10 +6,18 @@
 package localfs
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Mode controls the access level for an allowed directory.
@@ -28,52 +36,94 @@ type Dir struct {
 	Path string
 	// Mode is the access level (read or read-write).
 	Mode Mode
+	// Label, if set, is a short name for the directory (e.g. "invoices")
+	// that results show instead of its absolute path.
+	Label string
 }
 
+// labelPattern matches valid directory labels.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
 // openDir holds an os.Root handle along with its mode.
 type openDir struct {
-	root *os.Root
-	mode Mode
-	path string // original resolved path, for error messages
+	root  *os.Root
+	mode  Mode
+	path  string // original resolved path, for error messages
+	label string
+}
+
+// name returns the name results show for the directory: its label, or its
+// path when it has none.
+func (d openDir) name() string {
+	if d.label != "" {
+		return d.label
+	}
+	return d.path
 }
 
 // FS gates all local file access through a set of allowed directories.
 // Each directory is backed by an os.Root which enforces path containment
 // at the kernel level. If no directories are configured, all operations
 // return errors — local file access is opt-in only.
+//
+// The directories can be replaced with Reload while the FS is in use.
+// Every operation sees either the old or the new set, never a mix.
 type FS struct {
+	mu   sync.RWMutex
 	dirs []openDir
 }
 
 // New creates a new FS with the given allowed directories.
 // Each directory is opened as an os.Root and validated.
-// Returns an error if any directory path is invalid or does not exist.
+// Returns an error if any directory path is invalid or does not exist,
+// or if a label is invalid or used twice.
 // The caller should call Close when the FS is no longer needed.
 func New(dirs []Dir) (*FS, error) {
+	opened, err := openDirs(dirs)
+	if err != nil {
+		return nil, err
+	}
+	return &FS{dirs: opened}, nil
+}
+
+// openDirs opens the roots of dirs. On error, the roots already opened
+// are closed.
+func openDirs(dirs []Dir) ([]openDir, error) {
 	opened := make([]openDir, 0, len(dirs))
+	fail := func(err error) ([]openDir, error) {
+		closeDirs(opened)
+		return nil, err
+	}
+	labels := make(map[string]bool)
 	for _, d := range dirs {
+		if d.Label != "" {
+			if !labelPattern.MatchString(d.Label) {
+				return fail(fmt.Errorf("allowed dir %q: invalid label %q (use letters, digits, '.', '_' and '-')", d.Path, d.Label))
+			}
+			if labels[d.Label] {
+				return fail(fmt.Errorf("allowed dir %q: label %q is used by another directory", d.Path, d.Label))
+			}
+			labels[d.Label] = true
+		}
+
 		abs, err := filepath.Abs(d.Path)
 		if err != nil {
-			return nil, fmt.Errorf("allowed dir %q: resolving path: %w", d.Path, err)
+			return fail(fmt.Errorf("allowed dir %q: resolving path: %w", d.Path, err))
 		}
 
 		root, err := os.OpenRoot(abs)
 		if err != nil {
-			// Close any already-opened roots before returning.
-			for _, o := range opened {
-				o.root.Close()
-			}
-			return nil, fmt.Errorf("allowed dir %q: %w", d.Path, err)
+			return fail(fmt.Errorf("allowed dir %q: %w", d.Path, err))
 		}
-		opened = append(opened, openDir{root: root, mode: d.Mode, path: abs})
+		opened = append(opened, openDir{root: root, mode: d.Mode, path: abs, label: d.Label})
 	}
-	return &FS{dirs: opened}, nil
+	return opened, nil
 }
 
-// Close releases all os.Root handles.
-func (fs *FS) Close() error {
+// closeDirs closes the roots of dirs and returns the first error.
+func closeDirs(dirs []openDir) error {
 	var firstErr error
-	for _, d := range fs.dirs {
+	for _, d := range dirs {
 		if err := d.root.Close(); err != nil && firstErr == nil {
 			firstErr = err
 		}
@@ -81,8 +131,37 @@ func (fs *FS) Close() error {
 	return firstErr
 }
 
+// Reload replaces the allowed directories with dirs. The new directories
+// are opened first, so on error the FS keeps the old ones. The old roots
+// are closed once the operations in flight have finished; files they
+// opened stay usable until closed.
+func (fs *FS) Reload(dirs []Dir) error {
+	opened, err := openDirs(dirs)
+	if err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	old := fs.dirs
+	fs.dirs = opened
+	fs.mu.Unlock()
+	return closeDirs(old)
+}
+
+// Close releases all os.Root handles.
+func (fs *FS) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return closeDirs(fs.dirs)
+}
+
 // Enabled returns true if any directories are configured.
 func (fs *FS) Enabled() bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.enabled()
+}
+
+func (fs *FS) enabled() bool {
 	return len(fs.dirs) > 0
 }
 
@@ -90,7 +169,9 @@ func (fs *FS) Enabled() bool {
 // The path must be relative to one of the configured directories.
 // Returns the file contents and the directory it was read from.
 func (fs *FS) ReadFile(path string) ([]byte, string, error) {
-	if !fs.Enabled() {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
 		return nil, "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
 	}
 	if path == "" {
@@ -105,17 +186,20 @@ func (fs *FS) ReadFile(path string) ([]byte, string, error) {
 			lastErr = err
 			continue
 		}
-		return data, d.path, nil
+		return data, d.name(), nil
 	}
 
 	return nil, "", fmt.Errorf("cannot read %q: %w", path, lastErr)
 }
 
-// OpenFile opens a file from an allowed directory for streaming.
-// The caller must close the returned ReadCloser.
+// OpenFile opens a file from an allowed directory for streaming. The
+// returned file can seek, so callers can read a range without the rest of
+// the file. The caller must close it.
 // Returns the file handle and the directory it was opened from.
-func (fs *FS) OpenFile(path string) (io.ReadCloser, string, error) {
-	if !fs.Enabled() {
+func (fs *FS) OpenFile(path string) (io.ReadSeekCloser, string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
 		return nil, "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
 	}
 	if path == "" {
@@ -129,7 +213,7 @@ func (fs *FS) OpenFile(path string) (io.ReadCloser, string, error) {
 			lastErr = err
 			continue
 		}
-		return f, d.path, nil
+		return f, d.name(), nil
 	}
 
 	return nil, "", fmt.Errorf("cannot open %q: %w", path, lastErr)
@@ -137,59 +221,216 @@ func (fs *FS) OpenFile(path string) (io.ReadCloser, string, error) {
 
 // WriteFile writes data to a file in an allowed read-write directory.
 // The path must be relative to one of the configured read-write directories.
-// Creates the file if it doesn't exist, truncates if it does.
+// Creates the file if it doesn't exist and replaces it if it does; like
+// CreateFile, a failed write leaves the old file as it was.
 // Returns the directory it was written to.
 func (fs *FS) WriteFile(path string, data []byte) (string, error) {
-	if !fs.Enabled() {
-		return "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
+	f, dir, err := fs.CreateFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return "", fmt.Errorf("cannot write %q: %w", path, err)
+	}
+	if err := f.Commit(); err != nil {
+		return "", fmt.Errorf("cannot write %q: %w", path, err)
+	}
+	return dir, nil
+}
+
+// PendingFile is a file being written through CreateFile or CreateNewFile.
+// Its content only appears at its path once Commit succeeds; closing it
+// without Commit discards what was written, so a failed or cancelled write
+// never leaves a partial file behind.
+type PendingFile struct {
+	f    *os.File
+	root *os.Root
+	// tmp is the temporary file Commit renames to path, or "" when f was
+	// created at path itself.
+	tmp  string
+	path string
+	done bool
+}
+
+// Write writes to the file.
+func (p *PendingFile) Write(b []byte) (int, error) {
+	return p.f.Write(b)
+}
+
+// Commit closes the file and makes it appear at its path, replacing the
+// file that was there.
+func (p *PendingFile) Commit() error {
+	if p.done {
+		return os.ErrClosed
+	}
+	p.done = true
+	if err := p.f.Close(); err != nil {
+		p.remove()
+		return err
+	}
+	if p.tmp == "" {
+		return nil
+	}
+	if err := p.root.Rename(p.tmp, p.path); err != nil {
+		p.remove()
+		return err
+	}
+	return nil
+}
+
+// Close discards the file unless it was committed. It may be deferred
+// right after the file is created.
+func (p *PendingFile) Close() error {
+	if p.done {
+		return nil
+	}
+	p.done = true
+	p.f.Close()
+	return p.remove()
+}
+
+// remove deletes the file, at its temporary name if it has one.
+func (p *PendingFile) remove() error {
+	name := p.tmp
+	if name == "" {
+		name = p.path
+	}
+	return p.root.Remove(name)
+}
+
+// CreateFile creates a file in an allowed read-write directory for
+// streaming writes. The path must be relative to one of the configured
+// read-write directories. The content is written to a temporary file in
+// the same directory and replaces any existing file at path only when the
+// caller commits it, so that a failed write leaves the old file intact.
+// The caller must call Commit when done and should defer Close.
+// Returns the file handle and the directory it was created in.
+func (fs *FS) CreateFile(path string) (*PendingFile, string, error) {
+	return fs.create(path, false)
+}
+
+// CreateNewFile is like CreateFile but fails if the file already exists.
+// The existence check and creation are atomic, so the file is written at
+// path directly and removed again if it isn't committed. If the file
+// exists in the first read-write directory, the error wraps os.ErrExist
+// and no other directory is tried.
+func (fs *FS) CreateNewFile(path string) (*PendingFile, string, error) {
+	return fs.create(path, true)
+}
+
+func (fs *FS) create(path string, excl bool) (*PendingFile, string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
+		return nil, "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
 	}
 	if path == "" {
-		return "", fmt.Errorf("path is required")
+		return nil, "", fmt.Errorf("path is required")
 	}
 
 	var lastErr error
 	for _, d := range fs.dirs {
 		if d.mode == ModeRead {
-			lastErr = fmt.Errorf("directory %s is read-only", d.path)
+			lastErr = fmt.Errorf("directory %s is read-only", d.name())
 			continue
 		}
-		if err := d.root.WriteFile(path, data, 0644); err != nil {
+		p := &PendingFile{root: d.root, path: path}
+		var err error
+		if excl {
+			p.f, err = d.root.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if errors.Is(err, os.ErrExist) {
+				return nil, "", fmt.Errorf("cannot create %q: %w", path, err)
+			}
+		} else {
+			p.f, p.tmp, err = createTemp(d.root, path)
+		}
+		if err != nil {
 			lastErr = err
 			continue
 		}
-		return d.path, nil
+		return p, d.name(), nil
+	}
+
+	return nil, "", fmt.Errorf("cannot create %q: %w", path, lastErr)
+}
+
+// createTemp creates a new temporary file next to path in root, named
+// after it, and returns it with its name.
+func createTemp(root *os.Root, path string) (*os.File, string, error) {
+	if st, err := root.Lstat(path); err == nil && !st.Mode().IsRegular() {
+		// Renaming over a symlink or directory would replace it rather
+		// than write through it; refuse it as opening it would.
+		return nil, "", fmt.Errorf("%s is not a regular file", path)
+	}
+	dir, base := filepath.Split(path)
+	for range 10 {
+		tmp := filepath.Join(dir, "."+base+"."+rand.Text()[:8]+".tmp")
+		f, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return f, tmp, err
 	}
+	return nil, "", fmt.Errorf("cannot create a temporary file for %q", path)
+}
 
-	return "", fmt.Errorf("cannot write %q: %w", path, lastErr)
+// Writable reports whether at least one read-write directory is configured.
+func (fs *FS) Writable() bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	for _, d := range fs.dirs {
+		if d.mode == ModeReadWrite {
+			return true
+		}
+	}
+	return false
 }
 
 // DirInfo describes a configured allowed directory.
 type DirInfo struct {
-	Path string
-	Mode Mode
+	Path  string
+	Mode  Mode
+	Label string
+}
+
+// Name returns the name results show for the directory: its label, or its
+// path when it has none.
+func (d DirInfo) Name() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.Path
 }
 
 // Dirs returns the list of configured allowed directories.
 func (fs *FS) Dirs() []DirInfo {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	dirs := make([]DirInfo, len(fs.dirs))
 	for i, d := range fs.dirs {
-		dirs[i] = DirInfo{Path: d.path, Mode: d.mode}
+		dirs[i] = DirInfo{Path: d.path, Mode: d.mode, Label: d.label}
 	}
 	return dirs
 }
 
 // FileEntry describes a single entry in a directory listing.
 type FileEntry struct {
-	Name  string
-	IsDir bool
-	Size  int64
+	// Name is the entry name for ListDir, or the slash-separated path
+	// relative to the walked directory for Walk.
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
 }
 
 // ListDir lists the contents of a directory within an allowed directory.
 // The path is relative to one of the configured directories.
 // Use "." or "" to list the root of an allowed directory.
 func (fs *FS) ListDir(path string) ([]FileEntry, string, error) {
-	if !fs.Enabled() {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
 		return nil, "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
 	}
 	if path == "" {
@@ -213,24 +454,178 @@ func (fs *FS) ListDir(path string) ([]FileEntry, string, error) {
 		for i, e := range entries {
 			info, _ := e.Info()
 			var size int64
+			var modTime time.Time
 			if info != nil {
 				size = info.Size()
+				modTime = info.ModTime()
 			}
 			result[i] = FileEntry{
-				Name:  e.Name(),
-				IsDir: e.IsDir(),
-				Size:  size,
+				Name:    e.Name(),
+				IsDir:   e.IsDir(),
+				Size:    size,
+				ModTime: modTime,
 			}
 		}
-		return result, d.path, nil
+		return result, d.name(), nil
 	}
 
 	return nil, "", fmt.Errorf("cannot list %q: %w", path, lastErr)
 }
 
+// DefaultMaxWalkEntries is the number of directory entries Walk visits when
+// WalkOptions.MaxEntries is not set.
+const DefaultMaxWalkEntries = 10000
+
+// WalkOptions controls a Walk.
+type WalkOptions struct {
+	// Recursive descends into subdirectories. Otherwise only the files
+	// directly in the walked directory are returned.
+	Recursive bool
+	// Glob, if set, keeps only files matching a path.Match pattern. A
+	// pattern containing '/' is matched against the path relative to the
+	// walked directory; otherwise it is matched against the file name, so
+	// "*.pdf" finds PDFs at any depth.
+	Glob string
+	// MaxEntries caps the number of directory entries (files and
+	// directories) visited. Zero means DefaultMaxWalkEntries.
+	MaxEntries int
+}
+
+// WalkResult is the result of a Walk.
+type WalkResult struct {
+	// Files are the matching files in walk order, named by their path
+	// relative to the walked directory.
+	Files []FileEntry
+	// Dir is the allowed directory that was walked.
+	Dir string
+	// Truncated reports that the walk stopped at MaxEntries.
+	Truncated bool
+}
+
+// Walk lists the files under a directory within an allowed directory.
+// Symlinks are never followed into directories: a symlink to a directory
+// is skipped, and a symlink to a file is listed only if it resolves inside
+// the allowed directory. Unreadable subdirectories are skipped.
+// Use "." or "" to walk the root of an allowed directory.
+func (fs *FS) Walk(dirPath string, opts WalkOptions) (*WalkResult, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
+		return nil, fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
+	}
+	if opts.Glob != "" {
+		if _, err := path.Match(opts.Glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", opts.Glob, err)
+		}
+	}
+	limit := opts.MaxEntries
+	if limit <= 0 {
+		limit = DefaultMaxWalkEntries
+	}
+	start := path.Clean(filepath.ToSlash(dirPath))
+	if start == "" {
+		start = "."
+	}
+
+	var lastErr error
+	for _, d := range fs.dirs {
+		info, err := d.root.Stat(start)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !info.IsDir() {
+			lastErr = fmt.Errorf("%s is not a directory", start)
+			continue
+		}
+		result, err := walkRoot(d.root.FS(), start, opts, limit)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result.Dir = d.name()
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("cannot walk %q: %w", dirPath, lastErr)
+}
+
+func walkRoot(fsys iofs.FS, start string, opts WalkOptions, limit int) (*WalkResult, error) {
+	result := &WalkResult{}
+	visited := 0
+	err := iofs.WalkDir(fsys, start, func(p string, d iofs.DirEntry, err error) error {
+		if p == start {
+			return err
+		}
+		if err != nil {
+			// Unreadable subdirectory: skip it.
+			return nil
+		}
+		visited++
+		if visited > limit {
+			result.Truncated = true
+			return iofs.SkipAll
+		}
+
+		if d.IsDir() {
+			if !opts.Recursive {
+				return iofs.SkipDir
+			}
+			return nil
+		}
+
+		var info iofs.FileInfo
+		if d.Type()&iofs.ModeSymlink != 0 {
+			// Stat follows the link, but only within the root.
+			if info, err = iofs.Stat(fsys, p); err != nil || info.IsDir() {
+				return nil
+			}
+		} else if info, err = d.Info(); err != nil {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel := p
+		if start != "." {
+			rel = strings.TrimPrefix(p, start+"/")
+		}
+		if !matchGlob(opts.Glob, rel) {
+			return nil
+		}
+		result.Files = append(result.Files, FileEntry{
+			Name:    rel,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil && !errors.Is(err, iofs.SkipAll) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// matchGlob reports whether rel matches pattern (see WalkOptions.Glob).
+// An empty pattern matches everything.
+func matchGlob(pattern, rel string) bool {
+	if pattern == "" {
+		return true
+	}
+	name := rel
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(rel)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
 // Stat returns file info from an allowed directory.
 func (fs *FS) Stat(path string) (os.FileInfo, string, error) {
-	if !fs.Enabled() {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if !fs.enabled() {
 		return nil, "", fmt.Errorf("local file access is not enabled (use --allow-read-dir or --allow-write-dir)")
 	}
 	if path == "" {
@@ -244,7 +639,7 @@ func (fs *FS) Stat(path string) (os.FileInfo, string, error) {
 			lastErr = err
 			continue
 		}
-		return info, d.path, nil
+		return info, d.name(), nil
 	}
 
 	return nil, "", fmt.Errorf("cannot stat %q: %w", path, lastErr)
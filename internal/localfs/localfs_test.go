@@ -1,8 +1,15 @@
 package localfs
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -337,6 +344,13 @@ func TestDisabledFS(t *testing.T) {
 		}
 	})
 
+	t.Run("create disabled", func(t *testing.T) {
+		_, _, err := fs.CreateFile("test.txt")
+		if err == nil {
+			t.Fatal("expected error when FS is disabled")
+		}
+	})
+
 	t.Run("open disabled", func(t *testing.T) {
 		_, _, err := fs.OpenFile("anything.txt")
 		if err == nil {
@@ -376,6 +390,198 @@ func TestOpenFile(t *testing.T) {
 	}
 }
 
+func TestCreateFile(t *testing.T) {
+	readonlyDir, readwriteDir, outsideDir := setupTestDirs(t)
+
+	// A symlink inside the read-write dir that points outside.
+	if err := os.Symlink(filepath.Join(outsideDir, "secret.txt"), filepath.Join(readwriteDir, "escape-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := New([]Dir{
+		{Path: readonlyDir, Mode: ModeRead},
+		{Path: readwriteDir, Mode: ModeReadWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	t.Run("create in readwrite dir", func(t *testing.T) {
+		w, dir, err := fs.CreateFile("created.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := io.WriteString(w, "created content"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if dir != readwriteDir {
+			t.Errorf("dir = %q, want %q", dir, readwriteDir)
+		}
+		data, err := os.ReadFile(filepath.Join(readwriteDir, "created.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "created content" {
+			t.Errorf("got %q, want %q", string(data), "created content")
+		}
+	})
+
+	t.Run("replaces existing file", func(t *testing.T) {
+		w, _, err := fs.CreateFile("existing.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		io.WriteString(w, "short")
+		if data, _ := os.ReadFile(filepath.Join(readwriteDir, "existing.txt")); string(data) == "short" {
+			t.Error("existing file replaced before Commit")
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(filepath.Join(readwriteDir, "existing.txt"))
+		if string(data) != "short" {
+			t.Errorf("got %q, want %q", string(data), "short")
+		}
+	})
+
+	t.Run("close without commit keeps existing file", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(readwriteDir, "kept.txt"), []byte("old content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w, _, err := fs.CreateFile("kept.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		io.WriteString(w, "partial")
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(filepath.Join(readwriteDir, "kept.txt"))
+		if string(data) != "old content" {
+			t.Errorf("got %q, want %q", string(data), "old content")
+		}
+		entries, _ := os.ReadDir(readwriteDir)
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), ".tmp") {
+				t.Errorf("temporary file %s left behind", e.Name())
+			}
+		}
+	})
+
+	// os.Root prevents all of these at the kernel level.
+	tests := []struct {
+		name string
+		path string
+	}{
+		{
+			name: "dot-dot traversal",
+			path: "../outside/escape.txt",
+		},
+		{
+			name: "nested dot-dot traversal",
+			path: "sub/../../outside/escape.txt",
+		},
+		{
+			name: "absolute path",
+			path: filepath.Join(outsideDir, "escape.txt"),
+		},
+		{
+			name: "symlink escape",
+			path: "escape-link",
+		},
+		{
+			name: "empty path",
+			path: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _, err := fs.CreateFile(tt.path)
+			if err == nil {
+				w.Close()
+				t.Fatal("expected error creating file outside allowed dirs")
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outsideDir, "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("file was created outside allowed dirs (stat err: %v)", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outsideDir, "secret.txt")); string(data) != "secret content" {
+		t.Errorf("symlink target was modified: %q", string(data))
+	}
+}
+
+func TestCreateFileReadOnlyDenied(t *testing.T) {
+	readonlyDir, _, _ := setupTestDirs(t)
+
+	fs, err := New([]Dir{
+		{Path: readonlyDir, Mode: ModeRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	if _, _, err := fs.CreateFile("attempt.txt"); err == nil {
+		t.Fatal("expected error creating file in read-only directory")
+	}
+	if _, err := os.Stat(filepath.Join(readonlyDir, "attempt.txt")); !os.IsNotExist(err) {
+		t.Errorf("file was created in read-only dir (stat err: %v)", err)
+	}
+}
+
+func TestCreateFileStreamingCopy(t *testing.T) {
+	_, readwriteDir, _ := setupTestDirs(t)
+
+	fs, err := New([]Dir{
+		{Path: readwriteDir, Mode: ModeReadWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	// 8 MB of non-repeating-looking data.
+	payload := make([]byte, 8<<20)
+	for i := range payload {
+		payload[i] = byte(i*31 + i>>8)
+	}
+
+	w, _, err := fs.CreateFile("large.bin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	n, err := io.Copy(w, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(payload)) {
+		t.Errorf("copied %d bytes, want %d", n, len(payload))
+	}
+
+	// Read back through the FS to check the round trip.
+	rc, _, err := fs.OpenFile("large.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("read back %d bytes that don't match the %d-byte payload", len(got), len(payload))
+	}
+}
+
 func TestStat(t *testing.T) {
 	readonlyDir, _, _ := setupTestDirs(t)
 
@@ -617,3 +823,365 @@ func TestListDirFallthrough(t *testing.T) {
 		t.Errorf("unexpected entries: %v", entries)
 	}
 }
+
+func walkNames(r *WalkResult) []string {
+	names := make([]string, len(r.Files))
+	for i, f := range r.Files {
+		names[i] = f.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWalk(t *testing.T) {
+	readonlyDir, _, outsideDir := setupTestDirs(t)
+	os.MkdirAll(filepath.Join(readonlyDir, "subdir", "deep"), 0755)
+	os.WriteFile(filepath.Join(readonlyDir, "subdir", "deep", "invoice.pdf"), []byte("pdf"), 0644)
+	os.WriteFile(filepath.Join(readonlyDir, "top.pdf"), []byte("pdf"), 0644)
+
+	// A symlinked directory inside the root and one pointing outside:
+	// neither is descended into.
+	if err := os.Symlink("subdir", filepath.Join(readonlyDir, "loop")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(readonlyDir, "outside-link")); err != nil {
+		t.Fatal(err)
+	}
+	// A symlinked file inside the root is listed; one escaping it is not.
+	os.Symlink("file.txt", filepath.Join(readonlyDir, "file-link.txt"))
+	os.Symlink(filepath.Join(outsideDir, "secret.txt"), filepath.Join(readonlyDir, "secret-link.txt"))
+
+	fs, err := New([]Dir{
+		{Path: readonlyDir, Mode: ModeRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	t.Run("recursive", func(t *testing.T) {
+		r, err := fs.Walk(".", WalkOptions{Recursive: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := walkNames(r)
+		want := []string{"file-link.txt", "file.txt", "subdir/deep/invoice.pdf", "subdir/nested.txt", "top.pdf"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("files = %v, want %v", got, want)
+		}
+		if r.Dir != readonlyDir || r.Truncated {
+			t.Errorf("dir = %q, truncated = %v", r.Dir, r.Truncated)
+		}
+	})
+
+	t.Run("not recursive", func(t *testing.T) {
+		r, err := fs.Walk("", WalkOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := walkNames(r)
+		want := []string{"file-link.txt", "file.txt", "top.pdf"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("files = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("glob on name", func(t *testing.T) {
+		r, err := fs.Walk(".", WalkOptions{Recursive: true, Glob: "*.pdf"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := walkNames(r)
+		want := []string{"subdir/deep/invoice.pdf", "top.pdf"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("files = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("glob on relative path", func(t *testing.T) {
+		r, err := fs.Walk("subdir", WalkOptions{Recursive: true, Glob: "deep/*"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := walkNames(r)
+		want := []string{"deep/invoice.pdf"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("files = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid glob", func(t *testing.T) {
+		if _, err := fs.Walk(".", WalkOptions{Glob: "[a-"}); err == nil {
+			t.Fatal("expected error for invalid glob")
+		}
+	})
+
+	t.Run("traversal denied", func(t *testing.T) {
+		for _, p := range []string{"../outside", outsideDir, "outside-link"} {
+			if _, err := fs.Walk(p, WalkOptions{Recursive: true}); err == nil {
+				t.Errorf("Walk(%q): expected error", p)
+			}
+		}
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		if _, err := fs.Walk("file.txt", WalkOptions{}); err == nil {
+			t.Fatal("expected error walking a file")
+		}
+	})
+}
+
+func TestWalkMaxEntries(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 20 {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)), []byte("x"), 0644)
+	}
+
+	fs, err := New([]Dir{
+		{Path: dir, Mode: ModeRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	r, err := fs.Walk(".", WalkOptions{Recursive: true, MaxEntries: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Files) != 5 || !r.Truncated {
+		t.Errorf("got %d files, truncated = %v; want 5, true", len(r.Files), r.Truncated)
+	}
+
+	r, err = fs.Walk(".", WalkOptions{MaxEntries: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Files) != 20 || r.Truncated {
+		t.Errorf("got %d files, truncated = %v; want 20, false", len(r.Files), r.Truncated)
+	}
+}
+
+func TestWalkDisabled(t *testing.T) {
+	fs, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	if _, err := fs.Walk(".", WalkOptions{}); err == nil {
+		t.Fatal("expected error when FS is disabled")
+	}
+}
+
+func TestCreateNewFile(t *testing.T) {
+	readonlyDir, readwriteDir, _ := setupTestDirs(t)
+
+	fs, err := New([]Dir{
+		{Path: readonlyDir, Mode: ModeRead},
+		{Path: readwriteDir, Mode: ModeReadWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	w, _, err := fs.CreateNewFile("fresh.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _, err = fs.CreateNewFile("abandoned.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.WriteString(w, "partial")
+	w.Close()
+	if _, err := os.Stat(filepath.Join(readwriteDir, "abandoned.txt")); !os.IsNotExist(err) {
+		t.Errorf("uncommitted new file left behind (stat err: %v)", err)
+	}
+
+	_, _, err = fs.CreateNewFile("existing.txt")
+	if !errors.Is(err, os.ErrExist) {
+		t.Fatalf("err = %v, want os.ErrExist", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(readwriteDir, "existing.txt"))
+	if string(data) != "readwrite content" {
+		t.Errorf("existing file was modified: %q", string(data))
+	}
+}
+
+func TestWritable(t *testing.T) {
+	readonlyDir, readwriteDir, _ := setupTestDirs(t)
+
+	ro, err := New([]Dir{{Path: readonlyDir, Mode: ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	if ro.Writable() {
+		t.Error("expected Writable() to be false with only read-only dirs")
+	}
+
+	rw, err := New([]Dir{{Path: readonlyDir, Mode: ModeRead}, {Path: readwriteDir, Mode: ModeReadWrite}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	if !rw.Writable() {
+		t.Error("expected Writable() to be true with a read-write dir")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	readonlyDir, readwriteDir, _ := setupTestDirs(t)
+
+	fs, err := New([]Dir{
+		{Path: readonlyDir, Mode: ModeRead, Label: "docs"},
+		{Path: readwriteDir, Mode: ModeReadWrite, Label: "out"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	if _, dir, err := fs.ReadFile("file.txt"); err != nil || dir != "docs" {
+		t.Errorf("ReadFile() dir = %q, %v, want docs", dir, err)
+	}
+	if dir, err := fs.WriteFile("new.txt", []byte("x")); err != nil || dir != "out" {
+		t.Errorf("WriteFile() dir = %q, %v, want out", dir, err)
+	}
+	if _, dir, err := fs.Stat("subdir/nested.txt"); err != nil || dir != "docs" {
+		t.Errorf("Stat() dir = %q, %v, want docs", dir, err)
+	}
+	if r, err := fs.Walk(".", WalkOptions{}); err != nil || r.Dir != "docs" {
+		t.Errorf("Walk() dir = %v, %v, want docs", r, err)
+	}
+
+	dirs := fs.Dirs()
+	if dirs[0].Label != "docs" || dirs[0].Name() != "docs" || dirs[0].Path != readonlyDir {
+		t.Errorf("Dirs()[0] = %+v", dirs[0])
+	}
+
+	// Unlabeled directories are still named by their path.
+	if got := (DirInfo{Path: "/data"}).Name(); got != "/data" {
+		t.Errorf("Name() = %q, want /data", got)
+	}
+}
+
+func TestLabels_Invalid(t *testing.T) {
+	readonlyDir, readwriteDir, _ := setupTestDirs(t)
+
+	for _, tt := range []struct {
+		dirs    []Dir
+		wantErr string
+	}{
+		{[]Dir{{Path: readonlyDir, Label: "my docs"}}, "invalid label"},
+		{[]Dir{{Path: readonlyDir, Label: "-x"}}, "invalid label"},
+		{[]Dir{{Path: readonlyDir, Label: "a"}, {Path: readwriteDir, Label: "a"}}, "used by another directory"},
+	} {
+		fs, err := New(tt.dirs)
+		if err == nil {
+			fs.Close()
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("New(%+v) error = %v, want %q", tt.dirs, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	readonlyDir, readwriteDir, outsideDir := setupTestDirs(t)
+
+	fs, err := New([]Dir{{Path: readonlyDir, Mode: ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+	old := fs.dirs[0].root
+
+	if err := fs.Reload([]Dir{{Path: readwriteDir, Mode: ModeReadWrite, Label: "out"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fs.ReadFile("file.txt"); err == nil {
+		t.Error("ReadFile() from the removed directory succeeded")
+	}
+	if _, dir, err := fs.ReadFile("existing.txt"); err != nil || dir != "out" {
+		t.Errorf("ReadFile() after reload = %q, %v", dir, err)
+	}
+	if !fs.Writable() {
+		t.Error("Writable() = false after adding a read-write directory")
+	}
+	if _, err := old.Stat("."); err == nil {
+		t.Error("old root still open after reload")
+	}
+
+	// A failed reload keeps the current directories.
+	err = fs.Reload([]Dir{{Path: outsideDir}, {Path: filepath.Join(outsideDir, "missing")}})
+	if err == nil {
+		t.Fatal("Reload() with a missing directory succeeded")
+	}
+	if dirs := fs.Dirs(); len(dirs) != 1 || dirs[0].Path != readwriteDir {
+		t.Errorf("Dirs() after failed reload = %+v", dirs)
+	}
+
+	// Reloading to no directories disables access.
+	if err := fs.Reload(nil); err != nil {
+		t.Fatal(err)
+	}
+	if fs.Enabled() {
+		t.Error("Enabled() = true after reloading to no directories")
+	}
+}
+
+func TestReload_ConcurrentReads(t *testing.T) {
+	readonlyDir, readwriteDir, _ := setupTestDirs(t)
+	if err := os.WriteFile(filepath.Join(readwriteDir, "file.txt"), []byte("readwrite copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sets := [][]Dir{
+		{{Path: readonlyDir, Mode: ModeRead, Label: "a"}},
+		{{Path: readwriteDir, Mode: ModeReadWrite, Label: "b"}},
+	}
+	fs, err := New(sets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	// file.txt exists in both sets, so every read must succeed and report
+	// the directory its content came from, whichever set it saw.
+	want := map[string]string{"a": "readonly content", "b": "readwrite copy"}
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		wg.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				data, dir, err := fs.ReadFile("file.txt")
+				if err != nil {
+					t.Errorf("ReadFile() during reload: %v", err)
+					return
+				}
+				if string(data) != want[dir] {
+					t.Errorf("ReadFile() = %q from %q", data, dir)
+					return
+				}
+			}
+		})
+	}
+	for i := range 50 {
+		if err := fs.Reload(sets[i%2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SetAccountNames sets the configured account names, which AddTool lists in
+// the description of every account parameter (account, drive_account, ...)
+// so that clients don't have to guess them. Tools already registered are
+// registered again with the new names, which notifies clients that the tool
+// list changed. An empty list leaves the descriptions as written.
+func (s *Server) SetAccountNames(names []string) {
+	s.accountNames = names
+	for _, t := range s.tools {
+		if register := s.registrations[t.Name]; register != nil && s.toolExposed(t.Name) {
+			register()
+		}
+	}
+}
+
+// AccountNames returns the names set with SetAccountNames.
+func (s *Server) AccountNames() []string {
+	return s.accountNames
+}
+
+// withAccountNames returns t with the account parameters of its input
+// schema, inferred from In unless t sets one, describing names. t is
+// returned as is when it has no account parameters or names is empty.
+func withAccountNames[In any](t *mcp.Tool, names []string) *mcp.Tool {
+	if len(names) == 0 {
+		return t
+	}
+	var schema *jsonschema.Schema
+	switch in := t.InputSchema.(type) {
+	case nil:
+		rt := reflect.TypeFor[In]()
+		if rt.Kind() == reflect.Pointer {
+			rt = rt.Elem()
+		}
+		if rt.Kind() != reflect.Struct {
+			return t
+		}
+		inferred, err := jsonschema.ForType(rt, &jsonschema.ForOptions{})
+		if err != nil {
+			// Left for mcp.AddTool to report.
+			return t
+		}
+		schema = inferred
+	case *jsonschema.Schema:
+		schema = in.CloneSchemas()
+	default:
+		return t
+	}
+	if !describeAccountFields(schema, names) {
+		return t
+	}
+	tt := *t
+	tt.InputSchema = schema
+	return &tt
+}
+
+// describeAccountFields appends the account names to the description of
+// each account parameter in schema and its nested objects and arrays,
+// reporting whether there were any.
+func describeAccountFields(schema *jsonschema.Schema, names []string) bool {
+	if schema == nil {
+		return false
+	}
+	found := false
+	for name, prop := range schema.Properties {
+		if isAccountField(name) && prop.Type == "string" {
+			prop.Description = accountFieldDescription(prop.Description, names)
+			found = true
+			continue
+		}
+		if describeAccountFields(prop, names) {
+			found = true
+		}
+	}
+	if describeAccountFields(schema.Items, names) {
+		found = true
+	}
+	return found
+}
+
+// isAccountField reports whether the input parameter name takes an
+// account name.
+func isAccountField(name string) bool {
+	return name == "account" || strings.HasSuffix(name, "_account")
+}
+
+// accountFieldDescription appends names to desc, e.g. "Account name.
+// Configured accounts: 'personal', 'work'.", mentioning 'all' when desc
+// says the parameter accepts it.
+func accountFieldDescription(desc string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("'%s'", n)
+	}
+	list := strings.Join(quoted, ", ")
+	if strings.Contains(desc, "'all'") {
+		list += ", or 'all' for all of them"
+	}
+	if desc != "" && !strings.HasSuffix(desc, ".") {
+		desc += "."
+	}
+	return strings.TrimSpace(desc + " Configured accounts: " + list + ".")
+}
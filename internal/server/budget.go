@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxOutputBytes is the default output budget for tools that list
+// many items. See SetMaxOutputBytes.
+const DefaultMaxOutputBytes = 100 * 1024
+
+// SetMaxOutputBytes sets the output budget in bytes for tools that list
+// many items (messages, threads, events, files). Output past the budget is
+// cut at an item boundary with a truncation notice. Zero or a negative
+// value disables the budget.
+func (s *Server) SetMaxOutputBytes(n int) {
+	s.maxOutputBytes = n
+}
+
+// MaxOutputBytes returns the configured output budget, or 0 if disabled.
+func (s *Server) MaxOutputBytes() int {
+	return max(s.maxOutputBytes, 0)
+}
+
+// NewOutputBuilder returns an OutputBuilder using the server's output
+// budget.
+func (s *Server) NewOutputBuilder() *OutputBuilder {
+	return NewOutputBuilder(s.MaxOutputBytes())
+}
+
+// OutputBuilder accumulates tool output made of items (one message, thread,
+// event or file each) under a byte budget. Items are only ever added whole:
+// the first item that doesn't fit, and everything written after it, is
+// dropped and String appends a truncation notice. The first item is always
+// kept so that truncated output is never empty. A limit of 0 or less
+// disables the budget.
+//
+// OutputBuilder implements io.Writer, so headers and other text that isn't
+// an item can be written with fmt.Fprintf.
+type OutputBuilder struct {
+	sb        strings.Builder
+	limit     int
+	items     int
+	truncated bool
+}
+
+// NewOutputBuilder returns an OutputBuilder with the given budget in bytes.
+func NewOutputBuilder(limit int) *OutputBuilder {
+	return &OutputBuilder{limit: limit}
+}
+
+// Write appends text that isn't an item, such as a header. It is discarded
+// once the output has been truncated.
+func (b *OutputBuilder) Write(p []byte) (int, error) {
+	if !b.truncated {
+		b.sb.Write(p)
+	}
+	return len(p), nil
+}
+
+// WriteString is like Write for strings.
+func (b *OutputBuilder) WriteString(s string) (int, error) {
+	if !b.truncated {
+		b.sb.WriteString(s)
+	}
+	return len(s), nil
+}
+
+// AddItem appends one complete item. It reports false if the item was
+// dropped because the budget is exhausted; callers can stop producing
+// items (and skip the API calls behind them) at that point.
+func (b *OutputBuilder) AddItem(item string) bool {
+	if b.truncated {
+		return false
+	}
+	if b.limit > 0 && b.items > 0 && b.sb.Len()+len(item) > b.limit {
+		b.truncated = true
+		return false
+	}
+	b.sb.WriteString(item)
+	b.items++
+	return true
+}
+
+// Truncated reports whether any item was dropped.
+func (b *OutputBuilder) Truncated() bool {
+	return b.truncated
+}
+
+// String returns the output, followed by a truncation notice if items were
+// dropped.
+func (b *OutputBuilder) String() string {
+	if !b.truncated {
+		return b.sb.String()
+	}
+	return b.sb.String() + fmt.Sprintf("\n[output truncated after %d items, refine your query or use pagination]\n", b.items)
+}
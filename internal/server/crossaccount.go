@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"google.golang.org/api/googleapi"
+)
+
+// AccountProbe reports whether an item exists in account. It should be
+// cheap, e.g. a Get for the ID field only.
+type AccountProbe func(ctx context.Context, account string) (bool, error)
+
+// SetCrossAccountHints enables cross-account hints: when a lookup by ID
+// fails with 404, tools ask the other configured accounts whether they
+// have the item, to point out that the ID came from another account. It
+// costs a call per other account and reveals to the conversation which of
+// them has the item, so it is opt-in. See CrossAccountHint.
+func (s *Server) SetCrossAccountHints(enabled bool) {
+	s.crossAccountHints = enabled
+}
+
+// CrossAccountHints reports whether cross-account hints are enabled.
+func (s *Server) CrossAccountHints() bool {
+	return s.crossAccountHints
+}
+
+// crossAccountError is a not-found error for an item that another account
+// has.
+type crossAccountError struct {
+	item, account, other string
+	err                  error
+}
+
+func (e *crossAccountError) Error() string {
+	return fmt.Sprintf("%s not found in '%s' but exists in '%s' — retry with account='%s'", e.item, e.account, e.other, e.other)
+}
+
+func (e *crossAccountError) Unwrap() error { return e.err }
+
+// CrossAccountHint returns err, the failed lookup of item (e.g. "file
+// 1AbC") in account, with a hint naming another account that has it. The
+// other accounts are probed in name order until one reports the item;
+// probe errors count as not found. err is returned unchanged when hints
+// are disabled, err is not a 404, or only one account is configured.
+func (s *Server) CrossAccountHint(ctx context.Context, mgr *auth.Manager, account, item string, err error, probe AccountProbe) error {
+	var gerr *googleapi.Error
+	if !s.crossAccountHints || !errors.As(err, &gerr) || gerr.Code != http.StatusNotFound {
+		return err
+	}
+	resolved, rerr := mgr.ResolveAccounts(auth.ResolveAccount(ctx, account))
+	if rerr != nil || len(resolved) != 1 {
+		return err
+	}
+	all, rerr := mgr.ResolveAccounts("all")
+	if rerr != nil {
+		return err
+	}
+	sort.Strings(all)
+	for _, other := range all {
+		if other == resolved[0] || ctx.Err() != nil {
+			continue
+		}
+		if found, perr := probe(ctx, other); perr == nil && found {
+			return &crossAccountError{item: item, account: resolved[0], other: other, err: err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+)
+
+// DefaultJournalSize is the number of mutations the journal keeps in memory.
+const DefaultJournalSize = 500
+
+// AuditLogFile is the name of the audit log in the config directory.
+const AuditLogFile = "audit.jsonl"
+
+// maxMutationIDs caps the identifiers recorded for one call, so a batch
+// operation doesn't turn into a huge journal entry.
+const maxMutationIDs = 50
+
+// Mutation is a journal entry: a call of a tool that isn't read-only,
+// with the identifiers found in its result.
+type Mutation struct {
+	Time    time.Time    `json:"time"`
+	Tool    string       `json:"tool"`
+	Account string       `json:"account,omitempty"`
+	Summary string       `json:"summary,omitempty"` // first line of the result
+	IDs     []MutationID `json:"ids,omitempty"`
+	// Error is set when the call failed. It may still have made some
+	// changes, such as the items of a bulk call done before the failure.
+	Error string `json:"error,omitempty"`
+}
+
+// MutationID is an identifier from a tool result, such as the Event ID of
+// a created event.
+type MutationID struct {
+	Kind string `json:"kind"` // e.g. "Event ID"
+	ID   string `json:"id"`
+}
+
+// journal records mutations in a ring buffer and, when an audit log is
+// set, appends them to a JSONL file.
+type journal struct {
+	mu      sync.Mutex
+	entries []Mutation // ring buffer of at most size entries
+	next    int        // index of the oldest entry once the buffer is full
+	size    int
+	logPath string
+}
+
+func newJournal(size int) *journal {
+	return &journal{size: size}
+}
+
+// add stores m, overwriting the oldest entry when the buffer is full.
+func (j *journal) add(m Mutation) {
+	if len(j.entries) < j.size {
+		j.entries = append(j.entries, m)
+		return
+	}
+	j.entries[j.next] = m
+	j.next = (j.next + 1) % j.size
+}
+
+// record adds m to the journal and the audit log.
+func (j *journal) record(m Mutation) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.add(m)
+	if j.logPath == "" {
+		return nil
+	}
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// recent returns up to limit entries, newest first, optionally only those
+// of tool. A limit of zero or less returns all of them.
+func (j *journal) recent(limit int, tool string) []Mutation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var out []Mutation
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		m := j.entries[(j.next+i)%len(j.entries)]
+		if tool != "" && m.Tool != tool {
+			continue
+		}
+		out = append(out, m)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// SetAuditLog makes the server append every journaled mutation to the
+// JSONL file at path, and loads the newest entries already in the file so
+// list_recent_mutations also covers earlier sessions. Malformed lines, such
+// as one cut short by a crash, are skipped.
+func (s *Server) SetAuditLog(path string) error {
+	j := s.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for sc.Scan() {
+			var m Mutation
+			if json.Unmarshal(sc.Bytes(), &m) == nil && m.Tool != "" {
+				j.add(m)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return fmt.Errorf("reading audit log: %w", err)
+		}
+	}
+	j.logPath = path
+	return nil
+}
+
+// mutationIDPattern matches the identifier lines tools print in their
+// results, like "Event ID: abc" or "  - Draft ID: r-123".
+var mutationIDPattern = regexp.MustCompile(`(?m)^[\s-]*((?:[A-Z][A-Za-z]*\s)*ID):\s*(\S+)\s*$`)
+
+// mutationIDs extracts the identifiers from a tool result text.
+func mutationIDs(text string) []MutationID {
+	var ids []MutationID
+	seen := make(map[MutationID]bool)
+	for _, m := range mutationIDPattern.FindAllStringSubmatch(text, -1) {
+		id := MutationID{Kind: m[1], ID: m[2]}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == maxMutationIDs {
+			break
+		}
+	}
+	return ids
+}
+
+// newMutation builds the journal entry for a call of tool that returned
+// res and err.
+func newMutation(tool string, req *mcp.CallToolRequest, res *mcp.CallToolResult, err error, now time.Time) Mutation {
+	m := Mutation{Time: now.UTC(), Tool: tool, Account: callArgs(req).Account}
+	var text strings.Builder
+	if res != nil {
+		for _, c := range res.Content {
+			if tc, ok := c.(*mcp.TextContent); ok {
+				text.WriteString(tc.Text)
+				text.WriteString("\n")
+			}
+		}
+	}
+	for line := range strings.SplitSeq(text.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			m.Summary = truncateRunes(line, 200)
+			break
+		}
+	}
+	switch {
+	case err != nil:
+		m.Error = truncateRunes(err.Error(), 500)
+		text.WriteString(err.Error())
+	case res != nil && res.IsError:
+		m.Error, m.Summary = m.Summary, ""
+		if m.Error == "" {
+			m.Error = "failed"
+		}
+	}
+	m.IDs = mutationIDs(text.String())
+	return m
+}
+
+// journalArgs are the call arguments the journal looks at.
+type journalArgs struct {
+	Account string `json:"account"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// callArgs returns the journal's arguments of req; they are zero when req
+// has none or they don't parse.
+func callArgs(req *mcp.CallToolRequest) journalArgs {
+	var args journalArgs
+	if req != nil && req.Params != nil && len(req.Params.Arguments) > 0 {
+		_ = json.Unmarshal(req.Params.Arguments, &args)
+	}
+	return args
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// journalCall records a completed call of a tool that isn't read-only,
+// whatever its outcome: a failed call may have changed some data before
+// it failed, and records what was done so far. Calls with dry_run set are
+// skipped, since they change nothing. Calls without an account argument
+// are recorded with the session default account, if any.
+func (s *Server) journalCall(ctx context.Context, tool string, req *mcp.CallToolRequest, res *mcp.CallToolResult, err error) {
+	if callArgs(req).DryRun || (res == nil && err == nil) {
+		return
+	}
+	m := newMutation(tool, req, res, err, time.Now())
+	if m.Account == "" {
+		m.Account = auth.SessionAccount(ctx)
+	}
+	// The mutation already happened; failing to persist the entry must
+	// not turn the call into an error.
+	_ = s.journal.record(m)
+}
+
+// journaled returns a handler that records each call of h in the
+// journal. It wraps h inside the tool timeout, so the entry has what h
+// returned even when the client got a timeout error instead.
+func journaled[In, Out any](s *Server, tool string, h mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		res, out, err := h(ctx, req, input)
+		s.journalCall(ctx, tool, req, res, err)
+		return res, out, err
+	}
+}
+
+// MutationsToolName is the name of the tool registered by
+// RegisterMutationsTool.
+const MutationsToolName = "list_recent_mutations"
+
+// listMutationsInput is the input for the list_recent_mutations tool.
+type listMutationsInput struct {
+	Limit int    `json:"limit,omitempty" jsonschema:"Maximum number of entries to return, newest first (default 50)"`
+	Tool  string `json:"tool,omitempty" jsonschema:"Only show calls of this tool (e.g. create_event)"`
+}
+
+// RegisterMutationsTool registers the list_recent_mutations tool, which
+// lists the journal of changes made through this server. Like
+// list_accounts it is shared across all servers.
+func RegisterMutationsTool(s *Server) {
+	AddTool(s, &mcp.Tool{
+		Name:        MutationsToolName,
+		Description: "List the changes made through this server: each call of a tool that modifies data (sending, creating, updating, deleting, ...), newest first, with the account and the IDs from its result (Message ID, Event ID, File ID, ...). Failed calls are listed with their error, since they may have made some of their changes; dry runs are not listed. Use it to review or undo what was done in a session. The journal is kept in memory, and in the audit log when the server runs with --audit-log, in which case it also covers earlier sessions.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input listMutationsInput) (*mcp.CallToolResult, any, error) {
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 50
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: formatMutations(s.journal.recent(limit, input.Tool))},
+			},
+		}, nil, nil
+	})
+}
+
+// formatMutations formats journal entries for list_recent_mutations.
+func formatMutations(entries []Mutation) string {
+	if len(entries) == 0 {
+		return "No changes recorded."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d recent changes (newest first):\n", len(entries))
+	for _, m := range entries {
+		fmt.Fprintf(&sb, "\n%s  %s", m.Time.Format(time.RFC3339), m.Tool)
+		if m.Account != "" {
+			fmt.Fprintf(&sb, " (account %s)", m.Account)
+		}
+		sb.WriteString("\n")
+		if m.Summary != "" {
+			fmt.Fprintf(&sb, "  %s\n", m.Summary)
+		}
+		if m.Error != "" {
+			fmt.Fprintf(&sb, "  Failed: %s\n", m.Error)
+		}
+		for _, id := range m.IDs {
+			fmt.Fprintf(&sb, "  %s: %s\n", id.Kind, id.ID)
+		}
+	}
+	return sb.String()
+}
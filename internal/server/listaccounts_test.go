@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/calendar"
+	"github.com/thegrumpylion/google-mcp/internal/drive"
+	"github.com/thegrumpylion/google-mcp/internal/gmail"
+	"github.com/thegrumpylion/google-mcp/internal/server"
+)
+
+// TestListAccountsShared checks that every service server exposes the one
+// shared list_accounts tool rather than a definition of its own.
+func TestListAccountsShared(t *testing.T) {
+	dir := t.TempDir()
+	creds := `{"installed":{"client_id":"x","client_secret":"y","auth_uri":"https://a","token_uri":"https://t","redirect_uris":["http://localhost"]}}`
+	if err := os.WriteFile(filepath.Join(dir, "credentials.json"), []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := auth.NewManager(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref := server.NewServer(&mcp.Implementation{Name: "ref", Version: "test"}, nil)
+	server.RegisterAccountsListTool(ref, mgr)
+	want, err := json.Marshal(listTools(t, ref)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := map[string]func(*server.Server, *auth.Manager){
+		"gmail":    func(s *server.Server, m *auth.Manager) { gmail.RegisterTools(s, m) },
+		"drive":    drive.RegisterTools,
+		"calendar": calendar.RegisterTools,
+	}
+	for name, register := range services {
+		t.Run(name, func(t *testing.T) {
+			srv := server.NewServer(&mcp.Implementation{Name: name, Version: "test"}, nil)
+			register(srv, mgr)
+			var found []*mcp.Tool
+			for _, tool := range listTools(t, srv) {
+				if tool.Name == server.AccountsListToolName {
+					found = append(found, tool)
+				}
+			}
+			if len(found) != 1 {
+				t.Fatalf("got %d %s tools, want 1", len(found), server.AccountsListToolName)
+			}
+			got, err := json.Marshal(found[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("list_accounts differs from the shared definition:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func listTools(t *testing.T, s *server.Server) []*mcp.Tool {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	return res.Tools
+}
@@ -1,28 +1,60 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
 )
 
-// RegisterLocalFSTools registers the list_local_files and read_local_file
-// tools on the server. These are convenience tools that give the LLM
-// visibility into the allowed local directories. This is a no-op if
-// the server has no LocalFS configured.
+// RegisterLocalFSTools registers the list_local_files, read_local_file and
+// stat_local_file tools on the server. These are convenience tools that give the LLM
+// visibility into the allowed local directories. write_local_file is also
+// registered when at least one read-write directory is configured. This is
+// a no-op if the server has no LocalFS configured.
 func RegisterLocalFSTools(s *Server) {
 	if s.LocalFS() == nil {
 		return
 	}
 	registerListLocalFiles(s)
 	registerReadLocalFile(s)
+	registerStatLocalFile(s)
+	if s.LocalFS().Writable() {
+		registerWriteLocalFile(s)
+	}
+}
+
+// refreshLocalFSTools registers the exposed local file tools again, so
+// their descriptions list the current directories.
+func (s *Server) refreshLocalFSTools() {
+	for _, t := range []struct {
+		name     string
+		register func(*Server)
+	}{
+		{"list_local_files", registerListLocalFiles},
+		{"read_local_file", registerReadLocalFile},
+		{"stat_local_file", registerStatLocalFile},
+		{"write_local_file", registerWriteLocalFile},
+	} {
+		if s.toolExposed(t.name) {
+			t.register(s)
+		}
+	}
 }
 
 type listLocalFilesInput struct {
-	Path string `json:"path,omitempty" jsonschema:"Relative path within an allowed directory. Omit or use '.' to list the root of each allowed directory."`
+	Path      string `json:"path,omitempty" jsonschema:"Relative path within an allowed directory. Omit or use '.' to list the root of each allowed directory."`
+	Recursive bool   `json:"recursive,omitempty" jsonschema:"List files in all subdirectories too (default: false)"`
+	Glob      string `json:"glob,omitempty" jsonschema:"Only list files matching this pattern (e.g. '*.pdf' or 'invoices/2024-*.pdf'). Patterns without '/' match the file name, patterns with '/' match the relative path."`
 }
 
 func registerListLocalFiles(srv *Server) {
@@ -35,15 +67,19 @@ func registerListLocalFiles(srv *Server) {
 		if d.Mode == localfs.ModeReadWrite {
 			mode = "read-write"
 		}
-		fmt.Fprintf(&sb, "  - %s (%s)\n", d.Path, mode)
+		fmt.Fprintf(&sb, "  - %s (%s)\n", dirDescription(d), mode)
 	}
 	sb.WriteString("\nPaths are relative to an allowed directory. Omit path or use '.' to list root contents.")
+	sb.WriteString("\nSet recursive and/or glob to search for files: results show relative path, size and modification time, newest first.")
 
 	AddTool(srv, &mcp.Tool{
 		Name:        "list_local_files",
 		Description: sb.String(),
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input listLocalFilesInput) (*mcp.CallToolResult, any, error) {
 		lfs := srv.LocalFS()
@@ -51,6 +87,21 @@ func registerListLocalFiles(srv *Server) {
 			return nil, nil, fmt.Errorf("local file access is not enabled")
 		}
 
+		if input.Recursive || input.Glob != "" {
+			result, err := lfs.Walk(input.Path, localfs.WalkOptions{
+				Recursive: input.Recursive,
+				Glob:      input.Glob,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: formatWalkResult(srv.NewOutputBuilder(), result, input.Path)},
+				},
+			}, nil, nil
+		}
+
 		entries, dir, err := lfs.ListDir(input.Path)
 		if err != nil {
 			return nil, nil, err
@@ -85,8 +136,95 @@ func registerListLocalFiles(srv *Server) {
 	})
 }
 
+// formatWalkResult renders a Walk result with the newest files first.
+func formatWalkResult(out *OutputBuilder, result *localfs.WalkResult, path string) string {
+	displayPath := result.Dir
+	if path != "" && path != "." {
+		displayPath = result.Dir + "/" + path
+	}
+	fmt.Fprintf(out, "Directory: %s\n\n", displayPath)
+
+	files := result.Files
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].ModTime.After(files[j].ModTime)
+		}
+		return files[i].Name < files[j].Name
+	})
+
+	if len(files) == 0 {
+		out.WriteString("(no matching files)\n")
+	}
+	for _, f := range files {
+		if !out.AddItem(fmt.Sprintf("  %10d  %s  %s\n", f.Size, f.ModTime.Format(time.RFC3339), f.Name)) {
+			break
+		}
+	}
+	if result.Truncated {
+		fmt.Fprintf(out, "\n[search stopped after %d entries; narrow the path or glob]\n", localfs.DefaultMaxWalkEntries)
+	}
+	return out.String()
+}
+
+// maxLocalRead is the most read_local_file returns in one call, matching
+// read_file on the Drive server.
+const maxLocalRead = 512 * 1024
+
 type readLocalFileInput struct {
-	Path string `json:"path" jsonschema:"Relative path to a file within an allowed directory"`
+	Path   string `json:"path" jsonschema:"Relative path to a file within an allowed directory"`
+	Offset int64  `json:"offset,omitempty" jsonschema:"Byte offset to start reading at (default 0). Use the offset from a truncation notice to read the next chunk."`
+	Length int64  `json:"length,omitempty" jsonschema:"Maximum number of bytes to read (default and maximum 512 KB)"`
+}
+
+// localChunk is a byte range read from a local file.
+type localChunk struct {
+	Data   []byte
+	Dir    string // allowed directory the file was found in
+	Offset int64
+	Size   int64 // size of the whole file
+}
+
+// truncated reports whether the file continues past the chunk.
+func (c *localChunk) truncated() bool {
+	return c.Offset+int64(len(c.Data)) < c.Size
+}
+
+// readLocalChunk reads at most length bytes of path starting at offset,
+// without loading the rest of the file. A length of zero or more than
+// maxLocalRead reads maxLocalRead bytes.
+func readLocalChunk(lfs *localfs.FS, path string, offset, length int64) (*localChunk, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("length must not be negative")
+	}
+	if length == 0 || length > maxLocalRead {
+		length = maxLocalRead
+	}
+
+	f, dir, err := lfs.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q: %w", path, err)
+	}
+	if offset > size {
+		return nil, fmt.Errorf("offset %d is past the end of %q (%d bytes)", offset, path, size)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("cannot read %q: %w", path, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, length))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q: %w", path, err)
+	}
+	return &localChunk{Data: data, Dir: dir, Offset: offset, Size: size}, nil
 }
 
 func registerReadLocalFile(srv *Server) {
@@ -95,9 +233,12 @@ func registerReadLocalFile(srv *Server) {
 		Description: `Read a file from an allowed local directory.
 
 Returns text content for text files. Binary files are not supported — use save_to on download tools to save binary files to disk instead.
-Requires --allow-read-dir or --allow-write-dir. Content is truncated at 512 KB.`,
+Requires --allow-read-dir or --allow-write-dir. At most 512 KB is returned per call; use offset and length to read larger files in chunks, and stat_local_file to check a file's size first.`,
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
 		},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input readLocalFileInput) (*mcp.CallToolResult, any, error) {
 		lfs := srv.LocalFS()
@@ -109,35 +250,179 @@ Requires --allow-read-dir or --allow-write-dir. Content is truncated at 512 KB.`
 			return nil, nil, fmt.Errorf("path is required")
 		}
 
-		data, dir, err := lfs.ReadFile(input.Path)
+		chunk, err := readLocalChunk(lfs, input.Path, input.Offset, input.Length)
 		if err != nil {
 			return nil, nil, err
 		}
 
 		// Check if it looks like binary.
-		if !isLikelyText(data) {
+		if !isLikelyText(chunk.Data) {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
-					&mcp.TextContent{Text: fmt.Sprintf("Binary file (%d bytes) at %s/%s. Use save_to on download tools for binary files.", len(data), dir, input.Path)},
+					&mcp.TextContent{Text: fmt.Sprintf("Binary file (%d bytes) at %s/%s. Use save_to on download tools for binary files.", chunk.Size, chunk.Dir, input.Path)},
 				},
 			}, nil, nil
 		}
 
-		const maxSize = 512 * 1024 // 512 KB
-		truncated := false
-		if len(data) > maxSize {
-			data = data[:maxSize]
-			truncated = true
+		text := string(chunk.Data)
+		if chunk.truncated() {
+			end := chunk.Offset + int64(len(chunk.Data))
+			text += fmt.Sprintf("\n\n--- truncated: showed bytes %d-%d of %d; use offset=%d to continue ---", chunk.Offset, end, chunk.Size, end)
 		}
 
-		text := string(data)
-		if truncated {
-			text += "\n\n--- truncated at 512 KB ---"
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil, nil
+	})
+}
+
+type statLocalFileInput struct {
+	Path string `json:"path" jsonschema:"Relative path to a file or directory within an allowed directory"`
+}
+
+func registerStatLocalFile(srv *Server) {
+	AddTool(srv, &mcp.Tool{
+		Name:        "stat_local_file",
+		Description: "Show a local file's size, modification time and permissions, and which allowed directory it was found in, without reading it. Use this before read_local_file to check whether a file is too large to read in one call.",
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    true,
+			DestructiveHint: BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input statLocalFileInput) (*mcp.CallToolResult, any, error) {
+		lfs := srv.LocalFS()
+		if lfs == nil {
+			return nil, nil, fmt.Errorf("local file access is not enabled")
+		}
+
+		if input.Path == "" {
+			return nil, nil, fmt.Errorf("path is required")
+		}
+
+		info, dir, err := lfs.Stat(input.Path)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
-				&mcp.TextContent{Text: text},
+				&mcp.TextContent{Text: formatLocalStat(info, dir, dirMode(lfs, dir), input.Path)},
+			},
+		}, nil, nil
+	})
+}
+
+// dirDescription returns how tool descriptions list an allowed directory:
+// its path, preceded by its label if it has one, as results show the label.
+func dirDescription(d localfs.DirInfo) string {
+	if d.Label != "" {
+		return d.Label + " = " + d.Path
+	}
+	return d.Path
+}
+
+// dirMode returns the access mode of the allowed directory dir, as named
+// in results.
+func dirMode(lfs *localfs.FS, dir string) string {
+	for _, d := range lfs.Dirs() {
+		if d.Name() == dir && d.Mode == localfs.ModeReadWrite {
+			return "read-write"
+		}
+	}
+	return "read-only"
+}
+
+// formatLocalStat formats the stat_local_file result.
+func formatLocalStat(info os.FileInfo, dir, mode, path string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Path: %s/%s\n", dir, path)
+	if info.IsDir() {
+		sb.WriteString("Type: directory\n")
+	} else {
+		sb.WriteString("Type: file\n")
+		fmt.Fprintf(&sb, "Size: %d bytes\n", info.Size())
+	}
+	fmt.Fprintf(&sb, "Modified: %s\n", info.ModTime().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Mode: %s\n", info.Mode())
+	fmt.Fprintf(&sb, "Allowed directory: %s (%s)\n", dir, mode)
+	if !info.IsDir() && info.Size() > maxLocalRead {
+		sb.WriteString("\nLarger than the 512 KB read_local_file limit: read it in chunks with offset and length.\n")
+	}
+	return sb.String()
+}
+
+type writeLocalFileInput struct {
+	Path      string `json:"path" jsonschema:"Relative path of the file to write within an allowed read-write directory"`
+	Content   string `json:"content" jsonschema:"File content as text, or base64-encoded binary data when base64 is true"`
+	Base64    bool   `json:"base64,omitempty" jsonschema:"Set to true if content is base64-encoded binary data"`
+	Overwrite bool   `json:"overwrite,omitempty" jsonschema:"Replace the file if it already exists (default: false, writing to an existing file is an error)"`
+}
+
+func registerWriteLocalFile(srv *Server) {
+	var sb strings.Builder
+	sb.WriteString("Write a file to an allowed read-write local directory.\n\n")
+	sb.WriteString("Use this to save generated content (reports, notes, exports) to disk. Existing files are only replaced when overwrite is set. Parent directories must already exist.\n\n")
+	sb.WriteString("Allowed write directories:\n")
+	for _, d := range srv.LocalFS().Dirs() {
+		if d.Mode == localfs.ModeReadWrite {
+			fmt.Fprintf(&sb, "  - %s\n", dirDescription(d))
+		}
+	}
+
+	AddTool(srv, &mcp.Tool{
+		Name:        "write_local_file",
+		Description: sb.String(),
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:    false,
+			DestructiveHint: BoolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
+		},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input writeLocalFileInput) (*mcp.CallToolResult, any, error) {
+		lfs := srv.LocalFS()
+		if lfs == nil {
+			return nil, nil, fmt.Errorf("local file access is not enabled")
+		}
+
+		if input.Path == "" {
+			return nil, nil, fmt.Errorf("path is required")
+		}
+
+		var r io.Reader = strings.NewReader(input.Content)
+		if input.Base64 {
+			data, err := base64.StdEncoding.DecodeString(input.Content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decoding base64 content: %w", err)
+			}
+			r = bytes.NewReader(data)
+		}
+
+		create := lfs.CreateNewFile
+		if input.Overwrite {
+			create = lfs.CreateFile
+		}
+		f, dir, err := create(input.Path)
+		if errors.Is(err, os.ErrExist) {
+			return nil, nil, fmt.Errorf("file %q already exists (set overwrite to replace it)", input.Path)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		n, err := io.Copy(f, r)
+		if err == nil {
+			err = f.Commit()
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("writing %s/%s: %w", dir, input.Path, err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("File written.\n\nPath: %s/%s\nDirectory: %s\nSize: %d bytes", dir, input.Path, dir, n)},
 			},
 		}, nil, nil
 	})
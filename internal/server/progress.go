@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ProgressInterval is how many items long-running tools process between
+// progress notifications.
+const ProgressInterval = 25
+
+// Progress reports progress of the tool call in req to the client, if the
+// client asked for progress by sending a progress token. current should
+// grow with every call; total is the expected final value, or 0 if unknown.
+// Notifications are best effort: it does nothing without a token or a
+// session, or once ctx is done, and send errors are ignored.
+func Progress(ctx context.Context, req *mcp.CallToolRequest, current, total int, msg string) {
+	if req == nil || req.Session == nil || req.Params == nil || ctx.Err() != nil {
+		return
+	}
+	token := req.Params.GetProgressToken()
+	if token == nil {
+		return
+	}
+	_ = req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: token,
+		Progress:      float64(current),
+		Total:         float64(max(total, 0)),
+		Message:       msg,
+	})
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PromptTemplate is a prompt rendered from a text/template. The template is
+// executed with the prompt arguments as a map[string]string, so arguments
+// are referenced as {{.name}}, and with a "tool" function reporting whether
+// a tool is exposed, so that a prompt can adjust to a filtered server:
+//
+//	{{if tool "modify_messages"}}Archive them.{{else}}List them.{{end}}
+type PromptTemplate struct {
+	Name        string
+	Title       string
+	Description string
+	Arguments   []*mcp.PromptArgument
+	// Defaults are the values of optional arguments that aren't given.
+	Defaults map[string]string
+	// Tools are the tools the prompt can't do without. It is hidden once
+	// ApplyFilter removes any of them.
+	Tools []string
+	// Template is the text of the prompt's user message.
+	Template string
+}
+
+// PromptInfo describes a registered prompt for filtering purposes.
+type PromptInfo struct {
+	Name  string
+	Tools []string
+}
+
+// AddPrompt registers a prompt on the server and records the tools it
+// needs, as AddTool records tool metadata. It panics if the template
+// doesn't parse, as mcp.AddTool does for invalid schemas.
+func AddPrompt(s *Server, p PromptTemplate) {
+	tmpl, err := template.New(p.Name).
+		Funcs(template.FuncMap{"tool": s.toolExposed}).
+		Option("missingkey=zero").
+		Parse(p.Template)
+	if err != nil {
+		panic(fmt.Sprintf("parsing prompt %s: %v", p.Name, err))
+	}
+	s.prompts = append(s.prompts, PromptInfo{Name: p.Name, Tools: p.Tools})
+	s.Server.AddPrompt(&mcp.Prompt{
+		Name:        p.Name,
+		Title:       p.Title,
+		Description: p.Description,
+		Arguments:   p.Arguments,
+	}, func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		text, err := renderPrompt(tmpl, p, req.Params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.GetPromptResult{
+			Description: p.Description,
+			Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: text}},
+			},
+		}, nil
+	})
+}
+
+// Prompts returns the metadata for all registered prompts, including those
+// hidden by ApplyFilter.
+func (s *Server) Prompts() []PromptInfo {
+	return s.prompts
+}
+
+// renderPrompt executes tmpl with args, after checking that the required
+// arguments of p are set and filling in its defaults.
+func renderPrompt(tmpl *template.Template, p PromptTemplate, args map[string]string) (string, error) {
+	values := make(map[string]string, len(p.Arguments))
+	for _, arg := range p.Arguments {
+		v := strings.TrimSpace(args[arg.Name])
+		if v == "" {
+			v = p.Defaults[arg.Name]
+		}
+		if v == "" && arg.Required {
+			return "", fmt.Errorf("argument %q is required", arg.Name)
+		}
+		values[arg.Name] = v
+	}
+	for name := range args {
+		if !slices.ContainsFunc(p.Arguments, func(a *mcp.PromptArgument) bool { return a.Name == name }) {
+			return "", fmt.Errorf("unknown argument %q", name)
+		}
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, values); err != nil {
+		return "", fmt.Errorf("rendering prompt %s: %w", p.Name, err)
+	}
+	return sb.String(), nil
+}
+
+// toolExposed reports whether the tool is registered and not removed by
+// ApplyFilter.
+func (s *Server) toolExposed(name string) bool {
+	return !s.hidden[name] && slices.ContainsFunc(s.tools, func(t ToolInfo) bool { return t.Name == name })
+}
+
+// hideTools removes tools from the server and remembers them as hidden.
+func (s *Server) hideTools(names ...string) {
+	if s.hidden == nil {
+		s.hidden = make(map[string]bool)
+	}
+	for _, name := range names {
+		s.hidden[name] = true
+	}
+	s.RemoveTools(names...)
+}
+
+// filterPrompts removes the prompts that need a hidden tool.
+func (s *Server) filterPrompts() {
+	var remove []string
+	for _, p := range s.prompts {
+		if !slices.ContainsFunc(p.Tools, func(name string) bool { return !s.toolExposed(name) }) {
+			continue
+		}
+		remove = append(remove, p.Name)
+	}
+	if len(remove) > 0 {
+		s.RemovePrompts(remove...)
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+	"github.com/thegrumpylion/google-mcp/internal/localfs"
+)
+
+// Resource URIs served by every server.
+const (
+	AccountsResourceURI  = "google-mcp://accounts"
+	LocalDirsResourceURI = "google-mcp://local-dirs"
+)
+
+// ResourceInfo describes a registered resource.
+type ResourceInfo struct {
+	URI  string
+	Name string
+}
+
+// AddResource registers a resource on the server and records its metadata,
+// as AddTool does for tools. Registering a URI again replaces the resource.
+func AddResource(s *Server, r *mcp.Resource, h mcp.ResourceHandler) {
+	info := ResourceInfo{URI: r.URI, Name: r.Name}
+	replaced := false
+	for i, existing := range s.resources {
+		if existing.URI == r.URI {
+			s.resources[i] = info
+			replaced = true
+		}
+	}
+	if !replaced {
+		s.resources = append(s.resources, info)
+	}
+	s.Server.AddResource(r, h)
+}
+
+// Resources returns the metadata for all registered resources.
+func (s *Server) Resources() []ResourceInfo {
+	return s.resources
+}
+
+// removeResource unregisters the resource with uri, if any.
+func (s *Server) removeResource(uri string) {
+	for i, existing := range s.resources {
+		if existing.URI == uri {
+			s.resources = append(s.resources[:i], s.resources[i+1:]...)
+			s.Server.RemoveResources(uri)
+			return
+		}
+	}
+}
+
+// jsonResource returns the contents of a JSON resource read by req.
+func jsonResource(req *mcp.ReadResourceRequest, v any) (*mcp.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", req.Params.URI, err)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{URI: req.Params.URI, MIMEType: "application/json", Text: string(data)},
+		},
+	}, nil
+}
+
+// accountResource is an account in the google-mcp://accounts resource.
+// Tokens are never included.
+type accountResource struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email,omitempty"`
+	Default     bool      `json:"default,omitempty"`
+	Scopes      []string  `json:"scopes"`
+	TokenExpiry time.Time `json:"token_expiry,omitzero"`
+	LastRefresh time.Time `json:"last_refresh,omitzero"`
+}
+
+// accountResources converts account statuses for the accounts resource.
+func accountResources(statuses []auth.AccountStatus) []accountResource {
+	out := make([]accountResource, 0, len(statuses))
+	for _, st := range statuses {
+		scopes := st.Scopes
+		if scopes == nil {
+			scopes = []string{}
+		}
+		out = append(out, accountResource{
+			Name:        st.Name,
+			Email:       st.Email,
+			Default:     st.Default,
+			Scopes:      scopes,
+			TokenExpiry: st.Expiry,
+			LastRefresh: st.LastRefresh,
+		})
+	}
+	return out
+}
+
+// RegisterAccountsResource registers the google-mcp://accounts resource,
+// the configured accounts with their emails and granted scopes as JSON. It
+// gives clients the account names without a list_accounts call. Like
+// list_accounts it is shared across all servers.
+func RegisterAccountsResource(s *Server, mgr *auth.Manager) {
+	AddResource(s, &mcp.Resource{
+		URI:         AccountsResourceURI,
+		Name:        "accounts",
+		Title:       "Configured Google accounts",
+		Description: "The configured Google accounts as JSON: name, email, whether it is the default account, granted scopes, token expiry and last refresh time. Use the names as the account argument of tools. Reading it makes no network calls; call list_accounts with check=true to verify the tokens.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return jsonResource(req, accountResources(mgr.AccountStatuses()))
+	})
+}
+
+// localDirResource is a directory in the google-mcp://local-dirs resource.
+type localDirResource struct {
+	Path  string `json:"path"`
+	Label string `json:"label,omitempty"`
+	Mode  string `json:"mode"` // "read-only" or "read-write"
+}
+
+// localDirResources lists the directories of fs for the local-dirs
+// resource.
+func localDirResources(fs *localfs.FS) []localDirResource {
+	dirs := fs.Dirs()
+	out := make([]localDirResource, 0, len(dirs))
+	for _, d := range dirs {
+		out = append(out, localDirResource{Path: d.Path, Label: d.Label, Mode: dirModeName(d.Mode)})
+	}
+	return out
+}
+
+// dirModeName returns the name local directory modes are shown with.
+func dirModeName(m localfs.Mode) string {
+	if m == localfs.ModeReadWrite {
+		return "read-write"
+	}
+	return "read-only"
+}
+
+// registerLocalDirsResource registers, replaces or, for a nil fs, removes
+// the google-mcp://local-dirs resource. It is called by SetLocalFS.
+func (s *Server) registerLocalDirsResource(fs *localfs.FS) {
+	if fs == nil {
+		s.removeResource(LocalDirsResourceURI)
+		return
+	}
+	AddResource(s, &mcp.Resource{
+		URI:         LocalDirsResourceURI,
+		Name:        "local-dirs",
+		Title:       "Allowed local directories",
+		Description: "The local directories this server may access as JSON, each with its label, if any, and its mode: read-only directories can be used for attachments and uploads, read-write ones also for save_to paths. Results name labeled directories by their label.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return jsonResource(req, localDirResources(fs))
+	})
+	// Clients that subscribed to the previous directories learn about the
+	// change. There are no subscribers before the server is connected.
+	_ = s.ResourceUpdated(context.Background(), &mcp.ResourceUpdatedNotificationParams{URI: LocalDirsResourceURI})
+}
@@ -5,8 +5,11 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/thegrumpylion/google-mcp/internal/auth"
@@ -21,6 +24,27 @@ func BoolPtr(v bool) *bool { return &v }
 type ToolInfo struct {
 	Name     string
 	ReadOnly bool
+	// LocalWriteParams lists input parameters that write to local disk.
+	// See LocalWriteParams.
+	LocalWriteParams []string
+	// Timeout is the tool's own time limit. See Timeout.
+	Timeout time.Duration
+	// localDirs is set when the description lists the local directories.
+	// See ReadDirsDescription.
+	localDirs bool
+}
+
+// ToolOption configures the metadata recorded for a tool by AddTool.
+type ToolOption func(*ToolInfo)
+
+// LocalWriteParams marks input parameters (such as save_to) through which an
+// otherwise read-only tool writes to local disk. When the server is filtered
+// to read-only tools, calls that set any of these parameters are rejected
+// before the handler runs.
+func LocalWriteParams(names ...string) ToolOption {
+	return func(t *ToolInfo) {
+		t.LocalWriteParams = append(t.LocalWriteParams, names...)
+	}
 }
 
 // Server wraps an mcp.Server to capture tool metadata at registration time.
@@ -29,19 +53,96 @@ type ToolInfo struct {
 // remove tools that don't match the desired filter.
 type Server struct {
 	*mcp.Server
-	tools   []ToolInfo
-	localFS *localfs.FS
+	tools     []ToolInfo
+	resources []ResourceInfo
+	prompts   []PromptInfo
+	localFS   *localfs.FS
+
+	// maxBlockSize limits the size of TextContent blocks in tool results.
+	// Zero disables splitting. See SetMaxBlockSize.
+	maxBlockSize int
+
+	// maxOutputBytes is the output budget for list tools. Zero disables
+	// it. See SetMaxOutputBytes.
+	maxOutputBytes int
+
+	// hidden holds the tools removed by ApplyFilter.
+	hidden map[string]bool
+
+	// readOnly is set by ApplyFilter in read-only mode; it makes tools
+	// reject their local write parameters.
+	readOnly bool
+
+	// toolTimeout limits the duration of a tool call. Zero disables it.
+	// See SetToolTimeout.
+	toolTimeout time.Duration
+
+	// timeoutGrace is how long a timed out handler gets to return its
+	// partial result.
+	timeoutGrace time.Duration
+
+	// journal records the successful calls of tools that aren't
+	// read-only. See SetAuditLog and RegisterMutationsTool.
+	journal *journal
+
+	// crossAccountHints is set by SetCrossAccountHints.
+	crossAccountHints bool
+
+	// defaultAccount is the session default account for clients that
+	// don't name one. See SetDefaultAccount.
+	defaultAccount string
+
+	// accountNames are listed in the descriptions of account parameters.
+	// See SetAccountNames.
+	accountNames []string
+
+	// registrations registers each tool again with the current account
+	// names and directories. See SetAccountNames and ReloadLocalFS.
+	registrations map[string]func()
 }
 
 // NewServer creates a new Server wrapper around an mcp.Server.
 func NewServer(impl *mcp.Implementation, opts *mcp.ServerOptions) *Server {
-	return &Server{Server: mcp.NewServer(impl, opts)}
+	return &Server{
+		Server:         mcp.NewServer(impl, opts),
+		maxOutputBytes: DefaultMaxOutputBytes,
+		toolTimeout:    DefaultToolTimeout,
+		timeoutGrace:   timeoutGrace,
+		journal:        newJournal(DefaultJournalSize),
+	}
 }
 
 // SetLocalFS sets the local filesystem access for the server.
 // Tools can use LocalFS() to read/write local files within allowed directories.
+// The allowed directories are also served as the google-mcp://local-dirs
+// resource.
 func (s *Server) SetLocalFS(fs *localfs.FS) {
 	s.localFS = fs
+	s.registerLocalDirsResource(fs)
+}
+
+// ReloadLocalFS replaces the allowed directories of the local filesystem
+// set with SetLocalFS, for example after the user added a directory. The
+// descriptions of the local file tools and of the tools that list the
+// directories (see ReadDirsDescription) are rebuilt, and subscribers of the
+// google-mcp://local-dirs resource are notified. Tools are not added or
+// removed: a server started without write directories still has no
+// write_local_file tool.
+func (s *Server) ReloadLocalFS(dirs []localfs.Dir) error {
+	if s.localFS == nil {
+		return fmt.Errorf("local file access was not enabled at startup")
+	}
+	if err := s.localFS.Reload(dirs); err != nil {
+		return err
+	}
+	s.refreshLocalFSTools()
+	for _, t := range s.tools {
+		if register := s.registrations[t.Name]; register != nil && t.localDirs && s.toolExposed(t.Name) {
+			register()
+		}
+	}
+	_ = s.ResourceUpdated(context.Background(), &mcp.ResourceUpdatedNotificationParams{URI: LocalDirsResourceURI})
+	return nil
 }
 
 // LocalFS returns the local filesystem access, or nil if not configured.
@@ -57,26 +158,114 @@ func (s *Server) Tools() []ToolInfo {
 // AddTool registers a typed tool on the server and records its metadata.
 // This is a free generic function because Go does not allow generic methods
 // on types — the same pattern the MCP SDK uses for mcp.AddTool.
-func AddTool[In, Out any](s *Server, t *mcp.Tool, h mcp.ToolHandlerFor[In, Out]) {
-	s.tools = append(s.tools, ToolInfo{
-		Name:     t.Name,
-		ReadOnly: t.Annotations != nil && t.Annotations.ReadOnlyHint,
-	})
-	mcp.AddTool(s.Server, t, h)
+//
+// The handler is wrapped so that oversized results are shaped according to
+// the server's block size limit (see SetMaxBlockSize), so that local write
+// parameters are refused in read-only mode (see LocalWriteParams), and so
+// that calls are bounded by the tool timeout (see SetToolTimeout and
+// Timeout). Successful calls of tools that aren't read-only are recorded in
+// the mutation journal (see RegisterMutationsTool). The descriptions of account parameters list
+// the configured accounts (see SetAccountNames), and the directories from
+// ReadDirsDescription and WriteDirsDescription are filled in. Registering
+// a name again replaces the tool; the metadata recorded the first time is
+// kept.
+func AddTool[In, Out any](s *Server, t *mcp.Tool, h mcp.ToolHandlerFor[In, Out], opts ...ToolOption) {
+	info := ToolInfo{
+		Name:      t.Name,
+		ReadOnly:  t.Annotations != nil && t.Annotations.ReadOnlyHint,
+		localDirs: strings.Contains(t.Description, readDirsPlaceholder) || strings.Contains(t.Description, writeDirsPlaceholder),
+	}
+	for _, opt := range opts {
+		opt(&info)
+	}
+	if !slices.ContainsFunc(s.tools, func(t ToolInfo) bool { return t.Name == info.Name }) {
+		s.tools = append(s.tools, info)
+	}
+	handler := wrapHandler(s, info, h)
+	register := func() {
+		mcp.AddTool(s.Server, withAccountNames[In](s.withLocalDirs(t), s.accountNames), handler)
+	}
+	if s.registrations == nil {
+		s.registrations = make(map[string]func())
+	}
+	s.registrations[t.Name] = register
+	register()
+}
+
+// setParam returns the first of params that is set to a non-empty value in
+// the request arguments, or "" if none is.
+func setParam(req *mcp.CallToolRequest, params []string) string {
+	if len(params) == 0 || req == nil || req.Params == nil || len(req.Params.Arguments) == 0 {
+		return ""
+	}
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+		return ""
+	}
+	for _, name := range params {
+		switch strings.TrimSpace(string(args[name])) {
+		case "", "null", `""`, "false", "0", "[]", "{}":
+		default:
+			return name
+		}
+	}
+	return ""
 }
 
+// Placeholders that ReadDirsDescription and WriteDirsDescription put in
+// tool descriptions. AddTool replaces them with the directories each time
+// it registers the tool, so that ReloadLocalFS can update them.
+const (
+	readDirsPlaceholder  = "\x00read-dirs\x00"
+	writeDirsPlaceholder = "\x00write-dirs\x00"
+)
+
 // WriteDirsDescription returns a description snippet listing the configured
-// write-enabled directories, suitable for appending to a tool description.
-// Returns an empty string if no local filesystem is configured or there are
-// no write directories.
+// write-enabled directories, suitable for appending to a tool description
+// registered with AddTool. The snippet is empty if no local filesystem is
+// configured or there are no write directories, and follows ReloadLocalFS.
 func (s *Server) WriteDirsDescription() string {
+	if s.localFS == nil {
+		return ""
+	}
+	return writeDirsPlaceholder
+}
+
+// ReadDirsDescription returns a description snippet listing all configured
+// directories (both read-only and read-write), suitable for appending to a
+// tool description registered with AddTool. The snippet is empty if no
+// local filesystem is configured, and follows ReloadLocalFS.
+func (s *Server) ReadDirsDescription() string {
+	if s.localFS == nil {
+		return ""
+	}
+	return readDirsPlaceholder
+}
+
+// withLocalDirs returns t with the placeholders of ReadDirsDescription and
+// WriteDirsDescription in its description replaced by the current
+// directories. t is returned as is when it has none.
+func (s *Server) withLocalDirs(t *mcp.Tool) *mcp.Tool {
+	if !strings.Contains(t.Description, readDirsPlaceholder) && !strings.Contains(t.Description, writeDirsPlaceholder) {
+		return t
+	}
+	tt := *t
+	tt.Description = strings.NewReplacer(
+		readDirsPlaceholder, s.readDirsDescription(),
+		writeDirsPlaceholder, s.writeDirsDescription(),
+	).Replace(t.Description)
+	return &tt
+}
+
+// writeDirsDescription lists the write directories for WriteDirsDescription.
+func (s *Server) writeDirsDescription() string {
 	if s.localFS == nil {
 		return ""
 	}
 	var sb strings.Builder
 	for _, d := range s.localFS.Dirs() {
 		if d.Mode == localfs.ModeReadWrite {
-			fmt.Fprintf(&sb, "  - %s\n", d.Path)
+			fmt.Fprintf(&sb, "  - %s\n", dirDescription(d))
 		}
 	}
 	if sb.Len() == 0 {
@@ -85,10 +274,8 @@ func (s *Server) WriteDirsDescription() string {
 	return "\n\nAllowed write directories (for save_to paths):\n" + sb.String()
 }
 
-// ReadDirsDescription returns a description snippet listing all configured
-// directories (both read-only and read-write), suitable for appending to a
-// tool description. Returns an empty string if no local filesystem is configured.
-func (s *Server) ReadDirsDescription() string {
+// readDirsDescription lists the directories for ReadDirsDescription.
+func (s *Server) readDirsDescription() string {
 	if s.localFS == nil {
 		return ""
 	}
@@ -99,11 +286,7 @@ func (s *Server) ReadDirsDescription() string {
 	var sb strings.Builder
 	sb.WriteString("\n\nAllowed local directories (for local file paths):\n")
 	for _, d := range dirs {
-		mode := "read-only"
-		if d.Mode == localfs.ModeReadWrite {
-			mode = "read-write"
-		}
-		fmt.Fprintf(&sb, "  - %s (%s)\n", d.Path, mode)
+		fmt.Fprintf(&sb, "  - %s (%s)\n", dirDescription(d), dirModeName(d.Mode))
 	}
 	return sb.String()
 }
@@ -113,18 +296,23 @@ type ToolFilter struct {
 	// ReadOnly limits the server to read-only tools.
 	ReadOnly bool
 	// Enable is a whitelist of tool names to expose. Mutually exclusive with Disable.
+	// list_accounts is exposed even when not listed.
 	Enable []string
 	// Disable is a blacklist of tool names to hide. Mutually exclusive with Enable.
 	Disable []string
 }
 
-// ApplyFilter removes tools from the server based on the filter configuration.
+// ApplyFilter removes tools from the server based on the filter configuration,
+// along with the prompts that need a removed tool (see PromptTemplate.Tools).
+// In read-only mode the remaining tools also refuse their local write
+// parameters (see LocalWriteParams).
 // Returns an error if the filter is invalid (e.g. enable and disable both set,
 // or referencing unknown tool names).
 func (s *Server) ApplyFilter(filter ToolFilter) error {
 	if len(filter.Enable) > 0 && len(filter.Disable) > 0 {
 		return fmt.Errorf("--enable and --disable are mutually exclusive")
 	}
+	s.readOnly = filter.ReadOnly
 
 	// Build the base set: all tools or read-only only.
 	baseSet := make(map[string]bool, len(s.tools))
@@ -149,7 +337,7 @@ func (s *Server) ApplyFilter(filter ToolFilter) error {
 			}
 		}
 		if len(remove) > 0 {
-			s.RemoveTools(remove...)
+			s.hideTools(remove...)
 		}
 	}
 
@@ -163,7 +351,9 @@ func (s *Server) ApplyFilter(filter ToolFilter) error {
 				return fmt.Errorf("unknown tool %q", name)
 			}
 		}
-		enabled := make(map[string]bool, len(filter.Enable))
+		// list_accounts is how clients discover account names, which
+		// every other tool takes, so an allowlist keeps it implicitly.
+		enabled := map[string]bool{AccountsListToolName: true}
 		for _, name := range filter.Enable {
 			enabled[name] = true
 		}
@@ -174,7 +364,7 @@ func (s *Server) ApplyFilter(filter ToolFilter) error {
 			}
 		}
 		if len(remove) > 0 {
-			s.RemoveTools(remove...)
+			s.hideTools(remove...)
 		}
 	}
 
@@ -188,24 +378,42 @@ func (s *Server) ApplyFilter(filter ToolFilter) error {
 				return fmt.Errorf("unknown tool %q", name)
 			}
 		}
-		s.RemoveTools(filter.Disable...)
+		s.hideTools(filter.Disable...)
 	}
 
+	s.filterPrompts()
 	return nil
 }
 
+// accountsListInput is the input for the list_accounts tool.
+type accountsListInput struct {
+	Check bool `json:"check,omitempty" jsonschema:"Validate each account's token with Google (costs a network round trip per account)"`
+}
+
+// AccountsListToolName is the name of the tool registered by
+// RegisterAccountsListTool.
+const AccountsListToolName = "list_accounts"
+
 // RegisterAccountsListTool registers the list_accounts tool on the given server.
 // This tool is shared across all servers (Gmail, Drive, Calendar).
 func RegisterAccountsListTool(s *Server, mgr *auth.Manager) {
 	AddTool(s, &mcp.Tool{
-		Name:        "list_accounts",
-		Description: "List all configured Google accounts. Use this to discover available account names.",
+		Name:        AccountsListToolName,
+		Description: "List all configured Google accounts with granted scopes, token expiry, and last refresh time. Use this to discover available account names. Set check=true to verify each token is still valid.",
 		Annotations: &mcp.ToolAnnotations{
-			ReadOnlyHint: true,
+			ReadOnlyHint:    true,
+			DestructiveHint: BoolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   BoolPtr(false),
 		},
-	}, func(ctx context.Context, req *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
-		accounts := mgr.ListAccounts()
-		if len(accounts) == 0 {
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input accountsListInput) (*mcp.CallToolResult, any, error) {
+		var statuses []auth.AccountStatus
+		if input.Check {
+			statuses = mgr.CheckAccountStatuses(ctx)
+		} else {
+			statuses = mgr.AccountStatuses()
+		}
+		if len(statuses) == 0 {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					&mcp.TextContent{Text: "No accounts configured. Run 'google-mcp auth add <name>' to add one."},
@@ -213,13 +421,9 @@ func RegisterAccountsListTool(s *Server, mgr *auth.Manager) {
 			}, nil, nil
 		}
 		var sb strings.Builder
-		sb.WriteString("Configured accounts:\n")
-		for name, email := range accounts {
-			if email != "" {
-				fmt.Fprintf(&sb, "  - %s (%s)\n", name, email)
-			} else {
-				fmt.Fprintf(&sb, "  - %s\n", name)
-			}
+		auth.WriteAccountStatus(&sb, statuses, time.Now())
+		if account := auth.SessionAccount(ctx); account != "" {
+			fmt.Fprintf(&sb, "\nThis session's default account is %s; tool calls without an account use it.\n", account)
 		}
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
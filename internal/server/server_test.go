@@ -2,14 +2,25 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
 	"github.com/thegrumpylion/google-mcp/internal/localfs"
+	"google.golang.org/api/googleapi"
 )
 
 // dummyHandler is a no-op tool handler for testing.
@@ -209,6 +220,69 @@ func TestApplyFilter_ReadOnlyEnableMutation_Error(t *testing.T) {
 	}
 }
 
+type saveToInput struct {
+	ID     string `json:"id"`
+	SaveTo string `json:"save_to,omitempty"`
+}
+
+// newSaveToTestServer creates a Server with a read-only tool that can write
+// to local disk through save_to.
+func newSaveToTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer(&mcp.Implementation{Name: "save-to-test", Version: "test"}, nil)
+	AddTool(s, &mcp.Tool{
+		Name:        "read_thing",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(_ context.Context, _ *mcp.CallToolRequest, input saveToInput) (*mcp.CallToolResult, any, error) {
+		text := "returned " + input.ID
+		if input.SaveTo != "" {
+			text = "saved " + input.ID + " to " + input.SaveTo
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, nil, nil
+	}, LocalWriteParams("save_to"))
+	return s
+}
+
+func TestLocalWriteParams_Metadata(t *testing.T) {
+	tools := newSaveToTestServer(t).Tools()
+	if len(tools) != 1 || len(tools[0].LocalWriteParams) != 1 || tools[0].LocalWriteParams[0] != "save_to" {
+		t.Errorf("tools = %+v, want read_thing with LocalWriteParams [save_to]", tools)
+	}
+}
+
+func TestLocalWriteParams_ReadOnlyRejectsSaveTo(t *testing.T) {
+	s := newSaveToTestServer(t)
+	if err := s.ApplyFilter(ToolFilter{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	res := callToolResult(t, s, "read_thing", map[string]any{"id": "1", "save_to": "out.bin"})
+	if !res.IsError {
+		t.Fatalf("save_to in read-only mode succeeded: %+v", res.Content)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "save_to") || !strings.Contains(text, "read-only") {
+		t.Errorf("error = %q, want save_to read-only error", text)
+	}
+
+	// Reads without the write parameter, or with it empty, still work.
+	if got := callTool(t, s, "read_thing", map[string]any{"id": "2"}); got != "returned 2" {
+		t.Errorf("plain read = %q", got)
+	}
+	if got := callTool(t, s, "read_thing", map[string]any{"id": "3", "save_to": ""}); got != "returned 3" {
+		t.Errorf("read with empty save_to = %q", got)
+	}
+}
+
+func TestLocalWriteParams_AllowedWithoutReadOnly(t *testing.T) {
+	s := newSaveToTestServer(t)
+	if err := s.ApplyFilter(ToolFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := callTool(t, s, "read_thing", map[string]any{"id": "1", "save_to": "out.bin"}); got != "saved 1 to out.bin" {
+		t.Errorf("save_to = %q", got)
+	}
+}
+
 // --- Local FS tools tests ---
 
 // setupLocalFSDir creates a temp directory with some files for testing.
@@ -290,7 +364,7 @@ func TestRegisterLocalFSTools_Registered(t *testing.T) {
 	dir := setupLocalFSDir(t)
 	s := newLocalFSTestServer(t, dir)
 	got := listToolNames(t, s)
-	want := []string{"list_local_files", "read_local_file"}
+	want := []string{"list_local_files", "read_local_file", "stat_local_file", "write_local_file"}
 	if len(got) != len(want) {
 		t.Fatalf("got %v, want %v", got, want)
 	}
@@ -325,12 +399,89 @@ func TestLocalFSTools_ReadOnly(t *testing.T) {
 		t.Fatal(err)
 	}
 	for _, tool := range res.Tools {
-		if tool.Annotations == nil || !tool.Annotations.ReadOnlyHint {
+		a := tool.Annotations
+		if tool.Name == "write_local_file" {
+			if a == nil || a.ReadOnlyHint || a.DestructiveHint == nil || !*a.DestructiveHint || a.OpenWorldHint == nil || *a.OpenWorldHint {
+				t.Errorf("write_local_file should be a destructive, closed-world mutation: %+v", a)
+			}
+			continue
+		}
+		if a == nil || !a.ReadOnlyHint {
 			t.Errorf("tool %q should have ReadOnlyHint=true", tool.Name)
+			continue
+		}
+		// Local file tools never touch Google APIs.
+		if a.DestructiveHint == nil || *a.DestructiveHint || a.OpenWorldHint == nil || *a.OpenWorldHint {
+			t.Errorf("tool %q should set DestructiveHint=false and OpenWorldHint=false explicitly", tool.Name)
 		}
 	}
 }
 
+func TestWriteLocalFile_NotRegisteredForReadOnlyDirs(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	s := NewServer(&mcp.Implementation{Name: "localfs-test", Version: "test"}, nil)
+	lfs, err := localfs.New([]localfs.Dir{
+		{Path: dir, Mode: localfs.ModeRead},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lfs.Close() })
+	s.SetLocalFS(lfs)
+	RegisterLocalFSTools(s)
+
+	for _, name := range listToolNames(t, s) {
+		if name == "write_local_file" {
+			t.Fatal("write_local_file should not be registered without a read-write directory")
+		}
+	}
+}
+
+func TestWriteLocalFile_ExcludedByReadOnlyFilter(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	s := newLocalFSTestServer(t, dir)
+	if err := s.ApplyFilter(ToolFilter{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range listToolNames(t, s) {
+		if name == "write_local_file" {
+			t.Fatal("write_local_file should be removed by the read-only filter")
+		}
+	}
+}
+
+func TestWriteLocalFile(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	s := newLocalFSTestServer(t, dir)
+
+	result := callTool(t, s, "write_local_file", map[string]any{"path": "report.md", "content": "# Report\n"})
+	if !strings.Contains(result, "Directory: "+dir) || !strings.Contains(result, "Size: 9 bytes") {
+		t.Errorf("unexpected result:\n%s", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "report.md")); string(data) != "# Report\n" {
+		t.Errorf("file content = %q", data)
+	}
+
+	// Existing files are only replaced with overwrite.
+	res := callToolResult(t, s, "write_local_file", map[string]any{"path": "hello.txt", "content": "replaced"})
+	if !res.IsError {
+		t.Error("expected error writing an existing file without overwrite")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "hello.txt")); string(data) != "Hello, world!" {
+		t.Errorf("existing file was modified: %q", data)
+	}
+
+	callTool(t, s, "write_local_file", map[string]any{"path": "hello.txt", "content": "aGk=", "base64": true, "overwrite": true})
+	if data, _ := os.ReadFile(filepath.Join(dir, "hello.txt")); string(data) != "hi" {
+		t.Errorf("overwritten content = %q, want %q", data, "hi")
+	}
+
+	res = callToolResult(t, s, "write_local_file", map[string]any{"path": "../escape.txt", "content": "x"})
+	if !res.IsError {
+		t.Error("expected error writing outside the allowed directory")
+	}
+}
+
 func TestListLocalFiles_Root(t *testing.T) {
 	dir := setupLocalFSDir(t)
 	s := newLocalFSTestServer(t, dir)
@@ -357,6 +508,29 @@ func TestListLocalFiles_Subdir(t *testing.T) {
 	}
 }
 
+func TestListLocalFiles_RecursiveGlob(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "hello.txt"), old, old)
+	s := newLocalFSTestServer(t, dir)
+
+	result := callTool(t, s, "list_local_files", map[string]any{"recursive": true, "glob": "*.txt"})
+	nested := strings.Index(result, "subdir/nested.txt")
+	hello := strings.Index(result, "hello.txt")
+	if nested < 0 || hello < 0 {
+		t.Fatalf("expected both .txt files in listing:\n%s", result)
+	}
+	if nested > hello {
+		t.Errorf("expected newest file first:\n%s", result)
+	}
+	if strings.Contains(result, "data.csv") {
+		t.Errorf("glob should exclude data.csv:\n%s", result)
+	}
+	if !strings.Contains(result, old.Format(time.RFC3339)) {
+		t.Errorf("expected modification time in listing:\n%s", result)
+	}
+}
+
 func TestListLocalFiles_DescriptionContainsDirs(t *testing.T) {
 	dir := setupLocalFSDir(t)
 	s := newLocalFSTestServer(t, dir)
@@ -424,6 +598,91 @@ func TestReadLocalFile_Binary(t *testing.T) {
 	}
 }
 
+func TestReadLocalFile_Chunks(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	big := strings.Repeat("0123456789abcdef", (maxLocalRead+1000)/16)
+	os.WriteFile(filepath.Join(dir, "big.log"), []byte(big), 0644)
+	s := newLocalFSTestServer(t, dir)
+
+	first := callTool(t, s, "read_local_file", map[string]any{"path": "big.log"})
+	notice := fmt.Sprintf("--- truncated: showed bytes 0-%d of %d; use offset=%d to continue ---", maxLocalRead, len(big), maxLocalRead)
+	if !strings.HasPrefix(first, big[:maxLocalRead]) || !strings.HasSuffix(first, notice) {
+		t.Errorf("first chunk ends %q, want notice %q", first[len(first)-100:], notice)
+	}
+
+	rest := callTool(t, s, "read_local_file", map[string]any{"path": "big.log", "offset": maxLocalRead})
+	if rest != big[maxLocalRead:] {
+		t.Errorf("second chunk = %d bytes, want the remaining %d without a notice", len(rest), len(big)-maxLocalRead)
+	}
+
+	mid := callTool(t, s, "read_local_file", map[string]any{"path": "hello.txt", "offset": 7, "length": 5})
+	if want := "world\n\n--- truncated: showed bytes 7-12 of 13; use offset=12 to continue ---"; mid != want {
+		t.Errorf("offset 7 length 5 = %q, want %q", mid, want)
+	}
+}
+
+func TestReadLocalChunk(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	lfs, err := localfs.New([]localfs.Dir{{Path: dir, Mode: localfs.ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs.Close()
+
+	chunk, err := readLocalChunk(lfs, "hello.txt", 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chunk.Data) != "Hello" || chunk.Size != 13 || !chunk.truncated() || chunk.Dir != dir {
+		t.Errorf("chunk = %q size %d truncated %v dir %q", chunk.Data, chunk.Size, chunk.truncated(), chunk.Dir)
+	}
+	if chunk, err = readLocalChunk(lfs, "hello.txt", 13, 0); err != nil || len(chunk.Data) != 0 || chunk.truncated() {
+		t.Errorf("read at end = %v, %v", chunk, err)
+	}
+
+	for _, tt := range []struct {
+		offset, length int64
+		want           string
+	}{
+		{-1, 0, "offset must not be negative"},
+		{0, -1, "length must not be negative"},
+		{14, 0, "past the end"},
+	} {
+		if _, err := readLocalChunk(lfs, "hello.txt", tt.offset, tt.length); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("readLocalChunk(offset %d, length %d) = %v, want %q", tt.offset, tt.length, err, tt.want)
+		}
+	}
+}
+
+func TestStatLocalFile(t *testing.T) {
+	dir := setupLocalFSDir(t)
+	mtime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(dir, "hello.txt"), mtime, mtime)
+	s := newLocalFSTestServer(t, dir)
+
+	result := callTool(t, s, "stat_local_file", map[string]any{"path": "hello.txt"})
+	for _, want := range []string{
+		"Path: " + dir + "/hello.txt",
+		"Type: file",
+		"Size: 13 bytes",
+		"Modified: " + mtime.Local().Format(time.RFC3339),
+		"Mode: -rw-r--r--",
+		"Allowed directory: " + dir + " (read-write)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("stat_local_file missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "Larger than") {
+		t.Errorf("small file flagged as too large:\n%s", result)
+	}
+
+	result = callTool(t, s, "stat_local_file", map[string]any{"path": "subdir"})
+	if !strings.Contains(result, "Type: directory") || strings.Contains(result, "Size:") {
+		t.Errorf("stat_local_file of a directory:\n%s", result)
+	}
+}
+
 func TestReadLocalFile_EmptyPath(t *testing.T) {
 	dir := setupLocalFSDir(t)
 	s := newLocalFSTestServer(t, dir)
@@ -478,3 +737,1344 @@ func TestIsLikelyText(t *testing.T) {
 		})
 	}
 }
+
+// --- Result splitting tests ---
+
+// stripMarkers removes continuation markers and rejoins split blocks.
+func stripMarkers(parts []string) string {
+	var sb strings.Builder
+	for _, p := range parts {
+		if i := strings.LastIndex(p, "\n[part "); i >= 0 && strings.HasSuffix(p, ", continued in next block]") {
+			p = p[:i]
+		}
+		sb.WriteString(p)
+	}
+	return sb.String()
+}
+
+func TestSplitText_NoSplit(t *testing.T) {
+	parts := splitText("short", 100)
+	if len(parts) != 1 || parts[0] != "short" {
+		t.Errorf("splitText = %q, want [short]", parts)
+	}
+	parts = splitText(strings.Repeat("x", 500), 0)
+	if len(parts) != 1 {
+		t.Errorf("splitText with max=0 returned %d parts, want 1", len(parts))
+	}
+}
+
+func TestSplitText_UTF8Boundaries(t *testing.T) {
+	// Mix of 1-, 2-, 3- and 4-byte runes with no newlines so the splitter
+	// can't fall back to line boundaries.
+	text := strings.Repeat("aé€😀", 200)
+	for _, max := range []int{100, 101, 102, 103, 257} {
+		parts := splitText(text, max)
+		if len(parts) < 2 {
+			t.Fatalf("max=%d: got %d parts, want several", max, len(parts))
+		}
+		for i, p := range parts {
+			if !utf8.ValidString(p) {
+				t.Errorf("max=%d: part %d is not valid UTF-8", max, i)
+			}
+			if len(p) > max {
+				t.Errorf("max=%d: part %d is %d bytes", max, i, len(p))
+			}
+		}
+		if got := stripMarkers(parts); got != text {
+			t.Errorf("max=%d: rejoined text differs from original", max)
+		}
+	}
+}
+
+func TestSplitText_Markers(t *testing.T) {
+	parts := splitText(strings.Repeat("line of text\n", 100), 200)
+	n := len(parts)
+	for i, p := range parts[:n-1] {
+		want := fmt.Sprintf("[part %d/%d, continued in next block]", i+1, n)
+		if !strings.HasSuffix(p, want) {
+			t.Errorf("part %d should end with %q", i, want)
+		}
+	}
+	if strings.Contains(parts[n-1], "continued in next block") {
+		t.Error("last part should not have a continuation marker")
+	}
+	// Line boundaries are preferred.
+	for i, p := range parts[:n-1] {
+		body := p[:strings.LastIndex(p, "\n[part ")]
+		if !strings.HasSuffix(body, "\n") {
+			t.Errorf("part %d should end on a line boundary", i)
+		}
+	}
+}
+
+func TestSplitText_CitationAnchor(t *testing.T) {
+	anchor := "[Message ID: 18c2f0a9b7e4d321]"
+	text := strings.Repeat("word ", 30) + anchor + strings.Repeat(" word", 30)
+	// Choose a limit that would otherwise cut through the anchor.
+	max := 150 + markerReserve + 4
+	parts := splitText(text, max)
+	found := false
+	for _, p := range parts {
+		if strings.Contains(p, anchor) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("anchor %q was split across blocks: %q", anchor, parts)
+	}
+	if got := stripMarkers(parts); got != text {
+		t.Error("rejoined text differs from original")
+	}
+}
+
+func TestAddTool_SplitsLargeResults(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "split-test", Version: "test"}, nil)
+	s.SetMaxBlockSize(200)
+	big := strings.Repeat("ünïcödé line\n", 100)
+	AddTool(s, &mcp.Tool{Name: "big", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+		func(_ context.Context, _ *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: big}}}, nil, nil
+		})
+
+	result := callToolResult(t, s, "big", nil)
+	if len(result.Content) < 2 {
+		t.Fatalf("got %d content blocks, want several", len(result.Content))
+	}
+	var parts []string
+	for _, c := range result.Content {
+		tc := c.(*mcp.TextContent)
+		if len(tc.Text) > 200 {
+			t.Errorf("block is %d bytes, want <= 200", len(tc.Text))
+		}
+		parts = append(parts, tc.Text)
+	}
+	if stripMarkers(parts) != big {
+		t.Error("rejoined blocks differ from original result")
+	}
+}
+
+func TestAddTool_StructuredResultSummarized(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "split-test", Version: "test"}, nil)
+	s.SetMaxBlockSize(100)
+	AddTool(s, &mcp.Tool{Name: "structured", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+		func(_ context.Context, _ *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.Repeat("x", 1000)}}},
+				map[string]any{"items": []int{1, 2, 3}}, nil
+		})
+
+	result := callToolResult(t, s, "structured", nil)
+	if result.StructuredContent == nil {
+		t.Fatal("expected structured content")
+	}
+	if len(result.Content) != 1 {
+		t.Fatalf("got %d content blocks, want 1 summary", len(result.Content))
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "structured content") {
+		t.Errorf("summary = %q, want mention of structured content", text)
+	}
+}
+
+// callToolResult connects and invokes a tool, returning the raw result.
+func callToolResult(t *testing.T, s *Server, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	result, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool(%s): %v", name, err)
+	}
+	return result
+}
+
+func TestOutputBuilder_SmallOutputUnaffected(t *testing.T) {
+	out := NewOutputBuilder(DefaultMaxOutputBytes)
+	fmt.Fprintf(out, "Found %d items:\n\n", 2)
+	out.AddItem("- one\n")
+	out.AddItem("- two\n")
+	if out.Truncated() {
+		t.Error("small output should not be truncated")
+	}
+	if got, want := out.String(), "Found 2 items:\n\n- one\n- two\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestOutputBuilder_TruncatesAtItemBoundary(t *testing.T) {
+	out := NewOutputBuilder(40)
+	out.WriteString("Header\n")
+	for i := 1; i <= 10; i++ {
+		if !out.AddItem(fmt.Sprintf("- item %d: xxxxx\n", i)) {
+			break
+		}
+	}
+	// Text written after truncation, such as a later account header, is dropped.
+	out.WriteString("=== Account: other ===\n")
+
+	got := out.String()
+	want := "Header\n- item 1: xxxxx\n- item 2: xxxxx\n" +
+		"\n[output truncated after 2 items, refine your query or use pagination]\n"
+	if got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !out.Truncated() {
+		t.Error("Truncated() = false")
+	}
+	if out.AddItem("- x\n") {
+		t.Error("AddItem after truncation should report false")
+	}
+}
+
+func TestOutputBuilder_FirstItemAlwaysKept(t *testing.T) {
+	out := NewOutputBuilder(10)
+	big := strings.Repeat("x", 50) + "\n"
+	out.AddItem(big)
+	out.AddItem("- small\n")
+	got := out.String()
+	if !strings.HasPrefix(got, big) || !strings.Contains(got, "truncated after 1 items") {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestOutputBuilder_Disabled(t *testing.T) {
+	out := NewOutputBuilder(0)
+	for i := 0; i < 1000; i++ {
+		out.AddItem(strings.Repeat("x", 100))
+	}
+	if out.Truncated() || len(out.String()) != 100000 {
+		t.Errorf("disabled budget truncated output (len %d)", len(out.String()))
+	}
+}
+
+func TestServer_MaxOutputBytes(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "budget-test", Version: "test"}, nil)
+	if got := s.MaxOutputBytes(); got != DefaultMaxOutputBytes {
+		t.Errorf("default MaxOutputBytes = %d, want %d", got, DefaultMaxOutputBytes)
+	}
+	s.SetMaxOutputBytes(-1)
+	if got := s.MaxOutputBytes(); got != 0 {
+		t.Errorf("negative MaxOutputBytes = %d, want 0 (disabled)", got)
+	}
+	s.SetMaxOutputBytes(20)
+	out := s.NewOutputBuilder()
+	out.AddItem(strings.Repeat("a", 15))
+	if out.AddItem(strings.Repeat("b", 15)) {
+		t.Error("server budget not applied to NewOutputBuilder")
+	}
+}
+
+type sleepInput struct {
+	Millis int `json:"millis"`
+}
+
+// newTimeoutTestServer registers "sleep", which ignores its context like a
+// blocked API call, "fetch", which makes an HTTP request to url under the
+// call's context, "partial", which stops at the deadline and returns what
+// it has, and "long", a sleep with a 500ms time limit of its own.
+func newTimeoutTestServer(t *testing.T, timeout time.Duration, url string) *Server {
+	t.Helper()
+	s := NewServer(&mcp.Implementation{Name: "timeout-test", Version: "test"}, nil)
+	s.SetToolTimeout(timeout)
+	s.timeoutGrace = 50 * time.Millisecond
+	AddTool(s, &mcp.Tool{Name: "sleep"}, func(_ context.Context, _ *mcp.CallToolRequest, input sleepInput) (*mcp.CallToolResult, any, error) {
+		time.Sleep(time.Duration(input.Millis) * time.Millisecond)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+	})
+	AddTool(s, &mcp.Tool{Name: "fetch"}, func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		resp.Body.Close()
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "fetched"}}}, nil, nil
+	})
+	AddTool(s, &mcp.Tool{Name: "partial"}, func(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, any, error) {
+		<-ctx.Done()
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "2 of 5 done"}}}, nil, nil
+	})
+	AddTool(s, &mcp.Tool{Name: "long"}, func(_ context.Context, _ *mcp.CallToolRequest, input sleepInput) (*mcp.CallToolResult, any, error) {
+		time.Sleep(time.Duration(input.Millis) * time.Millisecond)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+	}, Timeout(500*time.Millisecond))
+	return s
+}
+
+func TestToolTimeout_Default(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "t", Version: "test"}, nil)
+	if got := s.ToolTimeout(); got != DefaultToolTimeout {
+		t.Errorf("ToolTimeout() = %v, want %v", got, DefaultToolTimeout)
+	}
+	s.SetToolTimeout(-time.Second)
+	if got := s.ToolTimeout(); got != 0 {
+		t.Errorf("ToolTimeout() after negative = %v, want 0", got)
+	}
+}
+
+func TestToolTimeout_HandlerIgnoringContext(t *testing.T) {
+	s := newTimeoutTestServer(t, 50*time.Millisecond, "")
+	start := time.Now()
+	res := callToolResult(t, s, "sleep", map[string]any{"millis": 2000})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call took %v, want it to return at the deadline", elapsed)
+	}
+	if !res.IsError {
+		t.Fatalf("slow call succeeded: %+v", res.Content)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "operation timed out after 50ms") {
+		t.Errorf("error = %q, want timeout error", text)
+	}
+
+	if got := callTool(t, s, "sleep", map[string]any{"millis": 1}); got != "done" {
+		t.Errorf("fast call = %q, want done", got)
+	}
+}
+
+func TestToolTimeout_CancelsRequests(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer hung.Close()
+	defer close(release)
+
+	s := newTimeoutTestServer(t, 50*time.Millisecond, hung.URL)
+	res := callToolResult(t, s, "fetch", nil)
+	if !res.IsError {
+		t.Fatalf("hung request succeeded: %+v", res.Content)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "operation timed out after 50ms") {
+		t.Errorf("error = %q, want timeout error", text)
+	}
+}
+
+func TestToolTimeout_Disabled(t *testing.T) {
+	s := newTimeoutTestServer(t, 0, "")
+	if got := callTool(t, s, "sleep", map[string]any{"millis": 100}); got != "done" {
+		t.Errorf("call with timeout disabled = %q, want done", got)
+	}
+}
+
+func TestToolTimeout_PartialResult(t *testing.T) {
+	s := newTimeoutTestServer(t, 50*time.Millisecond, "")
+	got := callTool(t, s, "partial", nil)
+	if !strings.HasPrefix(got, "2 of 5 done") || !strings.Contains(got, "Stopped at the time limit of 50ms") {
+		t.Errorf("result = %q, want the partial result and a note", got)
+	}
+	if m := s.journal.recent(0, "partial"); len(m) != 1 {
+		t.Errorf("journal = %+v, want the partial call recorded", m)
+	}
+}
+
+func TestToolTimeout_LateResultJournaled(t *testing.T) {
+	s := newTimeoutTestServer(t, 50*time.Millisecond, "")
+	res := callToolResult(t, s, "sleep", map[string]any{"millis": 300})
+	if !res.IsError {
+		t.Fatalf("slow call succeeded: %+v", res.Content)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "still running") {
+		t.Errorf("error = %q, want it to say the call is still running", text)
+	}
+	if m := s.journal.recent(0, "sleep"); len(m) != 0 {
+		t.Fatalf("journal = %+v before the call finished", m)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.journal.recent(0, "sleep")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("late result was not journaled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestToolTimeout_ToolOption(t *testing.T) {
+	s := newTimeoutTestServer(t, 50*time.Millisecond, "")
+	if got := callTool(t, s, "long", map[string]any{"millis": 150}); got != "done" {
+		t.Errorf("call within the tool's own limit = %q, want done", got)
+	}
+	res := callToolResult(t, s, "long", map[string]any{"millis": 1000})
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.Contains(text, "operation timed out after 500ms") {
+		t.Errorf("result = %q, want the tool's timeout", text)
+	}
+
+	// A tool's limit never lowers the server's, and doesn't apply when the
+	// server's is disabled.
+	info := ToolInfo{Name: "long", Timeout: 10 * time.Millisecond}
+	if got := s.toolTimeoutFor(info); got != 50*time.Millisecond {
+		t.Errorf("toolTimeoutFor = %v, want the server's 50ms", got)
+	}
+	s.SetToolTimeout(0)
+	if got := s.toolTimeoutFor(ToolInfo{Timeout: time.Minute}); got != 0 {
+		t.Errorf("toolTimeoutFor with the server's timeout disabled = %v, want 0", got)
+	}
+}
+
+// callToolWithProgress calls a tool with a progress token, or without one if
+// token is empty, and returns the progress notifications the client got.
+func callToolWithProgress(t *testing.T, s *Server, name, token string, args map[string]any) []*mcp.ProgressNotificationParams {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	var mu sync.Mutex
+	var got []*mcp.ProgressNotificationParams
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, req.Params)
+		},
+	})
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	// SetProgressToken only takes effect on a non-nil Meta.
+	params := &mcp.CallToolParams{Name: name, Arguments: args, Meta: mcp.Meta{}}
+	if token != "" {
+		params.SetProgressToken(token)
+	}
+	res, err := cs.CallTool(ctx, params)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if res.IsError {
+		t.Fatalf("CallTool returned error: %+v", res.Content)
+	}
+
+	// Notifications are handled asynchronously; give them a moment to land.
+	want, _ := args["steps"].(int)
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if token == "" || n >= want || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return got
+}
+
+func newProgressTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer(&mcp.Implementation{Name: "progress-test", Version: "test"}, nil)
+	type stepsInput struct {
+		Steps int `json:"steps"`
+	}
+	AddTool(s, &mcp.Tool{
+		Name:        "steps",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input stepsInput) (*mcp.CallToolResult, any, error) {
+		for i := 1; i <= input.Steps; i++ {
+			Progress(ctx, req, i, input.Steps, fmt.Sprintf("step %d", i))
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil, nil
+	})
+	return s
+}
+
+func TestProgress_Notifications(t *testing.T) {
+	s := newProgressTestServer(t)
+	got := callToolWithProgress(t, s, "steps", "tok-1", map[string]any{"steps": 3})
+	if len(got) != 3 {
+		t.Fatalf("got %d notifications, want 3", len(got))
+	}
+	for i, p := range got {
+		if p.ProgressToken != "tok-1" {
+			t.Errorf("notification %d token = %v, want tok-1", i, p.ProgressToken)
+		}
+		if p.Progress != float64(i+1) || p.Total != 3 {
+			t.Errorf("notification %d = %v/%v, want %d/3", i, p.Progress, p.Total, i+1)
+		}
+		if want := fmt.Sprintf("step %d", i+1); p.Message != want {
+			t.Errorf("notification %d message = %q, want %q", i, p.Message, want)
+		}
+	}
+}
+
+func TestProgress_NoToken(t *testing.T) {
+	s := newProgressTestServer(t)
+	got := callToolWithProgress(t, s, "steps", "", map[string]any{"steps": 3})
+	time.Sleep(20 * time.Millisecond)
+	if len(got) != 0 {
+		t.Errorf("got %d notifications without a progress token, want 0", len(got))
+	}
+}
+
+func TestProgress_NilRequest(t *testing.T) {
+	// Must not panic when called outside a client request.
+	Progress(context.Background(), nil, 1, 2, "x")
+	Progress(context.Background(), &mcp.CallToolRequest{}, 1, 2, "x")
+}
+
+// newAccountsTestServer creates a filter test server that also has
+// list_accounts.
+func newAccountsTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	creds := `{"installed":{"client_id":"x","client_secret":"y","auth_uri":"https://a","token_uri":"https://t","redirect_uris":["http://localhost"]}}`
+	if err := os.WriteFile(filepath.Join(dir, "credentials.json"), []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := auth.NewManager(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newFilterTestServer(t)
+	RegisterAccountsListTool(s, mgr)
+	return s
+}
+
+func TestApplyFilter_EnableKeepsListAccounts(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		s := newAccountsTestServer(t)
+		if err := s.ApplyFilter(ToolFilter{ReadOnly: readOnly, Enable: []string{"read_a"}}); err != nil {
+			t.Fatal(err)
+		}
+		got := listToolNames(t, s)
+		want := []string{"list_accounts", "read_a"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("read-only=%v: got %v, want %v", readOnly, got, want)
+		}
+	}
+}
+
+func TestApplyFilter_DisableListAccounts(t *testing.T) {
+	s := newAccountsTestServer(t)
+	if err := s.ApplyFilter(ToolFilter{Disable: []string{"list_accounts"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range listToolNames(t, s) {
+		if name == AccountsListToolName {
+			t.Error("explicitly disabled list_accounts is still exposed")
+		}
+	}
+}
+
+type tableTestRow struct {
+	name  string
+	size  int64
+	owner []string
+}
+
+var tableTestColumns = []Column[tableTestRow]{
+	{Name: "name", Value: func(r tableTestRow) any { return r.name }},
+	{Name: "size", Value: func(r tableTestRow) any { return r.size }},
+	{Name: "owners", Value: func(r tableTestRow) any { return r.owner }},
+}
+
+func TestTable_CSVQuoting(t *testing.T) {
+	table, err := NewTable("csv", tableTestColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Add(
+		tableTestRow{name: "plain.txt", size: 10, owner: []string{"Alice"}},
+		tableTestRow{name: "Budget, Q3.xlsx", size: 2048},
+		tableTestRow{name: `The "final" draft`, owner: []string{"Alice", "Bob"}},
+		tableTestRow{name: "two\nlines"},
+	)
+	want := "name,size,owners\r\n" +
+		"plain.txt,10,Alice\r\n" +
+		"\"Budget, Q3.xlsx\",2048,\r\n" +
+		"\"The \"\"final\"\" draft\",0,Alice; Bob\r\n" +
+		"\"two\r\nlines\",0,\r\n"
+	if got := table.String(); got != want {
+		t.Errorf("csv =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTable_JSON(t *testing.T) {
+	table, err := NewTable("JSON", tableTestColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := table.String(); got != "[]" {
+		t.Errorf("empty table = %s, want []", got)
+	}
+	table.Add(tableTestRow{name: "a \"b\"", size: 3, owner: []string{"Alice"}}, tableTestRow{name: "c"})
+	want := `[{"name":"a \"b\"","size":3,"owners":["Alice"]},{"name":"c","size":0,"owners":null}]`
+	if got := table.String(); got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+}
+
+func TestNewTable_Formats(t *testing.T) {
+	for _, format := range []string{"", "text", " Text "} {
+		table, err := NewTable(format, tableTestColumns)
+		if table != nil || err != nil {
+			t.Errorf("NewTable(%q) = %v, %v; want nil, nil", format, table, err)
+		}
+	}
+	if _, err := NewTable("xml", tableTestColumns); err == nil || !strings.Contains(err.Error(), `invalid format "xml"`) {
+		t.Errorf("err = %v, want invalid format error", err)
+	}
+}
+
+func TestTable_ResultErrors(t *testing.T) {
+	table, _ := NewTable("json", tableTestColumns)
+	if res := table.Result(); len(res.Content) != 1 {
+		t.Errorf("result without errors has %d blocks, want 1", len(res.Content))
+	}
+	table.AddError("account %s: %v", "work", "token expired")
+	res := table.Result()
+	if len(res.Content) != 2 {
+		t.Fatalf("result has %d blocks, want 2", len(res.Content))
+	}
+	if got := res.Content[1].(*mcp.TextContent).Text; got != "Errors:\n- account work: token expired\n" {
+		t.Errorf("errors block = %q", got)
+	}
+}
+
+// newJournalTestServer creates a Server with a read-only tool, a mutation
+// that reports an Event ID and a mutation that fails after deleting one
+// event.
+func newJournalTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer(&mcp.Implementation{Name: "journal-test", Version: "test"}, nil)
+	text := func(s string) *mcp.CallToolResult {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: s}}}
+	}
+	AddTool(s, &mcp.Tool{Name: "get_event", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+		func(_ context.Context, _ *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+			return text("Event: Standup\nEvent ID: ev1"), nil, nil
+		})
+	AddTool(s, &mcp.Tool{Name: "create_event", Annotations: &mcp.ToolAnnotations{}},
+		func(_ context.Context, _ *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+			return text("Event created successfully.\nEvent ID: ev2\nCalendar ID: primary\nLink: https://calendar.google.com/event?eid=x"), nil, nil
+		})
+	AddTool(s, &mcp.Tool{Name: "delete_event", Annotations: &mcp.ToolAnnotations{}},
+		func(_ context.Context, _ *mcp.CallToolRequest, _ any) (*mcp.CallToolResult, any, error) {
+			return nil, nil, fmt.Errorf("deleted 1 of 2 events:\nEvent ID: ev3\ndeleting event ev4: not found")
+		})
+	RegisterMutationsTool(s)
+	return s
+}
+
+func TestJournal_RecordsMutationsOnly(t *testing.T) {
+	s := newJournalTestServer(t)
+	callTool(t, s, "get_event", map[string]any{"account": "work"})
+	callTool(t, s, "create_event", map[string]any{"account": "work"})
+	callTool(t, s, "create_event", map[string]any{"account": "work", "dry_run": true})
+	callTool(t, s, "delete_event", map[string]any{"account": "work"})
+	callTool(t, s, MutationsToolName, nil)
+
+	got := s.journal.recent(0, "")
+	if len(got) != 2 {
+		t.Fatalf("journal has %d entries, want create_event and delete_event: %+v", len(got), got)
+	}
+	m := got[1]
+	if m.Tool != "create_event" || m.Account != "work" || m.Summary != "Event created successfully." || m.Error != "" {
+		t.Errorf("entry = %+v", m)
+	}
+	wantIDs := []MutationID{{"Event ID", "ev2"}, {"Calendar ID", "primary"}}
+	if fmt.Sprint(m.IDs) != fmt.Sprint(wantIDs) {
+		t.Errorf("IDs = %v, want %v", m.IDs, wantIDs)
+	}
+	m = got[0]
+	if m.Tool != "delete_event" || !strings.HasPrefix(m.Error, "deleted 1 of 2 events") {
+		t.Errorf("failed entry = %+v", m)
+	}
+	if wantIDs := []MutationID{{"Event ID", "ev3"}}; fmt.Sprint(m.IDs) != fmt.Sprint(wantIDs) {
+		t.Errorf("failed entry IDs = %v, want %v", m.IDs, wantIDs)
+	}
+
+	out := callTool(t, s, MutationsToolName, map[string]any{"tool": "create_event"})
+	for _, want := range []string{"1 recent changes", "create_event (account work)", "Event ID: ev2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list_recent_mutations output missing %q:\n%s", want, out)
+		}
+	}
+	out = callTool(t, s, MutationsToolName, map[string]any{"tool": "delete_event"})
+	for _, want := range []string{"Failed: deleted 1 of 2 events", "Event ID: ev3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list_recent_mutations output missing %q:\n%s", want, out)
+		}
+	}
+	if out := callTool(t, s, MutationsToolName, map[string]any{"tool": "get_event"}); out != "No changes recorded." {
+		t.Errorf("filtered output = %q", out)
+	}
+}
+
+func TestJournal_Ring(t *testing.T) {
+	j := newJournal(3)
+	for i := range 5 {
+		j.add(Mutation{Tool: fmt.Sprintf("t%d", i)})
+	}
+	var names []string
+	for _, m := range j.recent(0, "") {
+		names = append(names, m.Tool)
+	}
+	if got := strings.Join(names, ","); got != "t4,t3,t2" {
+		t.Errorf("recent() = %s, want t4,t3,t2", got)
+	}
+	if got := j.recent(2, ""); len(got) != 2 || got[0].Tool != "t4" {
+		t.Errorf("recent(2) = %+v", got)
+	}
+}
+
+func TestJournal_AuditLogRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AuditLogFile)
+	s := newJournalTestServer(t)
+	if err := s.SetAuditLog(path); err != nil {
+		t.Fatal(err)
+	}
+	callTool(t, s, "create_event", map[string]any{"account": "work"})
+	callTool(t, s, "get_event", nil)
+	callTool(t, s, "create_event", nil)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+	// A line cut short by a crash is skipped on load.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-`)
+	f.Close()
+
+	// A new server, as in the next session, loads the earlier entries.
+	next := newJournalTestServer(t)
+	if err := next.SetAuditLog(path); err != nil {
+		t.Fatal(err)
+	}
+	want := s.journal.recent(0, "")
+	got := next.journal.recent(0, "")
+	if len(got) != 2 || len(want) != 2 {
+		t.Fatalf("loaded %d entries, want 2", len(got))
+	}
+	for i := range got {
+		if !got[i].Time.Equal(want[i].Time) || fmt.Sprint(got[i].IDs) != fmt.Sprint(want[i].IDs) ||
+			got[i].Tool != want[i].Tool || got[i].Account != want[i].Account || got[i].Summary != want[i].Summary {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMutationIDs(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Message sent successfully.\nMessage ID: 18c2\nThread ID: 18c1", "[{Message ID 18c2} {Thread ID 18c1}]"},
+		{"Drafts:\n  - Draft ID: r-1\n  - Draft ID: r-2\n  - Draft ID: r-1", "[{Draft ID r-1} {Draft ID r-2}]"},
+		{"File ID: abc\nNew Parent ID: def", "[{File ID abc} {New Parent ID def}]"},
+		{"Subject: Invoice ID: 42 attached", "[]"},
+		{"Label deleted.", "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(mutationIDs(tt.text)); got != tt.want {
+			t.Errorf("mutationIDs(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+// connectClient connects an in-memory client to s.
+func connectClient(t *testing.T, s *Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+// readJSONResource reads uri through cs and decodes it into v.
+func readJSONResource(t *testing.T, cs *mcp.ClientSession, uri string, v any) {
+	t.Helper()
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		t.Fatalf("ReadResource(%s): %v", uri, err)
+	}
+	if len(res.Contents) != 1 || res.Contents[0].MIMEType != "application/json" {
+		t.Fatalf("ReadResource(%s) contents = %+v", uri, res.Contents)
+	}
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), v); err != nil {
+		t.Fatalf("decoding %s: %v", uri, err)
+	}
+}
+
+func TestAccountsResource(t *testing.T) {
+	dir := t.TempDir()
+	creds := `{"installed":{"client_id":"x","client_secret":"y","auth_uri":"https://a","token_uri":"https://t","redirect_uris":["http://localhost"]}}`
+	tokens := `{"default":"work","accounts":{
+		"work":{"email":"me@work.example","scopes":["https://mail.google.com/"],"token":{"access_token":"secret-access","refresh_token":"secret-refresh","expiry":"2030-01-01T00:00:00Z"}},
+		"home":{"email":"me@home.example","token":{"refresh_token":"secret-refresh"}}}}`
+	for name, data := range map[string]string{"credentials.json": creds, "tokens.json": tokens} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mgr, err := auth.NewManager(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+	RegisterAccountsResource(s, mgr)
+	cs := connectClient(t, s)
+
+	list, err := cs.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].URI != AccountsResourceURI {
+		t.Fatalf("ListResources = %+v, want only %s", list.Resources, AccountsResourceURI)
+	}
+
+	res, err := cs.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: AccountsResourceURI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(res.Contents[0].Text, "secret") {
+		t.Errorf("accounts resource leaks tokens:\n%s", res.Contents[0].Text)
+	}
+	var accounts []accountResource
+	readJSONResource(t, cs, AccountsResourceURI, &accounts)
+	if len(accounts) != 2 {
+		t.Fatalf("accounts = %+v, want 2", accounts)
+	}
+	home, work := accounts[0], accounts[1]
+	if home.Name != "home" || home.Email != "me@home.example" || home.Default || len(home.Scopes) != 0 {
+		t.Errorf("home = %+v", home)
+	}
+	if work.Name != "work" || !work.Default || len(work.Scopes) != 1 || !work.TokenExpiry.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("work = %+v", work)
+	}
+}
+
+func TestLocalDirsResource(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+	if len(s.Resources()) != 0 {
+		t.Fatalf("resources without local FS = %+v", s.Resources())
+	}
+
+	readDir, writeDir := t.TempDir(), t.TempDir()
+	lfs, err := localfs.New([]localfs.Dir{{Path: readDir, Mode: localfs.ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs.Close()
+	s.SetLocalFS(lfs)
+	cs := connectClient(t, s)
+
+	var dirs []localDirResource
+	readJSONResource(t, cs, LocalDirsResourceURI, &dirs)
+	if len(dirs) != 1 || dirs[0].Mode != "read-only" {
+		t.Fatalf("dirs = %+v", dirs)
+	}
+
+	// Setting the local FS again updates the resource.
+	lfs2, err := localfs.New([]localfs.Dir{
+		{Path: readDir, Mode: localfs.ModeRead},
+		{Path: writeDir, Mode: localfs.ModeReadWrite},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs2.Close()
+	s.SetLocalFS(lfs2)
+	readJSONResource(t, cs, LocalDirsResourceURI, &dirs)
+	if len(dirs) != 2 || dirs[1].Mode != "read-write" {
+		t.Errorf("dirs after SetLocalFS = %+v", dirs)
+	}
+	if len(s.Resources()) != 1 {
+		t.Errorf("resources = %+v, want one local-dirs entry", s.Resources())
+	}
+
+	// Clearing it removes the resource.
+	s.SetLocalFS(nil)
+	list, err := cs.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 0 || len(s.Resources()) != 0 {
+		t.Errorf("resources after SetLocalFS(nil) = %+v", list.Resources)
+	}
+}
+
+// newPromptTestServer creates a filter test server with a prompt that needs
+// read_a and adjusts to mutate_a.
+func newPromptTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newFilterTestServer(t)
+	AddPrompt(s, PromptTemplate{
+		Name:        "do_things",
+		Description: "Do things",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "target", Required: true},
+			{Name: "days"},
+		},
+		Defaults: map[string]string{"days": "7"},
+		Tools:    []string{"read_a"},
+		Template: `Read {{.target}} for {{.days}} days.{{if tool "mutate_a"}} Then mutate it.{{else}} Don't change it.{{end}}`,
+	})
+	return s
+}
+
+func getPromptText(t *testing.T, cs *mcp.ClientSession, name string, args map[string]string) (string, error) {
+	t.Helper()
+	res, err := cs.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", err
+	}
+	if len(res.Messages) != 1 || res.Messages[0].Role != "user" {
+		t.Fatalf("GetPrompt messages = %+v", res.Messages)
+	}
+	return res.Messages[0].Content.(*mcp.TextContent).Text, nil
+}
+
+func TestPrompt_Arguments(t *testing.T) {
+	cs := connectClient(t, newPromptTestServer(t))
+
+	list, err := cs.ListPrompts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Prompts) != 1 || list.Prompts[0].Name != "do_things" || len(list.Prompts[0].Arguments) != 2 || !list.Prompts[0].Arguments[0].Required {
+		t.Fatalf("ListPrompts = %+v", list.Prompts)
+	}
+
+	tests := []struct {
+		args    map[string]string
+		want    string
+		wantErr string
+	}{
+		{map[string]string{"target": "inbox", "days": "3"}, "Read inbox for 3 days. Then mutate it.", ""},
+		{map[string]string{"target": "inbox"}, "Read inbox for 7 days. Then mutate it.", ""},
+		{map[string]string{"target": "inbox", "days": " "}, "Read inbox for 7 days. Then mutate it.", ""},
+		{map[string]string{"days": "3"}, "", `argument "target" is required`},
+		{map[string]string{"target": "inbox", "weeks": "2"}, "", `unknown argument "weeks"`},
+	}
+	for _, tt := range tests {
+		got, err := getPromptText(t, cs, "do_things", tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("GetPrompt(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("GetPrompt(%v) = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestPrompt_ReadOnlyAdjusts(t *testing.T) {
+	s := newPromptTestServer(t)
+	if err := s.ApplyFilter(ToolFilter{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := getPromptText(t, connectClient(t, s), "do_things", map[string]string{"target": "inbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Read inbox for 7 days. Don't change it."; got != want {
+		t.Errorf("prompt = %q, want %q", got, want)
+	}
+}
+
+func TestPrompt_HiddenWithoutRequiredTool(t *testing.T) {
+	for _, filter := range []ToolFilter{{Disable: []string{"read_a"}}, {Enable: []string{"read_b"}}} {
+		s := newPromptTestServer(t)
+		if err := s.ApplyFilter(filter); err != nil {
+			t.Fatal(err)
+		}
+		list, err := connectClient(t, s).ListPrompts(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Prompts) != 0 {
+			t.Errorf("filter %+v: prompts = %+v, want none", filter, list.Prompts)
+		}
+	}
+}
+
+func TestReloadLocalFS(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+	if err := s.ReloadLocalFS(nil); err == nil {
+		t.Error("ReloadLocalFS() without a local FS succeeded")
+	}
+
+	readDir, invoiceDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(invoiceDir, "march.pdf"), []byte("%PDF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lfs, err := localfs.New([]localfs.Dir{{Path: readDir, Mode: localfs.ModeRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lfs.Close()
+	s.SetLocalFS(lfs)
+	RegisterLocalFSTools(s)
+	AddTool(s, &mcp.Tool{
+		Name:        "attach_file",
+		Description: "Attach a local file." + s.ReadDirsDescription(),
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	cs := connectClient(t, s)
+
+	if err := s.ReloadLocalFS([]localfs.Dir{
+		{Path: readDir, Mode: localfs.ModeRead},
+		{Path: invoiceDir, Mode: localfs.ModeRead, Label: "invoices"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools.Tools {
+		switch tool.Name {
+		case "list_local_files":
+			if !strings.Contains(tool.Description, "invoices = "+invoiceDir+" (read-only)") {
+				t.Errorf("list_local_files description not refreshed:\n%s", tool.Description)
+			}
+		case "attach_file":
+			if want := "Attach a local file.\n\nAllowed local directories (for local file paths):\n  - " + readDir + " (read-only)\n  - invoices = " + invoiceDir + " (read-only)\n"; tool.Description != want {
+				t.Errorf("attach_file description = %q, want %q", tool.Description, want)
+			}
+		}
+	}
+	if got := len(s.Tools()); got != 4 {
+		t.Errorf("tools after reload = %d, want 4 (no duplicates, no write tool)", got)
+	}
+
+	var dirs []localDirResource
+	readJSONResource(t, cs, LocalDirsResourceURI, &dirs)
+	if len(dirs) != 2 || dirs[1].Label != "invoices" || dirs[1].Path != invoiceDir {
+		t.Errorf("dirs after reload = %+v", dirs)
+	}
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "stat_local_file", Arguments: map[string]any{"path": "march.pdf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "Path: invoices/march.pdf\n") || !strings.Contains(text, "Allowed directory: invoices (read-only)") {
+		t.Errorf("stat_local_file result:\n%s", text)
+	}
+
+	// A failed reload keeps the directories.
+	if err := s.ReloadLocalFS([]localfs.Dir{{Path: filepath.Join(readDir, "missing")}}); err == nil {
+		t.Error("ReloadLocalFS() with a missing directory succeeded")
+	}
+	if len(lfs.Dirs()) != 2 {
+		t.Errorf("Dirs() after failed reload = %+v", lfs.Dirs())
+	}
+}
+
+// newMultiAccountManager returns a manager with the accounts home,
+// personal (the default) and work.
+func newMultiAccountManager(t *testing.T) *auth.Manager {
+	t.Helper()
+	dir := t.TempDir()
+	creds := `{"installed":{"client_id":"x","client_secret":"y","auth_uri":"https://a","token_uri":"https://t","redirect_uris":["http://localhost"]}}`
+	tokens := `{"default":"personal","accounts":{"home":{"token":{}},"personal":{"token":{}},"work":{"token":{}}}}`
+	for name, data := range map[string]string{"credentials.json": creds, "tokens.json": tokens} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mgr, err := auth.NewManager(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mgr
+}
+
+func TestCrossAccountHint(t *testing.T) {
+	mgr := newMultiAccountManager(t)
+	notFound := fmt.Errorf("getting file: %w", &googleapi.Error{Code: http.StatusNotFound, Message: "File not found: f1."})
+	var probed []string
+	// f1 is only in work; probing home fails.
+	probe := func(ctx context.Context, account string) (bool, error) {
+		probed = append(probed, account)
+		if account == "home" {
+			return false, errors.New("token expired")
+		}
+		return account == "work", nil
+	}
+
+	s := NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+	if err := s.CrossAccountHint(context.Background(), mgr, "personal", "file f1", notFound, probe); err != notFound || len(probed) != 0 {
+		t.Errorf("disabled: err = %v, probed %v; want the error unchanged and no probes", err, probed)
+	}
+
+	s.SetCrossAccountHints(true)
+	err := s.CrossAccountHint(context.Background(), mgr, "", "file f1", notFound, probe)
+	want := "file f1 not found in 'personal' but exists in 'work' — retry with account='work'"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) || gerr.Code != http.StatusNotFound {
+		t.Errorf("hint doesn't wrap the API error: %v", err)
+	}
+	if strings.Join(probed, ",") != "home,work" {
+		t.Errorf("probed %v, want the other accounts in name order", probed)
+	}
+
+	probed = nil
+	if err := s.CrossAccountHint(context.Background(), mgr, "work", "file f2", notFound, func(context.Context, string) (bool, error) { return false, nil }); err != notFound {
+		t.Errorf("found nowhere: err = %v, want it unchanged", err)
+	}
+
+	forbidden := &googleapi.Error{Code: http.StatusForbidden}
+	if err := s.CrossAccountHint(context.Background(), mgr, "personal", "file f1", forbidden, probe); err != forbidden || len(probed) != 0 {
+		t.Errorf("403: err = %v, probed %v; want the error unchanged and no probes", err, probed)
+	}
+}
+
+func TestSessionAccountPrecedence(t *testing.T) {
+	mgr := newMultiAccountManager(t)
+	type whoamiInput struct {
+		Account string `json:"account,omitempty"`
+	}
+	newWhoamiServer := func(defaultAccount string) *Server {
+		s := NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+		s.SetDefaultAccount(defaultAccount)
+		AddTool(s, &mcp.Tool{Name: "whoami", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true}},
+			func(ctx context.Context, req *mcp.CallToolRequest, input whoamiInput) (*mcp.CallToolResult, any, error) {
+				accounts, err := mgr.ResolveAccounts(auth.ResolveAccount(ctx, input.Account))
+				if err != nil {
+					return nil, nil, err
+				}
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: accounts[0]}}}, nil, nil
+			})
+		return s
+	}
+	whoami := func(t *testing.T, s *Server, caps *mcp.ClientCapabilities, account string) string {
+		t.Helper()
+		ctx := context.Background()
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		ss, err := s.Connect(ctx, serverTransport, nil)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		defer ss.Close()
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, &mcp.ClientOptions{Capabilities: caps})
+		cs, err := client.Connect(ctx, clientTransport, nil)
+		if err != nil {
+			t.Fatalf("client.Connect: %v", err)
+		}
+		defer cs.Close()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "whoami", Arguments: map[string]any{"account": account}})
+		if err != nil {
+			t.Fatalf("CallTool: %v", err)
+		}
+		text := res.Content[0].(*mcp.TextContent).Text
+		if res.IsError {
+			t.Fatalf("whoami failed: %s", text)
+		}
+		return text
+	}
+	clientWork := &mcp.ClientCapabilities{Experimental: map[string]any{SessionAccountCapability: map[string]any{"account": "work"}}}
+
+	tests := []struct {
+		name          string
+		serverDefault string
+		caps          *mcp.ClientCapabilities
+		account       string
+		want          string
+	}{
+		{"explicit argument wins", "home", clientWork, "home", "home"},
+		{"client session default", "home", clientWork, "", "work"},
+		{"server default", "home", nil, "", "home"},
+		{"configured default", "", nil, "", "personal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := whoami(t, newWhoamiServer(tt.serverDefault), tt.caps, tt.account); got != tt.want {
+				t.Errorf("account = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientAccount(t *testing.T) {
+	tests := []struct {
+		name   string
+		params *mcp.InitializeParams
+		want   string
+	}{
+		{"nil", nil, ""},
+		{"none", &mcp.InitializeParams{Capabilities: &mcp.ClientCapabilities{}}, ""},
+		{"meta", &mcp.InitializeParams{Meta: mcp.Meta{SessionAccountMetaKey: "home"}}, "home"},
+		{"experimental", &mcp.InitializeParams{Capabilities: &mcp.ClientCapabilities{
+			Experimental: map[string]any{SessionAccountCapability: map[string]any{"account": "work"}},
+		}}, "work"},
+		{"meta over experimental", &mcp.InitializeParams{
+			Meta: mcp.Meta{SessionAccountMetaKey: "home"},
+			Capabilities: &mcp.ClientCapabilities{
+				Experimental: map[string]any{SessionAccountCapability: map[string]any{"account": "work"}},
+			},
+		}, "home"},
+		{"malformed", &mcp.InitializeParams{Capabilities: &mcp.ClientCapabilities{
+			Experimental: map[string]any{SessionAccountCapability: "work"},
+		}}, ""},
+	}
+	for _, tt := range tests {
+		if got := clientAccount(tt.params); got != tt.want {
+			t.Errorf("%s: clientAccount = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type accountNamesTestInput struct {
+	Account string `json:"account,omitempty" jsonschema:"Account name or 'all' for all accounts"`
+	Query   string `json:"query,omitempty" jsonschema:"Search query"`
+	Copies  []struct {
+		DriveAccount string `json:"drive_account,omitempty" jsonschema:"Account of the Drive file"`
+	} `json:"copies,omitempty"`
+}
+
+// listToolSchemas connects an in-memory client and returns the input schema
+// of each tool by name.
+func listToolSchemas(t *testing.T, s *Server) map[string]*jsonschema.Schema {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client.Connect: %v", err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	schemas := make(map[string]*jsonschema.Schema)
+	for _, tool := range res.Tools {
+		data, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema jsonschema.Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		schemas[tool.Name] = &schema
+	}
+	return schemas
+}
+
+func TestSetAccountNames_DescribesAccountFields(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "accounts-test", Version: "test"}, nil)
+	s.SetAccountNames([]string{"personal", "work"})
+	AddTool(s, &mcp.Tool{Name: "search"}, func(context.Context, *mcp.CallToolRequest, accountNamesTestInput) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+
+	schema := listToolSchemas(t, s)["search"]
+	if schema == nil {
+		t.Fatal("search not listed")
+	}
+	want := "Account name or 'all' for all accounts. Configured accounts: 'personal', 'work', or 'all' for all of them."
+	if got := schema.Properties["account"].Description; got != want {
+		t.Errorf("account description = %q, want %q", got, want)
+	}
+	if got := schema.Properties["query"].Description; got != "Search query" {
+		t.Errorf("query description = %q, want it unchanged", got)
+	}
+	nested := schema.Properties["copies"].Items.Properties["drive_account"].Description
+	if want := "Account of the Drive file. Configured accounts: 'personal', 'work'."; nested != want {
+		t.Errorf("drive_account description = %q, want %q", nested, want)
+	}
+}
+
+func TestSetAccountNames_ReregistersTools(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "accounts-test", Version: "test"}, nil)
+	AddTool(s, &mcp.Tool{Name: "search"}, func(context.Context, *mcp.CallToolRequest, accountNamesTestInput) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+	s.SetAccountNames([]string{"home"})
+
+	got := listToolSchemas(t, s)["search"].Properties["account"].Description
+	if !strings.Contains(got, "Configured accounts: 'home'") {
+		t.Errorf("account description = %q, want it to list 'home'", got)
+	}
+}
+
+func TestSetAccountNames_NoNames(t *testing.T) {
+	s := NewServer(&mcp.Implementation{Name: "accounts-test", Version: "test"}, nil)
+	AddTool(s, &mcp.Tool{Name: "search"}, func(context.Context, *mcp.CallToolRequest, accountNamesTestInput) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{}, nil, nil
+	})
+
+	got := listToolSchemas(t, s)["search"].Properties["account"].Description
+	if got != "Account name or 'all' for all accounts" {
+		t.Errorf("account description = %q, want it unchanged", got)
+	}
+}
+
+func TestWithAccountNames_ExplicitSchemaNotModified(t *testing.T) {
+	schema, err := jsonschema.For[accountNamesTestInput](nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &mcp.Tool{Name: "search", InputSchema: schema}
+	got := withAccountNames[accountNamesTestInput](tool, []string{"work"})
+	if got == tool {
+		t.Fatal("withAccountNames returned the tool unchanged")
+	}
+	if desc := schema.Properties["account"].Description; strings.Contains(desc, "work") {
+		t.Errorf("original schema was modified: %q", desc)
+	}
+	if desc := got.InputSchema.(*jsonschema.Schema).Properties["account"].Description; !strings.Contains(desc, "'work'") {
+		t.Errorf("account description = %q, want it to list 'work'", desc)
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/thegrumpylion/google-mcp/internal/auth"
+)
+
+// Where a client names the default account of its session in the
+// initialize request: the SessionAccountMetaKey key of the request's
+// _meta, or the "account" field of its SessionAccountCapability
+// experimental capability, e.g.
+//
+//	"capabilities": {"experimental": {"google-mcp": {"account": "work"}}}
+const (
+	SessionAccountMetaKey    = "google-mcp/account"
+	SessionAccountCapability = "google-mcp"
+)
+
+// SetDefaultAccount sets the account used by tool calls that omit the
+// account argument in sessions whose client doesn't name one at connect
+// time. It takes precedence over the default account in tokens.json. An
+// empty name leaves the choice to the Manager.
+func (s *Server) SetDefaultAccount(name string) {
+	s.defaultAccount = name
+}
+
+// DefaultAccount returns the account set with SetDefaultAccount.
+func (s *Server) DefaultAccount() string {
+	return s.defaultAccount
+}
+
+// withSessionAccount returns ctx carrying the default account of the
+// session making the call, for auth.ResolveAccount. The precedence is the
+// account the client named in its initialize request, then the account set
+// with SetDefaultAccount.
+func (s *Server) withSessionAccount(ctx context.Context, req *mcp.CallToolRequest) context.Context {
+	name := s.defaultAccount
+	if req != nil && req.Session != nil {
+		if account := clientAccount(req.Session.InitializeParams()); account != "" {
+			name = account
+		}
+	}
+	return auth.WithSessionAccount(ctx, name)
+}
+
+// clientAccount returns the session default account named in the client's
+// initialize params, or "" if there is none.
+func clientAccount(params *mcp.InitializeParams) string {
+	if params == nil {
+		return ""
+	}
+	if account, ok := params.Meta[SessionAccountMetaKey].(string); ok && account != "" {
+		return account
+	}
+	if params.Capabilities == nil {
+		return ""
+	}
+	if c, ok := params.Capabilities.Experimental[SessionAccountCapability].(map[string]any); ok {
+		if account, ok := c["account"].(string); ok {
+			return account
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// structuredContentVersion is the first MCP protocol version that supports
+// structuredContent in tool results.
+const structuredContentVersion = "2025-06-18"
+
+// markerReserve is the number of bytes held back in each block for the
+// continuation marker, so that blocks including their marker stay within
+// the configured limit.
+const markerReserve = 48
+
+// maxAnchorLen bounds how far back the splitter looks for an opening '['
+// when avoiding splitting a bracketed citation anchor such as "[3]" or
+// "[Message ID: 18c...]". Longer bracketed spans are split normally.
+const maxAnchorLen = 256
+
+// SetMaxBlockSize sets the maximum size in bytes of a single TextContent
+// block in tool results. Oversized results are split into several blocks
+// with continuation markers. Zero (the default) disables splitting.
+func (s *Server) SetMaxBlockSize(n int) {
+	s.maxBlockSize = n
+}
+
+// MaxBlockSize returns the configured maximum TextContent block size, or 0
+// if splitting is disabled.
+func (s *Server) MaxBlockSize() int {
+	return s.maxBlockSize
+}
+
+// shapeResult enforces the block size limit on a tool result. If the tool
+// returned structured output and the client understands structuredContent,
+// oversized text is replaced by a short summary; otherwise each oversized
+// TextContent block is split into bounded blocks.
+func (s *Server) shapeResult(req *mcp.CallToolRequest, res *mcp.CallToolResult, hasStructured bool) {
+	max := s.maxBlockSize
+	if max <= 0 || res == nil {
+		return
+	}
+
+	oversized := false
+	total := 0
+	for _, c := range res.Content {
+		if tc, ok := c.(*mcp.TextContent); ok {
+			total += len(tc.Text)
+			if len(tc.Text) > max {
+				oversized = true
+			}
+		}
+	}
+	if !oversized {
+		return
+	}
+
+	if hasStructured && supportsStructuredContent(req) {
+		res.Content = []mcp.Content{&mcp.TextContent{
+			Text: fmt.Sprintf("Result is %d bytes, which exceeds the %d byte block limit; the full result is in the structured content.", total, max),
+		}}
+		return
+	}
+
+	var content []mcp.Content
+	for _, c := range res.Content {
+		tc, ok := c.(*mcp.TextContent)
+		if !ok || len(tc.Text) <= max {
+			content = append(content, c)
+			continue
+		}
+		for _, part := range splitText(tc.Text, max) {
+			content = append(content, &mcp.TextContent{Text: part})
+		}
+	}
+	res.Content = content
+}
+
+// supportsStructuredContent reports whether the calling client negotiated a
+// protocol version that includes structuredContent.
+func supportsStructuredContent(req *mcp.CallToolRequest) bool {
+	if req == nil || req.Session == nil {
+		return false
+	}
+	params := req.Session.InitializeParams()
+	if params == nil {
+		return false
+	}
+	// Protocol versions are ISO dates, so they compare lexically.
+	return params.ProtocolVersion >= structuredContentVersion
+}
+
+// splitText splits text into blocks of at most max bytes, each followed by
+// a continuation marker except the last. Splits never fall inside a
+// multi-byte UTF-8 sequence, prefer line boundaries, and avoid breaking a
+// bracketed citation anchor.
+func splitText(text string, max int) []string {
+	if max <= 0 || len(text) <= max {
+		return []string{text}
+	}
+
+	size := max
+	if size > 2*markerReserve {
+		size -= markerReserve
+	}
+
+	var parts []string
+	rest := text
+	for len(rest) > size {
+		cut := splitPoint(rest, size)
+		parts = append(parts, rest[:cut])
+		rest = rest[cut:]
+	}
+	if rest != "" {
+		parts = append(parts, rest)
+	}
+
+	for i := 0; i < len(parts)-1; i++ {
+		parts[i] += fmt.Sprintf("\n[part %d/%d, continued in next block]", i+1, len(parts))
+	}
+	return parts
+}
+
+// splitPoint returns the byte offset at which to split s so that the first
+// piece is at most size bytes. It always returns a value > 0.
+func splitPoint(s string, size int) int {
+	cut := size
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	// Prefer ending the block at a newline if one is reasonably close.
+	if nl := strings.LastIndexByte(s[:cut], '\n'); nl >= cut/2 {
+		cut = nl + 1
+	}
+
+	// Don't split inside a bracketed anchor that closes shortly after.
+	if open := strings.LastIndexByte(s[:cut], '['); open > 0 && open > strings.LastIndexByte(s[:cut], ']') {
+		if cut-open <= maxAnchorLen {
+			if end := strings.IndexByte(s[cut:], ']'); end >= 0 && cut+end-open <= maxAnchorLen {
+				cut = open
+			}
+		}
+	}
+
+	if cut == 0 {
+		// A single rune or anchor longer than size; advance to the next
+		// rune boundary so we always make progress.
+		_, n := utf8.DecodeRuneInString(s)
+		cut = n
+	}
+	return cut
+}
+
+// wrapHandler returns a handler that refuses the tool's local write
+// parameters in read-only mode, passes h the session default account in
+// its context, runs h under the tool timeout, records what h returns in
+// the mutation journal unless the tool is read-only (also when it fails,
+// or finishes after timing out), and applies result shaping to it.
+func wrapHandler[In, Out any](s *Server, info ToolInfo, h mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	if !info.ReadOnly {
+		h = journaled(s, info.Name, h)
+	}
+	return func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		if s.readOnly {
+			if name := setParam(req, info.LocalWriteParams); name != "" {
+				var zero Out
+				return nil, zero, fmt.Errorf("%s writes to local disk and is not available in read-only mode", name)
+			}
+		}
+		ctx = s.withSessionAccount(ctx, req)
+		res, out, err := callWithTimeout(ctx, s, info, h, req, input)
+		if err == nil {
+			s.shapeResult(req, res, any(out) != nil)
+		}
+		return res, out, err
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Output formats accepted by the format input of list tools.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Column is one column of a Table. Value returns the cell for a row: a
+// string, number, bool, []string or nil. JSON output keeps the value's type;
+// CSV output joins lists with "; " and leaves nil cells empty.
+type Column[T any] struct {
+	Name  string
+	Value func(T) any
+}
+
+// Table collects the rows of a list tool for machine-readable output, so a
+// tool only has to declare its columns. Rows are not subject to the output
+// budget; the tool's max_results bounds them instead.
+type Table[T any] struct {
+	format  string
+	columns []Column[T]
+	rows    []T
+	errs    []string
+}
+
+// NewTable returns a table for format, which is the tool's format input. It
+// returns nil (and no error) for the text format, including the empty
+// default, so tools can keep their text output when the table is nil.
+func NewTable[T any](format string, columns []Column[T]) (*Table[T], error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText:
+		return nil, nil
+	case FormatJSON:
+		return &Table[T]{format: FormatJSON, columns: columns}, nil
+	case FormatCSV:
+		return &Table[T]{format: FormatCSV, columns: columns}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q (use text, json, or csv)", format)
+	}
+}
+
+// Add appends rows to the table.
+func (t *Table[T]) Add(rows ...T) {
+	t.rows = append(t.rows, rows...)
+}
+
+// AddError records an error that didn't stop the tool, such as a failing
+// account in a multi-account call. Errors are returned in a separate
+// content block so the table itself stays machine-readable.
+func (t *Table[T]) AddError(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+// Len returns the number of rows.
+func (t *Table[T]) Len() int {
+	return len(t.rows)
+}
+
+// String renders the table: a compact JSON array of objects with the
+// columns as keys, in column order, or CSV (RFC 4180) with a header line.
+func (t *Table[T]) String() string {
+	var buf bytes.Buffer
+	if t.format == FormatCSV {
+		w := csv.NewWriter(&buf)
+		w.UseCRLF = true
+		header := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			header[i] = c.Name
+		}
+		w.Write(header)
+		for _, row := range t.rows {
+			record := make([]string, len(t.columns))
+			for i, c := range t.columns {
+				record[i] = csvCell(c.Value(row))
+			}
+			w.Write(record)
+		}
+		// Writes to a bytes.Buffer can't fail.
+		w.Flush()
+		return buf.String()
+	}
+
+	buf.WriteByte('[')
+	for n, row := range t.rows {
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for i, c := range t.columns {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(jsonCell(c.Name))
+			buf.WriteByte(':')
+			buf.Write(jsonCell(c.Value(row)))
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// Result returns the tool result for the table: the rendered table,
+// followed by a text block listing the errors, if any.
+func (t *Table[T]) Result() *mcp.CallToolResult {
+	res := &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: t.String()},
+		},
+	}
+	if len(t.errs) > 0 {
+		var sb strings.Builder
+		sb.WriteString("Errors:\n")
+		for _, e := range t.errs {
+			fmt.Fprintf(&sb, "- %s\n", e)
+		}
+		res.Content = append(res.Content, &mcp.TextContent{Text: sb.String()})
+	}
+	return res
+}
+
+// csvCell converts a column value to a CSV field.
+func csvCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, "; ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonCell encodes a column name or value as JSON. HTML characters are kept
+// as-is so addresses like "Name <addr>" stay readable, and values that
+// can't be encoded fall back to their string form.
+func jsonCell(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		buf.Reset()
+		enc.Encode(fmt.Sprint(v))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultToolTimeout is the default time limit for a single tool call. See
+// SetToolTimeout.
+const DefaultToolTimeout = 60 * time.Second
+
+// SetToolTimeout sets the time limit for a single tool call. A call that
+// runs longer fails with an "operation timed out" error instead of waiting
+// on a hung Google API request until the client gives up. Zero or a
+// negative value disables the limit.
+func (s *Server) SetToolTimeout(d time.Duration) {
+	s.toolTimeout = d
+}
+
+// ToolTimeout returns the configured tool call time limit, or 0 if
+// disabled.
+func (s *Server) ToolTimeout() time.Duration {
+	return max(s.toolTimeout, 0)
+}
+
+// BulkToolTimeout is the time limit for tools that make many API calls in
+// one call, such as bulk changes and exports. See Timeout.
+const BulkToolTimeout = 10 * time.Minute
+
+// timeoutGrace is how long a handler gets to return after its context is
+// cancelled at the time limit, so that one that stops between API calls
+// can report what it did.
+const timeoutGrace = 5 * time.Second
+
+// Timeout raises the time limit for calls of a tool that legitimately runs
+// long, such as an upload or a bulk operation. The call is bounded by the
+// larger of d and the server's tool timeout; when the server's timeout is
+// disabled, it isn't bounded at all.
+func Timeout(d time.Duration) ToolOption {
+	return func(t *ToolInfo) {
+		t.Timeout = d
+	}
+}
+
+// toolTimeoutFor returns the time limit for calls of the tool described by
+// info, or 0 if there is none.
+func (s *Server) toolTimeoutFor(info ToolInfo) time.Duration {
+	timeout := s.ToolTimeout()
+	if timeout == 0 {
+		return 0
+	}
+	return max(timeout, info.Timeout)
+}
+
+// callResult is the return value of a tool handler.
+type callResult[Out any] struct {
+	res *mcp.CallToolResult
+	out Out
+	err error
+}
+
+// callWithTimeout runs h under the tool's time limit. At the limit the
+// handler's context is cancelled, and the handler gets a grace period to
+// return: a result it returns then, such as the entries of a bulk call
+// done so far, is passed on with a note that it may be incomplete. Not
+// every API call observes the context, so a handler still running after
+// the grace period is left to finish on its own goroutine and the call
+// fails with a timeout error.
+func callWithTimeout[In, Out any](ctx context.Context, s *Server, info ToolInfo, h mcp.ToolHandlerFor[In, Out], req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+	timeout := s.toolTimeoutFor(info)
+	if timeout == 0 {
+		return h(ctx, req, input)
+	}
+
+	// The handler may outlive this call, so it must not be cancelled
+	// with the request before it has returned.
+	hctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	done := make(chan callResult[Out], 1)
+	go func() {
+		res, out, err := h(hctx, req, input)
+		done <- callResult[Out]{res, out, err}
+	}()
+
+	var r callResult[Out]
+	returned := false
+	select {
+	case r = <-done:
+		returned = true
+	case <-ctx.Done():
+		// The client cancelled the call.
+		cancel()
+	case <-hctx.Done():
+	}
+	if hctx.Err() == nil {
+		cancel()
+		return r.res, r.out, r.err
+	}
+
+	if !returned {
+		grace := time.NewTimer(s.timeoutGrace)
+		defer grace.Stop()
+		select {
+		case r = <-done:
+		case <-grace.C:
+			go func() {
+				<-done
+				cancel()
+			}()
+			var zero Out
+			if ctx.Err() != nil {
+				return nil, zero, ctx.Err()
+			}
+			return nil, zero, fmt.Errorf("%w; the call is still running and may yet make changes, which list_recent_mutations will show", timeoutError(timeout))
+		}
+	}
+	cancel()
+	if ctx.Err() != nil {
+		return nil, r.out, ctx.Err()
+	}
+	if r.err != nil || r.res == nil || r.res.IsError {
+		return nil, r.out, timeoutError(timeout)
+	}
+	r.res.Content = append(r.res.Content, &mcp.TextContent{
+		Text: fmt.Sprintf("Stopped at the time limit of %s; the result above may be incomplete (raise --tool-timeout to let such calls finish).", timeout),
+	})
+	return r.res, r.out, nil
+}
+
+func timeoutError(timeout time.Duration) error {
+	return fmt.Errorf("operation timed out after %s (raise --tool-timeout for long transfers)", timeout)
+}
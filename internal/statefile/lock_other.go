@@ -0,0 +1,13 @@
+//go:build (!unix && !windows) || aix
+
+package statefile
+
+// lockFile only creates the lock file: there is no file locking on this
+// platform, so updates are serialized within the process only.
+func lockFile(path string) (func(), error) {
+	f, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,25 @@
+//go:build unix && !aix
+
+package statefile
+
+import "golang.org/x/sys/unix"
+
+// lockFile takes an exclusive lock on the file at path, waiting for other
+// processes to release theirs, and returns the function that releases it.
+func lockFile(path string) (func(), error) {
+	f, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,42 @@
+//go:build unix && !aix
+
+package statefile
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json.lock")
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second lock on its own file descriptor, as another process would
+	// take it, waits for the first.
+	locked := make(chan struct{})
+	go func() {
+		unlock2, err := lockFile(path)
+		if err != nil {
+			t.Error(err)
+			close(locked)
+			return
+		}
+		close(locked)
+		unlock2()
+	}()
+	select {
+	case <-locked:
+		t.Fatal("second lock taken while the first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("second lock not taken after the first was released")
+	}
+}
@@ -0,0 +1,22 @@
+package statefile
+
+import "golang.org/x/sys/windows"
+
+// lockFile takes an exclusive lock on the file at path, waiting for other
+// processes to release theirs, and returns the function that releases it.
+func lockFile(path string) (func(), error) {
+	f, err := openLockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h := windows.Handle(f.Fd())
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(h, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(h, 0, 1, 0, overlapped)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,119 @@
+// Package statefile keeps small JSON state files in the config directory,
+// such as the snoozed messages and the active watches, that several server
+// processes read and change.
+//
+// Each change runs under a lock held both within the process and, through
+// a lock file next to the state file, across processes, so concurrent
+// read-modify-write cycles don't lose each other's entries. The new state
+// is written to a temporary file in the same directory and renamed over
+// the old one, so readers never see a partly written file.
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// File is a state file holding a JSON array of entries.
+type File[T any] struct {
+	path string
+	name string
+	cmp  func(a, b T) int
+}
+
+// New returns the state file at path. name describes it in errors, e.g.
+// "snooze state". If cmp is not nil, entries are sorted with it before
+// they are saved.
+func New[T any](path, name string, cmp func(a, b T) int) *File[T] {
+	return &File[T]{path: path, name: name, cmp: cmp}
+}
+
+// Path returns the path of the state file.
+func (f *File[T]) Path() string {
+	return f.path
+}
+
+// locks holds a mutex per state file path, since the file lock doesn't
+// necessarily exclude other goroutines of the same process.
+var locks sync.Map
+
+// Load returns the entries in the file, or none if it doesn't exist yet.
+func (f *File[T]) Load() ([]T, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.name, err)
+	}
+	var entries []T
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s %s: %w", f.name, f.path, err)
+	}
+	return entries, nil
+}
+
+// Update loads the entries, applies fn, and saves the result, holding the
+// file's lock throughout. If fn fails, the file is left unchanged.
+func (f *File[T]) Update(fn func([]T) ([]T, error)) error {
+	mu, _ := locks.LoadOrStore(f.path, new(sync.Mutex))
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("creating config dir: %w", err)
+	}
+	unlock, err := lockFile(f.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", f.name, err)
+	}
+	defer unlock()
+
+	entries, err := f.Load()
+	if err != nil {
+		return err
+	}
+	entries, err = fn(entries)
+	if err != nil {
+		return err
+	}
+	if err := f.save(entries); err != nil {
+		return fmt.Errorf("writing %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// save writes entries to a temporary file next to the state file and
+// renames it into place.
+func (f *File[T]) save(entries []T) error {
+	if f.cmp != nil {
+		slices.SortStableFunc(entries, f.cmp)
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// openLockFile opens the lock file at path, creating it if needed.
+func openLockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+}
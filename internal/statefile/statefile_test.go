@@ -0,0 +1,104 @@
+package statefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type entry struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+}
+
+func byN(a, b entry) int { return a.N - b.N }
+
+func TestFile_LoadMissing(t *testing.T) {
+	f := New[entry](filepath.Join(t.TempDir(), "state.json"), "test state", nil)
+	entries, err := f.Load()
+	if err != nil || entries != nil {
+		t.Errorf("Load() = %v, %v, want nothing", entries, err)
+	}
+}
+
+func TestFile_UpdateSortsAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "state.json")
+	f := New(path, "test state", byN)
+	err := f.Update(func(entries []entry) ([]entry, error) {
+		return append(entries, entry{"b", 2}, entry{"a", 1}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := New[entry](path, "test state", nil).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "a" || entries[1].Name != "b" {
+		t.Errorf("entries = %+v, want a, b", entries)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("state file mode = %v, %v, want 0600", info.Mode(), err)
+	}
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	if len(files) != 0 {
+		t.Errorf("temporary files left behind: %v", files)
+	}
+}
+
+func TestFile_UpdateErrorLeavesFile(t *testing.T) {
+	f := New[entry](filepath.Join(t.TempDir(), "state.json"), "test state", nil)
+	if err := f.Update(func([]entry) ([]entry, error) { return []entry{{"kept", 1}}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("no")
+	err := f.Update(func([]entry) ([]entry, error) { return nil, failed })
+	if !errors.Is(err, failed) {
+		t.Errorf("Update() = %v, want fn's error", err)
+	}
+	if entries, _ := f.Load(); len(entries) != 1 || entries[0].Name != "kept" {
+		t.Errorf("entries = %+v after a failed update", entries)
+	}
+}
+
+func TestFile_ConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	const n = 50
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each goroutine uses its own File, like separate tools or
+			// processes sharing the config directory.
+			f := New(path, "test state", byN)
+			if err := f.Update(func(entries []entry) ([]entry, error) {
+				return append(entries, entry{N: i}), nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	entries, err := New[entry](path, "test state", nil).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Errorf("%d entries, want %d: updates were lost", len(entries), n)
+	}
+}
+
+func TestFile_LoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := New[entry](path, "test state", nil).Load()
+	if err == nil || !strings.Contains(err.Error(), "parsing test state "+path) {
+		t.Errorf("Load() = %v, want a parse error naming the file", err)
+	}
+}